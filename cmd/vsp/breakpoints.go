@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bpSetsFile    string
+	bpDescription string
+	bpCondition   string
+)
+
+var breakpointsCmd = &cobra.Command{
+	Use:     "breakpoints",
+	Aliases: []string{"bp"},
+	Short:   "Manage named breakpoint sets",
+	Long: `Manage named breakpoint sets stored in .vsp/breakpoints.json.
+
+A breakpoint set holds line, method, exception, statement and message
+breakpoints (with optional conditions) independent of any system. Enable a
+set on any configured system to recreate its breakpoints there, e.g. to
+reproduce an investigation on QAS after finding it on DEV.
+
+Sets are enabled as external breakpoints for the system user, so they stay
+active after vsp exits. Method breakpoints are enabled as line breakpoints at
+the method's line in the current class source.
+
+Examples:
+  vsp breakpoints                                   # list sets
+  vsp bp add order-bug line ZORDER_CALC 42 --condition "lv_total < 0"
+  vsp bp add order-bug exception CX_SY_ZERODIVIDE
+  vsp bp add order-bug message ZSD 001 E
+  vsp -s dev bp save order-bug                      # capture breakpoints set on DEV
+  vsp -s qas bp enable order-bug                    # recreate them on QAS
+  vsp -s qas bp disable order-bug`,
+	Args: cobra.NoArgs,
+	RunE: runBreakpointsList,
+}
+
+var bpShowCmd = &cobra.Command{
+	Use:   "show <set>",
+	Short: "Show the breakpoints of a set",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBreakpointsShow,
+}
+
+var bpAddCmd = &cobra.Command{
+	Use:   "add <set> <kind> <args...>",
+	Short: "Add a breakpoint to a set (creating the set if needed)",
+	Long: `Add a breakpoint to a set, creating the set if needed.
+
+Kinds:
+  line <program> <line>             Program or class pool (ZCL_FOO====...CP), absolute line
+  method <class> <method> [line]    Line within the method, 1 = METHOD statement (default)
+  exception <class>                 e.g. CX_SY_ZERODIVIDE
+  statement <statement>             e.g. "CALL FUNCTION"
+  message <class> <number> [type]   e.g. ZSD 001 E`,
+	Args: cobra.MinimumNArgs(3),
+	RunE: runBreakpointsAdd,
+}
+
+var bpSaveCmd = &cobra.Command{
+	Use:   "save <set>",
+	Short: "Save the external breakpoints currently set on a system as a set",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBreakpointsSave,
+}
+
+var bpEnableCmd = &cobra.Command{
+	Use:     "enable <set>",
+	Aliases: []string{"restore"},
+	Short:   "Create the breakpoints of a set on a system",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runBreakpointsEnable,
+}
+
+var bpDisableCmd = &cobra.Command{
+	Use:   "disable <set>",
+	Short: "Delete the breakpoints a set created on a system",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBreakpointsDisable,
+}
+
+var bpDeleteCmd = &cobra.Command{
+	Use:   "delete <set>",
+	Short: "Delete a set from the store (breakpoints on systems are kept)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBreakpointsDelete,
+}
+
+func init() {
+	breakpointsCmd.PersistentFlags().StringVar(&bpSetsFile, "file", adt.DefaultBreakpointSetsFile, "Breakpoint sets file")
+	bpAddCmd.Flags().StringVar(&bpCondition, "condition", "", "Breakpoint condition (e.g., \"lv_count > 10\")")
+	bpAddCmd.Flags().StringVarP(&bpDescription, "description", "d", "", "Set description")
+	bpSaveCmd.Flags().StringVarP(&bpDescription, "description", "d", "", "Set description")
+
+	breakpointsCmd.AddCommand(bpShowCmd, bpAddCmd, bpSaveCmd, bpEnableCmd, bpDisableCmd, bpDeleteCmd)
+	rootCmd.AddCommand(breakpointsCmd)
+}
+
+func runBreakpointsList(cmd *cobra.Command, args []string) error {
+	store, err := adt.LoadBreakpointSets(bpSetsFile)
+	if err != nil {
+		return err
+	}
+	names := store.Names()
+	if len(names) == 0 {
+		fmt.Printf("No breakpoint sets in %s\n", store.Path())
+		return nil
+	}
+
+	fmt.Printf("Breakpoint sets (%s):\n", store.Path())
+	for _, name := range names {
+		set := store.Sets[name]
+		fmt.Printf("  %-24s %2d breakpoints", name, len(set.Breakpoints))
+		if set.Description != "" {
+			fmt.Printf("  %s", set.Description)
+		}
+		fmt.Println()
+		for system := range set.Enabled {
+			fmt.Printf("  %-24s    enabled on %s\n", "", system)
+		}
+	}
+	return nil
+}
+
+func runBreakpointsShow(cmd *cobra.Command, args []string) error {
+	store, err := adt.LoadBreakpointSets(bpSetsFile)
+	if err != nil {
+		return err
+	}
+	set, err := store.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Set: %s\n", set.Name)
+	if set.Description != "" {
+		fmt.Printf("Description: %s\n", set.Description)
+	}
+	fmt.Printf("Updated: %s\n", set.Updated.Format("2006-01-02 15:04:05"))
+	for i, bp := range set.Breakpoints {
+		fmt.Printf("  [%d] %s\n", i+1, bp)
+	}
+	for system, ids := range set.Enabled {
+		fmt.Printf("Enabled on %s: %s\n", system, strings.Join(ids, ", "))
+	}
+	return nil
+}
+
+func runBreakpointsAdd(cmd *cobra.Command, args []string) error {
+	bp, err := parseSavedBreakpoint(args[1], args[2:])
+	if err != nil {
+		return err
+	}
+	bp.Condition = bpCondition
+
+	store, err := adt.LoadBreakpointSets(bpSetsFile)
+	if err != nil {
+		return err
+	}
+	set, ok := store.Sets[args[0]]
+	if !ok {
+		set = &adt.BreakpointSet{Name: args[0]}
+	}
+	if bpDescription != "" {
+		set.Description = bpDescription
+	}
+	set.Breakpoints = append(set.Breakpoints, bp)
+	if err := store.Put(set); err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added %s to '%s' (%d breakpoints)\n", bp, set.Name, len(set.Breakpoints))
+	return nil
+}
+
+// parseSavedBreakpoint parses the kind-specific arguments of "bp add".
+func parseSavedBreakpoint(kind string, args []string) (adt.SavedBreakpoint, error) {
+	bp := adt.SavedBreakpoint{Kind: adt.BreakpointKind(strings.ToLower(kind))}
+	switch bp.Kind {
+	case adt.BreakpointKindLine:
+		if len(args) != 2 {
+			return bp, fmt.Errorf("usage: line <program> <line>")
+		}
+		line, err := strconv.Atoi(args[1])
+		if err != nil {
+			return bp, fmt.Errorf("invalid line: %s", args[1])
+		}
+		bp.Program, bp.Line = strings.ToUpper(args[0]), line
+	case adt.BreakpointKindMethod:
+		if len(args) < 2 || len(args) > 3 {
+			return bp, fmt.Errorf("usage: method <class> <method> [line]")
+		}
+		bp.Program, bp.Method = adt.ClassPoolName(args[0]), strings.ToUpper(args[1])
+		if len(args) == 3 {
+			line, err := strconv.Atoi(args[2])
+			if err != nil {
+				return bp, fmt.Errorf("invalid line: %s", args[2])
+			}
+			bp.Line = line
+		}
+	case adt.BreakpointKindException:
+		if len(args) != 1 {
+			return bp, fmt.Errorf("usage: exception <class>")
+		}
+		bp.Exception = strings.ToUpper(args[0])
+	case adt.BreakpointKindStatement:
+		bp.Statement = strings.ToUpper(strings.Join(args, " "))
+	case adt.BreakpointKindMessage:
+		if len(args) < 2 || len(args) > 3 {
+			return bp, fmt.Errorf("usage: message <class> <number> [type]")
+		}
+		bp.MessageClass, bp.MessageNumber = strings.ToUpper(args[0]), args[1]
+		if len(args) == 3 {
+			bp.MessageType = strings.ToUpper(args[2])
+		}
+	}
+	return bp, bp.Validate()
+}
+
+func runBreakpointsSave(cmd *cobra.Command, args []string) error {
+	params, err := resolveSystemParams(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := getClient(params)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.GetExternalBreakpoints(context.Background(), params.User)
+	if err != nil {
+		return fmt.Errorf("failed to read breakpoints: %w", err)
+	}
+	if len(resp.Breakpoints) == 0 {
+		return fmt.Errorf("no external breakpoints set for %s on %s", params.User, params.URL)
+	}
+
+	store, err := adt.LoadBreakpointSets(bpSetsFile)
+	if err != nil {
+		return err
+	}
+	set := &adt.BreakpointSet{Name: args[0], Description: bpDescription}
+	for _, b := range resp.Breakpoints {
+		set.Breakpoints = append(set.Breakpoints, adt.SavedBreakpointFromExternal(b))
+	}
+	if err := store.Put(set); err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved %d breakpoints from %s as '%s'\n", len(set.Breakpoints), params.URL, set.Name)
+	return nil
+}
+
+func runBreakpointsEnable(cmd *cobra.Command, args []string) error {
+	return applyBreakpointSet(cmd, args[0], true)
+}
+
+func runBreakpointsDisable(cmd *cobra.Command, args []string) error {
+	return applyBreakpointSet(cmd, args[0], false)
+}
+
+// applyBreakpointSet enables or disables a set on the selected system.
+func applyBreakpointSet(cmd *cobra.Command, name string, enable bool) error {
+	store, err := adt.LoadBreakpointSets(bpSetsFile)
+	if err != nil {
+		return err
+	}
+	set, err := store.Get(name)
+	if err != nil {
+		return err
+	}
+
+	params, err := resolveSystemParams(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := getClient(params)
+	if err != nil {
+		return err
+	}
+	setter := &adt.MethodBreakpoints{BreakpointSetter: &adt.ExternalBreakpoints{Client: client, User: params.User}, Client: client}
+
+	ctx := context.Background()
+	var result *adt.BreakpointSetResult
+	if enable {
+		result = adt.EnableBreakpointSet(ctx, setter, set, params.URL)
+	} else {
+		result = adt.DisableBreakpointSet(ctx, setter, set, params.URL)
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	action := "Enabled"
+	if !enable {
+		action = "Disabled"
+	}
+	fmt.Printf("%s '%s' on %s: %d breakpoints\n", action, set.Name, params.URL, len(result.IDs))
+	for _, f := range result.Failed {
+		fmt.Printf("  failed: %s\n", f)
+	}
+	if enable && len(result.IDs) == 0 && len(result.Failed) > 0 {
+		return fmt.Errorf("no breakpoints of '%s' could be created", set.Name)
+	}
+	return nil
+}
+
+func runBreakpointsDelete(cmd *cobra.Command, args []string) error {
+	store, err := adt.LoadBreakpointSets(bpSetsFile)
+	if err != nil {
+		return err
+	}
+	if !store.Delete(args[0]) {
+		return fmt.Errorf("breakpoint set '%s' not found", args[0])
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted breakpoint set '%s'\n", args[0])
+	return nil
+}
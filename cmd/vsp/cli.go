@@ -6,9 +6,11 @@ import (
 	"os"
 	"strings"
 
+	"github.com/oisee/vibing-steampunk/pkg/abapgit"
 	"github.com/oisee/vibing-steampunk/pkg/adt"
 	"github.com/oisee/vibing-steampunk/pkg/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -39,6 +41,9 @@ type systemParams struct {
 	Insecure     bool
 	CookieFile   string
 	CookieString string
+	ServiceKey   string
+	ClientCert   string
+	ClientKey    string
 }
 
 // resolveSystemParams resolves system parameters from --system flag or env vars.
@@ -58,10 +63,10 @@ func resolveSystemParams(cmd *cobra.Command) (*systemParams, error) {
 			return nil, err
 		}
 
-		// Require either password or cookie auth
+		// Require password, cookie, service key or client certificate auth
 		hasCookieAuth := sys.CookieFile != "" || sys.CookieString != ""
-		if sys.Password == "" && !hasCookieAuth {
-			return nil, fmt.Errorf("auth not found for system '%s'. Set VSP_%s_PASSWORD env var or use cookie_file/cookie_string", systemName, strings.ToUpper(systemName))
+		if sys.Password == "" && !hasCookieAuth && sys.ServiceKey == "" && sys.ClientCert == "" {
+			return nil, fmt.Errorf("auth not found for system '%s'. Set VSP_%s_PASSWORD env var or use cookie_file/cookie_string/service_key/client_cert", systemName, strings.ToUpper(systemName))
 		}
 
 		verbose, _ := cmd.Flags().GetBool("verbose")
@@ -78,6 +83,9 @@ func resolveSystemParams(cmd *cobra.Command) (*systemParams, error) {
 			Insecure:     sys.Insecure,
 			CookieFile:   sys.CookieFile,
 			CookieString: sys.CookieString,
+			ServiceKey:   sys.ServiceKey,
+			ClientCert:   sys.ClientCert,
+			ClientKey:    sys.ClientKey,
 		}, nil
 	}
 
@@ -89,7 +97,9 @@ func resolveSystemParams(cmd *cobra.Command) (*systemParams, error) {
 
 	user := os.Getenv("SAP_USER")
 	password := os.Getenv("SAP_PASSWORD")
-	if user == "" || password == "" {
+	// OAuth2 and client certificates come from the global flags (see transportOptions)
+	altAuth := viper.GetString("service-key") != "" || viper.GetString("oauth-token-url") != "" || viper.GetString("client-cert") != ""
+	if (user == "" || password == "") && !altAuth {
 		return nil, fmt.Errorf("SAP_USER and SAP_PASSWORD required")
 	}
 
@@ -112,6 +122,27 @@ func getClient(params *systemParams) (*adt.Client, error) {
 	if params.Insecure {
 		opts = append(opts, adt.WithInsecureSkipVerify())
 	}
+	transportOpts, err := transportOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, transportOpts...)
+
+	if params.ClientCert != "" {
+		opts = append(opts, adt.WithClientCertificate(params.ClientCert, params.ClientKey))
+	}
+	if params.ServiceKey != "" {
+		key, err := adt.LoadServiceKey(params.ServiceKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load service key: %w", err)
+		}
+		url := params.URL
+		if url == "" {
+			url = key.SystemURL()
+		}
+		opts = append(opts, adt.WithOAuth(key.OAuthConfig()))
+		return adt.NewClient(url, "", "", opts...), nil
+	}
 
 	// Use cookie auth if available
 	if params.CookieFile != "" {
@@ -133,14 +164,23 @@ func getClient(params *systemParams) (*adt.Client, error) {
 
 // getWSClient creates an AMDP WebSocket client for GitExport.
 func getWSClient(ctx context.Context, params *systemParams) (*adt.AMDPWebSocketClient, error) {
+	// The HTTP client config carries OAuth, client certificate and cookie auth
+	client, err := getClient(params)
+	if err != nil {
+		return nil, err
+	}
+	adtCfg := client.Config()
+
 	// NewAMDPWebSocketClient(baseURL, client, user, password, insecure)
 	wsClient := adt.NewAMDPWebSocketClient(
-		params.URL,
+		adtCfg.BaseURL,
 		params.Client,
 		params.User,
 		params.Password,
 		params.Insecure,
 	)
+	wsClient.UseAuth(adtCfg)
+	wsClient.UseSafety(adtCfg.Safety)
 
 	if err := wsClient.Connect(ctx); err != nil {
 		return nil, fmt.Errorf("failed to connect WebSocket: %w", err)
@@ -166,7 +206,13 @@ var exportCmd = &cobra.Command{
 Examples:
   vsp -s a4h export '$ZORK' '$ZLLM' -o packages.zip
   vsp export '$TMP' --output my-package.zip
-  vsp -s dev export 'Z*' --subpackages`,
+  vsp -s dev export 'Z*' --subpackages
+
+With --native the package is serialized in Go over standard ADT, without
+ZADT_VSP on the server. The output is a ZIP file, or a folder when the
+path does not end in .zip:
+  vsp -s dev export '$ZLOGGER' --native -o ./abap-logger
+  vsp -s dev export ZLOGGER --native --folder-logic FULL -o logger.zip`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runExport,
 }
@@ -174,6 +220,8 @@ Examples:
 func init() {
 	exportCmd.Flags().StringVarP(&outputFile, "output", "o", "export.zip", "Output ZIP file path")
 	exportCmd.Flags().BoolP("subpackages", "r", true, "Include subpackages")
+	exportCmd.Flags().Bool("native", false, "Serialize over standard ADT instead of ZADT_VSP")
+	exportCmd.Flags().String("folder-logic", "", "Folder logic for --native: PREFIX or FULL (default: detected)")
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
@@ -183,14 +231,17 @@ func runExport(cmd *cobra.Command, args []string) error {
 	}
 
 	ctx := context.Background()
+	includeSubpackages, _ := cmd.Flags().GetBool("subpackages")
+	if native, _ := cmd.Flags().GetBool("native"); native {
+		return runNativeExport(ctx, cmd, params, args, includeSubpackages)
+	}
+
 	wsClient, err := getWSClient(ctx, params)
 	if err != nil {
 		return err
 	}
 	defer wsClient.Close()
 
-	includeSubpackages, _ := cmd.Flags().GetBool("subpackages")
-
 	fmt.Fprintf(os.Stderr, "Exporting packages: %s\n", strings.Join(args, ", "))
 
 	zipData, result, err := wsClient.GitExportToBytes(ctx, adt.GitExportParams{
@@ -209,6 +260,48 @@ func runExport(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runNativeExport serializes a package with the Go abapGit exporter.
+func runNativeExport(ctx context.Context, cmd *cobra.Command, params *systemParams, args []string, includeSubpackages bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("--native exports one package (with its subpackages) per repository")
+	}
+	client, err := getClient(params)
+	if err != nil {
+		return err
+	}
+	folderLogic, _ := cmd.Flags().GetString("folder-logic")
+
+	fmt.Fprintf(os.Stderr, "Exporting package %s\n", args[0])
+	repo, result, err := abapgit.NewExporter(client, abapgit.ExportOptions{
+		FolderLogic:   folderLogic,
+		NoSubpackages: !includeSubpackages,
+	}).Export(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+	for _, o := range result.Objects {
+		if o.Action != "exported" {
+			fmt.Fprintf(os.Stderr, "  %-8s %-4s %s: %s\n", o.Action, o.Type, o.Name, o.Message)
+		}
+	}
+
+	if strings.HasSuffix(strings.ToLower(outputFile), ".zip") {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create ZIP file: %w", err)
+		}
+		defer f.Close()
+		if err := repo.WriteZip(f); err != nil {
+			return fmt.Errorf("failed to write ZIP file: %w", err)
+		}
+	} else if err := repo.WriteDir(outputFile); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
+	}
+
+	fmt.Printf("%s to %s\n", result.Summary, outputFile)
+	return nil
+}
+
 // --- search command ---
 
 var searchCmd = &cobra.Command{
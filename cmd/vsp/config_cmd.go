@@ -768,6 +768,7 @@ func GetAllToolNames() []string {
 		"GetPrettyPrinterSettings", "SetPrettyPrinterSettings",
 		"RunUnitTests", "RunATCCheck", "GetATCCustomizing",
 		"GetInactiveObjects", "CreatePackage", "CreateTable",
+		"GetDDIC", "WriteDDIC", "DeleteDDIC",
 		"CompareSource", "CreateClassWithTests", "CreateTestInclude",
 		"CreateAndActivateProgram", "UpdateClassInclude",
 		// Code intelligence
@@ -796,7 +797,7 @@ func GetAllToolNames() []string {
 		"AMDPDebuggerStart", "AMDPDebuggerResume", "AMDPDebuggerStop",
 		"AMDPDebuggerStep", "AMDPGetVariables", "AMDPSetBreakpoint", "AMDPGetBreakpoints",
 		// RFC (requires ZADT_VSP)
-		"CallRFC", "ExecuteABAP",
+		"CallRFC", "CallDomain", "ExecuteABAP",
 		// Git/abapGit (requires ZADT_VSP)
 		"GitTypes", "GitExport",
 		// Install tools
@@ -804,8 +805,11 @@ func GetAllToolNames() []string {
 		// UI5/BSP
 		"UI5ListApps", "UI5GetApp", "UI5GetFileContent",
 		"UI5CreateApp", "UI5DeleteApp", "UI5DeleteFile", "UI5UploadFile",
+		"UI5Deploy", "UI5Undeploy",
 		// Service binding
-		"PublishServiceBinding", "UnpublishServiceBinding",
+		"PublishServiceBinding", "UnpublishServiceBinding", "TestODataService",
+		// RAP
+		"GenerateRAPStack",
 	}
 }
 
@@ -827,6 +831,7 @@ func GetFocusedToolNames() []string {
 		"SyntaxCheck", "RunUnitTests", "RunATCCheck",
 		"Activate", "ActivatePackage", "PrettyPrint",
 		"GetInactiveObjects", "CreatePackage", "CreateTable",
+		"GetDDIC", "WriteDDIC",
 		"CompareSource", "CloneObject", "GetClassInfo",
 		// Lock/Unlock
 		"LockObject", "UnlockObject",
@@ -880,6 +885,8 @@ SAP_MODE=focused
 # SAP_FEATURE_AMDP=auto
 # SAP_FEATURE_UI5=auto
 # SAP_FEATURE_TRANSPORT=auto
+# SAP_FEATURE_VSP=auto
+# SAP_FEATURE_TOOLS=hide   # hide (or annotate) tools of unavailable features
 `
 
 var vspSystemsExample = func() string {
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/dap"
+	"github.com/spf13/cobra"
+)
+
+var dapCmd = &cobra.Command{
+	Use:   "dap",
+	Short: "Debug Adapter Protocol server for the ABAP debugger",
+	Long: `Run a Debug Adapter Protocol (DAP) server for the ABAP debugger.
+
+VS Code, Neovim (nvim-dap) and other DAP clients can then set breakpoints,
+step, and inspect and change variables in ABAP without Eclipse.
+
+By default the adapter speaks DAP over stdin/stdout (clients start it as a
+subprocess). With --listen it accepts TCP connections, one session at a time.
+
+Mapping:
+  setBreakpoints          Line breakpoints via ZADT_VSP (abapGit file names
+                          such as ztest.prog.abap or zcl_foo.clas.abap map
+                          to the program / class pool)
+  setFunctionBreakpoints  Exception classes (CX_*) or ABAP statements
+  attach                  Wait for debuggees of --user and attach
+  launch                  Like attach, then run "program" via ZADT_VSP
+  threads                 The attached debuggee (one at a time)
+  stackTrace/scopes       ABAP call stack; selecting a frame moves the cursor
+  variables               @ROOT, structure components and table rows
+  setVariable, evaluate   Change or read variables by name
+  next/stepIn/stepOut     Step over / into / return
+  continue                Continue to the next breakpoint
+
+Launch configuration (VS Code launch.json):
+  {
+    "type": "abap", "request": "attach", "name": "ABAP",
+    "timeout": 60
+  }
+
+Examples:
+  # stdio (configure the client to run "vsp dap")
+  vsp dap
+
+  # TCP on port 4711
+  vsp dap --listen 127.0.0.1:4711`,
+	RunE: runDAP,
+}
+
+var (
+	dapListen  string
+	dapUser    string
+	dapTimeout int
+)
+
+func init() {
+	dapCmd.Flags().StringVar(&dapListen, "listen", "", "Listen for DAP clients on this TCP address instead of stdio (e.g., 127.0.0.1:4711)")
+	dapCmd.Flags().StringVarP(&dapUser, "user", "u", "", "User to debug (defaults to current user)")
+	dapCmd.Flags().IntVarP(&dapTimeout, "timeout", "t", 60, "Listener long-poll timeout in seconds")
+
+	rootCmd.AddCommand(dapCmd)
+}
+
+func runDAP(cmd *cobra.Command, args []string) error {
+	resolveConfig(cmd.Parent())
+	if err := validateConfig(); err != nil {
+		return err
+	}
+	if err := processCookieAuth(cmd.Parent()); err != nil {
+		return err
+	}
+
+	client := createADTClient()
+
+	user := dapUser
+	if user == "" {
+		user = cfg.Username
+	}
+	adt.SetTerminalIDUser(user)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	// Breakpoints and program execution go through ZADT_VSP. stdout carries
+	// the protocol in stdio mode, so diagnostics go to stderr.
+	wsClient := adt.NewDebugWebSocketClient(cfg.BaseURL, cfg.Client, cfg.Username, cfg.Password, cfg.InsecureSkipVerify)
+	wsClient.UseAuth(client.Config())
+	wsClient.UseSafety(client.Config().Safety)
+	if err := wsClient.Connect(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Note: WebSocket (ZADT_VSP) unavailable: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Breakpoints cannot be set; attach still works for existing breakpoints.\n")
+		wsClient = nil
+	} else {
+		defer wsClient.Close()
+	}
+
+	dbg := dap.NewADTDebugger(client, wsClient, user)
+	serverCfg := dap.Config{ListenTimeout: dapTimeout}
+
+	if dapListen == "" {
+		return dap.NewServer(dbg, serverCfg).Serve(ctx, os.Stdin, os.Stdout)
+	}
+
+	ln, err := net.Listen("tcp", dapListen)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", dapListen, err)
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	fmt.Fprintf(os.Stderr, "DAP server listening on %s\n", ln.Addr())
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "DAP client connected: %s\n", conn.RemoteAddr())
+		if err := dap.NewServer(dbg, serverCfg).Serve(ctx, conn, conn); err != nil {
+			fmt.Fprintf(os.Stderr, "DAP session error: %v\n", err)
+		}
+		conn.Close()
+		fmt.Fprintf(os.Stderr, "DAP client disconnected\n")
+	}
+}
@@ -31,8 +31,10 @@ Commands:
   r, stack     Show call stack
   v, vars      Show local variables
   b <prog> <line>  Set breakpoint
+  lp <prog> <line> <message>  Set logpoint (logs and continues)
   d <id>       Delete breakpoint
   l            List breakpoints
+  log          Show logpoint messages
   q, quit      Detach and exit
   h, help      Show help
 
@@ -44,7 +46,10 @@ Examples:
   vsp debug --attach --user DEVELOPER
 
   # Set breakpoint and attach
-  vsp debug --program ZTEST --line 42`,
+  vsp debug --program ZTEST --line 42
+
+  # Logpoint: print variables at a line without stopping
+  (dbg) > lp ZORDERS 120 order {ls_order-vbeln} total {lv_total}`,
 	RunE: runDebug,
 }
 
@@ -73,6 +78,7 @@ type debugSession struct {
 	user       string
 	attached   bool
 	debuggeeID string
+	logpoints  *adt.LogpointManager
 	ctx        context.Context
 	cancel     context.CancelFunc
 }
@@ -124,6 +130,8 @@ func runDebug(cmd *cobra.Command, args []string) error {
 		cfg.Password,
 		cfg.InsecureSkipVerify,
 	)
+	wsClient.UseAuth(client.Config())
+	wsClient.UseSafety(client.Config().Safety)
 
 	// Try to connect WebSocket (optional - falls back to HTTP if unavailable)
 	wsConnected := false
@@ -137,11 +145,12 @@ func runDebug(cmd *cobra.Command, args []string) error {
 
 	// Create session
 	session := &debugSession{
-		client:   client,
-		wsClient: wsClient,
-		user:     user,
-		ctx:      ctx,
-		cancel:   cancel,
+		client:    client,
+		wsClient:  wsClient,
+		user:      user,
+		logpoints: adt.NewLogpointManager(),
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 
 	// Set initial breakpoint if specified
@@ -174,7 +183,7 @@ func printDebugBanner(user, url string, wsConnected bool) {
 		fmt.Println("WebSocket: not available (HTTP-only mode)")
 	}
 	fmt.Println()
-	fmt.Println("Commands: s=step, n=next, o=out, c=continue, r=stack, v=vars, q=quit, h=help")
+	fmt.Println("Commands: s=step, n=next, o=out, c=continue, r=stack, v=vars, lp=logpoint, q=quit, h=help")
 	fmt.Println()
 }
 
@@ -263,6 +272,14 @@ func (s *debugSession) repl() error {
 				fmt.Printf("Error: %v\n", err)
 			}
 
+		case "lp", "logpoint":
+			if err := s.setLogpoint(args); err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
+
+		case "log":
+			s.showLog(args)
+
 		case "d", "delete", "del":
 			if err := s.deleteBreakpoint(args); err != nil {
 				fmt.Printf("Error: %v\n", err)
@@ -310,8 +327,12 @@ Commands:
 
   Breakpoints:
     b <prog> <line>  Set line breakpoint
+    lp <prog> <line> <message>
+                     Set logpoint: when hit, log the message with
+                     {variable} replaced by its value and continue
     d <id>           Delete breakpoint
     l, list          List all breakpoints
+    log [clear]      Show (and optionally clear) logpoint messages
 
   Trigger:
     run <prog> [variant]   Run program via RFC (hits breakpoints)
@@ -375,6 +396,7 @@ func (s *debugSession) attach() error {
 	fmt.Printf("Attached! Session: %s\n", attachResult.DebugSessionID)
 	fmt.Printf("%s:%d\n", result.Debuggee.Program, result.Debuggee.Line)
 
+	s.passLogpoints(attachResult.ReachedBreakpoints)
 	return nil
 }
 
@@ -418,6 +440,10 @@ func (s *debugSession) step(stepType adt.DebugStepType) error {
 		return nil
 	}
 
+	if stepType == adt.DebugStepContinue && !s.passLogpoints(result.ReachedBreakpoints) {
+		return nil
+	}
+
 	// Show current position
 	stack, err := s.client.DebuggerGetStack(s.ctx, false)
 	if err == nil && len(stack.Stack) > 0 {
@@ -521,6 +547,69 @@ func (s *debugSession) setBreakpoint(args []string) error {
 	return fmt.Errorf("WebSocket not connected - cannot set breakpoints")
 }
 
+func (s *debugSession) setLogpoint(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("usage: lp <program> <line> <message>")
+	}
+
+	program := strings.ToUpper(args[0])
+	line, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid line number: %s", args[1])
+	}
+	message := strings.Join(args[2:], " ")
+
+	if s.wsClient == nil {
+		return fmt.Errorf("WebSocket not connected - cannot set logpoints")
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	bpID, err := s.wsClient.SetLineBreakpoint(ctx, program, line)
+	if err != nil {
+		return fmt.Errorf("set logpoint failed: %w", err)
+	}
+	s.logpoints.Add(adt.Logpoint{ID: bpID, Program: program, Line: line, Message: message})
+	fmt.Printf("Logpoint %s set at %s:%d: %s\n", bpID, program, line, message)
+	return nil
+}
+
+// passLogpoints logs and continues while the debuggee is stopped at a logpoint.
+// It reports whether the debuggee is still stopped.
+func (s *debugSession) passLogpoints(reached []adt.DebugReachedBreakpoint) bool {
+	if s.logpoints.Len() == 0 {
+		return true
+	}
+
+	hits, stopped, err := s.logpoints.ContinueThrough(s.ctx, s.client, reached)
+	for _, hit := range hits {
+		fmt.Printf("[log] %s:%d  %s\n", hit.Location.Program, hit.Location.Line, hit.Message)
+	}
+	if err != nil {
+		fmt.Printf("Warning: logpoint: %v\n", err)
+	}
+	if !stopped {
+		s.attached = false
+		s.debuggeeID = ""
+		fmt.Println("Debuggee terminated")
+	}
+	return stopped
+}
+
+func (s *debugSession) showLog(args []string) {
+	hits := s.logpoints.Hits()
+	if len(hits) == 0 {
+		fmt.Println("No logpoint messages")
+	}
+	for _, hit := range hits {
+		fmt.Printf("%s  %s:%d  %s\n", hit.Time.Format("15:04:05.000"), hit.Location.Program, hit.Location.Line, hit.Message)
+	}
+	if len(args) > 0 && strings.EqualFold(args[0], "clear") {
+		s.logpoints.ClearHits()
+	}
+}
+
 func (s *debugSession) deleteBreakpoint(args []string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("usage: d <breakpoint-id>")
@@ -535,6 +624,7 @@ func (s *debugSession) deleteBreakpoint(args []string) error {
 		if err := s.wsClient.DeleteBreakpoint(ctx, bpID); err != nil {
 			return fmt.Errorf("delete breakpoint failed: %w", err)
 		}
+		s.logpoints.Remove(bpID)
 		fmt.Printf("Breakpoint %s deleted\n", bpID)
 		return nil
 	}
@@ -557,11 +647,20 @@ func (s *debugSession) listBreakpoints() error {
 			return nil
 		}
 
+		messages := make(map[string]string)
+		for _, lp := range s.logpoints.List() {
+			messages[lp.ID] = lp.Message
+		}
+
 		fmt.Println("\nBreakpoints:")
 		for _, bp := range bps {
 			id, _ := bp["id"].(string)
 			program, _ := bp["program"].(string)
 			line, _ := bp["line"].(float64)
+			if msg, ok := messages[id]; ok {
+				fmt.Printf("  %s: %s:%d  logpoint %q\n", id, program, int(line), msg)
+				continue
+			}
 			fmt.Printf("  %s: %s:%d\n", id, program, int(line))
 		}
 		fmt.Println()
@@ -695,6 +794,7 @@ func (s *debugSession) runProgram(args []string) error {
 		s.attached = true
 		s.debuggeeID = result.debuggee.ID
 		fmt.Printf("Attached! Session: %s\n", attachResult.DebugSessionID)
+		s.passLogpoints(attachResult.ReachedBreakpoints)
 
 	case <-s.ctx.Done():
 		return s.ctx.Err()
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/oisee/vibing-steampunk/pkg/abapgit"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <dir|zip>",
+	Short: "Import an abapGit repository (folder or ZIP) through ADT",
+	Long: `Install an abapGit repository into a package without abapGit on the SAP side.
+
+The repository is read from a folder or a ZIP file (e.g. a GitHub archive).
+Folders below the starting folder become subpackages according to the
+folder logic in .abapgit.xml (PREFIX or FULL). Objects are created in
+dependency order - domains, data elements, tables and structures, message
+classes, then CDS views, behavior definitions, interfaces, classes, function
+groups, programs and service definitions - and activated, in several passes
+if they depend on each other. Existing source objects are updated.
+
+Supported types: CLAS, INTF, PROG, FUGR, DDLS, BDEF, SRVD, TABL, DTEL, DOMA, MSAG.
+Other types are reported as skipped.
+
+Examples:
+  vsp -s dev import ./abap-logger --package '$ZLOGGER'
+  vsp -s dev --enable-transports import abap-logger-main.zip --package ZLOGGER --transport DEVK900123
+  vsp -s dev import ./repo --package '$ZTEST' --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	importCmd.Flags().String("package", "", "Root package to install into (created if missing)")
+	importCmd.Flags().String("transport", "", "Transport request for transportable packages")
+	importCmd.Flags().String("software-component", "", "Software component of new transportable packages")
+	importCmd.Flags().Bool("dry-run", false, "Only report what would be created or updated")
+	importCmd.Flags().Bool("json", false, "Print the result as JSON")
+	importCmd.MarkFlagRequired("package")
+
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	resolveConfig(cmd.Parent())
+	if err := validateConfig(); err != nil {
+		return err
+	}
+	if err := processCookieAuth(cmd.Parent()); err != nil {
+		return err
+	}
+
+	repo, err := abapgit.ReadPath(args[0])
+	if err != nil {
+		return fmt.Errorf("reading repository: %w", err)
+	}
+
+	opts := abapgit.ImportOptions{}
+	opts.Package, _ = cmd.Flags().GetString("package")
+	opts.Transport, _ = cmd.Flags().GetString("transport")
+	opts.SoftwareComponent, _ = cmd.Flags().GetString("software-component")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	client := createADTClient()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Importing %d objects from %s into %s\n", len(repo.Objects), args[0], opts.Package)
+	result, err := abapgit.NewImporter(client, opts).Import(ctx, repo)
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	for _, pkg := range result.Packages {
+		fmt.Printf("  package  %s\n", pkg)
+	}
+	for _, o := range result.Objects {
+		line := fmt.Sprintf("  %-8s %-4s %s (%s)", o.Action, o.Type, o.Name, o.Package)
+		if o.Message != "" {
+			line += ": " + o.Message
+		}
+		fmt.Println(line)
+	}
+	for _, o := range result.Inactive {
+		fmt.Printf("  inactive %-4s %s: %s\n", o.Type, o.Name, o.Message)
+	}
+	fmt.Println(result.Summary)
+	if result.Count("failed") > 0 || len(result.Inactive) > 0 {
+		return fmt.Errorf("import incomplete")
+	}
+	return nil
+}
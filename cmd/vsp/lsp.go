@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/oisee/vibing-steampunk/pkg/lsp"
+	"github.com/spf13/cobra"
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Language Server Protocol server for ABAP",
+	Long: `Run a Language Server Protocol (LSP) server for ABAP over stdin/stdout.
+
+Neovim, Helix, VS Code and other LSP clients start it as a subprocess and
+get ABAP code intelligence from the connected system, without Eclipse.
+
+Local files must use abapGit names; they map to the ADT source of the object:
+  zcl_foo.clas.abap              Class ZCL_FOO (main source)
+  zcl_foo.clas.locals_imp.abap   Local implementations (also locals_def,
+                                 macros, testclasses)
+  ztest.prog.abap                Program or include ZTEST
+  zif_foo.intf.abap              Interface ZIF_FOO
+  zfg.fugr.z_run.func.abap       Function module Z_RUN of group ZFG
+  zi_foo.ddls.asddls             CDS view ZI_FOO
+
+Features:
+  completion                     ADT code completion
+  definition / implementation    Navigate; objects without a local file are
+                                 downloaded read-only to --cache-dir
+  references                     Where-used list
+  hover                          Statement defining the symbol
+  documentSymbol                 Classes, methods, forms, modules, functions
+  formatting                     ADT pretty printer
+  typeHierarchy                  Super- and subclasses
+  diagnostics                    Syntax check on save
+
+Examples:
+  # Neovim (lspconfig-style)
+  cmd = { "vsp", "-s", "dev", "lsp" }, filetypes = { "abap" }
+
+  # Helix languages.toml
+  [language-server.vsp]
+  command = "vsp"
+  args = ["-s", "dev", "lsp"]`,
+	RunE: runLSP,
+}
+
+var lspCacheDir string
+
+func init() {
+	lspCmd.Flags().StringVar(&lspCacheDir, "cache-dir", "", "Directory for read-only copies of objects without a local file (default: <user cache dir>/vsp/lsp)")
+
+	rootCmd.AddCommand(lspCmd)
+}
+
+func runLSP(cmd *cobra.Command, args []string) error {
+	resolveConfig(cmd.Parent())
+	if err := validateConfig(); err != nil {
+		return err
+	}
+	if err := processCookieAuth(cmd.Parent()); err != nil {
+		return err
+	}
+
+	client := createADTClient()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	// stdout carries the protocol, so nothing else may be printed to it.
+	server := lsp.NewServer(lsp.NewADTBackend(client), lsp.Config{CacheDir: lspCacheDir})
+	return server.Serve(ctx, os.Stdin, os.Stdout)
+}
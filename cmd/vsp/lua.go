@@ -3,23 +3,31 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/oisee/vibing-steampunk/pkg/adt"
 	"github.com/oisee/vibing-steampunk/pkg/scripting"
 	"github.com/spf13/cobra"
 )
 
 var luaCmd = &cobra.Command{
-	Use:   "lua [script.lua]",
+	Use:   "lua [script.lua] [-- args...]",
 	Short: "Run Lua scripts or interactive REPL",
 	Long: `Run Lua scripts for automated debugging, testing, and analysis.
 
 Without arguments, starts an interactive Lua REPL.
-With a script file, executes the script.
+With a script file, executes the script. Arguments after "--" are passed
+to the script as the arg table (arg[1], arg[2], ...) and as varargs.
+
+require() searches .vsp/lua and ~/.vsp/lua (plus --lua-path) and provides
+the bundled modules vsp.debug, vsp.report, vsp.assert and vsp.test.
 
 Available modules:
   - All MCP tools (searchObject, getSource, setBreakpoint, etc.)
   - Debug session management (listen, attach, stepOver, etc.)
   - Checkpoints (saveCheckpoint, injectCheckpoint)
+  - Transports, ATC, CDS, data preview, CRUD, UI5 (getTransport, runATC, runQuery, ...)
+  - ZADT_VSP WebSocket calls (callRFC, runReport)
   - JSON encoding/decoding
   - print, sleep utilities
 
@@ -30,25 +38,65 @@ Examples:
   # Run a script
   vsp lua scripts/debug-pricing.lua
 
+  # Run a script with arguments
+  vsp lua examples/scripts/debug-session.lua -- ZTEST_PROGRAM 42
+
   # Execute inline script
-  vsp lua -e 'print(searchObject("ZCL_*", "CLAS"))'`,
-	Args: cobra.MaximumNArgs(1),
+  vsp lua -e 'print(searchObject("ZCL_*", "CLAS"))'
+
+  # Run an untrusted script in the sandbox (no io/os, capped runtime)
+  vsp lua --sandbox --timeout 30s scripts/agent-debug.lua
+
+  # Regenerate the API reference
+  vsp lua --api-doc > docs/lua-api.md`,
+	Args: cobra.ArbitraryArgs,
 	RunE: runLua,
 }
 
 var (
-	luaExec    string
-	luaVerbose bool
+	luaExec            string
+	luaVerbose         bool
+	luaSandbox         bool
+	luaTimeout         time.Duration
+	luaMaxInstructions int64
+	luaMaxMemory       int64
+	luaAllowedOps      string
+	luaAPIDoc          bool
+	luaPath            []string
 )
 
 func init() {
 	luaCmd.Flags().StringVarP(&luaExec, "exec", "e", "", "Execute Lua code directly")
 	luaCmd.Flags().BoolVarP(&luaVerbose, "verbose", "v", false, "Verbose output")
+	luaCmd.Flags().BoolVar(&luaSandbox, "sandbox", false, "Run in sandbox mode (restricted libraries, limits, safety-filtered bindings)")
+	luaCmd.Flags().DurationVar(&luaTimeout, "timeout", 60*time.Second, "Wall-clock limit per script in sandbox mode")
+	luaCmd.Flags().Int64Var(&luaMaxInstructions, "max-instructions", 10_000_000, "VM instruction limit in sandbox mode (0 = unlimited)")
+	luaCmd.Flags().Int64Var(&luaMaxMemory, "max-memory", 256<<20, "Process heap growth limit in bytes while a script runs in sandbox mode (0 = unlimited)")
+	luaCmd.Flags().StringVar(&luaAllowedOps, "allowed-ops", "", "Operation types allowed for ADT bindings in sandbox mode (e.g., \"RSB\" for Read, Search, deBug)")
+
+	luaCmd.Flags().StringSliceVar(&luaPath, "lua-path", nil, "Additional directories for require(), searched before .vsp/lua and ~/.vsp/lua")
+	luaCmd.Flags().BoolVar(&luaAPIDoc, "api-doc", false, "Print the Lua API reference (Markdown) and exit")
 
 	rootCmd.AddCommand(luaCmd)
 }
 
 func runLua(cmd *cobra.Command, args []string) error {
+	if luaAPIDoc {
+		fmt.Print(scripting.APIReference())
+		return nil
+	}
+
+	// Split script arguments: "vsp lua script.lua -- a b" or "vsp lua script.lua a b"
+	var scriptArgs []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		args, scriptArgs = args[:dash], args[dash:]
+	} else if len(args) > 1 {
+		args, scriptArgs = args[:1], args[1:]
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("expected at most one script file, got %d (pass script arguments after --)", len(args))
+	}
+
 	// Resolve configuration (same as MCP server)
 	resolveConfig(cmd.Parent())
 
@@ -66,9 +114,29 @@ func runLua(cmd *cobra.Command, args []string) error {
 	client := createADTClient()
 
 	// Create Lua engine
-	engine := scripting.NewLuaEngine(client)
+	var engine *scripting.LuaEngine
+	if luaSandbox {
+		sandboxCfg := scripting.DefaultSandboxConfig()
+		sandboxCfg.Timeout = luaTimeout
+		sandboxCfg.MaxInstructions = luaMaxInstructions
+		sandboxCfg.MaxMemory = luaMaxMemory
+		if luaAllowedOps != "" {
+			sandboxCfg.Safety = &adt.SafetyConfig{AllowedOps: luaAllowedOps}
+		}
+		var err error
+		engine, err = scripting.NewSandboxedLuaEngine(client, sandboxCfg)
+		if err != nil {
+			return err
+		}
+	} else {
+		engine = scripting.NewLuaEngine(client)
+	}
 	defer engine.Close()
 
+	if len(luaPath) > 0 {
+		engine.SetSearchPath(append(luaPath, scripting.DefaultSearchPath()...))
+	}
+
 	// Set output for verbose mode
 	if luaVerbose {
 		fmt.Fprintf(os.Stderr, "[LUA] Connected to: %s\n", cfg.BaseURL)
@@ -78,6 +146,7 @@ func runLua(cmd *cobra.Command, args []string) error {
 	// Execute based on mode
 	if luaExec != "" {
 		// Execute inline code
+		engine.SetArgs("-e", scriptArgs)
 		if err := engine.Execute(luaExec); err != nil {
 			return fmt.Errorf("lua error: %w", err)
 		}
@@ -95,7 +164,7 @@ func runLua(cmd *cobra.Command, args []string) error {
 			fmt.Fprintf(os.Stderr, "[LUA] Running script: %s\n", scriptFile)
 		}
 
-		if err := engine.ExecuteFile(scriptFile); err != nil {
+		if err := engine.ExecuteFile(scriptFile, scriptArgs...); err != nil {
 			return fmt.Errorf("script error: %w", err)
 		}
 		return nil
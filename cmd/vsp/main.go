@@ -53,6 +53,7 @@ Quick start:
   vsp --read-only                                    # no writes at all
   vsp --allowed-packages 'Z*,$TMP' --block-free-sql  # sandbox AI to custom code
   vsp --disallowed-ops CDUA                           # block create/delete/update/activate
+  vsp --disallowed-ops B                              # block the debugger and breakpoints
 
 Configuration files:
   .env          Default SAP connection (MCP server mode). SAP_URL, SAP_USER, etc.
@@ -96,7 +97,7 @@ func init() {
 	// Safety options
 	rootCmd.Flags().BoolVar(&cfg.ReadOnly, "read-only", false, "Block all write operations (create, update, delete, activate)")
 	rootCmd.Flags().BoolVar(&cfg.BlockFreeSQL, "block-free-sql", false, "Block execution of arbitrary SQL queries via RunQuery")
-	rootCmd.Flags().StringVar(&cfg.AllowedOps, "allowed-ops", "", "Whitelist of allowed operation types (e.g., \"RSQ\" for Read, Search, Query only)")
+	rootCmd.Flags().StringVar(&cfg.AllowedOps, "allowed-ops", "", "Whitelist of allowed operation types (e.g., \"RSQ\" for Read, Search, Query only; the debugger (B) is only blocked by --disallowed-ops)")
 	rootCmd.Flags().StringVar(&cfg.DisallowedOps, "disallowed-ops", "", "Blacklist of operation types to block (e.g., \"CDUA\" for Create, Delete, Update, Activate)")
 	rootCmd.Flags().StringSliceVar(&cfg.AllowedPackages, "allowed-packages", nil, "Restrict operations to specific packages (comma-separated, supports wildcards like Z*)")
 	rootCmd.Flags().BoolVar(&cfg.EnableTransports, "enable-transports", false, "Enable transport management operations (disabled by default for safety)")
@@ -116,10 +117,32 @@ func init() {
 	rootCmd.Flags().StringVar(&cfg.FeatureAMDP, "feature-amdp", "auto", "AMDP/HANA debugger: auto, on, off")
 	rootCmd.Flags().StringVar(&cfg.FeatureUI5, "feature-ui5", "auto", "UI5/Fiori BSP management: auto, on, off")
 	rootCmd.Flags().StringVar(&cfg.FeatureTransport, "feature-transport", "auto", "CTS transport management: auto, on, off")
+	rootCmd.Flags().StringVar(&cfg.FeatureVSP, "feature-vsp", "auto", "ZADT_VSP WebSocket handler: auto, on, off")
+	rootCmd.Flags().StringVar(&cfg.FeatureTools, "feature-tools", "", "Tools of unavailable features: register (default), hide, annotate")
 
 	// Debugger configuration
 	rootCmd.Flags().StringVar(&cfg.TerminalID, "terminal-id", "", "SAP GUI terminal ID for cross-tool breakpoint sharing")
 
+	// HTTP traffic recording (persistent: also applies to CLI subcommands)
+	rootCmd.PersistentFlags().StringVar(&cfg.RecordDir, "record", "", "Record ADT HTTP traffic to a cassette directory (credentials and tokens redacted)")
+	rootCmd.PersistentFlags().StringVar(&cfg.ReplayDir, "replay", "", "Answer ADT HTTP requests from a recorded cassette directory instead of the system")
+
+	// Load limits (persistent: also apply to CLI subcommands)
+	rootCmd.PersistentFlags().IntVar(&cfg.MaxConcurrent, "max-concurrent", 0, "Maximum ADT requests in flight (0 = unlimited)")
+	rootCmd.PersistentFlags().Float64Var(&cfg.RateLimit, "rate-limit", 0, "Maximum ADT requests per second (0 = unlimited)")
+	rootCmd.PersistentFlags().IntVar(&cfg.SessionPool, "session-pool", 0, "Dedicated SAP sessions for lock/update/unlock (0 = one shared session)")
+	rootCmd.PersistentFlags().IntVar(&cfg.MaxRetries, "max-retries", 2, "Retries of read requests after transient errors (503, connection reset, timeout); 0 disables")
+
+	// OAuth2 and client certificate authentication
+	rootCmd.PersistentFlags().String("service-key", "", "SAP BTP ABAP Environment service key JSON file (OAuth2 login in the browser; provides the URL)")
+	rootCmd.PersistentFlags().String("oauth-token-url", "", "OAuth2 token endpoint")
+	rootCmd.PersistentFlags().String("oauth-auth-url", "", "OAuth2 authorization endpoint (authorization_code grant)")
+	rootCmd.PersistentFlags().String("oauth-client-id", "", "OAuth2 client ID")
+	rootCmd.PersistentFlags().String("oauth-client-secret", "", "OAuth2 client secret")
+	rootCmd.PersistentFlags().String("oauth-grant", "", "OAuth2 grant: authorization_code (browser login, default) or client_credentials")
+	rootCmd.PersistentFlags().StringVar(&cfg.ClientCertFile, "client-cert", "", "PEM client certificate for mutual TLS (X.509 logon)")
+	rootCmd.PersistentFlags().StringVar(&cfg.ClientKeyFile, "client-key", "", "PEM private key of --client-cert (if not in the certificate file)")
+
 	// Output options
 	rootCmd.Flags().BoolVarP(&cfg.Verbose, "verbose", "v", false, "Enable verbose output to stderr")
 
@@ -152,10 +175,27 @@ func init() {
 	viper.BindPFlag("feature-amdp", rootCmd.Flags().Lookup("feature-amdp"))
 	viper.BindPFlag("feature-ui5", rootCmd.Flags().Lookup("feature-ui5"))
 	viper.BindPFlag("feature-transport", rootCmd.Flags().Lookup("feature-transport"))
+	viper.BindPFlag("feature-vsp", rootCmd.Flags().Lookup("feature-vsp"))
+	viper.BindPFlag("feature-tools", rootCmd.Flags().Lookup("feature-tools"))
 
 	// Debugger configuration
 	viper.BindPFlag("terminal-id", rootCmd.Flags().Lookup("terminal-id"))
 
+	// HTTP traffic recording
+	viper.BindPFlag("record", rootCmd.PersistentFlags().Lookup("record"))
+	viper.BindPFlag("replay", rootCmd.PersistentFlags().Lookup("replay"))
+
+	// Load limits
+	viper.BindPFlag("max-concurrent", rootCmd.PersistentFlags().Lookup("max-concurrent"))
+	viper.BindPFlag("rate-limit", rootCmd.PersistentFlags().Lookup("rate-limit"))
+	viper.BindPFlag("session-pool", rootCmd.PersistentFlags().Lookup("session-pool"))
+	viper.BindPFlag("max-retries", rootCmd.PersistentFlags().Lookup("max-retries"))
+
+	// OAuth2 and client certificate flags
+	for _, name := range []string{"service-key", "oauth-token-url", "oauth-auth-url", "oauth-client-id", "oauth-client-secret", "oauth-grant", "client-cert", "client-key"} {
+		viper.BindPFlag(name, rootCmd.PersistentFlags().Lookup(name))
+	}
+
 	// Set up environment variable mapping
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
@@ -190,11 +230,16 @@ func runServer(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "[VERBOSE] SAP URL: %s\n", cfg.BaseURL)
 		fmt.Fprintf(os.Stderr, "[VERBOSE] SAP Client: %s\n", cfg.Client)
 		fmt.Fprintf(os.Stderr, "[VERBOSE] SAP Language: %s\n", cfg.Language)
-		if cfg.Username != "" {
+		if cfg.OAuth != nil {
+			fmt.Fprintf(os.Stderr, "[VERBOSE] Auth: OAuth2 %s (client: %s)\n", cfg.OAuth.GrantType, cfg.OAuth.ClientID)
+		} else if cfg.Username != "" {
 			fmt.Fprintf(os.Stderr, "[VERBOSE] Auth: Basic (user: %s)\n", cfg.Username)
 		} else if len(cfg.Cookies) > 0 {
 			fmt.Fprintf(os.Stderr, "[VERBOSE] Auth: Cookie (%d cookies)\n", len(cfg.Cookies))
 		}
+		if cfg.ClientCertFile != "" {
+			fmt.Fprintf(os.Stderr, "[VERBOSE] Auth: Client certificate %s\n", cfg.ClientCertFile)
+		}
 
 		// Safety status
 		if cfg.ReadOnly {
@@ -254,6 +299,8 @@ func resolveConfig(cmd *cobra.Command) {
 	cookieAuthViaCLI := cmd.Flags().Changed("cookie-file") || cmd.Flags().Changed("cookie-string")
 	cookieAuthViaEnv := viper.GetString("COOKIE_FILE") != "" || viper.GetString("COOKIE_STRING") != ""
 	hasCookieAuth := cookieAuthViaCLI || cookieAuthViaEnv
+	// OAuth2 (service key or token URL) likewise replaces basic auth
+	hasOAuth := viper.GetString("service-key") != "" || viper.GetString("oauth-token-url") != ""
 
 	// URL: flag > SAP_URL env
 	if cfg.BaseURL == "" {
@@ -264,18 +311,18 @@ func resolveConfig(cmd *cobra.Command) {
 	}
 
 	// Username: flag > SAP_USER env (skip if cookie auth is present)
-	if cfg.Username == "" && !hasCookieAuth {
+	if cfg.Username == "" && !hasCookieAuth && !hasOAuth {
 		cfg.Username = viper.GetString("USER")
 	}
-	if cfg.Username == "" && !hasCookieAuth {
+	if cfg.Username == "" && !hasCookieAuth && !hasOAuth {
 		cfg.Username = viper.GetString("USERNAME")
 	}
 
 	// Password: flag > SAP_PASSWORD env (skip if cookie auth is present)
-	if cfg.Password == "" && !hasCookieAuth {
+	if cfg.Password == "" && !hasCookieAuth && !hasOAuth {
 		cfg.Password = viper.GetString("PASSWORD")
 	}
-	if cfg.Password == "" && !hasCookieAuth {
+	if cfg.Password == "" && !hasCookieAuth && !hasOAuth {
 		cfg.Password = viper.GetString("PASS")
 	}
 
@@ -383,6 +430,16 @@ func resolveConfig(cmd *cobra.Command) {
 			cfg.FeatureTransport = v
 		}
 	}
+	if !cmd.Flags().Changed("feature-vsp") {
+		if v := viper.GetString("FEATURE_VSP"); v != "" {
+			cfg.FeatureVSP = v
+		}
+	}
+	if !cmd.Flags().Changed("feature-tools") {
+		if v := viper.GetString("FEATURE_TOOLS"); v != "" {
+			cfg.FeatureTools = v
+		}
+	}
 
 	// Terminal ID for debugger: flag > SAP_TERMINAL_ID env
 	if !cmd.Flags().Changed("terminal-id") {
@@ -390,9 +447,129 @@ func resolveConfig(cmd *cobra.Command) {
 			cfg.TerminalID = v
 		}
 	}
+
+	// Recording and load limits: flag > SAP_* env
+	resolveTransportConfig()
+}
+
+// resolveTransportConfig fills the HTTP transport settings (recording, load
+// limits, retries) from SAP_RECORD, SAP_REPLAY, SAP_MAX_CONCURRENT,
+// SAP_RATE_LIMIT, SAP_SESSION_POOL and SAP_MAX_RETRIES when the flags are not set. CLI subcommands call it
+// directly because they do not go through resolveConfig.
+func resolveTransportConfig() {
+	if cfg.RecordDir == "" {
+		cfg.RecordDir = viper.GetString("RECORD")
+	}
+	if cfg.ReplayDir == "" {
+		cfg.ReplayDir = viper.GetString("REPLAY")
+	}
+	if cfg.MaxConcurrent == 0 {
+		cfg.MaxConcurrent = viper.GetInt("MAX_CONCURRENT")
+	}
+	if cfg.RateLimit == 0 {
+		cfg.RateLimit = viper.GetFloat64("RATE_LIMIT")
+	}
+	if cfg.SessionPool == 0 {
+		cfg.SessionPool = viper.GetInt("SESSION_POOL")
+	}
+	// Bound flag: viper returns the flag if set, else the env var, else the default
+	cfg.MaxRetries = viper.GetInt("max-retries")
+}
+
+// transportOptions returns the client options for recording, load limits and retries.
+func transportOptions() ([]adt.Option, error) {
+	resolveTransportConfig()
+	if cfg.RecordDir != "" && cfg.ReplayDir != "" {
+		return nil, fmt.Errorf("--record and --replay cannot be used together")
+	}
+	var opts []adt.Option
+	if cfg.RecordDir != "" {
+		opts = append(opts, adt.WithRecording(cfg.RecordDir))
+	}
+	if cfg.ReplayDir != "" {
+		opts = append(opts, adt.WithReplay(cfg.ReplayDir))
+	}
+	if cfg.MaxConcurrent > 0 {
+		opts = append(opts, adt.WithMaxConcurrent(cfg.MaxConcurrent))
+	}
+	if cfg.RateLimit > 0 {
+		opts = append(opts, adt.WithRateLimit(cfg.RateLimit))
+	}
+	if cfg.SessionPool > 0 {
+		opts = append(opts, adt.WithSessionPool(cfg.SessionPool))
+	}
+	opts = append(opts, adt.WithMaxRetries(cfg.MaxRetries))
+
+	if err := resolveAuthConfig(); err != nil {
+		return nil, err
+	}
+	if cfg.OAuth != nil {
+		opts = append(opts, adt.WithOAuth(*cfg.OAuth))
+	}
+	if cfg.ClientCertFile != "" {
+		opts = append(opts, adt.WithClientCertificate(cfg.ClientCertFile, cfg.ClientKeyFile))
+	}
+	return opts, nil
+}
+
+// resolveAuthConfig sets up OAuth2 and client certificate authentication from
+// --service-key, --oauth-*, --client-cert and --client-key (or SAP_SERVICE_KEY,
+// SAP_OAUTH_*, SAP_CLIENT_CERT and SAP_CLIENT_KEY). A service key also
+// provides the system URL if none is given.
+func resolveAuthConfig() error {
+	if cfg.ClientCertFile == "" {
+		cfg.ClientCertFile = viper.GetString("client-cert")
+	}
+	if cfg.ClientKeyFile == "" {
+		cfg.ClientKeyFile = viper.GetString("client-key")
+	}
+	if cfg.OAuth != nil {
+		return nil
+	}
+
+	if path := viper.GetString("service-key"); path != "" {
+		key, err := adt.LoadServiceKey(path)
+		if err != nil {
+			return fmt.Errorf("loading service key: %w", err)
+		}
+		oauth := key.OAuthConfig()
+		cfg.OAuth = &oauth
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = key.SystemURL()
+		}
+	} else if tokenURL := viper.GetString("oauth-token-url"); tokenURL != "" {
+		cfg.OAuth = &adt.OAuthConfig{
+			GrantType:    adt.GrantAuthorizationCode,
+			TokenURL:     tokenURL,
+			AuthURL:      viper.GetString("oauth-auth-url"),
+			ClientID:     viper.GetString("oauth-client-id"),
+			ClientSecret: viper.GetString("oauth-client-secret"),
+		}
+	} else {
+		return nil
+	}
+
+	if grant := viper.GetString("oauth-grant"); grant != "" {
+		if grant != adt.GrantAuthorizationCode && grant != adt.GrantClientCredentials {
+			return fmt.Errorf("invalid --oauth-grant: %s (must be '%s' or '%s')", grant, adt.GrantAuthorizationCode, adt.GrantClientCredentials)
+		}
+		cfg.OAuth.GrantType = grant
+	}
+	if cfg.OAuth.ClientID == "" {
+		return fmt.Errorf("--oauth-client-id is required for OAuth2")
+	}
+	if cfg.OAuth.GrantType == adt.GrantAuthorizationCode && cfg.OAuth.TokenCacheFile == "" {
+		cfg.OAuth.TokenCacheFile = adt.DefaultTokenCacheFile(cfg.OAuth.ClientID, cfg.OAuth.TokenURL)
+	}
+	return nil
 }
 
 func validateConfig() error {
+	// A service key provides the URL, so it is loaded first
+	if err := resolveAuthConfig(); err != nil {
+		return err
+	}
+
 	if cfg.BaseURL == "" {
 		return fmt.Errorf("SAP URL is required. Use --url flag or SAP_URL environment variable")
 	}
@@ -402,6 +579,10 @@ func validateConfig() error {
 		return fmt.Errorf("invalid mode: %s (must be 'focused' or 'expert')", cfg.Mode)
 	}
 
+	if cfg.RecordDir != "" && cfg.ReplayDir != "" {
+		return fmt.Errorf("--record and --replay cannot be used together")
+	}
+
 	// Check if we have either basic auth or cookies will be processed
 	// Cookies are checked later in processCookieAuth
 	return nil
@@ -430,13 +611,17 @@ func processCookieAuth(cmd *cobra.Command) error {
 	if cookieString != "" {
 		authMethods++
 	}
+	if cfg.OAuth != nil {
+		authMethods++
+	}
 
 	if authMethods > 1 {
-		return fmt.Errorf("only one authentication method can be used at a time (basic auth, cookie-file, or cookie-string)")
+		return fmt.Errorf("only one authentication method can be used at a time (basic auth, cookie-file, cookie-string, or OAuth2)")
 	}
 
-	if authMethods == 0 {
-		return fmt.Errorf("authentication required. Use --user/--password, --cookie-file, or --cookie-string")
+	// A client certificate authenticates on its own (X.509 logon)
+	if authMethods == 0 && cfg.ClientCertFile == "" {
+		return fmt.Errorf("authentication required. Use --user/--password, --cookie-file, --cookie-string, --service-key, --oauth-token-url, or --client-cert")
 	}
 
 	// Process cookie file
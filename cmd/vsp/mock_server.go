@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
+	"github.com/spf13/cobra"
+)
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Run a local mock SAP ADT server for offline development",
+	Long: `Run an in-memory mock of the SAP ADT REST API and the ZADT_VSP WebSocket.
+
+The mock supports CSRF tokens and sessions, search, package contents, source
+read/write with lock/unlock, object creation and deletion, activation, syntax
+check (always clean), ABAP Unit (test methods found in the source pass) and
+ATC (no findings). Changes are kept in memory until the server stops.
+
+Point vsp, the MCP server or workflows at it like at any SAP system:
+
+  vsp mock-server --listen 127.0.0.1:8080 --seed ./src
+  vsp --url http://127.0.0.1:8080 --user developer --password any
+
+Seeding reads abapGit-style files (*.prog.abap, *.clas.abap,
+*.clas.testclasses.abap, *.intf.abap, *.ddls.asddls, ...) from a directory
+into --package.
+
+Go tests use the package pkg/adt/adttest directly, which also scripts
+failures (expired CSRF tokens and sessions, foreign locks, HTTP errors).`,
+	Args: cobra.NoArgs,
+	RunE: runMockServer,
+}
+
+var (
+	mockListen   string
+	mockSeed     string
+	mockPackage  string
+	mockUser     string
+	mockPassword string
+)
+
+func init() {
+	mockServerCmd.Flags().StringVar(&mockListen, "listen", "127.0.0.1:8080", "Address to listen on")
+	mockServerCmd.Flags().StringVar(&mockSeed, "seed", "", "Directory of abapGit-style source files to load")
+	mockServerCmd.Flags().StringVar(&mockPackage, "package", "$TMP", "Package for seeded objects")
+	mockServerCmd.Flags().StringVar(&mockUser, "user", "", "Require this basic auth user (default: accept any credentials)")
+	mockServerCmd.Flags().StringVar(&mockPassword, "password", "", "Password for --user")
+
+	rootCmd.AddCommand(mockServerCmd)
+}
+
+func runMockServer(cmd *cobra.Command, args []string) error {
+	srv := adttest.New()
+	srv.User, srv.Password = mockUser, mockPassword
+
+	if mockSeed != "" {
+		n, err := seedMockServer(srv, mockSeed, mockPackage)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Loaded %d objects from %s into %s\n", n, mockSeed, strings.ToUpper(mockPackage))
+	}
+
+	ln, err := net.Listen("tcp", mockListen)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", mockListen, err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	httpServer := &http.Server{Handler: srv}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+		httpServer.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Mock SAP system listening on http://%s (Ctrl+C to stop)\n", ln.Addr())
+	if err := httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// seedMockServer loads the ABAP source files below dir into the mock.
+// Class includes are merged into their class.
+func seedMockServer(srv *adttest.Server, dir, pkg string) (int, error) {
+	objects := make(map[string]*adttest.Object)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := adt.ParseABAPFile(path)
+		if err != nil || info.ObjectName == "" {
+			return nil // not an ABAP source file
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		uri := adt.GetObjectURL(info.ObjectType, info.ObjectName, info.ParentName)
+		obj, ok := objects[uri]
+		if !ok {
+			obj = &adttest.Object{
+				URI:         uri,
+				Type:        string(info.ObjectType),
+				Name:        info.ObjectName,
+				Package:     pkg,
+				Description: info.Description,
+				Includes:    make(map[string]string),
+			}
+			objects[uri] = obj
+		}
+		if info.ClassIncludeType != "" && info.ClassIncludeType != adt.ClassIncludeMain {
+			obj.Includes[string(info.ClassIncludeType)] = string(source)
+		} else {
+			obj.Source = string(source)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("seeding from %s: %w", dir, err)
+	}
+
+	uris := make([]string, 0, len(objects))
+	for uri := range objects {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		srv.AddObject(*objects[uri])
+	}
+	return len(objects), nil
+}
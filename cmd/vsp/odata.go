@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/spf13/cobra"
+)
+
+var odataCmd = &cobra.Command{
+	Use:   "odata",
+	Short: "Work with published OData services",
+}
+
+var odataTestCmd = &cobra.Command{
+	Use:   "test <binding|service-url>",
+	Short: "Smoke test a published OData service",
+	Long: `Smoke test the OData service of a service binding, or of a service URL.
+
+Fetches $metadata, then queries each entity set with $top, with $filter on a
+key of the first row, and with $expand on the first navigation property.
+With --draft, a draft is created, activated and the active instance deleted
+again (OData V4); a draft that fails to activate is discarded. Each request
+is reported with HTTP status, duration and a payload sample.
+
+Exits with an error if any request fails.
+
+Examples:
+  vsp -s dev odata test ZUI_TRAVEL_O4
+  vsp -s dev odata test ZUI_TRAVEL_O4 --entity Travel --draft --payload '{"Description":"Smoke test"}'
+  vsp -s dev odata test /sap/opu/odata/sap/ZUI_TRAVEL_O2/ --top 10 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runODataTest,
+}
+
+func init() {
+	odataTestCmd.Flags().StringSlice("entity", nil, "Entity sets to query (default: all)")
+	odataTestCmd.Flags().Int("top", 3, "Rows per query")
+	odataTestCmd.Flags().Bool("draft", false, "Run a draft create, activate and delete round trip")
+	odataTestCmd.Flags().String("draft-entity", "", "Entity set for the draft round trip (default: first draft-enabled set)")
+	odataTestCmd.Flags().String("payload", "", "Properties of the created draft as JSON")
+	odataTestCmd.Flags().Int("sample", 200, "Payload sample length in bytes")
+	odataTestCmd.Flags().Bool("json", false, "Print the result as JSON")
+
+	odataCmd.AddCommand(odataTestCmd)
+	rootCmd.AddCommand(odataCmd)
+}
+
+func runODataTest(cmd *cobra.Command, args []string) error {
+	resolveConfig(cmd.Root())
+	if err := validateConfig(); err != nil {
+		return err
+	}
+	if err := processCookieAuth(cmd.Root()); err != nil {
+		return err
+	}
+
+	var opts adt.ODataTestOptions
+	if strings.HasPrefix(args[0], "/sap/") || strings.HasPrefix(args[0], "http") {
+		opts.ServiceURL = args[0]
+	} else {
+		opts.Binding = args[0]
+	}
+	opts.EntitySets, _ = cmd.Flags().GetStringSlice("entity")
+	opts.Top, _ = cmd.Flags().GetInt("top")
+	opts.Draft, _ = cmd.Flags().GetBool("draft")
+	opts.DraftEntitySet, _ = cmd.Flags().GetString("draft-entity")
+	opts.SampleSize, _ = cmd.Flags().GetInt("sample")
+	if payload, _ := cmd.Flags().GetString("payload"); payload != "" {
+		if err := json.Unmarshal([]byte(payload), &opts.DraftPayload); err != nil {
+			return fmt.Errorf("invalid --payload: %w", err)
+		}
+	}
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	client := createADTClient()
+	result, err := client.TestODataService(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%s %s\n", result.ServiceURL, result.Version)
+		printODataRequest(result.Metadata)
+		for _, set := range result.EntitySets {
+			fmt.Printf("\n%s (%s, keys %s)\n", set.Name, set.EntityType, strings.Join(set.Keys, ", "))
+			for _, r := range set.Requests {
+				printODataRequest(r)
+			}
+		}
+		if len(result.Draft) > 0 {
+			fmt.Println("\nDraft round trip")
+			for _, r := range result.Draft {
+				printODataRequest(r)
+			}
+		}
+	}
+	if !result.Success {
+		return fmt.Errorf("OData service test failed")
+	}
+	return nil
+}
+
+func printODataRequest(r adt.ODataRequest) {
+	switch {
+	case r.Skipped != "":
+		fmt.Printf("  %-9s skipped: %s\n", r.Name, r.Skipped)
+		return
+	case r.OK():
+		fmt.Printf("  %-9s %d %5dms", r.Name, r.Status, r.DurationMs)
+		if r.Rows > 0 {
+			fmt.Printf(" %d rows", r.Rows)
+		}
+		fmt.Printf("  %s %s\n", r.Method, r.URL)
+	default:
+		fmt.Printf("  %-9s %d %5dms  FAILED %s %s\n", r.Name, r.Status, r.DurationMs, r.Method, r.URL)
+		fmt.Printf("            %s\n", strings.ReplaceAll(r.Error, "\n", " "))
+		return
+	}
+	if r.Sample != "" && r.Name != "$metadata" {
+		fmt.Printf("            %s\n", strings.ReplaceAll(r.Sample, "\n", " "))
+	}
+}
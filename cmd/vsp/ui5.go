@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/spf13/cobra"
+)
+
+var ui5Cmd = &cobra.Command{
+	Use:   "ui5",
+	Short: "Deploy UI5/Fiori apps to the ABAP repository",
+}
+
+var ui5DeployCmd = &cobra.Command{
+	Use:   "deploy <dir>",
+	Short: "Deploy a built UI5 app as BSP application",
+	Long: `Deploy a built UI5/Fiori app as BSP application.
+
+The folder, typically dist/ after ui5 build, is packed into a ZIP archive and
+uploaded through the /UI5/ABAP_REPOSITORY_SRV OData service, the service the
+Fiori tools deploy task uses. A new app is created in --package; an existing
+app is replaced and keeps its package.
+
+Examples:
+  vsp -s dev ui5 deploy ./dist --name ZORDERS --description "Sales orders"
+  vsp -s dev --enable-transports ui5 deploy ./dist --name ZORDERS --package ZFIORI --transport DEVK900123
+  vsp -s dev ui5 deploy ./dist --name ZORDERS --test`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUI5Deploy,
+}
+
+var ui5UndeployCmd = &cobra.Command{
+	Use:   "undeploy <name>",
+	Short: "Delete a BSP application deployed with ui5 deploy",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runUI5Undeploy,
+}
+
+func init() {
+	ui5DeployCmd.Flags().String("name", "", "BSP application name (max 15 characters after the namespace)")
+	ui5DeployCmd.Flags().String("package", "", "Package of a new app (default: $TMP)")
+	ui5DeployCmd.Flags().String("description", "", "App description")
+	ui5DeployCmd.Flags().String("transport", "", "Transport request for transportable packages")
+	ui5DeployCmd.Flags().Bool("test", false, "Only check the upload; nothing is changed")
+	ui5DeployCmd.Flags().Bool("json", false, "Print the result as JSON")
+	ui5DeployCmd.MarkFlagRequired("name")
+	ui5UndeployCmd.Flags().String("transport", "", "Transport request for transportable packages")
+	ui5UndeployCmd.Flags().Bool("json", false, "Print the result as JSON")
+
+	ui5Cmd.AddCommand(ui5DeployCmd, ui5UndeployCmd)
+	rootCmd.AddCommand(ui5Cmd)
+}
+
+func runUI5Deploy(cmd *cobra.Command, args []string) error {
+	client, err := ui5Client(cmd)
+	if err != nil {
+		return err
+	}
+
+	var opts adt.UI5DeployOptions
+	opts.Name, _ = cmd.Flags().GetString("name")
+	opts.Package, _ = cmd.Flags().GetString("package")
+	opts.Description, _ = cmd.Flags().GetString("description")
+	opts.Transport, _ = cmd.Flags().GetString("transport")
+	opts.TestMode, _ = cmd.Flags().GetBool("test")
+
+	result, err := client.UI5Deploy(context.Background(), args[0], opts)
+	if err != nil {
+		return err
+	}
+	return printUI5Result(cmd, result)
+}
+
+func runUI5Undeploy(cmd *cobra.Command, args []string) error {
+	client, err := ui5Client(cmd)
+	if err != nil {
+		return err
+	}
+
+	transport, _ := cmd.Flags().GetString("transport")
+	result, err := client.UI5Undeploy(context.Background(), args[0], transport)
+	if err != nil {
+		return err
+	}
+	return printUI5Result(cmd, result)
+}
+
+func ui5Client(cmd *cobra.Command) (*adt.Client, error) {
+	resolveConfig(cmd.Root())
+	if err := validateConfig(); err != nil {
+		return nil, err
+	}
+	if err := processCookieAuth(cmd.Root()); err != nil {
+		return nil, err
+	}
+	return createADTClient(), nil
+}
+
+func printUI5Result(cmd *cobra.Command, result *adt.UI5DeployResult) error {
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	for _, m := range result.Messages {
+		fmt.Printf("  %-7s %s\n", m.Severity, m.Text)
+	}
+	switch {
+	case result.TestMode:
+		fmt.Printf("Checked %s (%d files, %d bytes); nothing deployed\n", result.Name, len(result.Files), result.ZipSize)
+	case result.Action == "deleted":
+		fmt.Printf("Deleted %s from package %s\n", result.Name, result.Package)
+	default:
+		fmt.Printf("Deployed %s to package %s (%s, %d files, %d bytes): %s\n", result.Name, result.Package, result.Action, len(result.Files), result.ZipSize, result.URL)
+	}
+	return nil
+}
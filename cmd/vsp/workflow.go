@@ -42,15 +42,21 @@ var workflowRunCmd = &cobra.Command{
 }
 
 var workflowTestCmd = &cobra.Command{
-	Use:   "test <package-pattern>",
+	Use:   "test [package-pattern]",
 	Short: "Run unit tests for a package pattern",
 	Long: `Run ABAP Unit tests for all classes/programs matching a package pattern.
 
+With --affected-by or --affected-by-transport, only the tests reachable from
+the changed objects (via callers and where-used references) are run.
+Objects are given as TYPE:NAME (CLAS:ZCL_ORDER, PROG:ZREPORT, FUNC:ZFG/Z_FM).
+
 Examples:
   vsp workflow test "$TMP"
   vsp workflow test "$ZRAY*"
-  vsp workflow test "ZCL_*" --parallel 4`,
-	Args: cobra.ExactArgs(1),
+  vsp workflow test "ZCL_*" --parallel 4
+  vsp workflow test --affected-by CLAS:ZCL_ORDER,INTF:ZIF_PRICING
+  vsp workflow test --affected-by-transport DEVK900123`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runTestWorkflow,
 }
 
@@ -63,6 +69,9 @@ var (
 	testLong        bool
 	testStopOnFail  bool
 	outputJSON      bool
+	testAffectedBy  []string
+	testAffectedTR  string
+	testImpactDepth int
 )
 
 func init() {
@@ -77,6 +86,9 @@ func init() {
 	workflowTestCmd.Flags().BoolVar(&testLong, "long", false, "Include long duration tests")
 	workflowTestCmd.Flags().BoolVar(&testStopOnFail, "stop-on-fail", false, "Stop on first failure")
 	workflowTestCmd.Flags().BoolVar(&outputJSON, "json", false, "Output results as JSON")
+	workflowTestCmd.Flags().StringSliceVar(&testAffectedBy, "affected-by", nil, "Run only tests affected by these changed objects (TYPE:NAME)")
+	workflowTestCmd.Flags().StringVar(&testAffectedTR, "affected-by-transport", "", "Run only tests affected by the objects in this transport")
+	workflowTestCmd.Flags().IntVar(&testImpactDepth, "impact-depth", 3, "Caller levels to follow for --affected-by")
 
 	workflowCmd.AddCommand(workflowRunCmd)
 	workflowCmd.AddCommand(workflowTestCmd)
@@ -145,7 +157,10 @@ func runWorkflow(cmd *cobra.Command, args []string) error {
 }
 
 func runTestWorkflow(cmd *cobra.Command, args []string) error {
-	packagePattern := args[0]
+	affected := len(testAffectedBy) > 0 || testAffectedTR != ""
+	if len(args) == 0 && !affected {
+		return fmt.Errorf("package pattern or --affected-by/--affected-by-transport is required")
+	}
 
 	// Resolve configuration
 	resolveConfig(cmd.Parent().Parent())
@@ -160,19 +175,57 @@ func runTestWorkflow(cmd *cobra.Command, args []string) error {
 
 	// Create ADT client
 	client := createADTClient()
+	ctx := context.Background()
 
-	fmt.Fprintf(os.Stderr, "Discovering tests in: %s\n", packagePattern)
+	var objects []dsl.ObjectRef
+	if len(args) > 0 {
+		packagePattern := args[0]
+		fmt.Fprintf(os.Stderr, "Discovering tests in: %s\n", packagePattern)
 
-	// Search for testable objects
-	ctx := context.Background()
-	objects, err := dsl.Search(client).
-		Query(packagePattern).
-		Types(dsl.TypeClass, dsl.TypeProgram).
-		MaxResults(500).
-		Execute(ctx)
+		// Search for testable objects
+		found, err := dsl.Search(client).
+			Query(packagePattern).
+			Types(dsl.TypeClass, dsl.TypeProgram).
+			MaxResults(500).
+			Execute(ctx)
 
-	if err != nil {
-		return fmt.Errorf("search failed: %w", err)
+		if err != nil {
+			return fmt.Errorf("search failed: %w", err)
+		}
+		objects = found
+	}
+
+	var changed []dsl.ObjectRef
+	for _, s := range testAffectedBy {
+		obj, err := dsl.ParseObjectRef(s)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, obj)
+	}
+	if testAffectedTR != "" {
+		trObjects, err := dsl.ObjectsFromTransport(ctx, client, testAffectedTR)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, trObjects...)
+	}
+
+	if affected {
+		if len(changed) == 0 {
+			fmt.Println("No changed objects found")
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Analyzing impact of %d changed objects...\n", len(changed))
+		impact, err := dsl.Impact(client).MaxDepth(testImpactDepth).Analyze(ctx, changed...)
+		if err != nil {
+			return fmt.Errorf("impact analysis failed: %w", err)
+		}
+		for _, w := range impact.Warnings {
+			fmt.Fprintf(os.Stderr, "  warning: %s\n", w)
+		}
+		fmt.Fprintf(os.Stderr, "Reached %d objects, %d with tests\n", len(impact.Visited), len(impact.TestTargets))
+		objects = dsl.DedupeObjects(append(objects, impact.TestTargets...))
 	}
 
 	if len(objects) == 0 {
@@ -252,6 +305,10 @@ func createADTClient() *adt.Client {
 		opts = append(opts, adt.WithCookies(cfg.Cookies))
 	}
 
+	// Recording and load limits; conflicts were rejected by validateConfig
+	transportOpts, _ := transportOptions()
+	opts = append(opts, transportOpts...)
+
 	return adt.NewClient(cfg.BaseURL, cfg.Username, cfg.Password, opts...)
 }
 
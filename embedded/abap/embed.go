@@ -1,7 +1,10 @@
 // Package embedded provides embedded ABAP source files for ZADT_VSP deployment.
 package embedded
 
-import _ "embed"
+import (
+	_ "embed"
+	"regexp"
+)
 
 // ZADT_VSP WebSocket Handler Components
 // These files are deployed to SAP systems to enable WebSocket-based operations.
@@ -9,6 +12,9 @@ import _ "embed"
 //go:embed zif_vsp_service.intf.abap
 var ZifVspService string
 
+//go:embed zif_vsp_service_schema.intf.abap
+var ZifVspServiceSchema string
+
 //go:embed zcl_vsp_utils.clas.abap
 var ZclVspUtils string
 
@@ -30,6 +36,9 @@ var ZclVspGitService string
 //go:embed zcl_vsp_report_service.clas.abap
 var ZclVspReportService string
 
+//go:embed zcl_vsp_service_registry.clas.abap
+var ZclVspServiceRegistry string
+
 //go:embed zcl_vsp_apc_handler.clas.abap
 var ZclVspApcHandler string
 
@@ -40,6 +49,29 @@ type ObjectInfo struct {
 	Source      string // Source code
 	Description string // Human-readable description
 	Optional    bool   // If true, can be skipped (e.g., Git service without abapGit)
+	Domain      string // WebSocket domain served by the class ("system" for the handler)
+}
+
+var versionConstant = regexp.MustCompile(`(?i)CONSTANTS\s+c_version\s+TYPE\s+string\s+VALUE\s+'([^']*)'`)
+
+// Version returns the c_version constant of the source, or "" if it has none.
+func (o ObjectInfo) Version() string {
+	if m := versionConstant.FindStringSubmatch(o.Source); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// ExpectedVersions returns the embedded version of each WebSocket domain, to
+// compare with the handshake of an installed handler.
+func ExpectedVersions() map[string]string {
+	versions := map[string]string{}
+	for _, obj := range GetObjects() {
+		if obj.Domain != "" {
+			versions[obj.Domain] = obj.Version()
+		}
+	}
+	return versions
 }
 
 // GetObjects returns all ZADT_VSP objects in deployment order.
@@ -52,6 +84,13 @@ func GetObjects() []ObjectInfo {
 			Description: "Service interface for WebSocket domain handlers",
 			Optional:    false,
 		},
+		{
+			Type:        "INTF",
+			Name:        "ZIF_VSP_SERVICE_SCHEMA",
+			Source:      ZifVspServiceSchema,
+			Description: "Optional action descriptions and param schemas",
+			Optional:    false,
+		},
 		{
 			Type:        "CLAS",
 			Name:        "ZCL_VSP_UTILS",
@@ -72,6 +111,7 @@ func GetObjects() []ObjectInfo {
 			Source:      ZclVspRfcService,
 			Description: "RFC domain - function module execution",
 			Optional:    false,
+			Domain:      "rfc",
 		},
 		{
 			Type:        "CLAS",
@@ -79,6 +119,7 @@ func GetObjects() []ObjectInfo {
 			Source:      ZclVspDebugService,
 			Description: "Debug domain - TPDAPI integration",
 			Optional:    false,
+			Domain:      "debug",
 		},
 		{
 			Type:        "CLAS",
@@ -86,6 +127,7 @@ func GetObjects() []ObjectInfo {
 			Source:      ZclVspAmdpService,
 			Description: "AMDP domain - HANA debugging (experimental)",
 			Optional:    false,
+			Domain:      "amdp",
 		},
 		{
 			Type:        "CLAS",
@@ -93,6 +135,7 @@ func GetObjects() []ObjectInfo {
 			Source:      ZclVspGitService,
 			Description: "Git domain - abapGit export (requires abapGit)",
 			Optional:    true, // Requires abapGit on SAP system
+			Domain:      "git",
 		},
 		{
 			Type:        "CLAS",
@@ -100,6 +143,14 @@ func GetObjects() []ObjectInfo {
 			Source:      ZclVspReportService,
 			Description: "Report domain - background job execution with spool output",
 			Optional:    false,
+			Domain:      "report",
+		},
+		{
+			Type:        "CLAS",
+			Name:        "ZCL_VSP_SERVICE_REGISTRY",
+			Source:      ZclVspServiceRegistry,
+			Description: "Registry of domain services by domain name",
+			Optional:    false,
 		},
 		{
 			Type:        "CLAS",
@@ -107,6 +158,7 @@ func GetObjects() []ObjectInfo {
 			Source:      ZclVspApcHandler,
 			Description: "Main APC WebSocket handler (router)",
 			Optional:    false,
+			Domain:      "system",
 		},
 	}
 }
@@ -146,8 +198,9 @@ func PostDeploymentInstructions() string {
          -H "Authorization: Basic $(echo -n USER:PASS | base64)"
 
    Expected response:
-   {"id":"welcome","success":true,"data":{"session":"...","version":"2.2.0",
-    "domains":["rfc","debug","amdp","git"]}}
+   {"id":"welcome","success":true,"data":{"session":"...","version":"2.5.0",
+    "domains":["rfc","debug","amdp","git","report"],
+    "services":[{"domain":"system","version":"2.5.0","actions":[...]},...]}}
 
 4. VERIFY IN VSP
    ──────────────
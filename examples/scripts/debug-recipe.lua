@@ -0,0 +1,41 @@
+-- debug-recipe.lua
+-- Example: The debug-session.lua walkthrough, condensed with vsp.debug
+--
+-- Usage: vsp lua examples/scripts/debug-recipe.lua -- PROGRAM LINE [VARIABLE]
+
+local dbg = require("vsp.debug")
+local report = require("vsp.report")
+
+local PROGRAM = arg[1] or "ZTEST_MCP_CRUD"
+local LINE = tonumber(arg[2]) or 10
+local WATCH = arg[3]
+
+report.header("Debugging " .. PROGRAM .. " line " .. LINE)
+print("Trigger " .. PROGRAM .. " in SAP GUI or via unit test...")
+
+local trace = {}
+local _, err, stats = dbg.session(PROGRAM, LINE, function(s)
+    print("Caught " .. s.event.program .. ":" .. s.event.line)
+    report.section("Stack")
+    report.stack(getStack())
+
+    dbg.steps(20, function(frame, n)
+        local row = {step = n, program = frame.program, line = frame.line}
+        if WATCH then
+            row.value = dbg.vars({WATCH})[WATCH]
+        end
+        table.insert(trace, row)
+    end)
+end, {timeout = 120, record = "debug-recipe"})
+
+if err then
+    print("Error: " .. err)
+    return
+end
+
+report.section("Trace")
+report.table(trace, WATCH and {"step", "program", "line", "value"} or {"step", "program", "line"})
+if stats then
+    report.section("Recording")
+    report.kv(stats)
+end
@@ -0,0 +1,124 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// features.go publishes the registered tools according to feature probes.
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// featureTools lists the tools that need an optional feature. A tool may
+// need several features.
+var featureTools = map[adt.FeatureID][]string{
+	adt.FeatureRAP: {
+		"GenerateRAPStack",
+	},
+	adt.FeatureAMDP: {
+		"AMDPDebuggerStart", "AMDPDebuggerResume", "AMDPDebuggerStop",
+		"AMDPDebuggerStep", "AMDPGetVariables", "AMDPSetBreakpoint", "AMDPGetBreakpoints",
+	},
+	adt.FeatureUI5: {
+		"UI5ListApps", "UI5GetApp", "UI5GetFileContent",
+		"UI5UploadFile", "UI5DeleteFile", "UI5CreateApp", "UI5DeleteApp",
+		"UI5Deploy", "UI5Undeploy",
+	},
+	adt.FeatureTransport: {
+		"ListTransports", "GetTransport",
+		"CreateTransport", "ReleaseTransport", "DeleteTransport",
+	},
+	adt.FeatureAbapGit: {
+		"GitTypes", "GitExport",
+	},
+	adt.FeatureVSP: {
+		"GitTypes", "GitExport",
+		"RunReport", "RunReportAsync", "GetVariants", "GetTextElements", "SetTextElements",
+		"SetBreakpoint", "GetBreakpoints", "DeleteBreakpoint", "SetLogpoint",
+		"CallRFC", "CallDomain", "MoveObject",
+	},
+}
+
+// featureProbeTimeout bounds the feature probes run while publishing tools.
+const featureProbeTimeout = 30 * time.Second
+
+// addTool collects a tool during registerTools. Tools reach clients
+// through publishTools.
+func (s *Server) addTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	s.tools = append(s.tools, server.ServerTool{Tool: tool, Handler: handler})
+}
+
+// publishTools publishes the registered tools to the MCP server, leaving out
+// or annotating tools of unavailable features as configured by FeatureTools.
+// Connected clients receive notifications/tools/list_changed when the list
+// differs from the one published before. It reports whether it did.
+func (s *Server) publishTools(ctx context.Context) bool {
+	tools := s.visibleTools(ctx)
+
+	var sig strings.Builder
+	for _, t := range tools {
+		sig.WriteString(t.Tool.Name + "\x00" + t.Tool.Description + "\x00")
+	}
+
+	s.toolsMu.Lock()
+	defer s.toolsMu.Unlock()
+	if sig.String() == s.publishedTools {
+		return false
+	}
+	s.publishedTools = sig.String()
+	s.mcpServer.SetTools(tools...)
+	return true
+}
+
+// refreshTools probes features again, e.g. after installing ZADT_VSP, and
+// publishes the tools that became available or unavailable. Without ids,
+// all features are probed again.
+func (s *Server) refreshTools(ctx context.Context, ids ...adt.FeatureID) bool {
+	s.featureProber.Invalidate(ids...)
+	return s.publishTools(ctx)
+}
+
+// visibleTools returns the registered tools as clients should see them.
+// Tools enabled explicitly in .vsp.json are always shown as registered.
+func (s *Server) visibleTools(ctx context.Context) []server.ServerTool {
+	mode := strings.ToLower(s.config.FeatureTools)
+	if mode != "hide" && mode != "annotate" {
+		return s.tools
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, featureProbeTimeout)
+	defer cancel()
+	unavailable := make(map[string]string) // tool -> reason
+	for _, id := range adt.AllFeatures {
+		names := featureTools[id]
+		if len(names) == 0 {
+			continue
+		}
+		status := s.featureProber.Probe(ctx, id)
+		if status.Available {
+			continue
+		}
+		for _, name := range names {
+			if _, seen := unavailable[name]; !seen {
+				unavailable[name] = fmt.Sprintf("%s: %s", id, status.Message)
+			}
+		}
+	}
+
+	tools := make([]server.ServerTool, 0, len(s.tools))
+	for _, t := range s.tools {
+		reason, ok := unavailable[t.Tool.Name]
+		switch {
+		case !ok || s.config.ToolsConfig[t.Tool.Name]:
+			tools = append(tools, t)
+		case mode == "annotate":
+			t.Tool.Description = fmt.Sprintf("[Unavailable on this system - %s] %s", reason, t.Tool.Description)
+			tools = append(tools, t)
+		}
+	}
+	return tools
+}
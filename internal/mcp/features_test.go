@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
+)
+
+// listTools returns the published tools by name, as a client sees them.
+func listTools(t *testing.T, s *Server) map[string]mcp.Tool {
+	t.Helper()
+	resp := s.mcpServer.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Result mcp.ListToolsResult `json:"result"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	tools := make(map[string]mcp.Tool)
+	for _, tool := range out.Result.Tools {
+		tools[tool.Name] = tool
+	}
+	return tools
+}
+
+type testSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return "test" }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// withFeatures configures the feature flags of newMockMCPServer.
+func withFeatures(featureTools string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.FeatureTools = featureTools
+		cfg.FeatureHANA = "on"
+		cfg.FeatureAbapGit = "on"
+		cfg.FeatureRAP = "on"
+		cfg.FeatureAMDP = "off"
+		cfg.FeatureUI5 = "off"
+		cfg.FeatureTransport = "on"
+		cfg.FeatureVSP = "auto"
+	}
+}
+
+func TestFeatureToolsHide(t *testing.T) {
+	sap, s := newMockMCPServer(t, withFeatures("hide"))
+
+	tools := listTools(t, s)
+	for name, want := range map[string]bool{
+		"GetSource":         true,
+		"ListTransports":    true,
+		"GetFeatures":       true,
+		"UI5ListApps":       false, // UI5 off
+		"AMDPDebuggerStart": false, // AMDP off
+		"RunReport":         false, // ZADT_VSP not installed
+		"GitExport":         false,
+	} {
+		if _, ok := tools[name]; ok != want {
+			t.Errorf("%s published = %v, want %v", name, ok, want)
+		}
+	}
+
+	// Installing ZADT_VSP makes its tools available to connected clients
+	session := &testSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if err := s.mcpServer.RegisterSession(session); err != nil {
+		t.Fatal(err)
+	}
+	sap.AddObject(adttest.Object{Type: "CLAS/OC", Name: "ZCL_VSP_APC_HANDLER", Package: "$ZADT_VSP"})
+	if !s.refreshTools(context.Background(), adt.FeatureVSP) {
+		t.Fatal("refreshTools reported no change")
+	}
+	tools = listTools(t, s)
+	if _, ok := tools["RunReport"]; !ok {
+		t.Error("RunReport not published after ZADT_VSP was installed")
+	}
+	if _, ok := tools["GitExport"]; !ok {
+		t.Error("GitExport not published after ZADT_VSP was installed")
+	}
+	if n := len(session.notifications); n != 1 {
+		t.Fatalf("%d notifications, want 1", n)
+	}
+	if note := <-session.notifications; note.Method != "notifications/tools/list_changed" {
+		t.Errorf("notification %s", note.Method)
+	}
+
+	if s.refreshTools(context.Background()) {
+		t.Error("unchanged tools were published again")
+	}
+	if n := len(session.notifications); n != 0 {
+		t.Errorf("%d notifications for unchanged tools", n)
+	}
+}
+
+func TestFeatureToolsAnnotate(t *testing.T) {
+	_, s := newMockMCPServer(t, withFeatures("annotate"))
+	tools := listTools(t, s)
+	if ui5, ok := tools["UI5ListApps"]; !ok || !strings.HasPrefix(ui5.Description, "[Unavailable on this system - ui5: forced disabled]") {
+		t.Errorf("UI5ListApps = %+v", ui5)
+	}
+	if src := tools["GetSource"]; strings.HasPrefix(src.Description, "[Unavailable") {
+		t.Errorf("GetSource annotated: %s", src.Description)
+	}
+
+	// Without FeatureTools, all tools are published as registered
+	_, s = newMockMCPServer(t, withFeatures(""))
+	tools = listTools(t, s)
+	if ui5, ok := tools["UI5ListApps"]; !ok || strings.HasPrefix(ui5.Description, "[Unavailable") {
+		t.Errorf("UI5ListApps = %+v", ui5)
+	}
+}
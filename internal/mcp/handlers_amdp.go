@@ -27,6 +27,8 @@ func (s *Server) handleAMDPDebuggerStart(ctx context.Context, request mcp.CallTo
 		s.config.Password,
 		s.config.InsecureSkipVerify,
 	)
+	s.amdpWSClient.UseAuth(s.adtClient.Config())
+	s.amdpWSClient.UseSafety(s.adtClient.Config().Safety)
 
 	// Connect to ZADT_VSP WebSocket
 	if err := s.amdpWSClient.Connect(ctx); err != nil {
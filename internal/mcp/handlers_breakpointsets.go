@@ -0,0 +1,188 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// handlers_breakpointsets.go contains handlers for named breakpoint sets.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// breakpointSetter returns where breakpoint sets are enabled: the ZADT_VSP
+// session (default) or external breakpoints via ADT REST. The returned system
+// key distinguishes the two, as session breakpoint IDs end with the session.
+func (s *Server) breakpointSetter(ctx context.Context, external bool) (adt.BreakpointSetter, string, error) {
+	if external {
+		return &adt.MethodBreakpoints{BreakpointSetter: &adt.ExternalBreakpoints{Client: s.adtClient, User: s.config.Username}, Client: s.adtClient}, s.config.BaseURL, nil
+	}
+	if err := s.ensureDebugWSClient(ctx); err != nil {
+		return nil, "", fmt.Errorf("failed to connect to ZADT_VSP WebSocket: %v. Ensure ZADT_VSP is deployed and SAPC/SICF are configured, or use external=true", err)
+	}
+	return &adt.MethodBreakpoints{BreakpointSetter: s.debugWSClient, Client: s.adtClient}, s.config.BaseURL + " (ZADT_VSP session)", nil
+}
+
+func (s *Server) handleSaveBreakpointSet(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, _ := request.Params.Arguments["name"].(string)
+	if name == "" {
+		return newToolResultError("name is required"), nil
+	}
+	description, _ := request.Params.Arguments["description"].(string)
+	breakpointsJSON, _ := request.Params.Arguments["breakpoints"].(string)
+	fromSystem, _ := request.Params.Arguments["from_system"].(bool)
+	appendToSet, _ := request.Params.Arguments["append"].(bool)
+
+	var breakpoints []adt.SavedBreakpoint
+	if breakpointsJSON != "" {
+		if err := json.Unmarshal([]byte(breakpointsJSON), &breakpoints); err != nil {
+			return newToolResultError(fmt.Sprintf("invalid breakpoints JSON: %v", err)), nil
+		}
+	}
+	if fromSystem {
+		resp, err := s.adtClient.GetExternalBreakpoints(ctx, s.config.Username)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Failed to read external breakpoints: %v", err)), nil
+		}
+		for _, b := range resp.Breakpoints {
+			breakpoints = append(breakpoints, adt.SavedBreakpointFromExternal(b))
+		}
+	}
+	if len(breakpoints) == 0 {
+		return newToolResultError("no breakpoints given: pass breakpoints (JSON array) or from_system=true"), nil
+	}
+	for i := range breakpoints {
+		switch breakpoints[i].Kind {
+		case adt.BreakpointKindLine, adt.BreakpointKindMethod:
+			breakpoints[i].Program = convertToClassPool(breakpoints[i].Program)
+		}
+	}
+
+	store, err := adt.LoadBreakpointSets("")
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+	set := &adt.BreakpointSet{Name: name, Description: description}
+	if old, ok := store.Sets[name]; ok {
+		set.Enabled = old.Enabled
+		if appendToSet {
+			set.Breakpoints = old.Breakpoints
+			if description == "" {
+				set.Description = old.Description
+			}
+		}
+	}
+	set.Breakpoints = append(set.Breakpoints, breakpoints...)
+	if err := store.Put(set); err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+	if err := store.Save(); err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Saved breakpoint set '%s' (%d breakpoints) to %s\n\n", set.Name, len(set.Breakpoints), store.Path())
+	for i, bp := range set.Breakpoints {
+		fmt.Fprintf(&sb, "  [%d] %s\n", i+1, bp)
+	}
+	sb.WriteString("\nEnable it on a system with EnableBreakpointSet.\n")
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (s *Server) handleListBreakpointSets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	store, err := adt.LoadBreakpointSets("")
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+
+	names := store.Names()
+	if name, _ := request.Params.Arguments["name"].(string); name != "" {
+		if _, err := store.Get(name); err != nil {
+			return newToolResultError(err.Error()), nil
+		}
+		names = []string{name}
+	}
+	if len(names) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No breakpoint sets in %s. Use SaveBreakpointSet to create one.", store.Path())), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Breakpoint sets (%s):\n", store.Path())
+	for _, name := range names {
+		set := store.Sets[name]
+		fmt.Fprintf(&sb, "\n%s (%d breakpoints)", set.Name, len(set.Breakpoints))
+		if set.Description != "" {
+			fmt.Fprintf(&sb, " - %s", set.Description)
+		}
+		sb.WriteString("\n")
+		for i, bp := range set.Breakpoints {
+			fmt.Fprintf(&sb, "  [%d] %s\n", i+1, bp)
+		}
+		for system, ids := range set.Enabled {
+			fmt.Fprintf(&sb, "  enabled on %s: %s\n", system, strings.Join(ids, ", "))
+		}
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (s *Server) handleEnableBreakpointSet(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.applyBreakpointSet(ctx, request, true)
+}
+
+func (s *Server) handleDisableBreakpointSet(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.applyBreakpointSet(ctx, request, false)
+}
+
+// applyBreakpointSet enables or disables a breakpoint set on the connected system.
+func (s *Server) applyBreakpointSet(ctx context.Context, request mcp.CallToolRequest, enable bool) (*mcp.CallToolResult, error) {
+	name, _ := request.Params.Arguments["name"].(string)
+	if name == "" {
+		return newToolResultError("name is required"), nil
+	}
+	external, _ := request.Params.Arguments["external"].(bool)
+
+	store, err := adt.LoadBreakpointSets("")
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+	set, err := store.Get(name)
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+
+	setter, system, err := s.breakpointSetter(ctx, external)
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+
+	var result *adt.BreakpointSetResult
+	action := "Enabled"
+	if enable {
+		result = adt.EnableBreakpointSet(ctx, setter, set, system)
+	} else {
+		action = "Disabled"
+		result = adt.DisableBreakpointSet(ctx, setter, set, system)
+	}
+	if err := store.Save(); err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s breakpoint set '%s' on %s\n\n", action, set.Name, system)
+	fmt.Fprintf(&sb, "Breakpoints: %d\n", len(result.IDs))
+	if len(result.IDs) > 0 {
+		fmt.Fprintf(&sb, "IDs: %s\n", strings.Join(result.IDs, ", "))
+	}
+	if len(result.Failed) > 0 {
+		fmt.Fprintf(&sb, "\nFailed (%d):\n", len(result.Failed))
+		for _, f := range result.Failed {
+			fmt.Fprintf(&sb, "  %s\n", f)
+		}
+	}
+	if enable && len(result.IDs) > 0 {
+		sb.WriteString("\nUse DebuggerListen to wait for a debuggee.\n")
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
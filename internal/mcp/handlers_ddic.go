@@ -0,0 +1,248 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// handlers_ddic.go contains handlers for dictionary objects with JSON definitions.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// --- DDIC Handlers ---
+
+func (s *Server) handleGetDDIC(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	typeStr, _ := request.Params.Arguments["type"].(string)
+	name, _ := request.Params.Arguments["name"].(string)
+	if typeStr == "" || name == "" {
+		return newToolResultError("type and name are required"), nil
+	}
+	kind, err := adt.ParseDDICKind(typeStr)
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+
+	def, err := s.getDDIC(ctx, kind, strings.ToUpper(name))
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("GetDDIC failed: %v", err)), nil
+	}
+	output, _ := json.MarshalIndent(def, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+func (s *Server) handleWriteDDIC(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	typeStr, _ := request.Params.Arguments["type"].(string)
+	if typeStr == "" {
+		return newToolResultError("type is required"), nil
+	}
+	kind, err := adt.ParseDDICKind(typeStr)
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+	def, err := objectArg(request.Params.Arguments["definition"], "definition")
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+	if def == nil {
+		def = map[string]any{}
+	}
+
+	// Explicit params override the definition
+	for _, key := range []string{"name", "package", "transport"} {
+		if v, ok := request.Params.Arguments[key].(string); ok && v != "" {
+			def[key] = v
+		}
+	}
+	name, _ := def["name"].(string)
+	name = strings.ToUpper(name)
+	if name == "" {
+		return newToolResultError("name is required (as param or in definition)"), nil
+	}
+	def["name"] = name
+
+	mode := "upsert"
+	if m, ok := request.Params.Arguments["mode"].(string); ok && m != "" {
+		mode = strings.ToLower(m)
+	}
+	objectURL := adt.DDICObjectURL(kind, name)
+	exists, err := s.adtClient.ObjectExists(ctx, objectURL)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("WriteDDIC failed: %v", err)), nil
+	}
+	switch {
+	case mode != "upsert" && mode != "create" && mode != "update":
+		return newToolResultError(fmt.Sprintf("invalid mode %q (use upsert, create or update)", mode)), nil
+	case mode == "create" && exists:
+		return newToolResultError(fmt.Sprintf("%s %s already exists (use mode=update)", kind, name)), nil
+	case mode == "update" && !exists:
+		return newToolResultError(fmt.Sprintf("%s %s does not exist (use mode=create)", kind, name)), nil
+	}
+
+	if exists {
+		err = s.updateDDIC(ctx, kind, name, def)
+	} else {
+		if _, ok := def["package"]; !ok {
+			def["package"] = "$TMP"
+		}
+		err = s.createDDIC(ctx, kind, def)
+	}
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("WriteDDIC failed: %v", err)), nil
+	}
+
+	result := map[string]interface{}{
+		"type":   string(kind),
+		"name":   name,
+		"status": "created",
+		"uri":    objectURL,
+	}
+	if exists {
+		result["status"] = "updated"
+	}
+	activate := true
+	if a, ok := request.Params.Arguments["activate"].(bool); ok {
+		activate = a
+	}
+	if activate {
+		res, err := s.adtClient.Activate(ctx, objectURL, name)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("%s %s %s but activation failed: %v", kind, name, result["status"], err)), nil
+		}
+		result["activated"] = res.Success
+		if len(res.Messages) > 0 {
+			result["messages"] = res.Messages
+		}
+	}
+	output, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+func (s *Server) handleDeleteDDIC(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	typeStr, _ := request.Params.Arguments["type"].(string)
+	name, _ := request.Params.Arguments["name"].(string)
+	if typeStr == "" || name == "" {
+		return newToolResultError("type and name are required"), nil
+	}
+	kind, err := adt.ParseDDICKind(typeStr)
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+	transport, _ := request.Params.Arguments["transport"].(string)
+
+	name = strings.ToUpper(name)
+	if err := s.adtClient.DeleteDDICObject(ctx, kind, name, transport); err != nil {
+		return newToolResultError(fmt.Sprintf("DeleteDDIC failed: %v", err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Deleted %s %s", kind, name)), nil
+}
+
+// getDDIC reads the definition of a dictionary object.
+func (s *Server) getDDIC(ctx context.Context, kind adt.DDICKind, name string) (any, error) {
+	switch kind {
+	case adt.DDICDomain:
+		return s.adtClient.GetDomain(ctx, name)
+	case adt.DDICDataElement:
+		return s.adtClient.GetDataElement(ctx, name)
+	case adt.DDICStructure:
+		return s.adtClient.GetStructureDefinition(ctx, name)
+	case adt.DDICTableType:
+		return s.adtClient.GetTableType(ctx, name)
+	case adt.DDICSearchHelp:
+		return s.adtClient.GetSearchHelp(ctx, name)
+	}
+	return nil, fmt.Errorf("unsupported DDIC type %s", kind)
+}
+
+// createDDIC creates a dictionary object from its JSON definition.
+func (s *Server) createDDIC(ctx context.Context, kind adt.DDICKind, def map[string]any) error {
+	switch kind {
+	case adt.DDICDomain:
+		return withDefinition(def, func(o adt.DomainOptions) error { return s.adtClient.CreateDomain(ctx, o) })
+	case adt.DDICDataElement:
+		return withDefinition(def, func(o adt.DataElementOptions) error { return s.adtClient.CreateDataElement(ctx, o) })
+	case adt.DDICStructure:
+		return withDefinition(def, func(o adt.StructureOptions) error { return s.adtClient.CreateStructure(ctx, o) })
+	case adt.DDICTableType:
+		return withDefinition(def, func(o adt.TableTypeOptions) error { return s.adtClient.CreateTableType(ctx, o) })
+	case adt.DDICSearchHelp:
+		return withDefinition(def, func(o adt.SearchHelpOptions) error { return s.adtClient.CreateSearchHelp(ctx, o) })
+	}
+	return fmt.Errorf("unsupported DDIC type %s", kind)
+}
+
+// updateDDIC applies a partial JSON definition to the current definition of
+// a dictionary object. Top-level properties of def replace the current ones.
+func (s *Server) updateDDIC(ctx context.Context, kind adt.DDICKind, name string, def map[string]any) error {
+	current, err := s.getDDIC(ctx, kind, name)
+	if err != nil {
+		return err
+	}
+	merged, err := toJSONMap(current)
+	if err != nil {
+		return err
+	}
+	for k, v := range def {
+		merged[k] = v
+	}
+	// New fields replace the current source of a structure
+	if _, ok := def["fields"]; ok {
+		if _, ok := def["source"]; !ok {
+			delete(merged, "source")
+		}
+	}
+
+	switch kind {
+	case adt.DDICDomain:
+		return withDefinition(merged, func(o adt.DomainOptions) error { return s.adtClient.UpdateDomain(ctx, o) })
+	case adt.DDICDataElement:
+		return withDefinition(merged, func(o adt.DataElementOptions) error {
+			// A built-in type replaces the domain and vice versa
+			if _, ok := def["dataType"]; ok {
+				if _, ok := def["domain"]; !ok {
+					o.Domain = ""
+				}
+			}
+			return s.adtClient.UpdateDataElement(ctx, o)
+		})
+	case adt.DDICStructure:
+		return withDefinition(merged, func(o adt.StructureOptions) error { return s.adtClient.UpdateStructure(ctx, o) })
+	case adt.DDICTableType:
+		return withDefinition(merged, func(o adt.TableTypeOptions) error {
+			if _, ok := def["dataType"]; ok {
+				if _, ok := def["rowType"]; !ok {
+					o.RowType, o.RowTypeKind = "", ""
+				}
+			}
+			return s.adtClient.UpdateTableType(ctx, o)
+		})
+	case adt.DDICSearchHelp:
+		return withDefinition(merged, func(o adt.SearchHelpOptions) error { return s.adtClient.UpdateSearchHelp(ctx, o) })
+	}
+	return fmt.Errorf("unsupported DDIC type %s", kind)
+}
+
+// withDefinition decodes a JSON definition into options and passes them to fn.
+func withDefinition[T any](def map[string]any, fn func(T) error) error {
+	data, err := json.Marshal(def)
+	if err != nil {
+		return err
+	}
+	var opts T
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return fmt.Errorf("invalid definition: %v", err)
+	}
+	return fn(opts)
+}
+
+func toJSONMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -29,6 +30,8 @@ func (s *Server) ensureDebugWSClient(ctx context.Context) error {
 		s.config.Password,
 		s.config.InsecureSkipVerify,
 	)
+	s.debugWSClient.UseAuth(s.adtClient.Config())
+	s.debugWSClient.UseSafety(s.adtClient.Config().Safety)
 
 	return s.debugWSClient.Connect(ctx)
 }
@@ -56,24 +59,31 @@ func (s *Server) handleSetBreakpoint(ctx context.Context, request mcp.CallToolRe
 			return newToolResultError("program is required for line breakpoints"), nil
 		}
 
+		// Optional method parameter for method-relative line numbers
+		method, _ := request.Params.Arguments["method"].(string)
+
 		lineFloat, ok := request.Params.Arguments["line"].(float64)
-		if !ok || lineFloat <= 0 {
+		if method == "" && (!ok || lineFloat <= 0) {
 			return newToolResultError("line is required and must be positive for line breakpoints"), nil
 		}
 		line := int(lineFloat)
 
-		// Optional method parameter for include-relative line numbers
-		method, _ := request.Params.Arguments["method"].(string)
-
-		// Auto-convert class names to pool format (ZCL_TEST → ZCL_TEST================CP)
+		// Auto-convert class names to pool format (ZCL_TEST → ZCL_TEST======================CP)
 		originalProgram := program
 		program = convertToClassPool(program)
 
-		// Use method-aware breakpoint if method is specified
+		// ZADT_VSP only takes pool-absolute lines: resolve the method line first
 		if method != "" {
-			bpID, err = s.debugWSClient.SetMethodBreakpoint(ctx, program, method, line)
+			poolLine, err := s.adtClient.MethodBreakpointLine(ctx, program, method, line)
 			if err != nil {
-				return newToolResultError(fmt.Sprintf("SetMethodBreakpoint failed: %v", err)), nil
+				return newToolResultError(fmt.Sprintf("Resolving method line failed: %v", err)), nil
+			}
+			bpID, err = s.debugWSClient.SetLineBreakpoint(ctx, program, poolLine)
+			if err != nil {
+				return newToolResultError(fmt.Sprintf("SetLineBreakpoint failed: %v", err)), nil
+			}
+			if line <= 0 {
+				line = 1
 			}
 
 			msg.WriteString("Method breakpoint set successfully!\n\n")
@@ -84,8 +94,7 @@ func (s *Server) handleSetBreakpoint(ctx context.Context, request mcp.CallToolRe
 				fmt.Fprintf(&msg, "Program: %s\n", program)
 			}
 			fmt.Fprintf(&msg, "Method: %s\n", method)
-			fmt.Fprintf(&msg, "Line: %d (relative to method start)\n", line)
-			msg.WriteString("\nℹ️  Line number is relative to the METHOD implementation, not the full class.\n")
+			fmt.Fprintf(&msg, "Line: %d of the method (pool-absolute line %d)\n", line, poolLine)
 		} else {
 			bpID, err = s.debugWSClient.SetLineBreakpoint(ctx, program, line)
 			if err != nil {
@@ -146,15 +155,10 @@ func (s *Server) handleSetBreakpoint(ctx context.Context, request mcp.CallToolRe
 }
 
 // convertToClassPool converts class/interface names to pool format for debugging.
-// Example: ZCL_TEST → ZCL_TEST================CP (padded to 30 chars + CP suffix)
+// Example: ZCL_TEST → ZCL_TEST======================CP (see adt.ClassPoolName)
 func convertToClassPool(program string) string {
 	program = strings.ToUpper(program)
 
-	// Already in pool format
-	if strings.HasSuffix(program, "CP") && strings.Contains(program, "=") {
-		return program
-	}
-
 	// Check if it looks like a class or interface name
 	isClass := strings.HasPrefix(program, "ZCL_") ||
 		strings.HasPrefix(program, "YCL_") ||
@@ -169,14 +173,7 @@ func convertToClassPool(program string) string {
 		return program
 	}
 
-	// Pad to 30 chars with '=' and add 'CP' suffix
-	// Total length: 30 + 2 = 32 (standard ABAP class pool naming)
-	if len(program) < 30 {
-		padding := 30 - len(program)
-		program = program + strings.Repeat("=", padding) + "CP"
-	}
-
-	return program
+	return adt.ClassPoolName(program)
 }
 
 func (s *Server) handleGetBreakpoints(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -189,11 +186,24 @@ func (s *Server) handleGetBreakpoints(ctx context.Context, request mcp.CallToolR
 		return newToolResultError(fmt.Sprintf("GetBreakpoints failed: %v", err)), nil
 	}
 
-	if len(breakpoints) == 0 {
+	lost := s.debugWSClient.LostBreakpoints()
+	if len(breakpoints) == 0 && len(lost) == 0 {
 		return mcp.NewToolResultText("No breakpoints are currently set."), nil
 	}
 
 	var sb strings.Builder
+	if len(lost) > 0 {
+		ids := make([]string, 0, len(lost))
+		for id := range lost {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		fmt.Fprintf(&sb, "Lost after reconnect (%d), set them again:\n", len(ids))
+		for _, id := range ids {
+			fmt.Fprintf(&sb, "- %s: %v\n", id, lost[id])
+		}
+		sb.WriteString("\n")
+	}
 	fmt.Fprintf(&sb, "Active Breakpoints (%d):\n\n", len(breakpoints))
 	for i, bp := range breakpoints {
 		fmt.Fprintf(&sb, "%d. ID: %v\n", i+1, bp["id"])
@@ -226,9 +236,91 @@ func (s *Server) handleDeleteBreakpoint(ctx context.Context, request mcp.CallToo
 		return newToolResultError(fmt.Sprintf("DeleteBreakpoint failed: %v", err)), nil
 	}
 
+	if s.logpoints.Remove(bpID) {
+		return mcp.NewToolResultText(fmt.Sprintf("Logpoint %s deleted successfully.", bpID)), nil
+	}
 	return mcp.NewToolResultText(fmt.Sprintf("Breakpoint %s deleted successfully.", bpID)), nil
 }
 
+func (s *Server) handleSetLogpoint(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	program, _ := request.Params.Arguments["program"].(string)
+	message, _ := request.Params.Arguments["message"].(string)
+	lineFloat, _ := request.Params.Arguments["line"].(float64)
+	if program == "" || message == "" || lineFloat <= 0 {
+		return newToolResultError("program, line and message are required"), nil
+	}
+	line := int(lineFloat)
+	program = convertToClassPool(program)
+
+	if err := s.ensureDebugWSClient(ctx); err != nil {
+		return newToolResultError(fmt.Sprintf("Failed to connect to ZADT_VSP WebSocket: %v. Ensure ZADT_VSP is deployed and SAPC/SICF are configured.", err)), nil
+	}
+
+	bpID, err := s.debugWSClient.SetLineBreakpoint(ctx, program, line)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("SetLogpoint failed: %v", err)), nil
+	}
+	s.logpoints.Add(adt.Logpoint{ID: bpID, Program: program, Line: line, Message: message})
+
+	var sb strings.Builder
+	sb.WriteString("Logpoint set successfully!\n\n")
+	fmt.Fprintf(&sb, "Breakpoint ID: %s\n", bpID)
+	fmt.Fprintf(&sb, "Location: %s:%d\n", program, line)
+	fmt.Fprintf(&sb, "Message: %s\n", message)
+	if vars := adt.LogTemplateVariables(message); len(vars) > 0 {
+		fmt.Fprintf(&sb, "Variables: %s\n", strings.Join(vars, ", "))
+	}
+	sb.WriteString("\nUse DebuggerListen + DebuggerAttach as usual; stops at this logpoint are logged and continued automatically. Read the log with GetLogpointLog.")
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (s *Server) handleGetLogpointLog(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	points := s.logpoints.List()
+	hits := s.logpoints.Hits()
+	if clearLog, _ := request.Params.Arguments["clear"].(bool); clearLog {
+		s.logpoints.ClearHits()
+	}
+
+	if len(points) == 0 && len(hits) == 0 {
+		return mcp.NewToolResultText("No logpoints set. Use SetLogpoint to add one."), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Logpoints (%d):\n", len(points))
+	for _, lp := range points {
+		fmt.Fprintf(&sb, "  %s  %s:%d  %q  (%d hits)\n", lp.ID, lp.Program, lp.Line, lp.Message, lp.Hits)
+	}
+
+	fmt.Fprintf(&sb, "\nLog (%d messages):\n", len(hits))
+	for _, hit := range hits {
+		fmt.Fprintf(&sb, "  %s  %s  %s\n", hit.Time.Format("15:04:05.000"), formatLocation(hit.Location), hit.Message)
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// appendLogpointHits continues through logpoints at the current stop and notes
+// the logged messages in sb. It reports whether the debuggee is still stopped.
+func (s *Server) appendLogpointHits(ctx context.Context, sb *strings.Builder, reached []adt.DebugReachedBreakpoint) bool {
+	if s.logpoints.Len() == 0 {
+		return true
+	}
+
+	hits, stopped, err := s.logpoints.ContinueThrough(ctx, s.adtClient, reached)
+	if len(hits) > 0 {
+		fmt.Fprintf(sb, "\nLogpoints (%d messages):\n", len(hits))
+		for _, hit := range hits {
+			fmt.Fprintf(sb, "  %s  %s\n", formatLocation(hit.Location), hit.Message)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(sb, "\nLogpoint evaluation stopped: %v\n", err)
+	}
+	if !stopped {
+		sb.WriteString("\nDebuggee finished after logpoints - no halting breakpoint was reached.\n")
+	}
+	return stopped
+}
+
 func (s *Server) handleCallRFC(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	function, ok := request.Params.Arguments["function"].(string)
 	if !ok || function == "" {
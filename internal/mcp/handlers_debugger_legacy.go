@@ -101,6 +101,10 @@ func (s *Server) handleDebuggerAttach(ctx context.Context, request mcp.CallToolR
 		}
 	}
 
+	if s.appendLogpointHits(ctx, &sb, result.ReachedBreakpoints) {
+		s.appendRecordedStep(ctx, &sb, "entry")
+	}
+
 	sb.WriteString("\nUse DebuggerGetStack to see the call stack, DebuggerGetVariables to inspect variables.")
 	return mcp.NewToolResultText(sb.String()), nil
 }
@@ -159,6 +163,14 @@ func (s *Server) handleDebuggerStep(ctx context.Context, request mcp.CallToolReq
 		}
 	}
 
+	stopped := result.IsSteppingPossible
+	if stopped && stepType == adt.DebugStepContinue {
+		stopped = s.appendLogpointHits(ctx, &sb, result.ReachedBreakpoints)
+	}
+	if stopped {
+		s.appendRecordedStep(ctx, &sb, stepTypeStr)
+	}
+
 	sb.WriteString("\nUse DebuggerGetStack to see current position, DebuggerGetVariables to inspect variables.")
 	return mcp.NewToolResultText(sb.String()), nil
 }
@@ -0,0 +1,193 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// handlers_domain.go contains the generic handler for ZADT_VSP domains.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// --- Domain Handlers ---
+
+func (s *Server) handleCallDomain(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	domain, _ := request.Params.Arguments["domain"].(string)
+	action, _ := request.Params.Arguments["action"].(string)
+
+	if errResult := s.ensureWSConnected(ctx, "CallDomain"); errResult != nil {
+		return errResult, nil
+	}
+
+	// Without a domain, list what the handler serves
+	if domain == "" {
+		return s.listDomains(), nil
+	}
+
+	d := s.amdpWSClient.Domain(domain)
+	if timeout, ok := request.Params.Arguments["timeout"].(float64); ok && timeout > 0 {
+		d = d.WithTimeout(time.Duration(timeout) * time.Second)
+	}
+
+	info, err := d.Describe(ctx)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("CallDomain: %v", err)), nil
+	}
+
+	// Without an action, describe the domain
+	if action == "" {
+		out, _ := json.MarshalIndent(info, "", "  ")
+		return mcp.NewToolResultText(string(out)), nil
+	}
+
+	a := info.Action(action)
+	if a == nil {
+		names := make([]string, len(info.Actions))
+		for i, a := range info.Actions {
+			names[i] = a.Name
+		}
+		return newToolResultError(fmt.Sprintf("CallDomain: domain %s has no action %s (available: %s)", domain, action, strings.Join(names, ", "))), nil
+	}
+
+	if err := s.checkDomainAction(domain, action); err != nil {
+		return newToolResultError(fmt.Sprintf("CallDomain: %v", err)), nil
+	}
+
+	params, err := objectArg(request.Params.Arguments["params"], "params")
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("CallDomain: %v", err)), nil
+	}
+	if len(a.Schema) > 0 {
+		if err := adt.ValidateSchema(a.Schema, params); err != nil {
+			return newToolResultError(fmt.Sprintf("CallDomain: invalid params for %s/%s: %v\n\nSchema:\n%s", domain, action, err, a.Schema)), nil
+		}
+	}
+
+	data, err := d.CallRaw(ctx, action, params)
+	if err != nil {
+		var derr *adt.DomainError
+		if errors.As(err, &derr) {
+			return newToolResultError(fmt.Sprintf("CallDomain %s/%s failed: %s: %s", domain, action, derr.Code, derr.Message)), nil
+		}
+		return newToolResultError(fmt.Sprintf("CallDomain %s/%s failed: %v", domain, action, err)), nil
+	}
+	if len(data) == 0 {
+		return mcp.NewToolResultText("{}"), nil
+	}
+	var pretty any
+	if err := json.Unmarshal(data, &pretty); err != nil {
+		return mcp.NewToolResultText(string(data)), nil
+	}
+	out, _ := json.MarshalIndent(pretty, "", "  ")
+	return mcp.NewToolResultText(string(out)), nil
+}
+
+// domainActionOps maps built-in domain actions, by "domain/action" or by
+// domain, to the operation type of their dedicated tool. Actions of custom
+// domains have unknown side effects and count as workflows.
+var domainActionOps = map[string]adt.OperationType{
+	"rfc/call":          adt.OpWorkflow,
+	"rfc/search":        adt.OpSearch,
+	"rfc/getMetadata":   adt.OpRead,
+	"rfc/ping":          adt.OpRead,
+	"rfc/moveToPackage": adt.OpUpdate,
+	"rfc/runReport":     adt.OpWorkflow,
+
+	"debug": adt.OpDebug,
+	"amdp":  adt.OpDebug,
+
+	"git/getTypes": adt.OpRead,
+	"git/export":   adt.OpRead,
+	"git/validate": adt.OpRead,
+	"git/import":   adt.OpCreate,
+
+	"report/runReport":       adt.OpWorkflow,
+	"report/getTextElements": adt.OpRead,
+	"report/getVariants":     adt.OpRead,
+	"report/setTextElements": adt.OpUpdate,
+}
+
+// domainActionTools maps built-in domain actions, by "domain/action" or by
+// domain, to the tools that wrap them. CallDomain refuses an action when any
+// of its tools is disabled, so that disabling a tool group also closes the
+// raw path to the same functionality.
+var domainActionTools = map[string][]string{
+	"rfc/call":          {"CallRFC"},
+	"rfc/moveToPackage": {"MoveObject"},
+	"rfc/runReport":     {"RunReport"},
+
+	"debug": {"SetBreakpoint", "DebuggerListen"},
+	"amdp":  {"AMDPDebuggerStart"},
+	"git":   {"GitTypes", "GitExport"},
+
+	"report/runReport":       {"RunReport"},
+	"report/getTextElements": {"GetTextElements"},
+	"report/setTextElements": {"SetTextElements"},
+	"report/getVariants":     {"GetVariants"},
+}
+
+// checkDomainAction applies the tool groups and the safety configuration to
+// a domain action before it is sent.
+func (s *Server) checkDomainAction(domain, action string) error {
+	domain = strings.ToLower(domain)
+	key := domain + "/" + action
+	tools, ok := domainActionTools[key]
+	if !ok {
+		tools = domainActionTools[domain]
+	}
+	for _, tool := range tools {
+		if s.disabledTools[tool] {
+			return fmt.Errorf("%s is not available: tool %s is disabled", key, tool)
+		}
+	}
+
+	op, ok := domainActionOps[key]
+	if !ok {
+		if op, ok = domainActionOps[domain]; !ok {
+			op = adt.OpWorkflow
+		}
+	}
+	return s.adtClient.Safety().CheckOperation(op, "CallDomain "+key)
+}
+
+// listDomains returns the domains of the connected handler.
+func (s *Server) listDomains() *mcp.CallToolResult {
+	h := s.amdpWSClient.Handshake()
+	if h == nil {
+		return newToolResultError("CallDomain: no handshake received from ZADT_VSP")
+	}
+	out := map[string]any{"version": h.Version}
+	if h.Negotiated() {
+		out["services"] = h.Services
+	} else {
+		out["domains"] = h.Domains
+	}
+	data, _ := json.MarshalIndent(out, "", "  ")
+	return mcp.NewToolResultText(string(data))
+}
+
+// objectArg accepts a tool argument given as a JSON object or as a string
+// holding one. Absent or empty arguments yield nil.
+func objectArg(v any, name string) (map[string]any, error) {
+	switch p := v.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return p, nil
+	case string:
+		if strings.TrimSpace(p) == "" {
+			return nil, nil
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(p), &m); err != nil {
+			return nil, fmt.Errorf("%s must be a JSON object: %v", name, err)
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("%s must be a JSON object, got %T", name, v)
+}
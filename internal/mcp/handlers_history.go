@@ -0,0 +1,497 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// handlers_history.go contains handlers for execution recording and history (time-travel debugging).
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/extraction"
+)
+
+// recordingsPath is the directory where recordings are saved (shared with the Lua engine).
+const recordingsPath = ".vsp-recordings"
+
+// --- Execution Recording & History Handlers ---
+
+// historyManager returns the recording store, opening it on first use.
+func (s *Server) historyManager() (*adt.HistoryManager, error) {
+	s.recordingMu.Lock()
+	defer s.recordingMu.Unlock()
+	if s.history == nil {
+		hm, err := adt.NewHistoryManager(recordingsPath)
+		if err != nil {
+			return nil, err
+		}
+		s.history = hm
+	}
+	return s.history, nil
+}
+
+// recordStep captures the current debugger position and top-level variables
+// into the active recording. It returns the step number, or 0 if nothing is recorded.
+func (s *Server) recordStep(ctx context.Context, stepType string) (int, error) {
+	s.recordingMu.Lock()
+	recorder := s.recorder
+	s.recordingMu.Unlock()
+	if recorder == nil {
+		return 0, nil
+	}
+
+	stack, err := s.adtClient.DebuggerGetStack(ctx, true)
+	if err != nil {
+		return 0, fmt.Errorf("get stack: %w", err)
+	}
+	var loc adt.CodeLocation
+	for i, entry := range stack.Stack {
+		if i == 0 || entry.StackPosition == stack.DebugCursorStackIndex {
+			loc = adt.CodeLocation{Program: entry.ProgramName, Include: entry.IncludeName, Line: entry.Line}
+		}
+	}
+
+	vars := make(map[string]adt.VariableValue)
+	children, err := s.adtClient.DebuggerGetChildVariables(ctx, []string{"@ROOT", "@DATAAGING"})
+	if err != nil {
+		return 0, fmt.Errorf("get variables: %w", err)
+	}
+	for _, v := range children.Variables {
+		vars[v.Name] = adt.VariableValue{Name: v.Name, Type: v.DeclaredTypeName, MetaType: string(v.MetaType), Value: v.Value}
+	}
+
+	recorder.RecordFrame(loc, stepType, vars)
+	return recorder.GetRecording().TotalSteps, nil
+}
+
+// appendRecordedStep records the current step (if recording) and notes the result in sb.
+func (s *Server) appendRecordedStep(ctx context.Context, sb *strings.Builder, stepType string) {
+	step, err := s.recordStep(ctx, stepType)
+	if err != nil {
+		fmt.Fprintf(sb, "\nRecording: failed to capture step: %v\n", err)
+	} else if step > 0 {
+		fmt.Fprintf(sb, "\nRecording: captured step %d\n", step)
+	}
+}
+
+func (s *Server) handleStartRecording(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sessionID, _ := request.Params.Arguments["session_id"].(string)
+	program, _ := request.Params.Arguments["program"].(string)
+	description, _ := request.Params.Arguments["description"].(string)
+	if sessionID == "" {
+		sessionID = "mcp"
+	}
+
+	s.recordingMu.Lock()
+	defer s.recordingMu.Unlock()
+	if s.recorder != nil {
+		return newToolResultError(fmt.Sprintf("Recording %s is already active. Use StopRecording first.", s.recorder.GetRecording().ID)), nil
+	}
+
+	s.recorder = adt.NewExecutionRecorder(sessionID, strings.ToUpper(program))
+	s.recorder.GetRecording().Description = description
+	s.logpoints.SetRecorder(s.recorder)
+
+	return mcp.NewToolResultText(fmt.Sprintf("Recording %s started.\n\nEach DebuggerAttach and DebuggerStep now captures the current location and variables. Use StopRecording to finish and save.",
+		s.recorder.GetRecording().ID)), nil
+}
+
+func (s *Server) handleStopRecording(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	save := true
+	if v, ok := request.Params.Arguments["save"].(bool); ok {
+		save = v
+	}
+
+	s.recordingMu.Lock()
+	recorder := s.recorder
+	s.recorder = nil
+	s.recordingMu.Unlock()
+	if recorder == nil {
+		return newToolResultError("No active recording. Use StartRecording first."), nil
+	}
+	s.logpoints.SetRecorder(nil)
+
+	recorder.Complete()
+	rec := recorder.GetRecording()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Recording %s stopped.\n\n", rec.ID)
+	stats := recorder.Stats()
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "%s: %v\n", k, stats[k])
+	}
+
+	if !save {
+		sb.WriteString("\nRecording discarded (save=false).")
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+	if rec.TotalSteps == 0 {
+		sb.WriteString("\nNo steps captured; nothing saved.")
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+
+	hm, err := s.historyManager()
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("StopRecording: %v", err)), nil
+	}
+	if err := hm.SaveRecording(recorder); err != nil {
+		return newToolResultError(fmt.Sprintf("StopRecording: failed to save: %v", err)), nil
+	}
+	fmt.Fprintf(&sb, "\nSaved as %s in %s.", rec.ID, recordingsPath)
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (s *Server) handleListRecordings(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	program, _ := request.Params.Arguments["program"].(string)
+	limit := 20
+	if l, ok := request.Params.Arguments["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	hm, err := s.historyManager()
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("ListRecordings: %v", err)), nil
+	}
+
+	recordings := hm.ListRecordings(adt.RecordingFilter{Program: strings.ToUpper(program)})
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartTime.After(recordings[j].StartTime)
+	})
+	if len(recordings) > limit {
+		recordings = recordings[:limit]
+	}
+	if len(recordings) == 0 {
+		return mcp.NewToolResultText("No recordings found."), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Recordings (%d):\n\n", len(recordings))
+	for _, r := range recordings {
+		fmt.Fprintf(&sb, "%s  %s  %d steps  %s", r.ID, r.Program, r.TotalSteps, r.StartTime.Format("2006-01-02 15:04:05"))
+		if r.Description != "" {
+			fmt.Fprintf(&sb, "  %s", r.Description)
+		}
+		sb.WriteString("\n")
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (s *Server) handleSearchHistory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	matchType, _ := request.Params.Arguments["match_type"].(string)
+	query := adt.HistoryQuery{MatchType: matchType, Limit: 50}
+	query.VariableName, _ = request.Params.Arguments["variable"].(string)
+	query.LocationPattern, _ = request.Params.Arguments["location"].(string)
+	query.CheckpointName, _ = request.Params.Arguments["checkpoint"].(string)
+	if program, ok := request.Params.Arguments["program"].(string); ok {
+		query.RecordingFilter.Program = strings.ToUpper(program)
+	}
+	if value, ok := request.Params.Arguments["value"].(string); ok {
+		query.TargetValue = value
+	}
+	if l, ok := request.Params.Arguments["limit"].(float64); ok && l > 0 {
+		query.Limit = int(l)
+	}
+
+	switch matchType {
+	case "variable_value", "variable_changed":
+		if query.VariableName == "" {
+			return newToolResultError(fmt.Sprintf("variable is required for match_type %s", matchType)), nil
+		}
+		query.VariableName = strings.ToUpper(query.VariableName)
+	case "location":
+		if query.LocationPattern == "" {
+			return newToolResultError("location is required for match_type location"), nil
+		}
+		query.LocationPattern = strings.ToUpper(query.LocationPattern)
+	case "checkpoint":
+	default:
+		return newToolResultError(fmt.Sprintf("Invalid match_type: %s. Valid values: variable_value, variable_changed, location, checkpoint", matchType)), nil
+	}
+
+	hm, err := s.historyManager()
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("SearchHistory: %v", err)), nil
+	}
+
+	results := hm.SearchHistory(query)
+	if len(results) == 0 {
+		return mcp.NewToolResultText("No matches found."), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Matches (%d):\n\n", len(results))
+	for _, r := range results {
+		fmt.Fprintf(&sb, "%s  step %d  %s\n", r.RecordingID, r.StepNumber, formatLocation(r.Location))
+	}
+	sb.WriteString("\nUse GetStateAtStep with recording_id and step to inspect a match.")
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (s *Server) handleCompareRecordings(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id1, _ := request.Params.Arguments["id1"].(string)
+	id2, _ := request.Params.Arguments["id2"].(string)
+	if id1 == "" || id2 == "" {
+		return newToolResultError("id1 and id2 are required"), nil
+	}
+
+	hm, err := s.historyManager()
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("CompareRecordings: %v", err)), nil
+	}
+
+	cmp, err := hm.CompareRecordings(id1, id2)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("CompareRecordings failed: %v", err)), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Comparison %s vs %s\n\n", id1, id2)
+	fmt.Fprintf(&sb, "Steps compared: %d\n", cmp.StepsCompared)
+	fmt.Fprintf(&sb, "Paths match: %v\n", cmp.PathsMatch)
+	if len(cmp.Differences) == 0 {
+		sb.WriteString("\nNo differences found.")
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+	fmt.Fprintf(&sb, "\nDifferences (%d):\n", len(cmp.Differences))
+	for _, d := range cmp.Differences {
+		if d.StepNumber > 0 {
+			fmt.Fprintf(&sb, "  [step %d] %s: %s\n", d.StepNumber, d.Type, d.Description)
+		} else {
+			fmt.Fprintf(&sb, "  %s: %s\n", d.Type, d.Description)
+		}
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (s *Server) handleGetStateAtStep(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	step, ok := request.Params.Arguments["step"].(float64)
+	if !ok || step < 1 {
+		return newToolResultError("step is required (1-based)"), nil
+	}
+	recordingID, _ := request.Params.Arguments["recording_id"].(string)
+
+	rec, err := s.lookupRecording(recordingID)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("GetStateAtStep: %v", err)), nil
+	}
+	n := int(step)
+	if n > len(rec.Frames) {
+		return newToolResultError(fmt.Sprintf("step %d out of range (recording %s has %d steps)", n, rec.ID, len(rec.Frames))), nil
+	}
+
+	filter := make(map[string]bool)
+	if names, ok := request.Params.Arguments["variables"].([]interface{}); ok {
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				filter[strings.ToUpper(s)] = true
+			}
+		}
+	}
+
+	frame := rec.Frames[n-1]
+	vars := rec.VariablesAt(n)
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		if len(filter) == 0 || filter[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Recording %s, step %d of %d\n\n", rec.ID, n, len(rec.Frames))
+	fmt.Fprintf(&sb, "Location: %s\n", formatLocation(frame.Location))
+	fmt.Fprintf(&sb, "Step Type: %s\n", frame.StepType)
+	for name, cpStep := range rec.Checkpoints {
+		if cpStep == n {
+			fmt.Fprintf(&sb, "Checkpoint: %s\n", name)
+		}
+	}
+	fmt.Fprintf(&sb, "\nVariables (%d):\n", len(names))
+	for _, name := range names {
+		v := vars[name]
+		marker := " "
+		if _, changed := frame.VariableDelta[name]; changed && n > 1 {
+			marker = "*"
+		}
+		fmt.Fprintf(&sb, "%s %s: %s = %s\n", marker, name, v.Type, formatRecordedValue(v.Value))
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (s *Server) handleFindVariableChanges(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	variable, _ := request.Params.Arguments["variable"].(string)
+	if variable == "" {
+		return newToolResultError("variable is required"), nil
+	}
+	variable = strings.ToUpper(variable)
+	recordingID, _ := request.Params.Arguments["recording_id"].(string)
+	value, hasValue := request.Params.Arguments["value"].(string)
+
+	rec, err := s.lookupRecording(recordingID)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("FindVariableChanges: %v", err)), nil
+	}
+
+	changes := rec.VariableHistory(variable)
+	if len(changes) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("%s was not captured in recording %s.", variable, rec.ID)), nil
+	}
+
+	var sb strings.Builder
+	if hasValue {
+		for _, c := range changes {
+			if formatRecordedValue(c.NewValue) == value {
+				fmt.Fprintf(&sb, "%s first became %q at step %d (%s)", variable, value, c.StepNumber, formatLocation(c.Location))
+				if c.OldValue != nil {
+					fmt.Fprintf(&sb, ", previously %s", formatRecordedValue(c.OldValue))
+				}
+				sb.WriteString(".")
+				return mcp.NewToolResultText(sb.String()), nil
+			}
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s never became %q in recording %s.", variable, value, rec.ID)), nil
+	}
+
+	fmt.Fprintf(&sb, "%s in recording %s (%d changes):\n\n", variable, rec.ID, len(changes))
+	for _, c := range changes {
+		if c.OldValue == nil {
+			fmt.Fprintf(&sb, "  step %d  %s  = %s\n", c.StepNumber, formatLocation(c.Location), formatRecordedValue(c.NewValue))
+		} else {
+			fmt.Fprintf(&sb, "  step %d  %s  %s -> %s\n", c.StepNumber, formatLocation(c.Location),
+				formatRecordedValue(c.OldValue), formatRecordedValue(c.NewValue))
+		}
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (s *Server) handleGenerateTestFromRecording(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	className, _ := request.Params.Arguments["class"].(string)
+	method, _ := request.Params.Arguments["method"].(string)
+	if className == "" || method == "" {
+		return newToolResultError("class and method are required"), nil
+	}
+	recordingID, _ := request.Params.Arguments["recording_id"].(string)
+
+	boundary := extraction.MethodBoundary{Class: className, Method: method}
+	boundary.Static, _ = request.Params.Arguments["static"].(bool)
+	if v, ok := request.Params.Arguments["entry_step"].(float64); ok {
+		boundary.EntryStep = int(v)
+	}
+	if v, ok := request.Params.Arguments["exit_step"].(float64); ok {
+		boundary.ExitStep = int(v)
+	}
+	boundary.Importing = splitNames(request.Params.Arguments["importing"])
+	boundary.Exporting = splitNames(request.Params.Arguments["exporting"])
+	boundary.Changing = splitNames(request.Params.Arguments["changing"])
+	boundary.Returning, _ = request.Params.Arguments["returning"].(string)
+	boundary.Returning = strings.ToUpper(strings.TrimSpace(boundary.Returning))
+
+	rec, err := s.lookupRecording(recordingID)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("GenerateTestFromRecording: %v", err)), nil
+	}
+	tc, err := extraction.Extract(rec, boundary)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("GenerateTestFromRecording: %v", err)), nil
+	}
+	source := extraction.GenerateTestClass(tc, extraction.GenerateOptions{})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Test for %s->%s from recording %s (steps %d-%d)\n", tc.Class, tc.Method, tc.RecordingID, tc.EntryStep, tc.ExitStep)
+	fmt.Fprintf(&sb, "Inputs: %d, outputs: %d", len(tc.Importing)+len(tc.Changing), len(tc.Exporting)+len(tc.ChangingResults))
+	if tc.Returning != nil {
+		sb.WriteString(" + returning")
+	}
+	if tables := tc.Tables(); len(tables) > 0 {
+		fmt.Fprintf(&sb, "\nSQL doubles: %s", strings.Join(tables, ", "))
+	}
+	if functions := tc.Functions(); len(functions) > 0 {
+		fmt.Fprintf(&sb, "\nFunction doubles: %s", strings.Join(functions, ", "))
+	}
+	sb.WriteString("\n")
+
+	if deploy, _ := request.Params.Arguments["deploy"].(bool); deploy {
+		transport, _ := request.Params.Arguments["transport"].(string)
+		result, err := s.adtClient.WriteTestClasses(ctx, tc.Class, source, transport)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("GenerateTestFromRecording: %v", err)), nil
+		}
+		fmt.Fprintf(&sb, "\nDeploy: %s\n", result.Message)
+		if result.UnitTestResult != nil {
+			passed, failed := 0, 0
+			for _, class := range result.UnitTestResult.Classes {
+				for _, m := range class.TestMethods {
+					if len(m.Alerts) > 0 {
+						failed++
+					} else {
+						passed++
+					}
+				}
+			}
+			fmt.Fprintf(&sb, "Unit tests: %d passed, %d failed\n", passed, failed)
+		}
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(source)
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// splitNames parses a comma-separated list of parameter names.
+func splitNames(arg interface{}) []string {
+	list, _ := arg.(string)
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.ToUpper(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// lookupRecording returns a saved recording by ID, or a snapshot of the
+// active recording if id is empty. DebuggerStep and logpoints keep adding
+// frames to the active recording while the snapshot is read.
+func (s *Server) lookupRecording(id string) (*adt.ExecutionRecording, error) {
+	if id == "" {
+		s.recordingMu.Lock()
+		defer s.recordingMu.Unlock()
+		if s.recorder == nil {
+			return nil, fmt.Errorf("no active recording; pass recording_id")
+		}
+		return s.recorder.Snapshot(), nil
+	}
+
+	hm, err := s.historyManager()
+	if err != nil {
+		return nil, err
+	}
+	return hm.LoadRecording(id)
+}
+
+// formatLocation renders a code location as PROGRAM[/INCLUDE]:LINE.
+func formatLocation(loc adt.CodeLocation) string {
+	where := loc.Program
+	if loc.Include != "" && loc.Include != loc.Program {
+		where += "/" + loc.Include
+	}
+	return fmt.Sprintf("%s:%d", where, loc.Line)
+}
+
+// formatRecordedValue renders a recorded value; strings are shown as-is.
+func formatRecordedValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -4,6 +4,7 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -294,6 +295,11 @@ func (s *Server) handleInstallZADTVSP(ctx context.Context, request mcp.CallToolR
 		checkOnly = check
 	}
 
+	upgrade := false
+	if up, ok := request.Params.Arguments["upgrade"].(bool); ok {
+		upgrade = up
+	}
+
 	// Validate package name
 	if !strings.HasPrefix(packageName, "$") {
 		return newToolResultError("Package name must start with $ (local package)"), nil
@@ -306,10 +312,11 @@ func (s *Server) handleInstallZADTVSP(ctx context.Context, request mcp.CallToolR
 	// Phase 1: Check prerequisites
 	sb.WriteString("Checking prerequisites...\n")
 
-	// Check if package exists (verify URI is populated - empty URI means package doesn't really exist)
+	// Check if package exists (an empty result without URI means it doesn't
+	// really exist; nodestructure results carry no URI but list the contents)
 	packageExists := false
 	pkg, err := s.adtClient.GetPackage(ctx, packageName)
-	if err == nil && pkg.URI != "" {
+	if err == nil && (pkg.URI != "" || len(pkg.Objects) > 0 || len(pkg.SubPackages) > 0) {
 		packageExists = true
 		fmt.Fprintf(&sb, "  ✓ Package %s exists\n", packageName)
 	} else {
@@ -327,17 +334,28 @@ func (s *Server) handleInstallZADTVSP(ctx context.Context, request mcp.CallToolR
 		skipGitService = true
 	}
 
-	// Check existing objects
+	// Compare existing objects with the embedded sources
 	objects := embedded.GetObjects()
-	existingObjects := []string{}
+	states := s.compareVSPObjects(ctx, objects)
+	changedObjects := []string{}
+	currentObjects := []string{}
 	for _, obj := range objects {
-		results, err := s.adtClient.SearchObject(ctx, obj.Name, 1)
-		if err == nil && len(results) > 0 {
-			existingObjects = append(existingObjects, obj.Name)
+		switch states[obj.Name] {
+		case vspChanged:
+			changedObjects = append(changedObjects, obj.Name)
+		case vspCurrent:
+			currentObjects = append(currentObjects, obj.Name)
 		}
 	}
-	if len(existingObjects) > 0 {
-		fmt.Fprintf(&sb, "  ⚠ Existing objects will be updated: %s\n", strings.Join(existingObjects, ", "))
+	if len(changedObjects) > 0 {
+		fmt.Fprintf(&sb, "  ⚠ Objects differ from this vsp version: %s\n", strings.Join(changedObjects, ", "))
+	}
+	if len(currentObjects) > 0 {
+		if upgrade {
+			fmt.Fprintf(&sb, "  ✓ Up to date, not redeployed: %s\n", strings.Join(currentObjects, ", "))
+		} else {
+			fmt.Fprintf(&sb, "  ⚠ Existing objects will be updated: %s\n", strings.Join(currentObjects, ", "))
+		}
 	}
 
 	sb.WriteString("\n")
@@ -350,9 +368,15 @@ func (s *Server) handleInstallZADTVSP(ctx context.Context, request mcp.CallToolR
 			if obj.Optional && skipGitService && obj.Name == "ZCL_VSP_GIT_SERVICE" {
 				fmt.Fprintf(&sb, "  [%d/%d] %s - SKIP (no abapGit)\n", i+1, len(objects), obj.Name)
 			} else {
-				fmt.Fprintf(&sb, "  [%d/%d] %s - %s\n", i+1, len(objects), obj.Name, obj.Description)
+				fmt.Fprintf(&sb, "  [%d/%d] %s - %s [%s]\n", i+1, len(objects), obj.Name, obj.Description, states[obj.Name])
 			}
 		}
+
+		sb.WriteString("\nWebSocket handshake:\n")
+		s.checkVSPHandshake(ctx, &sb, skipGitService)
+		if len(changedObjects) > 0 {
+			sb.WriteString("\nRun InstallZADTVSP with upgrade=true to redeploy the changed objects.\n")
+		}
 		return mcp.NewToolResultText(sb.String()), nil
 	}
 
@@ -377,6 +401,7 @@ func (s *Server) handleInstallZADTVSP(ctx context.Context, request mcp.CallToolR
 	sb.WriteString("Deploying ABAP objects...\n")
 
 	deployed := []string{}
+	unchanged := []string{}
 	skipped := []string{}
 	failed := []string{}
 
@@ -388,14 +413,25 @@ func (s *Server) handleInstallZADTVSP(ctx context.Context, request mcp.CallToolR
 			continue
 		}
 
+		// Upgrade redeploys only what differs from the embedded source
+		if upgrade && states[obj.Name] == vspCurrent {
+			fmt.Fprintf(&sb, "  [%d/%d] %s = Up to date\n", i+1, len(objects), obj.Name)
+			unchanged = append(unchanged, obj.Name)
+			continue
+		}
+
 		fmt.Fprintf(&sb, "  [%d/%d] %s ", i+1, len(objects), obj.Name)
 
 		// Use WriteSource to create/update
 		opts := &adt.WriteSourceOptions{
-			Package: packageName,
-			Mode:    adt.WriteModeUpsert,
+			Package:     packageName,
+			Description: obj.Description,
+			Mode:        adt.WriteModeUpsert,
+		}
+		result, err := s.adtClient.WriteSource(ctx, obj.Type, obj.Name, obj.Source, opts)
+		if err == nil && !result.Success {
+			err = errors.New(result.Message)
 		}
-		_, err := s.adtClient.WriteSource(ctx, obj.Type, obj.Name, obj.Source, opts)
 		if err != nil {
 			fmt.Fprintf(&sb, "✗ Failed: %v\n", err)
 			failed = append(failed, obj.Name+": "+err.Error())
@@ -421,10 +457,18 @@ func (s *Server) handleInstallZADTVSP(ctx context.Context, request mcp.CallToolR
 		sb.WriteString("═══════════════════════════════════════════════════════════════════════════════\n")
 	}
 
-	fmt.Fprintf(&sb, "\nDeployed: %d, Skipped: %d, Failed: %d\n\n", len(deployed), len(skipped), len(failed))
+	fmt.Fprintf(&sb, "\nDeployed: %d, Unchanged: %d, Skipped: %d, Failed: %d\n\n", len(deployed), len(unchanged), len(skipped), len(failed))
 
-	// Post-deployment instructions
-	sb.WriteString(embedded.PostDeploymentInstructions())
+	// An upgrade of an installed handler keeps the SAPC and SICF setup, but
+	// open connections still run the old code
+	if upgrade && states["ZCL_VSP_APC_HANDLER"] != vspMissing {
+		if len(deployed) > 0 {
+			s.dropVSPConnections()
+			sb.WriteString("Open ZADT_VSP connections were closed and reconnect with the new version.\n")
+		}
+	} else {
+		sb.WriteString(embedded.PostDeploymentInstructions())
+	}
 
 	// Features unlocked
 	sb.WriteString("\nFeatures unlocked:\n")
@@ -437,9 +481,107 @@ func (s *Server) handleInstallZADTVSP(ctx context.Context, request mcp.CallToolR
 		sb.WriteString("  ✗ abapGit export (install abapGit first)\n")
 	}
 
+	// Tools hidden while ZADT_VSP was missing become available
+	if len(deployed) > 0 && s.refreshTools(ctx, adt.FeatureVSP, adt.FeatureAbapGit) {
+		sb.WriteString("\nTool list updated for the new features.\n")
+	}
+
 	return mcp.NewToolResultText(sb.String()), nil
 }
 
+// vspObjectState is the state of an embedded ZADT_VSP object on the system.
+type vspObjectState int
+
+const (
+	vspMissing vspObjectState = iota
+	vspChanged
+	vspCurrent
+)
+
+func (st vspObjectState) String() string {
+	switch st {
+	case vspChanged:
+		return "changed"
+	case vspCurrent:
+		return "up to date"
+	}
+	return "missing"
+}
+
+// compareVSPObjects compares the installed ZADT_VSP objects with the embedded
+// sources.
+func (s *Server) compareVSPObjects(ctx context.Context, objects []embedded.ObjectInfo) map[string]vspObjectState {
+	states := make(map[string]vspObjectState, len(objects))
+	for _, obj := range objects {
+		results, err := s.adtClient.SearchObject(ctx, obj.Name, 1)
+		if err != nil || len(results) == 0 {
+			states[obj.Name] = vspMissing
+			continue
+		}
+		source, err := s.adtClient.GetSource(ctx, obj.Type, obj.Name, nil)
+		if err != nil || normalizeABAPSource(source) != normalizeABAPSource(obj.Source) {
+			states[obj.Name] = vspChanged
+			continue
+		}
+		states[obj.Name] = vspCurrent
+	}
+	return states
+}
+
+// normalizeABAPSource drops line ending and trailing whitespace differences
+// that the SAP side may introduce.
+func normalizeABAPSource(source string) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// checkVSPHandshake connects to ZADT_VSP and compares the versions it reports
+// with the embedded ones.
+func (s *Server) checkVSPHandshake(ctx context.Context, sb *strings.Builder, skipGitService bool) {
+	ws := adt.NewBaseWebSocketClient(s.config.BaseURL, s.config.Client, s.config.Username, s.config.Password, s.config.InsecureSkipVerify)
+	ws.UseAuth(s.adtClient.Config())
+	if err := ws.Connect(ctx); err != nil {
+		fmt.Fprintf(sb, "  ⚠ Not available: %v\n", err)
+		return
+	}
+	defer ws.Close()
+
+	h := ws.Handshake()
+	fmt.Fprintf(sb, "  Handler version: %s (vsp expects %s)\n", h.Version, embedded.ExpectedVersions()["system"])
+	if !h.Negotiated() {
+		sb.WriteString("  ⚠ Handler predates version negotiation\n")
+	}
+
+	expected := embedded.ExpectedVersions()
+	if skipGitService {
+		delete(expected, "git")
+	}
+	mismatches := h.Mismatches(expected)
+	if len(mismatches) == 0 {
+		sb.WriteString("  ✓ All domains match\n")
+		return
+	}
+	for _, m := range mismatches {
+		fmt.Fprintf(sb, "  ⚠ %s\n", m)
+	}
+}
+
+// dropVSPConnections closes the ZADT_VSP clients so that the next request
+// connects to the redeployed handler.
+func (s *Server) dropVSPConnections() {
+	if s.amdpWSClient != nil {
+		s.amdpWSClient.Close()
+		s.amdpWSClient = nil
+	}
+	if s.debugWSClient != nil {
+		s.debugWSClient.Close()
+		s.debugWSClient = nil
+	}
+}
+
 func (s *Server) handleListDependencies(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var sb strings.Builder
 	sb.WriteString("Available Dependencies\n")
@@ -0,0 +1,57 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// handlers_lua.go contains the handler for running sandboxed Lua scripts.
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/scripting"
+)
+
+// maxLuaScriptTimeout is the upper bound for the timeout_seconds parameter.
+const maxLuaScriptTimeout = 300 * time.Second
+
+// --- Lua Scripting Handlers ---
+
+func (s *Server) handleRunLuaScript(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	script, ok := request.Params.Arguments["script"].(string)
+	if !ok || script == "" {
+		return newToolResultError("script is required"), nil
+	}
+
+	cfg := scripting.DefaultSandboxConfig()
+	cfg.Libraries = append(cfg.Libraries, "os")
+	if t, ok := request.Params.Arguments["timeout_seconds"].(float64); ok && t > 0 {
+		cfg.Timeout = time.Duration(t * float64(time.Second))
+		if cfg.Timeout > maxLuaScriptTimeout {
+			cfg.Timeout = maxLuaScriptTimeout
+		}
+	}
+	if n, ok := request.Params.Arguments["max_instructions"].(float64); ok && n > 0 && int64(n) < cfg.MaxInstructions {
+		cfg.MaxInstructions = int64(n)
+	}
+
+	engine, err := scripting.NewSandboxedLuaEngine(s.adtClient, cfg)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("RunLuaScript: %v", err)), nil
+	}
+	defer engine.Close()
+
+	var out bytes.Buffer
+	engine.SetOutput(&out)
+	engine.SetContext(ctx)
+
+	start := time.Now()
+	execErr := engine.Execute(script)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if execErr != nil {
+		return newToolResultError(fmt.Sprintf("Lua error after %v: %v\n\nOutput:\n%s", elapsed, execErr, out.String())), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Completed in %v\n\nOutput:\n%s", elapsed, out.String())), nil
+}
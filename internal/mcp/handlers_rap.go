@@ -0,0 +1,57 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// handlers_rap.go contains the handler for RAP stack generation.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// --- RAP Handlers ---
+
+func (s *Server) handleGenerateRAPStack(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var opts adt.RAPStackOptions
+	opts.Table, _ = request.Params.Arguments["table"].(string)
+	opts.Name, _ = request.Params.Arguments["name"].(string)
+	opts.Description, _ = request.Params.Arguments["description"].(string)
+	opts.Package, _ = request.Params.Arguments["package"].(string)
+	opts.Transport, _ = request.Params.Arguments["transport"].(string)
+	opts.DraftTable, _ = request.Params.Arguments["draft_table"].(string)
+	if p, ok := request.Params.Arguments["publish"].(bool); ok {
+		opts.SkipPublish = !p
+	}
+
+	spec, err := objectArg(request.Params.Arguments["spec"], "spec")
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+	switch {
+	case spec != nil && opts.Table != "":
+		return newToolResultError("pass either table or spec, not both"), nil
+	case spec == nil && opts.Table == "":
+		return newToolResultError("table or spec is required"), nil
+	case spec != nil:
+		if err := withDefinition(spec, func(t adt.CreateTableOptions) error {
+			opts.Spec = &t
+			return nil
+		}); err != nil {
+			return newToolResultError(err.Error()), nil
+		}
+	}
+
+	result, err := s.adtClient.GenerateRAPStack(ctx, opts)
+	if err != nil {
+		msg := fmt.Sprintf("GenerateRAPStack failed: %v", err)
+		if result != nil && len(result.Objects) > 0 {
+			created, _ := json.MarshalIndent(result.Objects, "", "  ")
+			msg += "\n\nCreated before the failure:\n" + string(created)
+		}
+		return newToolResultError(msg), nil
+	}
+	output, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
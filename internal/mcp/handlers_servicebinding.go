@@ -1,13 +1,16 @@
 // Package mcp provides the MCP server implementation for ABAP ADT tools.
-// handlers_servicebinding.go contains handlers for RAP service binding publish/unpublish.
+// handlers_servicebinding.go contains handlers for RAP service binding publish/unpublish
+// and OData service tests.
 package mcp
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
 )
 
 // --- Service Binding Publish/Unpublish Handlers ---
@@ -23,7 +26,16 @@ func (s *Server) handlePublishServiceBinding(ctx context.Context, request mcp.Ca
 		serviceVersion = sv
 	}
 
-	result, err := s.adtClient.PublishServiceBinding(ctx, serviceName, serviceVersion)
+	publish := s.adtClient.PublishServiceBinding
+	switch v, _ := request.Params.Arguments["odata_version"].(string); strings.ToUpper(v) {
+	case "", "V2":
+	case "V4":
+		publish = s.adtClient.PublishServiceBindingV4
+	default:
+		return newToolResultError(fmt.Sprintf("invalid odata_version %q (use V2 or V4)", v)), nil
+	}
+
+	result, err := publish(ctx, serviceName, serviceVersion)
 	if err != nil {
 		return newToolResultError(fmt.Sprintf("Failed to publish service binding: %v", err)), nil
 	}
@@ -43,7 +55,16 @@ func (s *Server) handleUnpublishServiceBinding(ctx context.Context, request mcp.
 		serviceVersion = sv
 	}
 
-	result, err := s.adtClient.UnpublishServiceBinding(ctx, serviceName, serviceVersion)
+	unpublish := s.adtClient.UnpublishServiceBinding
+	switch v, _ := request.Params.Arguments["odata_version"].(string); strings.ToUpper(v) {
+	case "", "V2":
+	case "V4":
+		unpublish = s.adtClient.UnpublishServiceBindingV4
+	default:
+		return newToolResultError(fmt.Sprintf("invalid odata_version %q (use V2 or V4)", v)), nil
+	}
+
+	result, err := unpublish(ctx, serviceName, serviceVersion)
 	if err != nil {
 		return newToolResultError(fmt.Sprintf("Failed to unpublish service binding: %v", err)), nil
 	}
@@ -51,3 +72,37 @@ func (s *Server) handleUnpublishServiceBinding(ctx context.Context, request mcp.
 	output, _ := json.MarshalIndent(result, "", "  ")
 	return mcp.NewToolResultText(string(output)), nil
 }
+
+func (s *Server) handleTestODataService(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var opts adt.ODataTestOptions
+	opts.Binding, _ = request.Params.Arguments["binding"].(string)
+	opts.ServiceURL, _ = request.Params.Arguments["service_url"].(string)
+	if opts.Binding == "" && opts.ServiceURL == "" {
+		return newToolResultError("binding or service_url is required"), nil
+	}
+	if sets, ok := request.Params.Arguments["entity_sets"].(string); ok && sets != "" {
+		for _, set := range strings.Split(sets, ",") {
+			opts.EntitySets = append(opts.EntitySets, strings.TrimSpace(set))
+		}
+	}
+	if top, ok := request.Params.Arguments["top"].(float64); ok {
+		opts.Top = int(top)
+	}
+	if size, ok := request.Params.Arguments["sample_size"].(float64); ok {
+		opts.SampleSize = int(size)
+	}
+	opts.Draft, _ = request.Params.Arguments["draft"].(bool)
+	opts.DraftEntitySet, _ = request.Params.Arguments["draft_entity_set"].(string)
+	payload, err := objectArg(request.Params.Arguments["draft_payload"], "draft_payload")
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+	opts.DraftPayload = payload
+
+	result, err := s.adtClient.TestODataService(ctx, opts)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("TestODataService failed: %v", err)), nil
+	}
+	output, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
@@ -49,23 +49,48 @@ func (s *Server) handleGetConnectionInfo(ctx context.Context, request mcp.CallTo
 	// Add debugger status
 	info["debugger_user"] = strings.ToUpper(s.config.Username) // Debugger uses uppercase
 
+	// Add request throttling and session pool metrics
+	stats := s.adtClient.TransportStats()
+	info["transport"] = map[string]interface{}{
+		"requests":            stats.Requests,
+		"in_flight":           stats.InFlight,
+		"peak_in_flight":      stats.PeakInFlight,
+		"max_concurrent":      stats.MaxConcurrent,
+		"requests_per_second": stats.RequestsPerSecond,
+		"throttled":           stats.Throttled,
+		"avg_wait_ms":         stats.AverageWait().Milliseconds(),
+		"max_wait_ms":         stats.MaxWait.Milliseconds(),
+		"retries":             stats.Retries,
+		"retries_denied":      stats.RetriesDenied,
+		"session_pool_size":   stats.SessionPoolSize,
+		"sessions_in_use":     stats.SessionsInUse,
+		"session_waits":       stats.SessionWaits,
+		"sessions_reclaimed":  stats.SessionsReclaimed,
+	}
+
 	result, _ := json.MarshalIndent(info, "", "  ")
 	return mcp.NewToolResultText(string(result)), nil
 }
 
 func (s *Server) handleGetFeatures(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Probe all features
+	// Probe all features, again if asked to
+	toolsChanged := false
+	if refresh, _ := request.Params.Arguments["refresh"].(bool); refresh {
+		toolsChanged = s.refreshTools(ctx)
+	}
 	results := s.featureProber.ProbeAll(ctx)
 
 	// Format output
 	type featureOutput struct {
-		Features map[string]*adt.FeatureStatus `json:"features"`
-		Summary  string                        `json:"summary"`
+		Features     map[string]*adt.FeatureStatus `json:"features"`
+		Summary      string                        `json:"summary"`
+		ToolsChanged bool                          `json:"tools_changed,omitempty"`
 	}
 
 	output := featureOutput{
-		Features: make(map[string]*adt.FeatureStatus),
-		Summary:  s.featureProber.FeatureSummary(ctx),
+		Features:     make(map[string]*adt.FeatureStatus),
+		Summary:      s.featureProber.FeatureSummary(ctx),
+		ToolsChanged: toolsChanged,
 	}
 
 	for id, status := range results {
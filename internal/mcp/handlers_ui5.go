@@ -4,10 +4,12 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
 )
 
 // --- UI5/Fiori BSP Management Handlers ---
@@ -186,3 +188,42 @@ func (s *Server) handleUI5DeleteApp(ctx context.Context, request mcp.CallToolReq
 
 	return mcp.NewToolResultText(fmt.Sprintf("Successfully deleted UI5 application %s", appName)), nil
 }
+
+func (s *Server) handleUI5Deploy(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	dir, ok := request.Params.Arguments["dir"].(string)
+	if !ok || dir == "" {
+		return newToolResultError("dir is required"), nil
+	}
+
+	var opts adt.UI5DeployOptions
+	opts.Name, _ = request.Params.Arguments["app_name"].(string)
+	opts.Package, _ = request.Params.Arguments["package"].(string)
+	opts.Description, _ = request.Params.Arguments["description"].(string)
+	opts.Transport, _ = request.Params.Arguments["transport"].(string)
+	opts.TestMode, _ = request.Params.Arguments["test_mode"].(bool)
+
+	result, err := s.adtClient.UI5Deploy(ctx, dir, opts)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("UI5Deploy failed: %v", err)), nil
+	}
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+func (s *Server) handleUI5Undeploy(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	appName, ok := request.Params.Arguments["app_name"].(string)
+	if !ok || appName == "" {
+		return newToolResultError("app_name is required"), nil
+	}
+
+	transport, _ := request.Params.Arguments["transport"].(string)
+
+	result, err := s.adtClient.UI5Undeploy(ctx, appName, transport)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("UI5Undeploy failed: %v", err)), nil
+	}
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
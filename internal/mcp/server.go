@@ -34,6 +34,23 @@ type Server struct {
 	featureProber  *adt.FeatureProber         // Feature detection system (safety network)
 	featureConfig  adt.FeatureConfig          // Feature configuration
 
+	// Registered tools, published to the MCP server by publishTools
+	tools          []server.ServerTool
+	toolsMu        sync.Mutex
+	publishedTools string // Signature of the published tool list
+
+	// Execution recording (time-travel debugging)
+	recorder    *adt.ExecutionRecorder // Active recording, nil when not recording
+	history     *adt.HistoryManager    // Saved recordings, opened on first use
+	recordingMu sync.Mutex
+
+	// Logpoints, evaluated when DebuggerAttach/DebuggerStep stop at one
+	logpoints *adt.LogpointManager
+
+	// Tools left out by disabled groups or the tools config; CallDomain
+	// refuses the built-in domain actions they wrap
+	disabledTools map[string]bool
+
 	// Async task management
 	asyncTasks   map[string]*AsyncTask
 	asyncTasksMu sync.RWMutex
@@ -53,6 +70,13 @@ type Config struct {
 	// Cookie authentication (alternative to basic auth)
 	Cookies map[string]string
 
+	// OAuth2 authentication (alternative to basic auth), e.g. from a BTP service key
+	OAuth *adt.OAuthConfig
+
+	// Client certificate for mutual TLS (PEM; the key may be in the certificate file)
+	ClientCertFile string
+	ClientKeyFile  string
+
 	// Verbose output
 	Verbose bool
 
@@ -83,10 +107,27 @@ type Config struct {
 	FeatureAMDP      string // AMDP/HANA debugger
 	FeatureUI5       string // UI5/Fiori BSP management
 	FeatureTransport string // CTS transport management (distinct from EnableTransports safety)
+	FeatureVSP       string // ZADT_VSP WebSocket handler (debugger, RFC, reports, Git export)
+
+	// FeatureTools controls tools whose feature the probe reports unavailable:
+	// "" (default) registers them anyway, "hide" leaves them out and
+	// "annotate" marks their descriptions. Probing happens at startup and
+	// again after InstallZADTVSP or GetFeatures with refresh.
+	FeatureTools string
 
 	// Debugger configuration
 	TerminalID string // SAP GUI terminal ID for cross-tool breakpoint sharing
 
+	// HTTP traffic recording (cassettes for offline reproduction)
+	RecordDir string // Record ADT traffic to this directory
+	ReplayDir string // Answer ADT requests from this directory
+
+	// Load limits (protect SAP dialog work processes)
+	MaxConcurrent int     // Maximum ADT requests in flight (0 = unlimited)
+	RateLimit     float64 // Maximum ADT requests per second (0 = unlimited)
+	SessionPool   int     // Dedicated SAP sessions for lock/update/unlock (0 = shared session)
+	MaxRetries    int     // Retries of read requests after transient errors (0 = none)
+
 	// Granular tool visibility (from .vsp.json)
 	// Key: tool name, Value: true=enabled, false=disabled
 	// Takes highest priority over mode and disabled groups
@@ -106,9 +147,30 @@ func NewServer(cfg *Config) *Server {
 	if len(cfg.Cookies) > 0 {
 		opts = append(opts, adt.WithCookies(cfg.Cookies))
 	}
+	if cfg.OAuth != nil {
+		opts = append(opts, adt.WithOAuth(*cfg.OAuth))
+	}
+	if cfg.ClientCertFile != "" {
+		opts = append(opts, adt.WithClientCertificate(cfg.ClientCertFile, cfg.ClientKeyFile))
+	}
 	if cfg.Verbose {
 		opts = append(opts, adt.WithVerbose())
 	}
+	if cfg.ReplayDir != "" {
+		opts = append(opts, adt.WithReplay(cfg.ReplayDir))
+	} else if cfg.RecordDir != "" {
+		opts = append(opts, adt.WithRecording(cfg.RecordDir))
+	}
+	if cfg.MaxConcurrent > 0 {
+		opts = append(opts, adt.WithMaxConcurrent(cfg.MaxConcurrent))
+	}
+	if cfg.RateLimit > 0 {
+		opts = append(opts, adt.WithRateLimit(cfg.RateLimit))
+	}
+	if cfg.SessionPool > 0 {
+		opts = append(opts, adt.WithSessionPool(cfg.SessionPool))
+	}
+	opts = append(opts, adt.WithMaxRetries(cfg.MaxRetries))
 
 	// Configure safety settings
 	safety := adt.UnrestrictedSafetyConfig() // Default: unrestricted for backwards compatibility
@@ -158,6 +220,7 @@ func NewServer(cfg *Config) *Server {
 		AMDP:      parseFeatureMode(cfg.FeatureAMDP),
 		UI5:       parseFeatureMode(cfg.FeatureUI5),
 		Transport: parseFeatureMode(cfg.FeatureTransport),
+		VSP:       parseFeatureMode(cfg.FeatureVSP),
 	}
 
 	// Create feature prober
@@ -168,6 +231,7 @@ func NewServer(cfg *Config) *Server {
 		"mcp-abap-adt-go",
 		"1.0.0",
 		server.WithResourceCapabilities(true, true),
+		server.WithToolCapabilities(true),
 		server.WithLogging(),
 	)
 
@@ -178,10 +242,12 @@ func NewServer(cfg *Config) *Server {
 		featureProber: featureProber,
 		featureConfig: featureConfig,
 		asyncTasks:    make(map[string]*AsyncTask),
+		logpoints:     adt.NewLogpointManager(),
 	}
 
 	// Register tools based on mode, disabled groups, and granular tool config
 	s.registerTools(cfg.Mode, cfg.DisabledGroups, cfg.ToolsConfig)
+	s.publishTools(context.Background())
 
 	return s
 }
@@ -207,10 +273,10 @@ func (s *Server) ServeStdio() error {
 // Mode "focused" registers essential tools.
 // Mode "expert" registers all tools.
 // DisabledGroups can disable specific tool groups using short codes:
-//   - "5" or "U" = UI5/BSP tools (3 tools, read-only)
+//   - "5" or "U" = UI5/BSP tools (3 read tools, UI5Deploy, UI5Undeploy)
 //   - "T" = Test tools: RunUnitTests, RunATCCheck (2 tools)
 //   - "H" = HANA/AMDP debugger (7 tools)
-//   - "D" = ABAP Debugger (6 session tools)
+//   - "D" = ABAP Debugger (6 session tools + 7 recording/history tools)
 //   - "C" = CTS/Transport tools (5 tools)
 //   - "G" = Git/abapGit tools (2 tools)
 //   - "R" = Report tools (4 tools)
@@ -225,8 +291,8 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 	// Define tool groups for selective disablement
 	// Short codes: 5/U=UI5, T=Tests, H=HANA, D=Debug, C=CTS, X=Experimental
 	toolGroups := map[string][]string{
-		"5": { // UI5/BSP tools (also mapped as "U") - read-only, write ops need custom plugin
-			"UI5ListApps", "UI5GetApp", "UI5GetFileContent",
+		"5": { // UI5/BSP tools (also mapped as "U") - file writes need a custom plugin, apps deploy as a whole
+			"UI5ListApps", "UI5GetApp", "UI5GetFileContent", "UI5Deploy", "UI5Undeploy",
 		},
 		"T": { // Test tools
 			"RunUnitTests", "RunATCCheck",
@@ -238,6 +304,9 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"D": { // ABAP debugger (session tools - breakpoints via WebSocket ZADT_VSP)
 			"DebuggerListen", "DebuggerAttach", "DebuggerDetach",
 			"DebuggerStep", "DebuggerGetStack", "DebuggerGetVariables",
+			"StartRecording", "StopRecording", "ListRecordings", "SearchHistory",
+			"CompareRecordings", "GetStateAtStep", "FindVariableChanges",
+			"GenerateTestFromRecording",
 		},
 		"C": { // CTS/Transport tools
 			"ListTransports", "GetTransport",
@@ -258,13 +327,23 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"X": { // EXPERIMENTAL - Tools requiring special setup or with known limitations
 			// ABAP Debugger - requires ZADT_VSP WebSocket handler
 			"SetBreakpoint", "GetBreakpoints", "DeleteBreakpoint",
+			"SetLogpoint", "GetLogpointLog",
+			"SaveBreakpointSet", "ListBreakpointSets", "EnableBreakpointSet", "DisableBreakpointSet",
 			"DebuggerListen", "DebuggerAttach", "DebuggerDetach",
 			"DebuggerStep", "DebuggerGetStack", "DebuggerGetVariables",
+			// Execution recording & history - records DebuggerAttach/DebuggerStep
+			"StartRecording", "StopRecording", "ListRecordings", "SearchHistory",
+			"CompareRecordings", "GetStateAtStep", "FindVariableChanges",
+			"GenerateTestFromRecording",
 			// AMDP/HANA Debugger - experimental, session management issues
 			"AMDPDebuggerStart", "AMDPDebuggerResume", "AMDPDebuggerStop",
 			"AMDPDebuggerStep", "AMDPGetVariables", "AMDPSetBreakpoint", "AMDPGetBreakpoints",
 			// RunReport - requires ZADT_VSP, APC context limitations
 			"RunReport",
+			// RunLuaScript - sandboxed scripting
+			"RunLuaScript",
+			// CallDomain - raw access to any ZADT_VSP domain
+			"CallDomain",
 		},
 	}
 	// Map "U" to same tools as "5"
@@ -280,6 +359,14 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		}
 	}
 
+	s.disabledTools = make(map[string]bool, len(disabledTools))
+	for tool := range disabledTools {
+		s.disabledTools[tool] = true
+	}
+	for tool, enabled := range toolsConfig {
+		s.disabledTools[tool] = !enabled
+	}
+
 	// Define focused mode tool whitelist (81 essential tools)
 	focusedTools := map[string]bool{
 		// Unified tools (2)
@@ -317,6 +404,8 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"GetInactiveObjects":  true,  // List pending activations
 		"CreatePackage":       true,  // Create local packages ($...)
 		"CreateTable":         true,  // Create DDIC tables from JSON
+		"GetDDIC":             true,  // Read DDIC definitions as JSON
+		"WriteDDIC":           true,  // Create/update DDIC objects from JSON
 		"CompareSource":       true,  // Diff two objects
 		"CloneObject":         true,  // Copy object to new name
 		"GetClassInfo":        true,  // Quick class metadata
@@ -359,6 +448,15 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"SetBreakpoint":    true, // Set line breakpoint
 		"GetBreakpoints":   true, // List active breakpoints
 		"DeleteBreakpoint": true, // Remove breakpoint
+		"SetLogpoint":      true, // Log a message and continue
+		"GetLogpointLog":   true, // Messages logged by logpoints
+
+		// Breakpoint sets (.vsp/breakpoints.json)
+		"SaveBreakpointSet":    true, // Save named breakpoint set
+		"ListBreakpointSets":   true, // List saved breakpoint sets
+		"EnableBreakpointSet":  true, // Create a set's breakpoints on the system
+		"DisableBreakpointSet": true, // Delete a set's breakpoints from the system
+
 		"CallRFC":          true, // Call function module via WebSocket (trigger execution)
 		"MoveObject":       true, // Move object to different package
 
@@ -370,12 +468,22 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"DebuggerGetStack":     true, // Get call stack
 		"DebuggerGetVariables": true, // Get variable values
 
+		// Execution Recording & History (8)
+		"StartRecording":            true, // Record debugger steps
+		"StopRecording":             true, // Finish and save recording
+		"ListRecordings":            true, // List saved recordings
+		"SearchHistory":             true, // Search across recordings
+		"CompareRecordings":         true, // Diff two recordings
+		"GetStateAtStep":            true, // Variables at step N
+		"FindVariableChanges":       true, // When did variable X change
+		"GenerateTestFromRecording": true, // ABAP Unit test from a recorded call
+
 		// UI5/Fiori BSP Management (3 read-only - ADT filestore is read-only)
 		"UI5ListApps":       true, // List UI5 applications
 		"UI5GetApp":         true, // Get UI5 app details
 		"UI5GetFileContent": true, // Get file content from UI5 app
 		// Write ops disabled - ADT filestore API is read-only (405 on POST)
-		// Future: implement via custom plugin using /UI5/CL_REPOSITORY_LOAD
+		// Whole apps deploy through /UI5/ABAP_REPOSITORY_SRV: UI5Deploy, UI5Undeploy (expert)
 		// "UI5UploadFile":     true, // Upload file to UI5 app
 		// "UI5DeleteFile":     true, // Delete file from UI5 app
 		// "UI5CreateApp":      true, // Create new UI5 app
@@ -444,7 +552,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetProgram
 	if shouldRegister("GetProgram") {
-		s.mcpServer.AddTool(mcp.NewTool("GetProgram",
+		s.addTool(mcp.NewTool("GetProgram",
 		mcp.WithDescription("Retrieve ABAP program source code"),
 		mcp.WithString("program_name",
 			mcp.Required(),
@@ -456,7 +564,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetClass
 	if shouldRegister("GetClass") {
-		s.mcpServer.AddTool(mcp.NewTool("GetClass",
+		s.addTool(mcp.NewTool("GetClass",
 		mcp.WithDescription("Retrieve ABAP class source code"),
 		mcp.WithString("class_name",
 			mcp.Required(),
@@ -468,7 +576,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetInterface
 	if shouldRegister("GetInterface") {
-		s.mcpServer.AddTool(mcp.NewTool("GetInterface",
+		s.addTool(mcp.NewTool("GetInterface",
 		mcp.WithDescription("Retrieve ABAP interface source code"),
 		mcp.WithString("interface_name",
 			mcp.Required(),
@@ -480,7 +588,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetFunction
 	if shouldRegister("GetFunction") {
-		s.mcpServer.AddTool(mcp.NewTool("GetFunction",
+		s.addTool(mcp.NewTool("GetFunction",
 		mcp.WithDescription("Retrieve ABAP Function Module source code"),
 		mcp.WithString("function_name",
 			mcp.Required(),
@@ -496,7 +604,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetFunctionGroup
 	if shouldRegister("GetFunctionGroup") {
-		s.mcpServer.AddTool(mcp.NewTool("GetFunctionGroup",
+		s.addTool(mcp.NewTool("GetFunctionGroup",
 		mcp.WithDescription("Retrieve ABAP Function Group source code"),
 		mcp.WithString("function_group",
 			mcp.Required(),
@@ -508,7 +616,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetInclude
 	if shouldRegister("GetInclude") {
-		s.mcpServer.AddTool(mcp.NewTool("GetInclude",
+		s.addTool(mcp.NewTool("GetInclude",
 		mcp.WithDescription("Retrieve ABAP Include Source Code"),
 		mcp.WithString("include_name",
 			mcp.Required(),
@@ -520,7 +628,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetTable
 	if shouldRegister("GetTable") {
-		s.mcpServer.AddTool(mcp.NewTool("GetTable",
+		s.addTool(mcp.NewTool("GetTable",
 		mcp.WithDescription("Retrieve ABAP table structure"),
 		mcp.WithString("table_name",
 			mcp.Required(),
@@ -532,7 +640,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetTableContents
 	if shouldRegister("GetTableContents") {
-		s.mcpServer.AddTool(mcp.NewTool("GetTableContents",
+		s.addTool(mcp.NewTool("GetTableContents",
 		mcp.WithDescription("Retrieve contents of an ABAP table. For simple queries use table_name + max_rows. For filtered queries use sql_query parameter with ABAP SQL syntax (use ASCENDING/DESCENDING, not ASC/DESC)."),
 		mcp.WithString("table_name",
 			mcp.Required(),
@@ -550,7 +658,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// RunQuery
 	if shouldRegister("RunQuery") {
-		s.mcpServer.AddTool(mcp.NewTool("RunQuery",
+		s.addTool(mcp.NewTool("RunQuery",
 		mcp.WithDescription("Execute a freestyle SQL query against the SAP database. IMPORTANT: Uses ABAP SQL syntax, NOT standard SQL. Use ASCENDING/DESCENDING instead of ASC/DESC. Use max_rows parameter instead of LIMIT. GROUP BY and WHERE work normally."),
 		mcp.WithString("sql_query",
 			mcp.Required(),
@@ -565,7 +673,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetCDSDependencies
 	if shouldRegister("GetCDSDependencies") {
-		s.mcpServer.AddTool(mcp.NewTool("GetCDSDependencies",
+		s.addTool(mcp.NewTool("GetCDSDependencies",
 		mcp.WithDescription("Retrieve CDS view FORWARD dependencies (tables/views this CDS reads FROM). Returns tree of base objects. Does NOT return reverse dependencies (where-used). Use with GetSource(DDLS) to read CDS source code."),
 		mcp.WithString("ddls_name",
 			mcp.Required(),
@@ -586,7 +694,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetStructure
 	if shouldRegister("GetStructure") {
-		s.mcpServer.AddTool(mcp.NewTool("GetStructure",
+		s.addTool(mcp.NewTool("GetStructure",
 		mcp.WithDescription("Retrieve ABAP Structure"),
 		mcp.WithString("structure_name",
 			mcp.Required(),
@@ -598,7 +706,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetPackage
 	if shouldRegister("GetPackage") {
-		s.mcpServer.AddTool(mcp.NewTool("GetPackage",
+		s.addTool(mcp.NewTool("GetPackage",
 		mcp.WithDescription("Retrieve ABAP package details"),
 		mcp.WithString("package_name",
 			mcp.Required(),
@@ -609,7 +717,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetMessages - Message class texts (SE91)
 	if shouldRegister("GetMessages") {
-		s.mcpServer.AddTool(mcp.NewTool("GetMessages",
+		s.addTool(mcp.NewTool("GetMessages",
 			mcp.WithDescription("Get all messages from an ABAP message class (SE91). Returns message number, text for all messages in the class. Use SearchObject to find message classes first."),
 			mcp.WithString("message_class",
 				mcp.Required(),
@@ -621,7 +729,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetTransaction
 	if shouldRegister("GetTransaction") {
-		s.mcpServer.AddTool(mcp.NewTool("GetTransaction",
+		s.addTool(mcp.NewTool("GetTransaction",
 		mcp.WithDescription("Retrieve ABAP transaction details"),
 		mcp.WithString("transaction_name",
 			mcp.Required(),
@@ -633,7 +741,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetTypeInfo
 	if shouldRegister("GetTypeInfo") {
-		s.mcpServer.AddTool(mcp.NewTool("GetTypeInfo",
+		s.addTool(mcp.NewTool("GetTypeInfo",
 		mcp.WithDescription("Retrieve ABAP type information"),
 		mcp.WithString("type_name",
 			mcp.Required(),
@@ -647,33 +755,36 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetSystemInfo
 	if shouldRegister("GetSystemInfo") {
-		s.mcpServer.AddTool(mcp.NewTool("GetSystemInfo",
+		s.addTool(mcp.NewTool("GetSystemInfo",
 			mcp.WithDescription("Get SAP system information (system ID, release, kernel, database)"),
 		), s.handleGetSystemInfo)
 	}
 
 	// GetInstalledComponents
 	if shouldRegister("GetInstalledComponents") {
-		s.mcpServer.AddTool(mcp.NewTool("GetInstalledComponents",
+		s.addTool(mcp.NewTool("GetInstalledComponents",
 			mcp.WithDescription("List installed software components with version information"),
 		), s.handleGetInstalledComponents)
 	}
 
 	// GetConnectionInfo - Self-inspection tool
 	// Always registered - useful for debugging and introspection
-	s.mcpServer.AddTool(mcp.NewTool("GetConnectionInfo",
+	s.addTool(mcp.NewTool("GetConnectionInfo",
 		mcp.WithDescription("Get current MCP connection info: user, URL, client. Useful for debugging and understanding current session context."),
 	), s.handleGetConnectionInfo)
 
 	// GetFeatures - Feature Detection (Safety Network)
 	// Always registered - provides visibility into what's available
-	s.mcpServer.AddTool(mcp.NewTool("GetFeatures",
-		mcp.WithDescription("Probe SAP system for available features. Returns status of optional capabilities like abapGit, RAP/OData, AMDP debugging, UI5/BSP, and CTS transports. Use this to understand what features are available before attempting to use them."),
+	s.addTool(mcp.NewTool("GetFeatures",
+		mcp.WithDescription("Probe SAP system for available features. Returns status of optional capabilities like abapGit, RAP/OData, AMDP debugging, UI5/BSP, CTS transports and ZADT_VSP. Use this to understand what features are available before attempting to use them."),
+		mcp.WithBoolean("refresh",
+			mcp.Description("Probe again instead of using cached results, e.g. after installing abapGit. Updates the tool list when features became available."),
+		),
 	), s.handleGetFeatures)
 
 	// GetAbapHelp - ABAP Keyword Documentation
 	// Always registered - provides URL and search query, optionally real docs via ZADT_VSP
-	s.mcpServer.AddTool(mcp.NewTool("GetAbapHelp",
+	s.addTool(mcp.NewTool("GetAbapHelp",
 		mcp.WithDescription("Get ABAP keyword documentation. Returns URL to SAP Help Portal and search query. If ZADT_VSP is installed, also returns real documentation from SAP system."),
 		mcp.WithString("keyword",
 			mcp.Required(),
@@ -685,7 +796,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetCallGraph
 	if shouldRegister("GetCallGraph") {
-		s.mcpServer.AddTool(mcp.NewTool("GetCallGraph",
+		s.addTool(mcp.NewTool("GetCallGraph",
 			mcp.WithDescription("Get call hierarchy for methods/functions. Shows callers or callees of an ABAP object."),
 			mcp.WithString("object_uri",
 				mcp.Required(),
@@ -705,7 +816,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetObjectStructure
 	if shouldRegister("GetObjectStructure") {
-		s.mcpServer.AddTool(mcp.NewTool("GetObjectStructure",
+		s.addTool(mcp.NewTool("GetObjectStructure",
 			mcp.WithDescription("Get object explorer tree structure. Returns hierarchical view of object components."),
 			mcp.WithString("object_name",
 				mcp.Required(),
@@ -719,7 +830,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetCallersOf - simplified up traversal
 	if shouldRegister("GetCallersOf") {
-		s.mcpServer.AddTool(mcp.NewTool("GetCallersOf",
+		s.addTool(mcp.NewTool("GetCallersOf",
 			mcp.WithDescription("Find all callers of an ABAP object (up traversal). Shows who calls this method/function. Simplified wrapper around GetCallGraph."),
 			mcp.WithString("object_uri",
 				mcp.Required(),
@@ -733,7 +844,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetCalleesOf - simplified down traversal
 	if shouldRegister("GetCalleesOf") {
-		s.mcpServer.AddTool(mcp.NewTool("GetCalleesOf",
+		s.addTool(mcp.NewTool("GetCalleesOf",
 			mcp.WithDescription("Find all callees of an ABAP object (down traversal). Shows what this method/function calls. Simplified wrapper around GetCallGraph."),
 			mcp.WithString("object_uri",
 				mcp.Required(),
@@ -747,7 +858,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// AnalyzeCallGraph - get call graph statistics
 	if shouldRegister("AnalyzeCallGraph") {
-		s.mcpServer.AddTool(mcp.NewTool("AnalyzeCallGraph",
+		s.addTool(mcp.NewTool("AnalyzeCallGraph",
 			mcp.WithDescription("Analyze call graph for an object. Returns statistics: total nodes, edges, max depth, nodes by type. Use for understanding code complexity and dependencies."),
 			mcp.WithString("object_uri",
 				mcp.Required(),
@@ -764,7 +875,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// CompareCallGraphs - compare static vs actual execution
 	if shouldRegister("CompareCallGraphs") {
-		s.mcpServer.AddTool(mcp.NewTool("CompareCallGraphs",
+		s.addTool(mcp.NewTool("CompareCallGraphs",
 			mcp.WithDescription("Compare static call graph with actual execution trace. Identifies: common paths, untested paths (static only), and dynamic calls (actual only). Use for test coverage analysis and RCA."),
 			mcp.WithString("object_uri",
 				mcp.Required(),
@@ -779,7 +890,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// TraceExecution - composite RCA tool
 	if shouldRegister("TraceExecution") {
-		s.mcpServer.AddTool(mcp.NewTool("TraceExecution",
+		s.addTool(mcp.NewTool("TraceExecution",
 			mcp.WithDescription("COMPOSITE RCA TOOL: Performs traced execution analysis. 1) Builds static call graph from object, 2) Optionally runs unit tests, 3) Collects trace data, 4) Extracts actual call edges, 5) Compares static vs actual for root cause analysis."),
 			mcp.WithString("object_uri",
 				mcp.Required(),
@@ -804,7 +915,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// ListDumps (renamed from GetDumps for consistency with List* pattern)
 	if shouldRegister("ListDumps") {
-		s.mcpServer.AddTool(mcp.NewTool("ListDumps",
+		s.addTool(mcp.NewTool("ListDumps",
 			mcp.WithDescription("List runtime errors (short dumps) from the SAP system. Filter by user, exception type, program, date range."),
 			mcp.WithString("user",
 				mcp.Description("Filter by username"),
@@ -832,7 +943,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetDump
 	if shouldRegister("GetDump") {
-		s.mcpServer.AddTool(mcp.NewTool("GetDump",
+		s.addTool(mcp.NewTool("GetDump",
 			mcp.WithDescription("Get full details of a specific runtime error (short dump) including stack trace."),
 			mcp.WithString("dump_id",
 				mcp.Required(),
@@ -845,7 +956,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// ListTraces
 	if shouldRegister("ListTraces") {
-		s.mcpServer.AddTool(mcp.NewTool("ListTraces",
+		s.addTool(mcp.NewTool("ListTraces",
 			mcp.WithDescription("List ABAP runtime traces (profiler results) from the SAP system."),
 			mcp.WithString("user",
 				mcp.Description("Filter by username"),
@@ -864,7 +975,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetTrace
 	if shouldRegister("GetTrace") {
-		s.mcpServer.AddTool(mcp.NewTool("GetTrace",
+		s.addTool(mcp.NewTool("GetTrace",
 			mcp.WithDescription("Get trace analysis (hitlist, statements, or database accesses) for a specific trace."),
 			mcp.WithString("trace_id",
 				mcp.Required(),
@@ -880,14 +991,14 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetSQLTraceState
 	if shouldRegister("GetSQLTraceState") {
-		s.mcpServer.AddTool(mcp.NewTool("GetSQLTraceState",
+		s.addTool(mcp.NewTool("GetSQLTraceState",
 			mcp.WithDescription("Check if SQL trace (ST05) is currently active."),
 		), s.handleGetSQLTraceState)
 	}
 
 	// ListSQLTraces
 	if shouldRegister("ListSQLTraces") {
-		s.mcpServer.AddTool(mcp.NewTool("ListSQLTraces",
+		s.addTool(mcp.NewTool("ListSQLTraces",
 			mcp.WithDescription("List SQL trace files from ST05."),
 			mcp.WithString("user",
 				mcp.Description("Filter by username"),
@@ -904,8 +1015,8 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// SetBreakpoint - WebSocket-based (supports line, statement, and exception breakpoints)
 	if shouldRegister("SetBreakpoint") {
-		s.mcpServer.AddTool(mcp.NewTool("SetBreakpoint",
-			mcp.WithDescription("Set a breakpoint in ABAP code. Supports three types: 'line' (specific location), 'statement' (ABAP keyword), 'exception' (exception class). For class methods, use 'method' parameter for include-relative line numbers. Uses WebSocket connection to ZADT_VSP."),
+		s.addTool(mcp.NewTool("SetBreakpoint",
+			mcp.WithDescription("Set a breakpoint in ABAP code. Supports three types: 'line' (specific location), 'statement' (ABAP keyword), 'exception' (exception class). For class methods, use the 'method' parameter with a line within the method; it is resolved to the pool-absolute line. Uses WebSocket connection to ZADT_VSP."),
 			mcp.WithString("kind",
 				mcp.Description("Breakpoint type: 'line' (default), 'statement', or 'exception'"),
 			),
@@ -913,10 +1024,10 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 				mcp.Description("Program name for line breakpoints (e.g., 'ZADT_DBG_PROG' or 'ZCL_MY_CLASS')"),
 			),
 			mcp.WithString("method",
-				mcp.Description("Method name for class breakpoints. When specified, line number is relative to method start (line 1 = first line of METHOD implementation). Enables accurate breakpoints in class methods."),
+				mcp.Description("Method name for class breakpoints. When specified, line number is relative to the METHOD statement (line 1, the default) and resolved to the pool-absolute line from the class structure. Changed: earlier versions passed method lines to ZADT_VSP unresolved and documented line 1 as the first line of the implementation after METHOD; add 1 to such lines."),
 			),
 			mcp.WithNumber("line",
-				mcp.Description("Line number for line breakpoints. Without 'method': pool-absolute line. With 'method': relative to method start."),
+				mcp.Description("Line number for line breakpoints. Without 'method': pool-absolute line. With 'method': relative to the METHOD statement."),
 			),
 			mcp.WithString("statement",
 				mcp.Description("ABAP statement for statement breakpoints (e.g., 'CALL FUNCTION', 'SELECT', 'LOOP', 'CALL METHOD')"),
@@ -929,14 +1040,14 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetBreakpoints - WebSocket-based
 	if shouldRegister("GetBreakpoints") {
-		s.mcpServer.AddTool(mcp.NewTool("GetBreakpoints",
+		s.addTool(mcp.NewTool("GetBreakpoints",
 			mcp.WithDescription("Get all breakpoints registered in the current debug session. Uses WebSocket connection to ZADT_VSP."),
 		), s.handleGetBreakpoints)
 	}
 
 	// DeleteBreakpoint - WebSocket-based
 	if shouldRegister("DeleteBreakpoint") {
-		s.mcpServer.AddTool(mcp.NewTool("DeleteBreakpoint",
+		s.addTool(mcp.NewTool("DeleteBreakpoint",
 			mcp.WithDescription("Delete a breakpoint by ID. Uses WebSocket connection to ZADT_VSP."),
 			mcp.WithString("breakpoint_id",
 				mcp.Required(),
@@ -945,9 +1056,99 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		), s.handleDeleteBreakpoint)
 	}
 
+	// SetLogpoint - WebSocket-based line breakpoint that logs and continues
+	if shouldRegister("SetLogpoint") {
+		s.addTool(mcp.NewTool("SetLogpoint",
+			mcp.WithDescription("Set a logpoint: a line breakpoint that does not halt. When DebuggerAttach or DebuggerStep(stepContinue) stops at it, the variables in the message template are read, the message is logged (and added to the active recording), and execution continues automatically. Read the log with GetLogpointLog; remove with DeleteBreakpoint. Uses WebSocket connection to ZADT_VSP."),
+			mcp.WithString("program",
+				mcp.Required(),
+				mcp.Description("Program name (e.g., 'ZORDERS' or 'ZCL_MY_CLASS')"),
+			),
+			mcp.WithNumber("line",
+				mcp.Required(),
+				mcp.Description("Line number (pool-absolute for classes)"),
+			),
+			mcp.WithString("message",
+				mcp.Required(),
+				mcp.Description("Message template; {expression} is replaced by the variable value, e.g. 'order {ls_order-vbeln} total {lv_total}'"),
+			),
+		), s.handleSetLogpoint)
+	}
+
+	// GetLogpointLog
+	if shouldRegister("GetLogpointLog") {
+		s.addTool(mcp.NewTool("GetLogpointLog",
+			mcp.WithDescription("List active logpoints and the messages they logged."),
+			mcp.WithBoolean("clear",
+				mcp.Description("Clear the log after reading (default: false)"),
+			),
+		), s.handleGetLogpointLog)
+	}
+
+	// SaveBreakpointSet - named, system-independent breakpoint sets
+	if shouldRegister("SaveBreakpointSet") {
+		s.addTool(mcp.NewTool("SaveBreakpointSet",
+			mcp.WithDescription("Save a named breakpoint set to .vsp/breakpoints.json. Sets hold line, method, exception, statement and message breakpoints with optional conditions and can be enabled on any system with EnableBreakpointSet, e.g. to reproduce a DEV investigation on QAS."),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Set name (e.g., 'order-total-bug')"),
+			),
+			mcp.WithString("breakpoints",
+				mcp.Description(`JSON array of breakpoints. Fields: kind (line|method|exception|statement|message), program, method, line, exception, statement, message_class, message_number, message_type, condition. Example: [{"kind":"line","program":"ZCL_ORDER","line":42,"condition":"lv_total < 0"},{"kind":"exception","exception":"CX_SY_ZERODIVIDE"}]`),
+			),
+			mcp.WithBoolean("from_system",
+				mcp.Description("Also capture the external breakpoints currently set for the user on the system (default: false)"),
+			),
+			mcp.WithBoolean("append",
+				mcp.Description("Add to an existing set instead of replacing its breakpoints (default: false)"),
+			),
+			mcp.WithString("description",
+				mcp.Description("Optional description"),
+			),
+		), s.handleSaveBreakpointSet)
+	}
+
+	// ListBreakpointSets
+	if shouldRegister("ListBreakpointSets") {
+		s.addTool(mcp.NewTool("ListBreakpointSets",
+			mcp.WithDescription("List saved breakpoint sets, their breakpoints and the systems they are enabled on."),
+			mcp.WithString("name",
+				mcp.Description("Show only this set"),
+			),
+		), s.handleListBreakpointSets)
+	}
+
+	// EnableBreakpointSet
+	if shouldRegister("EnableBreakpointSet") {
+		s.addTool(mcp.NewTool("EnableBreakpointSet",
+			mcp.WithDescription("Create the breakpoints of a saved set on the connected system. By default they are set in the ZADT_VSP WebSocket session (like SetBreakpoint; message breakpoints are not supported there). With external=true they are created as external breakpoints via ADT REST, which outlive the session (message breakpoints are only supported there). Method breakpoints are resolved to line breakpoints at the method's current line. Enabling an already enabled set replaces its breakpoints."),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Set name"),
+			),
+			mcp.WithBoolean("external",
+				mcp.Description("Create external breakpoints via ADT REST instead of ZADT_VSP session breakpoints (default: false)"),
+			),
+		), s.handleEnableBreakpointSet)
+	}
+
+	// DisableBreakpointSet
+	if shouldRegister("DisableBreakpointSet") {
+		s.addTool(mcp.NewTool("DisableBreakpointSet",
+			mcp.WithDescription("Delete the breakpoints a set created on the connected system with EnableBreakpointSet. The set itself is kept."),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Set name"),
+			),
+			mcp.WithBoolean("external",
+				mcp.Description("The set was enabled with external=true (default: false)"),
+			),
+		), s.handleDisableBreakpointSet)
+	}
+
 	// CallRFC - WebSocket-based RFC execution
 	if shouldRegister("CallRFC") {
-		s.mcpServer.AddTool(mcp.NewTool("CallRFC",
+		s.addTool(mcp.NewTool("CallRFC",
 			mcp.WithDescription("Call a function module via WebSocket (ZADT_VSP). Useful for triggering ABAP code execution to hit breakpoints. Parameters are passed as key-value pairs."),
 			mcp.WithString("function",
 				mcp.Required(),
@@ -959,9 +1160,28 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		), s.handleCallRFC)
 	}
 
+	// CallDomain - generic access to ZADT_VSP domains, including custom ones
+	if shouldRegister("CallDomain") {
+		s.addTool(mcp.NewTool("CallDomain",
+			mcp.WithDescription("Call an action of any ZADT_VSP domain, including custom services installed on the SAP side. Without domain, lists the domains with their versions and actions. Without action, describes the domain's actions and their param JSON schemas. Params are validated against the schema before the call. Actions follow the safety configuration; built-in domain actions are refused when their dedicated tools are disabled, and custom domain actions count as workflows (blocked in read-only mode)."),
+			mcp.WithString("domain",
+				mcp.Description("Domain name (e.g., 'rfc', or a custom domain like 'bal')"),
+			),
+			mcp.WithString("action",
+				mcp.Description("Action name within the domain"),
+			),
+			mcp.WithObject("params",
+				mcp.Description("Action params as a JSON object"),
+			),
+			mcp.WithNumber("timeout",
+				mcp.Description("Timeout in seconds (default: 60)"),
+			),
+		), s.handleCallDomain)
+	}
+
 	// MoveObject - Move object to different package via WebSocket
 	if shouldRegister("MoveObject") {
-		s.mcpServer.AddTool(mcp.NewTool("MoveObject",
+		s.addTool(mcp.NewTool("MoveObject",
 			mcp.WithDescription("Move an ABAP object to a different package. Uses ZADT_VSP WebSocket to call TR_TADIR_INTERFACE. Requires ZADT_VSP deployed."),
 			mcp.WithString("object_type",
 				mcp.Required(),
@@ -980,7 +1200,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// DebuggerListen
 	if shouldRegister("DebuggerListen") {
-		s.mcpServer.AddTool(mcp.NewTool("DebuggerListen",
+		s.addTool(mcp.NewTool("DebuggerListen",
 			mcp.WithDescription("Start a debug listener that waits for a debuggee to hit a breakpoint. This is a BLOCKING call that uses long-polling. Returns when a debuggee is caught, timeout occurs, or a conflict is detected."),
 			mcp.WithString("user",
 				mcp.Description("User to listen for (defaults to current user)"),
@@ -993,7 +1213,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// DebuggerAttach
 	if shouldRegister("DebuggerAttach") {
-		s.mcpServer.AddTool(mcp.NewTool("DebuggerAttach",
+		s.addTool(mcp.NewTool("DebuggerAttach",
 			mcp.WithDescription("Attach to a debuggee that has hit a breakpoint. Use the debuggee_id from DebuggerListen result."),
 			mcp.WithString("debuggee_id",
 				mcp.Required(),
@@ -1007,14 +1227,14 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// DebuggerDetach
 	if shouldRegister("DebuggerDetach") {
-		s.mcpServer.AddTool(mcp.NewTool("DebuggerDetach",
+		s.addTool(mcp.NewTool("DebuggerDetach",
 			mcp.WithDescription("Detach from the current debug session and release the debuggee."),
 		), s.handleDebuggerDetach)
 	}
 
 	// DebuggerStep
 	if shouldRegister("DebuggerStep") {
-		s.mcpServer.AddTool(mcp.NewTool("DebuggerStep",
+		s.addTool(mcp.NewTool("DebuggerStep",
 			mcp.WithDescription("Perform a step operation in the debugger."),
 			mcp.WithString("step_type",
 				mcp.Required(),
@@ -1028,14 +1248,14 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// DebuggerGetStack
 	if shouldRegister("DebuggerGetStack") {
-		s.mcpServer.AddTool(mcp.NewTool("DebuggerGetStack",
+		s.addTool(mcp.NewTool("DebuggerGetStack",
 			mcp.WithDescription("Get the current call stack during a debug session."),
 		), s.handleDebuggerGetStack)
 	}
 
 	// DebuggerGetVariables
 	if shouldRegister("DebuggerGetVariables") {
-		s.mcpServer.AddTool(mcp.NewTool("DebuggerGetVariables",
+		s.addTool(mcp.NewTool("DebuggerGetVariables",
 			mcp.WithDescription("Get variable values during a debug session. Use '@ROOT' to get top-level variables, or specific variable IDs to get their values."),
 			mcp.WithArray("variable_ids",
 				mcp.Description("Variable IDs to retrieve (e.g., ['@ROOT'] for top-level, or specific IDs like ['LV_COUNT', 'LS_DATA'])"),
@@ -1043,9 +1263,173 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		), s.handleDebuggerGetVariables)
 	}
 
+	// --- Execution Recording & History (time-travel debugging) ---
+
+	// StartRecording
+	if shouldRegister("StartRecording") {
+		s.addTool(mcp.NewTool("StartRecording",
+			mcp.WithDescription("Start recording the debug session. While active, every DebuggerAttach and DebuggerStep captures the current location and top-level variables, enabling time-travel queries (GetStateAtStep, FindVariableChanges)."),
+			mcp.WithString("program",
+				mcp.Description("Program being debugged (used for filtering saved recordings)"),
+			),
+			mcp.WithString("session_id",
+				mcp.Description("Session identifier stored with the recording (default: 'mcp')"),
+			),
+			mcp.WithString("description",
+				mcp.Description("Free-text description (e.g., the bug being investigated)"),
+			),
+		), s.handleStartRecording)
+	}
+
+	// StopRecording
+	if shouldRegister("StopRecording") {
+		s.addTool(mcp.NewTool("StopRecording",
+			mcp.WithDescription("Stop the active recording, show its statistics and save it to .vsp-recordings."),
+			mcp.WithBoolean("save",
+				mcp.Description("Save the recording to disk (default: true)"),
+			),
+		), s.handleStopRecording)
+	}
+
+	// ListRecordings
+	if shouldRegister("ListRecordings") {
+		s.addTool(mcp.NewTool("ListRecordings",
+			mcp.WithDescription("List saved execution recordings, newest first."),
+			mcp.WithString("program",
+				mcp.Description("Filter by program name (substring match)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of recordings (default: 20)"),
+			),
+		), s.handleListRecordings)
+	}
+
+	// SearchHistory
+	if shouldRegister("SearchHistory") {
+		s.addTool(mcp.NewTool("SearchHistory",
+			mcp.WithDescription("Search all saved recordings for steps where a variable had a value, a variable changed, execution reached a program, or a checkpoint was set."),
+			mcp.WithString("match_type",
+				mcp.Required(),
+				mcp.Description("Match type: 'variable_value', 'variable_changed', 'location', or 'checkpoint'"),
+			),
+			mcp.WithString("variable",
+				mcp.Description("Variable name (for variable_value and variable_changed)"),
+			),
+			mcp.WithString("value",
+				mcp.Description("Value to match (for variable_value)"),
+			),
+			mcp.WithString("location",
+				mcp.Description("Program name pattern (for location)"),
+			),
+			mcp.WithString("checkpoint",
+				mcp.Description("Checkpoint name pattern (for checkpoint; empty matches all)"),
+			),
+			mcp.WithString("program",
+				mcp.Description("Only search recordings of this program"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of matches (default: 50)"),
+			),
+		), s.handleSearchHistory)
+	}
+
+	// CompareRecordings
+	if shouldRegister("CompareRecordings") {
+		s.addTool(mcp.NewTool("CompareRecordings",
+			mcp.WithDescription("Compare two saved recordings step by step: step counts, execution path divergence and variable differences. Useful for comparing a good run against a failing one."),
+			mcp.WithString("id1",
+				mcp.Required(),
+				mcp.Description("First recording ID"),
+			),
+			mcp.WithString("id2",
+				mcp.Required(),
+				mcp.Description("Second recording ID"),
+			),
+		), s.handleCompareRecordings)
+	}
+
+	// GetStateAtStep
+	if shouldRegister("GetStateAtStep") {
+		s.addTool(mcp.NewTool("GetStateAtStep",
+			mcp.WithDescription("Reconstruct location and variable values at a given step of a recording. Variables changed at that step are marked with '*'."),
+			mcp.WithNumber("step",
+				mcp.Required(),
+				mcp.Description("Step number (1-based)"),
+			),
+			mcp.WithString("recording_id",
+				mcp.Description("Saved recording ID (default: the active recording)"),
+			),
+			mcp.WithArray("variables",
+				mcp.Description("Only show these variables (e.g., ['LV_STATUS', 'LS_ORDER'])"),
+			),
+		), s.handleGetStateAtStep)
+	}
+
+	// FindVariableChanges
+	if shouldRegister("FindVariableChanges") {
+		s.addTool(mcp.NewTool("FindVariableChanges",
+			mcp.WithDescription("Show every step at which a variable changed, with old and new values. With 'value', report the first step at which the variable took that value."),
+			mcp.WithString("variable",
+				mcp.Required(),
+				mcp.Description("Variable name (e.g., 'LV_STATUS')"),
+			),
+			mcp.WithString("value",
+				mcp.Description("Target value to find"),
+			),
+			mcp.WithString("recording_id",
+				mcp.Description("Saved recording ID (default: the active recording)"),
+			),
+		), s.handleFindVariableChanges)
+	}
+
+	// GenerateTestFromRecording
+	if shouldRegister("GenerateTestFromRecording") {
+		s.addTool(mcp.NewTool("GenerateTestFromRecording",
+			mcp.WithDescription("Generate an ABAP Unit test class from a recorded method call: recorded inputs become given data, recorded outputs become cl_abap_unit_assert expectations, and recorded DB and function module calls become cl_osql_test_environment / function module test doubles. By default the method boundary is the first run of steps in the class and parameters are found by naming convention (IV_/EV_/CV_/RV_...). With deploy=true the source is added to the class's local test include after any existing test classes (refused if the include already defines the generated class), activated and run."),
+			mcp.WithString("class",
+				mcp.Required(),
+				mcp.Description("Class under test (e.g., 'ZCL_PRICING')"),
+			),
+			mcp.WithString("method",
+				mcp.Required(),
+				mcp.Description("Method under test"),
+			),
+			mcp.WithString("recording_id",
+				mcp.Description("Saved recording ID (default: the active recording)"),
+			),
+			mcp.WithBoolean("static",
+				mcp.Description("Static method (default: false)"),
+			),
+			mcp.WithNumber("entry_step",
+				mcp.Description("First step inside the method (default: first step in the class)"),
+			),
+			mcp.WithNumber("exit_step",
+				mcp.Description("Last step inside the method (default: last consecutive step in the class)"),
+			),
+			mcp.WithString("importing",
+				mcp.Description("Comma-separated importing parameters (overrides naming convention)"),
+			),
+			mcp.WithString("exporting",
+				mcp.Description("Comma-separated exporting parameters"),
+			),
+			mcp.WithString("changing",
+				mcp.Description("Comma-separated changing parameters"),
+			),
+			mcp.WithString("returning",
+				mcp.Description("Returning parameter"),
+			),
+			mcp.WithBoolean("deploy",
+				mcp.Description("Write the test to the class's test include, activate and run it (default: false)"),
+			),
+			mcp.WithString("transport",
+				mcp.Description("Transport request for deploy"),
+			),
+		), s.handleGenerateTestFromRecording)
+	}
+
 	// SearchObject
 	if shouldRegister("SearchObject") {
-		s.mcpServer.AddTool(mcp.NewTool("SearchObject",
+		s.addTool(mcp.NewTool("SearchObject",
 		mcp.WithDescription("Search for ABAP objects using quick search"),
 		mcp.WithString("query",
 			mcp.Required(),
@@ -1062,7 +1446,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// SyntaxCheck
 	if shouldRegister("SyntaxCheck") {
-		s.mcpServer.AddTool(mcp.NewTool("SyntaxCheck",
+		s.addTool(mcp.NewTool("SyntaxCheck",
 		mcp.WithDescription("Check ABAP source code for syntax errors"),
 		mcp.WithString("object_url",
 			mcp.Required(),
@@ -1078,7 +1462,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// Activate
 	if shouldRegister("Activate") {
-		s.mcpServer.AddTool(mcp.NewTool("Activate",
+		s.addTool(mcp.NewTool("Activate",
 		mcp.WithDescription("Activate an ABAP object"),
 		mcp.WithString("object_url",
 			mcp.Required(),
@@ -1093,7 +1477,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// ActivatePackage - Batch activation of inactive objects
 	if shouldRegister("ActivatePackage") {
-		s.mcpServer.AddTool(mcp.NewTool("ActivatePackage",
+		s.addTool(mcp.NewTool("ActivatePackage",
 			mcp.WithDescription("Activate all inactive objects. Objects are sorted by dependency order (interfaces before classes). If no package specified, activates ALL inactive objects for current user."),
 			mcp.WithString("package",
 				mcp.Description("Package name to filter (optional, empty = all packages)"),
@@ -1106,7 +1490,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// RunUnitTests
 	if shouldRegister("RunUnitTests") {
-		s.mcpServer.AddTool(mcp.NewTool("RunUnitTests",
+		s.addTool(mcp.NewTool("RunUnitTests",
 		mcp.WithDescription("Run ABAP Unit tests for an object"),
 		mcp.WithString("object_url",
 			mcp.Required(),
@@ -1125,7 +1509,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// RunATCCheck - Convenience tool (combines variant + run + worklist)
 	if shouldRegister("RunATCCheck") {
-		s.mcpServer.AddTool(mcp.NewTool("RunATCCheck",
+		s.addTool(mcp.NewTool("RunATCCheck",
 			mcp.WithDescription("Run ATC (ABAP Test Cockpit) code quality check on an object. Returns findings with priority, check title, message, and location. Priority: 1=Error, 2=Warning, 3=Info."),
 			mcp.WithString("object_url",
 				mcp.Required(),
@@ -1142,7 +1526,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetATCCustomizing - Expert mode: get ATC configuration
 	if shouldRegister("GetATCCustomizing") {
-		s.mcpServer.AddTool(mcp.NewTool("GetATCCustomizing",
+		s.addTool(mcp.NewTool("GetATCCustomizing",
 			mcp.WithDescription("Get ATC system configuration including default check variant and exemption reasons"),
 		), s.handleGetATCCustomizing)
 	}
@@ -1152,7 +1536,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// LockObject
 	if shouldRegister("LockObject") {
-		s.mcpServer.AddTool(mcp.NewTool("LockObject",
+		s.addTool(mcp.NewTool("LockObject",
 		mcp.WithDescription("Acquire an edit lock on an ABAP object"),
 		mcp.WithString("object_url",
 			mcp.Required(),
@@ -1167,7 +1551,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// UnlockObject
 	if shouldRegister("UnlockObject") {
-		s.mcpServer.AddTool(mcp.NewTool("UnlockObject",
+		s.addTool(mcp.NewTool("UnlockObject",
 		mcp.WithDescription("Release an edit lock on an ABAP object"),
 		mcp.WithString("object_url",
 			mcp.Required(),
@@ -1183,7 +1567,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// UpdateSource
 	if shouldRegister("UpdateSource") {
-		s.mcpServer.AddTool(mcp.NewTool("UpdateSource",
+		s.addTool(mcp.NewTool("UpdateSource",
 		mcp.WithDescription("Write source code to an ABAP object (requires lock)"),
 		mcp.WithString("object_url",
 			mcp.Required(),
@@ -1206,11 +1590,11 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// CreateObject
 	if shouldRegister("CreateObject") {
-		s.mcpServer.AddTool(mcp.NewTool("CreateObject",
-		mcp.WithDescription("Create a new ABAP object. Supports: PROG/P (program), CLAS/OC (class), INTF/OI (interface), PROG/I (include), FUGR/F (function group), FUGR/FF (function module), DEVC/K (package), DDLS/DF (CDS view), BDEF/BDO (behavior definition), SRVD/SRV (service definition), SRVB/SVB (service binding)"),
+		s.addTool(mcp.NewTool("CreateObject",
+		mcp.WithDescription("Create a new ABAP object. Supports: PROG/P (program), CLAS/OC (class), INTF/OI (interface), PROG/I (include), FUGR/F (function group), FUGR/FF (function module), DEVC/K (package), DDLS/DF (CDS view), DDLX/EX (metadata extension), BDEF/BDO (behavior definition), SRVD/SRV (service definition), SRVB/SVB (service binding)"),
 		mcp.WithString("object_type",
 			mcp.Required(),
-			mcp.Description("Object type: PROG/P, CLAS/OC, INTF/OI, PROG/I, FUGR/F, FUGR/FF, DEVC/K, DDLS/DF, BDEF/BDO, SRVD/SRV, SRVB/SVB"),
+			mcp.Description("Object type: PROG/P, CLAS/OC, INTF/OI, PROG/I, FUGR/F, FUGR/FF, DEVC/K, DDLS/DF, DDLX/EX, BDEF/BDO, SRVD/SRV, SRVB/SVB"),
 		),
 		mcp.WithString("name",
 			mcp.Required(),
@@ -1245,7 +1629,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// CreatePackage - simplified package creation for focused mode
 	if shouldRegister("CreatePackage") {
-		s.mcpServer.AddTool(mcp.NewTool("CreatePackage",
+		s.addTool(mcp.NewTool("CreatePackage",
 		mcp.WithDescription("Create a new ABAP package. Local packages ($*) work by default. Transportable packages require --enable-transports flag and transport parameter."),
 		mcp.WithString("name",
 			mcp.Required(),
@@ -1269,7 +1653,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// CreateTable - Create DDIC tables from JSON
 	if shouldRegister("CreateTable") {
-		s.mcpServer.AddTool(mcp.NewTool("CreateTable",
+		s.addTool(mcp.NewTool("CreateTable",
 			mcp.WithDescription("Create a DDIC transparent table from a simple JSON definition. Handles full workflow: create → set source → activate. Supports common ABAP types: CHAR, NUMC, INT4, DEC, STRING, TIMESTAMPL, UUID, etc."),
 			mcp.WithString("name",
 				mcp.Required(),
@@ -1295,9 +1679,72 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		), s.handleCreateTable)
 	}
 
+	// GetDDIC - Read DDIC object definitions
+	if shouldRegister("GetDDIC") {
+		s.addTool(mcp.NewTool("GetDDIC",
+			mcp.WithDescription("Read the definition of a DDIC object as JSON: domain, data element, structure (with DDL source), table type or elementary search help. The JSON can be changed and passed to WriteDDIC."),
+			mcp.WithString("type",
+				mcp.Required(),
+				mcp.Description("DDIC type: DOMA, DTEL, STRU, TTYP or SHLP"),
+			),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Object name"),
+			),
+		), s.handleGetDDIC)
+	}
+
+	// WriteDDIC - Create or update DDIC objects from JSON
+	if shouldRegister("WriteDDIC") {
+		s.addTool(mcp.NewTool("WriteDDIC",
+			mcp.WithDescription("Create or update a DDIC object from a JSON definition and activate it. Create domains first, then data elements, structures and table types, so fields are typed by data elements. Updates merge the given top-level properties into the current definition."),
+			mcp.WithString("type",
+				mcp.Required(),
+				mcp.Description("DDIC type: DOMA, DTEL, STRU, TTYP or SHLP"),
+			),
+			mcp.WithObject("definition",
+				mcp.Required(),
+				mcp.Description(`JSON definition (object or JSON string). DOMA: {"name","description","dataType":"CHAR","length":1,"decimals","conversionExit","lowercase","valueTable","fixValues":[{"low":"N","text":"New"}]}. DTEL: {"name","description","domain"} or {"dataType","length","decimals"}, labels "shortLabel","mediumLabel","longLabel","heading", "searchHelp". STRU: {"name","description","fields":[{"name":"ID","type":"ZDATA_ELEMENT"}]} or {"source":"<DDL>"}. TTYP: {"name","description","rowType":"ZSTRUCT"} or {"dataType","length"}, "accessType":"standard|sorted|hashed", "keyDefinition":"standard|rowType|keyComponents", "keyKind":"nonUnique|unique", "keyComponents":["ID"]. SHLP: {"name","description","selectionMethod":"ZTABLE","dialogType":"D","parameters":[{"name":"ID","dataElement":"ZID","import":true,"export":true,"listPosition":1,"selectionPosition":1}]}`),
+			),
+			mcp.WithString("name",
+				mcp.Description("Object name (overrides the definition)"),
+			),
+			mcp.WithString("package",
+				mcp.Description("Target package for new objects (default: $TMP). Updates keep the current package; a different one is refused"),
+			),
+			mcp.WithString("transport",
+				mcp.Description("Transport request number (optional for $TMP)"),
+			),
+			mcp.WithString("mode",
+				mcp.Description("upsert (default), create or update"),
+			),
+			mcp.WithBoolean("activate",
+				mcp.Description("Activate after writing (default: true)"),
+			),
+		), s.handleWriteDDIC)
+	}
+
+	// DeleteDDIC - Delete DDIC objects
+	if shouldRegister("DeleteDDIC") {
+		s.addTool(mcp.NewTool("DeleteDDIC",
+			mcp.WithDescription("Delete a DDIC object. Objects still used by others cannot be deleted."),
+			mcp.WithString("type",
+				mcp.Required(),
+				mcp.Description("DDIC type: DOMA, DTEL, STRU, TTYP or SHLP"),
+			),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Object name"),
+			),
+			mcp.WithString("transport",
+				mcp.Description("Transport request number (optional for $TMP)"),
+			),
+		), s.handleDeleteDDIC)
+	}
+
 	// CompareSource - Diff two objects
 	if shouldRegister("CompareSource") {
-		s.mcpServer.AddTool(mcp.NewTool("CompareSource",
+		s.addTool(mcp.NewTool("CompareSource",
 			mcp.WithDescription("Compare source code of two objects and return unified diff. Supports all object types from GetSource."),
 			mcp.WithString("type1",
 				mcp.Required(),
@@ -1332,7 +1779,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// CloneObject - Copy object to new name
 	if shouldRegister("CloneObject") {
-		s.mcpServer.AddTool(mcp.NewTool("CloneObject",
+		s.addTool(mcp.NewTool("CloneObject",
 			mcp.WithDescription("Copy an ABAP object to a new name. Replaces object name in source. Supports PROG, CLAS, INTF."),
 			mcp.WithString("object_type",
 				mcp.Required(),
@@ -1355,7 +1802,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetClassInfo - Quick class metadata
 	if shouldRegister("GetClassInfo") {
-		s.mcpServer.AddTool(mcp.NewTool("GetClassInfo",
+		s.addTool(mcp.NewTool("GetClassInfo",
 			mcp.WithDescription("Get class metadata without full source: methods, attributes, interfaces, superclass, abstract/final status."),
 			mcp.WithString("class_name",
 				mcp.Required(),
@@ -1366,7 +1813,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// DeleteObject
 	if shouldRegister("DeleteObject") {
-		s.mcpServer.AddTool(mcp.NewTool("DeleteObject",
+		s.addTool(mcp.NewTool("DeleteObject",
 		mcp.WithDescription("Delete an ABAP object (requires lock)"),
 		mcp.WithString("object_url",
 			mcp.Required(),
@@ -1387,7 +1834,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetClassInclude
 	if shouldRegister("GetClassInclude") {
-		s.mcpServer.AddTool(mcp.NewTool("GetClassInclude",
+		s.addTool(mcp.NewTool("GetClassInclude",
 		mcp.WithDescription("Retrieve source code of a class include (definitions, implementations, macros, testclasses)"),
 		mcp.WithString("class_name",
 			mcp.Required(),
@@ -1403,7 +1850,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// CreateTestInclude
 	if shouldRegister("CreateTestInclude") {
-		s.mcpServer.AddTool(mcp.NewTool("CreateTestInclude",
+		s.addTool(mcp.NewTool("CreateTestInclude",
 		mcp.WithDescription("Create the test classes include for a class (required before writing test code)"),
 		mcp.WithString("class_name",
 			mcp.Required(),
@@ -1422,7 +1869,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// UpdateClassInclude
 	if shouldRegister("UpdateClassInclude") {
-		s.mcpServer.AddTool(mcp.NewTool("UpdateClassInclude",
+		s.addTool(mcp.NewTool("UpdateClassInclude",
 		mcp.WithDescription("Update source code of a class include (requires lock on parent class)"),
 		mcp.WithString("class_name",
 			mcp.Required(),
@@ -1449,7 +1896,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// PublishServiceBinding
 	if shouldRegister("PublishServiceBinding") {
-		s.mcpServer.AddTool(mcp.NewTool("PublishServiceBinding",
+		s.addTool(mcp.NewTool("PublishServiceBinding",
 		mcp.WithDescription("Publish a service binding to make it available as OData service"),
 		mcp.WithString("service_name",
 			mcp.Required(),
@@ -1458,13 +1905,16 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		mcp.WithString("service_version",
 			mcp.Description("Service version (default: 0001)"),
 		),
+		mcp.WithString("odata_version",
+			mcp.Description("OData version of the binding: V2 (default) or V4"),
+		),
 	), s.handlePublishServiceBinding)
 	}
 
 
 	// UnpublishServiceBinding
 	if shouldRegister("UnpublishServiceBinding") {
-		s.mcpServer.AddTool(mcp.NewTool("UnpublishServiceBinding",
+		s.addTool(mcp.NewTool("UnpublishServiceBinding",
 		mcp.WithDescription("Unpublish a service binding"),
 		mcp.WithString("service_name",
 			mcp.Required(),
@@ -1473,15 +1923,80 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		mcp.WithString("service_version",
 			mcp.Description("Service version (default: 0001)"),
 		),
+		mcp.WithString("odata_version",
+			mcp.Description("OData version of the binding: V2 (default) or V4"),
+		),
 	), s.handleUnpublishServiceBinding)
 	}
 
+	// TestODataService
+	if shouldRegister("TestODataService") {
+		s.addTool(mcp.NewTool("TestODataService",
+			mcp.WithDescription("Smoke test a published OData service: fetch $metadata, list the entity sets and query each with $top, $filter (on a key of the first row) and $expand (first navigation property). Optionally runs a draft create, prepare, activate and delete round trip (OData V4; changes data, so it needs create and delete operations and is blocked in read-only mode). Reports HTTP status, timing and a payload sample per request."),
+			mcp.WithString("binding",
+				mcp.Description("Service binding whose service URL is tested (e.g., ZUI_TRAVEL_O4)"),
+			),
+			mcp.WithString("service_url",
+				mcp.Description("Service root URL below /sap/opu/odata/ or /sap/opu/odata4/, instead of binding (e.g., /sap/opu/odata4/sap/zui_travel_o4/srvd/sap/zui_travel/0001/)"),
+			),
+			mcp.WithString("entity_sets",
+				mcp.Description("Comma-separated entity sets to query (default: all)"),
+			),
+			mcp.WithNumber("top",
+				mcp.Description("Rows per query (default: 3)"),
+			),
+			mcp.WithBoolean("draft",
+				mcp.Description("Run a draft round trip: create a draft, activate it and delete the active instance (default: false)"),
+			),
+			mcp.WithString("draft_entity_set",
+				mcp.Description("Entity set for the draft round trip (default: first draft-enabled set)"),
+			),
+			mcp.WithObject("draft_payload",
+				mcp.Description("Properties of the created draft, e.g. {\"Description\":\"Smoke test\"}. Mandatory fields must be set for activation to succeed."),
+			),
+			mcp.WithNumber("sample_size",
+				mcp.Description("Payload sample length in bytes (default: 500)"),
+			),
+		), s.handleTestODataService)
+	}
+
+	// GenerateRAPStack
+	if shouldRegister("GenerateRAPStack") {
+		s.addTool(mcp.NewTool("GenerateRAPStack",
+			mcp.WithDescription("Generate a draft-enabled RAP business object with an OData V4 UI service for a table: interface and projection CDS views, metadata extension, managed and projection behavior definitions, behavior pool, service definition and service binding. Activates everything and publishes the binding. The table needs local_last_changed_at and last_changed_at fields; with spec, the table is created and missing admin fields are added."),
+			mcp.WithString("table",
+				mcp.Description("Existing table to build the stack on (e.g., ZTRAVEL)"),
+			),
+			mcp.WithObject("spec",
+				mcp.Description("Table to create first, as for CreateTable: {\"name\":\"ZTRAVEL\",\"description\":\"...\",\"fields\":[{\"name\":\"travel_uuid\",\"type\":\"SYSUUID_X16\",\"key\":true},...]}. A single UUID key gets managed numbering."),
+			),
+			mcp.WithString("name",
+				mcp.Description("Entity name used in object names, e.g. TRAVEL gives ZR_TRAVEL, ZC_TRAVEL, ZBP_R_TRAVEL, ZUI_TRAVEL, ZUI_TRAVEL_O4 (default: table name without Z/Y prefix)"),
+			),
+			mcp.WithString("description",
+				mcp.Description("Label of the views and service (default: entity name)"),
+			),
+			mcp.WithString("package",
+				mcp.Description("Target package (default: $TMP)"),
+			),
+			mcp.WithString("transport",
+				mcp.Description("Transport request (required for transportable packages)"),
+			),
+			mcp.WithString("draft_table",
+				mcp.Description("Draft table name, max 16 characters (default: table name + _D)"),
+			),
+			mcp.WithBoolean("publish",
+				mcp.Description("Publish the service binding (default: true)"),
+			),
+		), s.handleGenerateRAPStack)
+	}
+
 
 	// --- Workflow Tools ---
 
 	// WriteProgram
 	if shouldRegister("WriteProgram") {
-		s.mcpServer.AddTool(mcp.NewTool("WriteProgram",
+		s.addTool(mcp.NewTool("WriteProgram",
 		mcp.WithDescription("Update an existing program with syntax check and activation (Lock -> SyntaxCheck -> Update -> Unlock -> Activate)"),
 		mcp.WithString("program_name",
 			mcp.Required(),
@@ -1500,7 +2015,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// WriteClass
 	if shouldRegister("WriteClass") {
-		s.mcpServer.AddTool(mcp.NewTool("WriteClass",
+		s.addTool(mcp.NewTool("WriteClass",
 		mcp.WithDescription("Update an existing class with syntax check and activation (Lock -> SyntaxCheck -> Update -> Unlock -> Activate)"),
 		mcp.WithString("class_name",
 			mcp.Required(),
@@ -1519,7 +2034,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// CreateAndActivateProgram
 	if shouldRegister("CreateAndActivateProgram") {
-		s.mcpServer.AddTool(mcp.NewTool("CreateAndActivateProgram",
+		s.addTool(mcp.NewTool("CreateAndActivateProgram",
 		mcp.WithDescription("Create a new program with source code and activate it (Create -> Lock -> Update -> Unlock -> Activate)"),
 		mcp.WithString("program_name",
 			mcp.Required(),
@@ -1546,7 +2061,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// CreateClassWithTests
 	if shouldRegister("CreateClassWithTests") {
-		s.mcpServer.AddTool(mcp.NewTool("CreateClassWithTests",
+		s.addTool(mcp.NewTool("CreateClassWithTests",
 		mcp.WithDescription("Create a new class with unit tests and run them (Create -> Lock -> Update -> CreateTestInclude -> UpdateTest -> Unlock -> Activate -> RunTests)"),
 		mcp.WithString("class_name",
 			mcp.Required(),
@@ -1579,7 +2094,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// DeployFromFile (Recommended)
 	if shouldRegister("DeployFromFile") {
-		s.mcpServer.AddTool(mcp.NewTool("DeployFromFile",
+		s.addTool(mcp.NewTool("DeployFromFile",
 		mcp.WithDescription("✅ RECOMMENDED - Smart deploy from file: auto-detects if object exists and creates/updates accordingly. Solves token limit problem for large generated files (ML models, 3948+ lines). Example: DeployFromFile(file_path=\"/path/to/zcl_ml_iris.clas.abap\", package_name=\"$ZAML_IRIS\") deploys any size file. Workflow: Parse → Check existence → Create or Update → Lock → SyntaxCheck → Write → Unlock → Activate. Supports .clas.abap, .prog.abap, .intf.abap, .fugr.abap, .func.abap. Use this for all file-based deployments."),
 		mcp.WithString("file_path",
 			mcp.Required(),
@@ -1598,7 +2113,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// SaveToFile
 	if shouldRegister("SaveToFile") {
-		s.mcpServer.AddTool(mcp.NewTool("SaveToFile",
+		s.addTool(mcp.NewTool("SaveToFile",
 		mcp.WithDescription("Save ABAP object source to local file (SAP → File). Enables BIDIRECTIONAL SYNC WORKFLOW: (1) SaveToFile downloads object from SAP, (2) edit locally with vim/VS Code/AI assistants, (3) DeployFromFile uploads changes back to SAP. Example: SaveToFile(objType=\"CLAS/OC\", objectName=\"ZCL_ML_IRIS\", outputPath=\"./src/\") creates ./src/zcl_ml_iris.clas.abap. Then edit locally and use DeployFromFile to sync back. Recommended for iterative development. Auto-determines file extension."),
 		mcp.WithString("objType",
 			mcp.Required(),
@@ -1627,7 +2142,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// RenameObject
 	if shouldRegister("RenameObject") {
-		s.mcpServer.AddTool(mcp.NewTool("RenameObject",
+		s.addTool(mcp.NewTool("RenameObject",
 		mcp.WithDescription("Rename ABAP object by creating copy with new name and deleting old one. Useful for fixing naming conventions. Workflow: GetSource → Replace names → CreateNew → ActivateNew → DeleteOld"),
 		mcp.WithString("objType",
 			mcp.Required(),
@@ -1656,7 +2171,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// EditSource
 	if shouldRegister("EditSource") {
-		s.mcpServer.AddTool(mcp.NewTool("EditSource",
+		s.addTool(mcp.NewTool("EditSource",
 		mcp.WithDescription("Surgical string replacement on ABAP source code. Matches the Edit tool pattern for local files. Workflow: GetSource → FindReplace → SyntaxCheck → Lock → Update → Unlock → Activate. Example: EditSource(object_url=\"/sap/bc/adt/programs/programs/ZTEST\", old_string=\"METHOD foo.\\n  ENDMETHOD.\", new_string=\"METHOD foo.\\n  rv_result = 42.\\n  ENDMETHOD.\", replace_all=false, syntax_check=true). Requires unique match if replace_all=false. Use this for incremental edits between syntax checks - no need to download/upload full source!"),
 		mcp.WithString("object_url",
 			mcp.Required(),
@@ -1693,7 +2208,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GrepObject
 	if shouldRegister("GrepObject") {
-		s.mcpServer.AddTool(mcp.NewTool("GrepObject",
+		s.addTool(mcp.NewTool("GrepObject",
 		mcp.WithDescription("Search for regex pattern in a single ABAP object's source code. Returns matches with line numbers and optional context. Use for finding TODO comments, string literals, patterns, or code snippets before editing."),
 		mcp.WithString("object_url",
 			mcp.Required(),
@@ -1715,7 +2230,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GrepPackage
 	if shouldRegister("GrepPackage") {
-		s.mcpServer.AddTool(mcp.NewTool("GrepPackage",
+		s.addTool(mcp.NewTool("GrepPackage",
 		mcp.WithDescription("Search for regex pattern across all source objects in an ABAP package. Returns matches grouped by object. Use for package-wide analysis, finding patterns across multiple programs/classes."),
 		mcp.WithString("package_name",
 			mcp.Required(),
@@ -1752,7 +2267,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// FindDefinition
 	if shouldRegister("FindDefinition") {
-		s.mcpServer.AddTool(mcp.NewTool("FindDefinition",
+		s.addTool(mcp.NewTool("FindDefinition",
 		mcp.WithDescription("Navigate to the definition of a symbol at a given position in source code"),
 		mcp.WithString("source_url",
 			mcp.Required(),
@@ -1786,7 +2301,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// FindReferences
 	if shouldRegister("FindReferences") {
-		s.mcpServer.AddTool(mcp.NewTool("FindReferences",
+		s.addTool(mcp.NewTool("FindReferences",
 		mcp.WithDescription("Find all references to an ABAP object or symbol"),
 		mcp.WithString("object_url",
 			mcp.Required(),
@@ -1804,7 +2319,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// CodeCompletion
 	if shouldRegister("CodeCompletion") {
-		s.mcpServer.AddTool(mcp.NewTool("CodeCompletion",
+		s.addTool(mcp.NewTool("CodeCompletion",
 		mcp.WithDescription("Get code completion suggestions at a position in source code"),
 		mcp.WithString("source_url",
 			mcp.Required(),
@@ -1828,7 +2343,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// PrettyPrint
 	if shouldRegister("PrettyPrint") {
-		s.mcpServer.AddTool(mcp.NewTool("PrettyPrint",
+		s.addTool(mcp.NewTool("PrettyPrint",
 		mcp.WithDescription("Format ABAP source code using the pretty printer"),
 		mcp.WithString("source",
 			mcp.Required(),
@@ -1840,7 +2355,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetPrettyPrinterSettings
 	if shouldRegister("GetPrettyPrinterSettings") {
-		s.mcpServer.AddTool(mcp.NewTool("GetPrettyPrinterSettings",
+		s.addTool(mcp.NewTool("GetPrettyPrinterSettings",
 		mcp.WithDescription("Get the current pretty printer (code formatter) settings"),
 	), s.handleGetPrettyPrinterSettings)
 	}
@@ -1848,7 +2363,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// SetPrettyPrinterSettings
 	if shouldRegister("SetPrettyPrinterSettings") {
-		s.mcpServer.AddTool(mcp.NewTool("SetPrettyPrinterSettings",
+		s.addTool(mcp.NewTool("SetPrettyPrinterSettings",
 		mcp.WithDescription("Update the pretty printer (code formatter) settings"),
 		mcp.WithBoolean("indentation",
 			mcp.Required(),
@@ -1864,7 +2379,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetTypeHierarchy
 	if shouldRegister("GetTypeHierarchy") {
-		s.mcpServer.AddTool(mcp.NewTool("GetTypeHierarchy",
+		s.addTool(mcp.NewTool("GetTypeHierarchy",
 		mcp.WithDescription("Get the type hierarchy (supertypes or subtypes) for a class/interface"),
 		mcp.WithString("source_url",
 			mcp.Required(),
@@ -1890,7 +2405,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetClassComponents - get class structure (methods, attributes, events)
 	if shouldRegister("GetClassComponents") {
-		s.mcpServer.AddTool(mcp.NewTool("GetClassComponents",
+		s.addTool(mcp.NewTool("GetClassComponents",
 			mcp.WithDescription("Get the structure of a class - lists all methods, attributes, events, and other components with their visibility and properties"),
 			mcp.WithString("class_url",
 				mcp.Required(),
@@ -1901,7 +2416,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetInactiveObjects - list objects that need activation
 	if shouldRegister("GetInactiveObjects") {
-		s.mcpServer.AddTool(mcp.NewTool("GetInactiveObjects",
+		s.addTool(mcp.NewTool("GetInactiveObjects",
 			mcp.WithDescription("Get all inactive objects for the current user - objects that have been modified but not yet activated"),
 		), s.handleGetInactiveObjects)
 	}
@@ -1909,7 +2424,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 	// Transport Management Tools (require EnableTransports flag)
 	// GetUserTransports - list transport requests for a user
 	if shouldRegister("GetUserTransports") {
-		s.mcpServer.AddTool(mcp.NewTool("GetUserTransports",
+		s.addTool(mcp.NewTool("GetUserTransports",
 			mcp.WithDescription("Get all transport requests for a user (requires --enable-transports flag). Returns both workbench and customizing requests grouped by target system."),
 			mcp.WithString("user_name",
 				mcp.Required(),
@@ -1920,7 +2435,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetTransportInfo - get transport info for an object
 	if shouldRegister("GetTransportInfo") {
-		s.mcpServer.AddTool(mcp.NewTool("GetTransportInfo",
+		s.addTool(mcp.NewTool("GetTransportInfo",
 			mcp.WithDescription("Get transport information for an ABAP object (requires --enable-transports flag). Returns available transports and lock status."),
 			mcp.WithString("object_url",
 				mcp.Required(),
@@ -1935,7 +2450,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// ExecuteABAP - execute arbitrary ABAP code via unit test wrapper (Expert mode only)
 	if shouldRegister("ExecuteABAP") {
-		s.mcpServer.AddTool(mcp.NewTool("ExecuteABAP",
+		s.addTool(mcp.NewTool("ExecuteABAP",
 			mcp.WithDescription("Execute arbitrary ABAP code via unit test wrapper. Creates temp program, injects code into test method, runs via RunUnitTests, extracts results from assertion messages, cleans up. Use lv_result variable to return output. WARNING: Powerful tool - use responsibly."),
 			mcp.WithString("code",
 				mcp.Required(),
@@ -1960,7 +2475,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// UI5ListApps
 	if shouldRegister("UI5ListApps") {
-		s.mcpServer.AddTool(mcp.NewTool("UI5ListApps",
+		s.addTool(mcp.NewTool("UI5ListApps",
 			mcp.WithDescription("List UI5/Fiori BSP applications. Use query parameter for filtering with wildcards (*)."),
 			mcp.WithString("query",
 				mcp.Description("Search query (supports * wildcard, e.g., 'Z*' for custom apps)"),
@@ -1973,7 +2488,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// UI5GetApp
 	if shouldRegister("UI5GetApp") {
-		s.mcpServer.AddTool(mcp.NewTool("UI5GetApp",
+		s.addTool(mcp.NewTool("UI5GetApp",
 			mcp.WithDescription("Get details of a UI5/Fiori BSP application including file structure."),
 			mcp.WithString("app_name",
 				mcp.Required(),
@@ -1984,7 +2499,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// UI5GetFileContent
 	if shouldRegister("UI5GetFileContent") {
-		s.mcpServer.AddTool(mcp.NewTool("UI5GetFileContent",
+		s.addTool(mcp.NewTool("UI5GetFileContent",
 			mcp.WithDescription("Get content of a specific file within a UI5/Fiori BSP application."),
 			mcp.WithString("app_name",
 				mcp.Required(),
@@ -1999,7 +2514,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// UI5UploadFile
 	if shouldRegister("UI5UploadFile") {
-		s.mcpServer.AddTool(mcp.NewTool("UI5UploadFile",
+		s.addTool(mcp.NewTool("UI5UploadFile",
 			mcp.WithDescription("Upload a file to a UI5/Fiori BSP application."),
 			mcp.WithString("app_name",
 				mcp.Required(),
@@ -2021,7 +2536,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// UI5DeleteFile
 	if shouldRegister("UI5DeleteFile") {
-		s.mcpServer.AddTool(mcp.NewTool("UI5DeleteFile",
+		s.addTool(mcp.NewTool("UI5DeleteFile",
 			mcp.WithDescription("Delete a file from a UI5/Fiori BSP application."),
 			mcp.WithString("app_name",
 				mcp.Required(),
@@ -2036,7 +2551,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// UI5CreateApp
 	if shouldRegister("UI5CreateApp") {
-		s.mcpServer.AddTool(mcp.NewTool("UI5CreateApp",
+		s.addTool(mcp.NewTool("UI5CreateApp",
 			mcp.WithDescription("Create a new UI5/Fiori BSP application."),
 			mcp.WithString("app_name",
 				mcp.Required(),
@@ -2057,7 +2572,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// UI5DeleteApp
 	if shouldRegister("UI5DeleteApp") {
-		s.mcpServer.AddTool(mcp.NewTool("UI5DeleteApp",
+		s.addTool(mcp.NewTool("UI5DeleteApp",
 			mcp.WithDescription("Delete a UI5/Fiori BSP application."),
 			mcp.WithString("app_name",
 				mcp.Required(),
@@ -2069,11 +2584,52 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		), s.handleUI5DeleteApp)
 	}
 
+	// UI5Deploy
+	if shouldRegister("UI5Deploy") {
+		s.addTool(mcp.NewTool("UI5Deploy",
+			mcp.WithDescription("Deploy a built UI5/Fiori app as BSP application: packs a local folder (e.g. dist/ of ui5 build) into a ZIP archive and uploads it through the /UI5/ABAP_REPOSITORY_SRV OData service, as the Fiori tools deploy task does. Creates the app or replaces an existing one, which keeps its package."),
+			mcp.WithString("dir",
+				mcp.Required(),
+				mcp.Description("Local folder with the built app (e.g., './dist')"),
+			),
+			mcp.WithString("app_name",
+				mcp.Required(),
+				mcp.Description("BSP application name, max 15 characters after the namespace (e.g., ZORDERS)"),
+			),
+			mcp.WithString("package",
+				mcp.Description("Package of a new application (default: $TMP)"),
+			),
+			mcp.WithString("description",
+				mcp.Description("Application description"),
+			),
+			mcp.WithString("transport",
+				mcp.Description("Transport request (required for transportable packages)"),
+			),
+			mcp.WithBoolean("test_mode",
+				mcp.Description("Only check the upload without changing the repository (default: false)"),
+			),
+		), s.handleUI5Deploy)
+	}
+
+	// UI5Undeploy
+	if shouldRegister("UI5Undeploy") {
+		s.addTool(mcp.NewTool("UI5Undeploy",
+			mcp.WithDescription("Delete a BSP application deployed with UI5Deploy (or the Fiori tools) through the /UI5/ABAP_REPOSITORY_SRV OData service."),
+			mcp.WithString("app_name",
+				mcp.Required(),
+				mcp.Description("BSP application name"),
+			),
+			mcp.WithString("transport",
+				mcp.Description("Transport request (required for transportable packages)"),
+			),
+		), s.handleUI5Undeploy)
+	}
+
 	// --- AMDP (HANA) Debugger ---
 
 	// AMDPDebuggerStart
 	if shouldRegister("AMDPDebuggerStart") {
-		s.mcpServer.AddTool(mcp.NewTool("AMDPDebuggerStart",
+		s.addTool(mcp.NewTool("AMDPDebuggerStart",
 			mcp.WithDescription("Start an AMDP (HANA SQLScript) debug session with persistent goroutine. Creates a background goroutine that maintains the HTTP session cookies. Use AMDPDebuggerStep/AMDPGetVariables to interact, AMDPDebuggerStop to terminate."),
 			mcp.WithString("user",
 				mcp.Description("User to debug (defaults to current user)"),
@@ -2083,21 +2639,21 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// AMDPDebuggerResume
 	if shouldRegister("AMDPDebuggerResume") {
-		s.mcpServer.AddTool(mcp.NewTool("AMDPDebuggerResume",
+		s.addTool(mcp.NewTool("AMDPDebuggerResume",
 			mcp.WithDescription("Get current AMDP debug session status. In goroutine model, this returns the current state without blocking. The session manager goroutine handles events internally."),
 		), s.handleAMDPDebuggerResume)
 	}
 
 	// AMDPDebuggerStop
 	if shouldRegister("AMDPDebuggerStop") {
-		s.mcpServer.AddTool(mcp.NewTool("AMDPDebuggerStop",
+		s.addTool(mcp.NewTool("AMDPDebuggerStop",
 			mcp.WithDescription("Stop the AMDP debug session and terminate the background goroutine. Cleans up the HTTP session on SAP server."),
 		), s.handleAMDPDebuggerStop)
 	}
 
 	// AMDPDebuggerStep
 	if shouldRegister("AMDPDebuggerStep") {
-		s.mcpServer.AddTool(mcp.NewTool("AMDPDebuggerStep",
+		s.addTool(mcp.NewTool("AMDPDebuggerStep",
 			mcp.WithDescription("Perform a step operation in the AMDP debugger. Communicates via channel to the session manager goroutine."),
 			mcp.WithString("step_type",
 				mcp.Required(),
@@ -2108,14 +2664,14 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// AMDPGetVariables
 	if shouldRegister("AMDPGetVariables") {
-		s.mcpServer.AddTool(mcp.NewTool("AMDPGetVariables",
+		s.addTool(mcp.NewTool("AMDPGetVariables",
 			mcp.WithDescription("Get variable values during AMDP debugging. Communicates via channel to the session manager goroutine. Returns scalar, table, and array types."),
 		), s.handleAMDPGetVariables)
 	}
 
 	// AMDPSetBreakpoint
 	if shouldRegister("AMDPSetBreakpoint") {
-		s.mcpServer.AddTool(mcp.NewTool("AMDPSetBreakpoint",
+		s.addTool(mcp.NewTool("AMDPSetBreakpoint",
 			mcp.WithDescription("Set a breakpoint in AMDP (SQLScript) code. Requires an active AMDP debug session. Specify the procedure name and line number."),
 			mcp.WithString("proc_name",
 				mcp.Required(),
@@ -2130,7 +2686,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// AMDPGetBreakpoints
 	if shouldRegister("AMDPGetBreakpoints") {
-		s.mcpServer.AddTool(mcp.NewTool("AMDPGetBreakpoints",
+		s.addTool(mcp.NewTool("AMDPGetBreakpoints",
 			mcp.WithDescription("Get all breakpoints registered in the current AMDP debug session. Useful for verifying breakpoints are set correctly."),
 		), s.handleAMDPGetBreakpoints)
 	}
@@ -2139,7 +2695,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// ListTransports
 	if shouldRegister("ListTransports") {
-		s.mcpServer.AddTool(mcp.NewTool("ListTransports",
+		s.addTool(mcp.NewTool("ListTransports",
 			mcp.WithDescription("List transport requests. Returns modifiable transports for a user. Requires --enable-transports OR --allow-transportable-edits flag."),
 			mcp.WithString("user",
 				mcp.Description("Username to list transports for (default: current user, '*' for all users)"),
@@ -2149,7 +2705,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetTransport
 	if shouldRegister("GetTransport") {
-		s.mcpServer.AddTool(mcp.NewTool("GetTransport",
+		s.addTool(mcp.NewTool("GetTransport",
 			mcp.WithDescription("Get detailed transport information including objects and tasks. Requires --enable-transports OR --allow-transportable-edits flag."),
 			mcp.WithString("transport",
 				mcp.Required(),
@@ -2160,7 +2716,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// CreateTransport (expert mode only)
 	if shouldRegister("CreateTransport") {
-		s.mcpServer.AddTool(mcp.NewTool("CreateTransport",
+		s.addTool(mcp.NewTool("CreateTransport",
 			mcp.WithDescription("Create a new transport request. Requires --enable-transports flag and not --transport-read-only."),
 			mcp.WithString("description",
 				mcp.Required(),
@@ -2181,7 +2737,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// ReleaseTransport (expert mode only)
 	if shouldRegister("ReleaseTransport") {
-		s.mcpServer.AddTool(mcp.NewTool("ReleaseTransport",
+		s.addTool(mcp.NewTool("ReleaseTransport",
 			mcp.WithDescription("Release a transport request. This action is IRREVERSIBLE. Requires --enable-transports flag and not --transport-read-only."),
 			mcp.WithString("transport",
 				mcp.Required(),
@@ -2198,7 +2754,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// DeleteTransport (expert mode only)
 	if shouldRegister("DeleteTransport") {
-		s.mcpServer.AddTool(mcp.NewTool("DeleteTransport",
+		s.addTool(mcp.NewTool("DeleteTransport",
 			mcp.WithDescription("Delete a transport request. Only modifiable transports can be deleted. Requires --enable-transports flag and not --transport-read-only."),
 			mcp.WithString("transport",
 				mcp.Required(),
@@ -2211,14 +2767,14 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GitTypes
 	if shouldRegister("GitTypes") {
-		s.mcpServer.AddTool(mcp.NewTool("GitTypes",
+		s.addTool(mcp.NewTool("GitTypes",
 			mcp.WithDescription("Get list of supported abapGit object types. Returns 158 object types that can be exported/imported via abapGit. Requires abapGit to be installed on SAP system."),
 		), s.handleGitTypes)
 	}
 
 	// GitExport
 	if shouldRegister("GitExport") {
-		s.mcpServer.AddTool(mcp.NewTool("GitExport",
+		s.addTool(mcp.NewTool("GitExport",
 			mcp.WithDescription("Export ABAP objects as abapGit-compatible ZIP. Supports 158 object types. Saves ZIP file to output_dir (default: current directory). Use packages OR objects parameter."),
 			mcp.WithString("packages",
 				mcp.Description("Comma-separated package names to export (e.g., '$ZRAY,$TMP'). Supports wildcards."),
@@ -2239,7 +2795,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// RunReport
 	if shouldRegister("RunReport") {
-		s.mcpServer.AddTool(mcp.NewTool("RunReport",
+		s.addTool(mcp.NewTool("RunReport",
 			mcp.WithDescription("Execute an ABAP selection-screen report with parameters or variant. Runs as background job and returns spool output. Requires ZADT_VSP WebSocket handler deployed."),
 			mcp.WithString("report",
 				mcp.Description("Report program name (e.g., 'RFITEMGL', 'ZREPORT_TEST')"),
@@ -2256,7 +2812,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// RunReportAsync - Background report execution
 	if shouldRegister("RunReportAsync") {
-		s.mcpServer.AddTool(mcp.NewTool("RunReportAsync",
+		s.addTool(mcp.NewTool("RunReportAsync",
 			mcp.WithDescription("Start report execution in background. Returns task_id immediately. Use GetAsyncResult to poll for completion. Useful for long-running reports that would timeout."),
 			mcp.WithString("report",
 				mcp.Description("Report program name"),
@@ -2273,7 +2829,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetAsyncResult - Retrieve async task results
 	if shouldRegister("GetAsyncResult") {
-		s.mcpServer.AddTool(mcp.NewTool("GetAsyncResult",
+		s.addTool(mcp.NewTool("GetAsyncResult",
 			mcp.WithDescription("Get result of an async task by ID. Returns status (running/completed/error) and result when done."),
 			mcp.WithString("task_id",
 				mcp.Description("Task ID from RunReportAsync"),
@@ -2287,7 +2843,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetVariants
 	if shouldRegister("GetVariants") {
-		s.mcpServer.AddTool(mcp.NewTool("GetVariants",
+		s.addTool(mcp.NewTool("GetVariants",
 			mcp.WithDescription("Get list of available variants for a report program. Returns variant names and whether they are protected."),
 			mcp.WithString("report",
 				mcp.Description("Report program name"),
@@ -2298,7 +2854,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// GetTextElements
 	if shouldRegister("GetTextElements") {
-		s.mcpServer.AddTool(mcp.NewTool("GetTextElements",
+		s.addTool(mcp.NewTool("GetTextElements",
 			mcp.WithDescription("Get program text elements (selection texts and text symbols). Selection texts describe parameters (P_BUKRS='Company Code'), text symbols are TEXT-001 etc."),
 			mcp.WithString("program",
 				mcp.Description("Program name"),
@@ -2312,7 +2868,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// SetTextElements
 	if shouldRegister("SetTextElements") {
-		s.mcpServer.AddTool(mcp.NewTool("SetTextElements",
+		s.addTool(mcp.NewTool("SetTextElements",
 			mcp.WithDescription("Set program text elements (selection texts, text symbols, and heading texts). Use for adding descriptions to selection screen parameters, text symbols, and list/column headings."),
 			mcp.WithString("program",
 				mcp.Description("Program name"),
@@ -2333,11 +2889,30 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		), s.handleSetTextElements)
 	}
 
+	// --- Lua Scripting ---
+
+	// RunLuaScript
+	if shouldRegister("RunLuaScript") {
+		s.addTool(mcp.NewTool("RunLuaScript",
+			mcp.WithDescription("Run a Lua debug/automation script in a sandbox. Only base, table, string, math and os.time/clock/date are available (no io, no os.execute, no require). ADT bindings (searchObject, getSource, setBreakpoint, listen, getVariables, ...) follow the server safety configuration. Runtime and instruction count are capped. Use print() for output."),
+			mcp.WithString("script",
+				mcp.Required(),
+				mcp.Description("Lua source code to execute"),
+			),
+			mcp.WithNumber("timeout_seconds",
+				mcp.Description("Wall-clock limit in seconds (default: 60, max: 300)"),
+			),
+			mcp.WithNumber("max_instructions",
+				mcp.Description("VM instruction limit (default and max: 10000000)"),
+			),
+		), s.handleRunLuaScript)
+	}
+
 	// --- Install/Setup Tools ---
 
 	// InstallZADTVSP
 	if shouldRegister("InstallZADTVSP") {
-		s.mcpServer.AddTool(mcp.NewTool("InstallZADTVSP",
+		s.addTool(mcp.NewTool("InstallZADTVSP",
 			mcp.WithDescription("Deploy ZADT_VSP WebSocket handler to SAP system. Creates package and deploys 6 ABAP objects (interface + 5 classes) that enable WebSocket debugging, RFC calls, and abapGit export. After deployment, manual SAPC and SICF setup is required."),
 			mcp.WithString("package",
 				mcp.Description("Target package name (default: $ZADT_VSP). Must be local package starting with $."),
@@ -2346,21 +2921,24 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 				mcp.Description("Skip ZCL_VSP_GIT_SERVICE deployment if abapGit is not installed (default: false, auto-detected)"),
 			),
 			mcp.WithBoolean("check_only",
-				mcp.Description("Only check prerequisites without deploying (default: false)"),
+				mcp.Description("Only check prerequisites and compare installed objects and handler versions with this vsp version, without deploying (default: false)"),
+			),
+			mcp.WithBoolean("upgrade",
+				mcp.Description("Redeploy only objects whose source differs from this vsp version, keeping the SAPC/SICF setup (default: false)"),
 			),
 		), s.handleInstallZADTVSP)
 	}
 
 	// ListDependencies
 	if shouldRegister("ListDependencies") {
-		s.mcpServer.AddTool(mcp.NewTool("ListDependencies",
+		s.addTool(mcp.NewTool("ListDependencies",
 			mcp.WithDescription("List available dependency packages that can be installed via InstallAbapGit. Shows abapGit editions and other optional dependencies."),
 		), s.handleListDependencies)
 	}
 
 	// InstallAbapGit
 	if shouldRegister("InstallAbapGit") {
-		s.mcpServer.AddTool(mcp.NewTool("InstallAbapGit",
+		s.addTool(mcp.NewTool("InstallAbapGit",
 			mcp.WithDescription("Deploy abapGit to SAP system from embedded ZIP. Supports standalone (single program) or developer edition (full package structure). Parses abapGit-format ZIP and deploys via WriteSource."),
 			mcp.WithString("edition",
 				mcp.Description("Edition to install: 'standalone' (single program ZABAPGIT) or 'dev' (full $ZGIT_DEV packages). Default: standalone"),
@@ -2376,7 +2954,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 
 	// InstallDummyTest - Test tool to verify Install* workflow
 	if shouldRegister("InstallDummyTest") {
-		s.mcpServer.AddTool(mcp.NewTool("InstallDummyTest",
+		s.addTool(mcp.NewTool("InstallDummyTest",
 			mcp.WithDescription("Test tool that creates a simple interface and class to verify the Install* workflow (create, lock, update, unlock, activate, verify). Uses package $ZADT_INSTALL_TEST."),
 			mcp.WithBoolean("check_only",
 				mcp.Description("Only check prerequisites without deploying (default: false)"),
@@ -2432,7 +3010,7 @@ func (s *Server) registerToolAliases(shouldRegister func(string) bool) {
 	/*
 	for alias, info := range aliases {
 		if shouldRegister(info.canonical) {
-			s.mcpServer.AddTool(mcp.NewTool(alias,
+			s.addTool(mcp.NewTool(alias,
 				mcp.WithDescription(info.desc),
 				// Aliases inherit all parameters from the canonical tool
 				// The handler is the same, so parameters work identically
@@ -2456,6 +3034,8 @@ func (s *Server) ensureWSConnected(ctx context.Context, toolName string) *mcp.Ca
 		s.amdpWSClient = adt.NewAMDPWebSocketClient(
 			s.config.BaseURL, s.config.Client, s.config.Username, s.config.Password, s.config.InsecureSkipVerify,
 		)
+		s.amdpWSClient.UseAuth(s.adtClient.Config())
+		s.amdpWSClient.UseSafety(s.adtClient.Config().Safety)
 		if err := s.amdpWSClient.Connect(ctx); err != nil {
 			s.amdpWSClient = nil
 			return newToolResultError(fmt.Sprintf("%s: WebSocket connect failed: %v", toolName, err))
@@ -2480,10 +3060,17 @@ func (s *Server) requireActiveAMDPSession() *mcp.CallToolResult {
 // - handlers_diagnostics.go: ListDumps, ListTraces, etc.
 // - handlers_devtools.go: SyntaxCheck, Activate, ATC, etc.
 // - handlers_crud.go: Lock, Create, Update, Delete, etc.
+// - handlers_ddic.go: GetDDIC, WriteDDIC, DeleteDDIC
+// - handlers_servicebinding.go: PublishServiceBinding, TestODataService, etc.
+// - handlers_rap.go: GenerateRAPStack
 // - handlers_debug.go: SetBreakpoint, DebuggerListen, etc.
+// - handlers_breakpointsets.go: SaveBreakpointSet, EnableBreakpointSet, etc.
 // - handlers_amdp.go: AMDPDebugger* handlers
 // - handlers_ui5.go: UI5ListApps, UI5GetApp, etc.
 // - handlers_git.go: GitTypes, GitExport
+// - handlers_domain.go: CallDomain
 // - handlers_report.go: RunReport, GetVariants, etc.
 // - handlers_install.go: InstallZADTVSP, InstallAbapGit, etc.
 // - handlers_transport.go: ListTransports, GetTransport, etc.
+// - handlers_lua.go: RunLuaScript
+// - handlers_history.go: StartRecording, SearchHistory, GetStateAtStep, GenerateTestFromRecording, etc.
@@ -1,9 +1,16 @@
 package mcp
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	embedded "github.com/oisee/vibing-steampunk/embedded/abap"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
 )
 
 func TestNewToolResultError(t *testing.T) {
@@ -82,3 +89,351 @@ func TestNewServer(t *testing.T) {
 		t.Error("ADT client should not be nil")
 	}
 }
+
+// newMockMCPServer starts a mock SAP system and an expert mode server
+// against it. configure adjusts the server configuration.
+func newMockMCPServer(t *testing.T, configure ...func(*Config)) (*adttest.Server, *Server) {
+	t.Helper()
+	sap := adttest.NewServer()
+	t.Cleanup(sap.Close)
+	cfg := &Config{
+		BaseURL:  sap.URL,
+		Username: "testuser",
+		Password: "testpass",
+		Client:   "001",
+		Mode:     "expert",
+	}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	return sap, NewServer(cfg)
+}
+
+// callTool calls a tool handler and returns the text of the result and
+// whether it is an error.
+func callTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+	t.Helper()
+	var req mcp.CallToolRequest
+	req.Params.Arguments = args
+	result, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestWriteAndGetSourceAgainstMockSystem(t *testing.T) {
+	sap, server := newMockMCPServer(t, func(cfg *Config) { cfg.Language = "EN" })
+
+	source := "REPORT zmcp_test.\nWRITE 'mock'.\n"
+	out, isErr := callTool(t, server.handleWriteSource, map[string]any{
+		"object_type": "PROG",
+		"name":        "ZMCP_TEST",
+		"source":      source,
+		"package":     "$TMP",
+		"description": "MCP test",
+	})
+	if isErr || !strings.Contains(out, `"success": true`) {
+		t.Fatalf("WriteSource did not succeed: %s", out)
+	}
+
+	if got, isErr := callTool(t, server.handleGetSource, map[string]any{"object_type": "PROG", "name": "ZMCP_TEST"}); isErr || got != source {
+		t.Errorf("GetSource = %q, want %q", got, source)
+	}
+	if obj, ok := sap.Object("/sap/bc/adt/programs/programs/ZMCP_TEST"); !ok || obj.Inactive {
+		t.Errorf("expected active program in mock system, got %+v", obj)
+	}
+}
+
+func TestInstallZADTVSPUpgrade(t *testing.T) {
+	sap, server := newMockMCPServer(t)
+	sap.AddPackage("$ZADT_VSP", "VSP WebSocket Handler", "")
+
+	// Installed: everything current except an outdated RFC service and a
+	// missing report service
+	for _, obj := range embedded.GetObjects() {
+		typ := "CLAS/OC"
+		if obj.Type == "INTF" {
+			typ = "INTF/OI"
+		}
+		source := obj.Source
+		switch obj.Name {
+		case "ZCL_VSP_REPORT_SERVICE", "ZCL_VSP_GIT_SERVICE":
+			continue
+		case "ZCL_VSP_RFC_SERVICE":
+			source = "CLASS zcl_vsp_rfc_service DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_vsp_rfc_service IMPLEMENTATION.\nENDCLASS.\n"
+		}
+		sap.AddObject(adttest.Object{Type: typ, Name: obj.Name, Package: "$ZADT_VSP", Source: source})
+	}
+
+	out, isErr := callTool(t, server.handleInstallZADTVSP, map[string]any{"upgrade": true})
+	if isErr {
+		t.Fatalf("tool error: %s", out)
+	}
+
+	if !strings.Contains(out, "Deployed: 2, Unchanged: 8, Skipped: 1, Failed: 0") {
+		t.Errorf("unexpected summary:\n%s", out)
+	}
+	if strings.Contains(out, "MANUAL STEPS") {
+		t.Errorf("upgrade of an installed handler should not repeat the SAPC/SICF steps:\n%s", out)
+	}
+	if n := sap.CountRequests("PUT", "/sap/bc/adt/oo/classes/zcl_vsp_utils"); n != 0 {
+		t.Errorf("unchanged ZCL_VSP_UTILS written %d times", n)
+	}
+	for _, name := range []string{"ZCL_VSP_RFC_SERVICE", "ZCL_VSP_REPORT_SERVICE"} {
+		obj, ok := sap.Object("/sap/bc/adt/oo/classes/" + name)
+		if !ok || obj.Inactive || !strings.Contains(obj.Source, "c_version") {
+			t.Errorf("%s not upgraded: %+v", name, obj)
+		}
+	}
+}
+
+func TestCallDomain(t *testing.T) {
+	sap, server := newMockMCPServer(t)
+	sap.SetWSVersions("2.5.0", map[string]string{"system": "2.5.0", "idoc": "1.0.0"})
+	sap.HandleWS("idoc", "status", func(req adttest.WSRequest) (any, error) {
+		return map[string]any{"docnum": req.Params["docnum"], "status": "53"}, nil
+	})
+	sap.SetWSSchema("idoc", "status", `{"type":"object","required":["docnum"],"properties":{"docnum":{"type":"string","pattern":"^[0-9]+$"}}}`)
+	if out, isErr := callTool(t, server.handleCallDomain, map[string]any{}); isErr || !strings.Contains(out, `"idoc"`) {
+		t.Errorf("domain list: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleCallDomain, map[string]any{"domain": "idoc"}); isErr || !strings.Contains(out, `"pattern"`) {
+		t.Errorf("describe: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleCallDomain, map[string]any{"domain": "idoc", "action": "status", "params": map[string]any{"docnum": "ABC"}}); !isErr || !strings.Contains(out, "params.docnum: does not match") {
+		t.Errorf("expected schema violation, got %s", out)
+	}
+	if out, isErr := callTool(t, server.handleCallDomain, map[string]any{"domain": "idoc", "action": "status", "params": `{"docnum":"4711"}`}); isErr || !strings.Contains(out, `"status": "53"`) {
+		t.Errorf("call: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleCallDomain, map[string]any{"domain": "idoc", "action": "reprocess"}); !isErr || !strings.Contains(out, "available: status") {
+		t.Errorf("expected unknown action, got %s", out)
+	}
+}
+
+func TestCallDomainSafety(t *testing.T) {
+	sap, server := newMockMCPServer(t, func(cfg *Config) {
+		cfg.ReadOnly = true
+		cfg.DisabledGroups = "R"
+	})
+	calls := 0
+	for _, key := range []string{"rfc/moveToPackage", "rfc/ping", "report/getVariants", "idoc/status"} {
+		domain, action, _ := strings.Cut(key, "/")
+		sap.HandleWS(domain, action, func(adttest.WSRequest) (any, error) {
+			calls++
+			return map[string]any{"ok": true}, nil
+		})
+	}
+	if out, isErr := callTool(t, server.handleCallDomain, map[string]any{"domain": "rfc", "action": "moveToPackage"}); !isErr || !strings.Contains(out, "blocked by safety configuration") {
+		t.Errorf("moveToPackage in read-only mode: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleCallDomain, map[string]any{"domain": "idoc", "action": "status"}); !isErr || !strings.Contains(out, "blocked by safety configuration") {
+		t.Errorf("custom domain in read-only mode: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleCallDomain, map[string]any{"domain": "report", "action": "getVariants"}); !isErr || !strings.Contains(out, "tool GetVariants is disabled") {
+		t.Errorf("report domain with group R disabled: %s", out)
+	}
+	if calls != 0 {
+		t.Errorf("%d refused actions reached the handler", calls)
+	}
+	if out, isErr := callTool(t, server.handleCallDomain, map[string]any{"domain": "rfc", "action": "ping"}); isErr || calls != 1 {
+		t.Errorf("rfc/ping: %s", out)
+	}
+
+	_, server = newMockMCPServer(t, func(cfg *Config) { cfg.DisabledGroups = "X" })
+	for _, tool := range server.tools {
+		if tool.Tool.Name == "CallDomain" {
+			t.Error("CallDomain registered with group X disabled")
+		}
+	}
+}
+
+func TestHistoryTools(t *testing.T) {
+	_, server := newMockMCPServer(t)
+	hm, err := adt.NewHistoryManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.history = hm
+
+	if out, isErr := callTool(t, server.handleGetStateAtStep, map[string]any{"step": float64(1)}); !isErr || !strings.Contains(out, "no active recording") {
+		t.Errorf("without recording: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleStartRecording, map[string]any{"program": "zpricing"}); isErr {
+		t.Fatalf("start: %s", out)
+	}
+	recorder := server.recorder
+	loc := adt.CodeLocation{Program: "ZPRICING", Line: 10}
+	recorder.RecordFrame(loc, "entry", map[string]adt.VariableValue{"LV_TOTAL": {Name: "LV_TOTAL", Type: "I", Value: "0"}})
+	loc.Line = 11
+	recorder.RecordFrame(loc, "step_over", map[string]adt.VariableValue{"LV_TOTAL": {Name: "LV_TOTAL", Type: "I", Value: "42"}})
+
+	// The active recording is read while the debugger keeps recording
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			recorder.RecordFrame(adt.CodeLocation{Program: "ZPRICING", Line: 20 + i}, "step_over", map[string]adt.VariableValue{"LV_TOTAL": {Name: "LV_TOTAL", Type: "I", Value: "42"}})
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if out, isErr := callTool(t, server.handleGetStateAtStep, map[string]any{"step": float64(2)}); isErr || !strings.Contains(out, "* LV_TOTAL: I = 42") {
+			t.Fatalf("state of the active recording: %s", out)
+		}
+		if out, isErr := callTool(t, server.handleFindVariableChanges, map[string]any{"variable": "lv_total"}); isErr || !strings.Contains(out, "0 -> 42") {
+			t.Fatalf("changes in the active recording: %s", out)
+		}
+	}
+	<-done
+
+	out, isErr := callTool(t, server.handleStopRecording, map[string]any{})
+	if isErr || !strings.Contains(out, "Saved as") {
+		t.Fatalf("stop: %s", out)
+	}
+	id := recorder.GetRecording().ID
+	if server.recorder != nil {
+		t.Error("recording still active after StopRecording")
+	}
+
+	if out, isErr := callTool(t, server.handleListRecordings, map[string]any{"program": "zpricing"}); isErr || !strings.Contains(out, id+"  ZPRICING  52 steps") {
+		t.Errorf("list: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleGetStateAtStep, map[string]any{"recording_id": id, "step": float64(2), "variables": []interface{}{"lv_total"}}); isErr || !strings.Contains(out, "Location: ZPRICING:11") {
+		t.Errorf("state of a saved recording: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleGetStateAtStep, map[string]any{"recording_id": id, "step": float64(99)}); !isErr || !strings.Contains(out, "out of range") {
+		t.Errorf("step out of range: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleFindVariableChanges, map[string]any{"recording_id": id, "variable": "LV_TOTAL", "value": "42"}); isErr || !strings.Contains(out, `first became "42" at step 2 (ZPRICING:11), previously 0`) {
+		t.Errorf("find value: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleSearchHistory, map[string]any{"match_type": "location", "location": "zpricing"}); isErr || !strings.Contains(out, id+"  step 1  ZPRICING:10") {
+		t.Errorf("search: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleSearchHistory, map[string]any{"match_type": "variable_value"}); !isErr || !strings.Contains(out, "variable is required") {
+		t.Errorf("search without variable: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleCompareRecordings, map[string]any{"id1": id, "id2": id}); isErr || !strings.Contains(out, "No differences found") {
+		t.Errorf("compare: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleCompareRecordings, map[string]any{"id1": id, "id2": "missing"}); !isErr {
+		t.Errorf("compare with a missing recording: %s", out)
+	}
+}
+
+func TestDDICTools(t *testing.T) {
+	sap, server := newMockMCPServer(t)
+
+	out, isErr := callTool(t, server.handleWriteDDIC, map[string]any{
+		"type":       "DOMA",
+		"definition": `{"name":"ZPRIO","description":"Priority","dataType":"NUMC","length":1,"fixValues":[{"low":"1","text":"High"}]}`,
+	})
+	if isErr || !strings.Contains(out, `"status": "created"`) || !strings.Contains(out, `"activated": true`) {
+		t.Fatalf("create domain: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleWriteDDIC, map[string]any{"type": "DOMA", "name": "zprio", "mode": "create", "definition": map[string]any{"dataType": "NUMC"}}); !isErr || !strings.Contains(out, "already exists") {
+		t.Errorf("expected create conflict, got %s", out)
+	}
+
+	// Partial update keeps the other properties
+	out, isErr = callTool(t, server.handleWriteDDIC, map[string]any{
+		"type":       "DOMA",
+		"name":       "ZPRIO",
+		"definition": map[string]any{"fixValues": []any{map[string]any{"low": "1", "text": "High"}, map[string]any{"low": "2", "text": "Low"}}},
+	})
+	if isErr || !strings.Contains(out, `"status": "updated"`) {
+		t.Fatalf("update domain: %s", out)
+	}
+	out, isErr = callTool(t, server.handleGetDDIC, map[string]any{"type": "doma", "name": "zprio"})
+	if isErr || !strings.Contains(out, `"dataType": "NUMC"`) || !strings.Contains(out, `"text": "Low"`) || !strings.Contains(out, `"package": "$TMP"`) {
+		t.Fatalf("get domain: %s", out)
+	}
+
+	if out, isErr := callTool(t, server.handleWriteDDIC, map[string]any{"type": "DTEL", "definition": `{"name":"ZPRIO","description":"Priority","domain":"ZPRIO"}`}); isErr {
+		t.Fatalf("create data element: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleWriteDDIC, map[string]any{"type": "STRU", "definition": `{"name":"ZTASK","description":"Task","fields":[{"name":"PRIO","type":"ZPRIO"}]}`}); isErr {
+		t.Fatalf("create structure: %s", out)
+	}
+	if obj, ok := sap.Object("/sap/bc/adt/ddic/structures/ztask"); !ok || obj.Inactive || !strings.Contains(obj.Source, "prio : zprio;") {
+		t.Errorf("structure: %+v", obj)
+	}
+
+	if out, isErr := callTool(t, server.handleDeleteDDIC, map[string]any{"type": "STRU", "name": "ZTASK"}); isErr {
+		t.Fatalf("delete: %s", out)
+	}
+	if _, ok := sap.Object("/sap/bc/adt/ddic/structures/ztask"); ok {
+		t.Error("structure not deleted")
+	}
+	if out, isErr := callTool(t, server.handleGetDDIC, map[string]any{"type": "TABL", "name": "ZTASK"}); !isErr || !strings.Contains(out, "supported: DOMA") {
+		t.Errorf("expected unsupported type, got %s", out)
+	}
+}
+
+func TestGenerateRAPStackTool(t *testing.T) {
+	sap, server := newMockMCPServer(t)
+
+	if out, isErr := callTool(t, server.handleGenerateRAPStack, map[string]any{}); !isErr || !strings.Contains(out, "table or spec is required") {
+		t.Errorf("expected missing table error, got %s", out)
+	}
+	out, isErr := callTool(t, server.handleGenerateRAPStack, map[string]any{
+		"spec":    `{"name":"ZTASK","description":"Tasks","fields":[{"name":"task_uuid","type":"SYSUUID_X16","key":true},{"name":"title","type":"CHAR","length":60}]}`,
+		"publish": false,
+	})
+	if isErr || !strings.Contains(out, `"success": true`) || !strings.Contains(out, `"name": "ZUI_TASK_O4"`) {
+		t.Fatalf("generate: %s", out)
+	}
+	if sap.Published("ZUI_TASK_O4") != "" {
+		t.Error("binding published despite publish=false")
+	}
+
+	out, isErr = callTool(t, server.handlePublishServiceBinding, map[string]any{"service_name": "ZUI_TASK_O4", "odata_version": "v4"})
+	if isErr || !strings.Contains(out, `"severity": "OK"`) || sap.Published("ZUI_TASK_O4") != "odatav4" {
+		t.Fatalf("publish: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleUnpublishServiceBinding, map[string]any{"service_name": "ZUI_TASK_O4", "odata_version": "V3"}); !isErr || !strings.Contains(out, "invalid odata_version") {
+		t.Errorf("expected invalid version error, got %s", out)
+	}
+
+	// Smoke test the published service
+	sap.AddODataService(adttest.ODataService{URL: "/sap/opu/odata4/sap/zui_task_o4/srvd/sap/zui_task/0001/", EntitySets: []adttest.ODataEntitySet{{
+		Name:       "Task",
+		Keys:       []string{"TaskUUID"},
+		Properties: []adttest.ODataProperty{{Name: "TaskUUID", Type: "Edm.Guid"}, {Name: "Title", Type: "Edm.String"}},
+		Draft:      true,
+	}}})
+	if out, isErr := callTool(t, server.handleTestODataService, map[string]any{}); !isErr || !strings.Contains(out, "binding or service_url is required") {
+		t.Errorf("expected missing binding error, got %s", out)
+	}
+	out, isErr = callTool(t, server.handleTestODataService, map[string]any{"binding": "ZUI_TASK_O4", "draft": true, "draft_payload": `{"Title":"Smoke test"}`})
+	if isErr || !strings.Contains(out, `"success": true`) || !strings.Contains(out, `"skipped": "no rows to filter on"`) || !strings.Contains(out, `"name": "activate"`) {
+		t.Fatalf("test service: %s", out)
+	}
+}
+
+func TestUI5DeployTools(t *testing.T) {
+	sap, server := newMockMCPServer(t)
+
+	dist := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dist, "index.html"), []byte("<html/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, isErr := callTool(t, server.handleUI5Deploy, map[string]any{"app_name": "ZAPP"}); !isErr || !strings.Contains(out, "dir is required") {
+		t.Errorf("expected missing dir error, got %s", out)
+	}
+	out, isErr := callTool(t, server.handleUI5Deploy, map[string]any{"dir": dist, "app_name": "zapp", "description": "App"})
+	if isErr || !strings.Contains(out, `"action": "created"`) || !strings.Contains(out, `"index.html"`) {
+		t.Fatalf("deploy: %s", out)
+	}
+	if app, ok := sap.UI5App("ZAPP"); !ok || app.Description != "App" {
+		t.Errorf("deployed app = %+v", app)
+	}
+	out, isErr = callTool(t, server.handleUI5Undeploy, map[string]any{"app_name": "ZAPP"})
+	if isErr || !strings.Contains(out, `"action": "deleted"`) {
+		t.Fatalf("undeploy: %s", out)
+	}
+	if out, isErr := callTool(t, server.handleUI5Undeploy, map[string]any{"app_name": "ZAPP"}); !isErr || !strings.Contains(out, "does not exist") {
+		t.Errorf("expected missing app error, got %s", out)
+	}
+}
@@ -0,0 +1,451 @@
+package abapgit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// ExportOptions configures an export.
+type ExportOptions struct {
+	FolderLogic    string // PREFIX or FULL; default: PREFIX if every subpackage is named <parent>_<name>, else FULL
+	MasterLanguage string // default: E
+	NoSubpackages  bool   // Only export the objects of the root package
+}
+
+// ExportResult summarizes an export.
+type ExportResult struct {
+	Packages []string       `json:"packages"`
+	Objects  []ObjectResult `json:"objects"` // Action: exported, skipped, failed
+	Summary  string         `json:"summary"`
+}
+
+// Count returns the number of objects with an action.
+func (r *ExportResult) Count(action string) int {
+	n := 0
+	for _, o := range r.Objects {
+		if o.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Exporter serializes packages into abapGit repositories through ADT.
+type Exporter struct {
+	client *adt.Client
+	opts   ExportOptions
+}
+
+// NewExporter creates an exporter reading from the system of client.
+func NewExporter(client *adt.Client, opts ExportOptions) *Exporter {
+	opts.FolderLogic = strings.ToUpper(opts.FolderLogic)
+	if opts.MasterLanguage == "" {
+		opts.MasterLanguage = "E"
+	}
+	return &Exporter{client: client, opts: opts}
+}
+
+// exportPackage is a package of the exported tree.
+type exportPackage struct {
+	name, parent, folder string
+	objects              []adt.PackageObject
+}
+
+// Export reads a package and its subpackages and returns them as an abapGit
+// repository, one folder per subpackage. Objects of unsupported types are
+// reported as skipped; objects that cannot be read are reported as failed
+// and left out.
+func (ex *Exporter) Export(ctx context.Context, pkg string) (*Repository, *ExportResult, error) {
+	packages, err := ex.readPackages(ctx, strings.ToUpper(pkg))
+	if err != nil {
+		return nil, nil, err
+	}
+	logic, err := ex.folderLogic(packages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo := &Repository{
+		StartingFolder:      "/src/",
+		FolderLogic:         logic,
+		MasterLanguage:      ex.opts.MasterLanguage,
+		packageDescriptions: make(map[string]string),
+	}
+	result := &ExportResult{Objects: []ObjectResult{}}
+	folders := map[string]string{packages[0].name: ""}
+	for _, p := range packages {
+		if p.parent != "" {
+			segment := p.name
+			if logic == FolderLogicPrefix {
+				segment = strings.TrimPrefix(p.name, p.parent+"_")
+			}
+			folders[p.name] = strings.TrimPrefix(folders[p.parent]+"/"+strings.ToLower(strings.ReplaceAll(segment, "/", "#")), "/")
+		}
+		p.folder = folders[p.name]
+		repo.packageDescriptions[p.folder] = ex.packageDescription(ctx, p.name)
+		result.Packages = append(result.Packages, p.name)
+	}
+
+	for _, p := range packages {
+		for _, po := range p.objects {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			typ, _, _ := strings.Cut(po.Type, "/")
+			res := ObjectResult{Type: typ, Name: strings.ToUpper(po.Name), Package: p.name}
+			obj := &Object{Type: typ, Name: res.Name, Folder: p.folder, Files: make(map[string][]byte)}
+			switch err := ex.exportObject(ctx, po, obj); {
+			case errors.Is(err, errUnsupported):
+				res.Action, res.Message = "skipped", fmt.Sprintf("object type %s is not supported", po.Type)
+			case err != nil:
+				res.Action, res.Message = "failed", err.Error()
+			default:
+				res.Action = "exported"
+				repo.Objects = append(repo.Objects, obj)
+			}
+			result.Objects = append(result.Objects, res)
+		}
+	}
+
+	sort.Slice(repo.Objects, func(i, j int) bool {
+		a, b := repo.Objects[i], repo.Objects[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Name < b.Name
+	})
+	result.Summary = fmt.Sprintf("%d packages, %d exported, %d skipped, %d failed",
+		len(result.Packages), result.Count("exported"), result.Count("skipped"), result.Count("failed"))
+	return repo, result, nil
+}
+
+// readPackages reads the package tree below root, parents first.
+func (ex *Exporter) readPackages(ctx context.Context, root string) ([]*exportPackage, error) {
+	var packages []*exportPackage
+	queue := []*exportPackage{{name: root}}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		content, err := ex.client.GetPackage(ctx, p.name)
+		if err != nil {
+			return nil, fmt.Errorf("reading package %s: %w", p.name, err)
+		}
+		p.objects = content.Objects
+		packages = append(packages, p)
+		if ex.opts.NoSubpackages {
+			break
+		}
+		subs := append([]string(nil), content.SubPackages...)
+		sort.Strings(subs)
+		for _, sub := range subs {
+			queue = append(queue, &exportPackage{name: strings.ToUpper(sub), parent: p.name})
+		}
+	}
+	return packages, nil
+}
+
+// folderLogic returns the folder logic the package tree is exported with.
+func (ex *Exporter) folderLogic(packages []*exportPackage) (string, error) {
+	prefixed := true
+	for _, p := range packages {
+		if p.parent != "" && !strings.HasPrefix(p.name, p.parent+"_") {
+			prefixed = false
+		}
+	}
+	switch ex.opts.FolderLogic {
+	case "":
+		if prefixed {
+			return FolderLogicPrefix, nil
+		}
+		return FolderLogicFull, nil
+	case FolderLogicPrefix:
+		if !prefixed {
+			return "", fmt.Errorf("folder logic PREFIX needs every subpackage to be named <parent>_<name>")
+		}
+		return FolderLogicPrefix, nil
+	case FolderLogicFull:
+		return FolderLogicFull, nil
+	}
+	return "", fmt.Errorf("folder logic %s is not supported (use PREFIX or FULL)", ex.opts.FolderLogic)
+}
+
+// packageDescription looks up the short text of a package.
+func (ex *Exporter) packageDescription(ctx context.Context, name string) string {
+	results, err := ex.client.SearchObject(ctx, name, 10)
+	if err != nil {
+		return ""
+	}
+	for _, r := range results {
+		if strings.EqualFold(r.Name, name) && strings.HasPrefix(r.Type, "DEVC") {
+			return r.Description
+		}
+	}
+	return ""
+}
+
+// exportObject reads the files of one object.
+func (ex *Exporter) exportObject(ctx context.Context, po adt.PackageObject, obj *Object) error {
+	name, desc := obj.Name, po.Description
+	switch po.Type {
+	case "CLAS/OC":
+		return ex.exportClass(ctx, obj, desc)
+	case "INTF/OI":
+		source, err := ex.client.GetInterface(ctx, name)
+		if err != nil {
+			return err
+		}
+		obj.setSource("abap", source)
+		obj.Files["xml"] = encodeValues("INTF", values{VSEOINTERF: &vseoClass{
+			ClsName: name, Langu: ex.opts.MasterLanguage, Descript: desc, Exposure: "2", State: "1", Unicode: "X",
+		}})
+	case "PROG/P", "PROG/I":
+		read, subc := ex.client.GetProgram, "1"
+		if po.Type == "PROG/I" {
+			read, subc = ex.client.GetInclude, "I"
+		}
+		source, err := read(ctx, name)
+		if err != nil {
+			return err
+		}
+		obj.setSource("abap", source)
+		v := values{PROGDIR: &progdir{Name: name, Subc: subc, Fixpt: "X", Uccheck: "X"}}
+		if desc != "" {
+			v.TPOOL = []textEntry{{ID: "R", Entry: desc, Length: len(desc)}}
+		}
+		obj.Files["xml"] = encodeValues("PROG", v)
+	case "FUGR/F":
+		return ex.exportFunctionGroup(ctx, obj, desc)
+	case "DDLS/DF":
+		source, err := ex.client.GetDDLS(ctx, name)
+		if err != nil {
+			return err
+		}
+		obj.setSource("asddls", source)
+		obj.Files["xml"] = encodeValues("DDLS", values{DDLS: &ddls{Name: name, Ddtext: desc}})
+	case "BDEF/BDO":
+		source, err := ex.client.GetBDEF(ctx, name)
+		if err != nil {
+			return err
+		}
+		obj.setSource("asbdef", source)
+		obj.Files["xml"] = encodeValues("BDEF", values{DescrText: desc})
+	case "SRVD/SRV":
+		source, err := ex.client.GetSRVD(ctx, name)
+		if err != nil {
+			return err
+		}
+		obj.setSource("srvdsrv", source)
+		obj.Files["xml"] = encodeValues("SRVD", values{DescrText: desc})
+	case "TABL/DT", "TABL/DS":
+		read := ex.client.GetTable
+		if po.Type == "TABL/DS" {
+			read = ex.client.GetStructure
+		}
+		source, err := read(ctx, name)
+		if err != nil {
+			return err
+		}
+		t, fields := parseTableDDL(source)
+		t.Name = name
+		if t.Ddtext == "" {
+			t.Ddtext = desc
+		}
+		obj.Files["xml"] = encodeValues("TABL", values{DD02V: &t, DD03PTab: fields})
+	case "DOMA/DD":
+		return ex.exportDomain(ctx, obj)
+	case "DTEL/DE":
+		return ex.exportDataElement(ctx, obj)
+	case "MSAG/N":
+		mc, err := ex.client.GetMessageClass(ctx, name)
+		if err != nil {
+			return err
+		}
+		if mc.Description != "" {
+			desc = mc.Description
+		}
+		v := values{T100A: &t100a{Name: name, Stext: desc}}
+		for _, m := range mc.Messages {
+			v.T100 = append(v.T100, t100{Sprsl: ex.opts.MasterLanguage, Arbgb: name, Msgnr: m.Number, Text: m.Text})
+		}
+		obj.Files["xml"] = encodeValues("MSAG", v)
+	default:
+		return errUnsupported
+	}
+	return nil
+}
+
+// setSource stores a source file, with Unix line endings.
+func (o *Object) setSource(part, source string) {
+	o.Files[part] = []byte(strings.ReplaceAll(source, "\r\n", "\n"))
+}
+
+// exportClass reads a class with its local includes. Includes holding only
+// the comments ADT generates are left out, as abapGit does.
+func (ex *Exporter) exportClass(ctx context.Context, obj *Object, desc string) error {
+	source, err := ex.client.GetClassSource(ctx, obj.Name)
+	if err != nil {
+		return err
+	}
+	obj.setSource("abap", source)
+
+	class := &vseoClass{
+		ClsName: obj.Name, Langu: ex.opts.MasterLanguage, Descript: desc,
+		State: "1", Clsccincl: "X", Fixpt: "X", Unicode: "X",
+	}
+	for _, ci := range classIncludes {
+		include, err := ex.client.GetClassInclude(ctx, obj.Name, ci.include)
+		if adt.IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("include %s: %w", ci.include, err)
+		}
+		if isEmptyInclude(include) {
+			continue
+		}
+		obj.setSource(ci.part, include)
+		if ci.include == adt.ClassIncludeTestClasses {
+			class.WithTests = "X"
+		}
+	}
+	obj.Files["xml"] = encodeValues("CLAS", values{VSEOCLASS: class})
+	return nil
+}
+
+// isEmptyInclude reports whether an include holds nothing but blank lines
+// and the *"* comments ADT generates.
+func isEmptyInclude(source string) bool {
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, `*"*`) {
+			return false
+		}
+	}
+	return true
+}
+
+// includePattern matches the INCLUDE statements of a function group's main program.
+var includePattern = regexp.MustCompile(`(?im)^\s*INCLUDE\s+([/\w]+)\s*\.`)
+
+// exportFunctionGroup reads a function group: its main program, its
+// includes and its function modules. The includes generated for function
+// modules (L<group>UXX, L<group>Unn) are left out, as abapGit does.
+func (ex *Exporter) exportFunctionGroup(ctx context.Context, obj *Object, desc string) error {
+	group := obj.Name
+	fg, err := ex.client.GetFunctionGroup(ctx, group)
+	if err != nil {
+		return err
+	}
+	main, err := ex.client.GetFunctionGroupSource(ctx, group)
+	if err != nil {
+		return err
+	}
+	namespace, base := "", group
+	if i := strings.LastIndex(group, "/"); i > 0 {
+		namespace, base = group[:i+1], group[i+1:]
+	}
+	obj.setSource(partName(namespace+"SAPL"+base)+".abap", main)
+
+	v := values{AREAT: desc}
+	generated := regexp.MustCompile(`^` + regexp.QuoteMeta(namespace+"L"+base) + `U(XX|\d\d)$`)
+	for _, m := range includePattern.FindAllStringSubmatch(main, -1) {
+		include := strings.ToUpper(m[1])
+		if generated.MatchString(include) {
+			continue
+		}
+		source, err := ex.client.GetFunctionGroupInclude(ctx, group, include)
+		if err != nil {
+			return fmt.Errorf("include %s: %w", include, err)
+		}
+		obj.setSource(partName(include)+".abap", source)
+		v.INCLUDES = append(v.INCLUDES, include)
+	}
+	v.INCLUDES = append(v.INCLUDES, namespace+"SAPL"+base)
+
+	for _, f := range fg.Functions {
+		source, err := ex.client.GetFunction(ctx, f.Name, group)
+		if err != nil {
+			return fmt.Errorf("function module %s: %w", f.Name, err)
+		}
+		fm, body := parseFunctionSource(source)
+		fm.Name = strings.ToUpper(f.Name)
+		v.FUNCTIONS = append(v.FUNCTIONS, fm)
+		obj.Files[partName(fm.Name)+".abap"] = []byte(abapGitFunctionSource(fm, body))
+	}
+	obj.Files["xml"] = encodeValues("FUGR", v)
+	return nil
+}
+
+// partName returns the file name part of a function group include or
+// function module, e.g. "#dmo#lfootop" for /DMO/LFOOTOP.
+func partName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "/", "#"))
+}
+
+// exportDomain reads a domain with its fixed values.
+func (ex *Exporter) exportDomain(ctx context.Context, obj *Object) error {
+	d, err := ex.client.GetDomain(ctx, obj.Name)
+	if err != nil {
+		return err
+	}
+	dd := &dd01v{
+		Name:      obj.Name,
+		Datatype:  d.DataType,
+		Leng:      fmt.Sprintf("%06d", d.Length),
+		Outputlen: fmt.Sprintf("%06d", d.OutputLength),
+		Decimals:  fmt.Sprintf("%06d", d.Decimals),
+		Convexit:  d.ConversionExit,
+		Entitytab: d.ValueTable,
+		Ddtext:    d.Description,
+	}
+	if d.Lowercase {
+		dd.Lowercase = "X"
+	}
+	if d.Signed {
+		dd.Signflag = "X"
+	}
+	v := values{DD01V: dd}
+	for i, fv := range d.FixValues {
+		dd.Valexi = "X"
+		v.DD07VTab = append(v.DD07VTab, dd07v{Valpos: fmt.Sprintf("%04d", i+1), DomvalueL: fv.Low, DomvalueH: fv.High, Ddtext: fv.Text})
+	}
+	obj.Files["xml"] = encodeValues("DOMA", v)
+	return nil
+}
+
+// exportDataElement reads a data element.
+func (ex *Exporter) exportDataElement(ctx context.Context, obj *Object) error {
+	d, err := ex.client.GetDataElement(ctx, obj.Name)
+	if err != nil {
+		return err
+	}
+	dd := &dd04v{
+		Name:     obj.Name,
+		Headlen:  "55",
+		Scrlen1:  "10",
+		Scrlen2:  "20",
+		Scrlen3:  "40",
+		Ddtext:   d.Description,
+		Reptext:  d.Heading,
+		ScrtextS: d.ShortLabel,
+		ScrtextM: d.MediumLabel,
+		ScrtextL: d.LongLabel,
+		Shlpname: d.SearchHelp,
+		Memoryid: d.ParameterID,
+	}
+	if d.Domain != "" {
+		dd.Domname, dd.Refkind = d.Domain, "D"
+	} else {
+		dd.Datatype = d.DataType
+		dd.Leng = fmt.Sprintf("%06d", d.Length)
+		dd.Decimals = fmt.Sprintf("%06d", d.Decimals)
+	}
+	obj.Files["xml"] = encodeValues("DTEL", values{DD04V: dd})
+	return nil
+}
@@ -0,0 +1,105 @@
+package abapgit
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
+)
+
+func TestExport(t *testing.T) {
+	srv := adttest.NewServer()
+	defer srv.Close()
+	client := adt.NewClient(srv.URL, "developer", "secret")
+	ctx := context.Background()
+
+	if _, err := NewImporter(client, ImportOptions{Package: "$ZGIT"}).Import(ctx, newTestRepository(t)); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	repo, result, err := NewExporter(client, ExportOptions{}).Export(ctx, "$zgit")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if got := strings.Join(result.Packages, ","); got != "$ZGIT,$ZGIT_TOOLS" {
+		t.Errorf("packages = %s", got)
+	}
+	if result.Count("exported") != 9 || result.Count("failed") != 0 {
+		t.Errorf("export: %s %+v", result.Summary, result.Objects)
+	}
+	if repo.FolderLogic != FolderLogicPrefix {
+		t.Errorf("folder logic = %s", repo.FolderLogic)
+	}
+
+	files := repo.Files()
+	for name, want := range map[string]string{
+		".abapgit.xml":                      "<FOLDER_LOGIC>PREFIX</FOLDER_LOGIC>",
+		"src/package.devc.xml":              "<CTEXT>Git demo</CTEXT>",
+		"src/tools/package.devc.xml":        "<DEVC>",
+		"src/zcl_git.clas.abap":             "CLASS zcl_git DEFINITION PUBLIC.",
+		"src/zcl_git.clas.locals_imp.abap":  "CLASS lcl_helper DEFINITION.",
+		"src/zcl_git.clas.testclasses.abap": "ltcl_git",
+		"src/zcl_git.clas.xml":              "<WITH_UNIT_TESTS>X</WITH_UNIT_TESTS>",
+		"src/zif_git.intf.abap":             "INTERFACE zif_git PUBLIC.",
+		"src/zgit.fugr.saplzgit.abap":       "INCLUDE lzgittop.",
+		"src/zgit.fugr.lzgittop.abap":       "FUNCTION-POOL zgit.",
+		"src/zgit.fugr.z_demo_get.abap":     "FUNCTION z_demo_get.\n*\"----",
+		"src/zgit.fugr.xml":                 "<RSTBL>",
+		"src/zgit_status.doma.xml":          "<DOMVALUE_L>N</DOMVALUE_L>",
+		"src/zgit_status.dtel.xml":          "<DOMNAME>ZGIT_STATUS</DOMNAME>",
+		"src/zgit_line.tabl.xml":            "<ROLLNAME>ZGIT_STATUS</ROLLNAME>",
+		"src/zgit.msag.xml":                 "<TEXT>Done &amp;1</TEXT>",
+		"src/tools/zgit_tool.prog.abap":     "REPORT zgit_tool.",
+		"src/tools/zgit_view.ddls.asddls":   "define view entity ZGIT_VIEW",
+	} {
+		if !strings.Contains(string(files[name]), want) {
+			t.Errorf("%s: want %q in\n%s", name, want, files[name])
+		}
+	}
+	if _, ok := files["src/zcl_git.clas.locals_def.abap"]; ok {
+		t.Error("empty class include exported")
+	}
+
+	// The ZIP reads back as the same repository
+	var buf bytes.Buffer
+	if err := repo.WriteZip(&buf); err != nil {
+		t.Fatal(err)
+	}
+	back, err := ReadZip(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ReadZip: %v", err)
+	}
+	if len(back.Objects) != len(repo.Objects) || back.PackageOf("tools", "$ZGIT") != "$ZGIT_TOOLS" {
+		t.Errorf("read back %d objects, want %d", len(back.Objects), len(repo.Objects))
+	}
+	for _, obj := range back.Objects {
+		if desc := description(obj); desc == "" {
+			t.Errorf("%s %s has no description", obj.Type, obj.Name)
+		}
+	}
+}
+
+func TestExportFolderLogic(t *testing.T) {
+	srv := adttest.NewServer()
+	defer srv.Close()
+	srv.AddPackage("ZAPP", "App", "")
+	srv.AddPackage("ZAPP_CORE", "Core", "ZAPP")
+	srv.AddPackage("ZUTIL", "Utilities", "ZAPP")
+	srv.AddObject(adttest.Object{Type: "PROG/P", Name: "ZUTIL_REPORT", Package: "ZUTIL", Source: "REPORT zutil_report.\n"})
+	client := adt.NewClient(srv.URL, "developer", "secret")
+	ctx := context.Background()
+
+	repo, _, err := NewExporter(client, ExportOptions{}).Export(ctx, "ZAPP")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if repo.FolderLogic != FolderLogicFull || repo.Objects[0].Folder != "zutil" {
+		t.Errorf("folder logic %s, folder %q", repo.FolderLogic, repo.Objects[0].Folder)
+	}
+	if _, _, err := NewExporter(client, ExportOptions{FolderLogic: "prefix"}).Export(ctx, "ZAPP"); err == nil {
+		t.Error("PREFIX accepted for unprefixed subpackages")
+	}
+}
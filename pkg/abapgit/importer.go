@@ -0,0 +1,635 @@
+package abapgit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// ImportOptions configures an import.
+type ImportOptions struct {
+	Package           string // Root package the repository is installed into (required)
+	Transport         string // Transport request for transportable packages
+	SoftwareComponent string // Software component of new transportable packages
+	DryRun            bool   // Only report what would be created or updated
+	MaxPasses         int    // Activation passes for interdependent objects (default: 5)
+}
+
+// ObjectResult is the outcome of importing one object.
+type ObjectResult struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	Action  string `json:"action"` // created, updated, skipped, failed
+	Message string `json:"message,omitempty"`
+}
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	Packages  []string       `json:"packages,omitempty"` // Packages created
+	Objects   []ObjectResult `json:"objects"`
+	Activated []string       `json:"activated,omitempty"`
+	Inactive  []ObjectResult `json:"inactive,omitempty"` // Objects that failed to activate, with the first error
+	Passes    int            `json:"passes"`
+	Summary   string         `json:"summary"`
+}
+
+// Count returns the number of objects with an action.
+func (r *ImportResult) Count(action string) int {
+	n := 0
+	for _, o := range r.Objects {
+		if o.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Importer installs abapGit repositories through ADT.
+type Importer struct {
+	client *adt.Client
+	opts   ImportOptions
+}
+
+// NewImporter creates an importer writing to the system of client.
+func NewImporter(client *adt.Client, opts ImportOptions) *Importer {
+	opts.Package = strings.ToUpper(opts.Package)
+	if opts.MaxPasses <= 0 {
+		opts.MaxPasses = 5
+	}
+	return &Importer{client: client, opts: opts}
+}
+
+// activation is an object written inactive, to be activated at the end.
+type activation struct {
+	url, name, typ string
+}
+
+// typeOrder is the order object types are written and activated in.
+// Dictionary objects come first: everything else may refer to them.
+var typeOrder = map[string]int{
+	"DOMA": 1,
+	"DTEL": 2,
+	"TABL": 3,
+	"MSAG": 4,
+	"DDLS": 5,
+	"BDEF": 6,
+	"INTF": 7,
+	"CLAS": 8,
+	"FUGR": 9,
+	"PROG": 10,
+	"SRVD": 11,
+}
+
+// importState carries the objects of a running import.
+type importState struct {
+	result  *ImportResult
+	pending []activation
+}
+
+// Import installs a repository: it creates the packages, then writes the
+// objects in dependency order and activates them. Dictionary objects are
+// activated as they are written so later objects can use them; source
+// objects are written inactive and activated together, in several passes
+// when they depend on each other. Existing objects are updated.
+func (im *Importer) Import(ctx context.Context, repo *Repository) (*ImportResult, error) {
+	if im.opts.Package == "" {
+		return nil, fmt.Errorf("target package is required")
+	}
+	st := &importState{result: &ImportResult{Objects: []ObjectResult{}}}
+
+	if err := im.createPackages(ctx, repo, st); err != nil {
+		return st.result, err
+	}
+
+	objects := append([]*Object(nil), repo.Objects...)
+	sort.SliceStable(objects, func(i, j int) bool {
+		return order(objects[i]) < order(objects[j])
+	})
+
+	for i, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return st.result, err
+		}
+		pkg := repo.PackageOf(obj.Folder, im.opts.Package)
+		res := ObjectResult{Type: obj.Type, Name: obj.Name, Package: pkg}
+		created, err := im.importObject(ctx, obj, pkg, st)
+		switch {
+		case errors.Is(err, errUnsupported):
+			res.Action, res.Message = "skipped", fmt.Sprintf("object type %s is not supported", obj.Type)
+		case errors.Is(err, errExists):
+			res.Action, res.Message = "skipped", err.Error()
+		case err != nil:
+			res.Action, res.Message = "failed", err.Error()
+		case created:
+			res.Action = "created"
+		default:
+			res.Action = "updated"
+		}
+		st.result.Objects = append(st.result.Objects, res)
+
+		// Dictionary objects are activated before the objects that use them
+		if isDictionary(obj.Type) && (i+1 == len(objects) || order(objects[i+1]) != order(obj)) {
+			im.activate(ctx, st, false)
+		}
+	}
+	im.activate(ctx, st, true)
+
+	st.result.Summary = fmt.Sprintf("%d created, %d updated, %d skipped, %d failed, %d activated, %d inactive",
+		st.result.Count("created"), st.result.Count("updated"), st.result.Count("skipped"), st.result.Count("failed"),
+		len(st.result.Activated), len(st.result.Inactive))
+	if im.opts.DryRun {
+		st.result.Summary = "Dry run: " + st.result.Summary
+	}
+	return st.result, nil
+}
+
+func order(obj *Object) int {
+	if o, ok := typeOrder[obj.Type]; ok {
+		return o
+	}
+	return 50
+}
+
+func isDictionary(typ string) bool {
+	return typ == "DOMA" || typ == "DTEL" || typ == "TABL"
+}
+
+var (
+	errUnsupported = errors.New("unsupported object type")
+	errExists      = errors.New("exists; dictionary definitions are not updated")
+)
+
+// ddicURL returns the ADT URL of a dictionary object in a collection.
+func ddicURL(collection, name string) string {
+	return collection + "/" + url.PathEscape(strings.ToLower(name))
+}
+
+// createPackages creates the root package and a subpackage per folder.
+func (im *Importer) createPackages(ctx context.Context, repo *Repository, st *importState) error {
+	for _, f := range repo.Folders(im.opts.Package) {
+		if _, err := im.client.GetPackage(ctx, f.Package); err == nil {
+			continue
+		}
+		st.result.Packages = append(st.result.Packages, f.Package)
+		if im.opts.DryRun {
+			continue
+		}
+		desc := f.Description
+		if desc == "" {
+			desc = f.Package
+		}
+		err := im.client.CreateObject(ctx, adt.CreateObjectOptions{
+			ObjectType:        adt.ObjectTypePackage,
+			Name:              f.Package,
+			Description:       desc,
+			PackageName:       repo.ParentPackage(f.Path, im.opts.Package),
+			Transport:         im.opts.Transport,
+			SoftwareComponent: im.opts.SoftwareComponent,
+		})
+		if err != nil {
+			return fmt.Errorf("creating package %s: %w", f.Package, err)
+		}
+	}
+	return nil
+}
+
+// importObject creates or updates one object and reports whether it was created.
+func (im *Importer) importObject(ctx context.Context, obj *Object, pkg string, st *importState) (bool, error) {
+	var v values
+	if data, ok := obj.Files["xml"]; ok {
+		if err := decodeValues(data, &v); err != nil {
+			return false, fmt.Errorf("%s: %w", FileName(obj.Type, obj.Name, "xml"), err)
+		}
+	}
+	desc := description(obj)
+	if desc == "" {
+		desc = obj.Name
+	}
+
+	switch obj.Type {
+	case "DOMA":
+		return im.importDomain(ctx, obj, pkg, desc, v, st)
+	case "DTEL":
+		return im.importDataElement(ctx, obj, pkg, desc, v, st)
+	case "TABL":
+		return im.importTable(ctx, obj, pkg, v, st)
+	case "MSAG":
+		return im.importMessageClass(ctx, obj, pkg, desc, v)
+	case "CLAS":
+		return im.importClass(ctx, obj, pkg, desc, st)
+	case "INTF":
+		return im.importSource(ctx, adt.ObjectTypeInterface, obj.Name, "", pkg, desc, obj, "abap", st)
+	case "PROG":
+		typ := adt.ObjectTypeProgram
+		if v.PROGDIR != nil && v.PROGDIR.Subc == "I" {
+			typ = adt.ObjectTypeInclude
+		}
+		return im.importSource(ctx, typ, obj.Name, "", pkg, desc, obj, "abap", st)
+	case "FUGR":
+		return im.importFunctionGroup(ctx, obj, pkg, desc, v, st)
+	case "DDLS":
+		return im.importSource(ctx, adt.ObjectTypeDDLS, obj.Name, "", pkg, desc, obj, "asddls", st)
+	case "BDEF":
+		return im.importSource(ctx, adt.ObjectTypeBDEF, obj.Name, "", pkg, desc, obj, "asbdef", st)
+	case "SRVD":
+		return im.importSource(ctx, adt.ObjectTypeSRVD, obj.Name, "", pkg, desc, obj, "srvdsrv", st)
+	}
+	return false, errUnsupported
+}
+
+// ensure creates an object unless it exists and reports whether it was
+// (or, in a dry run, would be) created.
+func (im *Importer) ensure(ctx context.Context, objectURL string, create func() error) (bool, error) {
+	exists, err := im.client.ObjectExists(ctx, objectURL)
+	if err != nil {
+		return false, err
+	}
+	if exists || im.opts.DryRun {
+		return !exists, nil
+	}
+	return true, create()
+}
+
+// writeSource writes a source under a lock on the object, leaving it inactive.
+func (im *Importer) writeSource(ctx context.Context, objectURL, sourceURL, source string) error {
+	lock, err := im.client.LockObject(ctx, objectURL, "MODIFY")
+	if err != nil {
+		return fmt.Errorf("locking: %w", err)
+	}
+	defer im.client.UnlockObject(ctx, objectURL, lock.LockHandle)
+	return im.client.UpdateSource(ctx, sourceURL, source, lock.LockHandle, im.opts.Transport)
+}
+
+// importSource creates or updates a single-source object.
+func (im *Importer) importSource(ctx context.Context, typ adt.CreatableObjectType, name, parent, pkg, desc string, obj *Object, part string, st *importState) (bool, error) {
+	source, ok := obj.Source(part)
+	if !ok {
+		return false, fmt.Errorf("%s is missing", FileName(obj.Type, obj.Name, part))
+	}
+	objectURL := adt.GetObjectURL(typ, name, parent)
+	created, err := im.ensure(ctx, objectURL, func() error {
+		return im.client.CreateObject(ctx, adt.CreateObjectOptions{
+			ObjectType:  typ,
+			Name:        name,
+			Description: truncate(desc, 60),
+			PackageName: pkg,
+			Transport:   im.opts.Transport,
+			ParentName:  parent,
+		})
+	})
+	if err != nil || im.opts.DryRun {
+		return created, err
+	}
+	if err := im.writeSource(ctx, objectURL, adt.GetSourceURL(typ, name, parent), source); err != nil {
+		return created, err
+	}
+	st.pending = append(st.pending, activation{url: objectURL, name: strings.ToUpper(name), typ: obj.Type})
+	return created, nil
+}
+
+// classIncludes maps abapGit class file parts to ADT class includes.
+var classIncludes = []struct {
+	part    string
+	include adt.ClassIncludeType
+}{
+	{"locals_def.abap", adt.ClassIncludeDefinitions},
+	{"locals_imp.abap", adt.ClassIncludeImplementations},
+	{"macros.abap", adt.ClassIncludeMacros},
+	{"testclasses.abap", adt.ClassIncludeTestClasses},
+}
+
+// importClass creates or updates a class with its local includes.
+func (im *Importer) importClass(ctx context.Context, obj *Object, pkg, desc string, st *importState) (bool, error) {
+	source, ok := obj.Source("abap")
+	if !ok {
+		return false, fmt.Errorf("%s is missing", FileName(obj.Type, obj.Name, "abap"))
+	}
+	objectURL := adt.GetObjectURL(adt.ObjectTypeClass, obj.Name, "")
+	created, err := im.ensure(ctx, objectURL, func() error {
+		return im.client.CreateObject(ctx, adt.CreateObjectOptions{
+			ObjectType:  adt.ObjectTypeClass,
+			Name:        obj.Name,
+			Description: truncate(desc, 60),
+			PackageName: pkg,
+			Transport:   im.opts.Transport,
+		})
+	})
+	if err != nil || im.opts.DryRun {
+		return created, err
+	}
+
+	lock, err := im.client.LockObject(ctx, objectURL, "MODIFY")
+	if err != nil {
+		return created, fmt.Errorf("locking: %w", err)
+	}
+	defer im.client.UnlockObject(ctx, objectURL, lock.LockHandle)
+
+	if err := im.client.UpdateSource(ctx, adt.GetSourceURL(adt.ObjectTypeClass, obj.Name, ""), source, lock.LockHandle, im.opts.Transport); err != nil {
+		return created, err
+	}
+	for _, ci := range classIncludes {
+		src, ok := obj.Source(ci.part)
+		if !ok {
+			continue
+		}
+		if ci.include == adt.ClassIncludeTestClasses {
+			// The test include exists only once created; an existing one makes this fail harmlessly
+			im.client.CreateTestInclude(ctx, obj.Name, lock.LockHandle, im.opts.Transport)
+		}
+		if err := im.client.UpdateClassInclude(ctx, obj.Name, ci.include, src, lock.LockHandle, im.opts.Transport); err != nil {
+			return created, fmt.Errorf("%s: %w", ci.part, err)
+		}
+	}
+	st.pending = append(st.pending, activation{url: objectURL, name: obj.Name, typ: obj.Type})
+	return created, nil
+}
+
+// importFunctionGroup creates or updates a function group, its includes and
+// its function modules.
+func (im *Importer) importFunctionGroup(ctx context.Context, obj *Object, pkg, desc string, v values, st *importState) (bool, error) {
+	group := obj.Name
+	groupURL := adt.GetObjectURL(adt.ObjectTypeFunctionGroup, group, "")
+	created, err := im.ensure(ctx, groupURL, func() error {
+		return im.client.CreateObject(ctx, adt.CreateObjectOptions{
+			ObjectType:  adt.ObjectTypeFunctionGroup,
+			Name:        group,
+			Description: truncate(desc, 60),
+			PackageName: pkg,
+			Transport:   im.opts.Transport,
+		})
+	})
+	if err != nil || im.opts.DryRun {
+		return created, err
+	}
+
+	modules := make(map[string]functionModule)
+	for _, fm := range v.FUNCTIONS {
+		modules[strings.ToUpper(fm.Name)] = fm
+	}
+
+	// Includes first: function modules may use their declarations
+	var parts []string
+	for part := range obj.Files {
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+	for _, part := range parts {
+		name := strings.ToUpper(strings.ReplaceAll(strings.TrimSuffix(part, ".abap"), "#", "/"))
+		if !strings.HasSuffix(part, ".abap") || strings.Contains(name, ".") {
+			continue
+		}
+		if _, isModule := modules[name]; isModule {
+			continue
+		}
+		// The main program and the function module include list are generated
+		base := name[strings.LastIndex(name, "/")+1:]
+		if strings.HasPrefix(base, "SAPL") || strings.HasSuffix(name, "UXX") {
+			continue
+		}
+		includeURL := adt.GetObjectURL(adt.ObjectTypeFunctionIncl, name, group)
+		if _, err := im.ensure(ctx, includeURL, func() error {
+			return im.client.CreateObject(ctx, adt.CreateObjectOptions{
+				ObjectType:  adt.ObjectTypeFunctionIncl,
+				Name:        name,
+				Description: name,
+				PackageName: pkg,
+				Transport:   im.opts.Transport,
+				ParentName:  group,
+			})
+		}); err != nil {
+			return created, fmt.Errorf("include %s: %w", name, err)
+		}
+		if err := im.writeSource(ctx, includeURL, adt.GetSourceURL(adt.ObjectTypeFunctionIncl, name, group), string(obj.Files[part])); err != nil {
+			return created, fmt.Errorf("include %s: %w", name, err)
+		}
+		st.pending = append(st.pending, activation{url: includeURL, name: name, typ: obj.Type})
+	}
+
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fm := modules[name]
+		source, ok := obj.Source(strings.ToLower(strings.ReplaceAll(name, "/", "#")) + ".abap")
+		if !ok {
+			return created, fmt.Errorf("source of function module %s is missing", name)
+		}
+		fmURL := adt.GetObjectURL(adt.ObjectTypeFunctionMod, name, group)
+		text := fm.ShortText
+		if text == "" {
+			text = name
+		}
+		if _, err := im.ensure(ctx, fmURL, func() error {
+			return im.client.CreateObject(ctx, adt.CreateObjectOptions{
+				ObjectType:  adt.ObjectTypeFunctionMod,
+				Name:        name,
+				Description: truncate(text, 74),
+				PackageName: pkg,
+				Transport:   im.opts.Transport,
+				ParentName:  group,
+			})
+		}); err != nil {
+			return created, fmt.Errorf("function module %s: %w", name, err)
+		}
+		if err := im.writeSource(ctx, fmURL, adt.GetSourceURL(adt.ObjectTypeFunctionMod, name, group), functionSource(fm, source)); err != nil {
+			return created, fmt.Errorf("function module %s: %w", name, err)
+		}
+		st.pending = append(st.pending, activation{url: fmURL, name: name, typ: obj.Type})
+	}
+	st.pending = append(st.pending, activation{url: groupURL, name: group, typ: obj.Type})
+	return created, nil
+}
+
+// importDomain creates a domain. Existing domains are left unchanged.
+func (im *Importer) importDomain(ctx context.Context, obj *Object, pkg, desc string, v values, st *importState) (bool, error) {
+	if v.DD01V == nil {
+		return false, fmt.Errorf("%s has no DD01V", FileName(obj.Type, obj.Name, "xml"))
+	}
+	d := v.DD01V
+	opts := adt.DomainOptions{
+		Name:           obj.Name,
+		Description:    desc,
+		Package:        pkg,
+		Transport:      im.opts.Transport,
+		DataType:       d.Datatype,
+		Length:         atoi(d.Leng),
+		Decimals:       atoi(d.Decimals),
+		OutputLength:   atoi(d.Outputlen),
+		ConversionExit: d.Convexit,
+		Lowercase:      d.Lowercase == "X",
+		Signed:         d.Signflag == "X",
+		ValueTable:     d.Entitytab,
+	}
+	for _, fv := range v.DD07VTab {
+		opts.FixValues = append(opts.FixValues, adt.DomainFixValue{Low: fv.DomvalueL, High: fv.DomvalueH, Text: fv.Ddtext})
+	}
+	return im.createDictionary(ctx, ddicURL("/sap/bc/adt/ddic/domains", obj.Name), obj, st, func() error {
+		return im.client.CreateDomain(ctx, opts)
+	})
+}
+
+// importDataElement creates a data element. Existing data elements are left unchanged.
+func (im *Importer) importDataElement(ctx context.Context, obj *Object, pkg, desc string, v values, st *importState) (bool, error) {
+	if v.DD04V == nil {
+		return false, fmt.Errorf("%s has no DD04V", FileName(obj.Type, obj.Name, "xml"))
+	}
+	d := v.DD04V
+	opts := adt.DataElementOptions{
+		Name:        obj.Name,
+		Description: desc,
+		Package:     pkg,
+		Transport:   im.opts.Transport,
+		ShortLabel:  d.ScrtextS,
+		MediumLabel: d.ScrtextM,
+		LongLabel:   d.ScrtextL,
+		Heading:     d.Reptext,
+		SearchHelp:  d.Shlpname,
+		ParameterID: d.Memoryid,
+	}
+	if d.Refkind == "D" || (d.Refkind == "" && d.Domname != "") {
+		opts.Domain = d.Domname
+	} else {
+		opts.DataType, opts.Length, opts.Decimals = d.Datatype, atoi(d.Leng), atoi(d.Decimals)
+	}
+	return im.createDictionary(ctx, ddicURL("/sap/bc/adt/ddic/dataelements", obj.Name), obj, st, func() error {
+		return im.client.CreateDataElement(ctx, opts)
+	})
+}
+
+// createDictionary creates an XML-defined dictionary object unless it exists.
+// Updating an existing one would replace its definition wholesale, so it is
+// skipped.
+func (im *Importer) createDictionary(ctx context.Context, objectURL string, obj *Object, st *importState, create func() error) (bool, error) {
+	created, err := im.ensure(ctx, objectURL, create)
+	if err == nil && !created {
+		err = errExists
+	}
+	if err != nil || im.opts.DryRun {
+		return created, err
+	}
+	st.pending = append(st.pending, activation{url: objectURL, name: obj.Name, typ: obj.Type})
+	return created, nil
+}
+
+// importTable creates or updates a table or structure from its DDL.
+func (im *Importer) importTable(ctx context.Context, obj *Object, pkg string, v values, st *importState) (bool, error) {
+	if v.DD02V == nil {
+		return false, fmt.Errorf("%s has no DD02V", FileName(obj.Type, obj.Name, "xml"))
+	}
+	category, collection := "TRANSPARENT", "/sap/bc/adt/ddic/tables"
+	if v.DD02V.Tabclass == "INTTAB" {
+		category, collection = "STRUCTURE", "/sap/bc/adt/ddic/structures"
+	} else if v.DD02V.Tabclass != "TRANSP" {
+		return false, fmt.Errorf("table class %s is not supported", v.DD02V.Tabclass)
+	}
+	source := tableDDL(*v.DD02V, v.DD03PTab)
+	objectURL := ddicURL(collection, obj.Name)
+
+	created, err := im.ensure(ctx, objectURL, func() error {
+		// CreateTable activates; a failed activation is retried with the others
+		return im.client.CreateTable(ctx, adt.CreateTableOptions{
+			Name:          obj.Name,
+			Description:   truncate(v.DD02V.Ddtext, 60),
+			Package:       pkg,
+			Transport:     im.opts.Transport,
+			TableCategory: category,
+			Source:        source,
+		})
+	})
+	if err != nil || im.opts.DryRun {
+		return created, err
+	}
+	if !created {
+		if err := im.writeSource(ctx, objectURL, objectURL+"/source/main", source); err != nil {
+			return false, err
+		}
+	}
+	st.pending = append(st.pending, activation{url: objectURL, name: obj.Name, typ: obj.Type})
+	return created, nil
+}
+
+// importMessageClass creates a message class. Existing message classes are left unchanged.
+func (im *Importer) importMessageClass(ctx context.Context, obj *Object, pkg, desc string, v values) (bool, error) {
+	var messages []adt.MessageClassMessage
+	for _, m := range v.T100 {
+		messages = append(messages, adt.MessageClassMessage{Number: m.Msgnr, Text: m.Text})
+	}
+	created, err := im.ensure(ctx, ddicURL("/sap/bc/adt/messageclass", obj.Name), func() error {
+		return im.client.CreateMessageClass(ctx, obj.Name, desc, pkg, im.opts.Transport, messages)
+	})
+	if err == nil && !created {
+		err = errExists
+	}
+	return created, err
+}
+
+// activate activates the pending objects in type order. Objects failing
+// activation are retried while each pass activates at least one object.
+// Those still failing stay pending for the final activation, after which
+// they are reported as inactive.
+func (im *Importer) activate(ctx context.Context, st *importState, final bool) {
+	pending := st.pending
+	st.pending = nil
+	if len(pending) == 0 || im.opts.DryRun {
+		return
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return typeOrder[pending[i].typ] < typeOrder[pending[j].typ]
+	})
+
+	failures := make(map[activation]string)
+	for pass := 1; pass <= im.opts.MaxPasses && len(pending) > 0; pass++ {
+		st.result.Passes++
+		var retry []activation
+		for _, a := range pending {
+			res, err := im.client.Activate(ctx, a.url, a.name)
+			switch {
+			case err != nil:
+				failures[a] = err.Error()
+				retry = append(retry, a)
+			case !res.Success:
+				failures[a] = firstError(res)
+				retry = append(retry, a)
+			default:
+				delete(failures, a)
+				st.result.Activated = append(st.result.Activated, a.name)
+			}
+		}
+		if len(retry) == len(pending) {
+			pending = retry
+			break
+		}
+		pending = retry
+	}
+	if !final {
+		st.pending = pending
+		return
+	}
+	for _, a := range pending {
+		st.result.Inactive = append(st.result.Inactive, ObjectResult{Type: a.typ, Name: a.name, Action: "failed", Message: failures[a]})
+	}
+}
+
+func firstError(res *adt.ActivationResult) string {
+	for _, m := range res.Messages {
+		if m.Type == "E" || m.Type == "A" {
+			return m.ShortText
+		}
+	}
+	return "activation failed"
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
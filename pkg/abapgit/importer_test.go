@@ -0,0 +1,154 @@
+package abapgit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
+)
+
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".abapgit.xml":         abapgitXML,
+		"src/package.devc.xml": objectXML("LCL_OBJECT_DEVC", "<DEVC><CTEXT>Git demo</CTEXT></DEVC>"),
+		"src/zgit_status.doma.xml": objectXML("LCL_OBJECT_DOMA", `<DD01V><DOMNAME>ZGIT_STATUS</DOMNAME><DATATYPE>CHAR</DATATYPE><LENG>000001</LENG><DDTEXT>Status</DDTEXT></DD01V>
+<DD07V_TAB><DD07V><DOMVALUE_L>N</DOMVALUE_L><DDTEXT>New</DDTEXT></DD07V></DD07V_TAB>`),
+		"src/zgit_status.dtel.xml": objectXML("LCL_OBJECT_DTEL", `<DD04V><ROLLNAME>ZGIT_STATUS</ROLLNAME><DOMNAME>ZGIT_STATUS</DOMNAME><DDTEXT>Status</DDTEXT><REFKIND>D</REFKIND></DD04V>`),
+		"src/zgit_line.tabl.xml": objectXML("LCL_OBJECT_TABL", `<DD02V><TABNAME>ZGIT_LINE</TABNAME><TABCLASS>INTTAB</TABCLASS><DDTEXT>Line</DDTEXT></DD02V>
+<DD03P_TABLE><DD03P><FIELDNAME>STATUS</FIELDNAME><ROLLNAME>ZGIT_STATUS</ROLLNAME><COMPTYPE>E</COMPTYPE></DD03P></DD03P_TABLE>`),
+		"src/zgit.msag.xml": objectXML("LCL_OBJECT_MSAG", `<T100A><ARBGB>ZGIT</ARBGB><STEXT>Messages</STEXT></T100A>
+<T100><T100><MSGNR>001</MSGNR><TEXT>Done &amp;1</TEXT></T100></T100>`),
+		"src/zif_git.intf.abap":             "INTERFACE zif_git PUBLIC.\nENDINTERFACE.\n",
+		"src/zcl_git.clas.abap":             "CLASS zcl_git DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_git IMPLEMENTATION.\nENDCLASS.\n",
+		"src/zcl_git.clas.locals_imp.abap":  "CLASS lcl_helper DEFINITION.\nENDCLASS.\n",
+		"src/zcl_git.clas.testclasses.abap": "CLASS ltcl_git DEFINITION FOR TESTING.\nENDCLASS.\n",
+		"src/zcl_git.clas.xml":              objectXML("LCL_OBJECT_CLAS", "<VSEOCLASS><CLSNAME>ZCL_GIT</CLSNAME><DESCRIPT>Git class</DESCRIPT></VSEOCLASS>"),
+		"src/zgit.fugr.xml":                 objectXML("LCL_OBJECT_FUGR", fugrXML),
+		"src/zgit.fugr.lzdemotop.abap":      "FUNCTION-POOL zgit.\n",
+		"src/zgit.fugr.saplzgit.abap":       "INCLUDE lzgittop.\n",
+		"src/zgit.fugr.z_demo_get.abap":     "FUNCTION z_demo_get.\n*\"---\n  CLEAR et_flights.\nENDFUNCTION.\n",
+		"src/zgit.tran.xml":                 objectXML("LCL_OBJECT_TRAN", "<TSTC><TCODE>ZGIT</TCODE></TSTC>"),
+		"src/tools/zgit_tool.prog.abap":     "REPORT zgit_tool.\n",
+		"src/tools/zgit_tool.prog.xml":      objectXML("LCL_OBJECT_PROG", "<PROGDIR><NAME>ZGIT_TOOL</NAME><SUBC>1</SUBC></PROGDIR><TPOOL><item><ID>R</ID><ENTRY>Git tool</ENTRY></item></TPOOL>"),
+		"src/tools/zgit_view.ddls.asddls":   "define view entity ZGIT_VIEW as select from t000 { key mandt }",
+		"src/tools/zgit_view.ddls.xml":      objectXML("LCL_OBJECT_DDLS", "<DDLS><DDLNAME>ZGIT_VIEW</DDLNAME><DDTEXT>Git view</DDTEXT></DDLS>"),
+	})
+	repo, err := ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestImport(t *testing.T) {
+	srv := adttest.NewServer()
+	defer srv.Close()
+	client := adt.NewClient(srv.URL, "developer", "secret", adt.WithClient("001"))
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	result, err := NewImporter(client, ImportOptions{Package: "$zgit"}).Import(ctx, repo)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if got := strings.Join(result.Packages, ","); got != "$ZGIT,$ZGIT_TOOLS" {
+		t.Errorf("packages = %s", got)
+	}
+	for _, o := range result.Objects {
+		want := "created"
+		if o.Type == "TRAN" {
+			want = "skipped"
+		}
+		if o.Action != want {
+			t.Errorf("%s %s: %s %s", o.Type, o.Name, o.Action, o.Message)
+		}
+	}
+	if len(result.Inactive) != 0 {
+		t.Errorf("inactive objects: %+v", result.Inactive)
+	}
+
+	check := func(uri, pkg, source string) adttest.Object {
+		t.Helper()
+		obj, ok := srv.Object(uri)
+		if !ok {
+			t.Fatalf("%s not created", uri)
+		}
+		if obj.Inactive || obj.Package != pkg || !strings.Contains(obj.Source, source) {
+			t.Errorf("%s: inactive=%v package=%s source=%q", uri, obj.Inactive, obj.Package, obj.Source)
+		}
+		return obj
+	}
+	check("/sap/bc/adt/ddic/domains/zgit_status", "$ZGIT", "<doma:low>N</doma:low>")
+	check("/sap/bc/adt/ddic/dataelements/zgit_status", "$ZGIT", "ZGIT_STATUS")
+	check("/sap/bc/adt/ddic/structures/zgit_line", "$ZGIT", "status : zgit_status;")
+	// Message classes need no activation
+	if msag, ok := srv.Object("/sap/bc/adt/messageclass/zgit"); !ok || !strings.Contains(msag.Source, `mc:msgtext="Done &amp;1"`) {
+		t.Errorf("message class: %+v", msag)
+	}
+	check("/sap/bc/adt/oo/interfaces/ZIF_GIT", "$ZGIT", "INTERFACE zif_git")
+	cls := check("/sap/bc/adt/oo/classes/ZCL_GIT", "$ZGIT", "CLASS zcl_git")
+	if !strings.Contains(cls.Includes["implementations"], "lcl_helper") || !strings.Contains(cls.Includes["testclasses"], "ltcl_git") {
+		t.Errorf("class includes: %+v", cls.Includes)
+	}
+	check("/sap/bc/adt/functions/groups/ZGIT/includes/LZDEMOTOP", "$ZGIT", "FUNCTION-POOL")
+	check("/sap/bc/adt/functions/groups/ZGIT/fmodules/Z_DEMO_GET", "$ZGIT", "FUNCTION z_demo_get\n  IMPORTING\n    VALUE(IV_ID) TYPE I")
+	check("/sap/bc/adt/programs/programs/ZGIT_TOOL", "$ZGIT_TOOLS", "REPORT zgit_tool.")
+	check("/sap/bc/adt/ddic/ddl/sources/zgit_view", "$ZGIT_TOOLS", "define view entity")
+	if _, ok := srv.Object("/sap/bc/adt/functions/groups/ZGIT/includes/SAPLZGIT"); ok {
+		t.Error("generated main program was imported")
+	}
+
+	// A second import updates sources and leaves dictionary definitions alone
+	result, err = NewImporter(client, ImportOptions{Package: "$ZGIT"}).Import(ctx, repo)
+	if err != nil {
+		t.Fatalf("second Import: %v", err)
+	}
+	if len(result.Packages) != 0 || result.Count("created") != 0 || result.Count("updated") != 6 || result.Count("skipped") != 4 || result.Count("failed") != 0 {
+		t.Errorf("second import: %s %+v", result.Summary, result.Objects)
+	}
+}
+
+func TestImportActivationErrors(t *testing.T) {
+	srv := adttest.NewServer()
+	defer srv.Close()
+	srv.Check = func(uri, source string) []adttest.Message {
+		if strings.Contains(source, "zcl_git") {
+			return []adttest.Message{{Severity: "E", Line: 1, Text: "Type ZIF_MISSING is unknown"}}
+		}
+		return nil
+	}
+	client := adt.NewClient(srv.URL, "developer", "secret")
+	repo := newTestRepository(t)
+
+	result, err := NewImporter(client, ImportOptions{Package: "$ZGIT", MaxPasses: 3}).Import(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(result.Inactive) != 1 || result.Inactive[0].Name != "ZCL_GIT" || !strings.Contains(result.Inactive[0].Message, "ZIF_MISSING") {
+		t.Errorf("inactive = %+v", result.Inactive)
+	}
+	if obj, _ := srv.Object("/sap/bc/adt/oo/interfaces/ZIF_GIT"); obj.Inactive {
+		t.Error("interface not activated")
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	srv := adttest.NewServer()
+	defer srv.Close()
+	client := adt.NewClient(srv.URL, "developer", "secret")
+
+	result, err := NewImporter(client, ImportOptions{Package: "$ZGIT", DryRun: true}).Import(context.Background(), newTestRepository(t))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(result.Packages) != 2 || result.Count("created") != 9 || !strings.HasPrefix(result.Summary, "Dry run") {
+		t.Errorf("dry run: %s %+v", result.Summary, result.Objects)
+	}
+	if n := srv.CountRequests("POST", "/sap/bc/adt/packages"); n != 0 {
+		t.Errorf("dry run sent %d package creations", n)
+	}
+}
@@ -0,0 +1,804 @@
+package abapgit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// values is the asx:values payload of abapGit XML files. Each object type
+// fills its own elements; all are optional.
+type values struct {
+	Data *repoData `xml:"DATA,omitempty"` // .abapgit.xml
+	DEVC *devc     `xml:"DEVC,omitempty"`
+
+	VSEOCLASS  *vseoClass `xml:"VSEOCLASS,omitempty"`
+	VSEOINTERF *vseoClass `xml:"VSEOINTERF,omitempty"`
+
+	PROGDIR *progdir    `xml:"PROGDIR,omitempty"`
+	TPOOL   []textEntry `xml:"TPOOL>item,omitempty"`
+
+	AREAT     string           `xml:"AREAT,omitempty"`
+	INCLUDES  []string         `xml:"INCLUDES>SOBJ_NAME,omitempty"`
+	FUNCTIONS []functionModule `xml:"FUNCTIONS>item,omitempty"`
+
+	DD01V     *dd01v  `xml:"DD01V,omitempty"`
+	DD07VTab  []dd07v `xml:"DD07V_TAB>DD07V,omitempty"`
+	DD04V     *dd04v  `xml:"DD04V,omitempty"`
+	DD02V     *dd02v  `xml:"DD02V,omitempty"`
+	DD03PTab  []dd03p `xml:"DD03P_TABLE>DD03P,omitempty"`
+	T100A     *t100a  `xml:"T100A,omitempty"`
+	T100      []t100  `xml:"T100>T100,omitempty"`
+	DDLS      *ddls   `xml:"DDLS,omitempty"`
+	Metadata  *meta   `xml:"METADATA,omitempty"` // BDEF, SRVD
+	DescrText string  `xml:"DESCRIPTION,omitempty"`
+}
+
+type repoData struct {
+	MasterLanguage string `xml:"MASTER_LANGUAGE,omitempty"`
+	StartingFolder string `xml:"STARTING_FOLDER,omitempty"`
+	FolderLogic    string `xml:"FOLDER_LOGIC,omitempty"`
+}
+
+type devc struct {
+	Ctext string `xml:"CTEXT"`
+}
+
+type vseoClass struct {
+	ClsName   string `xml:"CLSNAME"`
+	Langu     string `xml:"LANGU,omitempty"`
+	Descript  string `xml:"DESCRIPT,omitempty"`
+	State     string `xml:"STATE,omitempty"`
+	Exposure  string `xml:"EXPOSURE,omitempty"`
+	Clsccincl string `xml:"CLSCCINCL,omitempty"`
+	Fixpt     string `xml:"FIXPT,omitempty"`
+	Unicode   string `xml:"UNICODE,omitempty"`
+	WithTests string `xml:"WITH_UNIT_TESTS,omitempty"`
+}
+
+type progdir struct {
+	Name    string `xml:"NAME"`
+	Subc    string `xml:"SUBC"` // 1 = report, I = include
+	Fixpt   string `xml:"FIXPT,omitempty"`
+	Uccheck string `xml:"UCCHECK,omitempty"`
+}
+
+type textEntry struct {
+	ID     string `xml:"ID"` // R = title, I = text symbol, S = selection text
+	Key    string `xml:"KEY,omitempty"`
+	Entry  string `xml:"ENTRY"`
+	Length int    `xml:"LENGTH,omitempty"`
+}
+
+type functionModule struct {
+	Name           string        `xml:"FUNCNAME"`
+	RemoteCall     string        `xml:"REMOTE_CALL,omitempty"`
+	ShortText      string        `xml:"SHORT_TEXT,omitempty"`
+	Import         []fmParameter `xml:"IMPORT>RSIMP,omitempty"`
+	Changing       []fmParameter `xml:"CHANGING>RSCHA,omitempty"`
+	Export         []fmParameter `xml:"EXPORT>RSEXP,omitempty"`
+	Tables         []fmParameter `xml:"TABLES>RSTBL,omitempty"`
+	Exception      []fmException `xml:"EXCEPTION>RSEXC,omitempty"`
+	ExceptionClass string        `xml:"EXCEPTION_CLASSES,omitempty"`
+}
+
+type fmParameter struct {
+	Parameter string `xml:"PARAMETER"`
+	DBField   string `xml:"DBFIELD,omitempty"`  // LIKE
+	DBStruct  string `xml:"DBSTRUCT,omitempty"` // STRUCTURE (TABLES)
+	Default   string `xml:"DEFAULT,omitempty"`
+	Optional  string `xml:"OPTIONAL,omitempty"`
+	Reference string `xml:"REFERENCE,omitempty"`
+	Typ       string `xml:"TYP,omitempty"`
+	RefClass  string `xml:"REF_CLASS,omitempty"`
+}
+
+type fmException struct {
+	Exception string `xml:"EXCEPTION"`
+}
+
+type dd01v struct {
+	Name      string `xml:"DOMNAME"`
+	Datatype  string `xml:"DATATYPE,omitempty"`
+	Leng      string `xml:"LENG,omitempty"`
+	Outputlen string `xml:"OUTPUTLEN,omitempty"`
+	Decimals  string `xml:"DECIMALS,omitempty"`
+	Lowercase string `xml:"LOWERCASE,omitempty"`
+	Signflag  string `xml:"SIGNFLAG,omitempty"`
+	Valexi    string `xml:"VALEXI,omitempty"`
+	Convexit  string `xml:"CONVEXIT,omitempty"`
+	Entitytab string `xml:"ENTITYTAB,omitempty"`
+	Ddtext    string `xml:"DDTEXT,omitempty"`
+}
+
+type dd07v struct {
+	Valpos    string `xml:"VALPOS,omitempty"`
+	DomvalueL string `xml:"DOMVALUE_L"`
+	DomvalueH string `xml:"DOMVALUE_H,omitempty"`
+	Ddtext    string `xml:"DDTEXT,omitempty"`
+}
+
+type dd04v struct {
+	Name     string `xml:"ROLLNAME"`
+	Domname  string `xml:"DOMNAME,omitempty"`
+	Headlen  string `xml:"HEADLEN,omitempty"`
+	Scrlen1  string `xml:"SCRLEN1,omitempty"`
+	Scrlen2  string `xml:"SCRLEN2,omitempty"`
+	Scrlen3  string `xml:"SCRLEN3,omitempty"`
+	Ddtext   string `xml:"DDTEXT,omitempty"`
+	Reptext  string `xml:"REPTEXT,omitempty"`
+	ScrtextS string `xml:"SCRTEXT_S,omitempty"`
+	ScrtextM string `xml:"SCRTEXT_M,omitempty"`
+	ScrtextL string `xml:"SCRTEXT_L,omitempty"`
+	Shlpname string `xml:"SHLPNAME,omitempty"`
+	Memoryid string `xml:"MEMORYID,omitempty"`
+	Refkind  string `xml:"REFKIND,omitempty"` // D = domain, R = reference, "" = built-in
+	Datatype string `xml:"DATATYPE,omitempty"`
+	Leng     string `xml:"LENG,omitempty"`
+	Decimals string `xml:"DECIMALS,omitempty"`
+}
+
+type dd02v struct {
+	Name     string `xml:"TABNAME"`
+	Tabclass string `xml:"TABCLASS"` // TRANSP, INTTAB (structure), ...
+	Ddtext   string `xml:"DDTEXT,omitempty"`
+	Contflag string `xml:"CONTFLAG,omitempty"` // delivery class
+	Exclass  string `xml:"EXCLASS,omitempty"`  // enhancement category
+	Mateflag string `xml:"MATEFLAG,omitempty"` // data maintenance
+	Clidep   string `xml:"CLIDEP,omitempty"`
+}
+
+type dd03p struct {
+	Fieldname  string `xml:"FIELDNAME"`
+	Keyflag    string `xml:"KEYFLAG,omitempty"`
+	Rollname   string `xml:"ROLLNAME,omitempty"`
+	Adminfield string `xml:"ADMINFIELD,omitempty"`
+	Inttype    string `xml:"INTTYPE,omitempty"`
+	Intlen     string `xml:"INTLEN,omitempty"`
+	Reftable   string `xml:"REFTABLE,omitempty"`
+	Precfield  string `xml:"PRECFIELD,omitempty"`
+	Reffield   string `xml:"REFFIELD,omitempty"`
+	Notnull    string `xml:"NOTNULL,omitempty"`
+	Datatype   string `xml:"DATATYPE,omitempty"`
+	Leng       string `xml:"LENG,omitempty"`
+	Decimals   string `xml:"DECIMALS,omitempty"`
+	Mask       string `xml:"MASK,omitempty"`
+	Comptype   string `xml:"COMPTYPE,omitempty"` // E = data element, S = structure, R = reference
+	Ddtext     string `xml:"DDTEXT,omitempty"`
+}
+
+type t100a struct {
+	Name  string `xml:"ARBGB"`
+	Stext string `xml:"STEXT,omitempty"`
+}
+
+type t100 struct {
+	Sprsl string `xml:"SPRSL,omitempty"`
+	Arbgb string `xml:"ARBGB,omitempty"`
+	Msgnr string `xml:"MSGNR"`
+	Text  string `xml:"TEXT"`
+}
+
+type ddls struct {
+	Name   string `xml:"DDLNAME"`
+	Ddtext string `xml:"DDTEXT,omitempty"`
+}
+
+type meta struct {
+	Description string `xml:"DESCRIPTION,attr"`
+}
+
+// decodeValues decodes the asx:values element of an abapGit XML file.
+func decodeValues(data []byte, v *values) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return fmt.Errorf("no asx:values element")
+		}
+		if err != nil {
+			return err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "values" {
+			return dec.DecodeElement(v, &start)
+		}
+	}
+}
+
+// asxAbap is the asx:abap element of abapGit XML files.
+type asxAbap struct {
+	XMLName xml.Name `xml:"asx:abap"`
+	Xmlns   string   `xml:"xmlns:asx,attr"`
+	Version string   `xml:"version,attr"`
+	Values  values   `xml:"asx:values"`
+}
+
+// objectFile is the envelope of an object's abapGit XML file.
+type objectFile struct {
+	XMLName           xml.Name `xml:"abapGit"`
+	Version           string   `xml:"version,attr"`
+	Serializer        string   `xml:"serializer,attr"`
+	SerializerVersion string   `xml:"serializer_version,attr"`
+	Abap              asxAbap
+}
+
+func newAsxAbap(v values) asxAbap {
+	return asxAbap{Xmlns: "http://www.sap.com/abapxml", Version: "1.0", Values: v}
+}
+
+// encodeValues encodes the XML file of an object of type typ, e.g. CLAS,
+// in the layout abapGit writes.
+func encodeValues(typ string, v values) []byte {
+	return marshalXML(objectFile{
+		Version:           "v1.0.0",
+		Serializer:        "LCL_OBJECT_" + typ,
+		SerializerVersion: "v1.0.0",
+		Abap:              newAsxAbap(v),
+	})
+}
+
+// encodeRepoData encodes .abapgit.xml.
+func encodeRepoData(data repoData) []byte {
+	return marshalXML(newAsxAbap(values{Data: &data}))
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="utf-8"?>` + "\n"
+
+func marshalXML(v any) []byte {
+	out, err := xml.MarshalIndent(v, "", " ")
+	if err != nil {
+		// The types are fixed; marshaling cannot fail
+		panic(err)
+	}
+	// Like abapGit, leave out empty elements. encoding/xml writes the
+	// parents of empty a>b lists despite omitempty.
+	for {
+		stripped := emptyElement.ReplaceAllFunc(out, func(m []byte) []byte {
+			if sub := emptyElement.FindSubmatch(m); string(sub[1]) != string(sub[2]) {
+				return m
+			}
+			return nil
+		})
+		if len(stripped) == len(out) {
+			break
+		}
+		out = stripped
+	}
+	return append([]byte(xmlHeader), append(out, '\n')...)
+}
+
+var emptyElement = regexp.MustCompile(`\n *<([\w:]+)></([\w:]+)>`)
+
+// description returns the short text of an object from its XML file.
+func description(obj *Object) string {
+	data, ok := obj.Files["xml"]
+	if !ok {
+		return ""
+	}
+	var v values
+	if err := decodeValues(data, &v); err != nil {
+		return ""
+	}
+	switch {
+	case v.VSEOCLASS != nil:
+		return v.VSEOCLASS.Descript
+	case v.VSEOINTERF != nil:
+		return v.VSEOINTERF.Descript
+	case v.DD01V != nil:
+		return v.DD01V.Ddtext
+	case v.DD04V != nil:
+		return v.DD04V.Ddtext
+	case v.DD02V != nil:
+		return v.DD02V.Ddtext
+	case v.T100A != nil:
+		return v.T100A.Stext
+	case v.DDLS != nil:
+		return v.DDLS.Ddtext
+	case v.AREAT != "":
+		return v.AREAT
+	case v.Metadata != nil:
+		return v.Metadata.Description
+	}
+	for _, t := range v.TPOOL {
+		if t.ID == "R" {
+			return t.Entry
+		}
+	}
+	return v.DescrText
+}
+
+// atoi parses the zero-padded numbers of DDIC XML ("000030").
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+// --- Function module signatures ---
+
+// functionSource converts the source of a function module as abapGit stores
+// it (FUNCTION x. with the signature as a *" comment block) into the form
+// ADT expects, with the signature spelled out in the FUNCTION statement.
+func functionSource(fm functionModule, source string) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	body := lines
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(strings.ToUpper(trimmed), "FUNCTION ") {
+			body = lines[i+1:]
+			break
+		}
+	}
+	for len(body) > 0 && strings.HasPrefix(body[0], `*"`) {
+		body = body[1:]
+	}
+	return functionSignature(fm) + "\n" + strings.Join(body, "\n")
+}
+
+// functionSignature returns the FUNCTION statement of a function module with
+// its parameters.
+func functionSignature(fm functionModule) string {
+	var sb strings.Builder
+	sb.WriteString("FUNCTION " + strings.ToLower(fm.Name))
+
+	section := func(keyword string, params []fmParameter, format func(fmParameter) string) {
+		if len(params) == 0 {
+			return
+		}
+		sb.WriteString("\n  " + keyword)
+		for _, p := range params {
+			sb.WriteString("\n    " + format(p))
+		}
+	}
+	section("IMPORTING", fm.Import, func(p fmParameter) string { return parameterDecl(p, true) })
+	section("EXPORTING", fm.Export, func(p fmParameter) string { return parameterDecl(p, false) })
+	section("CHANGING", fm.Changing, func(p fmParameter) string { return parameterDecl(p, true) })
+	section("TABLES", fm.Tables, func(p fmParameter) string {
+		decl := p.Parameter
+		switch {
+		case p.DBStruct != "":
+			decl += " STRUCTURE " + p.DBStruct
+		case p.Typ != "":
+			decl += " TYPE " + p.Typ
+		}
+		if p.Optional == "X" {
+			decl += " OPTIONAL"
+		}
+		return decl
+	})
+	if len(fm.Exception) > 0 {
+		keyword := "EXCEPTIONS"
+		if fm.ExceptionClass == "X" {
+			keyword = "RAISING"
+		}
+		sb.WriteString("\n  " + keyword)
+		for _, e := range fm.Exception {
+			sb.WriteString("\n    " + e.Exception)
+		}
+	}
+	sb.WriteString(".")
+	return sb.String()
+}
+
+// parameterDecl returns the declaration of an importing, exporting or
+// changing parameter, e.g. VALUE(IV_ID) TYPE I OPTIONAL.
+func parameterDecl(p fmParameter, canBeOptional bool) string {
+	decl := "VALUE(" + p.Parameter + ")"
+	if p.Reference == "X" {
+		decl = "REFERENCE(" + p.Parameter + ")"
+	}
+	switch {
+	case p.Typ != "" && p.RefClass == "X":
+		decl += " TYPE REF TO " + p.Typ
+	case p.Typ != "":
+		decl += " TYPE " + p.Typ
+	case p.DBField != "":
+		decl += " LIKE " + p.DBField
+	}
+	if canBeOptional {
+		if p.Default != "" {
+			decl += " DEFAULT " + p.Default
+		}
+		if p.Optional == "X" {
+			decl += " OPTIONAL"
+		}
+	}
+	return decl
+}
+
+// parseFunctionSource splits the source of a function module as ADT returns
+// it into its signature and body; the inverse of functionSource.
+func parseFunctionSource(source string) (functionModule, []string) {
+	var fm functionModule
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+
+	// Collect the FUNCTION statement up to its period, without comments
+	var statement strings.Builder
+	start, end := -1, len(lines)
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if start < 0 {
+			if !strings.HasPrefix(strings.ToUpper(trimmed), "FUNCTION ") {
+				continue
+			}
+			start = i
+		}
+		if strings.HasPrefix(line, "*") {
+			continue
+		}
+		code, done := statementPart(line)
+		statement.WriteString(code + " ")
+		if done {
+			end = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return fm, lines
+	}
+	body := lines[end:]
+	for len(body) > 0 && strings.HasPrefix(body[0], `*"`) {
+		body = body[1:]
+	}
+
+	text := strings.NewReplacer("(", "( ", ")", " )").Replace(statement.String())
+	tokens := strings.Fields(text)
+	if len(tokens) > 1 {
+		fm.Name = strings.ToUpper(tokens[1])
+	}
+	var section string
+	var params *[]fmParameter
+	last := func() *fmParameter {
+		if params == nil || len(*params) == 0 {
+			return &fmParameter{}
+		}
+		return &(*params)[len(*params)-1]
+	}
+	next := func(i *int) string {
+		*i++
+		if *i < len(tokens) {
+			return tokens[*i]
+		}
+		return ""
+	}
+	for i := 2; i < len(tokens); i++ {
+		tok := strings.ToUpper(tokens[i])
+		switch tok {
+		case "IMPORTING":
+			section, params = tok, &fm.Import
+		case "EXPORTING":
+			section, params = tok, &fm.Export
+		case "CHANGING":
+			section, params = tok, &fm.Changing
+		case "TABLES":
+			section, params = tok, &fm.Tables
+		case "EXCEPTIONS", "RAISING":
+			section, params = tok, nil
+			if tok == "RAISING" {
+				fm.ExceptionClass = "X"
+			}
+		case "TYPE":
+			p := last()
+			typ := strings.ToUpper(next(&i))
+			if typ == "REF" && i+2 < len(tokens) && strings.EqualFold(tokens[i+1], "TO") {
+				i++
+				typ = strings.ToUpper(next(&i))
+				p.RefClass = "X"
+			}
+			p.Typ = typ
+		case "LIKE":
+			last().DBField = strings.ToUpper(next(&i))
+		case "STRUCTURE":
+			last().DBStruct = strings.ToUpper(next(&i))
+		case "DEFAULT":
+			last().Default = next(&i)
+		case "OPTIONAL":
+			last().Optional = "X"
+		default:
+			switch {
+			case section == "EXCEPTIONS" || section == "RAISING":
+				fm.Exception = append(fm.Exception, fmException{Exception: tok})
+			case params == nil:
+			case (tok == "VALUE(" || tok == "REFERENCE(") && i+2 < len(tokens):
+				p := fmParameter{Parameter: strings.ToUpper(tokens[i+1])}
+				if tok == "REFERENCE(" {
+					p.Reference = "X"
+				}
+				*params = append(*params, p)
+				i += 2
+			default:
+				p := fmParameter{Parameter: tok}
+				if section != "TABLES" {
+					p.Reference = "X"
+				}
+				*params = append(*params, p)
+			}
+		}
+	}
+	return fm, body
+}
+
+// statementPart returns the code of a line up to a closing period outside
+// literals, without a trailing comment, and whether the statement ended.
+func statementPart(line string) (string, bool) {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '`':
+			quote = r
+		case r == '"':
+			return line[:i], false
+		case r == '.':
+			return line[:i], true
+		}
+	}
+	return line, false
+}
+
+// abapGitFunctionSource returns the source of a function module as abapGit
+// stores it, with the signature as the *"Local Interface comment block.
+func abapGitFunctionSource(fm functionModule, body []string) string {
+	const rule = `*"----------------------------------------------------------------------`
+	var sb strings.Builder
+	sb.WriteString("FUNCTION " + strings.ToLower(fm.Name) + ".\n")
+	sb.WriteString(rule + "\n")
+	sb.WriteString(`*"*"Local Interface:` + "\n")
+	signature := strings.Split(functionSignature(fm), "\n")
+	for _, line := range signature[1:] {
+		sb.WriteString(`*"` + strings.TrimSuffix(line, ".") + "\n")
+	}
+	sb.WriteString(rule + "\n")
+	sb.WriteString(strings.Join(body, "\n"))
+	return sb.String()
+}
+
+// --- Table and structure definitions ---
+
+// builtinTypes maps DDIC data types to their ABAP CDS spelling. true means
+// the type takes a length.
+var builtinTypes = map[string]bool{
+	"CHAR": true, "NUMC": true, "RAW": true, "SSTRING": true, "LCHR": true, "LRAW": true,
+	"CUKY": true, "UNIT": true, "LANG": true, "CLNT": true, "DATS": false, "TIMS": false,
+	"INT1": false, "INT2": false, "INT4": false, "INT8": false, "FLTP": false,
+	"STRING": false, "RAWSTRING": false, "ACCP": false, "PREC": false,
+	"D16N": false, "D34N": false, "UTCLONG": false, "DATN": false, "TIMN": false,
+	"DEC": true, "CURR": true, "QUAN": true, "DF16_DEC": true, "DF34_DEC": true,
+}
+
+// decimalTypes take a length and a number of decimals.
+var decimalTypes = map[string]bool{"DEC": true, "CURR": true, "QUAN": true, "DF16_DEC": true, "DF34_DEC": true}
+
+// fieldType returns the ABAP CDS type of a table field.
+func fieldType(f dd03p) string {
+	if f.Rollname != "" {
+		if f.Comptype == "R" {
+			return "reference to " + strings.ToLower(f.Rollname)
+		}
+		return strings.ToLower(f.Rollname)
+	}
+	typ := strings.ToUpper(f.Datatype)
+	hasLength, ok := builtinTypes[typ]
+	if !ok {
+		return "abap." + strings.ToLower(typ)
+	}
+	switch {
+	case decimalTypes[typ]:
+		return fmt.Sprintf("abap.%s(%d,%d)", strings.ToLower(typ), atoi(f.Leng), atoi(f.Decimals))
+	case typ == "LANG":
+		return "abap.lang"
+	case typ == "CLNT":
+		return "abap.clnt"
+	case hasLength:
+		return fmt.Sprintf("abap.%s(%d)", strings.ToLower(typ), atoi(f.Leng))
+	case typ == "STRING" || typ == "RAWSTRING":
+		return fmt.Sprintf("abap.%s(%d)", strings.ToLower(typ), atoi(f.Leng))
+	}
+	return "abap." + strings.ToLower(typ)
+}
+
+var enhancementCategories = map[string]string{
+	"0": "NOT_CLASSIFIED",
+	"1": "NOT_EXTENSIBLE",
+	"2": "EXTENSIBLE_CHARACTER",
+	"3": "EXTENSIBLE_CHARACTER_NUMERIC",
+	"4": "EXTENSIBLE_ANY",
+}
+
+var dataMaintenance = map[string]string{
+	"X": "ALLOWED",
+	"":  "RESTRICTED",
+	"N": "NOT_ALLOWED",
+}
+
+// tableDDL generates the DDL source of a table or structure from its
+// abapGit definition.
+func tableDDL(t dd02v, fields []dd03p) string {
+	var sb strings.Builder
+	name := strings.ToLower(t.Name)
+	structure := t.Tabclass == "INTTAB"
+
+	fmt.Fprintf(&sb, "@EndUserText.label : '%s'\n", strings.ReplaceAll(t.Ddtext, "'", "''"))
+	category, ok := enhancementCategories[t.Exclass]
+	if !ok {
+		category = "NOT_EXTENSIBLE"
+	}
+	fmt.Fprintf(&sb, "@AbapCatalog.enhancement.category : #%s\n", category)
+	if structure {
+		fmt.Fprintf(&sb, "define structure %s {\n", name)
+	} else {
+		delivery := t.Contflag
+		if delivery == "" {
+			delivery = "A"
+		}
+		sb.WriteString("@AbapCatalog.tableCategory : #TRANSPARENT\n")
+		fmt.Fprintf(&sb, "@AbapCatalog.deliveryClass : #%s\n", delivery)
+		fmt.Fprintf(&sb, "@AbapCatalog.dataMaintenance : #%s\n", dataMaintenance[t.Mateflag])
+		fmt.Fprintf(&sb, "define table %s {\n", name)
+	}
+
+	for _, f := range fields {
+		switch {
+		case f.Fieldname == ".INCLUDE" || strings.HasPrefix(f.Fieldname, ".INCLU"):
+			if f.Precfield != "" {
+				key := ""
+				if f.Keyflag == "X" {
+					key = "key "
+				}
+				fmt.Fprintf(&sb, "  %sinclude %s", key, strings.ToLower(f.Precfield))
+				if f.Notnull == "X" {
+					sb.WriteString(" not null")
+				}
+				sb.WriteString(";\n")
+			}
+			continue
+		case strings.HasPrefix(f.Fieldname, "."):
+			// .APPEND and other technical entries are not part of the definition
+			continue
+		}
+
+		if f.Reftable != "" && f.Reffield != "" {
+			ref := strings.ToLower(f.Reftable) + "." + strings.ToLower(f.Reffield)
+			switch strings.ToUpper(f.Datatype) {
+			case "CURR":
+				fmt.Fprintf(&sb, "  @Semantics.amount.currencyCode : '%s'\n", ref)
+			case "QUAN":
+				fmt.Fprintf(&sb, "  @Semantics.quantity.unitOfMeasure : '%s'\n", ref)
+			}
+		}
+		sb.WriteString("  ")
+		if f.Keyflag == "X" {
+			sb.WriteString("key ")
+		}
+		fmt.Fprintf(&sb, "%s : %s", strings.ToLower(f.Fieldname), fieldType(f))
+		if f.Notnull == "X" {
+			sb.WriteString(" not null")
+		}
+		sb.WriteString(";\n")
+	}
+	sb.WriteString("\n}\n")
+	return sb.String()
+}
+
+// parseTableDDL reads the abapGit definition of a table or structure from
+// its DDL source; the inverse of tableDDL.
+func parseTableDDL(source string) (dd02v, []dd03p) {
+	t := dd02v{Tabclass: "TRANSP", Exclass: "1"}
+	var fields []dd03p
+	annotations := make(map[string]string)
+	inBody := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case line == "" || line == "}":
+			continue
+		case strings.HasPrefix(line, "@"):
+			key, value, _ := strings.Cut(line[1:], ":")
+			annotations[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+			continue
+		}
+
+		if !inBody {
+			words := strings.Fields(strings.TrimSuffix(line, "{"))
+			if len(words) >= 3 && strings.EqualFold(words[0], "define") {
+				t.Name = strings.ToUpper(words[2])
+				if strings.EqualFold(words[1], "structure") {
+					t.Tabclass = "INTTAB"
+				}
+				t.Ddtext = quoted(annotations["endusertext.label"])
+				for code, category := range enhancementCategories {
+					if annotations["abapcatalog.enhancement.category"] == "#"+category {
+						t.Exclass = code
+					}
+				}
+				if t.Tabclass == "TRANSP" {
+					t.Contflag = strings.TrimPrefix(annotations["abapcatalog.deliveryclass"], "#")
+					for flag, maintenance := range dataMaintenance {
+						if annotations["abapcatalog.datamaintenance"] == "#"+maintenance {
+							t.Mateflag = flag
+						}
+					}
+				}
+				annotations = make(map[string]string)
+				inBody = true
+			}
+			continue
+		}
+
+		f := dd03p{}
+		words := strings.Fields(strings.TrimSuffix(line, ";"))
+		if len(words) > 0 && strings.EqualFold(words[0], "key") {
+			f.Keyflag = "X"
+			words = words[1:]
+		}
+		if n := len(words); n >= 2 && strings.EqualFold(words[n-2], "not") && strings.EqualFold(words[n-1], "null") {
+			f.Notnull = "X"
+			words = words[:n-2]
+		}
+		switch {
+		case len(words) == 2 && strings.EqualFold(words[0], "include"):
+			f.Fieldname, f.Precfield = ".INCLUDE", strings.ToUpper(words[1])
+		case strings.Contains(strings.Join(words, " "), ":"):
+			name, typ, _ := strings.Cut(strings.Join(words, " "), ":")
+			f.Fieldname = strings.ToUpper(strings.TrimSpace(name))
+			setFieldType(&f, strings.TrimSpace(typ))
+		default:
+			continue
+		}
+
+		for key, kind := range map[string]string{"semantics.amount.currencycode": "CURR", "semantics.quantity.unitofmeasure": "QUAN"} {
+			ref, ok := annotations[key]
+			if !ok {
+				continue
+			}
+			f.Reftable, f.Reffield, _ = strings.Cut(strings.ToUpper(quoted(ref)), ".")
+			if f.Datatype == "" {
+				f.Datatype = kind
+			}
+		}
+		annotations = make(map[string]string)
+		fields = append(fields, f)
+	}
+	return t, fields
+}
+
+// setFieldType sets the type of a table field from its ABAP CDS spelling;
+// the inverse of fieldType.
+func setFieldType(f *dd03p, typ string) {
+	lower := strings.ToLower(typ)
+	switch {
+	case strings.HasPrefix(lower, "reference to "):
+		f.Rollname, f.Comptype = strings.ToUpper(strings.TrimSpace(typ[len("reference to "):])), "R"
+	case strings.HasPrefix(lower, "abap."):
+		name, args, _ := strings.Cut(strings.TrimSuffix(lower[len("abap."):], ")"), "(")
+		f.Datatype = strings.ToUpper(name)
+		leng, decimals, _ := strings.Cut(args, ",")
+		if leng != "" {
+			f.Leng = fmt.Sprintf("%06d", atoi(leng))
+		}
+		if decimals != "" {
+			f.Decimals = fmt.Sprintf("%06d", atoi(decimals))
+		}
+	default:
+		f.Rollname, f.Comptype = strings.ToUpper(typ), "E"
+	}
+}
+
+// quoted returns the content of a quoted annotation value.
+func quoted(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		s = strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
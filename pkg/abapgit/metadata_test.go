@@ -0,0 +1,171 @@
+package abapgit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const fugrXML = `<AREAT>Demo functions</AREAT>
+<INCLUDES>
+ <SOBJ_NAME>LZDEMOTOP</SOBJ_NAME>
+ <SOBJ_NAME>SAPLZDEMO</SOBJ_NAME>
+</INCLUDES>
+<FUNCTIONS>
+ <item>
+  <FUNCNAME>Z_DEMO_GET</FUNCNAME>
+  <SHORT_TEXT>Get demo</SHORT_TEXT>
+  <IMPORT>
+   <RSIMP><PARAMETER>IV_ID</PARAMETER><TYP>I</TYP></RSIMP>
+   <RSIMP><PARAMETER>IV_MODE</PARAMETER><DEFAULT>&apos;A&apos;</DEFAULT><OPTIONAL>X</OPTIONAL><REFERENCE>X</REFERENCE><TYP>CHAR1</TYP></RSIMP>
+  </IMPORT>
+  <EXPORT>
+   <RSEXP><PARAMETER>EO_DEMO</PARAMETER><TYP>ZCL_DEMO</TYP><REF_CLASS>X</REF_CLASS></RSEXP>
+  </EXPORT>
+  <TABLES>
+   <RSTBL><PARAMETER>ET_FLIGHTS</PARAMETER><DBSTRUCT>SFLIGHT</DBSTRUCT><OPTIONAL>X</OPTIONAL></RSTBL>
+  </TABLES>
+  <EXCEPTION>
+   <RSEXC><EXCEPTION>NOT_FOUND</EXCEPTION></RSEXC>
+  </EXCEPTION>
+ </item>
+</FUNCTIONS>`
+
+func TestFunctionSource(t *testing.T) {
+	var v values
+	if err := decodeValues([]byte(objectXML("LCL_OBJECT_FUGR", fugrXML)), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.AREAT != "Demo functions" || len(v.INCLUDES) != 2 || len(v.FUNCTIONS) != 1 {
+		t.Fatalf("unexpected values: %+v", v)
+	}
+
+	source := `FUNCTION z_demo_get.
+*"----------------------------------------------------------------------
+*"*"Local Interface:
+*"  IMPORTING
+*"     VALUE(IV_ID) TYPE  I
+*"----------------------------------------------------------------------
+  eo_demo = NEW #( iv_id ).
+ENDFUNCTION.
+`
+	want := `FUNCTION z_demo_get
+  IMPORTING
+    VALUE(IV_ID) TYPE I
+    REFERENCE(IV_MODE) TYPE CHAR1 DEFAULT 'A' OPTIONAL
+  EXPORTING
+    VALUE(EO_DEMO) TYPE REF TO ZCL_DEMO
+  TABLES
+    ET_FLIGHTS STRUCTURE SFLIGHT OPTIONAL
+  EXCEPTIONS
+    NOT_FOUND.
+  eo_demo = NEW #( iv_id ).
+ENDFUNCTION.
+`
+	if got := functionSource(v.FUNCTIONS[0], source); got != want {
+		t.Errorf("functionSource =\n%s\nwant\n%s", got, want)
+	}
+
+	v.FUNCTIONS[0].ExceptionClass = "X"
+	if got := functionSignature(v.FUNCTIONS[0]); !strings.HasSuffix(got, "RAISING\n    NOT_FOUND.") {
+		t.Errorf("class-based exceptions: %s", got)
+	}
+}
+
+func TestTableDDL(t *testing.T) {
+	table := dd02v{Name: "ZDEMO_ORDER", Tabclass: "TRANSP", Ddtext: "Demo's orders", Contflag: "A", Exclass: "1", Mateflag: "X"}
+	fields := []dd03p{
+		{Fieldname: "CLIENT", Keyflag: "X", Rollname: "MANDT", Comptype: "E", Notnull: "X"},
+		{Fieldname: "ORDER_ID", Keyflag: "X", Datatype: "NUMC", Leng: "000010", Notnull: "X"},
+		{Fieldname: ".INCLUDE", Precfield: "ZDEMO_ADMIN"},
+		{Fieldname: "AMOUNT", Datatype: "CURR", Leng: "000015", Decimals: "000002", Reftable: "ZDEMO_ORDER", Reffield: "CURRENCY"},
+		{Fieldname: "CURRENCY", Rollname: "WAERS", Comptype: "E"},
+		{Fieldname: "NOTE", Datatype: "STRING"},
+	}
+	want := `@EndUserText.label : 'Demo''s orders'
+@AbapCatalog.enhancement.category : #NOT_EXTENSIBLE
+@AbapCatalog.tableCategory : #TRANSPARENT
+@AbapCatalog.deliveryClass : #A
+@AbapCatalog.dataMaintenance : #ALLOWED
+define table zdemo_order {
+  key client : mandt not null;
+  key order_id : abap.numc(10) not null;
+  include zdemo_admin;
+  @Semantics.amount.currencyCode : 'zdemo_order.currency'
+  amount : abap.curr(15,2);
+  currency : waers;
+  note : abap.string(0);
+
+}
+`
+	if got := tableDDL(table, fields); got != want {
+		t.Errorf("tableDDL =\n%s\nwant\n%s", got, want)
+	}
+
+	structure := dd02v{Name: "ZDEMO_ADMIN", Tabclass: "INTTAB", Ddtext: "Admin fields", Exclass: "4"}
+	got := tableDDL(structure, []dd03p{{Fieldname: "CREATED_BY", Rollname: "SYUNAME", Comptype: "E"}})
+	if !strings.Contains(got, "#EXTENSIBLE_ANY\ndefine structure zdemo_admin {\n  created_by : syuname;\n") || strings.Contains(got, "tableCategory") {
+		t.Errorf("structure DDL:\n%s", got)
+	}
+}
+
+func TestParseFunctionSource(t *testing.T) {
+	var v values
+	if err := decodeValues([]byte(objectXML("LCL_OBJECT_FUGR", fugrXML)), &v); err != nil {
+		t.Fatal(err)
+	}
+	fm := v.FUNCTIONS[0]
+	fm.ShortText = ""
+	adtSource := functionSource(fm, "FUNCTION z_demo_get.\n  eo_demo = NEW #( iv_id ). \" create\nENDFUNCTION.\n")
+
+	parsed, body := parseFunctionSource(adtSource)
+	if !reflect.DeepEqual(parsed, fm) {
+		t.Errorf("parseFunctionSource =\n%+v\nwant\n%+v", parsed, fm)
+	}
+	if got := strings.Join(body, "\n"); got != "  eo_demo = NEW #( iv_id ). \" create\nENDFUNCTION.\n" {
+		t.Errorf("body = %q", got)
+	}
+
+	// The abapGit form carries the signature as comments and converts back
+	source := abapGitFunctionSource(parsed, body)
+	if !strings.Contains(source, "*\"*\"Local Interface:\n*\"  IMPORTING\n*\"    VALUE(IV_ID) TYPE I\n") {
+		t.Errorf("abapGit source:\n%s", source)
+	}
+	if got := functionSource(parsed, source); got != adtSource {
+		t.Errorf("round trip =\n%s\nwant\n%s", got, adtSource)
+	}
+}
+
+func TestParseTableDDL(t *testing.T) {
+	for _, source := range []string{
+		`@EndUserText.label : 'Demo''s orders'
+@AbapCatalog.enhancement.category : #NOT_EXTENSIBLE
+@AbapCatalog.tableCategory : #TRANSPARENT
+@AbapCatalog.deliveryClass : #A
+@AbapCatalog.dataMaintenance : #RESTRICTED
+define table zdemo_order {
+  key client : mandt not null;
+  key order_id : abap.numc(10) not null;
+  include zdemo_admin;
+  @Semantics.amount.currencyCode : 'zdemo_order.currency'
+  amount : abap.curr(15,2);
+  currency : waers;
+  owner : reference to zcl_demo;
+
+}
+`,
+		`@EndUserText.label : 'Admin fields'
+@AbapCatalog.enhancement.category : #EXTENSIBLE_ANY
+define structure zdemo_admin {
+  created_by : syuname;
+  created_at : abap.utclong;
+
+}
+`,
+	} {
+		table, fields := parseTableDDL(source)
+		if got := tableDDL(table, fields); got != source {
+			t.Errorf("round trip =\n%s\nwant\n%s", got, source)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+// Package abapgit reads and writes abapGit repositories (folders and ZIP
+// files), imports them into a SAP system and exports packages from it,
+// through ADT alone, without abapGit on the server.
+//
+// An abapGit repository holds one file per object part, named
+// <name>.<type>[.<part>].<extension>, e.g. zcl_foo.clas.abap,
+// zcl_foo.clas.locals_imp.abap and zcl_foo.clas.xml. Namespaces are encoded
+// with '#' (#dmo#cl_foo.clas.abap). Folders below the starting folder map to
+// subpackages according to the folder logic in .abapgit.xml.
+package abapgit
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Folder logics of .abapgit.xml.
+const (
+	// FolderLogicPrefix names subpackages <parent>_<FOLDER>.
+	FolderLogicPrefix = "PREFIX"
+	// FolderLogicFull names subpackages after their folder.
+	FolderLogicFull = "FULL"
+)
+
+// Repository is an abapGit repository read from a folder or ZIP file.
+type Repository struct {
+	StartingFolder string // e.g. /src/
+	FolderLogic    string // PREFIX or FULL
+	MasterLanguage string // e.g. E
+
+	Objects []*Object
+
+	// Package descriptions from package.devc.xml, by folder
+	packageDescriptions map[string]string
+}
+
+// Object is a repository object with its files.
+type Object struct {
+	Type   string // abapGit (TADIR) type, e.g. CLAS, PROG, FUGR
+	Name   string // upper case, with namespace, e.g. /DMO/CL_FOO
+	Folder string // folder below the starting folder, "" for the root package
+
+	// Files by the part of the file name after <name>.<type>., e.g. "abap",
+	// "xml", "locals_imp.abap" or, for function groups, "lzfootop.abap".
+	Files map[string][]byte
+}
+
+// Source returns the content of a file part as a string.
+func (o *Object) Source(part string) (string, bool) {
+	data, ok := o.Files[part]
+	return string(data), ok
+}
+
+// Folder is a package folder of the repository.
+type Folder struct {
+	Path        string // below the starting folder, "" for the root package
+	Package     string // package name under the repository's folder logic
+	Description string // from package.devc.xml
+}
+
+// ReadDir reads an abapGit repository from a folder. The folder is the
+// repository root (containing .abapgit.xml) or the starting folder itself.
+func ReadDir(dir string) (*Repository, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && (d.Name() == ".git" || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+	return newRepository(files)
+}
+
+// ReadZip reads an abapGit repository from a ZIP file, e.g. a GitHub
+// archive with the repository below a top-level folder.
+func ReadZip(data []byte) (*Repository, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("reading ZIP: %w", err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		files[path.Clean(f.Name)] = content
+	}
+	return newRepository(files)
+}
+
+// ReadPath reads a repository from a folder or, if path is a file, a ZIP file.
+func ReadPath(p string) (*Repository, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ReadDir(p)
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return ReadZip(data)
+}
+
+// newRepository builds a repository from files by slash-separated path.
+func newRepository(files map[string][]byte) (*Repository, error) {
+	repo := &Repository{
+		StartingFolder:      "/src/",
+		FolderLogic:         FolderLogicPrefix,
+		MasterLanguage:      "E",
+		packageDescriptions: make(map[string]string),
+	}
+
+	// The shallowest .abapgit.xml marks the repository root
+	depth := func(dir string) int {
+		if dir == "." {
+			return 0
+		}
+		return strings.Count(dir, "/") + 1
+	}
+	root, found := "", false
+	for p := range files {
+		if path.Base(p) != ".abapgit.xml" {
+			continue
+		}
+		if dir := path.Dir(p); !found || depth(dir) < depth(root) {
+			root, found = dir, true
+		}
+	}
+
+	src := ""
+	if found {
+		var v values
+		if err := decodeValues(files[path.Join(root, ".abapgit.xml")], &v); err != nil {
+			return nil, fmt.Errorf(".abapgit.xml: %w", err)
+		}
+		if v.Data != nil {
+			if v.Data.StartingFolder != "" {
+				repo.StartingFolder = v.Data.StartingFolder
+			}
+			if v.Data.FolderLogic != "" {
+				repo.FolderLogic = strings.ToUpper(v.Data.FolderLogic)
+			}
+			if v.Data.MasterLanguage != "" {
+				repo.MasterLanguage = v.Data.MasterLanguage
+			}
+		}
+		src = path.Join(root, strings.Trim(repo.StartingFolder, "/"))
+	}
+	if repo.FolderLogic != FolderLogicPrefix && repo.FolderLogic != FolderLogicFull {
+		return nil, fmt.Errorf("folder logic %s is not supported (use PREFIX or FULL)", repo.FolderLogic)
+	}
+
+	byKey := make(map[string]*Object)
+	for p, content := range files {
+		if src != "" && src != "." {
+			if !strings.HasPrefix(p, src+"/") {
+				continue
+			}
+			p = strings.TrimPrefix(p, src+"/")
+		}
+		folder, base := path.Dir(p), path.Base(p)
+		if folder == "." {
+			folder = ""
+		}
+
+		name, typ, part, ok := ParseFileName(base)
+		if !ok {
+			continue
+		}
+		if typ == "DEVC" {
+			var v values
+			if err := decodeValues(content, &v); err == nil && v.DEVC != nil {
+				repo.packageDescriptions[folder] = v.DEVC.Ctext
+			} else {
+				repo.packageDescriptions[folder] = ""
+			}
+			continue
+		}
+
+		key := typ + " " + name
+		obj := byKey[key]
+		if obj == nil {
+			obj = &Object{Type: typ, Name: name, Folder: folder, Files: make(map[string][]byte)}
+			byKey[key] = obj
+			repo.Objects = append(repo.Objects, obj)
+		}
+		obj.Files[part] = content
+	}
+	if len(repo.Objects) == 0 {
+		return nil, fmt.Errorf("no abapGit objects found below %s", repo.StartingFolder)
+	}
+
+	sort.Slice(repo.Objects, func(i, j int) bool {
+		a, b := repo.Objects[i], repo.Objects[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Name < b.Name
+	})
+	return repo, nil
+}
+
+// ParseFileName splits an abapGit file name into object name, type and
+// part, e.g. "#dmo#cl_foo.clas.locals_imp.abap" into "/DMO/CL_FOO", "CLAS"
+// and "locals_imp.abap". Package files (package.devc.xml) have type DEVC.
+func ParseFileName(base string) (name, typ, part string, ok bool) {
+	parts := strings.SplitN(strings.ToLower(base), ".", 3)
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", false
+	}
+	name = strings.ToUpper(strings.ReplaceAll(parts[0], "#", "/"))
+	return name, strings.ToUpper(parts[1]), parts[2], true
+}
+
+// FileName returns the abapGit file name of an object part, the inverse of
+// ParseFileName.
+func FileName(typ, name, part string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "/", "#") + "." + strings.ToLower(typ) + "." + part
+}
+
+// Folders returns the package folders of the repository, parents first,
+// with their package names below root.
+func (r *Repository) Folders(root string) []Folder {
+	paths := map[string]bool{"": true}
+	for _, obj := range r.Objects {
+		for f := obj.Folder; f != "" && f != "."; f = path.Dir(f) {
+			paths[f] = true
+		}
+	}
+	for f := range r.packageDescriptions {
+		for ; f != "" && f != "."; f = path.Dir(f) {
+			paths[f] = true
+		}
+	}
+
+	var folders []Folder
+	for p := range paths {
+		folders = append(folders, Folder{Path: p, Package: r.PackageOf(p, root), Description: r.packageDescriptions[p]})
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		di, dj := strings.Count(folders[i].Path, "/"), strings.Count(folders[j].Path, "/")
+		if folders[i].Path == "" || folders[j].Path == "" {
+			return folders[i].Path == ""
+		}
+		if di != dj {
+			return di < dj
+		}
+		return folders[i].Path < folders[j].Path
+	})
+	return folders
+}
+
+// PackageOf returns the package of a folder below the starting folder when
+// the repository is installed into root.
+func (r *Repository) PackageOf(folder, root string) string {
+	pkg := strings.ToUpper(root)
+	if folder == "" || folder == "." {
+		return pkg
+	}
+	for _, segment := range strings.Split(folder, "/") {
+		segment = strings.ToUpper(strings.ReplaceAll(segment, "#", "/"))
+		if r.FolderLogic == FolderLogicFull {
+			pkg = segment
+		} else {
+			pkg = pkg + "_" + segment
+		}
+	}
+	return pkg
+}
+
+// ParentPackage returns the package a folder's package belongs to, or ""
+// for the root package.
+func (r *Repository) ParentPackage(folder, root string) string {
+	if folder == "" || folder == "." {
+		return ""
+	}
+	parent := path.Dir(folder)
+	if parent == "." {
+		parent = ""
+	}
+	return r.PackageOf(parent, root)
+}
@@ -0,0 +1,150 @@
+package abapgit
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const abapgitXML = `<?xml version="1.0" encoding="utf-8"?>
+<asx:abap xmlns:asx="http://www.sap.com/abapxml" version="1.0">
+ <asx:values>
+  <DATA>
+   <MASTER_LANGUAGE>E</MASTER_LANGUAGE>
+   <STARTING_FOLDER>/src/</STARTING_FOLDER>
+   <FOLDER_LOGIC>PREFIX</FOLDER_LOGIC>
+  </DATA>
+ </asx:values>
+</asx:abap>`
+
+// objectXML wraps asx:values content in an abapGit object file.
+func objectXML(serializer, content string) string {
+	return `<?xml version="1.0" encoding="utf-8"?>
+<abapGit version="v1.0.0" serializer="` + serializer + `" serializer_version="v1.0.0">
+ <asx:abap xmlns:asx="http://www.sap.com/abapxml" version="1.0">
+  <asx:values>
+` + content + `
+  </asx:values>
+ </asx:abap>
+</abapGit>`
+}
+
+// writeFiles writes files by slash path below dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseFileName(t *testing.T) {
+	tests := []struct {
+		file, name, typ, part string
+		ok                    bool
+	}{
+		{"zcl_foo.clas.abap", "ZCL_FOO", "CLAS", "abap", true},
+		{"zcl_foo.clas.locals_imp.abap", "ZCL_FOO", "CLAS", "locals_imp.abap", true},
+		{"#dmo#cl_foo.clas.xml", "/DMO/CL_FOO", "CLAS", "xml", true},
+		{"zfg.fugr.lzfgtop.abap", "ZFG", "FUGR", "lzfgtop.abap", true},
+		{"package.devc.xml", "PACKAGE", "DEVC", "xml", true},
+		{"README.md", "", "", "", false},
+		{".abapgit.xml", "", "", "", false},
+	}
+	for _, tt := range tests {
+		name, typ, part, ok := ParseFileName(tt.file)
+		if name != tt.name || typ != tt.typ || part != tt.part || ok != tt.ok {
+			t.Errorf("ParseFileName(%q) = %q, %q, %q, %v", tt.file, name, typ, part, ok)
+		}
+		if ok && typ != "DEVC" && FileName(typ, name, part) != tt.file {
+			t.Errorf("FileName(%q, %q, %q) = %q", typ, name, part, FileName(typ, name, part))
+		}
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".abapgit.xml":                         abapgitXML,
+		"README.md":                            "# demo",
+		"src/package.devc.xml":                 objectXML("LCL_OBJECT_DEVC", "<DEVC><CTEXT>Demo</CTEXT></DEVC>"),
+		"src/zcl_foo.clas.abap":                "CLASS zcl_foo DEFINITION PUBLIC.\nENDCLASS.",
+		"src/zcl_foo.clas.locals_imp.abap":     "* local types",
+		"src/zcl_foo.clas.xml":                 objectXML("LCL_OBJECT_CLAS", "<VSEOCLASS><CLSNAME>ZCL_FOO</CLSNAME><DESCRIPT>Foo</DESCRIPT></VSEOCLASS>"),
+		"src/util/package.devc.xml":            objectXML("LCL_OBJECT_DEVC", "<DEVC><CTEXT>Utilities</CTEXT></DEVC>"),
+		"src/util/zif_util.intf.abap":          "INTERFACE zif_util PUBLIC.\nENDINTERFACE.",
+		"src/util/deep/zutil_report.prog.abap": "REPORT zutil_report.",
+		"other/zignored.prog.abap":             "REPORT zignored.",
+	})
+
+	repo, err := ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(repo.Objects) != 3 {
+		t.Fatalf("got %d objects, want 3: %+v", len(repo.Objects), repo.Objects)
+	}
+	cls := repo.Objects[0]
+	if cls.Type != "CLAS" || cls.Name != "ZCL_FOO" || cls.Folder != "" || len(cls.Files) != 3 {
+		t.Errorf("unexpected class: %+v", cls)
+	}
+	if description(cls) != "Foo" {
+		t.Errorf("description = %q", description(cls))
+	}
+
+	folders := repo.Folders("$ZDEMO")
+	want := []Folder{
+		{Path: "", Package: "$ZDEMO", Description: "Demo"},
+		{Path: "util", Package: "$ZDEMO_UTIL", Description: "Utilities"},
+		{Path: "util/deep", Package: "$ZDEMO_UTIL_DEEP"},
+	}
+	if len(folders) != len(want) {
+		t.Fatalf("Folders = %+v", folders)
+	}
+	for i := range want {
+		if folders[i] != want[i] {
+			t.Errorf("folder %d = %+v, want %+v", i, folders[i], want[i])
+		}
+	}
+	if parent := repo.ParentPackage("util/deep", "$ZDEMO"); parent != "$ZDEMO_UTIL" {
+		t.Errorf("ParentPackage = %q", parent)
+	}
+
+	repo.FolderLogic = FolderLogicFull
+	if pkg := repo.PackageOf("util/zdemo_deep", "$ZDEMO"); pkg != "ZDEMO_DEEP" {
+		t.Errorf("PackageOf with FULL logic = %q", pkg)
+	}
+}
+
+func TestReadZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"demo-main/.abapgit.xml":            abapgitXML,
+		"demo-main/src/zdemo.prog.abap":     "REPORT zdemo.",
+		"demo-main/src/#dmo#if_x.intf.abap": "INTERFACE /dmo/if_x PUBLIC.\nENDINTERFACE.",
+		"demo-main/test/zother.prog.abap":   "REPORT zother.",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	repo, err := ReadZip(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ReadZip: %v", err)
+	}
+	if len(repo.Objects) != 2 || repo.Objects[0].Name != "/DMO/IF_X" || repo.Objects[1].Name != "ZDEMO" {
+		t.Errorf("unexpected objects: %+v", repo.Objects)
+	}
+}
@@ -0,0 +1,76 @@
+package abapgit
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Files returns the files of the repository by slash-separated path: the
+// .abapgit.xml, a package.devc.xml per package folder and the object files
+// below the starting folder.
+func (r *Repository) Files() map[string][]byte {
+	files := map[string][]byte{
+		".abapgit.xml": encodeRepoData(repoData{
+			MasterLanguage: r.MasterLanguage,
+			StartingFolder: r.StartingFolder,
+			FolderLogic:    r.FolderLogic,
+		}),
+	}
+	src := strings.Trim(r.StartingFolder, "/")
+	join := func(folder, base string) string {
+		return path.Join(src, folder, base)
+	}
+
+	for _, f := range r.Folders("") {
+		files[join(f.Path, "package.devc.xml")] = encodeValues("DEVC", values{DEVC: &devc{Ctext: f.Description}})
+	}
+	for _, obj := range r.Objects {
+		for part, content := range obj.Files {
+			files[join(obj.Folder, FileName(obj.Type, obj.Name, part))] = content
+		}
+	}
+	return files
+}
+
+// WriteDir writes the repository into a folder, creating it if needed.
+// Existing files are overwritten; other files are left in place.
+func (r *Repository) WriteDir(dir string) error {
+	for name, content := range r.Files() {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteZip writes the repository as a ZIP file.
+func (r *Repository) WriteZip(w io.Writer) error {
+	files := r.Files()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+		if _, err := fw.Write(files[name]); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,245 @@
+package adttest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Message is a syntax check or activation message.
+type Message struct {
+	Line     int
+	Column   int
+	Severity string // E, W or I
+	Text     string
+}
+
+// Finding is an ATC finding.
+type Finding struct {
+	CheckID      string
+	CheckTitle   string
+	MessageID    string
+	MessageTitle string
+	Priority     int // 1 error, 2 warning, 3 info
+	Line         int
+	Column       int
+}
+
+// AddATCFinding adds a finding that ATC runs report for the object.
+func (s *Server) AddATCFinding(uri string, f Finding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.atc[objectKey(uri)] = append(s.atc[objectKey(uri)], f)
+}
+
+// FailUnitTest makes a test method fail with message. Test classes and
+// methods are found in the testclasses include (or the main source of
+// programs); all other test methods pass.
+func (s *Server) FailUnitTest(testClass, method, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unitFail[strings.ToUpper(testClass+"=>"+method)] = message
+}
+
+var (
+	checkObjectPattern  = regexp.MustCompile(`<chkrun:checkObject\s[^>]*adtcore:uri="([^"]*)"`)
+	checkContentPattern = regexp.MustCompile(`(?s)<chkrun:content>(.*?)</chkrun:content>`)
+)
+
+// serveSyntaxCheck runs Check on the source sent with the check run.
+func (s *Server) serveSyntaxCheck(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	doc := string(body)
+
+	sourceURI := ""
+	if m := checkObjectPattern.FindStringSubmatch(doc); m != nil {
+		sourceURI = m[1]
+	}
+	source := ""
+	if m := checkContentPattern.FindStringSubmatch(doc); m != nil {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(m[1]))
+		if err != nil {
+			writeException(w, http.StatusBadRequest, "ExceptionInvalidData", "Invalid check content: %v", err)
+			return
+		}
+		source = string(decoded)
+	}
+
+	var msgs []Message
+	if s.Check != nil {
+		objectURI := strings.TrimSuffix(sourceURI, "/source/main")
+		if i := strings.Index(objectURI, "/includes/"); i >= 0 {
+			objectURI = objectURI[:i]
+		}
+		msgs = s.Check(objectURI, source)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="utf-8"?><chkrun:checkRunReports xmlns:chkrun="http://www.sap.com/adt/checkrun">`)
+	sb.WriteString(`<chkrun:checkReport chkrun:reporter="abapCheckRun" chkrun:triggeringUri="` + sourceURI + `" chkrun:status="processed"><chkrun:checkMessageList>`)
+	for _, m := range msgs {
+		fmt.Fprintf(&sb, `<chkrun:checkMessage chkrun:uri="%s#start=%d,%d" chkrun:type="%s" chkrun:shortText="%s"/>`,
+			sourceURI, m.Line, m.Column, m.Severity, xmlEscape(m.Text))
+	}
+	sb.WriteString(`</chkrun:checkMessageList></chkrun:checkReport></chkrun:checkRunReports>`)
+	writeXML(w, http.StatusOK, sb.String())
+}
+
+// testClass is a local test class found in ABAP source.
+type testClass struct {
+	name    string
+	methods []string
+}
+
+var (
+	testClassPattern  = regexp.MustCompile(`(?is)CLASS\s+(\S+)\s+DEFINITION([^.]*)\.(.*?)ENDCLASS\s*\.`)
+	forTestingPattern = regexp.MustCompile(`(?i)\bFOR\s+TESTING\b`)
+)
+
+// findTestClasses returns the classes defined FOR TESTING and their test methods.
+func findTestClasses(source string) []testClass {
+	var classes []testClass
+	for _, m := range testClassPattern.FindAllStringSubmatch(source, -1) {
+		if !forTestingPattern.MatchString(m[2]) {
+			continue
+		}
+		tc := testClass{name: strings.ToUpper(m[1])}
+		for _, stmt := range strings.Split(m[3], ".") {
+			stmt = strings.TrimSpace(stmt)
+			upper := strings.ToUpper(stmt)
+			if !strings.HasPrefix(upper, "METHODS") {
+				continue
+			}
+			stmt = strings.TrimLeft(stmt[len("METHODS"):], ": \t\r\n")
+			for _, decl := range strings.Split(stmt, ",") {
+				fields := strings.Fields(decl)
+				if len(fields) > 0 && forTestingPattern.MatchString(decl) {
+					tc.methods = append(tc.methods, strings.ToUpper(fields[0]))
+				}
+			}
+		}
+		classes = append(classes, tc)
+	}
+	return classes
+}
+
+// serveUnitTests runs the test methods of the referenced object.
+func (s *Server) serveUnitTests(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="utf-8"?><aunit:runResult xmlns:aunit="http://www.sap.com/adt/aunit" xmlns:adtcore="http://www.sap.com/adt/core">`)
+	for _, m := range objectReferencePattern.FindAllStringSubmatch(string(body), -1) {
+		o, _ := s.findObject(m[1])
+		if o == nil {
+			continue
+		}
+		source := o.source("testclasses", true)
+		if !o.hasInclude("testclasses") {
+			source = o.source("main", true)
+		}
+		classes := findTestClasses(source)
+		if len(classes) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&sb, `<program adtcore:uri="%s" adtcore:type="%s" adtcore:name="%s"><testClasses>`, o.uri, o.typ, xmlEscape(o.name))
+		for _, tc := range classes {
+			classURI := fmt.Sprintf("%s/includes/testclasses#type=CLAS/OL;name=%s", o.uri, tc.name)
+			fmt.Fprintf(&sb, `<testClass adtcore:uri="%s" adtcore:type="CLAS/OL" adtcore:name="%s" uriType="semantic" durationCategory="short" riskLevel="harmless"><testMethods>`,
+				xmlEscape(classURI), tc.name)
+			for _, method := range tc.methods {
+				fmt.Fprintf(&sb, `<testMethod adtcore:uri="%s" adtcore:type="CLAS/OLD" adtcore:name="%s" executionTime="0.001" uriType="semantic" unit="s"><alerts>`,
+					xmlEscape(classURI), method)
+				if msg, ok := s.unitFail[tc.name+"=>"+method]; ok {
+					fmt.Fprintf(&sb, `<alert kind="failedAssertion" severity="critical"><title>%s</title><details><detail text="%s"/></details><stack><stackEntry adtcore:uri="%s" adtcore:type="CLAS/OLD" adtcore:name="%s" adtcore:description="%s->%s"/></stack></alert>`,
+						xmlEscape(msg), xmlEscape(msg), xmlEscape(classURI), method, tc.name, method)
+				}
+				sb.WriteString(`</alerts></testMethod>`)
+			}
+			sb.WriteString(`</testMethods></testClass>`)
+		}
+		sb.WriteString(`</testClasses></program>`)
+	}
+	s.mu.Unlock()
+	sb.WriteString(`</aunit:runResult>`)
+	writeXML(w, http.StatusOK, sb.String())
+}
+
+// serveATC implements customizing, worklist creation, runs and worklist retrieval.
+func (s *Server) serveATC(w http.ResponseWriter, r *http.Request) {
+	path := strings.ToLower(r.URL.Path)
+	switch {
+	case path == "/sap/bc/adt/atc/customizing" && r.Method == http.MethodGet:
+		writeXML(w, http.StatusOK, `<?xml version="1.0" encoding="utf-8"?><atccust:customizing xmlns:atccust="http://www.sap.com/adt/atc/customizing">`+
+			`<properties><property name="systemCheckVariant" value="DEFAULT"/></properties>`+
+			`<exemption><reasons><reason id="FPOS" title="False Positive" justificationMandatory="true"/></reasons></exemption></atccust:customizing>`)
+
+	case path == "/sap/bc/adt/atc/worklists" && r.Method == http.MethodPost:
+		s.mu.Lock()
+		id := s.nextHandle("WL")
+		s.worklist[id] = ""
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(id))
+
+	case path == "/sap/bc/adt/atc/runs" && r.Method == http.MethodPost:
+		body, _ := io.ReadAll(r.Body)
+		id := r.URL.Query().Get("worklistId")
+		s.mu.Lock()
+		_, ok := s.worklist[id]
+		if ok {
+			if m := objectReferencePattern.FindStringSubmatch(string(body)); m != nil {
+				s.worklist[id] = m[1]
+			}
+		}
+		s.mu.Unlock()
+		if !ok {
+			writeException(w, http.StatusNotFound, "ExceptionResourceNotFound", "Worklist %s does not exist", id)
+			return
+		}
+		writeXML(w, http.StatusOK, fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?><atcworklist:worklistRun xmlns:atcworklist="http://www.sap.com/adt/atc/worklist">`+
+			`<atcworklist:worklistId>%s</atcworklist:worklistId><atcworklist:worklistTimestamp>2024-01-01T00:00:00Z</atcworklist:worklistTimestamp>`+
+			`<atcworklist:infos><atcinfo:info xmlns:atcinfo="http://www.sap.com/adt/atc/info" type="FINDING_STATS" description="0,0,0"/></atcworklist:infos></atcworklist:worklistRun>`, id))
+
+	case strings.HasPrefix(path, "/sap/bc/adt/atc/worklists/") && r.Method == http.MethodGet:
+		s.serveATCWorklist(w, r.URL.Path[len("/sap/bc/adt/atc/worklists/"):])
+
+	default:
+		writeException(w, http.StatusNotFound, "ExceptionResourceNotFound", "Resource %s does not exist", r.URL.Path)
+	}
+}
+
+func (s *Server) serveATCWorklist(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	uri, ok := s.worklist[id]
+	if !ok {
+		writeException(w, http.StatusNotFound, "ExceptionResourceNotFound", "Worklist %s does not exist", id)
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<?xml version="1.0" encoding="utf-8"?><atcworklist:worklist xmlns:atcworklist="http://www.sap.com/adt/atc/worklist" xmlns:adtcore="http://www.sap.com/adt/core" xmlns:atcobject="http://www.sap.com/adt/atc/object" xmlns:atcfinding="http://www.sap.com/adt/atc/finding" atcworklist:id="%s" atcworklist:timestamp="2024-01-01T00:00:00Z" atcworklist:usedObjectSet="99999999999999999999999999999999" atcworklist:objectSetIsComplete="true">`, id)
+	sb.WriteString(`<atcworklist:objectSets><atcworklist:objectSet atcworklist:name="00000000000000000000000000000000" atcworklist:title="All Objects" atcworklist:kind="ALL"/></atcworklist:objectSets><atcworklist:objects>`)
+	if o, _ := s.findObject(uri); o != nil && len(s.atc[objectKey(o.uri)]) > 0 {
+		findings := append([]Finding(nil), s.atc[objectKey(o.uri)]...)
+		sort.SliceStable(findings, func(i, j int) bool { return findings[i].Priority < findings[j].Priority })
+		fmt.Fprintf(&sb, `<atcobject:object adtcore:uri="%s" adtcore:type="%s" adtcore:name="%s" adtcore:packageName="%s" atcobject:author="DEVELOPER"><atcobject:findings>`,
+			o.uri, o.typ, xmlEscape(o.name), xmlEscape(o.pkg))
+		for _, f := range findings {
+			location := fmt.Sprintf("%s/source/main#start=%d,%d", o.uri, f.Line, f.Column)
+			fmt.Fprintf(&sb, `<atcfinding:finding adtcore:uri="%s" atcfinding:location="%s" atcfinding:priority="%d" atcfinding:checkId="%s" atcfinding:checkTitle="%s" atcfinding:messageId="%s" atcfinding:messageTitle="%s"/>`,
+				xmlEscape(location), xmlEscape(location), f.Priority, xmlEscape(f.CheckID), xmlEscape(f.CheckTitle), xmlEscape(f.MessageID), xmlEscape(f.MessageTitle))
+		}
+		sb.WriteString(`</atcobject:findings></atcobject:object>`)
+	}
+	sb.WriteString(`</atcworklist:objects></atcworklist:worklist>`)
+	writeXML(w, http.StatusOK, sb.String())
+}
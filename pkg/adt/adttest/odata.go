@@ -0,0 +1,390 @@
+package adttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ODataService is a fake OData service, served below URL once added with
+// AddODataService.
+type ODataService struct {
+	URL        string // service root, e.g. /sap/opu/odata4/sap/zui_travel_o4/srvd/sap/zui_travel/0001/
+	V2         bool   // serve OData V2 instead of V4
+	Namespace  string // schema namespace (default: com.sap.gateway.srvd.mock.v0001)
+	EntitySets []ODataEntitySet
+}
+
+// ODataEntitySet is an entity set of a fake OData service. Queries support
+// $top, $filter with eq comparisons joined by "and", and $expand, which
+// returns empty collections.
+type ODataEntitySet struct {
+	Name       string
+	Keys       []string
+	Properties []ODataProperty // including the keys
+	Navigation []string
+	// Draft makes the set draft-enabled (V4): IsActiveEntity becomes part of
+	// the key, creating adds a draft and the bound actions Prepare and
+	// Activate are available.
+	Draft bool
+	// ActivateError makes Activate fail with this message, as a failed
+	// validation does.
+	ActivateError string
+	Rows          []map[string]any
+}
+
+// ODataProperty is a property of an entity set.
+type ODataProperty struct {
+	Name string
+	Type string // EDM type, e.g. Edm.String or Edm.Guid
+}
+
+type odataService struct {
+	ODataService
+	sets map[string]*ODataEntitySet
+}
+
+// AddODataService adds an OData service, replacing one with the same URL.
+func (s *Server) AddODataService(svc ODataService) {
+	if svc.Namespace == "" {
+		svc.Namespace = "com.sap.gateway.srvd.mock.v0001"
+	}
+	if !strings.HasSuffix(svc.URL, "/") {
+		svc.URL += "/"
+	}
+	o := &odataService{sets: make(map[string]*ODataEntitySet)}
+	for _, set := range svc.EntitySets {
+		set.Rows = append([]map[string]any(nil), set.Rows...)
+		if set.Draft {
+			set.Keys = append(append([]string(nil), set.Keys...), "IsActiveEntity")
+			set.Properties = append(append([]ODataProperty(nil), set.Properties...), ODataProperty{Name: "IsActiveEntity", Type: "Edm.Boolean"})
+			for i, row := range set.Rows {
+				set.Rows[i] = copyRow(row)
+				if _, ok := row["IsActiveEntity"]; !ok {
+					set.Rows[i]["IsActiveEntity"] = true
+				}
+			}
+		}
+		o.sets[set.Name] = &set
+		o.EntitySets = append(o.EntitySets, set)
+	}
+	o.ODataService.URL, o.V2, o.Namespace = svc.URL, svc.V2, svc.Namespace
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.odata[strings.ToLower(svc.URL)] = o
+}
+
+// ODataRows returns the current rows of an entity set of an added service.
+func (s *Server) ODataRows(serviceURL, entitySet string) []map[string]any {
+	if !strings.HasSuffix(serviceURL, "/") {
+		serviceURL += "/"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	svc, ok := s.odata[strings.ToLower(serviceURL)]
+	if !ok || svc.sets[entitySet] == nil {
+		return nil
+	}
+	var rows []map[string]any
+	for _, row := range svc.sets[entitySet].Rows {
+		rows = append(rows, copyRow(row))
+	}
+	return rows
+}
+
+var odataResourcePattern = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(?:/([\w.]+))?$`)
+
+// serveOData serves the OData services added with AddODataService.
+func (s *Server) serveOData(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var svc *odataService
+	for root, o := range s.odata {
+		if strings.HasPrefix(strings.ToLower(r.URL.Path), root) && (svc == nil || len(root) > len(svc.URL)) {
+			svc = o
+		}
+	}
+	if svc == nil {
+		writeException(w, http.StatusNotFound, "ExceptionResourceNotFound", "Service %s does not exist", r.URL.Path)
+		return
+	}
+	rest := r.URL.Path[len(svc.URL):]
+	if rest == "$metadata" && r.Method == http.MethodGet {
+		writeXML(w, http.StatusOK, svc.metadata())
+		return
+	}
+
+	m := odataResourcePattern.FindStringSubmatch(rest)
+	if m == nil || svc.sets[m[1]] == nil {
+		svc.writeError(w, http.StatusNotFound, "Resource %s not found", rest)
+		return
+	}
+	set, preds, action := svc.sets[m[1]], m[2], m[3]
+	q := r.URL.Query()
+	switch {
+	case preds == "" && action == "" && r.Method == http.MethodGet:
+		svc.serveCollection(w, set, q.Get("$top"), q.Get("$filter"), q.Get("$expand"))
+	case preds != "" && action == "" && r.Method == http.MethodGet:
+		if i := svc.find(set, preds); i >= 0 {
+			svc.writeEntity(w, http.StatusOK, set, set.Rows[i])
+		} else {
+			svc.writeError(w, http.StatusNotFound, "Entity %s(%s) not found", set.Name, preds)
+		}
+	case preds == "" && action == "" && r.Method == http.MethodPost:
+		svc.create(w, r, set)
+	case preds != "" && action != "" && r.Method == http.MethodPost:
+		svc.action(w, set, preds, action)
+	case preds != "" && action == "" && r.Method == http.MethodDelete:
+		i := svc.find(set, preds)
+		if i < 0 {
+			svc.writeError(w, http.StatusNotFound, "Entity %s(%s) not found", set.Name, preds)
+			return
+		}
+		set.Rows = append(set.Rows[:i], set.Rows[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		svc.writeError(w, http.StatusMethodNotAllowed, "Method %s not allowed on %s", r.Method, rest)
+	}
+}
+
+func (svc *odataService) serveCollection(w http.ResponseWriter, set *ODataEntitySet, top, filter, expand string) {
+	conds := make(map[string]string)
+	if filter != "" {
+		for _, cond := range strings.Split(filter, " and ") {
+			parts := strings.SplitN(cond, " eq ", 2)
+			if len(parts) != 2 {
+				svc.writeError(w, http.StatusBadRequest, "Unsupported $filter %q", filter)
+				return
+			}
+			conds[strings.TrimSpace(parts[0])] = odataLiteralValue(strings.TrimSpace(parts[1]))
+		}
+	}
+	if expand != "" && !contains(set.Navigation, expand) {
+		svc.writeError(w, http.StatusBadRequest, "Property %s is not a navigation property of %s", expand, set.Name)
+		return
+	}
+	n := len(set.Rows)
+	if top != "" {
+		fmt.Sscanf(top, "%d", &n)
+	}
+
+	rows := []map[string]any{}
+	for _, row := range set.Rows {
+		if len(rows) >= n {
+			break
+		}
+		if !rowMatches(row, conds) {
+			continue
+		}
+		row = copyRow(row)
+		if expand != "" {
+			row[expand] = []any{}
+			if svc.V2 {
+				row[expand] = map[string]any{"results": []any{}}
+			}
+		}
+		rows = append(rows, row)
+	}
+	if svc.V2 {
+		writeJSON(w, http.StatusOK, map[string]any{"d": map[string]any{"results": rows}})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"@odata.context": "$metadata#" + set.Name, "value": rows})
+}
+
+func (svc *odataService) create(w http.ResponseWriter, r *http.Request, set *ODataEntitySet) {
+	row := make(map[string]any)
+	body, _ := io.ReadAll(r.Body)
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &row); err != nil {
+			svc.writeError(w, http.StatusBadRequest, "Invalid payload: %v", err)
+			return
+		}
+	}
+	for _, p := range set.Properties {
+		if contains(set.Keys, p.Name) && p.Type == "Edm.Guid" && row[p.Name] == nil {
+			t := randomToken()
+			row[p.Name] = fmt.Sprintf("%s-%s-%s-%s-%s", t[:8], t[8:12], t[12:16], t[16:20], t[20:])
+		}
+	}
+	if set.Draft {
+		row["IsActiveEntity"] = false
+	}
+	for _, k := range set.Keys {
+		if row[k] == nil {
+			svc.writeError(w, http.StatusBadRequest, "Key %s is required", k)
+			return
+		}
+	}
+	if svc.findRow(set, row) >= 0 {
+		svc.writeError(w, http.StatusConflict, "Entity already exists")
+		return
+	}
+	set.Rows = append(set.Rows, row)
+	svc.writeEntity(w, http.StatusCreated, set, row)
+}
+
+func (svc *odataService) action(w http.ResponseWriter, set *ODataEntitySet, preds, action string) {
+	i := svc.find(set, preds)
+	if i < 0 {
+		svc.writeError(w, http.StatusNotFound, "Entity %s(%s) not found", set.Name, preds)
+		return
+	}
+	row := set.Rows[i]
+	switch {
+	case !set.Draft || row["IsActiveEntity"] != false:
+		svc.writeError(w, http.StatusBadRequest, "Action %s needs a draft instance", action)
+	case action == svc.Namespace+".Prepare":
+		svc.writeEntity(w, http.StatusOK, set, row)
+	case action == svc.Namespace+".Activate" && set.ActivateError != "":
+		svc.writeError(w, http.StatusBadRequest, "%s", set.ActivateError)
+	case action == svc.Namespace+".Activate":
+		active := copyRow(row)
+		active["IsActiveEntity"] = true
+		set.Rows = append(set.Rows[:i], set.Rows[i+1:]...)
+		if j := svc.findRow(set, active); j >= 0 {
+			set.Rows[j] = active
+		} else {
+			set.Rows = append(set.Rows, active)
+		}
+		svc.writeEntity(w, http.StatusOK, set, active)
+	default:
+		svc.writeError(w, http.StatusBadRequest, "Action %s not found", action)
+	}
+}
+
+// find returns the index of the row matching a key predicate, or -1.
+func (svc *odataService) find(set *ODataEntitySet, preds string) int {
+	conds := make(map[string]string)
+	for _, pred := range strings.Split(preds, ",") {
+		name, value, ok := strings.Cut(pred, "=")
+		if !ok && len(set.Keys) == 1 {
+			name, value = set.Keys[0], pred
+		}
+		conds[strings.TrimSpace(name)] = odataLiteralValue(strings.TrimSpace(value))
+	}
+	for i, row := range set.Rows {
+		if len(conds) == len(set.Keys) && rowMatches(row, conds) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findRow returns the index of the row with the keys of row, or -1.
+func (svc *odataService) findRow(set *ODataEntitySet, row map[string]any) int {
+	conds := make(map[string]string)
+	for _, k := range set.Keys {
+		conds[k] = fmt.Sprint(row[k])
+	}
+	for i, other := range set.Rows {
+		if rowMatches(other, conds) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (svc *odataService) writeEntity(w http.ResponseWriter, status int, set *ODataEntitySet, row map[string]any) {
+	if svc.V2 {
+		writeJSON(w, status, map[string]any{"d": row})
+		return
+	}
+	entity := copyRow(row)
+	entity["@odata.context"] = "$metadata#" + set.Name + "/$entity"
+	writeJSON(w, status, entity)
+}
+
+func (svc *odataService) writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, map[string]any{"error": map[string]any{"code": fmt.Sprint(status), "message": fmt.Sprintf(format, args...)}})
+}
+
+// metadata returns the EDMX document of the service.
+func (svc *odataService) metadata() string {
+	version, edmx, edm := "4.0", "http://docs.oasis-open.org/odata/ns/edmx", "http://docs.oasis-open.org/odata/ns/edm"
+	if svc.V2 {
+		version, edmx, edm = "1.0", "http://schemas.microsoft.com/ado/2007/06/edmx", "http://schemas.microsoft.com/ado/2008/09/edm"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<?xml version="1.0" encoding="utf-8"?><edmx:Edmx Version="%s" xmlns:edmx="%s"><edmx:DataServices><Schema Namespace="%s" xmlns="%s">`, version, edmx, svc.Namespace, edm)
+	draft := false
+	for _, set := range svc.EntitySets {
+		fmt.Fprintf(&sb, `<EntityType Name="%sType"><Key>`, set.Name)
+		for _, k := range set.Keys {
+			fmt.Fprintf(&sb, `<PropertyRef Name="%s"/>`, k)
+		}
+		sb.WriteString(`</Key>`)
+		for _, p := range set.Properties {
+			fmt.Fprintf(&sb, `<Property Name="%s" Type="%s"/>`, p.Name, p.Type)
+		}
+		for _, n := range set.Navigation {
+			fmt.Fprintf(&sb, `<NavigationProperty Name="%s" Type="Collection(%s.%sType)"/>`, n, svc.Namespace, set.Name)
+		}
+		sb.WriteString(`</EntityType>`)
+		draft = draft || set.Draft
+	}
+	if draft {
+		for _, action := range []string{"Prepare", "Activate"} {
+			fmt.Fprintf(&sb, `<Action Name="%s" IsBound="true"/>`, action)
+		}
+	}
+	sb.WriteString(`<EntityContainer Name="Container">`)
+	for _, set := range svc.EntitySets {
+		fmt.Fprintf(&sb, `<EntitySet Name="%s" EntityType="%s.%sType"/>`, set.Name, svc.Namespace, set.Name)
+	}
+	sb.WriteString(`</EntityContainer></Schema></edmx:DataServices></edmx:Edmx>`)
+	return sb.String()
+}
+
+// odataLiteralValue returns the value of a URL literal as fmt.Sprint prints
+// the JSON value, e.g. 'abc' -> abc, guid'...' -> ..., 12.5M -> 12.5.
+func odataLiteralValue(lit string) string {
+	if i := strings.Index(lit, "'"); i >= 0 && strings.HasSuffix(lit, "'") && len(lit) > i+1 {
+		return strings.ReplaceAll(lit[i+1:len(lit)-1], "''", "'")
+	}
+	// Type suffixes only follow numbers; unquoted V4 GUIDs may end in d or f
+	if n := strings.TrimRight(lit, "MLmldf"); n != lit {
+		if _, err := strconv.ParseFloat(n, 64); err == nil {
+			return n
+		}
+	}
+	return lit
+}
+
+func rowMatches(row map[string]any, conds map[string]string) bool {
+	for name, value := range conds {
+		if fmt.Sprint(row[name]) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func copyRow(row map[string]any) map[string]any {
+	c := make(map[string]any, len(row))
+	for k, v := range row {
+		c[k] = v
+	}
+	return c
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
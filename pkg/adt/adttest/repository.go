@@ -0,0 +1,639 @@
+package adttest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Object is a repository object of the fake system.
+type Object struct {
+	URI         string // e.g. /sap/bc/adt/programs/programs/ZTEST; derived from Type and Name if empty
+	Type        string // ADT type, e.g. PROG/P, CLAS/OC, INTF/OI
+	Name        string
+	Package     string // defaults to $TMP
+	Description string
+	Source      string            // main source
+	Includes    map[string]string // class includes other than main, e.g. "testclasses"
+
+	Inactive   bool   // set by Object: the object has unactivated changes
+	LockHandle string // set by Object: the current lock handle, if locked
+}
+
+type object struct {
+	uri, typ, name, pkg, description string
+
+	sources  map[string]string // active sources by include, "main" for the main source
+	inactive map[string]string // unactivated changes by include
+	lock     string
+	lockUser string // set for locks held by another user (LockBy)
+}
+
+// objectPaths maps ADT object types to the collection they are created in.
+var objectPaths = map[string]string{
+	"PROG/P":   "/sap/bc/adt/programs/programs",
+	"PROG/I":   "/sap/bc/adt/programs/includes",
+	"CLAS/OC":  "/sap/bc/adt/oo/classes",
+	"INTF/OI":  "/sap/bc/adt/oo/interfaces",
+	"FUGR/F":   "/sap/bc/adt/functions/groups",
+	"DDLS/DF":  "/sap/bc/adt/ddic/ddl/sources",
+	"DDLX/EX":  "/sap/bc/adt/ddic/ddlx/sources",
+	"BDEF/BDO": "/sap/bc/adt/bo/behaviordefinitions",
+	"SRVD/SRV": "/sap/bc/adt/ddic/srvd/sources",
+	"SRVB/SVB": "/sap/bc/adt/businessservices/bindings",
+	"DOMA/DD":  "/sap/bc/adt/ddic/domains",
+	"DTEL/DE":  "/sap/bc/adt/ddic/dataelements",
+	"TABL/DT":  "/sap/bc/adt/ddic/tables",
+	"TABL/DS":  "/sap/bc/adt/ddic/structures",
+	"TTYP/DA":  "/sap/bc/adt/ddic/tabletypes",
+	"SHLP/DH":  "/sap/bc/adt/ddic/searchhelps",
+	"MSAG/N":   "/sap/bc/adt/messageclass",
+}
+
+func objectKey(uri string) string {
+	if p, err := url.PathUnescape(uri); err == nil {
+		uri = p
+	}
+	return strings.ToLower(strings.TrimSuffix(uri, "/"))
+}
+
+// AddObject adds an active object to the repository, replacing an existing
+// one with the same URI. The object's package is created if needed.
+func (s *Server) AddObject(obj Object) {
+	obj.Name = strings.ToUpper(obj.Name)
+	if obj.Package == "" {
+		obj.Package = "$TMP"
+	}
+	if obj.URI == "" {
+		obj.URI = objectPaths[obj.Type] + "/" + url.PathEscape(obj.Name)
+	}
+
+	o := &object{
+		uri:         obj.URI,
+		typ:         obj.Type,
+		name:        obj.Name,
+		pkg:         strings.ToUpper(obj.Package),
+		description: obj.Description,
+		sources:     map[string]string{"main": obj.Source},
+		inactive:    make(map[string]string),
+	}
+	for include, source := range obj.Includes {
+		o.sources[include] = source
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.packages[o.pkg]; !ok {
+		s.addPackage(o.pkg, "", "")
+	}
+	s.objects[objectKey(o.uri)] = o
+}
+
+// AddPackage adds a package. parent may be empty.
+func (s *Server) AddPackage(name, description, parent string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addPackage(strings.ToUpper(name), description, strings.ToUpper(parent))
+}
+
+func (s *Server) addPackage(name, description, parent string) {
+	s.packages[name] = &object{
+		uri:         "/sap/bc/adt/packages/" + url.PathEscape(strings.ToLower(name)),
+		typ:         "DEVC/K",
+		name:        name,
+		pkg:         parent,
+		description: description,
+	}
+}
+
+// Object returns a snapshot of the object with the given URI. Source and
+// Includes hold the newest version, active or not.
+func (s *Server) Object(uri string) (Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.objects[objectKey(uri)]
+	if !ok {
+		return Object{}, false
+	}
+	obj := Object{
+		URI:         o.uri,
+		Type:        o.typ,
+		Name:        o.name,
+		Package:     o.pkg,
+		Description: o.description,
+		Source:      o.source("main", false),
+		Includes:    make(map[string]string),
+		Inactive:    len(o.inactive) > 0,
+	}
+	if o.lockUser == "" {
+		obj.LockHandle = o.lock
+	}
+	for include := range o.sources {
+		if include != "main" {
+			obj.Includes[include] = o.source(include, false)
+		}
+	}
+	for include := range o.inactive {
+		if include != "main" {
+			obj.Includes[include] = o.source(include, false)
+		}
+	}
+	return obj, true
+}
+
+// LockBy simulates a lock held by another user. Lock requests fail with 403
+// "currently editing" until UnlockBy is called.
+func (s *Server) LockBy(uri, user string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.objects[objectKey(uri)]
+	if !ok {
+		return fmt.Errorf("object %s not found", uri)
+	}
+	o.lock, o.lockUser = s.nextHandle("FOREIGN"), strings.ToUpper(user)
+	return nil
+}
+
+// UnlockBy releases a lock set with LockBy.
+func (s *Server) UnlockBy(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o, ok := s.objects[objectKey(uri)]; ok && o.lockUser != "" {
+		o.lock, o.lockUser = "", ""
+	}
+}
+
+// source returns the inactive version of an include if there is one, else the
+// active version. activeOnly skips the inactive version.
+func (o *object) source(include string, activeOnly bool) string {
+	if src, ok := o.inactive[include]; ok && !activeOnly {
+		return src
+	}
+	return o.sources[include]
+}
+
+func (o *object) hasInclude(include string) bool {
+	_, active := o.sources[include]
+	_, inactive := o.inactive[include]
+	return active || inactive
+}
+
+// findObject returns the object whose URI is p or a prefix of p, and the rest
+// of the path. Callers must hold s.mu.
+func (s *Server) findObject(p string) (*object, string) {
+	key := objectKey(p)
+	var best *object
+	var rest string
+	for k, o := range s.objects {
+		if key != k && !strings.HasPrefix(key, k+"/") {
+			continue
+		}
+		if best == nil || len(k) > len(objectKey(best.uri)) {
+			best, rest = o, key[len(k):]
+		}
+	}
+	return best, rest
+}
+
+// serveObject handles object URIs and their sub-resources, and object
+// creation on collection URIs.
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodPost && s.isCollection(r.URL.Path) {
+		s.createObject(w, r)
+		return
+	}
+	o, rest := s.findObject(r.URL.Path)
+	if o == nil {
+		writeException(w, http.StatusNotFound, "ExceptionResourceNotFound", "Resource %s does not exist", r.URL.Path)
+		return
+	}
+
+	q := r.URL.Query()
+	switch {
+	case rest == "" && r.Method == http.MethodPost && q.Get("_action") == "LOCK":
+		s.lockObject(w, o)
+	case rest == "" && r.Method == http.MethodPost && q.Get("_action") == "UNLOCK":
+		if o.lockUser == "" && o.lock == q.Get("lockHandle") {
+			o.lock = ""
+		}
+		w.WriteHeader(http.StatusOK)
+	case rest == "" && r.Method == http.MethodGet && xmlDefined[o.typ] && strings.HasPrefix(o.source("main", false), "<?xml"):
+		writeXML(w, http.StatusOK, o.source("main", false))
+	case rest == "" && r.Method == http.MethodGet && o.typ == "FUGR/F":
+		s.serveFunctionGroup(w, o)
+	case rest == "" && r.Method == http.MethodGet:
+		writeXML(w, http.StatusOK, fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<adtcore:mainObject xmlns:adtcore="http://www.sap.com/adt/core" adtcore:uri="%s" adtcore:type="%s" adtcore:name="%s" adtcore:description="%s">
+  <adtcore:packageRef adtcore:name="%s"/>
+</adtcore:mainObject>`, o.uri, o.typ, xmlEscape(o.name), xmlEscape(o.description), xmlEscape(o.pkg)))
+	case rest == "" && r.Method == http.MethodPut:
+		// XML-defined objects (domains, data elements, table types, ...) are
+		// written as a whole; the document is kept as the main source.
+		if !s.checkLock(w, o, q.Get("lockHandle")) {
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		o.inactive["main"] = string(body)
+		w.WriteHeader(http.StatusOK)
+	case rest == "" && r.Method == http.MethodDelete:
+		if !s.checkLock(w, o, q.Get("lockHandle")) {
+			return
+		}
+		for k, other := range s.objects {
+			if other == o || strings.HasPrefix(k, objectKey(o.uri)+"/") {
+				delete(s.objects, k)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	case rest == "/includes" && r.Method == http.MethodPost:
+		if !s.checkLock(w, o, q.Get("lockHandle")) {
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		include := attr(string(body), "class:includeType")
+		if include == "" {
+			include = "testclasses"
+		}
+		if o.hasInclude(include) {
+			writeException(w, http.StatusBadRequest, "ExceptionResourceAlreadyExists", "Include %s of %s already exists", include, o.name)
+			return
+		}
+		o.inactive[include] = ""
+		w.WriteHeader(http.StatusCreated)
+	case rest == "/source/main" || strings.HasPrefix(rest, "/includes/"):
+		include := "main"
+		if rest != "/source/main" {
+			include = path.Base(rest)
+		}
+		s.serveSource(w, r, o, include)
+	default:
+		writeException(w, http.StatusNotFound, "ExceptionResourceNotFound", "Resource %s does not exist", r.URL.Path)
+	}
+}
+
+func (s *Server) serveSource(w http.ResponseWriter, r *http.Request, o *object, include string) {
+	switch r.Method {
+	case http.MethodGet:
+		if !o.hasInclude(include) {
+			writeException(w, http.StatusNotFound, "ExceptionResourceNotFound", "Include %s of %s does not exist", include, o.name)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(o.source(include, r.URL.Query().Get("version") == "active")))
+	case http.MethodPut:
+		if !s.checkLock(w, o, r.URL.Query().Get("lockHandle")) {
+			return
+		}
+		if include != "main" && !o.hasInclude(include) {
+			writeException(w, http.StatusNotFound, "ExceptionResourceNotFound", "Include %s of %s does not exist", include, o.name)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		o.inactive[include] = string(body)
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeException(w, http.StatusMethodNotAllowed, "ExceptionMethodNotSupported", "Method %s not supported", r.Method)
+	}
+}
+
+func (s *Server) lockObject(w http.ResponseWriter, o *object) {
+	if o.lockUser != "" {
+		writeException(w, http.StatusForbidden, "ExceptionResourceNoAccess", "User %s is currently editing %s", o.lockUser, o.name)
+		return
+	}
+	if o.lock == "" {
+		o.lock = s.nextHandle("LOCK")
+	}
+	writeXML(w, http.StatusOK, fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<asx:abap xmlns:asx="http://www.sap.com/abapxml" version="1.0">
+  <asx:values>
+    <DATA>
+      <LOCK_HANDLE>%s</LOCK_HANDLE>
+      <CORRNR/>
+      <CORRUSER/>
+      <CORRTEXT/>
+      <IS_LOCAL>X</IS_LOCAL>
+      <IS_LINK_UP/>
+      <MODIFICATION_SUPPORT>ModifcationsLoggedSupported</MODIFICATION_SUPPORT>
+    </DATA>
+  </asx:values>
+</asx:abap>`, o.lock))
+}
+
+// checkLock writes an error and returns false unless handle is the object's lock handle.
+func (s *Server) checkLock(w http.ResponseWriter, o *object, handle string) bool {
+	if o.lockUser != "" {
+		writeException(w, http.StatusForbidden, "ExceptionResourceNoAccess", "User %s is currently editing %s", o.lockUser, o.name)
+		return false
+	}
+	if o.lock == "" || o.lock != handle {
+		writeException(w, http.StatusLocked, "ExceptionResourceInvalidLockHandle", "Resource %s is not locked (invalid lock handle)", o.name)
+		return false
+	}
+	return true
+}
+
+// xmlDefined are the object types whose definition is an XML document
+// rather than source; reading the object returns the document.
+var xmlDefined = map[string]bool{"DOMA/DD": true, "DTEL/DE": true, "TTYP/DA": true, "SHLP/DH": true, "MSAG/N": true, "SRVB/SVB": true}
+
+// serveFunctionGroup returns a function group with its function modules.
+// Callers must hold s.mu.
+func (s *Server) serveFunctionGroup(w http.ResponseWriter, o *object) {
+	var modules []*object
+	prefix := objectKey(o.uri) + "/fmodules/"
+	for k, fm := range s.objects {
+		if strings.HasPrefix(k, prefix) {
+			modules = append(modules, fm)
+		}
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].name < modules[j].name })
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<?xml version="1.0" encoding="utf-8"?><group uri="%s" type="%s" name="%s">`, o.uri, o.typ, xmlEscape(o.name))
+	for _, fm := range modules {
+		fmt.Fprintf(&sb, `<functionModule uri="%s" type="%s" name="%s"/>`, fm.uri, fm.typ, xmlEscape(fm.name))
+	}
+	sb.WriteString(`</group>`)
+	writeXML(w, http.StatusOK, sb.String())
+}
+
+// isCollection reports whether p is a URI objects are created in. Callers must hold s.mu.
+func (s *Server) isCollection(p string) bool {
+	key := objectKey(p)
+	if key == "/sap/bc/adt/packages" {
+		return true
+	}
+	for _, c := range objectPaths {
+		if key == c {
+			return true
+		}
+	}
+	// Function modules and includes are created below their function group
+	for _, sub := range []string{"/fmodules", "/includes"} {
+		if strings.HasSuffix(key, sub) {
+			if o, rest := s.findObject(strings.TrimSuffix(key, sub)); o != nil && rest == "" && o.typ == "FUGR/F" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	attrPattern       = regexp.MustCompile(`([\w:]+)="([^"]*)"`)
+	packageRefPattern = regexp.MustCompile(`<(?:adtcore:packageRef|pack:superPackage)\s+adtcore:name="([^"]*)"`)
+)
+
+// attr returns the first value of the named attribute in an XML document.
+func attr(doc, name string) string {
+	for _, m := range attrPattern.FindAllStringSubmatch(doc, -1) {
+		if m[1] == name {
+			return xmlUnescape(m[2])
+		}
+	}
+	return ""
+}
+
+func xmlUnescape(s string) string {
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&amp;", "&").Replace(s)
+}
+
+// createObject creates an inactive object from an ADT creation document. Callers must hold s.mu.
+func (s *Server) createObject(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	doc := string(body)
+	name := strings.ToUpper(attr(doc, "adtcore:name"))
+	typ := attr(doc, "adtcore:type")
+	pkg := ""
+	if m := packageRefPattern.FindStringSubmatch(doc); m != nil {
+		pkg = strings.ToUpper(m[1])
+	}
+	if name == "" || typ == "" {
+		writeException(w, http.StatusBadRequest, "ExceptionInvalidData", "Object name and type are required")
+		return
+	}
+
+	if typ == "DEVC/K" {
+		if _, ok := s.packages[name]; ok {
+			writeException(w, http.StatusBadRequest, "ExceptionResourceAlreadyExists", "Package %s already exists", name)
+			return
+		}
+		s.addPackage(name, attr(doc, "adtcore:description"), pkg)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	// Objects below a function group reference the group, not a package
+	if pkg == "" {
+		if parent, rest := s.findObject(path.Dir(r.URL.Path)); parent != nil && rest == "" {
+			pkg = parent.pkg
+		}
+	}
+	if _, ok := s.packages[pkg]; !ok {
+		writeException(w, http.StatusBadRequest, "ExceptionResourceNotFound", "Package %s does not exist", pkg)
+		return
+	}
+	uri := strings.TrimSuffix(r.URL.Path, "/") + "/" + url.PathEscape(name)
+	if o, _ := s.findObject(uri); o != nil && objectKey(o.uri) == objectKey(uri) {
+		writeException(w, http.StatusBadRequest, "ExceptionResourceAlreadyExists", "%s %s already exists", typ, name)
+		return
+	}
+
+	o := &object{
+		uri:         uri,
+		typ:         typ,
+		name:        name,
+		pkg:         pkg,
+		description: attr(doc, "adtcore:description"),
+		sources:     make(map[string]string),
+		inactive:    map[string]string{"main": initialSource(typ, name)},
+	}
+	if typ == "SRVB/SVB" {
+		// Service bindings are defined by their creation document
+		o.inactive["main"] = doc
+	}
+	if typ == "CLAS/OC" {
+		// SAP creates the local type includes with the class
+		for _, include := range []string{"definitions", "implementations", "macros"} {
+			o.inactive[include] = ""
+		}
+	}
+	s.objects[objectKey(uri)] = o
+	if typ == "FUGR/F" {
+		// and the global data include with the function group
+		top := functionGroupInclude(name, "TOP")
+		topURI := uri + "/includes/" + url.PathEscape(top)
+		s.objects[objectKey(topURI)] = &object{
+			uri:      topURI,
+			typ:      "FUGR/I",
+			name:     top,
+			pkg:      pkg,
+			sources:  make(map[string]string),
+			inactive: map[string]string{"main": fmt.Sprintf("FUNCTION-POOL %s.\n", strings.ToLower(name))},
+		}
+	}
+	w.Header().Set("Location", uri)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// functionGroupInclude returns the name of a function group include, e.g.
+// LZFGTOP for group ZFG or /NS/LFGTOP for group /NS/FG.
+func functionGroupInclude(group, suffix string) string {
+	i := strings.LastIndex(group, "/") + 1
+	return group[:i] + "L" + group[i:] + suffix
+}
+
+// initialSource returns the source SAP generates for a new object.
+func initialSource(typ, name string) string {
+	lower := strings.ToLower(name)
+	switch typ {
+	case "FUGR/F":
+		return fmt.Sprintf("INCLUDE %s.\nINCLUDE %s.\n", strings.ToLower(functionGroupInclude(name, "TOP")), strings.ToLower(functionGroupInclude(name, "UXX")))
+	case "PROG/P":
+		return fmt.Sprintf("REPORT %s.\n", lower)
+	case "CLAS/OC":
+		return fmt.Sprintf("CLASS %s DEFINITION\n  PUBLIC\n  FINAL\n  CREATE PUBLIC .\n\n  PUBLIC SECTION.\n  PROTECTED SECTION.\n  PRIVATE SECTION.\nENDCLASS.\n\n\n\nCLASS %s IMPLEMENTATION.\nENDCLASS.\n", lower, lower)
+	case "INTF/OI":
+		return fmt.Sprintf("INTERFACE %s\n  PUBLIC .\nENDINTERFACE.\n", lower)
+	}
+	return ""
+}
+
+// serveSearch implements the quickSearch operation of the repository information system.
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	pattern := strings.ToUpper(q.Get("query"))
+	if pattern == "" {
+		pattern = "*"
+	}
+	max := 100
+	fmt.Sscanf(q.Get("maxResults"), "%d", &max)
+
+	s.mu.Lock()
+	var matches []*object
+	for _, o := range s.objects {
+		if ok, _ := path.Match(pattern, o.name); ok {
+			matches = append(matches, o)
+		}
+	}
+	for _, p := range s.packages {
+		if ok, _ := path.Match(pattern, p.name); ok {
+			matches = append(matches, p)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].name < matches[j].name })
+	if len(matches) > max {
+		matches = matches[:max]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="utf-8"?><adtcore:objectReferences xmlns:adtcore="http://www.sap.com/adt/core">`)
+	for _, o := range matches {
+		fmt.Fprintf(&sb, `<adtcore:objectReference adtcore:uri="%s" adtcore:type="%s" adtcore:name="%s" adtcore:packageName="%s" adtcore:description="%s"/>`,
+			o.uri, o.typ, xmlEscape(o.name), xmlEscape(o.pkg), xmlEscape(o.description))
+	}
+	sb.WriteString(`</adtcore:objectReferences>`)
+	writeXML(w, http.StatusOK, sb.String())
+}
+
+// serveNodeStructure returns the objects and subpackages of a package.
+func (s *Server) serveNodeStructure(w http.ResponseWriter, r *http.Request) {
+	name := strings.ToUpper(r.URL.Query().Get("parent_name"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.packages[name]; !ok {
+		writeException(w, http.StatusNotFound, "ExceptionResourceNotFound", "Package %s does not exist", name)
+		return
+	}
+
+	var nodes []*object
+	for _, p := range s.packages {
+		if p.pkg == name {
+			nodes = append(nodes, p)
+		}
+	}
+	for _, o := range s.objects {
+		// Function modules and includes are listed below their group, not the package
+		if o.pkg == name && o.typ != "FUGR/FF" && o.typ != "FUGR/I" {
+			nodes = append(nodes, o)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].name < nodes[j].name })
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="utf-8"?><asx:abap xmlns:asx="http://www.sap.com/abapxml" version="1.0"><asx:values><DATA><TREE_CONTENT>`)
+	for _, o := range nodes {
+		fmt.Fprintf(&sb, `<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>%s</OBJECT_TYPE><OBJECT_NAME>%s</OBJECT_NAME><OBJECT_URI>%s</OBJECT_URI><DESCRIPTION>%s</DESCRIPTION></SEU_ADT_REPOSITORY_OBJ_NODE>`,
+			o.typ, xmlEscape(o.name), o.uri, xmlEscape(o.description))
+	}
+	sb.WriteString(`</TREE_CONTENT></DATA></asx:values></asx:abap>`)
+	writeXML(w, http.StatusOK, sb.String())
+}
+
+var objectReferencePattern = regexp.MustCompile(`<adtcore:objectReference\s[^>]*adtcore:uri="([^"]*)"`)
+
+// serveActivation activates the referenced objects. Objects whose Check
+// returns errors stay inactive and the errors are returned as messages.
+func (s *Server) serveActivation(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	var msgs []string
+	var activate []*object
+	failed := false
+	for _, m := range objectReferencePattern.FindAllStringSubmatch(string(body), -1) {
+		o, _ := s.findObject(m[1])
+		if o == nil {
+			msgs = append(msgs, activationMessage(m[1], "", Message{Severity: "E", Text: fmt.Sprintf("Object %s does not exist", m[1])}))
+			failed = true
+			continue
+		}
+		if s.Check != nil && o.hasInclude("main") {
+			for _, msg := range s.Check(o.uri, o.source("main", false)) {
+				msgs = append(msgs, activationMessage(o.uri+"/source/main", o.typ+" "+o.name, msg))
+				failed = failed || msg.Severity == "E"
+			}
+		}
+		activate = append(activate, o)
+	}
+	// Like SAP, nothing is activated if any object has errors
+	if !failed {
+		for _, o := range activate {
+			for include, src := range o.inactive {
+				o.sources[include] = src
+			}
+			o.inactive = make(map[string]string)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(msgs) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	// SAP wraps the checklist in the activation response; parseActivationResult
+	// reads the messages element below the root.
+	writeXML(w, http.StatusOK, `<?xml version="1.0" encoding="utf-8"?><adtcore:activationResult xmlns:adtcore="http://www.sap.com/adt/core"><chkl:messages xmlns:chkl="http://www.sap.com/abapxml/checklist">`+
+		strings.Join(msgs, "")+`</chkl:messages></adtcore:activationResult>`)
+}
+
+func activationMessage(href, objDescr string, msg Message) string {
+	if msg.Line > 0 {
+		href = fmt.Sprintf("%s#start=%d,%d", href, msg.Line, msg.Column)
+	}
+	return fmt.Sprintf(`<msg objDescr="%s" type="%s" line="%d" href="%s" forceSupported="true"><shortText><txt>%s</txt></shortText></msg>`,
+		xmlEscape(objDescr), msg.Severity, msg.Line, href, xmlEscape(msg.Text))
+}
@@ -0,0 +1,334 @@
+// Package adttest provides an in-memory fake of the SAP ADT REST API and the
+// ZADT_VSP WebSocket service, for tests and offline development.
+//
+// The fake implements the endpoints the adt package uses most: CSRF token and
+// session handling, search, package contents, source read/write with
+// lock/unlock, object creation and deletion, activation, syntax check,
+// ABAP Unit and ATC. Objects live in an in-memory repository that tests seed
+// with AddObject. Failures are scripted with Fail, ExpireCSRFToken,
+// ExpireSessions and LockBy.
+//
+// Usage:
+//
+//	srv := adttest.NewServer()
+//	defer srv.Close()
+//	srv.AddObject(adttest.Object{Type: "PROG/P", Name: "ZTEST", Source: "REPORT ztest."})
+//	client := adt.NewClient(srv.URL, "user", "pass")
+package adttest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// SessionCookie is the cookie that carries the mock session ID.
+const SessionCookie = "SAP_SESSIONID"
+
+// Request is a request received by the server, recorded for assertions.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+// Failure makes matching requests fail with a fixed response.
+type Failure struct {
+	Method string // empty matches any method
+	Path   string // path prefix, e.g. "/sap/bc/adt/activation"
+	Status int
+	Body   string
+	Times  int // number of requests to fail; 0 means once, -1 means always
+}
+
+// Server is a fake SAP system. It implements http.Handler; NewServer also
+// starts it on a loopback port.
+type Server struct {
+	// URL is the base URL of a server started with NewServer.
+	URL string
+
+	// User and Password, if User is set, are required as basic auth credentials.
+	User     string
+	Password string
+
+	// Check, if set, returns the syntax messages of an object's source. It is
+	// used by syntax check and activation (messages with severity "E" make
+	// activation fail). uri is the object URI, e.g. /sap/bc/adt/programs/programs/ZTEST.
+	Check func(uri, source string) []Message
+
+	mu        sync.Mutex
+	ts        *httptest.Server
+	csrfToken string
+	sessions  map[string]bool
+	failures  []*Failure
+	requests  []Request
+	nextID    int
+
+	objects  map[string]*object // by lowercase object URI
+	packages map[string]*object // by uppercase package name
+	atc      map[string][]Finding
+	unitFail map[string]string        // "CLASS=>METHOD" -> failure message
+	worklist map[string]string        // worklist ID -> object URI
+	services map[string]string        // published service binding -> protocol
+	odata    map[string]*odataService // by lowercase service root URL
+	ui5Apps  map[string]*UI5App       // by uppercase BSP application name
+
+	ws *wsEndpoint
+}
+
+// New returns a server with an empty repository containing only package $TMP.
+// Use it as an http.Handler, or call NewServer for a started server.
+func New() *Server {
+	s := &Server{
+		csrfToken: randomToken(),
+		sessions:  make(map[string]bool),
+		objects:   make(map[string]*object),
+		packages:  make(map[string]*object),
+		atc:       make(map[string][]Finding),
+		unitFail:  make(map[string]string),
+		worklist:  make(map[string]string),
+		services:  make(map[string]string),
+		odata:     make(map[string]*odataService),
+		ui5Apps:   make(map[string]*UI5App),
+	}
+	s.ws = newWSEndpoint()
+	s.AddPackage("$TMP", "Local objects", "")
+	return s
+}
+
+// NewServer returns a server listening on a loopback port. Close it when done.
+func NewServer() *Server {
+	s := New()
+	s.ts = httptest.NewServer(s)
+	s.URL = s.ts.URL
+	return s
+}
+
+// Close stops a server started with NewServer and drops WebSocket connections.
+func (s *Server) Close() {
+	s.ws.closeAll()
+	if s.ts != nil {
+		s.ts.Close()
+	}
+}
+
+// Fail scripts a failure for matching requests. Failures are checked in the
+// order they were added, before any other processing except authentication.
+func (s *Server) Fail(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Times == 0 {
+		f.Times = 1
+	}
+	s.failures = append(s.failures, &f)
+}
+
+// ExpireCSRFToken invalidates the current CSRF token. The next modifying
+// request is rejected with 403 until the client fetches a new token.
+func (s *Server) ExpireCSRFToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.csrfToken = randomToken()
+}
+
+// ExpireSessions ends all sessions. Requests with an old session cookie get
+// 400 "Session Timed Out" until the client fetches a new CSRF token.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]bool)
+}
+
+// Requests returns the requests received so far (excluding CSRF fetches).
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// CountRequests returns how many requests matched method (empty for any) and path prefix.
+func (s *Server) CountRequests(method, pathPrefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		if (method == "" || r.Method == method) && strings.HasPrefix(strings.ToLower(r.Path), strings.ToLower(pathPrefix)) {
+			n++
+		}
+	}
+	return n
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.User != "" {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != s.User || password != s.Password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="SAP NetWeaver Application Server"`)
+			http.Error(w, "Logon failed", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if strings.EqualFold(r.URL.Path, wsPath) {
+		s.ws.serve(w, r)
+		return
+	}
+
+	if strings.EqualFold(r.Header.Get("X-CSRF-Token"), "fetch") {
+		s.serveCSRFFetch(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query()})
+	if f := s.takeFailure(r); f != nil {
+		s.mu.Unlock()
+		writeRaw(w, f.Status, f.Body)
+		return
+	}
+	if c, err := r.Cookie(SessionCookie); err == nil && !s.sessions[c.Value] {
+		s.mu.Unlock()
+		writeRaw(w, http.StatusBadRequest, "Session Timed Out (ICMENOSESSION)")
+		return
+	}
+	if isModifying(r.Method) && r.Header.Get("X-CSRF-Token") != s.csrfToken {
+		s.mu.Unlock()
+		w.Header().Set("X-CSRF-Token", "Required")
+		writeRaw(w, http.StatusForbidden, "CSRF token validation failed")
+		return
+	}
+	s.mu.Unlock()
+
+	s.route(w, r)
+}
+
+// serveCSRFFetch returns the CSRF token and starts a session if the request has none.
+func (s *Server) serveCSRFFetch(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if c, err := r.Cookie(SessionCookie); err != nil || !s.sessions[c.Value] {
+		id := randomToken()
+		s.sessions[id] = true
+		http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: id, Path: "/"})
+	}
+	token := s.csrfToken
+	s.mu.Unlock()
+
+	w.Header().Set("X-CSRF-Token", token)
+	w.WriteHeader(http.StatusOK)
+}
+
+// takeFailure returns the first scripted failure matching r and consumes it.
+// Callers must hold s.mu.
+func (s *Server) takeFailure(r *http.Request) *Failure {
+	for i, f := range s.failures {
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(r.URL.Path), strings.ToLower(f.Path)) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	path := strings.ToLower(r.URL.Path)
+	switch {
+	case path == "/sap/bc/adt/core/discovery":
+		writeXML(w, http.StatusOK, `<?xml version="1.0" encoding="utf-8"?><app:service xmlns:app="http://www.w3.org/2007/app"/>`)
+	case path == "/sap/bc/adt/repository/informationsystem/search":
+		s.serveSearch(w, r)
+	case path == "/sap/bc/adt/repository/nodestructure":
+		s.serveNodeStructure(w, r)
+	case path == "/sap/bc/adt/activation":
+		s.serveActivation(w, r)
+	case path == "/sap/bc/adt/checkruns":
+		s.serveSyntaxCheck(w, r)
+	case path == "/sap/bc/adt/abapunit/testruns":
+		s.serveUnitTests(w, r)
+	case strings.HasPrefix(path, "/sap/bc/adt/atc/"):
+		s.serveATC(w, r)
+	case strings.HasPrefix(path, "/sap/bc/adt/businessservices/odatav"):
+		s.servePublishJob(w, r)
+	case strings.HasPrefix(path, ui5RepositoryPath):
+		s.serveUI5Repository(w, r)
+	case strings.HasPrefix(path, "/sap/opu/"):
+		s.serveOData(w, r)
+	default:
+		s.serveObject(w, r)
+	}
+}
+
+// nextHandle returns a new unique ID with the given prefix. Callers must hold s.mu.
+func (s *Server) nextHandle(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%08d", prefix, s.nextID)
+}
+
+func isModifying(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeRaw(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+func writeXML(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+// writeException writes an ADT exception document, as SAP does for errors.
+func writeException(w http.ResponseWriter, status int, typ, format string, args ...any) {
+	writeXML(w, status, fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<exc:exception xmlns:exc="http://www.sap.com/abapxml/types/communicationframework">
+  <namespace id="com.sap.adt"/>
+  <type id="%s"/>
+  <message lang="EN">%s</message>
+</exc:exception>`, typ, xmlEscape(fmt.Sprintf(format, args...))))
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '&':
+			sb.WriteString("&amp;")
+		case '<':
+			sb.WriteString("&lt;")
+		case '>':
+			sb.WriteString("&gt;")
+		case '"':
+			sb.WriteString("&quot;")
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
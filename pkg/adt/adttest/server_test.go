@@ -0,0 +1,387 @@
+package adttest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+func newTestClient(t *testing.T) (*Server, *adt.Client) {
+	t.Helper()
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+	return srv, adt.NewClient(srv.URL, "developer", "secret", adt.WithClient("001"))
+}
+
+func TestCreateAndActivateProgram(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+
+	source := "REPORT zhello.\nWRITE 'Hello'.\n"
+	result, err := client.CreateAndActivateProgram(ctx, "ZHELLO", "Hello", "$TMP", source, "")
+	if err != nil {
+		t.Fatalf("CreateAndActivateProgram: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected success, got %s", result.Message)
+	}
+
+	obj, ok := srv.Object("/sap/bc/adt/programs/programs/ZHELLO")
+	if !ok {
+		t.Fatal("program not in repository")
+	}
+	if obj.Inactive || obj.LockHandle != "" || obj.Package != "$TMP" {
+		t.Errorf("unexpected object state: %+v", obj)
+	}
+
+	got, err := client.GetProgram(ctx, "zhello")
+	if err != nil || got != source {
+		t.Errorf("GetProgram = %q, %v", got, err)
+	}
+}
+
+func TestCreateClassWithTests(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+
+	classSource := "CLASS zcl_calc DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_calc IMPLEMENTATION.\nENDCLASS.\n"
+	testSource := `CLASS ltcl_calc DEFINITION FOR TESTING RISK LEVEL HARMLESS DURATION SHORT.
+  PRIVATE SECTION.
+    METHODS: add FOR TESTING,
+             setup,
+             divide FOR TESTING.
+ENDCLASS.
+CLASS ltcl_calc IMPLEMENTATION.
+ENDCLASS.`
+	srv.FailUnitTest("LTCL_CALC", "DIVIDE", "Expected 2, got 3")
+
+	result, err := client.CreateClassWithTests(ctx, "ZCL_CALC", "Calculator", "$TMP", classSource, testSource, "")
+	if err != nil || !result.Success {
+		t.Fatalf("CreateClassWithTests: %v %+v", err, result)
+	}
+	if result.UnitTestResult == nil || len(result.UnitTestResult.Classes) != 1 {
+		t.Fatalf("expected one test class, got %+v", result.UnitTestResult)
+	}
+	methods := result.UnitTestResult.Classes[0].TestMethods
+	if len(methods) != 2 || methods[0].Name != "ADD" || methods[1].Name != "DIVIDE" {
+		t.Fatalf("unexpected test methods: %+v", methods)
+	}
+	if len(methods[0].Alerts) != 0 || len(methods[1].Alerts) != 1 || methods[1].Alerts[0].Title != "Expected 2, got 3" {
+		t.Errorf("unexpected alerts: %+v / %+v", methods[0].Alerts, methods[1].Alerts)
+	}
+
+	include, err := client.GetClassInclude(ctx, "ZCL_CALC", adt.ClassIncludeTestClasses)
+	if err != nil || include != testSource {
+		t.Errorf("GetClassInclude = %q, %v", include, err)
+	}
+}
+
+func TestCSRFTokenRefresh(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+	srv.AddObject(Object{Type: "PROG/P", Name: "ZTEST", Source: "REPORT ztest."})
+	uri := "/sap/bc/adt/programs/programs/ZTEST"
+
+	lock, err := client.LockObject(ctx, uri, "MODIFY")
+	if err != nil {
+		t.Fatalf("LockObject: %v", err)
+	}
+
+	srv.ExpireCSRFToken()
+	if err := client.UpdateSource(ctx, uri+"/source/main", "REPORT ztest.\n* changed", lock.LockHandle, ""); err != nil {
+		t.Fatalf("UpdateSource after token expiry: %v", err)
+	}
+	if n := srv.CountRequests(http.MethodPut, uri); n != 2 {
+		t.Errorf("expected rejected PUT to be retried once, got %d PUTs", n)
+	}
+	if obj, _ := srv.Object(uri); !obj.Inactive || !strings.Contains(obj.Source, "changed") {
+		t.Errorf("expected inactive change, got %+v", obj)
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+	srv.AddObject(Object{Type: "PROG/P", Name: "ZTEST", Source: "REPORT ztest."})
+
+	// Start a session
+	if _, err := client.LockObject(ctx, "/sap/bc/adt/programs/programs/ZTEST", "MODIFY"); err != nil {
+		t.Fatalf("LockObject: %v", err)
+	}
+
+	srv.ExpireSessions()
+	if _, err := client.GetProgram(ctx, "ZTEST"); err != nil {
+		t.Fatalf("GetProgram after session expiry: %v", err)
+	}
+}
+
+func TestLockConflict(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+	srv.AddObject(Object{Type: "PROG/P", Name: "ZTEST", Source: "REPORT ztest."})
+	uri := "/sap/bc/adt/programs/programs/ZTEST"
+
+	if err := srv.LockBy(uri, "colleague"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.LockObject(ctx, uri, "MODIFY")
+	if err == nil || !strings.Contains(err.Error(), "COLLEAGUE is currently editing") {
+		t.Fatalf("expected lock conflict, got %v", err)
+	}
+
+	srv.UnlockBy(uri)
+	if _, err := client.LockObject(ctx, uri, "MODIFY"); err != nil {
+		t.Fatalf("LockObject after UnlockBy: %v", err)
+	}
+
+	// Writing with a wrong handle fails
+	if err := client.UpdateSource(ctx, uri+"/source/main", "REPORT ztest.", "WRONG", ""); err == nil {
+		t.Error("expected invalid lock handle error")
+	}
+}
+
+func TestSyntaxCheckAndActivationErrors(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+	srv.Check = func(uri, source string) []Message {
+		if strings.Contains(source, "WRTE") {
+			return []Message{{Line: 2, Column: 0, Severity: "E", Text: `Statement "WRTE" is not defined.`}}
+		}
+		return nil
+	}
+	srv.AddObject(Object{Type: "PROG/P", Name: "ZTEST", Source: "REPORT ztest."})
+	uri := "/sap/bc/adt/programs/programs/ZTEST"
+
+	results, err := client.SyntaxCheck(ctx, uri, "REPORT ztest.\nWRTE 'x'.")
+	if err != nil {
+		t.Fatalf("SyntaxCheck: %v", err)
+	}
+	if len(results) != 1 || results[0].Line != 2 || results[0].Severity != "E" {
+		t.Fatalf("unexpected syntax check results: %+v", results)
+	}
+
+	lock, _ := client.LockObject(ctx, uri, "MODIFY")
+	client.UpdateSource(ctx, uri+"/source/main", "REPORT ztest.\nWRTE 'x'.", lock.LockHandle, "")
+	client.UnlockObject(ctx, uri, lock.LockHandle)
+
+	activation, err := client.Activate(ctx, uri, "ZTEST")
+	if err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if activation.Success || len(activation.Messages) != 1 || activation.Messages[0].Line != 2 {
+		t.Fatalf("expected failed activation with one message, got %+v", activation)
+	}
+	if obj, _ := srv.Object(uri); !obj.Inactive {
+		t.Error("expected object to stay inactive")
+	}
+}
+
+func TestATCCheck(t *testing.T) {
+	srv, client := newTestClient(t)
+	uri := "/sap/bc/adt/oo/classes/ZCL_TEST"
+	srv.AddObject(Object{Type: "CLAS/OC", Name: "ZCL_TEST", Package: "ZDEV"})
+	srv.AddATCFinding(uri, Finding{CheckID: "CL_CI_TEST_SELECT", CheckTitle: "Performance", MessageTitle: "SELECT in loop", Priority: 2, Line: 12})
+
+	worklist, err := client.RunATCCheck(context.Background(), uri, "", 100)
+	if err != nil {
+		t.Fatalf("RunATCCheck: %v", err)
+	}
+	if len(worklist.Objects) != 1 || len(worklist.Objects[0].Findings) != 1 {
+		t.Fatalf("expected one finding, got %+v", worklist)
+	}
+	f := worklist.Objects[0].Findings[0]
+	if f.Priority != 2 || f.Line != 12 || f.MessageTitle != "SELECT in loop" || worklist.Objects[0].PackageName != "ZDEV" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
+
+func TestSearchAndPackage(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+	srv.AddPackage("ZDEV", "Development", "")
+	srv.AddPackage("ZDEV_SUB", "Sub", "ZDEV")
+	srv.AddObject(Object{Type: "PROG/P", Name: "ZDEV_REPORT", Package: "ZDEV"})
+	srv.AddObject(Object{Type: "CLAS/OC", Name: "ZCL_DEV", Package: "ZDEV", Description: "Class"})
+	srv.AddObject(Object{Type: "PROG/P", Name: "ZOTHER"})
+
+	results, err := client.SearchObject(ctx, "ZDEV*", 10)
+	if err != nil {
+		t.Fatalf("SearchObject: %v", err)
+	}
+	if len(results) != 3 || results[0].Name != "ZDEV" || results[1].Name != "ZDEV_REPORT" {
+		t.Errorf("unexpected search results: %+v", results)
+	}
+
+	pkg, err := client.GetPackage(ctx, "zdev")
+	if err != nil {
+		t.Fatalf("GetPackage: %v", err)
+	}
+	if len(pkg.Objects) != 2 || len(pkg.SubPackages) != 1 || pkg.SubPackages[0] != "ZDEV_SUB" {
+		t.Errorf("unexpected package contents: %+v", pkg)
+	}
+
+	if _, err := client.GetPackage(ctx, "ZMISSING"); err == nil {
+		t.Error("expected error for missing package")
+	}
+}
+
+func TestScriptedFailures(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+	srv.AddObject(Object{Type: "PROG/P", Name: "ZTEST", Source: "REPORT ztest."})
+
+	srv.Fail(Failure{Method: http.MethodGet, Path: "/sap/bc/adt/programs/programs/ZTEST", Status: 500, Body: "dump"})
+	_, err := client.GetProgram(ctx, "ZTEST")
+	var apiErr *adt.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 {
+		t.Fatalf("expected scripted 500, got %v", err)
+	}
+	if _, err := client.GetProgram(ctx, "ZTEST"); err != nil {
+		t.Fatalf("failure should apply once: %v", err)
+	}
+
+	// Creating in a missing package fails before the create request
+	err = client.CreateObject(ctx, adt.CreateObjectOptions{ObjectType: adt.ObjectTypeProgram, Name: "ZNEW", PackageName: "ZMISSING"})
+	if err == nil || srv.CountRequests(http.MethodPost, "/sap/bc/adt/programs/programs") != 0 {
+		t.Errorf("expected package check to fail, got %v", err)
+	}
+}
+
+func TestZADTVSPWebSocket(t *testing.T) {
+	srv, _ := newTestClient(t)
+	ctx := context.Background()
+	srv.HandleWS("report", "run", func(req WSRequest) (any, error) {
+		if req.Params["report"] == "ZFAIL" {
+			return nil, &WSError{Code: "REPORT_ERROR", Message: "report failed"}
+		}
+		return map[string]any{"output": "ok"}, nil
+	})
+
+	ws := adt.NewDebugWebSocketClient(srv.URL, "001", "developer", "secret", false)
+	ws.SetOptions(adt.WebSocketOptions{MaxReconnectAttempts: 3, ReconnectBackoff: 10 * time.Millisecond})
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer ws.Close()
+
+	id, err := ws.SetLineBreakpoint(ctx, "ZTEST", 10)
+	if err != nil || id == "" {
+		t.Fatalf("SetLineBreakpoint = %q, %v", id, err)
+	}
+	bps, err := ws.GetBreakpoints(ctx)
+	if err != nil || len(bps) != 1 {
+		t.Fatalf("GetBreakpoints = %v, %v", bps, err)
+	}
+
+	resp, err := ws.SendDomainRequest(ctx, "report", "run", map[string]any{"report": "ZFAIL"}, time.Second)
+	if err != nil || resp.Success || resp.Error == nil || resp.Error.Code != "REPORT_ERROR" {
+		t.Fatalf("expected REPORT_ERROR, got %+v, %v", resp, err)
+	}
+
+	// Breakpoints are lost with the APC session and restored by the client
+	srv.DropWebSockets()
+	deadline := time.Now().Add(2 * time.Second)
+	for srv.WebSocketConnections() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	bps, err = ws.GetBreakpoints(ctx)
+	if err != nil || len(bps) != 1 {
+		t.Fatalf("GetBreakpoints after reconnect = %v, %v", bps, err)
+	}
+}
+
+func TestZADTVSPHandshake(t *testing.T) {
+	srv, _ := newTestClient(t)
+	ctx := context.Background()
+	srv.SetWSVersions("2.4.0", map[string]string{"system": "2.4.0", "debug": "1.0.0"})
+
+	ws := adt.NewDebugWebSocketClient(srv.URL, "001", "developer", "secret", false)
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer ws.Close()
+
+	h := ws.Handshake()
+	if h == nil || !h.Negotiated() || h.Version != "2.4.0" {
+		t.Fatalf("Handshake = %+v", h)
+	}
+	if !h.Supports("debug", "setBreakpoint") || h.Supports("debug", "teleport") {
+		t.Errorf("unexpected debug actions: %+v", h.Service("debug"))
+	}
+
+	// Unsupported requests fail without reaching the handler
+	_, err := ws.SendDomainRequest(ctx, "report", "runReport", nil, time.Second)
+	var unsupported *adt.VSPUnsupportedError
+	if !errors.As(err, &unsupported) || unsupported.Domain != "report" {
+		t.Fatalf("expected VSPUnsupportedError, got %v", err)
+	}
+
+	got := h.Mismatches(map[string]string{"system": "2.4.0", "debug": "1.1.0", "rfc": "1.0.0"})
+	want := []adt.VSPVersionMismatch{
+		{Domain: "debug", Expected: "1.1.0", Actual: "1.0.0"},
+		{Domain: "rfc", Expected: "1.0.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Mismatches = %+v, want %+v", got, want)
+	}
+
+	if h, err := ws.Hello(ctx); err != nil || h.Service("system") == nil {
+		t.Errorf("Hello = %+v, %v", h, err)
+	}
+}
+
+func TestZADTVSPCustomDomain(t *testing.T) {
+	srv, _ := newTestClient(t)
+	ctx := context.Background()
+	srv.SetWSVersions("2.5.0", map[string]string{"system": "2.5.0", "bal": "1.0.0"})
+	srv.HandleWS("bal", "read", func(req WSRequest) (any, error) {
+		return []map[string]any{{"object": req.Params["object"], "text": "Order saved"}}, nil
+	})
+	srv.SetWSSchema("bal", "read", `{"type":"object","required":["object"],"properties":{"object":{"type":"string"}}}`)
+
+	ws := adt.NewDebugWebSocketClient(srv.URL, "001", "developer", "secret", false)
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer ws.Close()
+
+	info, err := ws.Domain("bal").Describe(ctx)
+	if err != nil {
+		t.Fatalf("Describe: %v", err)
+	}
+	if info.Version != "1.0.0" || info.Action("read") == nil || len(info.Action("read").Schema) == 0 {
+		t.Fatalf("Describe = %+v", info)
+	}
+
+	type query struct {
+		Object string `json:"object"`
+	}
+	type entry struct {
+		Object string `json:"object"`
+		Text   string `json:"text"`
+	}
+	read := adt.DomainCall[query, []entry]{Domain: "bal", Action: "read"}
+	entries, err := read.Do(ctx, ws.BaseWebSocketClient, query{Object: "ZSALES"})
+	if err != nil || len(entries) != 1 || entries[0].Text != "Order saved" {
+		t.Fatalf("Do = %+v, %v", entries, err)
+	}
+
+	// Registered schemas are checked before sending
+	adt.RegisterDomain(*info)
+	var serr *adt.SchemaError
+	if err := ws.Domain("bal").Call(ctx, "read", nil, nil); !errors.As(err, &serr) {
+		t.Fatalf("expected SchemaError, got %v", err)
+	}
+
+	_, err = ws.Domain("idoc").Describe(ctx)
+	var derr *adt.DomainError
+	if !errors.As(err, &derr) || derr.Code != "UNKNOWN_DOMAIN" {
+		t.Fatalf("expected UNKNOWN_DOMAIN, got %v", err)
+	}
+}
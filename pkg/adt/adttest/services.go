@@ -0,0 +1,60 @@
+package adttest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Published returns the protocol ("odatav2" or "odatav4") a service binding
+// is published with, or "" if it is not published.
+func (s *Server) Published(binding string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.services[strings.ToUpper(binding)]
+}
+
+// servePublishJob publishes or unpublishes an active service binding, e.g.
+// POST /sap/bc/adt/businessservices/odatav4/publishjobs?servicename=ZUI_X_O4.
+func (s *Server) servePublishJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeException(w, http.StatusMethodNotAllowed, "ExceptionMethodNotSupported", "Method %s not supported", r.Method)
+		return
+	}
+	protocol := path.Base(path.Dir(strings.ToLower(r.URL.Path)))
+	action := path.Base(strings.ToLower(r.URL.Path))
+	name := strings.ToUpper(r.URL.Query().Get("servicename"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.objects[objectKey(objectPaths["SRVB/SVB"]+"/"+url.PathEscape(name))]
+	switch {
+	case o == nil:
+		writePublishResult(w, "ERROR", fmt.Sprintf("Service binding %s does not exist", name))
+	case len(o.inactive) > 0:
+		writePublishResult(w, "ERROR", fmt.Sprintf("Service binding %s is not active", name))
+	case action == "publishjobs":
+		s.services[name] = protocol
+		writePublishResult(w, "OK", fmt.Sprintf("Local Service Endpoint of %s is activated", name))
+	case action == "unpublishjobs":
+		delete(s.services, name)
+		writePublishResult(w, "OK", fmt.Sprintf("Local Service Endpoint of %s is deactivated", name))
+	default:
+		writeException(w, http.StatusNotFound, "ExceptionResourceNotFound", "Resource %s does not exist", r.URL.Path)
+	}
+}
+
+func writePublishResult(w http.ResponseWriter, severity, text string) {
+	writeXML(w, http.StatusOK, fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<asx:abap xmlns:asx="http://www.sap.com/abapxml" version="1.0">
+  <asx:values>
+    <DATA>
+      <SEVERITY>%s</SEVERITY>
+      <SHORT_TEXT>%s</SHORT_TEXT>
+      <LONG_TEXT/>
+    </DATA>
+  </asx:values>
+</asx:abap>`, severity, xmlEscape(text)))
+}
@@ -0,0 +1,165 @@
+package adttest
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ui5RepositoryPath is the service root of /UI5/ABAP_REPOSITORY_SRV.
+const ui5RepositoryPath = "/sap/opu/odata/ui5/abap_repository_srv/"
+
+// UI5App is a BSP application deployed through the UI5 repository service.
+type UI5App struct {
+	Name        string
+	Package     string
+	Description string
+	Transport   string            // transport request of the last change
+	Files       map[string][]byte // by path in the uploaded ZIP archive
+}
+
+// UI5App returns a deployed BSP application.
+func (s *Server) UI5App(name string) (UI5App, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.ui5Apps[strings.ToUpper(name)]
+	if !ok {
+		return UI5App{}, false
+	}
+	return *app, true
+}
+
+var ui5RepositoryEntity = regexp.MustCompile(`^repositories(?:\('([^']+)'\))?$`)
+
+// serveUI5Repository serves the Repositories entity set of
+// /UI5/ABAP_REPOSITORY_SRV: read, upload (POST creates, PUT replaces) and
+// delete.
+func (s *Server) serveUI5Repository(w http.ResponseWriter, r *http.Request) {
+	rest, _ := url.PathUnescape(r.URL.Path[len(ui5RepositoryPath):])
+	m := ui5RepositoryEntity.FindStringSubmatch(strings.ToLower(rest))
+	if m == nil {
+		writeODataError(w, http.StatusNotFound, "Resource not found for segment '%s'", rest)
+		return
+	}
+	name := strings.ToUpper(m[1])
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app := s.ui5Apps[name]
+	switch {
+	case name != "" && r.Method == http.MethodGet:
+		if app == nil {
+			writeODataError(w, http.StatusNotFound, "Requested repository %s not found", name)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"d": map[string]any{"Name": app.Name, "Package": app.Package, "Description": app.Description}})
+	case name == "" && r.Method == http.MethodPost, name != "" && r.Method == http.MethodPut:
+		upload, err := parseUI5Upload(r.Body)
+		switch {
+		case err != nil:
+			writeODataError(w, http.StatusBadRequest, "Invalid upload: %v", err)
+			return
+		case name != "" && upload.Name != name:
+			writeODataError(w, http.StatusBadRequest, "Name %s does not match the key %s", upload.Name, name)
+			return
+		case r.Method == http.MethodPost && s.ui5Apps[upload.Name] != nil:
+			writeODataError(w, http.StatusBadRequest, "Repository %s already exists", upload.Name)
+			return
+		case r.Method == http.MethodPut && app == nil:
+			writeODataError(w, http.StatusNotFound, "Requested repository %s not found", name)
+			return
+		case s.packages[upload.Package] == nil:
+			writeODataError(w, http.StatusBadRequest, "Package %s does not exist", upload.Package)
+			return
+		case !strings.HasPrefix(upload.Package, "$") && q.Get("TransportRequest") == "":
+			writeODataError(w, http.StatusBadRequest, "Package %s is transportable; specify a transport request", upload.Package)
+			return
+		}
+		upload.Transport = q.Get("TransportRequest")
+		status, verb := http.StatusCreated, "created"
+		if r.Method == http.MethodPut {
+			status, verb = http.StatusNoContent, "updated"
+		}
+		if q.Get("TestMode") == "true" {
+			verb = "checked (test mode)"
+		} else {
+			s.ui5Apps[upload.Name] = upload
+		}
+		w.Header().Set("sap-message", fmt.Sprintf(`{"code":"/UI5/UI5_REP_LOAD/001","message":"Application %s %s","severity":"success","details":[{"code":"/UI5/UI5_REP_LOAD/002","message":"%d files uploaded","severity":"info"}]}`, upload.Name, verb, len(upload.Files)))
+		if status == http.StatusNoContent {
+			w.WriteHeader(status)
+			return
+		}
+		writeJSON(w, status, map[string]any{"d": map[string]any{"Name": upload.Name, "Package": upload.Package, "Description": upload.Description}})
+	case name != "" && r.Method == http.MethodDelete:
+		if app == nil {
+			writeODataError(w, http.StatusNotFound, "Requested repository %s not found", name)
+			return
+		}
+		if !strings.HasPrefix(app.Package, "$") && q.Get("TransportRequest") == "" {
+			writeODataError(w, http.StatusBadRequest, "Package %s is transportable; specify a transport request", app.Package)
+			return
+		}
+		delete(s.ui5Apps, name)
+		w.Header().Set("sap-message", fmt.Sprintf(`{"code":"/UI5/UI5_REP_LOAD/003","message":"Application %s deleted","severity":"success","details":[]}`, name))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeODataError(w, http.StatusMethodNotAllowed, "Method %s not allowed", r.Method)
+	}
+}
+
+// parseUI5Upload reads the Atom entry of an upload and unpacks its archive.
+func parseUI5Upload(body io.Reader) (*UI5App, error) {
+	var entry struct {
+		Properties struct {
+			Name        string `xml:"Name"`
+			Package     string `xml:"Package"`
+			Description string `xml:"Description"`
+			ZipArchive  string `xml:"ZipArchive"`
+		} `xml:"content>properties"`
+	}
+	if err := xml.NewDecoder(body).Decode(&entry); err != nil {
+		return nil, err
+	}
+	p := entry.Properties
+	data, err := base64.StdEncoding.DecodeString(p.ZipArchive)
+	if err != nil {
+		return nil, fmt.Errorf("ZipArchive: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("ZipArchive: %v", err)
+	}
+	app := &UI5App{Name: strings.ToUpper(p.Name), Package: strings.ToUpper(p.Package), Description: p.Description, Files: make(map[string][]byte)}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		app.Files[f.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return app, nil
+}
+
+// writeODataError writes an OData V2 JSON error.
+func writeODataError(w http.ResponseWriter, status int, format string, args ...any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{
+		"code":    "/UI5/UI5_REP_LOAD/000",
+		"message": map[string]any{"lang": "en", "value": fmt.Sprintf(format, args...)},
+	}})
+}
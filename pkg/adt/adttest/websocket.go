@@ -0,0 +1,306 @@
+package adttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const wsPath = "/sap/bc/apc/sap/zadt_vsp"
+
+// WSRequest is a ZADT_VSP request received over the WebSocket.
+type WSRequest struct {
+	ID      string         `json:"id"`
+	Domain  string         `json:"domain"`
+	Action  string         `json:"action"`
+	Params  map[string]any `json:"params,omitempty"`
+	Session string         `json:"-"` // session ID of the connection
+}
+
+// WSError is returned by a WSHandler to answer with a specific error code.
+type WSError struct {
+	Code    string
+	Message string
+}
+
+func (e *WSError) Error() string { return e.Code + ": " + e.Message }
+
+// WSHandler answers a ZADT_VSP request. The result is sent as the response
+// data; an error makes the response unsuccessful.
+type WSHandler func(req WSRequest) (any, error)
+
+// wsEndpoint is the fake ZADT_VSP APC service. Like APC, it handles one
+// request at a time per connection. Breakpoints belong to the connection and
+// are deleted when it closes.
+type wsEndpoint struct {
+	mu          sync.Mutex
+	handlers    map[string]WSHandler // "domain/action"
+	conns       map[*websocket.Conn]string
+	sessions    int
+	breakpoints map[string]map[string]map[string]any // session -> ID -> params
+	nextBP      int
+	version     string
+	versions    map[string]string // domain -> version; nil before 2.4.0
+	schemas     map[string]string // "domain/action" -> JSON schema of params
+}
+
+func newWSEndpoint() *wsEndpoint {
+	e := &wsEndpoint{
+		handlers:    make(map[string]WSHandler),
+		conns:       make(map[*websocket.Conn]string),
+		breakpoints: make(map[string]map[string]map[string]any),
+		version:     "mock",
+		schemas:     make(map[string]string),
+	}
+	e.handlers["system/ping"] = func(WSRequest) (any, error) {
+		return map[string]any{"pong": true, "timestamp": time.Now().Unix()}, nil
+	}
+	e.handlers["system/hello"] = func(req WSRequest) (any, error) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.handshake(req.Session), nil
+	}
+	e.handlers["system/describe"] = e.describe
+	e.handlers["debug/setBreakpoint"] = e.setBreakpoint
+	e.handlers["debug/getBreakpoints"] = e.getBreakpoints
+	e.handlers["debug/deleteBreakpoint"] = e.deleteBreakpoint
+	e.handlers["debug/getStatus"] = func(req WSRequest) (any, error) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return map[string]any{"attached": false, "session": req.Session, "breakpoints": len(e.breakpoints[req.Session])}, nil
+	}
+	return e
+}
+
+// HandleWS registers a handler for a ZADT_VSP domain action, replacing the
+// built-in one. Built in are system/ping, system/hello, system/describe and
+// the debug breakpoint actions
+// (setBreakpoint, getBreakpoints, deleteBreakpoint, getStatus); other actions
+// fail with UNKNOWN_ACTION.
+func (s *Server) HandleWS(domain, action string, h WSHandler) {
+	s.ws.mu.Lock()
+	defer s.ws.mu.Unlock()
+	s.ws.handlers[domain+"/"+action] = h
+}
+
+// SetWSVersions makes the handler report version and, by domain, the service
+// versions in its handshake, together with the actions of the registered
+// handlers. Domains without a version are reported without services, like
+// handlers before 2.4.0. It applies to new connections.
+func (s *Server) SetWSVersions(version string, services map[string]string) {
+	s.ws.mu.Lock()
+	defer s.ws.mu.Unlock()
+	s.ws.version = version
+	s.ws.versions = services
+}
+
+// SetWSSchema sets the JSON schema that system/describe reports for the
+// params of a domain action.
+func (s *Server) SetWSSchema(domain, action, schema string) {
+	s.ws.mu.Lock()
+	defer s.ws.mu.Unlock()
+	s.ws.schemas[domain+"/"+action] = schema
+}
+
+// DropWebSockets closes all ZADT_VSP connections, as when the APC session
+// ends on the SAP side.
+func (s *Server) DropWebSockets() {
+	s.ws.closeAll()
+}
+
+// WebSocketConnections returns the number of ZADT_VSP connections accepted so far.
+func (s *Server) WebSocketConnections() int {
+	s.ws.mu.Lock()
+	defer s.ws.mu.Unlock()
+	return s.ws.sessions
+}
+
+func (e *wsEndpoint) serve(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	e.sessions++
+	session := fmt.Sprintf("MOCK%04d", e.sessions)
+	e.conns[conn] = session
+	welcome := e.handshake(session)
+	e.mu.Unlock()
+
+	defer func() {
+		e.mu.Lock()
+		delete(e.conns, conn)
+		delete(e.breakpoints, session)
+		e.mu.Unlock()
+		conn.Close()
+	}()
+
+	conn.WriteJSON(map[string]any{"id": "welcome", "success": true, "data": welcome})
+
+	for {
+		var req WSRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		req.Session = session
+
+		e.mu.Lock()
+		h := e.handlers[req.Domain+"/"+req.Action]
+		e.mu.Unlock()
+
+		resp := map[string]any{"id": req.ID, "success": true}
+		var data any
+		if h == nil {
+			err = &WSError{Code: "UNKNOWN_ACTION", Message: fmt.Sprintf("unknown action %s/%s", req.Domain, req.Action)}
+		} else {
+			data, err = h(req)
+		}
+		if err != nil {
+			code := "ERROR"
+			if wsErr, ok := err.(*WSError); ok {
+				code, err = wsErr.Code, fmt.Errorf("%s", wsErr.Message)
+			}
+			resp["success"] = false
+			resp["error"] = map[string]any{"code": code, "message": err.Error()}
+		} else if data != nil {
+			raw, err := json.Marshal(data)
+			if err != nil {
+				return
+			}
+			resp["data"] = json.RawMessage(raw)
+		}
+		if err := conn.WriteJSON(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handshake builds the welcome data. The caller holds e.mu.
+func (e *wsEndpoint) handshake(session string) map[string]any {
+	actions := make(map[string][]string)
+	for key := range e.handlers {
+		domain, action, _ := strings.Cut(key, "/")
+		actions[domain] = append(actions[domain], action)
+	}
+	var names []string
+	var services []map[string]any
+	for d := range actions {
+		names = append(names, d)
+	}
+	sort.Strings(names)
+	for _, d := range names {
+		if v, ok := e.versions[d]; ok {
+			sort.Strings(actions[d])
+			services = append(services, map[string]any{"domain": d, "version": v, "actions": actions[d]})
+		}
+	}
+	data := map[string]any{"session": session, "version": e.version, "domains": names}
+	if services != nil {
+		data["services"] = services
+	}
+	return data
+}
+
+// describe answers system/describe from the registered handlers and schemas.
+func (e *wsEndpoint) describe(req WSRequest) (any, error) {
+	domain, _ := req.Params["domain"].(string)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var names []string
+	for key := range e.handlers {
+		if d, action, _ := strings.Cut(key, "/"); d == domain && d != "system" {
+			names = append(names, action)
+		}
+	}
+	if len(names) == 0 {
+		return nil, &WSError{Code: "UNKNOWN_DOMAIN", Message: fmt.Sprintf("Domain '%s' not found", domain)}
+	}
+	sort.Strings(names)
+
+	actions := make([]map[string]any, len(names))
+	for i, name := range names {
+		var schema json.RawMessage
+		if sch, ok := e.schemas[domain+"/"+name]; ok {
+			schema = json.RawMessage(sch)
+		}
+		actions[i] = map[string]any{"name": name, "description": "", "schema": schema}
+	}
+	return map[string]any{"domain": domain, "version": e.versions[domain], "actions": actions}, nil
+}
+
+func (e *wsEndpoint) closeAll() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for conn := range e.conns {
+		conn.Close()
+	}
+}
+
+func (e *wsEndpoint) setBreakpoint(req WSRequest) (any, error) {
+	kind, _ := req.Params["kind"].(string)
+	switch kind {
+	case "", "line":
+		if req.Params["program"] == nil && req.Params["uri"] == nil {
+			return nil, &WSError{Code: "INVALID_PARAMS", Message: "program or uri is required"}
+		}
+	case "exception":
+		if req.Params["exception"] == nil {
+			return nil, &WSError{Code: "INVALID_PARAMS", Message: "exception is required"}
+		}
+	case "statement":
+		if req.Params["statement"] == nil {
+			return nil, &WSError{Code: "INVALID_PARAMS", Message: "statement is required"}
+		}
+	default:
+		return nil, &WSError{Code: "INVALID_PARAMS", Message: "unsupported breakpoint kind " + kind}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.nextBP++
+	id := fmt.Sprintf("BP%04d", e.nextBP)
+	if e.breakpoints[req.Session] == nil {
+		e.breakpoints[req.Session] = make(map[string]map[string]any)
+	}
+	e.breakpoints[req.Session][id] = req.Params
+	return map[string]any{"breakpointId": id, "registered": true}, nil
+}
+
+func (e *wsEndpoint) getBreakpoints(req WSRequest) (any, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var ids []string
+	for id := range e.breakpoints[req.Session] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	bps := []map[string]any{}
+	for _, id := range ids {
+		bp := map[string]any{"id": id}
+		for k, v := range e.breakpoints[req.Session][id] {
+			bp[k] = v
+		}
+		bps = append(bps, bp)
+	}
+	return map[string]any{"breakpoints": bps}, nil
+}
+
+func (e *wsEndpoint) deleteBreakpoint(req WSRequest) (any, error) {
+	id, _ := req.Params["breakpointId"].(string)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.breakpoints[req.Session][id]; !ok {
+		return nil, &WSError{Code: "NOT_FOUND", Message: "breakpoint " + id + " not found"}
+	}
+	delete(e.breakpoints[req.Session], id)
+	return map[string]any{"deleted": true}, nil
+}
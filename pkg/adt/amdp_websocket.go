@@ -52,6 +52,9 @@ func NewAMDPWebSocketClient(baseURL, client, user, password string, insecure boo
 
 // sendRequest sends a request to the amdp domain and waits for response.
 func (c *AMDPWebSocketClient) sendRequest(ctx context.Context, action string, params map[string]any) (*WSResponse, error) {
+	if err := c.checkSafety(OpDebug, "amdp/"+action); err != nil {
+		return nil, err
+	}
 	return c.SendDomainRequest(ctx, "amdp", action, params, 60*time.Second)
 }
 
@@ -0,0 +1,553 @@
+package adt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2 grant types supported by OAuthTokenSource.
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantAuthorizationCode = "authorization_code"
+)
+
+// TokenSource supplies OAuth2 access tokens. When Config.TokenSource is set,
+// requests carry "Authorization: Bearer <token>" instead of basic auth.
+type TokenSource interface {
+	// Token returns a valid access token, fetching or refreshing it if needed.
+	Token(ctx context.Context) (*OAuthToken, error)
+}
+
+// OAuthToken is an OAuth2 token response.
+type OAuthToken struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresIn    int       `json:"expires_in,omitempty"` // seconds, as returned by the server
+	Expiry       time.Time `json:"expiry,omitempty"`     // computed from ExpiresIn
+}
+
+// Valid reports whether the access token is present and not about to expire.
+func (t *OAuthToken) Valid() bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Until(t.Expiry) > 30*time.Second)
+}
+
+// OAuthConfig configures OAuth2 authentication, e.g. against the XSUAA of an
+// SAP BTP ABAP Environment (see ServiceKey.OAuthConfig) or an on-premise
+// system's OAuth server.
+type OAuthConfig struct {
+	// GrantType is GrantClientCredentials (technical user) or
+	// GrantAuthorizationCode (interactive login in the browser; this is how
+	// SSO/SAML identity providers are used). Default: authorization code.
+	GrantType    string   `json:"grant_type,omitempty"`
+	TokenURL     string   `json:"token_url"`
+	AuthURL      string   `json:"auth_url,omitempty"` // authorization code only
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+
+	// RedirectPort is the local port of the browser callback
+	// (http://localhost:<port>/callback). 0 picks a free port.
+	RedirectPort int `json:"redirect_port,omitempty"`
+
+	// TokenCacheFile, if set, keeps the token (including the refresh token)
+	// between runs, so the browser login is needed only once.
+	TokenCacheFile string `json:"token_cache_file,omitempty"`
+
+	// OpenBrowser opens the login page. Default: print the URL to stderr and
+	// try the platform's browser opener.
+	OpenBrowser func(url string) error `json:"-"`
+
+	// HTTPClient sends token requests. Default: a client honouring the proxy
+	// environment.
+	HTTPClient HTTPDoer `json:"-"`
+}
+
+// OAuthError is an error response from the token endpoint.
+type OAuthError struct {
+	StatusCode  int
+	Code        string // e.g. "invalid_client", "invalid_grant"
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("OAuth error %s (HTTP %d): %s", e.Code, e.StatusCode, e.Description)
+	}
+	return fmt.Sprintf("OAuth error %s (HTTP %d)", e.Code, e.StatusCode)
+}
+
+// OAuthTokenSource is a TokenSource for OAuthConfig. It caches the token,
+// refreshes it with the refresh token when it expires, and falls back to a
+// new grant (client credentials or browser login) when refreshing fails.
+type OAuthTokenSource struct {
+	cfg OAuthConfig
+
+	mu    sync.Mutex
+	token *OAuthToken // replaced, never modified: callers keep using it unlocked
+	fetch *tokenFetch // refresh or login in progress
+}
+
+// tokenFetch is a token request shared by concurrent Token calls. The lock is
+// not held while it runs, as a browser login can take minutes.
+type tokenFetch struct {
+	done  chan struct{}
+	token *OAuthToken
+	err   error
+}
+
+// NewOAuthTokenSource returns a token source for cfg. No request is made
+// until the first Token call.
+func NewOAuthTokenSource(cfg OAuthConfig) *OAuthTokenSource {
+	if cfg.GrantType == "" {
+		cfg.GrantType = GrantAuthorizationCode
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 60 * time.Second, Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}}
+	}
+	return &OAuthTokenSource{cfg: cfg}
+}
+
+// Token implements TokenSource. Concurrent calls that need a new token wait
+// for the same refresh or login.
+func (s *OAuthTokenSource) Token(ctx context.Context) (*OAuthToken, error) {
+	s.mu.Lock()
+	if s.token == nil && s.cfg.TokenCacheFile != "" {
+		s.token = loadCachedToken(s.cfg.TokenCacheFile)
+	}
+	if tok := s.token; tok.Valid() {
+		s.mu.Unlock()
+		return tok, nil
+	}
+	if f := s.fetch; f != nil {
+		s.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.token, f.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	f := &tokenFetch{done: make(chan struct{})}
+	s.fetch = f
+	current := s.token
+	s.mu.Unlock()
+
+	f.token, f.err = s.newToken(ctx, current)
+	s.mu.Lock()
+	if f.err == nil {
+		s.token = f.token
+	}
+	s.fetch = nil
+	s.mu.Unlock()
+	close(f.done)
+	return f.token, f.err
+}
+
+// newToken refreshes current, or requests a new grant if that is not
+// possible, and caches the result.
+func (s *OAuthTokenSource) newToken(ctx context.Context, current *OAuthToken) (*OAuthToken, error) {
+	var tok *OAuthToken
+	var err error
+	if current != nil && current.RefreshToken != "" {
+		tok, err = s.refresh(ctx, current.RefreshToken)
+	}
+	if tok == nil {
+		switch s.cfg.GrantType {
+		case GrantClientCredentials:
+			tok, err = s.clientCredentials(ctx)
+		case GrantAuthorizationCode:
+			tok, err = s.authorize(ctx)
+		default:
+			err = fmt.Errorf("unsupported OAuth grant type %q", s.cfg.GrantType)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cfg.TokenCacheFile != "" {
+		if err := saveCachedToken(s.cfg.TokenCacheFile, tok); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not cache OAuth token: %v\n", err)
+		}
+	}
+	return tok, nil
+}
+
+// Invalidate discards the access token, e.g. after the server rejected it
+// with 401. The next Token call refreshes it.
+func (s *OAuthTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != nil {
+		tok := *s.token
+		tok.AccessToken = ""
+		s.token = &tok
+	}
+}
+
+func (s *OAuthTokenSource) clientCredentials(ctx context.Context) (*OAuthToken, error) {
+	form := url.Values{"grant_type": {GrantClientCredentials}}
+	if len(s.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	return s.tokenRequest(ctx, form)
+}
+
+func (s *OAuthTokenSource) refresh(ctx context.Context, refreshToken string) (*OAuthToken, error) {
+	tok, err := s.tokenRequest(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		var oauthErr *OAuthError
+		if errors.As(err, &oauthErr) {
+			return nil, nil // refresh token expired or revoked: start over
+		}
+		return nil, err
+	}
+	if tok.RefreshToken == "" {
+		tok.RefreshToken = refreshToken
+	}
+	return tok, nil
+}
+
+// authorize runs the authorization code flow with PKCE: it opens the login
+// page in the browser and waits for the redirect to a local callback server.
+func (s *OAuthTokenSource) authorize(ctx context.Context) (*OAuthToken, error) {
+	if s.cfg.AuthURL == "" {
+		return nil, fmt.Errorf("OAuth authorization URL is required for the authorization code grant")
+	}
+
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", s.cfg.RedirectPort))
+	if err != nil {
+		return nil, fmt.Errorf("starting OAuth callback server: %w", err)
+	}
+	defer ln.Close()
+	redirectURI := fmt.Sprintf("http://localhost:%d/callback", ln.Addr().(*net.TCPAddr).Port)
+
+	state := randomString(16)
+	verifier := randomString(32)
+	challenge := sha256.Sum256([]byte(verifier))
+
+	authURL, err := url.Parse(s.cfg.AuthURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAuth authorization URL: %w", err)
+	}
+	q := authURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", s.cfg.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	if len(s.cfg.Scopes) > 0 {
+		q.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	authURL.RawQuery = q.Encode()
+
+	type callback struct {
+		code string
+		err  error
+	}
+	result := make(chan callback, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		var cb callback
+		switch {
+		case q.Get("state") != state:
+			cb.err = fmt.Errorf("OAuth callback with unexpected state")
+		case q.Get("error") != "":
+			cb.err = &OAuthError{StatusCode: http.StatusBadRequest, Code: q.Get("error"), Description: q.Get("error_description")}
+		default:
+			cb.code = q.Get("code")
+		}
+		if cb.err != nil {
+			http.Error(w, "Login failed: "+cb.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprint(w, "<html><body><h3>vsp: login complete</h3>You can close this window.</body></html>")
+		}
+		select {
+		case result <- cb:
+		default:
+		}
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	open := s.cfg.OpenBrowser
+	if open == nil {
+		open = openBrowser
+	}
+	if err := open(authURL.String()); err != nil {
+		return nil, fmt.Errorf("opening browser for OAuth login: %w", err)
+	}
+
+	var cb callback
+	select {
+	case cb = <-result:
+	case <-time.After(5 * time.Minute):
+		return nil, fmt.Errorf("OAuth login timed out after 5 minutes")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if cb.err != nil {
+		return nil, cb.err
+	}
+
+	return s.tokenRequest(ctx, url.Values{
+		"grant_type":    {GrantAuthorizationCode},
+		"code":          {cb.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+}
+
+// tokenRequest posts form to the token endpoint, authenticating the client
+// with basic auth (or client_id in the form for public clients).
+func (s *OAuthTokenSource) tokenRequest(ctx context.Context, form url.Values) (*OAuthToken, error) {
+	if s.cfg.TokenURL == "" {
+		return nil, fmt.Errorf("OAuth token URL is required")
+	}
+	if s.cfg.ClientSecret == "" {
+		form.Set("client_id", s.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.cfg.ClientSecret != "" {
+		req.SetBasicAuth(s.cfg.ClientID, s.cfg.ClientSecret)
+	}
+
+	resp, err := s.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting OAuth token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading OAuth token: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &e) != nil || e.Error == "" {
+			e.Error, e.Description = "server_error", strings.TrimSpace(string(body))
+		}
+		return nil, &OAuthError{StatusCode: resp.StatusCode, Code: e.Error, Description: e.Description}
+	}
+
+	var tok OAuthToken
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, fmt.Errorf("parsing OAuth token: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return nil, fmt.Errorf("OAuth token response without access_token")
+	}
+	if tok.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+	return &tok, nil
+}
+
+// DefaultTokenCacheFile returns ~/.vsp/oauth/<hash>.json for a client ID and
+// token URL.
+func DefaultTokenCacheFile(clientID, tokenURL string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(clientID + "\x00" + tokenURL))
+	return filepath.Join(home, ".vsp", "oauth", hex.EncodeToString(sum[:8])+".json")
+}
+
+func loadCachedToken(path string) *OAuthToken {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var tok OAuthToken
+	if json.Unmarshal(data, &tok) != nil {
+		return nil
+	}
+	return &tok
+}
+
+func saveCachedToken(path string, tok *OAuthToken) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(tok, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// openBrowser prints url and tries to open it in the default browser.
+func openBrowser(url string) error {
+	fmt.Fprintf(os.Stderr, "Log in to SAP in your browser:\n  %s\n", url)
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	_ = cmd.Start() // the printed URL is the fallback
+	return nil
+}
+
+// ServiceKey is the service key of an SAP BTP ABAP Environment instance, as
+// downloaded from the BTP cockpit or "cf service-key".
+type ServiceKey struct {
+	URL       string            `json:"url"`
+	SystemID  string            `json:"systemid,omitempty"`
+	Endpoints map[string]string `json:"endpoints,omitempty"`
+	UAA       struct {
+		URL          string `json:"url"`
+		ClientID     string `json:"clientid"`
+		ClientSecret string `json:"clientsecret"`
+	} `json:"uaa"`
+}
+
+// LoadServiceKey reads a service key JSON file.
+func LoadServiceKey(path string) (*ServiceKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseServiceKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParseServiceKey parses a service key. Keys wrapped in {"credentials": ...}
+// (cf CLI v8 output) are accepted too.
+func ParseServiceKey(data []byte) (*ServiceKey, error) {
+	var wrapped struct {
+		Credentials json.RawMessage `json:"credentials"`
+	}
+	if json.Unmarshal(data, &wrapped) == nil && len(wrapped.Credentials) > 0 {
+		data = wrapped.Credentials
+	}
+	var key ServiceKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("parsing service key: %w", err)
+	}
+	if key.SystemURL() == "" || key.UAA.URL == "" || key.UAA.ClientID == "" {
+		return nil, fmt.Errorf("service key is missing url, uaa.url or uaa.clientid")
+	}
+	return &key, nil
+}
+
+// SystemURL returns the ABAP system URL of the key.
+func (k *ServiceKey) SystemURL() string {
+	if u := k.Endpoints["abap"]; u != "" {
+		return u
+	}
+	return k.URL
+}
+
+// OAuthConfig returns the OAuth settings for developer access: the
+// authorization code grant against the instance's XSUAA. The login page of
+// the configured identity provider (SSO/SAML) opens in the browser.
+func (k *ServiceKey) OAuthConfig() OAuthConfig {
+	uaa := strings.TrimSuffix(k.UAA.URL, "/")
+	return OAuthConfig{
+		GrantType:      GrantAuthorizationCode,
+		TokenURL:       uaa + "/oauth/token",
+		AuthURL:        uaa + "/oauth/authorize",
+		ClientID:       k.UAA.ClientID,
+		ClientSecret:   k.UAA.ClientSecret,
+		TokenCacheFile: DefaultTokenCacheFile(k.UAA.ClientID, uaa),
+	}
+}
+
+// TLSConfig returns the TLS settings for HTTP and WebSocket connections:
+// certificate verification (InsecureSkipVerify) and, with ClientCertFile and
+// ClientKeyFile, the client certificate for mutual TLS. The certificate is
+// read at each handshake, so renewed certificates are picked up.
+func (c *Config) TLSConfig() *tls.Config {
+	cfg := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.ClientCertFile != "" {
+		certFile, keyFile := c.ClientCertFile, c.ClientKeyFile
+		if keyFile == "" {
+			keyFile = certFile // PEM with certificate and key
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("loading client certificate: %w", err)
+			}
+			return &cert, nil
+		}
+	}
+	return cfg
+}
+
+// authenticate sets the credentials of a request: a bearer token from the
+// token source, or basic auth, plus the configured cookies.
+func (t *Transport) authenticate(ctx context.Context, req *http.Request) error {
+	if t.config.TokenSource != nil {
+		tok, err := t.config.TokenSource.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("getting OAuth token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	} else if t.config.HasBasicAuth() {
+		req.SetBasicAuth(t.config.Username, t.config.Password)
+	}
+	for name, value := range t.config.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	return nil
+}
+
+// invalidateToken discards a rejected access token. It reports whether the
+// token source can supply a new one.
+func (t *Transport) invalidateToken() bool {
+	inv, ok := t.config.TokenSource.(interface{ Invalidate() })
+	if ok {
+		inv.Invalidate()
+	}
+	return ok
+}
@@ -0,0 +1,451 @@
+package adt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeTokenServer is an OAuth2 token endpoint issuing numbered tokens.
+type fakeTokenServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	grants   []string
+	forms    []url.Values
+	issued   int
+	expires  int  // expires_in of issued tokens
+	denyNext bool // answer the next refresh with invalid_grant
+}
+
+func newFakeTokenServer(t *testing.T) *fakeTokenServer {
+	f := &fakeTokenServer{expires: 3600}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.grants = append(f.grants, r.PostForm.Get("grant_type"))
+		f.forms = append(f.forms, r.PostForm)
+
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client" || secret != "s3cr$t=" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		if r.PostForm.Get("grant_type") == "refresh_token" && f.denyNext {
+			f.denyNext = false
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "refresh token expired"})
+			return
+		}
+		f.issued++
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("access%d", f.issued),
+			"token_type":    "bearer",
+			"refresh_token": fmt.Sprintf("refresh%d", f.issued),
+			"expires_in":    f.expires,
+		})
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeTokenServer) grantTypes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.grants...)
+}
+
+func TestOAuth_ClientCredentialsWithRefreshOn401(t *testing.T) {
+	tokens := newFakeTokenServer(t)
+	mock := &mockHTTPClient{responses: []*http.Response{
+		newMockResponse(200, "OK", nil),
+		newMockResponse(401, "token revoked", nil),
+		newMockResponse(200, "OK", nil),
+	}}
+	cfg := NewConfig("https://sap.example.com", "", "", WithOAuth(OAuthConfig{
+		GrantType:    GrantClientCredentials,
+		TokenURL:     tokens.URL,
+		ClientID:     "client",
+		ClientSecret: "s3cr$t=",
+		Scopes:       []string{"ADT"},
+	}))
+	transport := NewTransportWithClient(cfg, mock)
+
+	for i := 0; i < 2; i++ {
+		if _, err := transport.Request(context.Background(), "/sap/bc/adt/test", nil); err != nil {
+			t.Fatalf("Request %d: %v", i, err)
+		}
+	}
+
+	var auth []string
+	for _, req := range mock.requests {
+		auth = append(auth, req.Header.Get("Authorization"))
+	}
+	want := []string{"Bearer access1", "Bearer access1", "Bearer access2"}
+	if strings.Join(auth, ",") != strings.Join(want, ",") {
+		t.Errorf("Authorization headers = %v, want %v", auth, want)
+	}
+	// The rejected token is refreshed with its refresh token.
+	if grants := tokens.grantTypes(); strings.Join(grants, ",") != "client_credentials,refresh_token" {
+		t.Errorf("grants = %v", grants)
+	}
+	if scope := tokens.forms[0].Get("scope"); scope != "ADT" {
+		t.Errorf("scope = %q", scope)
+	}
+}
+
+func TestOAuth_RefreshExpiredToken(t *testing.T) {
+	tokens := newFakeTokenServer(t)
+	tokens.expires = 1 // within the expiry margin: refreshed on every use
+	ts := NewOAuthTokenSource(OAuthConfig{GrantType: GrantClientCredentials, TokenURL: tokens.URL, ClientID: "client", ClientSecret: "s3cr$t="})
+	ctx := context.Background()
+
+	tok, err := ts.Token(ctx)
+	if err != nil || tok.AccessToken != "access1" {
+		t.Fatalf("first token = %+v, %v", tok, err)
+	}
+	tok, err = ts.Token(ctx)
+	if err != nil || tok.AccessToken != "access2" {
+		t.Fatalf("refreshed token = %+v, %v", tok, err)
+	}
+	if got := tokens.forms[1].Get("refresh_token"); got != "refresh1" {
+		t.Errorf("refresh_token = %q, want refresh1", got)
+	}
+
+	// A rejected refresh token falls back to a new grant.
+	tokens.denyNext = true
+	tok, err = ts.Token(ctx)
+	if err != nil || tok.AccessToken != "access3" {
+		t.Fatalf("token after failed refresh = %+v, %v", tok, err)
+	}
+	if grants := tokens.grantTypes(); strings.Join(grants, ",") != "client_credentials,refresh_token,refresh_token,client_credentials" {
+		t.Errorf("grants = %v", grants)
+	}
+}
+
+func TestOAuth_ConcurrentTokenAndInvalidate(t *testing.T) {
+	tokens := newFakeTokenServer(t)
+	ts := NewOAuthTokenSource(OAuthConfig{GrantType: GrantClientCredentials, TokenURL: tokens.URL, ClientID: "client", ClientSecret: "s3cr$t="})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				tok, err := ts.Token(ctx)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				// Read the token without the lock, like Transport.authenticate
+				if !strings.HasPrefix(tok.AccessToken, "access") {
+					t.Errorf("AccessToken = %q", tok.AccessToken)
+					return
+				}
+				if j%5 == 0 {
+					ts.Invalidate()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOAuth_LoginDoesNotHoldLock(t *testing.T) {
+	tokens := newFakeTokenServer(t)
+	opened := make(chan struct{})
+	release := make(chan struct{})
+	var logins int
+	ts := NewOAuthTokenSource(OAuthConfig{
+		TokenURL:     tokens.URL,
+		AuthURL:      "https://idp.example.com/oauth/authorize",
+		ClientID:     "client",
+		ClientSecret: "s3cr$t=",
+		OpenBrowser: func(loginURL string) error {
+			logins++
+			close(opened)
+			<-release
+			u, _ := url.Parse(loginURL)
+			resp, err := http.Get(u.Query().Get("redirect_uri") + "?code=CODE&state=" + url.QueryEscape(u.Query().Get("state")))
+			if err == nil {
+				resp.Body.Close()
+			}
+			return err
+		},
+	})
+
+	results := make(chan string, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			tok, err := ts.Token(context.Background())
+			if err != nil {
+				results <- err.Error()
+				return
+			}
+			results <- tok.AccessToken
+		}()
+	}
+	<-opened
+
+	// Invalidate and cancelled callers are not blocked by the login
+	ts.Invalidate()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := ts.Token(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Token during login = %v, want deadline exceeded", err)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if got := <-results; got != "access1" {
+			t.Errorf("Token = %q, want access1", got)
+		}
+	}
+	if logins != 1 {
+		t.Errorf("expected one browser login, got %d", logins)
+	}
+}
+
+func TestOAuth_InvalidClient(t *testing.T) {
+	tokens := newFakeTokenServer(t)
+	ts := NewOAuthTokenSource(OAuthConfig{GrantType: GrantClientCredentials, TokenURL: tokens.URL, ClientID: "client", ClientSecret: "wrong"})
+	_, err := ts.Token(context.Background())
+	oauthErr, ok := err.(*OAuthError)
+	if !ok || oauthErr.Code != "invalid_client" || oauthErr.StatusCode != 401 {
+		t.Errorf("err = %v, want invalid_client", err)
+	}
+}
+
+func TestOAuth_AuthorizationCode(t *testing.T) {
+	tokens := newFakeTokenServer(t)
+	cacheFile := filepath.Join(t.TempDir(), "oauth", "token.json")
+
+	var challenge string
+	browser := func(loginURL string) error {
+		u, err := url.Parse(loginURL)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		if q.Get("response_type") != "code" || q.Get("client_id") != "client" || q.Get("code_challenge_method") != "S256" {
+			return fmt.Errorf("unexpected login URL %s", loginURL)
+		}
+		challenge = q.Get("code_challenge")
+		// The identity provider redirects the browser back with a code.
+		resp, err := http.Get(q.Get("redirect_uri") + "?code=CODE&state=" + url.QueryEscape(q.Get("state")))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+	cfg := OAuthConfig{
+		TokenURL:       tokens.URL,
+		AuthURL:        "https://idp.example.com/oauth/authorize",
+		ClientID:       "client",
+		ClientSecret:   "s3cr$t=",
+		TokenCacheFile: cacheFile,
+		OpenBrowser:    browser,
+	}
+
+	tok, err := NewOAuthTokenSource(cfg).Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if tok.AccessToken != "access1" {
+		t.Errorf("AccessToken = %q", tok.AccessToken)
+	}
+	form := tokens.forms[0]
+	if form.Get("grant_type") != GrantAuthorizationCode || form.Get("code") != "CODE" {
+		t.Errorf("token request = %v", form)
+	}
+	sum := sha256.Sum256([]byte(form.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+		t.Error("code_verifier does not match code_challenge")
+	}
+	if info, err := os.Stat(cacheFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("token cache: %v %v", info, err)
+	}
+
+	// The cached token is reused without another login.
+	cfg.OpenBrowser = func(string) error { return fmt.Errorf("browser opened again") }
+	tok, err = NewOAuthTokenSource(cfg).Token(context.Background())
+	if err != nil || tok.AccessToken != "access1" {
+		t.Errorf("cached token = %+v, %v", tok, err)
+	}
+}
+
+func TestOAuth_AuthorizationCodeStateMismatch(t *testing.T) {
+	ts := NewOAuthTokenSource(OAuthConfig{
+		TokenURL: "http://127.0.0.1:1/token",
+		AuthURL:  "https://idp.example.com/oauth/authorize",
+		ClientID: "client",
+		OpenBrowser: func(loginURL string) error {
+			u, _ := url.Parse(loginURL)
+			resp, err := http.Get(u.Query().Get("redirect_uri") + "?code=CODE&state=forged")
+			if err == nil {
+				resp.Body.Close()
+			}
+			return err
+		},
+	})
+	if _, err := ts.Token(context.Background()); err == nil || !strings.Contains(err.Error(), "state") {
+		t.Errorf("err = %v, want state error", err)
+	}
+}
+
+func TestParseServiceKey(t *testing.T) {
+	key := `{
+		"url": "https://abc.abap.eu10.hana.ondemand.com",
+		"systemid": "H01",
+		"endpoints": {"abap": "https://abc-api.abap.eu10.hana.ondemand.com"},
+		"uaa": {"url": "https://sub.authentication.eu10.hana.ondemand.com/", "clientid": "sb-abc", "clientsecret": "secret"}
+	}`
+	for name, data := range map[string]string{
+		"plain":   key,
+		"wrapped": `{"credentials": ` + key + `}`,
+	} {
+		sk, err := ParseServiceKey([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if sk.SystemURL() != "https://abc-api.abap.eu10.hana.ondemand.com" {
+			t.Errorf("%s: SystemURL = %q", name, sk.SystemURL())
+		}
+		oauth := sk.OAuthConfig()
+		if oauth.TokenURL != "https://sub.authentication.eu10.hana.ondemand.com/oauth/token" ||
+			oauth.AuthURL != "https://sub.authentication.eu10.hana.ondemand.com/oauth/authorize" ||
+			oauth.ClientID != "sb-abc" || oauth.GrantType != GrantAuthorizationCode {
+			t.Errorf("%s: OAuthConfig = %+v", name, oauth)
+		}
+	}
+
+	if _, err := ParseServiceKey([]byte(`{"url": "https://abc"}`)); err == nil {
+		t.Error("expected error for service key without uaa")
+	}
+}
+
+// writeClientCert writes a self-signed client certificate and key as PEM files.
+func writeClientCert(t *testing.T, cn string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+// newMTLSServer starts a TLS server that requires a client certificate and
+// echoes its common name.
+func newMTLSServer(t *testing.T, handler http.Handler) *httptest.Server {
+	srv := httptest.NewUnstartedServer(handler)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientCertificate_MutualTLS(t *testing.T) {
+	srv := newMTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-CSRF-Token", "token")
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	certFile, keyFile := writeClientCert(t, "DEVELOPER")
+	ctx := context.Background()
+
+	client := NewClient(srv.URL, "", "", WithInsecureSkipVerify(), WithClientCertificate(certFile, keyFile))
+	resp, err := client.transport.Request(ctx, "/sap/bc/adt/test", nil)
+	if err != nil {
+		t.Fatalf("Request with client certificate: %v", err)
+	}
+	if string(resp.Body) != "DEVELOPER" {
+		t.Errorf("server saw certificate %q", resp.Body)
+	}
+
+	client = NewClient(srv.URL, "", "", WithInsecureSkipVerify(), WithMaxRetries(0))
+	if _, err := client.transport.Request(ctx, "/sap/bc/adt/test", nil); err == nil {
+		t.Error("request without client certificate succeeded")
+	}
+}
+
+// staticTokenSource returns a fixed token.
+type staticTokenSource string
+
+func (s staticTokenSource) Token(context.Context) (*OAuthToken, error) {
+	return &OAuthToken{AccessToken: string(s)}, nil
+}
+
+func TestWebSocket_UseAuth(t *testing.T) {
+	srv := newMTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ws-token" || !strings.Contains(r.Header.Get("Cookie"), "MYSAPSSO2=sso") {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteJSON(map[string]any{"id": "welcome", "success": true, "data": map[string]any{"session": "S1"}})
+		conn.ReadMessage()
+	}))
+	certFile, keyFile := writeClientCert(t, "DEVELOPER")
+	cfg := NewConfig(srv.URL, "", "",
+		WithInsecureSkipVerify(),
+		WithClientCertificate(certFile, keyFile),
+		WithTokenSource(staticTokenSource("ws-token")),
+		WithCookies(map[string]string{"MYSAPSSO2": "sso"}),
+	)
+
+	ws := NewBaseWebSocketClient(cfg.BaseURL, "001", "", "", true)
+	ws.UseAuth(cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	ws.Close()
+}
@@ -0,0 +1,484 @@
+// Package adt provides ABAP Development Tools client functionality.
+// breakpoint_sets.go persists named breakpoint sets and applies them to systems.
+package adt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBreakpointSetsFile is where breakpoint sets are stored, relative to
+// the working directory.
+const DefaultBreakpointSetsFile = ".vsp/breakpoints.json"
+
+// SavedBreakpoint is a system-independent breakpoint definition.
+type SavedBreakpoint struct {
+	Kind BreakpointKind `json:"kind"` // line, method, exception, statement or message
+
+	// Line and method breakpoints. For line breakpoints Line is absolute
+	// (pool-absolute for classes); for method breakpoints it is relative to
+	// the METHOD statement, which is line 1.
+	Program string `json:"program,omitempty"` // program or class pool (ZCL_TEST======================CP)
+	Method  string `json:"method,omitempty"`
+	Line    int    `json:"line,omitempty"`
+
+	Exception string `json:"exception,omitempty"` // e.g. CX_SY_ZERODIVIDE
+	Statement string `json:"statement,omitempty"` // e.g. CALL FUNCTION
+
+	MessageClass  string `json:"message_class,omitempty"`  // e.g. 00
+	MessageNumber string `json:"message_number,omitempty"` // e.g. 001
+	MessageType   string `json:"message_type,omitempty"`   // E, W, I, S or A
+
+	Condition string `json:"condition,omitempty"` // e.g. lv_count > 10
+}
+
+// Validate checks that the fields required by the breakpoint kind are set.
+func (b SavedBreakpoint) Validate() error {
+	switch b.Kind {
+	case BreakpointKindLine:
+		if b.Program == "" || b.Line <= 0 {
+			return fmt.Errorf("line breakpoint requires program and a positive line")
+		}
+	case BreakpointKindMethod:
+		if b.Program == "" || b.Method == "" {
+			return fmt.Errorf("method breakpoint requires program and method")
+		}
+	case BreakpointKindException:
+		if b.Exception == "" {
+			return fmt.Errorf("exception breakpoint requires exception")
+		}
+	case BreakpointKindStatement:
+		if b.Statement == "" {
+			return fmt.Errorf("statement breakpoint requires statement")
+		}
+	case BreakpointKindMessage:
+		if b.MessageClass == "" || b.MessageNumber == "" {
+			return fmt.Errorf("message breakpoint requires message_class and message_number")
+		}
+	default:
+		return fmt.Errorf("unsupported breakpoint kind %q (valid: line, method, exception, statement, message)", b.Kind)
+	}
+	return nil
+}
+
+// String returns a one-line description of the breakpoint.
+func (b SavedBreakpoint) String() string {
+	var s string
+	switch b.Kind {
+	case BreakpointKindLine:
+		s = fmt.Sprintf("line %s:%d", b.Program, b.Line)
+	case BreakpointKindMethod:
+		s = fmt.Sprintf("method %s->%s", b.Program, b.Method)
+		if b.Line > 0 {
+			s += fmt.Sprintf(" +%d", b.Line)
+		}
+	case BreakpointKindException:
+		s = "exception " + b.Exception
+	case BreakpointKindStatement:
+		s = "statement " + b.Statement
+	case BreakpointKindMessage:
+		s = fmt.Sprintf("message %s/%s", b.MessageClass, b.MessageNumber)
+		if b.MessageType != "" {
+			s += " type " + b.MessageType
+		}
+	default:
+		s = string(b.Kind)
+	}
+	if b.Condition != "" {
+		s += " if " + b.Condition
+	}
+	return s
+}
+
+// BreakpointSet is a named group of breakpoints, e.g. the breakpoints of one
+// investigation, that can be enabled on any system.
+type BreakpointSet struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Breakpoints []SavedBreakpoint `json:"breakpoints"`
+	Updated     time.Time         `json:"updated"`
+
+	// Enabled maps the URL of each system the set is enabled on to the IDs
+	// of the breakpoints created there, so the set can be disabled later.
+	Enabled map[string][]string `json:"enabled,omitempty"`
+}
+
+// BreakpointSets is the breakpoint set store, persisted as a JSON file.
+type BreakpointSets struct {
+	path string
+	Sets map[string]*BreakpointSet `json:"sets"`
+}
+
+// LoadBreakpointSets reads the breakpoint sets stored at path. A missing file
+// yields an empty store.
+func LoadBreakpointSets(path string) (*BreakpointSets, error) {
+	if path == "" {
+		path = DefaultBreakpointSetsFile
+	}
+	store := &BreakpointSets{path: path, Sets: make(map[string]*BreakpointSet)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read breakpoint sets: %w", err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse breakpoint sets %s: %w", path, err)
+	}
+	if store.Sets == nil {
+		store.Sets = make(map[string]*BreakpointSet)
+	}
+	return store, nil
+}
+
+// Path returns the file the store is persisted to.
+func (s *BreakpointSets) Path() string {
+	return s.path
+}
+
+// Save writes the store to its file, creating the directory if needed.
+func (s *BreakpointSets) Save() error {
+	if dir := filepath.Dir(s.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	// Conditions often contain < and >, keep them readable
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("failed to marshal breakpoint sets: %w", err)
+	}
+	if err := os.WriteFile(s.path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write breakpoint sets: %w", err)
+	}
+	return nil
+}
+
+// Names returns the set names, sorted.
+func (s *BreakpointSets) Names() []string {
+	names := make([]string, 0, len(s.Sets))
+	for name := range s.Sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the named set.
+func (s *BreakpointSets) Get(name string) (*BreakpointSet, error) {
+	set, ok := s.Sets[name]
+	if !ok {
+		return nil, fmt.Errorf("breakpoint set '%s' not found. Available: %s", name, strings.Join(s.Names(), ", "))
+	}
+	return set, nil
+}
+
+// Put validates the breakpoints of set and stores it, replacing a set with the
+// same name. The systems the replaced set was enabled on are kept.
+func (s *BreakpointSets) Put(set *BreakpointSet) error {
+	if set.Name == "" {
+		return fmt.Errorf("breakpoint set name is required")
+	}
+	for i, bp := range set.Breakpoints {
+		if err := bp.Validate(); err != nil {
+			return fmt.Errorf("breakpoint %d: %w", i+1, err)
+		}
+	}
+	if old, ok := s.Sets[set.Name]; ok && set.Enabled == nil {
+		set.Enabled = old.Enabled
+	}
+	set.Updated = time.Now()
+	s.Sets[set.Name] = set
+	return nil
+}
+
+// Delete removes the named set. It reports whether the set existed.
+func (s *BreakpointSets) Delete(name string) bool {
+	_, ok := s.Sets[name]
+	delete(s.Sets, name)
+	return ok
+}
+
+// BreakpointSetter creates and deletes breakpoints on a system. It is
+// implemented by *DebugWebSocketClient (breakpoints of the current ZADT_VSP
+// session) and *ExternalBreakpoints (external breakpoints via ADT REST);
+// wrap either in *MethodBreakpoints to support method breakpoints.
+type BreakpointSetter interface {
+	SetBreakpoint(ctx context.Context, bp SavedBreakpoint) (string, error)
+	DeleteBreakpoint(ctx context.Context, id string) error
+}
+
+// BreakpointSetResult reports the outcome of enabling or disabling a set.
+type BreakpointSetResult struct {
+	Set    string   `json:"set"`
+	System string   `json:"system"`
+	IDs    []string `json:"ids,omitempty"`    // breakpoints created or deleted
+	Failed []string `json:"failed,omitempty"` // one message per breakpoint that failed
+}
+
+// EnableBreakpointSet creates the breakpoints of set on system and remembers
+// their IDs in set.Enabled. If the set is already enabled on system, its
+// previous breakpoints are deleted first. Breakpoints that cannot be created
+// are reported in Failed; the caller should save the store afterwards.
+func EnableBreakpointSet(ctx context.Context, setter BreakpointSetter, set *BreakpointSet, system string) *BreakpointSetResult {
+	if len(set.Enabled[system]) > 0 {
+		DisableBreakpointSet(ctx, setter, set, system)
+	}
+
+	result := &BreakpointSetResult{Set: set.Name, System: system}
+	for _, bp := range set.Breakpoints {
+		id, err := setter.SetBreakpoint(ctx, bp)
+		if err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", bp, err))
+			continue
+		}
+		result.IDs = append(result.IDs, id)
+	}
+
+	if len(result.IDs) > 0 {
+		if set.Enabled == nil {
+			set.Enabled = make(map[string][]string)
+		}
+		set.Enabled[system] = result.IDs
+	}
+	return result
+}
+
+// DisableBreakpointSet deletes the breakpoints created by EnableBreakpointSet
+// on system. Deletions that fail, e.g. because the breakpoint was already
+// removed, are reported in Failed; the set is marked disabled either way.
+func DisableBreakpointSet(ctx context.Context, setter BreakpointSetter, set *BreakpointSet, system string) *BreakpointSetResult {
+	result := &BreakpointSetResult{Set: set.Name, System: system}
+	for _, id := range set.Enabled[system] {
+		if err := setter.DeleteBreakpoint(ctx, id); err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		result.IDs = append(result.IDs, id)
+	}
+	delete(set.Enabled, system)
+	return result
+}
+
+// MethodBreakpoints wraps a BreakpointSetter that only knows line
+// breakpoints and resolves method breakpoints to the method's line in the
+// class pool first. The line is resolved when the breakpoint is set, so it
+// follows the current source of the class.
+type MethodBreakpoints struct {
+	BreakpointSetter
+	Client *Client
+}
+
+// SetBreakpoint creates bp, resolving a method breakpoint to a line breakpoint.
+func (m *MethodBreakpoints) SetBreakpoint(ctx context.Context, bp SavedBreakpoint) (string, error) {
+	bp, err := m.Client.ResolveMethodBreakpoint(ctx, bp)
+	if err != nil {
+		return "", err
+	}
+	return m.BreakpointSetter.SetBreakpoint(ctx, bp)
+}
+
+// ResolveMethodBreakpoint turns a method breakpoint into a line breakpoint
+// on the class pool. Other kinds are returned unchanged.
+func (c *Client) ResolveMethodBreakpoint(ctx context.Context, bp SavedBreakpoint) (SavedBreakpoint, error) {
+	if bp.Kind != BreakpointKindMethod {
+		return bp, nil
+	}
+	class := breakpointClassName(bp.Program)
+	line, err := c.MethodBreakpointLine(ctx, class, bp.Method, bp.Line)
+	if err != nil {
+		return bp, err
+	}
+	return SavedBreakpoint{Kind: BreakpointKindLine, Program: ClassPoolName(class), Line: line, Condition: bp.Condition}, nil
+}
+
+// MethodBreakpointLine returns the pool-absolute line of a line within a
+// class method. Line 1 is the METHOD statement; 0 means the same.
+func (c *Client) MethodBreakpointLine(ctx context.Context, class, method string, line int) (int, error) {
+	if line <= 0 {
+		line = 1
+	}
+	class = breakpointClassName(class)
+	methods, err := c.GetClassMethods(ctx, class)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range methods {
+		if !strings.EqualFold(m.Name, method) {
+			continue
+		}
+		if m.ImplementationStart == 0 {
+			return 0, fmt.Errorf("method %s of %s has no implementation", m.Name, class)
+		}
+		abs := m.ImplementationStart + line - 1
+		if abs > m.ImplementationEnd {
+			return 0, fmt.Errorf("line %d is beyond the end of method %s->%s (%d lines)", line, class, m.Name, m.ImplementationEnd-m.ImplementationStart+1)
+		}
+		return abs, nil
+	}
+	return 0, fmt.Errorf("method %s not found in class %s", strings.ToUpper(method), class)
+}
+
+// breakpointClassName returns the class of a class pool name
+// (ZCL_TEST======================CP); other names are returned upper-cased.
+func breakpointClassName(program string) string {
+	name := strings.ToUpper(program)
+	if isClassPoolName(name) {
+		return strings.TrimRight(strings.TrimSuffix(name, "CP"), "=")
+	}
+	return name
+}
+
+// ClassPoolName returns the class pool program of a class: the upper-cased
+// name padded with "=" to 30 characters, followed by "CP"
+// (ZCL_TEST======================CP). Class pool names are returned as is.
+func ClassPoolName(class string) string {
+	name := strings.ToUpper(class)
+	if isClassPoolName(name) {
+		return name
+	}
+	if len(name) < 30 {
+		name += strings.Repeat("=", 30-len(name))
+	}
+	return name + "CP"
+}
+
+// isClassPoolName reports whether name is a class pool name. Class names
+// have at most 30 characters, so a 32 character name ending in CP is a pool.
+func isClassPoolName(name string) bool {
+	return len(name) == 32 && strings.HasSuffix(name, "CP")
+}
+
+// SetBreakpoint creates bp in the current ZADT_VSP session. ZADT_VSP only
+// sets line breakpoints in programs; method breakpoints are set through
+// MethodBreakpoints, and message breakpoints are not supported.
+func (c *DebugWebSocketClient) SetBreakpoint(ctx context.Context, bp SavedBreakpoint) (string, error) {
+	params := map[string]any{"kind": string(bp.Kind)}
+	switch bp.Kind {
+	case BreakpointKindLine:
+		params["program"] = bp.Program
+		params["line"] = bp.Line
+	case BreakpointKindMethod:
+		return "", fmt.Errorf("method breakpoints must be resolved to a line first (see MethodBreakpoints)")
+	case BreakpointKindException:
+		params["exception"] = bp.Exception
+	case BreakpointKindStatement:
+		params["statement"] = bp.Statement
+	default:
+		return "", fmt.Errorf("%s breakpoints are not supported by ZADT_VSP; use external breakpoints", bp.Kind)
+	}
+	if bp.Condition != "" {
+		params["condition"] = bp.Condition
+	}
+	return c.setBreakpointInternal(ctx, params)
+}
+
+// ExternalBreakpoints creates external breakpoints for User through the ADT
+// REST API. Unlike ZADT_VSP session breakpoints, they stay on the system after
+// vsp exits. Method breakpoints are not supported by the REST API (wrap in
+// MethodBreakpoints to set them as line breakpoints), and conditions only
+// apply to line breakpoints.
+type ExternalBreakpoints struct {
+	Client *Client
+	User   string
+}
+
+// SetBreakpoint creates bp as an external breakpoint.
+func (e *ExternalBreakpoints) SetBreakpoint(ctx context.Context, bp SavedBreakpoint) (string, error) {
+	b := Breakpoint{Kind: bp.Kind, Enabled: true, Condition: bp.Condition}
+	switch bp.Kind {
+	case BreakpointKindLine:
+		b.URI = BreakpointProgramURI(bp.Program)
+		b.Line = bp.Line
+	case BreakpointKindException:
+		b.Exception = bp.Exception
+	case BreakpointKindStatement:
+		b.Statement = bp.Statement
+	case BreakpointKindMessage:
+		b.MessageArea = bp.MessageClass
+		b.MessageID = bp.MessageNumber
+		b.MessageType = bp.MessageType
+	default:
+		return "", fmt.Errorf("%s breakpoints are not supported as external breakpoints", bp.Kind)
+	}
+
+	resp, err := e.Client.SetExternalBreakpoint(ctx, &BreakpointRequest{
+		User:        e.User,
+		Breakpoints: []Breakpoint{b},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Breakpoints) == 0 {
+		return "", fmt.Errorf("breakpoint was not created")
+	}
+	return resp.Breakpoints[0].ID, nil
+}
+
+// DeleteBreakpoint deletes an external breakpoint.
+func (e *ExternalBreakpoints) DeleteBreakpoint(ctx context.Context, id string) error {
+	return e.Client.DeleteExternalBreakpoint(ctx, id, e.User)
+}
+
+// SavedBreakpointFromExternal converts an external breakpoint read from a
+// system into a saved breakpoint.
+func SavedBreakpointFromExternal(bp Breakpoint) SavedBreakpoint {
+	saved := SavedBreakpoint{Kind: bp.Kind, Condition: bp.Condition}
+	switch bp.Kind {
+	case BreakpointKindLine:
+		saved.Program = BreakpointURIProgram(bp.URI)
+		saved.Line = bp.Line
+	case BreakpointKindException:
+		saved.Exception = bp.Exception
+	case BreakpointKindStatement:
+		saved.Statement = bp.Statement
+	case BreakpointKindMessage:
+		saved.MessageClass = bp.MessageArea
+		saved.MessageNumber = bp.MessageID
+		saved.MessageType = bp.MessageType
+	case BreakpointKindMethod:
+		saved.Program = bp.ClassName
+		saved.Method = bp.MethodName
+	}
+	return saved
+}
+
+// BreakpointProgramURI returns the ADT source URI of a program or class pool
+// (ZCL_TEST======================CP), as used by line breakpoints.
+func BreakpointProgramURI(program string) string {
+	name := strings.ToUpper(program)
+	if strings.HasSuffix(name, "CP") && strings.Contains(name, "=") {
+		class := strings.TrimRight(strings.TrimSuffix(name, "CP"), "=")
+		return "/sap/bc/adt/oo/classes/" + strings.ToLower(class) + "/source/main"
+	}
+	return "/sap/bc/adt/programs/programs/" + strings.ToLower(name) + "/source/main"
+}
+
+// BreakpointURIProgram is the inverse of BreakpointProgramURI. Class URIs
+// yield the class pool name.
+func BreakpointURIProgram(uri string) string {
+	uri = strings.SplitN(uri, "#", 2)[0]
+	for _, prefix := range []string{"/sap/bc/adt/oo/classes/", "/sap/bc/adt/programs/programs/", "/sap/bc/adt/programs/includes/"} {
+		if !strings.HasPrefix(uri, prefix) {
+			continue
+		}
+		name := strings.ToUpper(strings.SplitN(strings.TrimPrefix(uri, prefix), "/", 2)[0])
+		if prefix == "/sap/bc/adt/oo/classes/" {
+			return ClassPoolName(name)
+		}
+		return name
+	}
+	return uri
+}
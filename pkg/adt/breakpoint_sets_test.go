@@ -0,0 +1,260 @@
+package adt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeBreakpointSetter records created and deleted breakpoints.
+type fakeBreakpointSetter struct {
+	next    int
+	created map[string]SavedBreakpoint
+	deleted []string
+}
+
+func (f *fakeBreakpointSetter) SetBreakpoint(ctx context.Context, bp SavedBreakpoint) (string, error) {
+	if bp.Kind == BreakpointKindMessage {
+		return "", fmt.Errorf("not supported")
+	}
+	f.next++
+	id := fmt.Sprintf("BP%d", f.next)
+	if f.created == nil {
+		f.created = make(map[string]SavedBreakpoint)
+	}
+	f.created[id] = bp
+	return id, nil
+}
+
+func (f *fakeBreakpointSetter) DeleteBreakpoint(ctx context.Context, id string) error {
+	if _, ok := f.created[id]; !ok {
+		return fmt.Errorf("unknown breakpoint %s", id)
+	}
+	delete(f.created, id)
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func TestBreakpointSetsSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".vsp", "breakpoints.json")
+
+	store, err := LoadBreakpointSets(path)
+	if err != nil {
+		t.Fatalf("load missing file: %v", err)
+	}
+	if len(store.Names()) != 0 {
+		t.Fatalf("expected empty store, got %v", store.Names())
+	}
+
+	err = store.Put(&BreakpointSet{
+		Name:        "order-bug",
+		Description: "wrong totals",
+		Breakpoints: []SavedBreakpoint{
+			{Kind: BreakpointKindLine, Program: "ZORDER", Line: 42, Condition: "lv_total < 0"},
+			{Kind: BreakpointKindMessage, MessageClass: "ZSD", MessageNumber: "001", MessageType: "E"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	loaded, err := LoadBreakpointSets(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	set, err := loaded.Get("order-bug")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(set.Breakpoints) != 2 || set.Breakpoints[0].Condition != "lv_total < 0" {
+		t.Errorf("unexpected breakpoints: %+v", set.Breakpoints)
+	}
+	if _, err := loaded.Get("missing"); err == nil {
+		t.Error("expected error for missing set")
+	}
+	if !loaded.Delete("order-bug") || loaded.Delete("order-bug") {
+		t.Error("Delete should report whether the set existed")
+	}
+}
+
+func TestBreakpointSetsPutValidates(t *testing.T) {
+	store, _ := LoadBreakpointSets(filepath.Join(t.TempDir(), "bp.json"))
+	tests := []SavedBreakpoint{
+		{Kind: BreakpointKindLine, Program: "ZTEST"},
+		{Kind: BreakpointKindMethod, Program: "ZCL_TEST================CP"},
+		{Kind: BreakpointKindException},
+		{Kind: BreakpointKindStatement},
+		{Kind: BreakpointKindMessage, MessageClass: "00"},
+		{Kind: "watchpoint"},
+	}
+	for _, bp := range tests {
+		if err := store.Put(&BreakpointSet{Name: "x", Breakpoints: []SavedBreakpoint{bp}}); err == nil {
+			t.Errorf("expected validation error for %+v", bp)
+		}
+	}
+	if err := store.Put(&BreakpointSet{}); err == nil {
+		t.Error("expected error for empty name")
+	}
+}
+
+func TestEnableDisableBreakpointSet(t *testing.T) {
+	ctx := context.Background()
+	setter := &fakeBreakpointSetter{}
+	set := &BreakpointSet{
+		Name: "investigation",
+		Breakpoints: []SavedBreakpoint{
+			{Kind: BreakpointKindLine, Program: "ZORDER", Line: 42},
+			{Kind: BreakpointKindException, Exception: "CX_SY_ZERODIVIDE"},
+			{Kind: BreakpointKindMessage, MessageClass: "ZSD", MessageNumber: "001"},
+		},
+	}
+
+	result := EnableBreakpointSet(ctx, setter, set, "http://dev:50000")
+	if len(result.IDs) != 2 || len(result.Failed) != 1 {
+		t.Fatalf("expected 2 created and 1 failed, got %+v", result)
+	}
+	if got := set.Enabled["http://dev:50000"]; len(got) != 2 {
+		t.Fatalf("expected enabled IDs to be remembered, got %v", got)
+	}
+
+	// Enabling again replaces the previous breakpoints instead of duplicating them
+	EnableBreakpointSet(ctx, setter, set, "http://dev:50000")
+	if len(setter.created) != 2 {
+		t.Errorf("expected 2 breakpoints after re-enable, got %d", len(setter.created))
+	}
+
+	result = DisableBreakpointSet(ctx, setter, set, "http://dev:50000")
+	if len(result.IDs) != 2 || len(setter.created) != 0 {
+		t.Errorf("expected all breakpoints deleted, got %+v (remaining %d)", result, len(setter.created))
+	}
+	if _, ok := set.Enabled["http://dev:50000"]; ok {
+		t.Error("expected set to be marked disabled")
+	}
+}
+
+func TestMethodBreakpoints(t *testing.T) {
+	structure := `<?xml version="1.0" encoding="utf-8"?>
+<abapsource:objectStructureElement xmlns:abapsource="http://www.sap.com/adt/abapsource" xmlns:atom="http://www.w3.org/2005/Atom" name="ZCL_ORDER" type="CLAS/OC">
+  <abapsource:objectStructureElement name="CALCULATE" type="CLAS/OM" visibility="public" level="instance">
+    <atom:link href="./../class/source/main#start=5,4;end=5,20" rel="http://www.sap.com/adt/relations/source/definitionBlock"/>
+    <atom:link href="./../class/source/main#start=12,2;end=20,11" rel="http://www.sap.com/adt/relations/source/implementationBlock"/>
+  </abapsource:objectStructureElement>
+</abapsource:objectStructureElement>`
+	// The mock replays a response once, so each lookup gets a fresh client
+	newClient := func() *Client {
+		mock := &mockTransportClient{responses: map[string]*http.Response{
+			"/sap/bc/adt/oo/classes/ZCL_ORDER/objectstructure": newTestResponse(structure),
+		}}
+		cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+		return NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+	}
+	fake := &fakeBreakpointSetter{}
+	setter := &MethodBreakpoints{BreakpointSetter: fake, Client: newClient()}
+	ctx := context.Background()
+
+	id, err := setter.SetBreakpoint(ctx, SavedBreakpoint{Kind: BreakpointKindMethod, Program: "ZCL_ORDER=====================CP", Method: "calculate", Line: 3, Condition: "lv_total < 0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SavedBreakpoint{Kind: BreakpointKindLine, Program: "ZCL_ORDER=====================CP", Line: 14, Condition: "lv_total < 0"}
+	if got := fake.created[id]; got != want {
+		t.Errorf("created %+v, want %+v", got, want)
+	}
+	if line, err := newClient().MethodBreakpointLine(ctx, "zcl_order", "CALCULATE", 0); err != nil || line != 12 {
+		t.Errorf("default line = %d, %v; want the METHOD statement at 12", line, err)
+	}
+	if _, err := newClient().MethodBreakpointLine(ctx, "ZCL_ORDER", "CALCULATE", 10); err == nil || !strings.Contains(err.Error(), "beyond the end") {
+		t.Errorf("line past ENDMETHOD: %v", err)
+	}
+	if _, err := newClient().MethodBreakpointLine(ctx, "ZCL_ORDER", "MISSING", 1); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing method: %v", err)
+	}
+
+	// Other kinds pass through; the session client alone rejects method breakpoints
+	if id, err := setter.SetBreakpoint(ctx, SavedBreakpoint{Kind: BreakpointKindException, Exception: "CX_SY_ZERODIVIDE"}); err != nil || fake.created[id].Exception != "CX_SY_ZERODIVIDE" {
+		t.Errorf("exception breakpoint: %v", err)
+	}
+	if _, err := (&DebugWebSocketClient{}).SetBreakpoint(ctx, SavedBreakpoint{Kind: BreakpointKindMethod, Program: "ZCL_ORDER", Method: "CALCULATE"}); err == nil {
+		t.Error("expected ZADT_VSP session to reject an unresolved method breakpoint")
+	}
+}
+
+func TestSetMethodBreakpoint(t *testing.T) {
+	structure := `<?xml version="1.0" encoding="utf-8"?>
+<abapsource:objectStructureElement xmlns:abapsource="http://www.sap.com/adt/abapsource" xmlns:atom="http://www.w3.org/2005/Atom" name="ZCL_ORDER" type="CLAS/OC">
+  <abapsource:objectStructureElement name="CALCULATE" type="CLAS/OM" visibility="public" level="instance">
+    <atom:link href="./../class/source/main#start=12,2;end=20,11" rel="http://www.sap.com/adt/relations/source/implementationBlock"/>
+  </abapsource:objectStructureElement>
+</abapsource:objectStructureElement>`
+	// One server answers the class structure and the ZADT_VSP WebSocket
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sap/bc/adt/oo/classes/ZCL_ORDER/objectstructure", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(structure))
+	})
+	mux.Handle("/", &fakeZADTVSP{})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := NewDebugWebSocketClient(srv.URL, "001", "user", "pass", false)
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer c.Close()
+
+	id, err := c.SetMethodBreakpoint(context.Background(), "ZCL_ORDER", "calculate", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.RLock()
+	params := c.breakpoints[id]
+	c.mu.RUnlock()
+	if params["program"] != "ZCL_ORDER=====================CP" || params["line"] != 14 || params["method"] != nil {
+		t.Errorf("expected a pool-absolute line breakpoint, got %v", params)
+	}
+}
+
+func TestBreakpointProgramURI(t *testing.T) {
+	tests := []struct {
+		program string
+		uri     string
+	}{
+		{"ZTEST", "/sap/bc/adt/programs/programs/ztest/source/main"},
+		{"ZCL_TEST======================CP", "/sap/bc/adt/oo/classes/zcl_test/source/main"},
+	}
+	for _, tt := range tests {
+		if got := BreakpointProgramURI(tt.program); got != tt.uri {
+			t.Errorf("BreakpointProgramURI(%q) = %q, want %q", tt.program, got, tt.uri)
+		}
+		if got := BreakpointURIProgram(tt.uri + "#start=10"); got != tt.program {
+			t.Errorf("BreakpointURIProgram(%q) = %q, want %q", tt.uri, got, tt.program)
+		}
+	}
+}
+
+func TestClassPoolName(t *testing.T) {
+	tests := []struct {
+		class string
+		pool  string
+	}{
+		{"ZCL_TEST", "ZCL_TEST======================CP"},
+		{"zcl_test", "ZCL_TEST======================CP"},
+		{"ZCL_TEST======================CP", "ZCL_TEST======================CP"},
+		{"ZCL_ABCDEFGHIJKLMNOPQRSTUVWXYZ", "ZCL_ABCDEFGHIJKLMNOPQRSTUVWXYZCP"},
+		{"ZCL_ABCDEFGHIJKLMNOPQRSTUVWXYZCP", "ZCL_ABCDEFGHIJKLMNOPQRSTUVWXYZCP"},
+	}
+	for _, tt := range tests {
+		if got := ClassPoolName(tt.class); got != tt.pool {
+			t.Errorf("ClassPoolName(%q) = %q, want %q", tt.class, got, tt.pool)
+		}
+	}
+	if got := breakpointClassName("ZCL_ABCDEFGHIJKLMNOPQRSTUVWXYZCP"); got != "ZCL_ABCDEFGHIJKLMNOPQRSTUVWXYZ" {
+		t.Errorf("breakpointClassName of a 30 character class pool = %q", got)
+	}
+}
@@ -0,0 +1,367 @@
+package adt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Redacted replaces credentials, cookies and CSRF tokens in recorded traffic.
+const Redacted = "REDACTED"
+
+// Interaction is one recorded HTTP request and its response.
+// Cassettes are directories with one JSON file per interaction, written by
+// NewRecorder in request order.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the request half of an Interaction. The base URL is not
+// recorded, so a cassette replays against any system URL.
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	Path    string      `json:"path"`
+	Query   string      `json:"query,omitempty"` // encoded, sorted by key
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	Base64  bool        `json:"base64,omitempty"` // Body is base64-encoded binary
+}
+
+// RecordedResponse is the response half of an Interaction.
+type RecordedResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	Base64  bool        `json:"base64,omitempty"`
+}
+
+// RequestBody returns the decoded request body.
+func (i *Interaction) RequestBody() []byte {
+	return decodeBody(i.Request.Body, i.Request.Base64)
+}
+
+// ResponseBody returns the decoded response body.
+func (i *Interaction) ResponseBody() []byte {
+	return decodeBody(i.Response.Body, i.Response.Base64)
+}
+
+// Cassette is a sequence of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction
+}
+
+// LoadCassette reads the interactions recorded in dir, in recording order.
+func LoadCassette(dir string) (*Cassette, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recorded interactions in %s", dir)
+	}
+	sort.Strings(files)
+
+	c := &Cassette{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var i Interaction
+		if err := json.Unmarshal(data, &i); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", file, err)
+		}
+		c.Interactions = append(c.Interactions, i)
+	}
+	return c, nil
+}
+
+// Find returns the first interaction with the given method (empty for any)
+// whose path starts with pathPrefix (case-insensitive), or nil.
+// Parser regression tests use it to pick the response body they need.
+func (c *Cassette) Find(method, pathPrefix string) *Interaction {
+	for i := range c.Interactions {
+		in := &c.Interactions[i]
+		if (method == "" || strings.EqualFold(in.Request.Method, method)) &&
+			strings.HasPrefix(strings.ToLower(in.Request.Path), strings.ToLower(pathPrefix)) {
+			return in
+		}
+	}
+	return nil
+}
+
+// Recorder is an HTTPDoer that passes requests on to another HTTPDoer and
+// writes each request/response pair to a cassette directory.
+// Authorization, cookies and CSRF tokens are redacted before writing.
+type Recorder struct {
+	cassette *cassetteWriter
+	next     HTTPDoer
+}
+
+// cassetteWriter numbers and writes the interactions of one cassette.
+type cassetteWriter struct {
+	dir string
+
+	mu sync.Mutex
+	n  int
+}
+
+// NewRecorder returns a Recorder writing to dir, which is created on the
+// first request.
+func NewRecorder(dir string, next HTTPDoer) *Recorder {
+	return &Recorder{cassette: &cassetteWriter{dir: dir}, next: next}
+}
+
+// Wrap returns a Recorder for next that writes to the same cassette, e.g. for
+// the HTTP client of another session.
+func (r *Recorder) Wrap(next HTTPDoer) *Recorder {
+	return &Recorder{cassette: r.cassette, next: next}
+}
+
+// Do implements HTTPDoer.
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("recording request body: %w", err)
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("recording response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	in := Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Query:   req.URL.Query().Encode(),
+			Headers: redactHeaders(req.Header),
+		},
+		Response: RecordedResponse{
+			Status:  resp.StatusCode,
+			Headers: redactHeaders(resp.Header),
+		},
+	}
+	in.Request.Body, in.Request.Base64 = encodeBody(reqBody)
+	in.Response.Body, in.Response.Base64 = encodeBody(respBody)
+
+	if err := r.cassette.write(&in); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (w *cassetteWriter) write(in *Interaction) error {
+	data, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return fmt.Errorf("creating cassette directory: %w", err)
+	}
+	w.n++
+	name := fmt.Sprintf("%04d_%s_%s.json", w.n, in.Request.Method, cassetteFileName(in.Request.Path))
+	if err := os.WriteFile(filepath.Join(w.dir, name), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing interaction: %w", err)
+	}
+	return nil
+}
+
+// Replayer is an HTTPDoer that answers requests from a cassette without
+// network access. Requests are matched by method, path and query; among
+// several matches, unused interactions are served in recording order, those
+// with an identical body first. Once all matches are used, the last one is
+// repeated. Unmatched requests fail.
+type Replayer struct {
+	dir string
+
+	mu   sync.Mutex
+	c    *Cassette
+	err  error
+	used []bool
+}
+
+// NewReplayer returns a Replayer for the cassette in dir, which is loaded on
+// the first request.
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir}
+}
+
+// Do implements HTTPDoer.
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.c == nil && r.err == nil {
+		r.c, r.err = LoadCassette(r.dir)
+		if r.c != nil {
+			r.used = make([]bool, len(r.c.Interactions))
+		}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("replay: %w", r.err)
+	}
+
+	in := r.match(req, body)
+	if in == nil {
+		return nil, fmt.Errorf("replay: no recorded interaction for %s %s", req.Method, req.URL.RequestURI())
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+		StatusCode:    in.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        in.Response.Headers.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(in.ResponseBody())),
+		ContentLength: -1,
+		Request:       req,
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	return resp, nil
+}
+
+// match picks the interaction for req. Callers must hold r.mu.
+func (r *Replayer) match(req *http.Request, body []byte) *Interaction {
+	query := req.URL.Query().Encode()
+	var candidates []int
+	for i, in := range r.c.Interactions {
+		if in.Request.Method == req.Method && in.Request.Path == req.URL.Path && in.Request.Query == query {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	pick := -1
+	for _, i := range candidates {
+		if !r.used[i] && bytes.Equal(r.c.Interactions[i].RequestBody(), body) {
+			pick = i
+			break
+		}
+	}
+	if pick < 0 {
+		for _, i := range candidates {
+			if !r.used[i] {
+				pick = i
+				break
+			}
+		}
+	}
+	if pick < 0 {
+		pick = candidates[len(candidates)-1]
+	}
+	r.used[pick] = true
+	return &r.c.Interactions[pick]
+}
+
+// redactHeaders copies h with credentials, session cookies and CSRF tokens
+// replaced. The CSRF protocol values "fetch" and "Required" are kept, so
+// replay follows the same token handling as the original session.
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for name, values := range out {
+		switch http.CanonicalHeaderKey(name) {
+		case "Authorization", "Proxy-Authorization", "Cookie":
+			out[name] = []string{Redacted}
+		case "Set-Cookie":
+			for i, v := range values {
+				values[i] = redactSetCookie(v)
+			}
+		case "X-Csrf-Token":
+			for i, v := range values {
+				if !strings.EqualFold(v, "fetch") && v != "Required" {
+					values[i] = Redacted
+				}
+			}
+		}
+	}
+	return out
+}
+
+// redactSetCookie replaces the value of a Set-Cookie header, keeping the
+// cookie name and attributes.
+func redactSetCookie(v string) string {
+	pair, attrs, _ := strings.Cut(v, ";")
+	name, _, _ := strings.Cut(pair, "=")
+	if attrs != "" {
+		return name + "=" + Redacted + ";" + attrs
+	}
+	return name + "=" + Redacted
+}
+
+// cassetteFileName turns a URL path into a readable file name fragment.
+func cassetteFileName(p string) string {
+	p = strings.TrimPrefix(strings.ToLower(p), "/sap/bc/adt/")
+	if unescaped, err := url.PathUnescape(p); err == nil {
+		p = unescaped
+	}
+	var sb strings.Builder
+	for _, r := range p {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			sb.WriteRune(r)
+		} else if s := sb.String(); s != "" && !strings.HasSuffix(s, "-") {
+			sb.WriteByte('-')
+		}
+	}
+	name := strings.Trim(sb.String(), "-")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	if name == "" {
+		name = "root"
+	}
+	return name
+}
+
+func encodeBody(b []byte) (string, bool) {
+	if utf8.Valid(b) {
+		return string(b), false
+	}
+	return base64.StdEncoding.EncodeToString(b), true
+}
+
+func decodeBody(s string, isBase64 bool) []byte {
+	if !isBase64 {
+		return []byte(s)
+	}
+	b, _ := base64.StdEncoding.DecodeString(s)
+	return b
+}
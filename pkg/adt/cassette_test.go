@@ -0,0 +1,161 @@
+package adt
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
+)
+
+const cassetteTestSource = `CLASS ltcl_calc DEFINITION FOR TESTING RISK LEVEL HARMLESS DURATION SHORT.
+  PRIVATE SECTION.
+    METHODS: add FOR TESTING,
+             divide FOR TESTING.
+ENDCLASS.
+CLASS ltcl_calc IMPLEMENTATION.
+ENDCLASS.`
+
+// recordSession records a class creation with unit tests and an ATC run
+// against the mock system and returns the cassette directory.
+func recordSession(t *testing.T) string {
+	t.Helper()
+	srv := adttest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.FailUnitTest("LTCL_CALC", "DIVIDE", "Expected 2, got 3")
+
+	dir := t.TempDir()
+	client := NewClient(srv.URL, "developer", "s3cret-pw", WithRecording(dir))
+	runCassetteSession(t, client)
+	return dir
+}
+
+func runCassetteSession(t *testing.T, client *Client) (*UnitTestResult, *ATCWorklist) {
+	t.Helper()
+	ctx := context.Background()
+	classSource := "CLASS zcl_calc DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_calc IMPLEMENTATION.\nENDCLASS.\n"
+	result, err := client.CreateClassWithTests(ctx, "ZCL_CALC", "Calculator", "$TMP", classSource, cassetteTestSource, "")
+	if err != nil || !result.Success {
+		t.Fatalf("CreateClassWithTests: %v %+v", err, result)
+	}
+	worklist, err := client.RunATCCheck(ctx, "/sap/bc/adt/oo/classes/ZCL_CALC", "", 100)
+	if err != nil {
+		t.Fatalf("RunATCCheck: %v", err)
+	}
+	return result.UnitTestResult, worklist
+}
+
+func TestRecorderRedactsSecrets(t *testing.T) {
+	dir := recordSession(t)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) < 5 {
+		t.Fatalf("expected several interactions, got %d", len(files))
+	}
+	if !strings.HasPrefix(filepath.Base(files[0]), "0001_HEAD_core-discovery") {
+		t.Errorf("first interaction = %s, want the CSRF fetch", filepath.Base(files[0]))
+	}
+
+	cassette, err := LoadCassette(dir)
+	if err != nil {
+		t.Fatalf("LoadCassette: %v", err)
+	}
+	for _, in := range cassette.Interactions {
+		if v := in.Request.Headers.Get("Authorization"); v != "" && v != Redacted {
+			t.Errorf("%s %s: Authorization not redacted: %q", in.Request.Method, in.Request.Path, v)
+		}
+		if v := in.Request.Headers.Get("X-CSRF-Token"); v != "" && v != "fetch" && v != Redacted {
+			t.Errorf("%s %s: request CSRF token not redacted: %q", in.Request.Method, in.Request.Path, v)
+		}
+		if v := in.Response.Headers.Get("X-CSRF-Token"); v != "" && v != "Required" && v != Redacted {
+			t.Errorf("%s %s: response CSRF token not redacted: %q", in.Request.Method, in.Request.Path, v)
+		}
+		for _, c := range in.Response.Headers.Values("Set-Cookie") {
+			if !strings.HasPrefix(c, adttest.SessionCookie+"="+Redacted) {
+				t.Errorf("Set-Cookie not redacted: %q", c)
+			}
+		}
+	}
+
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), "s3cret-pw") {
+			t.Errorf("%s contains the password", filepath.Base(file))
+		}
+	}
+
+	if cassette.Find(http.MethodPost, "/sap/bc/adt/abapunit/testruns") == nil {
+		t.Error("unit test run not recorded")
+	}
+}
+
+func TestReplayerServesRecordedSession(t *testing.T) {
+	dir := recordSession(t)
+
+	// No server: every request is answered from the cassette.
+	client := NewClient("http://sap.invalid:8000", "someone", "else", WithReplay(dir))
+	units, worklist := runCassetteSession(t, client)
+
+	if units == nil || len(units.Classes) != 1 || len(units.Classes[0].TestMethods) != 2 {
+		t.Fatalf("unexpected unit test result: %+v", units)
+	}
+	if alerts := units.Classes[0].TestMethods[1].Alerts; len(alerts) != 1 || alerts[0].Title != "Expected 2, got 3" {
+		t.Errorf("unexpected alerts: %+v", alerts)
+	}
+	if worklist == nil {
+		t.Fatal("expected ATC worklist")
+	}
+
+	// A request that was never recorded fails instead of reaching a system.
+	if _, err := client.GetProgram(context.Background(), "ZOTHER"); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("GetProgram error = %v, want unmatched replay error", err)
+	}
+}
+
+func TestReplayerMissingCassette(t *testing.T) {
+	client := NewClient("http://sap.invalid", "u", "p", WithReplay(filepath.Join(t.TempDir(), "missing")))
+	if _, err := client.GetProgram(context.Background(), "ZTEST"); err == nil || !strings.Contains(err.Error(), "no recorded interactions") {
+		t.Errorf("error = %v, want missing cassette error", err)
+	}
+}
+
+// Parser regression tests feed recorded responses straight into the parsers.
+// Cassettes recorded against real systems go into testdata the same way.
+func TestParsersAgainstCassette(t *testing.T) {
+	cassette, err := LoadCassette(recordSession(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := cassette.Find(http.MethodPost, "/sap/bc/adt/abapunit/testruns")
+	units, err := parseUnitTestResult(in.ResponseBody())
+	if err != nil || len(units.Classes) != 1 {
+		t.Errorf("parseUnitTestResult = %+v, %v", units, err)
+	}
+
+	in = cassette.Find(http.MethodGet, "/sap/bc/adt/atc/worklists/")
+	if in == nil {
+		t.Fatal("ATC worklist not recorded")
+	}
+	if _, err := parseATCWorklist(in.ResponseBody()); err != nil {
+		t.Errorf("parseATCWorklist: %v", err)
+	}
+}
+
+func TestCassetteFileName(t *testing.T) {
+	tests := map[string]string{
+		"/sap/bc/adt/core/discovery":                  "core-discovery",
+		"/sap/bc/adt/oo/classes/ZCL_CALC/source/main": "oo-classes-zcl_calc-source-main",
+		"/sap/bc/adt/programs/programs/%2FNS%2FZPROG": "programs-programs-ns-zprog",
+		"/":                        "root",
+		"/sap/bc/apc/sap/zadt_vsp": "sap-bc-apc-sap-zadt_vsp",
+	}
+	for path, want := range tests {
+		if got := cassetteFileName(path); got != want {
+			t.Errorf("cassetteFileName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -33,6 +33,19 @@ func NewClientWithTransport(cfg *Config, transport *Transport) *Client {
 	}
 }
 
+// TransportStats returns request throttling and session pool statistics.
+func (c *Client) TransportStats() TransportStats {
+	return c.transport.Stats()
+}
+
+// WithSession runs fn with a dedicated SAP session from the session pool
+// (see WithSessionPool): all requests made with the ctx passed to fn,
+// including locks, go to that session. Use it for stateful sequences beyond
+// lock/update/unlock. Without a pool, fn runs in the shared session.
+func (c *Client) WithSession(ctx context.Context, fn func(ctx context.Context) error) error {
+	return c.transport.withDedicatedSession(ctx, fn)
+}
+
 // checkSafety checks if an operation is allowed by the safety configuration.
 func (c *Client) checkSafety(op OperationType, opName string) error {
 	return c.config.Safety.CheckOperation(op, opName)
@@ -53,6 +66,12 @@ func (c *Client) Safety() *SafetyConfig {
 	return &c.config.Safety
 }
 
+// Config returns the client configuration, e.g. to open WebSocket clients
+// against the same system with the same credentials.
+func (c *Client) Config() *Config {
+	return c.config
+}
+
 // --- Search Operations ---
 
 // SearchObject searches for ABAP objects by name pattern.
@@ -264,6 +283,35 @@ func (c *Client) GetFunction(ctx context.Context, functionName, groupName string
 	return string(resp.Body), nil
 }
 
+// GetFunctionGroupSource retrieves the main program of a function group
+// (SAPL<group>), which lists the group's includes.
+func (c *Client) GetFunctionGroupSource(ctx context.Context, groupName string) (string, error) {
+	sourcePath := fmt.Sprintf("/sap/bc/adt/functions/groups/%s/source/main", url.PathEscape(strings.ToUpper(groupName)))
+	resp, err := c.transport.Request(ctx, sourcePath, &RequestOptions{
+		Method: http.MethodGet,
+		Accept: "text/plain",
+	})
+	if err != nil {
+		return "", fmt.Errorf("getting function group source: %w", err)
+	}
+
+	return string(resp.Body), nil
+}
+
+// GetFunctionGroupInclude retrieves the source code of a function group include,
+// e.g. LZFGTOP of group ZFG.
+func (c *Client) GetFunctionGroupInclude(ctx context.Context, groupName, includeName string) (string, error) {
+	resp, err := c.transport.Request(ctx, GetSourceURL(ObjectTypeFunctionIncl, includeName, groupName), &RequestOptions{
+		Method: http.MethodGet,
+		Accept: "text/plain",
+	})
+	if err != nil {
+		return "", fmt.Errorf("getting function group include source: %w", err)
+	}
+
+	return string(resp.Body), nil
+}
+
 // --- Include Operations ---
 
 // GetInclude retrieves the source code of an ABAP include.
@@ -353,6 +401,8 @@ type ServiceBinding struct {
 	BindingVersion  string `json:"bindingVersion"`  // V2, V4
 	ServiceURL      string `json:"serviceUrl,omitempty"`
 	ServiceDefName  string `json:"serviceDefName,omitempty"`
+	ServiceName     string `json:"serviceName,omitempty"`
+	ServiceVersion  string `json:"serviceVersion,omitempty"`
 }
 
 // GetSRVB retrieves metadata for a Service Binding.
@@ -389,6 +439,7 @@ func parseSRVBMetadata(data []byte) (*ServiceBinding, error) {
 		Name string `xml:"name,attr"`
 	}
 	type serviceContent struct {
+		Version    string     `xml:"version,attr"`
 		ServiceDef serviceRef `xml:"serviceDefinition"`
 	}
 	type service struct {
@@ -409,7 +460,7 @@ func parseSRVBMetadata(data []byte) (*ServiceBinding, error) {
 		return nil, fmt.Errorf("parsing SRVB metadata: %w", err)
 	}
 
-	return &ServiceBinding{
+	sb := &ServiceBinding{
 		Name:            root.Name,
 		Type:            root.Type,
 		Description:     root.Description,
@@ -417,7 +468,51 @@ func parseSRVBMetadata(data []byte) (*ServiceBinding, error) {
 		BindingType:     root.Binding.Type,
 		BindingVersion:  root.Binding.Version,
 		ServiceDefName:  root.Services.Content.ServiceDef.Name,
-	}, nil
+		ServiceName:     root.Services.Name,
+		ServiceVersion:  root.Services.Content.Version,
+	}
+	if sb.ServiceVersion == "" {
+		sb.ServiceVersion = "0001"
+	}
+	switch strings.ToUpper(sb.BindingVersion) {
+	case "V2":
+		service := sb.ServiceName
+		if service == "" {
+			service = sb.Name
+		}
+		sb.ServiceURL = ODataV2ServiceURL(service)
+	case "V4":
+		if sb.ServiceDefName != "" {
+			sb.ServiceURL = ODataV4ServiceURL(sb.Name, sb.ServiceDefName, sb.ServiceVersion)
+		}
+	}
+	return sb, nil
+}
+
+// ODataV2ServiceURL returns the root URL of a published OData V2 service,
+// e.g. /sap/opu/odata/sap/ZUI_TRAVEL_O2/.
+func ODataV2ServiceURL(service string) string {
+	ns, name := splitODataNamespace(service)
+	return fmt.Sprintf("/sap/opu/odata/%s/%s/", ns, strings.ToUpper(name))
+}
+
+// ODataV4ServiceURL returns the root URL of a published OData V4 service
+// binding, e.g. /sap/opu/odata4/sap/zui_travel_o4/srvd/sap/zui_travel/0001/.
+func ODataV4ServiceURL(binding, serviceDef, version string) string {
+	bindingNS, bindingName := splitODataNamespace(binding)
+	srvdNS, srvdName := splitODataNamespace(serviceDef)
+	return fmt.Sprintf("/sap/opu/odata4/%s/%s/srvd/%s/%s/%s/", bindingNS, strings.ToLower(bindingName), srvdNS, strings.ToLower(srvdName), version)
+}
+
+// splitODataNamespace splits /DMO/UI_TRAVEL into dmo and UI_TRAVEL. Objects
+// without namespace are in namespace sap.
+func splitODataNamespace(name string) (string, string) {
+	if strings.HasPrefix(name, "/") {
+		if i := strings.Index(name[1:], "/"); i > 0 {
+			return strings.ToLower(name[1 : i+1]), name[i+2:]
+		}
+	}
+	return "sap", name
 }
 
 // --- Message Class Operations ---
@@ -472,8 +567,9 @@ func (c *Client) GetPackage(ctx context.Context, packageName string) (*PackageCo
 	params.Set("withShortDescriptions", "true")
 
 	resp, err := c.transport.Request(ctx, "/sap/bc/adt/repository/nodestructure", &RequestOptions{
-		Method: http.MethodPost,
-		Query:  params,
+		Method:     http.MethodPost,
+		Query:      params,
+		Idempotent: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("getting package contents: %w", err)
@@ -623,9 +719,10 @@ func (c *Client) GetTableContents(ctx context.Context, tableName string, maxRows
 	params.Set("ddicEntityName", tableName)
 
 	opts := &RequestOptions{
-		Method: http.MethodPost,
-		Query:  params,
-		Accept: "application/*",
+		Method:     http.MethodPost,
+		Query:      params,
+		Accept:     "application/*",
+		Idempotent: true,
 	}
 
 	// Add SQL filter as request body if provided
@@ -662,6 +759,7 @@ func (c *Client) RunQuery(ctx context.Context, sqlQuery string, maxRows int) (*T
 
 	resp, err := c.transport.Request(ctx, "/sap/bc/adt/datapreview/freestyle", &RequestOptions{
 		Method:      http.MethodPost,
+		Idempotent:  true,
 		Query:       params,
 		Accept:      "application/*",
 		Body:        []byte(sqlQuery),
@@ -990,6 +1088,7 @@ func (c *Client) GetCallGraph(ctx context.Context, objectURI string, opts *CallG
 
 	resp, err := c.transport.Request(ctx, "/sap/bc/adt/cai/callgraph", &RequestOptions{
 		Method:      http.MethodPost,
+		Idempotent:  true,
 		Query:       params,
 		Accept:      "application/xml",
 		ContentType: "application/xml",
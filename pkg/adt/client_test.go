@@ -226,4 +226,16 @@ func TestParseSRVBMetadata(t *testing.T) {
 	if result.ServiceDefName != "Z_RAP_TRAVEL" {
 		t.Errorf("expected service def name 'Z_RAP_TRAVEL', got '%s'", result.ServiceDefName)
 	}
+	if result.ServiceURL != "/sap/opu/odata/sap/Z_RAP_TRAVEL_O2/" {
+		t.Errorf("expected service URL '/sap/opu/odata/sap/Z_RAP_TRAVEL_O2/', got '%s'", result.ServiceURL)
+	}
+}
+
+func TestODataServiceURLs(t *testing.T) {
+	if got := ODataV2ServiceURL("/DMO/ui_travel_o2"); got != "/sap/opu/odata/dmo/UI_TRAVEL_O2/" {
+		t.Errorf("ODataV2ServiceURL = %s", got)
+	}
+	if got := ODataV4ServiceURL("/DMO/UI_TRAVEL_O4", "/DMO/UI_TRAVEL", "0001"); got != "/sap/opu/odata4/dmo/ui_travel_o4/srvd/dmo/ui_travel/0001/" {
+		t.Errorf("ODataV4ServiceURL = %s", got)
+	}
 }
@@ -45,6 +45,7 @@ func (c *Client) FindDefinition(ctx context.Context, sourceURL string, source st
 
 	resp, err := c.transport.Request(ctx, endpoint, &RequestOptions{
 		Method:      http.MethodPost,
+		Idempotent:  true,
 		Body:        []byte(source),
 		ContentType: "text/plain",
 		Accept:      "application/*",
@@ -123,6 +124,7 @@ func (c *Client) FindReferences(ctx context.Context, objectURL string, line int,
 
 	resp, err := c.transport.Request(ctx, endpoint, &RequestOptions{
 		Method:      http.MethodPost,
+		Idempotent:  true,
 		Body:        []byte(body),
 		ContentType: "application/*",
 		Accept:      "application/*",
@@ -249,6 +251,7 @@ func (c *Client) CodeCompletion(ctx context.Context, sourceURL string, source st
 
 	resp, err := c.transport.Request(ctx, endpoint, &RequestOptions{
 		Method:      http.MethodPost,
+		Idempotent:  true,
 		Body:        []byte(source),
 		ContentType: "application/*",
 	})
@@ -328,6 +331,7 @@ func (c *Client) CodeCompletionFull(ctx context.Context, sourceURL string, sourc
 
 	resp, err := c.transport.Request(ctx, endpoint, &RequestOptions{
 		Method:      http.MethodPost,
+		Idempotent:  true,
 		Body:        []byte(source),
 		ContentType: "application/*",
 	})
@@ -412,6 +416,7 @@ prettyprintersettings:indentation="%t" prettyprintersettings:style="%s"/>`,
 func (c *Client) PrettyPrint(ctx context.Context, source string) (string, error) {
 	resp, err := c.transport.Request(ctx, "/sap/bc/adt/abapsource/prettyprinter", &RequestOptions{
 		Method:      http.MethodPost,
+		Idempotent:  true,
 		Body:        []byte(source),
 		ContentType: "text/plain",
 		Accept:      "text/plain",
@@ -566,6 +571,7 @@ func (c *Client) GetTypeHierarchy(ctx context.Context, sourceURL string, source
 
 	resp, err := c.transport.Request(ctx, endpoint, &RequestOptions{
 		Method:      http.MethodPost,
+		Idempotent:  true,
 		Body:        []byte(source),
 		ContentType: "text/plain",
 		Accept:      "application/*",
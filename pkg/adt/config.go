@@ -2,7 +2,6 @@
 package adt
 
 import (
-	"crypto/tls"
 	"net/http"
 	"net/http/cookiejar"
 	"time"
@@ -40,6 +39,15 @@ type Config struct {
 	Timeout time.Duration
 	// Cookies for cookie-based authentication (alternative to basic auth)
 	Cookies map[string]string
+	// OAuth configures OAuth2 authentication (alternative to basic auth);
+	// NewConfig turns it into TokenSource
+	OAuth *OAuthConfig
+	// TokenSource supplies bearer tokens; takes precedence over basic auth
+	TokenSource TokenSource
+	// ClientCertFile and ClientKeyFile are PEM files of an X.509 client
+	// certificate for mutual TLS (the key may be in the certificate file)
+	ClientCertFile string
+	ClientKeyFile  string
 	// Verbose enables verbose logging
 	Verbose bool
 	// Safety defines protection parameters to prevent unintended modifications
@@ -48,6 +56,26 @@ type Config struct {
 	Features FeatureConfig
 	// TerminalID for debugger session (shared with SAP GUI for cross-tool debugging)
 	TerminalID string
+	// RecordDir, if set, records HTTP traffic as a cassette in this directory
+	RecordDir string
+	// ReplayDir, if set, answers HTTP requests from the cassette in this directory
+	ReplayDir string
+	// MaxConcurrent limits the number of HTTP requests in flight (0 = unlimited)
+	MaxConcurrent int
+	// RequestsPerSecond limits the request rate (0 = unlimited)
+	RequestsPerSecond float64
+	// SessionPoolSize is the number of dedicated SAP sessions for lock/update/unlock
+	// sequences (0 = all requests share one session)
+	SessionPoolSize int
+	// SessionAcquireTimeout limits how long a lock waits for a free pooled
+	// session before failing with ErrSessionPoolExhausted (0 = 30s)
+	SessionAcquireTimeout time.Duration
+	// SessionLockTTL is how long a lock keeps its pooled session; when the
+	// pool runs out, the sessions of older locks that were never unlocked are
+	// replaced by new SAP sessions (0 = 15m)
+	SessionLockTTL time.Duration
+	// Retry controls retries of transient failures (503, connection resets, ...)
+	Retry RetryPolicy
 }
 
 // Option is a functional option for configuring the ADT client.
@@ -169,6 +197,11 @@ func (c *Config) HasBasicAuth() bool {
 	return c.Username != "" && c.Password != ""
 }
 
+// HasOAuth returns true if requests are authenticated with OAuth2 bearer tokens.
+func (c *Config) HasOAuth() bool {
+	return c.TokenSource != nil || c.OAuth != nil
+}
+
 // HasCookieAuth returns true if cookies are configured.
 func (c *Config) HasCookieAuth() bool {
 	return len(c.Cookies) > 0
@@ -187,12 +220,25 @@ func NewConfig(baseURL, username, password string, opts ...Option) *Config {
 		Timeout:     60 * time.Second,
 		Safety:      UnrestrictedSafetyConfig(), // Default: no restrictions for backwards compatibility
 		Features:    DefaultFeatureConfig(),     // Default: auto-detect all features
+		Retry:       DefaultRetryPolicy(),
 	}
 
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
+	if cfg.OAuth != nil && cfg.TokenSource == nil {
+		oauth := *cfg.OAuth
+		if oauth.HTTPClient == nil {
+			// Token requests use the same TLS settings as the system
+			oauth.HTTPClient = &http.Client{
+				Timeout:   cfg.Timeout,
+				Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: cfg.TLSConfig()},
+			}
+		}
+		cfg.TokenSource = NewOAuthTokenSource(oauth)
+	}
+
 	return cfg
 }
 
@@ -218,10 +264,12 @@ func (c *Config) NewHTTPClient() *http.Client {
 	jar, _ := cookiejar.New(nil)
 
 	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment, // Honor HTTP_PROXY/HTTPS_PROXY env vars
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: c.InsecureSkipVerify,
-		},
+		Proxy:           http.ProxyFromEnvironment, // Honor HTTP_PROXY/HTTPS_PROXY env vars
+		TLSClientConfig: c.TLSConfig(),
+	}
+	// Keep a connection per concurrent request alive instead of the default two
+	if c.MaxConcurrent > 0 {
+		transport.MaxIdleConnsPerHost = c.MaxConcurrent
 	}
 
 	return &http.Client{
@@ -230,3 +278,86 @@ func (c *Config) NewHTTPClient() *http.Client {
 		Timeout:   c.Timeout,
 	}
 }
+
+// WithRecording records all ADT HTTP traffic to a cassette directory, with
+// credentials, cookies and CSRF tokens redacted. See NewRecorder.
+func WithRecording(dir string) Option {
+	return func(c *Config) {
+		c.RecordDir = dir
+	}
+}
+
+// WithReplay answers all ADT HTTP requests from a cassette directory instead
+// of the SAP system. See NewReplayer.
+func WithReplay(dir string) Option {
+	return func(c *Config) {
+		c.ReplayDir = dir
+	}
+}
+
+// WithMaxConcurrent limits the number of ADT requests in flight at a time.
+// Requests beyond the limit wait for a free slot. 0 means unlimited.
+func WithMaxConcurrent(n int) Option {
+	return func(c *Config) {
+		c.MaxConcurrent = n
+	}
+}
+
+// WithRateLimit limits ADT requests to rps requests per second, spaced
+// evenly. 0 means unlimited.
+func WithRateLimit(rps float64) Option {
+	return func(c *Config) {
+		c.RequestsPerSecond = rps
+	}
+}
+
+// WithSessionPool gives each object lock a dedicated SAP session from a pool
+// of n sessions, so parallel edits do not share one stateful session.
+// Lock, update and unlock of an object run in the same session; LockObject
+// waits while all n sessions hold locks, up to Config.SessionAcquireTimeout,
+// and reclaims sessions of locks older than Config.SessionLockTTL.
+func WithSessionPool(n int) Option {
+	return func(c *Config) {
+		c.SessionPoolSize = n
+	}
+}
+
+// WithRetryPolicy sets the retry policy for transient failures.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Config) {
+		c.Retry = policy
+	}
+}
+
+// WithMaxRetries sets how often a request is retried after a transient
+// failure, keeping the default backoff. 0 disables retries.
+func WithMaxRetries(n int) Option {
+	return func(c *Config) {
+		c.Retry.MaxAttempts = n + 1
+	}
+}
+
+// WithOAuth authenticates with OAuth2 bearer tokens instead of basic auth.
+// See OAuthConfig and ServiceKey.OAuthConfig.
+func WithOAuth(oauth OAuthConfig) Option {
+	return func(c *Config) {
+		c.OAuth = &oauth
+	}
+}
+
+// WithTokenSource authenticates with bearer tokens from ts.
+func WithTokenSource(ts TokenSource) Option {
+	return func(c *Config) {
+		c.TokenSource = ts
+	}
+}
+
+// WithClientCertificate enables mutual TLS with a PEM client certificate and
+// key (keyFile may be empty if certFile contains both). SAP maps the
+// certificate to a user, so no password is needed.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(c *Config) {
+		c.ClientCertFile = certFile
+		c.ClientKeyFile = keyFile
+	}
+}
@@ -41,16 +41,30 @@ func (c *Client) LockObject(ctx context.Context, objectURL string, accessMode st
 	params.Set("_action", "LOCK")
 	params.Set("accessMode", accessMode)
 
-	resp, err := c.transport.Request(ctx, objectURL, &RequestOptions{
+	// With a session pool, the lock gets its own session; requests carrying
+	// the lock handle follow it there until UnlockObject.
+	lockCtx, sess, release, err := c.transport.lockSession(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("locking object: %w", err)
+	}
+
+	resp, err := c.transport.Request(lockCtx, objectURL, &RequestOptions{
 		Method: http.MethodPost,
 		Query:  params,
 		Accept: "application/vnd.sap.as+xml;charset=UTF-8;dataname=com.sap.adt.lock.result",
 	})
 	if err != nil {
+		release()
 		return nil, fmt.Errorf("locking object: %w", err)
 	}
 
-	return parseLockResult(resp.Body)
+	result, err := parseLockResult(resp.Body)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	c.transport.pinLock(result.LockHandle, sess)
+	return result, nil
 }
 
 func parseLockResult(data []byte) (*LockResult, error) {
@@ -97,6 +111,8 @@ func (c *Client) UnlockObject(ctx context.Context, objectURL string, lockHandle
 		Method: http.MethodPost,
 		Query:  params,
 	})
+	// The lock is gone or unusable either way; free its session
+	c.transport.unpinLock(lockHandle)
 	if err != nil {
 		return fmt.Errorf("unlocking object: %w", err)
 	}
@@ -153,10 +169,18 @@ const (
 	ObjectTypeInterface     CreatableObjectType = "INTF/OI"
 	ObjectTypeFunctionGroup CreatableObjectType = "FUGR/F"
 	ObjectTypeFunctionMod   CreatableObjectType = "FUGR/FF"
+	ObjectTypeFunctionIncl  CreatableObjectType = "FUGR/I"
 	ObjectTypeTable         CreatableObjectType = "TABL/DT"
 	ObjectTypePackage       CreatableObjectType = "DEVC/K"
+	// DDIC object types (created with the functions in ddic.go)
+	ObjectTypeDomain      CreatableObjectType = "DOMA/DD"
+	ObjectTypeDataElement CreatableObjectType = "DTEL/DE"
+	ObjectTypeStructure   CreatableObjectType = "TABL/DS"
+	ObjectTypeTableType   CreatableObjectType = "TTYP/DA"
+	ObjectTypeSearchHelp  CreatableObjectType = "SHLP/DH"
 	// RAP object types (read-only via ADT, created via RAP generators)
 	ObjectTypeDDLS CreatableObjectType = "DDLS/DF"  // CDS DDL Source
+	ObjectTypeDDLX CreatableObjectType = "DDLX/EX"  // CDS Metadata Extension
 	ObjectTypeBDEF CreatableObjectType = "BDEF/BDO" // Behavior Definition
 	ObjectTypeSRVD CreatableObjectType = "SRVD/SRV" // Service Definition
 	ObjectTypeSRVB CreatableObjectType = "SRVB/SVB" // Service Binding
@@ -170,7 +194,7 @@ type CreateObjectOptions struct {
 	PackageName string              `json:"packageName"`
 	Transport   string              `json:"transport,omitempty"`
 	Responsible string              `json:"responsible,omitempty"`
-	// For function modules - the function group name
+	// For function modules and function group includes - the function group name
 	ParentName string `json:"parentName,omitempty"`
 	// For packages - the software component (required for transportable packages)
 	SoftwareComponent string `json:"softwareComponent,omitempty"`
@@ -231,6 +255,11 @@ var objectTypes = map[CreatableObjectType]objectTypeInfo{
 		rootName:     "fmodule:abapFunctionModule",
 		namespace:    `xmlns:fmodule="http://www.sap.com/adt/functions/fmodules"`,
 	},
+	ObjectTypeFunctionIncl: {
+		creationPath: "/sap/bc/adt/functions/groups/%s/includes",
+		rootName:     "finclude:abapFunctionGroupInclude",
+		namespace:    `xmlns:finclude="http://www.sap.com/adt/functions/fincludes"`,
+	},
 	ObjectTypePackage: {
 		creationPath: "/sap/bc/adt/packages",
 		rootName:     "pack:package",
@@ -242,6 +271,11 @@ var objectTypes = map[CreatableObjectType]objectTypeInfo{
 		rootName:     "ddl:ddlSource",
 		namespace:    `xmlns:ddl="http://www.sap.com/adt/ddic/ddlsources"`,
 	},
+	ObjectTypeDDLX: {
+		creationPath: "/sap/bc/adt/ddic/ddlx/sources",
+		rootName:     "ddlx:ddlxSource",
+		namespace:    `xmlns:ddlx="http://www.sap.com/adt/ddic/ddlxsources"`,
+	},
 	ObjectTypeBDEF: {
 		creationPath: "/sap/bc/adt/bo/behaviordefinitions",
 		rootName:     "bdef:behaviorDefinition",
@@ -339,7 +373,7 @@ func (c *Client) CreateObject(ctx context.Context, opts CreateObjectOptions) err
 
 	// Build creation URL
 	creationURL := typeInfo.creationPath
-	if opts.ObjectType == ObjectTypeFunctionMod && opts.ParentName != "" {
+	if (opts.ObjectType == ObjectTypeFunctionMod || opts.ObjectType == ObjectTypeFunctionIncl) && opts.ParentName != "" {
 		creationURL = fmt.Sprintf(typeInfo.creationPath, strings.ToUpper(opts.ParentName))
 	}
 
@@ -439,8 +473,8 @@ func buildCreateObjectBody(opts CreateObjectOptions, typeInfo objectTypeInfo, de
 			typeInfo.rootName)
 	}
 
-	// For function modules, reference the function group
-	if opts.ObjectType == ObjectTypeFunctionMod {
+	// For function modules and includes, reference the function group
+	if opts.ObjectType == ObjectTypeFunctionMod || opts.ObjectType == ObjectTypeFunctionIncl {
 		return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <%s %s xmlns:adtcore="http://www.sap.com/adt/core"
   adtcore:description="%s"
@@ -595,6 +629,8 @@ func (c *Client) DeleteObject(ctx context.Context, objectURL string, lockHandle
 	if err != nil {
 		return fmt.Errorf("deleting object: %w", err)
 	}
+	// Deleting the object releases its lock
+	c.transport.unpinLock(lockHandle)
 
 	return nil
 }
@@ -622,11 +658,27 @@ func GetObjectURL(objectType CreatableObjectType, name string, parentName string
 		parentName = strings.ToUpper(parentName)
 		encodedParent := url.PathEscape(parentName)
 		return fmt.Sprintf("/sap/bc/adt/functions/groups/%s/fmodules/%s", encodedParent, encodedName)
+	case ObjectTypeFunctionIncl:
+		encodedParent := url.PathEscape(strings.ToUpper(parentName))
+		return fmt.Sprintf("/sap/bc/adt/functions/groups/%s/includes/%s", encodedParent, encodedName)
 	case ObjectTypePackage:
 		return fmt.Sprintf("/sap/bc/adt/packages/%s", encodedName)
+	// DDIC object types
+	case ObjectTypeDomain:
+		return DDICObjectURL(DDICDomain, name)
+	case ObjectTypeDataElement:
+		return DDICObjectURL(DDICDataElement, name)
+	case ObjectTypeStructure:
+		return DDICObjectURL(DDICStructure, name)
+	case ObjectTypeTableType:
+		return DDICObjectURL(DDICTableType, name)
+	case ObjectTypeSearchHelp:
+		return DDICObjectURL(DDICSearchHelp, name)
 	// RAP object types - use lowercase for CDS objects
 	case ObjectTypeDDLS:
 		return fmt.Sprintf("/sap/bc/adt/ddic/ddl/sources/%s", url.PathEscape(strings.ToLower(name)))
+	case ObjectTypeDDLX:
+		return fmt.Sprintf("/sap/bc/adt/ddic/ddlx/sources/%s", url.PathEscape(strings.ToLower(name)))
 	case ObjectTypeBDEF:
 		return fmt.Sprintf("/sap/bc/adt/bo/behaviordefinitions/%s", url.PathEscape(strings.ToLower(name)))
 	case ObjectTypeSRVD:
@@ -769,15 +821,25 @@ type PublishResult struct {
 // serviceName is the service binding name (e.g., "ZTRAVEL_SB")
 // serviceVersion is typically "0001"
 func (c *Client) PublishServiceBinding(ctx context.Context, serviceName string, serviceVersion string) (*PublishResult, error) {
-	return c.publishUnpublishServiceBinding(ctx, "publishjobs", serviceName, serviceVersion)
+	return c.publishUnpublishServiceBinding(ctx, "odatav2", "publishjobs", serviceName, serviceVersion)
 }
 
 // UnpublishServiceBinding unpublishes a service binding.
 func (c *Client) UnpublishServiceBinding(ctx context.Context, serviceName string, serviceVersion string) (*PublishResult, error) {
-	return c.publishUnpublishServiceBinding(ctx, "unpublishjobs", serviceName, serviceVersion)
+	return c.publishUnpublishServiceBinding(ctx, "odatav2", "unpublishjobs", serviceName, serviceVersion)
+}
+
+// PublishServiceBindingV4 publishes an OData V4 service binding.
+func (c *Client) PublishServiceBindingV4(ctx context.Context, serviceName string, serviceVersion string) (*PublishResult, error) {
+	return c.publishUnpublishServiceBinding(ctx, "odatav4", "publishjobs", serviceName, serviceVersion)
+}
+
+// UnpublishServiceBindingV4 unpublishes an OData V4 service binding.
+func (c *Client) UnpublishServiceBindingV4(ctx context.Context, serviceName string, serviceVersion string) (*PublishResult, error) {
+	return c.publishUnpublishServiceBinding(ctx, "odatav4", "unpublishjobs", serviceName, serviceVersion)
 }
 
-func (c *Client) publishUnpublishServiceBinding(ctx context.Context, action, serviceName, serviceVersion string) (*PublishResult, error) {
+func (c *Client) publishUnpublishServiceBinding(ctx context.Context, protocol, action, serviceName, serviceVersion string) (*PublishResult, error) {
 	if serviceVersion == "" {
 		serviceVersion = "0001"
 	}
@@ -790,7 +852,7 @@ func (c *Client) publishUnpublishServiceBinding(ctx context.Context, action, ser
   <adtcore:objectReference adtcore:name="%s"/>
 </adtcore:objectReferences>`, serviceName)
 
-	path := fmt.Sprintf("/sap/bc/adt/businessservices/odatav2/%s", action)
+	path := fmt.Sprintf("/sap/bc/adt/businessservices/%s/%s", protocol, action)
 
 	resp, err := c.transport.Request(ctx, path, &RequestOptions{
 		Method:      http.MethodPost,
@@ -842,6 +904,7 @@ type CreateTableOptions struct {
 	Transport     string       `json:"transport,omitempty"` // Transport request (optional for $TMP)
 	DeliveryClass string       `json:"deliveryClass,omitempty"` // A=Application, C=Customizing, L=Temp, etc. (default: A)
 	TableCategory string       `json:"tableCategory,omitempty"` // TRANSPARENT (default), STRUCTURE, etc.
+	Source        string       `json:"source,omitempty"`        // Complete DDL source; Fields are ignored when set
 }
 
 // CreateTable creates a new DDIC transparent table from JSON-like options.
@@ -856,7 +919,7 @@ func (c *Client) CreateTable(ctx context.Context, opts CreateTableOptions) error
 	if opts.Name == "" || len(opts.Name) > 30 {
 		return fmt.Errorf("table name must be 1-30 characters")
 	}
-	if len(opts.Fields) == 0 {
+	if len(opts.Fields) == 0 && opts.Source == "" {
 		return fmt.Errorf("at least one field is required")
 	}
 	if opts.Package == "" {
@@ -870,47 +933,72 @@ func (c *Client) CreateTable(ctx context.Context, opts CreateTableOptions) error
 	}
 
 	// Generate DDL source
-	ddlSource := generateTableDDL(opts)
+	ddlSource := opts.Source
+	if ddlSource == "" {
+		ddlSource = generateTableDDL(opts)
+	}
+
+	// Structures have their own collection and object type
+	collection, objectType, contentType := "/sap/bc/adt/ddic/tables", "TABL/DT", "application/vnd.sap.adt.tables.v2+xml"
+	if opts.TableCategory == "STRUCTURE" {
+		collection, objectType, contentType = "/sap/bc/adt/ddic/structures", "TABL/DS", "application/vnd.sap.adt.structures.v2+xml"
+	}
 
+	tableURL, err := c.createDDLObject(ctx, collection, objectType, contentType, opts.Name, opts.Description, opts.Package, opts.Transport, ddlSource)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Activate
+	if _, err := c.Activate(ctx, tableURL, opts.Name); err != nil {
+		return fmt.Errorf("activating table: %w", err)
+	}
+
+	return nil
+}
+
+// createDDLObject creates an inactive table or structure and writes its DDL
+// source. It returns the object URL.
+func (c *Client) createDDLObject(ctx context.Context, collection, objectType, contentType, name, description, pkg, transport, ddlSource string) (string, error) {
 	// Step 1: Create table object
 	createBody := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <blue:blueSource xmlns:blue="http://www.sap.com/wbobj/blue"
                  xmlns:adtcore="http://www.sap.com/adt/core"
                  adtcore:name="%s"
-                 adtcore:type="TABL/DT"
+                 adtcore:type="%s"
                  adtcore:description="%s">
   <adtcore:packageRef adtcore:name="%s"/>
-</blue:blueSource>`, opts.Name, escapeXML(opts.Description), opts.Package)
+</blue:blueSource>`, name, objectType, escapeXML(description), pkg)
 
 	params := url.Values{}
-	if opts.Transport != "" {
-		params.Set("corrNr", opts.Transport)
+	if transport != "" {
+		params.Set("corrNr", transport)
 	}
 
-	_, err := c.transport.Request(ctx, "/sap/bc/adt/ddic/tables", &RequestOptions{
+	_, err := c.transport.Request(ctx, collection, &RequestOptions{
 		Method:      http.MethodPost,
 		Query:       params,
 		Body:        []byte(createBody),
-		ContentType: "application/vnd.sap.adt.tables.v2+xml",
-		Accept:      "application/vnd.sap.adt.tables.v2+xml",
+		ContentType: contentType,
+		Accept:      contentType,
 	})
 	if err != nil {
-		return fmt.Errorf("creating table object: %w", err)
+		return "", fmt.Errorf("creating table object: %w", err)
 	}
 
 	// Step 2: Lock, update source, unlock
-	tableURL := fmt.Sprintf("/sap/bc/adt/ddic/tables/%s", strings.ToLower(opts.Name))
+	tableURL := fmt.Sprintf("%s/%s", collection, url.PathEscape(strings.ToLower(name)))
 	sourceURL := tableURL + "/source/main"
 
 	lock, err := c.LockObject(ctx, tableURL, "MODIFY")
 	if err != nil {
-		return fmt.Errorf("locking table: %w", err)
+		return "", fmt.Errorf("locking table: %w", err)
 	}
 
 	params = url.Values{}
 	params.Set("lockHandle", lock.LockHandle)
-	if opts.Transport != "" {
-		params.Set("corrNr", opts.Transport)
+	if transport != "" {
+		params.Set("corrNr", transport)
 	}
 
 	_, err = c.transport.Request(ctx, sourceURL, &RequestOptions{
@@ -921,18 +1009,12 @@ func (c *Client) CreateTable(ctx context.Context, opts CreateTableOptions) error
 	})
 	if err != nil {
 		c.UnlockObject(ctx, tableURL, lock.LockHandle)
-		return fmt.Errorf("updating table source: %w", err)
+		return "", fmt.Errorf("updating table source: %w", err)
 	}
 
 	// Unlock BEFORE activation
 	c.UnlockObject(ctx, tableURL, lock.LockHandle)
-
-	// Step 3: Activate
-	if _, err := c.Activate(ctx, tableURL, opts.Name); err != nil {
-		return fmt.Errorf("activating table: %w", err)
-	}
-
-	return nil
+	return tableURL, nil
 }
 
 // generateTableDDL converts CreateTableOptions to CDS-style DDL source.
@@ -942,6 +1024,14 @@ func generateTableDDL(opts CreateTableOptions) string {
 	// Annotations - must match SAP's expected format
 	sb.WriteString(fmt.Sprintf("@EndUserText.label : '%s'\n", escapeQuote(opts.Description)))
 	sb.WriteString("@AbapCatalog.enhancement.category : #NOT_EXTENSIBLE\n")
+	if opts.TableCategory == "STRUCTURE" {
+		sb.WriteString(fmt.Sprintf("define structure %s {\n\n", strings.ToLower(opts.Name)))
+		for _, f := range opts.Fields {
+			sb.WriteString(fmt.Sprintf("  %s : %s;\n", strings.ToLower(f.Name), mapFieldType(f)))
+		}
+		sb.WriteString("\n}\n")
+		return sb.String()
+	}
 	sb.WriteString(fmt.Sprintf("@AbapCatalog.tableCategory : #%s\n", opts.TableCategory))
 	sb.WriteString(fmt.Sprintf("@AbapCatalog.deliveryClass : #%s\n", opts.DeliveryClass))
 	sb.WriteString("@AbapCatalog.dataMaintenance : #ALLOWED\n")
@@ -0,0 +1,1098 @@
+package adt
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// --- DDIC Object Kinds ---
+
+// DDICKind is a kind of dictionary object with a JSON definition.
+type DDICKind string
+
+const (
+	DDICDomain      DDICKind = "DOMA"
+	DDICDataElement DDICKind = "DTEL"
+	DDICStructure   DDICKind = "STRU" // DDL-based structure (TABL/DS)
+	DDICTableType   DDICKind = "TTYP"
+	DDICSearchHelp  DDICKind = "SHLP" // Elementary search help
+)
+
+// ddicKindInfo contains the ADT metadata of a dictionary object kind.
+type ddicKindInfo struct {
+	collection  string
+	contentType string
+	rootName    string
+	namespace   string
+	objectType  string
+}
+
+var ddicKinds = map[DDICKind]ddicKindInfo{
+	DDICDomain: {
+		collection:  "/sap/bc/adt/ddic/domains",
+		contentType: "application/vnd.sap.adt.domains.v2+xml",
+		rootName:    "doma:domain",
+		namespace:   `xmlns:doma="http://www.sap.com/dictionary/domain"`,
+		objectType:  "DOMA/DD",
+	},
+	DDICDataElement: {
+		collection:  "/sap/bc/adt/ddic/dataelements",
+		contentType: "application/vnd.sap.adt.dataelements.v2+xml",
+		rootName:    "blue:wbobj",
+		namespace:   `xmlns:blue="http://www.sap.com/wbobj/dictionary/dtel"`,
+		objectType:  "DTEL/DE",
+	},
+	DDICStructure: {
+		collection:  "/sap/bc/adt/ddic/structures",
+		contentType: "application/vnd.sap.adt.structures.v2+xml",
+		objectType:  "TABL/DS",
+	},
+	DDICTableType: {
+		collection:  "/sap/bc/adt/ddic/tabletypes",
+		contentType: "application/vnd.sap.adt.tabletype.v1+xml",
+		rootName:    "ttyp:tableType",
+		namespace:   `xmlns:ttyp="http://www.sap.com/dictionary/tabletype"`,
+		objectType:  "TTYP/DA",
+	},
+	DDICSearchHelp: {
+		collection:  "/sap/bc/adt/ddic/searchhelps",
+		contentType: "application/vnd.sap.adt.searchhelps.v1+xml",
+		rootName:    "shlp:searchHelp",
+		namespace:   `xmlns:shlp="http://www.sap.com/dictionary/searchhelp"`,
+		objectType:  "SHLP/DH",
+	},
+}
+
+// ParseDDICKind parses a kind name like "DTEL" or an ADT type like "DTEL/DE".
+// STRU and TABL/DS both denote structures.
+func ParseDDICKind(s string) (DDICKind, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	for kind, info := range ddicKinds {
+		if s == string(kind) || s == info.objectType {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported DDIC type %q (supported: DOMA, DTEL, STRU, TTYP, SHLP)", s)
+}
+
+// DDICObjectURL returns the ADT URL of a dictionary object, or "" for an
+// unknown kind.
+func DDICObjectURL(kind DDICKind, name string) string {
+	info, ok := ddicKinds[kind]
+	if !ok {
+		return ""
+	}
+	return info.collection + "/" + url.PathEscape(strings.ToLower(name))
+}
+
+// newDDICObject describes an XML-defined object of the given kind.
+func newDDICObject(kind DDICKind, name, description, pkg, transport, content string) ddicObject {
+	info := ddicKinds[kind]
+	return ddicObject{
+		creationPath: info.collection,
+		objectURL:    DDICObjectURL(kind, name),
+		contentType:  info.contentType,
+		rootName:     info.rootName,
+		namespace:    info.namespace,
+		objectType:   info.objectType,
+		name:         name,
+		description:  description,
+		pkg:          pkg,
+		transport:    transport,
+		content:      content,
+	}
+}
+
+// DeleteDDICObject deletes a dictionary object. SAP refuses to delete
+// objects that are still used; delete or change the users first.
+func (c *Client) DeleteDDICObject(ctx context.Context, kind DDICKind, name, transport string) error {
+	if err := c.checkSafety(OpDelete, "DeleteDDICObject"); err != nil {
+		return err
+	}
+	objectURL := DDICObjectURL(kind, name)
+	if objectURL == "" {
+		return fmt.Errorf("unsupported DDIC type %q", kind)
+	}
+	if _, err := c.checkDDICPackage(ctx, kind, name, ""); err != nil {
+		return err
+	}
+	lock, err := c.LockObject(ctx, objectURL, "MODIFY")
+	if err != nil {
+		return fmt.Errorf("locking %s: %w", strings.ToUpper(name), err)
+	}
+	if err := c.DeleteObject(ctx, objectURL, lock.LockHandle, transport); err != nil {
+		c.UnlockObject(ctx, objectURL, lock.LockHandle)
+		return err
+	}
+	return nil
+}
+
+// --- DDIC Domains and Data Elements ---
+
+// DomainFixValue is a fixed value (or range) of a domain.
+type DomainFixValue struct {
+	Low  string `json:"low" xml:"low"`
+	High string `json:"high,omitempty" xml:"high"`
+	Text string `json:"text,omitempty" xml:"text"`
+}
+
+// DomainOptions defines a DDIC domain.
+type DomainOptions struct {
+	Name           string           `json:"name"`
+	Description    string           `json:"description"`
+	Package        string           `json:"package"`
+	Transport      string           `json:"transport,omitempty"`
+	DataType       string           `json:"dataType"`                 // CHAR, NUMC, DEC, INT4, ...
+	Length         int              `json:"length,omitempty"`         // Number of characters/digits
+	Decimals       int              `json:"decimals,omitempty"`       // Decimal places (DEC, CURR, QUAN)
+	OutputLength   int              `json:"outputLength,omitempty"`   // Default: Length
+	ConversionExit string           `json:"conversionExit,omitempty"` // e.g. ALPHA
+	Lowercase      bool             `json:"lowercase,omitempty"`
+	Signed         bool             `json:"signed,omitempty"`
+	ValueTable     string           `json:"valueTable,omitempty"`
+	FixValues      []DomainFixValue `json:"fixValues,omitempty"`
+}
+
+// DataElementOptions defines a DDIC data element, typed by a domain or a
+// built-in type.
+type DataElementOptions struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Package     string `json:"package"`
+	Transport   string `json:"transport,omitempty"`
+	Domain      string `json:"domain,omitempty"`   // Domain name; if empty, DataType is used
+	DataType    string `json:"dataType,omitempty"` // Built-in type when no domain is given
+	Length      int    `json:"length,omitempty"`
+	Decimals    int    `json:"decimals,omitempty"`
+	ShortLabel  string `json:"shortLabel,omitempty"`  // Up to 10 characters
+	MediumLabel string `json:"mediumLabel,omitempty"` // Up to 20 characters
+	LongLabel   string `json:"longLabel,omitempty"`   // Up to 40 characters
+	Heading     string `json:"heading,omitempty"`     // Up to 55 characters
+	SearchHelp  string `json:"searchHelp,omitempty"`
+	ParameterID string `json:"parameterId,omitempty"` // SET/GET parameter
+}
+
+// CreateDomain creates a DDIC domain. The domain is created inactive;
+// activate it with Activate before data elements refer to it.
+func (c *Client) CreateDomain(ctx context.Context, opts DomainOptions) error {
+	if err := c.checkSafety(OpCreate, "CreateDomain"); err != nil {
+		return err
+	}
+	obj, err := domainObject(opts)
+	if err != nil {
+		return err
+	}
+	return c.createDDICObject(ctx, obj)
+}
+
+// UpdateDomain replaces the definition of a DDIC domain with opts, typically
+// a definition read with GetDomain and changed. Package must be empty or the
+// current package; objects are not moved. The change is inactive until
+// activated.
+func (c *Client) UpdateDomain(ctx context.Context, opts DomainOptions) error {
+	if err := c.checkSafety(OpUpdate, "UpdateDomain"); err != nil {
+		return err
+	}
+	obj, err := domainObject(opts)
+	if err != nil {
+		return err
+	}
+	return c.updateDDICObject(ctx, obj)
+}
+
+// domainObject normalizes opts and builds the domain document.
+func domainObject(opts DomainOptions) (ddicObject, error) {
+	opts.Name = strings.ToUpper(opts.Name)
+	opts.Package = strings.ToUpper(opts.Package)
+	opts.DataType = strings.ToUpper(opts.DataType)
+	if opts.Name == "" || opts.DataType == "" {
+		return ddicObject{}, fmt.Errorf("domain name and data type are required")
+	}
+	if opts.OutputLength == 0 {
+		opts.OutputLength = opts.Length
+	}
+
+	var fixValues strings.Builder
+	for i, fv := range opts.FixValues {
+		fmt.Fprintf(&fixValues, `
+        <doma:fixValue>
+          <doma:position>%04d</doma:position>
+          <doma:low>%s</doma:low>
+          <doma:high>%s</doma:high>
+          <doma:text>%s</doma:text>
+        </doma:fixValue>`, i+1, escapeXML(fv.Low), escapeXML(fv.High), escapeXML(fv.Text))
+	}
+
+	content := fmt.Sprintf(`
+  <doma:content>
+    <doma:typeInformation>
+      <doma:datatype>%s</doma:datatype>
+      <doma:length>%d</doma:length>
+      <doma:decimals>%d</doma:decimals>
+    </doma:typeInformation>
+    <doma:outputInformation>
+      <doma:length>%d</doma:length>
+      <doma:style>00</doma:style>
+      <doma:conversionExit>%s</doma:conversionExit>
+      <doma:signExists>%t</doma:signExists>
+      <doma:lowercase>%t</doma:lowercase>
+      <doma:ampmFormat>false</doma:ampmFormat>
+    </doma:outputInformation>
+    <doma:valueInformation>
+      <doma:valueTableRef adtcore:name="%s"/>
+      <doma:appendExists>false</doma:appendExists>
+      <doma:fixValues>%s
+      </doma:fixValues>
+    </doma:valueInformation>
+  </doma:content>`,
+		opts.DataType, opts.Length, opts.Decimals,
+		opts.OutputLength, escapeXML(strings.ToUpper(opts.ConversionExit)), opts.Signed, opts.Lowercase,
+		escapeXML(strings.ToUpper(opts.ValueTable)), fixValues.String())
+
+	return newDDICObject(DDICDomain, opts.Name, opts.Description, opts.Package, opts.Transport, content), nil
+}
+
+// CreateDataElement creates a DDIC data element. The data element is created
+// inactive; activate it with Activate before tables refer to it.
+func (c *Client) CreateDataElement(ctx context.Context, opts DataElementOptions) error {
+	if err := c.checkSafety(OpCreate, "CreateDataElement"); err != nil {
+		return err
+	}
+	obj, err := dataElementObject(opts)
+	if err != nil {
+		return err
+	}
+	return c.createDDICObject(ctx, obj)
+}
+
+// UpdateDataElement replaces the definition of a DDIC data element with
+// opts, typically a definition read with GetDataElement and changed.
+// Package must be empty or the current package; objects are not moved. The
+// change is inactive until activated.
+func (c *Client) UpdateDataElement(ctx context.Context, opts DataElementOptions) error {
+	if err := c.checkSafety(OpUpdate, "UpdateDataElement"); err != nil {
+		return err
+	}
+	obj, err := dataElementObject(opts)
+	if err != nil {
+		return err
+	}
+	return c.updateDDICObject(ctx, obj)
+}
+
+// dataElementObject normalizes opts and builds the data element document.
+func dataElementObject(opts DataElementOptions) (ddicObject, error) {
+	opts.Name = strings.ToUpper(opts.Name)
+	opts.Package = strings.ToUpper(opts.Package)
+	if opts.Name == "" {
+		return ddicObject{}, fmt.Errorf("data element name is required")
+	}
+
+	typeKind, typeName := "domain", strings.ToUpper(opts.Domain)
+	if typeName == "" {
+		if opts.DataType == "" {
+			return ddicObject{}, fmt.Errorf("data element %s needs a domain or a data type", opts.Name)
+		}
+		typeKind = "predefinedAbapType"
+	}
+	label := func(text, fallback string) string {
+		if text == "" {
+			return fallback
+		}
+		return text
+	}
+	short := label(opts.ShortLabel, opts.Description)
+	medium := label(opts.MediumLabel, opts.Description)
+	long := label(opts.LongLabel, opts.Description)
+	heading := label(opts.Heading, opts.Description)
+
+	content := fmt.Sprintf(`
+  <dtel:dataElement xmlns:dtel="http://www.sap.com/adt/dictionary/dataelements">
+    <dtel:typeKind>%s</dtel:typeKind>
+    <dtel:typeName>%s</dtel:typeName>
+    <dtel:dataType>%s</dtel:dataType>
+    <dtel:dataTypeLength>%d</dtel:dataTypeLength>
+    <dtel:dataTypeDecimals>%d</dtel:dataTypeDecimals>
+    <dtel:shortFieldLabel>%s</dtel:shortFieldLabel>
+    <dtel:shortFieldLength>10</dtel:shortFieldLength>
+    <dtel:shortFieldMaxLength>10</dtel:shortFieldMaxLength>
+    <dtel:mediumFieldLabel>%s</dtel:mediumFieldLabel>
+    <dtel:mediumFieldLength>20</dtel:mediumFieldLength>
+    <dtel:mediumFieldMaxLength>20</dtel:mediumFieldMaxLength>
+    <dtel:longFieldLabel>%s</dtel:longFieldLabel>
+    <dtel:longFieldLength>40</dtel:longFieldLength>
+    <dtel:longFieldMaxLength>40</dtel:longFieldMaxLength>
+    <dtel:headingFieldLabel>%s</dtel:headingFieldLabel>
+    <dtel:headingFieldLength>55</dtel:headingFieldLength>
+    <dtel:headingFieldMaxLength>55</dtel:headingFieldMaxLength>
+    <dtel:searchHelp>%s</dtel:searchHelp>
+    <dtel:searchHelpParameter/>
+    <dtel:setGetParameter>%s</dtel:setGetParameter>
+    <dtel:defaultComponentName/>
+    <dtel:deactivateInputHistory>false</dtel:deactivateInputHistory>
+    <dtel:changeDocument>false</dtel:changeDocument>
+    <dtel:leftToRightDirection>false</dtel:leftToRightDirection>
+    <dtel:deactivateBIDIFiltering>false</dtel:deactivateBIDIFiltering>
+  </dtel:dataElement>`,
+		typeKind, escapeXML(typeName), escapeXML(strings.ToUpper(opts.DataType)), opts.Length, opts.Decimals,
+		escapeXML(truncate(short, 10)), escapeXML(truncate(medium, 20)), escapeXML(truncate(long, 40)), escapeXML(truncate(heading, 55)),
+		escapeXML(strings.ToUpper(opts.SearchHelp)), escapeXML(strings.ToUpper(opts.ParameterID)))
+
+	return newDDICObject(DDICDataElement, opts.Name, opts.Description, opts.Package, opts.Transport, content), nil
+}
+
+// GetDomain reads the definition of a DDIC domain.
+func (c *Client) GetDomain(ctx context.Context, name string) (*DomainOptions, error) {
+	if err := c.checkSafety(OpRead, "GetDomain"); err != nil {
+		return nil, err
+	}
+	resp, err := c.transport.Request(ctx, DDICObjectURL(DDICDomain, name), &RequestOptions{
+		Method: http.MethodGet,
+		Accept: "application/vnd.sap.adt.domains.v2+xml",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting domain: %w", err)
+	}
+
+	var doc struct {
+		Name        string `xml:"name,attr"`
+		Description string `xml:"description,attr"`
+		PackageRef  struct {
+			Name string `xml:"name,attr"`
+		} `xml:"packageRef"`
+		Content struct {
+			Type struct {
+				DataType string `xml:"datatype"`
+				Length   int    `xml:"length"`
+				Decimals int    `xml:"decimals"`
+			} `xml:"typeInformation"`
+			Output struct {
+				Length         int    `xml:"length"`
+				ConversionExit string `xml:"conversionExit"`
+				SignExists     bool   `xml:"signExists"`
+				Lowercase      bool   `xml:"lowercase"`
+			} `xml:"outputInformation"`
+			Value struct {
+				ValueTableRef struct {
+					Name string `xml:"name,attr"`
+				} `xml:"valueTableRef"`
+				FixValues []DomainFixValue `xml:"fixValues>fixValue"`
+			} `xml:"valueInformation"`
+		} `xml:"content"`
+	}
+	if err := xml.Unmarshal(resp.Body, &doc); err != nil {
+		return nil, fmt.Errorf("parsing domain: %w", err)
+	}
+	return &DomainOptions{
+		Name:           strings.ToUpper(doc.Name),
+		Description:    doc.Description,
+		Package:        doc.PackageRef.Name,
+		DataType:       doc.Content.Type.DataType,
+		Length:         doc.Content.Type.Length,
+		Decimals:       doc.Content.Type.Decimals,
+		OutputLength:   doc.Content.Output.Length,
+		ConversionExit: doc.Content.Output.ConversionExit,
+		Lowercase:      doc.Content.Output.Lowercase,
+		Signed:         doc.Content.Output.SignExists,
+		ValueTable:     doc.Content.Value.ValueTableRef.Name,
+		FixValues:      doc.Content.Value.FixValues,
+	}, nil
+}
+
+// GetDataElement reads the definition of a DDIC data element.
+func (c *Client) GetDataElement(ctx context.Context, name string) (*DataElementOptions, error) {
+	if err := c.checkSafety(OpRead, "GetDataElement"); err != nil {
+		return nil, err
+	}
+	resp, err := c.transport.Request(ctx, DDICObjectURL(DDICDataElement, name), &RequestOptions{
+		Method: http.MethodGet,
+		Accept: "application/vnd.sap.adt.dataelements.v2+xml",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting data element: %w", err)
+	}
+
+	var doc struct {
+		Name        string `xml:"name,attr"`
+		Description string `xml:"description,attr"`
+		PackageRef  struct {
+			Name string `xml:"name,attr"`
+		} `xml:"packageRef"`
+		DataElement struct {
+			TypeKind    string `xml:"typeKind"`
+			TypeName    string `xml:"typeName"`
+			DataType    string `xml:"dataType"`
+			Length      int    `xml:"dataTypeLength"`
+			Decimals    int    `xml:"dataTypeDecimals"`
+			Short       string `xml:"shortFieldLabel"`
+			Medium      string `xml:"mediumFieldLabel"`
+			Long        string `xml:"longFieldLabel"`
+			Heading     string `xml:"headingFieldLabel"`
+			SearchHelp  string `xml:"searchHelp"`
+			SetGetParam string `xml:"setGetParameter"`
+		} `xml:"dataElement"`
+	}
+	if err := xml.Unmarshal(resp.Body, &doc); err != nil {
+		return nil, fmt.Errorf("parsing data element: %w", err)
+	}
+	de := doc.DataElement
+	opts := &DataElementOptions{
+		Name:        strings.ToUpper(doc.Name),
+		Description: doc.Description,
+		Package:     doc.PackageRef.Name,
+		ShortLabel:  de.Short,
+		MediumLabel: de.Medium,
+		LongLabel:   de.Long,
+		Heading:     de.Heading,
+		SearchHelp:  de.SearchHelp,
+		ParameterID: de.SetGetParam,
+	}
+	if de.TypeKind == "domain" {
+		opts.Domain = de.TypeName
+	} else {
+		opts.DataType, opts.Length, opts.Decimals = de.DataType, de.Length, de.Decimals
+	}
+	return opts, nil
+}
+
+// --- DDIC Structures ---
+
+// StructureOptions defines a DDL-based DDIC structure.
+type StructureOptions struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Package     string       `json:"package"`
+	Transport   string       `json:"transport,omitempty"`
+	Fields      []TableField `json:"fields,omitempty"` // Types as in CreateTable, typically data elements
+	Source      string       `json:"source,omitempty"` // Complete DDL source; Fields are ignored when set
+}
+
+// structureSource returns the DDL source of the structure.
+func (opts *StructureOptions) structureSource() (string, error) {
+	if opts.Source != "" {
+		return opts.Source, nil
+	}
+	if len(opts.Fields) == 0 {
+		return "", fmt.Errorf("structure %s needs fields or a source", opts.Name)
+	}
+	return generateTableDDL(CreateTableOptions{
+		Name:          opts.Name,
+		Description:   opts.Description,
+		Fields:        opts.Fields,
+		TableCategory: "STRUCTURE",
+	}), nil
+}
+
+// CreateStructure creates a DDIC structure from fields or DDL source. The
+// structure is created inactive; activate it with Activate.
+func (c *Client) CreateStructure(ctx context.Context, opts StructureOptions) error {
+	if err := c.checkSafety(OpCreate, "CreateStructure"); err != nil {
+		return err
+	}
+	opts.Name = strings.ToUpper(opts.Name)
+	opts.Package = strings.ToUpper(opts.Package)
+	if opts.Name == "" || len(opts.Name) > 30 {
+		return fmt.Errorf("structure name must be 1-30 characters")
+	}
+	source, err := opts.structureSource()
+	if err != nil {
+		return err
+	}
+	if err := c.checkPackageSafety(opts.Package); err != nil {
+		return err
+	}
+	if opts.Package != "" && !c.packageExists(ctx, opts.Package) {
+		return fmt.Errorf("package %s does not exist - create it first to avoid orphan locks", opts.Package)
+	}
+	info := ddicKinds[DDICStructure]
+	_, err = c.createDDLObject(ctx, info.collection, info.objectType, info.contentType, opts.Name, opts.Description, opts.Package, opts.Transport, source)
+	return err
+}
+
+// UpdateStructure replaces the DDL source of a DDIC structure, generated
+// from Fields unless Source is set. Package must be empty or the current
+// package. The change is inactive until activated.
+func (c *Client) UpdateStructure(ctx context.Context, opts StructureOptions) error {
+	if err := c.checkSafety(OpUpdate, "UpdateStructure"); err != nil {
+		return err
+	}
+	opts.Name = strings.ToUpper(opts.Name)
+	source, err := opts.structureSource()
+	if err != nil {
+		return err
+	}
+	if _, err := c.checkDDICPackage(ctx, DDICStructure, opts.Name, opts.Package); err != nil {
+		return err
+	}
+	objectURL := DDICObjectURL(DDICStructure, opts.Name)
+	lock, err := c.LockObject(ctx, objectURL, "MODIFY")
+	if err != nil {
+		return fmt.Errorf("locking %s: %w", opts.Name, err)
+	}
+	defer c.UnlockObject(ctx, objectURL, lock.LockHandle)
+	return c.UpdateSource(ctx, objectURL+"/source/main", source, lock.LockHandle, opts.Transport)
+}
+
+// GetStructureDefinition reads a DDIC structure with its DDL source.
+func (c *Client) GetStructureDefinition(ctx context.Context, name string) (*StructureOptions, error) {
+	if err := c.checkSafety(OpRead, "GetStructureDefinition"); err != nil {
+		return nil, err
+	}
+	header, err := c.readObjectHeader(ctx, DDICObjectURL(DDICStructure, name), ddicKinds[DDICStructure].contentType)
+	if err != nil {
+		return nil, fmt.Errorf("getting structure: %w", err)
+	}
+	source, err := c.GetStructure(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &StructureOptions{
+		Name:        strings.ToUpper(name),
+		Description: header.Description,
+		Package:     header.Package,
+		Source:      source,
+	}, nil
+}
+
+// --- DDIC Table Types ---
+
+// TableTypeOptions defines a DDIC table type. The row type is a dictionary
+// type (data element, structure, table, table type) or a built-in type.
+type TableTypeOptions struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	Package       string   `json:"package"`
+	Transport     string   `json:"transport,omitempty"`
+	RowType       string   `json:"rowType,omitempty"`       // Dictionary type; if empty, DataType is used
+	RowTypeKind   string   `json:"rowTypeKind,omitempty"`   // dictionaryType (default) or refToDictionaryType
+	DataType      string   `json:"dataType,omitempty"`      // Built-in type when no row type is given
+	Length        int      `json:"length,omitempty"`        // Length of the built-in type
+	Decimals      int      `json:"decimals,omitempty"`      // Decimals of the built-in type
+	AccessType    string   `json:"accessType,omitempty"`    // standard (default), sorted, hashed, index, notSpecified
+	KeyDefinition string   `json:"keyDefinition,omitempty"` // standard (default), rowType, keyComponents, notSpecified
+	KeyKind       string   `json:"keyKind,omitempty"`       // nonUnique (default; unique for hashed), unique, notSpecified
+	KeyComponents []string `json:"keyComponents,omitempty"` // For keyDefinition keyComponents
+}
+
+// CreateTableType creates a DDIC table type. The table type is created
+// inactive; activate it with Activate.
+func (c *Client) CreateTableType(ctx context.Context, opts TableTypeOptions) error {
+	if err := c.checkSafety(OpCreate, "CreateTableType"); err != nil {
+		return err
+	}
+	obj, err := tableTypeObject(opts)
+	if err != nil {
+		return err
+	}
+	return c.createDDICObject(ctx, obj)
+}
+
+// UpdateTableType replaces the definition of a DDIC table type with opts,
+// typically a definition read with GetTableType and changed. Package must be
+// empty or the current package; objects are not moved. The change is
+// inactive until activated.
+func (c *Client) UpdateTableType(ctx context.Context, opts TableTypeOptions) error {
+	if err := c.checkSafety(OpUpdate, "UpdateTableType"); err != nil {
+		return err
+	}
+	obj, err := tableTypeObject(opts)
+	if err != nil {
+		return err
+	}
+	return c.updateDDICObject(ctx, obj)
+}
+
+// tableTypeObject normalizes opts and builds the table type document.
+func tableTypeObject(opts TableTypeOptions) (ddicObject, error) {
+	opts.Name = strings.ToUpper(opts.Name)
+	opts.Package = strings.ToUpper(opts.Package)
+	if opts.Name == "" {
+		return ddicObject{}, fmt.Errorf("table type name is required")
+	}
+
+	typeKind, typeName := opts.RowTypeKind, strings.ToUpper(opts.RowType)
+	switch {
+	case typeName != "" && typeKind == "":
+		typeKind = "dictionaryType"
+	case typeName != "" && typeKind != "dictionaryType" && typeKind != "refToDictionaryType":
+		return ddicObject{}, fmt.Errorf("table type %s: unsupported row type kind %q", opts.Name, typeKind)
+	case typeName == "" && opts.DataType == "":
+		return ddicObject{}, fmt.Errorf("table type %s needs a row type or a data type", opts.Name)
+	case typeName == "":
+		typeKind = "predefinedAbapType"
+	}
+
+	if opts.AccessType == "" {
+		opts.AccessType = "standard"
+	}
+	if opts.KeyDefinition == "" {
+		opts.KeyDefinition = "standard"
+		if len(opts.KeyComponents) > 0 {
+			opts.KeyDefinition = "keyComponents"
+		}
+	}
+	if opts.KeyKind == "" {
+		opts.KeyKind = "nonUnique"
+		if opts.AccessType == "hashed" {
+			opts.KeyKind = "unique"
+		}
+	}
+	switch {
+	case opts.AccessType == "hashed" && opts.KeyKind != "unique":
+		return ddicObject{}, fmt.Errorf("table type %s: hashed tables need a unique key", opts.Name)
+	case opts.KeyDefinition == "keyComponents" && len(opts.KeyComponents) == 0:
+		return ddicObject{}, fmt.Errorf("table type %s: key components are required", opts.Name)
+	}
+
+	var components strings.Builder
+	for _, comp := range opts.KeyComponents {
+		fmt.Fprintf(&components, `
+      <ttyp:component>
+        <ttyp:name>%s</ttyp:name>
+      </ttyp:component>`, escapeXML(strings.ToUpper(comp)))
+	}
+
+	content := fmt.Sprintf(`
+  <ttyp:rowType>
+    <ttyp:typeKind>%s</ttyp:typeKind>
+    <ttyp:typeName>%s</ttyp:typeName>
+    <ttyp:builtInType>
+      <ttyp:dataType>%s</ttyp:dataType>
+      <ttyp:length>%d</ttyp:length>
+      <ttyp:decimals>%d</ttyp:decimals>
+    </ttyp:builtInType>
+  </ttyp:rowType>
+  <ttyp:initialRowCount>0</ttyp:initialRowCount>
+  <ttyp:accessType>%s</ttyp:accessType>
+  <ttyp:primaryKey>
+    <ttyp:definition>%s</ttyp:definition>
+    <ttyp:kind>%s</ttyp:kind>
+    <ttyp:components>%s
+    </ttyp:components>
+  </ttyp:primaryKey>
+  <ttyp:secondaryKeys ttyp:allowed="notSpecified"/>`,
+		typeKind, escapeXML(typeName), escapeXML(strings.ToUpper(opts.DataType)), opts.Length, opts.Decimals,
+		escapeXML(opts.AccessType), escapeXML(opts.KeyDefinition), escapeXML(opts.KeyKind), components.String())
+
+	return newDDICObject(DDICTableType, opts.Name, opts.Description, opts.Package, opts.Transport, content), nil
+}
+
+// GetTableType reads the definition of a DDIC table type.
+func (c *Client) GetTableType(ctx context.Context, name string) (*TableTypeOptions, error) {
+	if err := c.checkSafety(OpRead, "GetTableType"); err != nil {
+		return nil, err
+	}
+	resp, err := c.transport.Request(ctx, DDICObjectURL(DDICTableType, name), &RequestOptions{
+		Method: http.MethodGet,
+		Accept: ddicKinds[DDICTableType].contentType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting table type: %w", err)
+	}
+
+	var doc struct {
+		Name        string `xml:"name,attr"`
+		Description string `xml:"description,attr"`
+		PackageRef  struct {
+			Name string `xml:"name,attr"`
+		} `xml:"packageRef"`
+		RowType struct {
+			TypeKind string `xml:"typeKind"`
+			TypeName string `xml:"typeName"`
+			BuiltIn  struct {
+				DataType string `xml:"dataType"`
+				Length   int    `xml:"length"`
+				Decimals int    `xml:"decimals"`
+			} `xml:"builtInType"`
+		} `xml:"rowType"`
+		AccessType string `xml:"accessType"`
+		PrimaryKey struct {
+			Definition string   `xml:"definition"`
+			Kind       string   `xml:"kind"`
+			Components []string `xml:"components>component>name"`
+		} `xml:"primaryKey"`
+	}
+	if err := xml.Unmarshal(resp.Body, &doc); err != nil {
+		return nil, fmt.Errorf("parsing table type: %w", err)
+	}
+	opts := &TableTypeOptions{
+		Name:          strings.ToUpper(doc.Name),
+		Description:   doc.Description,
+		Package:       doc.PackageRef.Name,
+		AccessType:    doc.AccessType,
+		KeyDefinition: doc.PrimaryKey.Definition,
+		KeyKind:       doc.PrimaryKey.Kind,
+		KeyComponents: doc.PrimaryKey.Components,
+	}
+	if doc.RowType.TypeKind == "predefinedAbapType" {
+		opts.DataType, opts.Length, opts.Decimals = doc.RowType.BuiltIn.DataType, doc.RowType.BuiltIn.Length, doc.RowType.BuiltIn.Decimals
+	} else {
+		opts.RowType, opts.RowTypeKind = doc.RowType.TypeName, doc.RowType.TypeKind
+	}
+	return opts, nil
+}
+
+// --- DDIC Search Helps ---
+
+// SearchHelpParameter is a parameter of an elementary search help.
+type SearchHelpParameter struct {
+	Name              string `json:"name" xml:"name"`
+	DataElement       string `json:"dataElement,omitempty" xml:"dataElement"`
+	Import            bool   `json:"import,omitempty" xml:"import"`
+	Export            bool   `json:"export,omitempty" xml:"export"`
+	ListPosition      int    `json:"listPosition,omitempty" xml:"listPosition"`           // Position in the hit list, 0 to hide
+	SelectionPosition int    `json:"selectionPosition,omitempty" xml:"selectionPosition"` // Position in the restriction dialog, 0 to hide
+	Default           string `json:"default,omitempty" xml:"defaultValue"`
+}
+
+// SearchHelpOptions defines an elementary DDIC search help. Collective
+// search helps are not supported.
+type SearchHelpOptions struct {
+	Name            string                `json:"name"`
+	Description     string                `json:"description"`
+	Package         string                `json:"package"`
+	Transport       string                `json:"transport,omitempty"`
+	SelectionMethod string                `json:"selectionMethod"`      // Table or view the values are read from
+	TextTable       string                `json:"textTable,omitempty"`  // Text table of the selection method
+	DialogType      string                `json:"dialogType,omitempty"` // D=display values immediately (default), C=with restrictions, A=depends on number of values
+	HotKey          string                `json:"hotKey,omitempty"`     // Short cut for the restriction dialog
+	Parameters      []SearchHelpParameter `json:"parameters"`
+}
+
+// CreateSearchHelp creates an elementary DDIC search help. The search help is
+// created inactive; activate it with Activate.
+func (c *Client) CreateSearchHelp(ctx context.Context, opts SearchHelpOptions) error {
+	if err := c.checkSafety(OpCreate, "CreateSearchHelp"); err != nil {
+		return err
+	}
+	obj, err := searchHelpObject(opts)
+	if err != nil {
+		return err
+	}
+	return c.createDDICObject(ctx, obj)
+}
+
+// UpdateSearchHelp replaces the definition of an elementary search help with
+// opts, typically a definition read with GetSearchHelp and changed. Package
+// must be empty or the current package; objects are not moved. The change is
+// inactive until activated.
+func (c *Client) UpdateSearchHelp(ctx context.Context, opts SearchHelpOptions) error {
+	if err := c.checkSafety(OpUpdate, "UpdateSearchHelp"); err != nil {
+		return err
+	}
+	obj, err := searchHelpObject(opts)
+	if err != nil {
+		return err
+	}
+	return c.updateDDICObject(ctx, obj)
+}
+
+// searchHelpObject normalizes opts and builds the search help document.
+func searchHelpObject(opts SearchHelpOptions) (ddicObject, error) {
+	opts.Name = strings.ToUpper(opts.Name)
+	opts.Package = strings.ToUpper(opts.Package)
+	opts.DialogType = strings.ToUpper(opts.DialogType)
+	if opts.Name == "" || opts.SelectionMethod == "" {
+		return ddicObject{}, fmt.Errorf("search help name and selection method are required")
+	}
+	if len(opts.Parameters) == 0 {
+		return ddicObject{}, fmt.Errorf("search help %s needs parameters", opts.Name)
+	}
+	switch opts.DialogType {
+	case "":
+		opts.DialogType = "D"
+	case "A", "C", "D":
+	default:
+		return ddicObject{}, fmt.Errorf("search help %s: dialog type must be A, C or D", opts.Name)
+	}
+
+	var params strings.Builder
+	for _, p := range opts.Parameters {
+		fmt.Fprintf(&params, `
+      <shlp:parameter>
+        <shlp:name>%s</shlp:name>
+        <shlp:dataElement>%s</shlp:dataElement>
+        <shlp:import>%t</shlp:import>
+        <shlp:export>%t</shlp:export>
+        <shlp:listPosition>%d</shlp:listPosition>
+        <shlp:selectionPosition>%d</shlp:selectionPosition>
+        <shlp:defaultValue>%s</shlp:defaultValue>
+      </shlp:parameter>`,
+			escapeXML(strings.ToUpper(p.Name)), escapeXML(strings.ToUpper(p.DataElement)), p.Import, p.Export,
+			p.ListPosition, p.SelectionPosition, escapeXML(p.Default))
+	}
+
+	content := fmt.Sprintf(`
+  <shlp:content>
+    <shlp:selectionMethod>%s</shlp:selectionMethod>
+    <shlp:textTable>%s</shlp:textTable>
+    <shlp:dialogType>%s</shlp:dialogType>
+    <shlp:hotKey>%s</shlp:hotKey>
+    <shlp:parameters>%s
+    </shlp:parameters>
+  </shlp:content>`,
+		escapeXML(strings.ToUpper(opts.SelectionMethod)), escapeXML(strings.ToUpper(opts.TextTable)),
+		opts.DialogType, escapeXML(opts.HotKey), params.String())
+
+	return newDDICObject(DDICSearchHelp, opts.Name, opts.Description, opts.Package, opts.Transport, content), nil
+}
+
+// GetSearchHelp reads the definition of an elementary DDIC search help.
+func (c *Client) GetSearchHelp(ctx context.Context, name string) (*SearchHelpOptions, error) {
+	if err := c.checkSafety(OpRead, "GetSearchHelp"); err != nil {
+		return nil, err
+	}
+	resp, err := c.transport.Request(ctx, DDICObjectURL(DDICSearchHelp, name), &RequestOptions{
+		Method: http.MethodGet,
+		Accept: ddicKinds[DDICSearchHelp].contentType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting search help: %w", err)
+	}
+
+	var doc struct {
+		Name        string `xml:"name,attr"`
+		Description string `xml:"description,attr"`
+		PackageRef  struct {
+			Name string `xml:"name,attr"`
+		} `xml:"packageRef"`
+		Content struct {
+			SelectionMethod string                `xml:"selectionMethod"`
+			TextTable       string                `xml:"textTable"`
+			DialogType      string                `xml:"dialogType"`
+			HotKey          string                `xml:"hotKey"`
+			Parameters      []SearchHelpParameter `xml:"parameters>parameter"`
+		} `xml:"content"`
+	}
+	if err := xml.Unmarshal(resp.Body, &doc); err != nil {
+		return nil, fmt.Errorf("parsing search help: %w", err)
+	}
+	return &SearchHelpOptions{
+		Name:            strings.ToUpper(doc.Name),
+		Description:     doc.Description,
+		Package:         doc.PackageRef.Name,
+		SelectionMethod: doc.Content.SelectionMethod,
+		TextTable:       doc.Content.TextTable,
+		DialogType:      doc.Content.DialogType,
+		HotKey:          doc.Content.HotKey,
+		Parameters:      doc.Content.Parameters,
+	}, nil
+}
+
+// ddicObject describes an object whose definition is XML rather than source.
+type ddicObject struct {
+	creationPath string
+	objectURL    string
+	contentType  string
+	rootName     string
+	namespace    string
+	objectType   string
+	name         string
+	description  string
+	pkg          string
+	transport    string
+	content      string // child elements after the package reference
+}
+
+// createDDICObject creates an XML-defined object the way ADT editors do:
+// POST the header, then lock, PUT the full definition and unlock.
+func (c *Client) createDDICObject(ctx context.Context, obj ddicObject) error {
+	if err := c.checkPackageSafety(obj.pkg); err != nil {
+		return err
+	}
+	if obj.pkg != "" && !c.packageExists(ctx, obj.pkg) {
+		return fmt.Errorf("package %s does not exist - create it first to avoid orphan locks", obj.pkg)
+	}
+
+	params := url.Values{}
+	if obj.transport != "" {
+		params.Set("corrNr", obj.transport)
+	}
+	if _, err := c.transport.Request(ctx, obj.creationPath, &RequestOptions{
+		Method:      http.MethodPost,
+		Query:       params,
+		Body:        c.ddicDocument(obj, ""),
+		ContentType: obj.contentType,
+		Accept:      obj.contentType,
+	}); err != nil {
+		return fmt.Errorf("creating %s %s: %w", obj.objectType, obj.name, err)
+	}
+	if obj.content == "" {
+		return nil
+	}
+	return c.writeDDICObject(ctx, obj)
+}
+
+// updateDDICObject replaces the definition of an existing XML-defined
+// object in its current package.
+func (c *Client) updateDDICObject(ctx context.Context, obj ddicObject) error {
+	pkg, err := c.existingObjectPackage(ctx, obj.objectURL, obj.contentType, obj.objectType, obj.name, obj.pkg)
+	if err != nil {
+		return err
+	}
+	obj.pkg = pkg
+	return c.writeDDICObject(ctx, obj)
+}
+
+// checkDDICPackage reads the package of an existing dictionary object and
+// checks it against the allowed packages, see existingObjectPackage.
+func (c *Client) checkDDICPackage(ctx context.Context, kind DDICKind, name, pkg string) (string, error) {
+	info := ddicKinds[kind]
+	return c.existingObjectPackage(ctx, DDICObjectURL(kind, name), info.contentType, info.objectType, strings.ToUpper(name), pkg)
+}
+
+// existingObjectPackage returns the package of an existing object after
+// checking it against the allowed packages. The package always comes from
+// the server; a different pkg is refused, as updates do not move objects.
+func (c *Client) existingObjectPackage(ctx context.Context, objectURL, accept, objectType, name, pkg string) (string, error) {
+	header, err := c.readObjectHeader(ctx, objectURL, accept)
+	if err != nil {
+		return "", fmt.Errorf("reading %s %s: %w", objectType, name, err)
+	}
+	if err := c.checkPackageSafety(header.Package); err != nil {
+		return "", err
+	}
+	if pkg != "" && !strings.EqualFold(pkg, header.Package) {
+		return "", fmt.Errorf("%s %s is in package %s; it cannot be moved to %s by an update", objectType, name, header.Package, strings.ToUpper(pkg))
+	}
+	return header.Package, nil
+}
+
+// writeDDICObject locks the object, PUTs its full definition and unlocks it.
+func (c *Client) writeDDICObject(ctx context.Context, obj ddicObject) error {
+	lock, err := c.LockObject(ctx, obj.objectURL, "MODIFY")
+	if err != nil {
+		return fmt.Errorf("locking %s: %w", obj.name, err)
+	}
+	defer c.UnlockObject(ctx, obj.objectURL, lock.LockHandle)
+
+	params := url.Values{}
+	params.Set("lockHandle", lock.LockHandle)
+	if obj.transport != "" {
+		params.Set("corrNr", obj.transport)
+	}
+	if _, err := c.transport.Request(ctx, obj.objectURL, &RequestOptions{
+		Method:      http.MethodPut,
+		Query:       params,
+		Body:        c.ddicDocument(obj, obj.content),
+		ContentType: obj.contentType,
+		Accept:      obj.contentType,
+	}); err != nil {
+		return fmt.Errorf("writing %s %s: %w", obj.objectType, obj.name, err)
+	}
+	return nil
+}
+
+// ddicDocument returns the XML document of obj with the given content.
+func (c *Client) ddicDocument(obj ddicObject, content string) []byte {
+	responsible := c.config.Username
+	if responsible == "" {
+		responsible = "DDIC"
+	}
+	language := c.config.Language
+	if language == "" {
+		language = "EN"
+	}
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<%s %s xmlns:adtcore="http://www.sap.com/adt/core"
+  adtcore:description="%s"
+  adtcore:language="%s"
+  adtcore:name="%s"
+  adtcore:type="%s"
+  adtcore:masterLanguage="%s"
+  adtcore:responsible="%s">
+  <adtcore:packageRef adtcore:name="%s"/>%s
+</%s>`,
+		obj.rootName, obj.namespace,
+		escapeXML(obj.description), language, obj.name, obj.objectType, language, responsible,
+		obj.pkg, content, obj.rootName))
+}
+
+// objectHeader holds the adtcore attributes of an object document.
+type objectHeader struct {
+	Name        string
+	Description string
+	Package     string
+}
+
+// readObjectHeader reads the name, description and package of an object.
+func (c *Client) readObjectHeader(ctx context.Context, objectURL, accept string) (*objectHeader, error) {
+	resp, err := c.transport.Request(ctx, objectURL, &RequestOptions{
+		Method: http.MethodGet,
+		Accept: accept,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Name        string `xml:"name,attr"`
+		Description string `xml:"description,attr"`
+		PackageRef  struct {
+			Name string `xml:"name,attr"`
+		} `xml:"packageRef"`
+	}
+	if err := xml.Unmarshal(resp.Body, &doc); err != nil {
+		return nil, fmt.Errorf("parsing object: %w", err)
+	}
+	return &objectHeader{Name: strings.ToUpper(doc.Name), Description: doc.Description, Package: doc.PackageRef.Name}, nil
+}
+
+// --- Message Classes ---
+
+// CreateMessageClass creates a message class with its messages. Message
+// classes need no activation.
+func (c *Client) CreateMessageClass(ctx context.Context, name, description, packageName, transport string, messages []MessageClassMessage) error {
+	if err := c.checkSafety(OpCreate, "CreateMessageClass"); err != nil {
+		return err
+	}
+	name = strings.ToUpper(name)
+	if name == "" {
+		return fmt.Errorf("message class name is required")
+	}
+
+	var content strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&content, `
+  <mc:messages mc:msgno="%s" mc:msgtext="%s" mc:selfexplainatory="true"/>`, escapeXML(m.Number), escapeXML(m.Text))
+	}
+
+	return c.createDDICObject(ctx, ddicObject{
+		creationPath: "/sap/bc/adt/messageclass",
+		objectURL:    "/sap/bc/adt/messageclass/" + url.PathEscape(strings.ToLower(name)),
+		contentType:  "application/vnd.sap.adt.mc.messageclass+xml",
+		rootName:     "mc:messageClass",
+		namespace:    `xmlns:mc="http://www.sap.com/adt/MessageClass"`,
+		objectType:   "MSAG/N",
+		name:         name,
+		description:  description,
+		pkg:          strings.ToUpper(packageName),
+		transport:    transport,
+		content:      content.String(),
+	})
+}
+
+// ObjectExists reports whether the object at an ADT URL exists.
+func (c *Client) ObjectExists(ctx context.Context, objectURL string) (bool, error) {
+	if err := c.checkSafety(OpRead, "ObjectExists"); err != nil {
+		return false, err
+	}
+	_, err := c.transport.Request(ctx, objectURL, &RequestOptions{Method: http.MethodGet})
+	if err != nil {
+		if IsNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
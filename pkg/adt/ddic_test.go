@@ -0,0 +1,284 @@
+package adt
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
+)
+
+func TestDDICLifecycle(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	sap.AddPackage("$ZDDIC", "DDIC test", "")
+	client := NewClient(sap.URL, "developer", "secret")
+	ctx := context.Background()
+
+	activate := func(kind DDICKind, name string) {
+		t.Helper()
+		res, err := client.Activate(ctx, DDICObjectURL(kind, name), name)
+		if err != nil || !res.Success {
+			t.Fatalf("activating %s %s: %v %+v", kind, name, err, res)
+		}
+	}
+
+	// Domain
+	if err := client.CreateDomain(ctx, DomainOptions{
+		Name: "zddic_status", Description: "Status", Package: "$ZDDIC", DataType: "char", Length: 1,
+		FixValues: []DomainFixValue{{Low: "N", Text: "New"}, {Low: "D", Text: "Done"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	activate(DDICDomain, "ZDDIC_STATUS")
+	dom, err := client.GetDomain(ctx, "ZDDIC_STATUS")
+	if err != nil || dom.DataType != "CHAR" || len(dom.FixValues) != 2 || dom.Package != "$ZDDIC" {
+		t.Fatalf("GetDomain = %+v, %v", dom, err)
+	}
+	dom.FixValues = append(dom.FixValues, DomainFixValue{Low: "X", Text: "Cancelled"})
+	dom.Package = ""
+	if err := client.UpdateDomain(ctx, *dom); err != nil {
+		t.Fatal(err)
+	}
+	if dom, err = client.GetDomain(ctx, "ZDDIC_STATUS"); err != nil || len(dom.FixValues) != 3 || dom.Package != "$ZDDIC" {
+		t.Errorf("updated domain = %+v, %v", dom, err)
+	}
+
+	// Data element typed by the domain
+	if err := client.CreateDataElement(ctx, DataElementOptions{
+		Name: "ZDDIC_STATUS", Description: "Order status", Package: "$ZDDIC", Domain: "zddic_status",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	activate(DDICDataElement, "ZDDIC_STATUS")
+	de, err := client.GetDataElement(ctx, "ZDDIC_STATUS")
+	if err != nil || de.Domain != "ZDDIC_STATUS" || de.MediumLabel != "Order status" {
+		t.Fatalf("GetDataElement = %+v, %v", de, err)
+	}
+
+	// Structure using the data element
+	if err := client.CreateStructure(ctx, StructureOptions{
+		Name: "ZDDIC_LINE", Description: "Order line", Package: "$ZDDIC",
+		Fields: []TableField{{Name: "ID", Type: "CHAR10"}, {Name: "STATUS", Type: "ZDDIC_STATUS"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	activate(DDICStructure, "ZDDIC_LINE")
+	st, err := client.GetStructureDefinition(ctx, "ZDDIC_LINE")
+	if err != nil || st.Description != "Order line" || !strings.Contains(st.Source, "status : zddic_status;") {
+		t.Fatalf("GetStructureDefinition = %+v, %v", st, err)
+	}
+	st.Source = ""
+	st.Fields = []TableField{{Name: "ID", Type: "CHAR10"}}
+	if err := client.UpdateStructure(ctx, *st); err != nil {
+		t.Fatal(err)
+	}
+	if obj, _ := sap.Object(DDICObjectURL(DDICStructure, "ZDDIC_LINE")); !obj.Inactive || strings.Contains(obj.Source, "status") {
+		t.Errorf("structure not updated: %+v", obj)
+	}
+
+	// Table type of the structure
+	if err := client.CreateTableType(ctx, TableTypeOptions{
+		Name: "ZDDIC_LINES", Description: "Order lines", Package: "$ZDDIC", RowType: "zddic_line",
+		AccessType: "sorted", KeyComponents: []string{"id"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	activate(DDICTableType, "ZDDIC_LINES")
+	tt, err := client.GetTableType(ctx, "ZDDIC_LINES")
+	if err != nil || tt.RowType != "ZDDIC_LINE" || tt.RowTypeKind != "dictionaryType" || tt.KeyDefinition != "keyComponents" ||
+		len(tt.KeyComponents) != 1 || tt.KeyComponents[0] != "ID" {
+		t.Fatalf("GetTableType = %+v, %v", tt, err)
+	}
+
+	// Search help
+	if err := client.CreateSearchHelp(ctx, SearchHelpOptions{
+		Name: "ZDDIC_SH_STATUS", Description: "Status values", Package: "$ZDDIC", SelectionMethod: "zddic_status_v",
+		Parameters: []SearchHelpParameter{{Name: "STATUS", DataElement: "ZDDIC_STATUS", Import: true, Export: true, ListPosition: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sh, err := client.GetSearchHelp(ctx, "ZDDIC_SH_STATUS")
+	if err != nil || sh.SelectionMethod != "ZDDIC_STATUS_V" || sh.DialogType != "D" || len(sh.Parameters) != 1 || !sh.Parameters[0].Export {
+		t.Fatalf("GetSearchHelp = %+v, %v", sh, err)
+	}
+
+	// Delete
+	if err := client.DeleteDDICObject(ctx, DDICTableType, "ZDDIC_LINES", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sap.Object(DDICObjectURL(DDICTableType, "ZDDIC_LINES")); ok {
+		t.Error("table type still exists")
+	}
+}
+
+func TestDDICPackageSafety(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	sap.AddPackage("$ZDDIC", "DDIC test", "")
+	sap.AddPackage("$ZOTHER", "Other", "")
+	ctx := context.Background()
+
+	client := NewClient(sap.URL, "developer", "secret")
+	if err := client.CreateStructure(ctx, StructureOptions{
+		Name: "ZDDIC_LINE", Description: "Order line", Package: "$ZOTHER", Fields: []TableField{{Name: "ID", Type: "CHAR10"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	locks := sap.CountRequests("POST", "/sap/bc/adt/ddic/structures/zddic_line")
+
+	// The package comes from the object, not from the options
+	restricted := NewClient(sap.URL, "developer", "secret", WithAllowedPackages("$ZDDIC"))
+	err := restricted.UpdateStructure(ctx, StructureOptions{Name: "ZDDIC_LINE", Package: "$ZDDIC", Fields: []TableField{{Name: "KEY", Type: "CHAR10"}}})
+	if err == nil || !strings.Contains(err.Error(), "$ZOTHER") {
+		t.Errorf("UpdateStructure outside allowed packages: %v", err)
+	}
+	if err := restricted.DeleteDDICObject(ctx, DDICStructure, "ZDDIC_LINE", ""); err == nil || !strings.Contains(err.Error(), "$ZOTHER") {
+		t.Errorf("DeleteDDICObject outside allowed packages: %v", err)
+	}
+	if obj, ok := sap.Object(DDICObjectURL(DDICStructure, "ZDDIC_LINE")); !ok || strings.Contains(obj.Source, "key") {
+		t.Errorf("structure changed: %+v", obj)
+	}
+	if n := sap.CountRequests("POST", "/sap/bc/adt/ddic/structures/zddic_line") - locks; n != 0 {
+		t.Errorf("%d lock requests, want 0", n)
+	}
+
+	if err := restricted.DeleteDDICObject(ctx, DDICStructure, "ZDDIC_MISSING", ""); err == nil {
+		t.Error("deleting a missing structure succeeded")
+	}
+}
+
+func TestDDICUpdateBlockedPackage(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	sap.AddPackage("$ZDDIC", "DDIC test", "")
+	sap.AddPackage("$ZOTHER", "Other", "")
+	ctx := context.Background()
+	client := NewClient(sap.URL, "developer", "secret")
+	restricted := NewClient(sap.URL, "developer", "secret", WithAllowedPackages("$ZDDIC"))
+
+	tests := []struct {
+		kind   DDICKind
+		create func() error
+		update func(c *Client, pkg string) error
+		pkg    func() string
+	}{
+		{
+			kind: DDICDomain,
+			create: func() error {
+				return client.CreateDomain(ctx, DomainOptions{Name: "ZDDIC_PRIO", Description: "Priority", Package: "$ZOTHER", DataType: "NUMC", Length: 1})
+			},
+			update: func(c *Client, pkg string) error {
+				return c.UpdateDomain(ctx, DomainOptions{Name: "ZDDIC_PRIO", Description: "Changed", Package: pkg, DataType: "NUMC", Length: 2})
+			},
+			pkg: func() string { d, _ := client.GetDomain(ctx, "ZDDIC_PRIO"); return d.Package },
+		},
+		{
+			kind: DDICDataElement,
+			create: func() error {
+				return client.CreateDataElement(ctx, DataElementOptions{Name: "ZDDIC_PRIO", Description: "Priority", Package: "$ZOTHER", Domain: "ZDDIC_PRIO"})
+			},
+			update: func(c *Client, pkg string) error {
+				return c.UpdateDataElement(ctx, DataElementOptions{Name: "ZDDIC_PRIO", Description: "Changed", Package: pkg, DataType: "CHAR", Length: 5})
+			},
+			pkg: func() string { d, _ := client.GetDataElement(ctx, "ZDDIC_PRIO"); return d.Package },
+		},
+		{
+			kind: DDICTableType,
+			create: func() error {
+				return client.CreateTableType(ctx, TableTypeOptions{Name: "ZDDIC_PRIOS", Description: "Priorities", Package: "$ZOTHER", RowType: "ZDDIC_PRIO"})
+			},
+			update: func(c *Client, pkg string) error {
+				return c.UpdateTableType(ctx, TableTypeOptions{Name: "ZDDIC_PRIOS", Description: "Changed", Package: pkg, DataType: "CHAR", Length: 10})
+			},
+			pkg: func() string { d, _ := client.GetTableType(ctx, "ZDDIC_PRIOS"); return d.Package },
+		},
+		{
+			kind: DDICSearchHelp,
+			create: func() error {
+				return client.CreateSearchHelp(ctx, SearchHelpOptions{
+					Name: "ZDDIC_SH_PRIO", Description: "Priorities", Package: "$ZOTHER", SelectionMethod: "ZDDIC_PRIO_V",
+					Parameters: []SearchHelpParameter{{Name: "PRIO", DataElement: "ZDDIC_PRIO", Export: true, ListPosition: 1}},
+				})
+			},
+			update: func(c *Client, pkg string) error {
+				return c.UpdateSearchHelp(ctx, SearchHelpOptions{
+					Name: "ZDDIC_SH_PRIO", Description: "Changed", Package: pkg, SelectionMethod: "ZDDIC_PRIO_V",
+					Parameters: []SearchHelpParameter{{Name: "PRIO", DataElement: "ZDDIC_PRIO", Export: true, ListPosition: 1}},
+				})
+			},
+			pkg: func() string { d, _ := client.GetSearchHelp(ctx, "ZDDIC_SH_PRIO"); return d.Package },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			if err := tt.create(); err != nil {
+				t.Fatal(err)
+			}
+			puts := sap.CountRequests("PUT", "/sap/bc/adt/ddic/")
+
+			// The object's own package is checked, whatever the options say
+			for _, pkg := range []string{"", "$ZDDIC", "$ZOTHER"} {
+				if err := tt.update(restricted, pkg); err == nil || !strings.Contains(err.Error(), "$ZOTHER") {
+					t.Errorf("update with package %q outside allowed packages: %v", pkg, err)
+				}
+			}
+			// Updates do not move objects
+			if err := tt.update(client, "$ZDDIC"); err == nil || !strings.Contains(err.Error(), "cannot be moved to $ZDDIC") {
+				t.Errorf("package change: %v", err)
+			}
+			if n := sap.CountRequests("PUT", "/sap/bc/adt/ddic/") - puts; n != 0 {
+				t.Errorf("%d refused updates written", n)
+			}
+			if pkg := tt.pkg(); pkg != "$ZOTHER" {
+				t.Errorf("package = %s, want $ZOTHER", pkg)
+			}
+
+			if err := tt.update(client, ""); err != nil {
+				t.Errorf("update in place: %v", err)
+			}
+		})
+	}
+}
+
+func TestTableTypeValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts TableTypeOptions
+		err  string
+	}{
+		{"no row type", TableTypeOptions{Name: "ZTT"}, "needs a row type"},
+		{"hashed non-unique", TableTypeOptions{Name: "ZTT", RowType: "ZS", AccessType: "hashed", KeyKind: "nonUnique"}, "unique key"},
+		{"no components", TableTypeOptions{Name: "ZTT", RowType: "ZS", KeyDefinition: "keyComponents"}, "key components"},
+		{"bad kind", TableTypeOptions{Name: "ZTT", RowType: "ZS", RowTypeKind: "range"}, "row type kind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tableTypeObject(tt.opts); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	obj, err := tableTypeObject(TableTypeOptions{Name: "ztt", DataType: "char", Length: 10, AccessType: "hashed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<ttyp:typeKind>predefinedAbapType</ttyp:typeKind>", "<ttyp:dataType>CHAR</ttyp:dataType>", "<ttyp:kind>unique</ttyp:kind>"} {
+		if !strings.Contains(obj.content, want) {
+			t.Errorf("content lacks %s:\n%s", want, obj.content)
+		}
+	}
+}
+
+func TestParseDDICKind(t *testing.T) {
+	for in, want := range map[string]DDICKind{"dtel": DDICDataElement, "DOMA/DD": DDICDomain, "TABL/DS": DDICStructure, " ttyp ": DDICTableType, "SHLP": DDICSearchHelp} {
+		if got, err := ParseDDICKind(in); err != nil || got != want {
+			t.Errorf("ParseDDICKind(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseDDICKind("TABL"); err == nil {
+		t.Error("TABL should not parse")
+	}
+}
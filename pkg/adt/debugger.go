@@ -159,6 +159,9 @@ type BreakpointResponse struct {
 // For statement breakpoints:
 //   - statement: Statement type (e.g., "WRITE", "CALL FUNCTION")
 func (c *Client) SetExternalBreakpoint(ctx context.Context, req *BreakpointRequest) (*BreakpointResponse, error) {
+	if err := c.checkSafety(OpDebug, "SetExternalBreakpoint"); err != nil {
+		return nil, err
+	}
 	if req.Scope == "" {
 		req.Scope = BreakpointScopeExternal
 	}
@@ -189,6 +192,9 @@ func (c *Client) SetExternalBreakpoint(ctx context.Context, req *BreakpointReque
 // Deprecated: Use ZADT_VSP WebSocket handler with debug domain instead.
 // user is required for external breakpoints in user debugging mode.
 func (c *Client) GetExternalBreakpoints(ctx context.Context, user string) (*BreakpointResponse, error) {
+	if err := c.checkSafety(OpDebug, "GetExternalBreakpoints"); err != nil {
+		return nil, err
+	}
 	query := url.Values{}
 	query.Set("scope", string(BreakpointScopeExternal))
 	query.Set("debuggingMode", string(DebuggingModeUser))
@@ -218,6 +224,9 @@ func (c *Client) GetExternalBreakpoints(ctx context.Context, user string) (*Brea
 // Deprecated: Use ZADT_VSP WebSocket handler with debug domain instead.
 // user is required for external breakpoints in user debugging mode.
 func (c *Client) DeleteExternalBreakpoint(ctx context.Context, breakpointID string, user string) error {
+	if err := c.checkSafety(OpDebug, "DeleteExternalBreakpoint"); err != nil {
+		return err
+	}
 	query := url.Values{}
 	query.Set("scope", string(BreakpointScopeExternal))
 	query.Set("debuggingMode", string(DebuggingModeUser))
@@ -241,6 +250,9 @@ func (c *Client) DeleteExternalBreakpoint(ctx context.Context, breakpointID stri
 // DeleteAllExternalBreakpoints removes all external breakpoints for a user.
 // Deprecated: Use ZADT_VSP WebSocket handler with debug domain instead.
 func (c *Client) DeleteAllExternalBreakpoints(ctx context.Context, user string) error {
+	if err := c.checkSafety(OpDebug, "DeleteAllExternalBreakpoints"); err != nil {
+		return err
+	}
 	// Get all breakpoints first
 	bps, err := c.GetExternalBreakpoints(ctx, user)
 	if err != nil {
@@ -259,6 +271,9 @@ func (c *Client) DeleteAllExternalBreakpoints(ctx context.Context, user string)
 
 // ValidateBreakpointCondition checks if a breakpoint condition expression is valid.
 func (c *Client) ValidateBreakpointCondition(ctx context.Context, condition string) (bool, string, error) {
+	if err := c.checkSafety(OpDebug, "ValidateBreakpointCondition"); err != nil {
+		return false, "", err
+	}
 	body := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <dbg:condition xmlns:dbg="http://www.sap.com/adt/debugger">%s</dbg:condition>`,
 		xmlEscape(condition))
@@ -565,6 +580,9 @@ type ListenOptions struct {
 //
 // Default timeout is 240 seconds. For longer waits, call this in a loop.
 func (c *Client) DebuggerListen(ctx context.Context, opts *ListenOptions) (*ListenResult, error) {
+	if err := c.checkSafety(OpDebug, "DebuggerListen"); err != nil {
+		return nil, err
+	}
 	if opts == nil {
 		opts = &ListenOptions{}
 	}
@@ -636,6 +654,9 @@ func (c *Client) DebuggerListen(ctx context.Context, opts *ListenOptions) (*List
 // DebuggerCheckListener checks if there are active debug listeners.
 // Returns nil if no listeners are active.
 func (c *Client) DebuggerCheckListener(ctx context.Context, opts *ListenOptions) (*ListenerConflict, error) {
+	if err := c.checkSafety(OpDebug, "DebuggerCheckListener"); err != nil {
+		return nil, err
+	}
 	if opts == nil {
 		opts = &ListenOptions{}
 	}
@@ -681,6 +702,9 @@ func (c *Client) DebuggerCheckListener(ctx context.Context, opts *ListenOptions)
 
 // DebuggerStopListener stops an active debug listener.
 func (c *Client) DebuggerStopListener(ctx context.Context, opts *ListenOptions) error {
+	if err := c.checkSafety(OpDebug, "DebuggerStopListener"); err != nil {
+		return err
+	}
 	if opts == nil {
 		opts = &ListenOptions{}
 	}
@@ -978,6 +1002,9 @@ type DebugChildVariablesInfo struct {
 // debuggeeId: The ID of the debuggee (from ListenResult.Debuggee.ID)
 // user: Optional user for user-mode debugging
 func (c *Client) DebuggerAttach(ctx context.Context, debuggeeID string, user string) (*DebugAttachResult, error) {
+	if err := c.checkSafety(OpDebug, "DebuggerAttach"); err != nil {
+		return nil, err
+	}
 	query := url.Values{}
 	query.Set("method", "attach")
 	query.Set("debuggeeId", debuggeeID)
@@ -1002,6 +1029,9 @@ func (c *Client) DebuggerAttach(ctx context.Context, debuggeeID string, user str
 // DebuggerDetach terminates the current debug session.
 // This releases the debuggee and ends the debugging session.
 func (c *Client) DebuggerDetach(ctx context.Context) error {
+	if err := c.checkSafety(OpDebug, "DebuggerDetach"); err != nil {
+		return err
+	}
 	_, err := c.DebuggerStep(ctx, DebugTerminate, "")
 	return err
 }
@@ -1010,6 +1040,9 @@ func (c *Client) DebuggerDetach(ctx context.Context) error {
 // stepType: One of stepInto, stepOver, stepReturn, stepContinue, stepRunToLine, stepJumpToLine, terminateDebuggee
 // uri: Required for stepRunToLine and stepJumpToLine (target line URI)
 func (c *Client) DebuggerStep(ctx context.Context, stepType DebugStepType, uri string) (*DebugStepResult, error) {
+	if err := c.checkSafety(OpDebug, "DebuggerStep"); err != nil {
+		return nil, err
+	}
 	query := url.Values{}
 	query.Set("method", string(stepType))
 	if uri != "" {
@@ -1031,6 +1064,9 @@ func (c *Client) DebuggerStep(ctx context.Context, stepType DebugStepType, uri s
 // DebuggerGetStack retrieves the current call stack.
 // semanticURIs: If true, returns semantic URIs that can be used for navigation
 func (c *Client) DebuggerGetStack(ctx context.Context, semanticURIs bool) (*DebugStackInfo, error) {
+	if err := c.checkSafety(OpDebug, "DebuggerGetStack"); err != nil {
+		return nil, err
+	}
 	query := url.Values{}
 	query.Set("method", "getStack")
 	query.Set("emode", "_")
@@ -1053,6 +1089,9 @@ func (c *Client) DebuggerGetStack(ctx context.Context, semanticURIs bool) (*Debu
 // DebuggerGetVariables retrieves the values of specific variables.
 // variableIDs: List of variable IDs to retrieve (e.g., ["@ROOT", "@DATAAGING", "LV_COUNT"])
 func (c *Client) DebuggerGetVariables(ctx context.Context, variableIDs []string) ([]DebugVariable, error) {
+	if err := c.checkSafety(OpDebug, "DebuggerGetVariables"); err != nil {
+		return nil, err
+	}
 	if len(variableIDs) == 0 {
 		return nil, fmt.Errorf("at least one variable ID required")
 	}
@@ -1083,6 +1122,9 @@ func (c *Client) DebuggerGetVariables(ctx context.Context, variableIDs []string)
 // DebuggerGetChildVariables retrieves child variables (for expanding structures/tables).
 // parentIDs: List of parent variable IDs (e.g., ["@ROOT", "@DATAAGING"] for top-level)
 func (c *Client) DebuggerGetChildVariables(ctx context.Context, parentIDs []string) (*DebugChildVariablesInfo, error) {
+	if err := c.checkSafety(OpDebug, "DebuggerGetChildVariables"); err != nil {
+		return nil, err
+	}
 	if len(parentIDs) == 0 {
 		parentIDs = []string{"@ROOT", "@DATAAGING"}
 	}
@@ -1114,6 +1156,12 @@ func (c *Client) DebuggerGetChildVariables(ctx context.Context, parentIDs []stri
 // variableName: The name of the variable to modify
 // value: The new value as a string
 func (c *Client) DebuggerSetVariableValue(ctx context.Context, variableName, value string) (string, error) {
+	if err := c.checkSafety(OpDebug, "DebuggerSetVariableValue"); err != nil {
+		return "", err
+	}
+	if err := c.checkSafety(OpUpdate, "DebuggerSetVariableValue"); err != nil {
+		return "", err
+	}
 	query := url.Values{}
 	query.Set("method", "setVariableValue")
 	query.Set("variableName", variableName)
@@ -1133,6 +1181,9 @@ func (c *Client) DebuggerSetVariableValue(ctx context.Context, variableName, val
 // DebuggerGoToStack navigates to a specific stack entry.
 // stackURI: The stack URI (e.g., "/sap/bc/adt/debugger/stack/type/ABAP/position/3")
 func (c *Client) DebuggerGoToStack(ctx context.Context, stackURI string) error {
+	if err := c.checkSafety(OpDebug, "DebuggerGoToStack"); err != nil {
+		return err
+	}
 	_, err := c.transport.Request(ctx, stackURI, &RequestOptions{
 		Method: http.MethodPut,
 	})
@@ -1635,6 +1686,9 @@ type DebugBatchResponse struct {
 // DebuggerBatchRequest sends multiple debugger operations in a single batch request.
 // This matches the Eclipse ADT debugging protocol.
 func (c *Client) DebuggerBatchRequest(ctx context.Context, operations []DebugBatchOperation) ([]DebugBatchResponse, error) {
+	if err := c.checkSafety(OpDebug, "DebuggerBatchRequest"); err != nil {
+		return nil, err
+	}
 	if len(operations) == 0 {
 		return nil, fmt.Errorf("no operations provided")
 	}
@@ -1777,6 +1831,9 @@ func parseBatchResponse(body []byte, contentType string) ([]DebugBatchResponse,
 // DebuggerStepWithBatch performs a step operation and retrieves stack+variables in one batch.
 // This matches Eclipse's behavior of combining multiple operations.
 func (c *Client) DebuggerStepWithBatch(ctx context.Context, stepType DebugStepType, uri string) (*DebugStepResult, *DebugStackInfo, []DebugVariable, error) {
+	if err := c.checkSafety(OpDebug, "DebuggerStepWithBatch"); err != nil {
+		return nil, nil, nil, err
+	}
 	operations := []DebugBatchOperation{
 		{
 			Path:   fmt.Sprintf("/sap/bc/adt/debugger?method=%s", stepType),
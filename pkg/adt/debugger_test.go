@@ -1268,3 +1268,39 @@ func TestDebugStepTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestDebuggerSafety(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/sap/bc/adt/debugger") {
+			requests++
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "testuser", "testpass", WithSafety(SafetyConfig{DisallowedOps: "B"}))
+	ctx := context.Background()
+
+	_, err := client.SetExternalBreakpoint(ctx, &BreakpointRequest{
+		Breakpoints: []Breakpoint{NewLineBreakpoint("/sap/bc/adt/programs/programs/ZTEST/source/main", 42)},
+	})
+	if err == nil || !strings.Contains(err.Error(), "blocked by safety configuration") {
+		t.Errorf("SetExternalBreakpoint: expected safety error, got %v", err)
+	}
+	if _, err := client.DebuggerListen(ctx, nil); err == nil {
+		t.Error("DebuggerListen: expected safety error")
+	}
+	if _, err := client.DebuggerGetStack(ctx, false); err == nil {
+		t.Error("DebuggerGetStack: expected safety error")
+	}
+	if requests != 0 {
+		t.Errorf("%d debugger requests sent", requests)
+	}
+
+	// Read-only mode does not block debugging, but changing variables is an update
+	client = NewClient(server.URL, "testuser", "testpass", WithReadOnly())
+	if _, err := client.DebuggerSetVariableValue(ctx, "LV_X", "1"); err == nil || !strings.Contains(err.Error(), "type U") {
+		t.Errorf("DebuggerSetVariableValue: expected safety error, got %v", err)
+	}
+}
@@ -50,6 +50,7 @@ func (c *Client) SyntaxCheck(ctx context.Context, objectURL string, content stri
 
 	resp, err := c.transport.Request(ctx, "/sap/bc/adt/checkruns?reporters=abapCheckRun", &RequestOptions{
 		Method:      http.MethodPost,
+		Idempotent:  true,
 		Body:        []byte(body),
 		ContentType: "application/*",
 	})
@@ -155,10 +156,35 @@ func (c *Client) Activate(ctx context.Context, objectURL string, objectName stri
 		return nil, err
 	}
 
+	return c.activate(ctx, []ActivationObject{{URI: objectURL, Name: objectName}})
+}
+
+// ActivationObject references an object to activate.
+type ActivationObject struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+// ActivateObjects activates objects together in one request. Objects that
+// depend on each other, like a behavior definition and its implementation
+// class, must be activated this way. Like SAP, nothing is activated if any
+// object has errors.
+func (c *Client) ActivateObjects(ctx context.Context, objects []ActivationObject) (*ActivationResult, error) {
+	if err := c.checkSafety(OpActivate, "ActivateObjects"); err != nil {
+		return nil, err
+	}
+	return c.activate(ctx, objects)
+}
+
+func (c *Client) activate(ctx context.Context, objects []ActivationObject) (*ActivationResult, error) {
+	var refs strings.Builder
+	for _, obj := range objects {
+		fmt.Fprintf(&refs, `
+  <adtcore:objectReference adtcore:uri="%s" adtcore:name="%s"/>`, obj.URI, obj.Name)
+	}
 	body := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
-<adtcore:objectReferences xmlns:adtcore="http://www.sap.com/adt/core">
-  <adtcore:objectReference adtcore:uri="%s" adtcore:name="%s"/>
-</adtcore:objectReferences>`, objectURL, objectName)
+<adtcore:objectReferences xmlns:adtcore="http://www.sap.com/adt/core">%s
+</adtcore:objectReferences>`, refs.String())
 
 	resp, err := c.transport.Request(ctx, "/sap/bc/adt/activation?method=activate&preauditRequested=true", &RequestOptions{
 		Method:      http.MethodPost,
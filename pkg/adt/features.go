@@ -27,8 +27,22 @@ const (
 	FeatureTransport FeatureID = "transport"
 	// FeatureHANA indicates HANA database (required for some AMDP features)
 	FeatureHANA FeatureID = "hana"
+	// FeatureVSP indicates the ZADT_VSP WebSocket handler is installed
+	FeatureVSP FeatureID = "zadt_vsp"
 )
 
+// AllFeatures lists the probed features in probe order. HANA comes first:
+// other features may depend on it.
+var AllFeatures = []FeatureID{
+	FeatureHANA,
+	FeatureAbapGit,
+	FeatureRAP,
+	FeatureAMDP,
+	FeatureUI5,
+	FeatureTransport,
+	FeatureVSP,
+}
+
 // FeatureMode controls how a feature is enabled
 type FeatureMode string
 
@@ -64,6 +78,8 @@ type FeatureConfig struct {
 	UI5 FeatureMode
 	// Transport controls CTS transport tools (default: auto)
 	Transport FeatureMode
+	// VSP controls tools using the ZADT_VSP WebSocket handler (default: auto)
+	VSP FeatureMode
 }
 
 // DefaultFeatureConfig returns default feature configuration (all auto-detect)
@@ -75,6 +91,7 @@ func DefaultFeatureConfig() FeatureConfig {
 		AMDP:      FeatureModeAuto,
 		UI5:       FeatureModeAuto,
 		Transport: FeatureModeAuto,
+		VSP:       FeatureModeAuto,
 	}
 }
 
@@ -93,6 +110,8 @@ func (f *FeatureConfig) GetMode(id FeatureID) FeatureMode {
 		return f.UI5
 	case FeatureTransport:
 		return f.Transport
+	case FeatureVSP:
+		return f.VSP
 	default:
 		return FeatureModeAuto
 	}
@@ -119,17 +138,8 @@ func NewFeatureProber(client *Client, config FeatureConfig, verbose bool) *Featu
 
 // ProbeAll probes all features and returns their status
 func (p *FeatureProber) ProbeAll(ctx context.Context) map[FeatureID]*FeatureStatus {
-	features := []FeatureID{
-		FeatureHANA,     // Probe first - other features may depend on it
-		FeatureAbapGit,
-		FeatureRAP,
-		FeatureAMDP,
-		FeatureUI5,
-		FeatureTransport,
-	}
-
 	results := make(map[FeatureID]*FeatureStatus)
-	for _, id := range features {
+	for _, id := range AllFeatures {
 		status := p.Probe(ctx, id)
 		results[id] = status
 	}
@@ -188,6 +198,21 @@ func (p *FeatureProber) Probe(ctx context.Context, id FeatureID) *FeatureStatus
 	return status
 }
 
+// Invalidate drops cached probe results so the next probe asks the system
+// again, e.g. after installing abapGit or ZADT_VSP. Without ids, all
+// results are dropped.
+func (p *FeatureProber) Invalidate(ids ...FeatureID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(ids) == 0 {
+		p.cache = make(map[FeatureID]*FeatureStatus)
+		return
+	}
+	for _, id := range ids {
+		delete(p.cache, id)
+	}
+}
+
 // IsAvailable checks if a feature is available (uses cache)
 func (p *FeatureProber) IsAvailable(ctx context.Context, id FeatureID) bool {
 	return p.Probe(ctx, id).Available
@@ -214,6 +239,8 @@ func (p *FeatureProber) probeFeature(ctx context.Context, id FeatureID) *Feature
 		status.Available, status.Message, err = p.probeUI5(ctx)
 	case FeatureTransport:
 		status.Available, status.Message, err = p.probeTransport(ctx)
+	case FeatureVSP:
+		status.Available, status.Message, err = p.probeVSP(ctx)
 	default:
 		status.Available = false
 		status.Message = "unknown feature"
@@ -355,13 +382,26 @@ func (p *FeatureProber) probeTransport(ctx context.Context) (bool, string, error
 	return false, "CTS not responding", nil
 }
 
+// probeVSP checks if the ZADT_VSP WebSocket handler class is deployed
+func (p *FeatureProber) probeVSP(ctx context.Context) (bool, string, error) {
+	results, err := p.client.SearchObject(ctx, "ZCL_VSP_APC_HANDLER", 1)
+	if err != nil {
+		return false, "", err
+	}
+
+	if len(results) > 0 {
+		return true, "ZCL_VSP_APC_HANDLER found", nil
+	}
+
+	return false, "ZADT_VSP not installed (see InstallZADTVSP)", nil
+}
+
 // FeatureSummary returns a human-readable summary of all features
 func (p *FeatureProber) FeatureSummary(ctx context.Context) string {
 	results := p.ProbeAll(ctx)
 	var parts []string
 
-	features := []FeatureID{FeatureHANA, FeatureAbapGit, FeatureRAP, FeatureAMDP, FeatureUI5, FeatureTransport}
-	for _, id := range features {
+	for _, id := range AllFeatures {
 		status := results[id]
 		symbol := "✗"
 		if status.Available {
@@ -6,8 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
-
-	"github.com/gorilla/websocket"
 )
 
 // GitTypes returns the list of supported abapGit object types.
@@ -105,62 +103,12 @@ func (c *AMDPWebSocketClient) GitExportToBytes(ctx context.Context, params GitEx
 
 // sendGitRequest sends a request to the git domain.
 func (c *AMDPWebSocketClient) sendGitRequest(ctx context.Context, action string, params map[string]interface{}) (*WSResponse, error) {
-	c.mu.RLock()
-	if c.conn == nil {
-		c.mu.RUnlock()
-		return nil, fmt.Errorf("not connected")
-	}
-	c.mu.RUnlock()
-
-	id := fmt.Sprintf("git_%d", c.msgID.Add(1))
-
-	msg := WSMessage{
-		ID:      id,
-		Domain:  "git",
-		Action:  action,
-		Params:  params,
-		Timeout: 120000, // 2 minute timeout for git operations
-	}
-
-	// Create response channel
-	respCh := make(chan *WSResponse, 1)
-	c.pendingMu.Lock()
-	c.pending[id] = respCh
-	c.pendingMu.Unlock()
-
-	defer func() {
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
-	}()
-
-	// Send message
-	data, err := json.Marshal(msg)
+	resp, err := c.SendDomainRequest(ctx, "git", action, params, 120*time.Second) // 2 minute timeout for git operations
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal message: %w", err)
-	}
-
-	c.mu.Lock()
-	err = c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-	if err == nil {
-		err = c.conn.WriteMessage(websocket.TextMessage, data)
-	}
-	c.mu.Unlock()
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to send message: %w", err)
+		return nil, err
 	}
-
-	// Wait for response
-	select {
-	case resp := <-respCh:
-		if !resp.Success && resp.Error != nil {
-			return nil, fmt.Errorf("%s: %s", resp.Error.Code, resp.Error.Message)
-		}
-		return resp, nil
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-time.After(120 * time.Second):
-		return nil, fmt.Errorf("timeout waiting for git response")
+	if !resp.Success && resp.Error != nil {
+		return nil, fmt.Errorf("%s: %s", resp.Error.Code, resp.Error.Message)
 	}
+	return resp, nil
 }
@@ -9,7 +9,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // HTTPDoer is an interface for executing HTTP requests.
@@ -20,34 +21,172 @@ type HTTPDoer interface {
 
 // Transport handles HTTP communication with SAP ADT REST API.
 // It manages CSRF tokens, sessions, and authentication automatically.
+//
+// Requests are limited by Config.MaxConcurrent and Config.RequestsPerSecond.
+// All requests share one SAP session unless Config.SessionPoolSize is set; then
+// each object lock gets a dedicated session from the pool, and the requests
+// carrying its lock handle (update, delete, unlock) are sent in that session.
 type Transport struct {
 	config     *Config
 	httpClient HTTPDoer
 
-	// CSRF token management
-	csrfToken string
-	csrfMu    sync.RWMutex
+	shared   *session     // session of all requests not pinned to a pooled one
+	pool     *sessionPool // nil without Config.SessionPoolSize
+	throttle *throttle
 
-	// Session management
-	sessionID string
-	sessionMu sync.RWMutex
+	// Retries of transient failures (Config.Retry)
+	retryBudget            *retryBudget
+	retries, retriesDenied atomic.Int64
 }
 
 // NewTransport creates a new Transport with the given configuration.
+// With cfg.ReplayDir set, requests are answered from a recorded cassette;
+// with cfg.RecordDir set, traffic is recorded to one.
 func NewTransport(cfg *Config) *Transport {
-	return &Transport{
-		config:     cfg,
-		httpClient: cfg.NewHTTPClient(),
-	}
+	newClient := func() HTTPDoer { return cfg.NewHTTPClient() }
+	if cfg.ReplayDir != "" {
+		replayer := NewReplayer(cfg.ReplayDir)
+		newClient = func() HTTPDoer { return replayer }
+	} else if cfg.RecordDir != "" {
+		recorder := NewRecorder(cfg.RecordDir, nil)
+		newClient = func() HTTPDoer { return recorder.Wrap(cfg.NewHTTPClient()) }
+	}
+	return newTransport(cfg, newClient)
 }
 
 // NewTransportWithClient creates a new Transport with a custom HTTP client.
-// This is useful for testing with mock HTTP clients.
+// This is useful for testing with mock HTTP clients. Pooled sessions share
+// the client.
 func NewTransportWithClient(cfg *Config, client HTTPDoer) *Transport {
-	return &Transport{
-		config:     cfg,
-		httpClient: client,
+	return newTransport(cfg, func() HTTPDoer { return client })
+}
+
+func newTransport(cfg *Config, newClient func() HTTPDoer) *Transport {
+	t := &Transport{
+		config:      cfg,
+		httpClient:  newClient(),
+		throttle:    newThrottle(cfg.MaxConcurrent, cfg.RequestsPerSecond),
+		retryBudget: newRetryBudget(cfg.Retry.BudgetPerMinute),
+	}
+	t.shared = &session{client: t.httpClient}
+	if cfg.SessionPoolSize > 0 {
+		t.pool = newSessionPool(cfg.SessionPoolSize, newClient)
+		if cfg.SessionAcquireTimeout > 0 {
+			t.pool.acquireTimeout = cfg.SessionAcquireTimeout
+		}
+		if cfg.SessionLockTTL > 0 {
+			t.pool.lockTTL = cfg.SessionLockTTL
+		}
+	}
+	return t
+}
+
+// Stats returns request throttling and session pool statistics.
+func (t *Transport) Stats() TransportStats {
+	st := TransportStats{
+		Requests:          t.throttle.requests.Load(),
+		InFlight:          t.throttle.inFlight.Load(),
+		PeakInFlight:      t.throttle.peak.Load(),
+		Throttled:         t.throttle.throttled.Load(),
+		TotalWait:         time.Duration(t.throttle.totalWait.Load()),
+		MaxWait:           time.Duration(t.throttle.maxWait.Load()),
+		MaxConcurrent:     t.config.MaxConcurrent,
+		RequestsPerSecond: t.config.RequestsPerSecond,
+		Retries:           t.retries.Load(),
+		RetriesDenied:     t.retriesDenied.Load(),
+	}
+	if t.pool != nil {
+		st.SessionPoolSize = cap(t.pool.free)
+		st.SessionsInUse = cap(t.pool.free) - len(t.pool.free)
+		st.SessionWaits = t.pool.waits.Load()
+		st.SessionTotalWait = time.Duration(t.pool.totalWait.Load())
+		st.SessionsReclaimed = t.pool.reclaimed.Load()
+	}
+	return st
+}
+
+// sessionFor returns the session a request is sent in: the one set on ctx,
+// the pooled session holding the request's lock handle, or the shared one.
+func (t *Transport) sessionFor(ctx context.Context, opts *RequestOptions) *session {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		return s
+	}
+	if t.pool != nil {
+		if handle := opts.Query.Get("lockHandle"); handle != "" {
+			if s := t.pool.lookup(handle); s != nil {
+				return s
+			}
+		}
+	}
+	return t.shared
+}
+
+// lockSession prepares ctx for a lock request. With a session pool and no
+// session on ctx yet, it takes a pooled session; pin it with pinLock once the
+// lock handle is known, or call release if locking fails.
+func (t *Transport) lockSession(ctx context.Context) (lockCtx context.Context, s *session, release func(), err error) {
+	if _, ok := ctx.Value(sessionKey{}).(*session); ok || t.pool == nil {
+		return ctx, nil, func() {}, nil
+	}
+	s, err = t.pool.acquire(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("waiting for a free session: %w", err)
+	}
+	return withSession(ctx, s), s, func() { t.pool.release(s) }, nil
+}
+
+// pinLock keeps s for requests with lockHandle until unpinLock.
+func (t *Transport) pinLock(lockHandle string, s *session) {
+	if s != nil {
+		t.pool.pin(lockHandle, s)
+	}
+}
+
+// unpinLock returns the session holding lockHandle to the pool.
+func (t *Transport) unpinLock(lockHandle string) {
+	if t.pool != nil {
+		t.pool.unpin(lockHandle)
+	}
+}
+
+// withDedicatedSession runs fn with a pooled session set on ctx, so that all
+// its requests go to the same SAP session. Without a pool, fn runs in the
+// shared session.
+func (t *Transport) withDedicatedSession(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(sessionKey{}).(*session); ok || t.pool == nil {
+		return fn(ctx)
+	}
+	s, err := t.pool.acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("waiting for a free session: %w", err)
+	}
+	defer t.pool.release(s)
+	return fn(withSession(ctx, s))
+}
+
+// do sends req in session s within the concurrency and rate limits and reads
+// the response body.
+func (t *Transport) do(ctx context.Context, s *session, req *http.Request) (*http.Response, []byte, error) {
+	release, err := t.throttle.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if isNetworkFailure(err) {
+			err = &NetworkError{Err: err}
+		}
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading response body: %w", &NetworkError{Err: err})
 	}
+	return resp, body, nil
 }
 
 // RequestOptions contains options for an HTTP request.
@@ -58,6 +197,10 @@ type RequestOptions struct {
 	Body        []byte
 	ContentType string
 	Accept      string
+	// Idempotent marks a POST without side effects (syntax check, code
+	// completion, data preview) as safe to retry after transient failures.
+	// GET and HEAD requests are always retriable.
+	Idempotent bool
 }
 
 // Response wraps an HTTP response with convenience methods.
@@ -68,6 +211,8 @@ type Response struct {
 }
 
 // Request performs an HTTP request to the ADT API.
+// Retriable requests that fail with a transient error (see IsRetriableError)
+// are retried according to Config.Retry.
 func (t *Transport) Request(ctx context.Context, path string, opts *RequestOptions) (*Response, error) {
 	if opts == nil {
 		opts = &RequestOptions{}
@@ -76,6 +221,38 @@ func (t *Transport) Request(ctx context.Context, path string, opts *RequestOptio
 		opts.Method = http.MethodGet
 	}
 
+	policy := t.config.Retry
+	retriable := opts.Method == http.MethodGet || opts.Method == http.MethodHead || opts.Idempotent
+	for attempt := 1; ; attempt++ {
+		resp, err := t.request(ctx, path, opts)
+		if err == nil || !retriable || !IsRetriableError(err) {
+			return resp, err
+		}
+		if attempt >= policy.MaxAttempts {
+			if attempt > 1 {
+				return nil, &RetryError{Attempts: attempt, Err: err}
+			}
+			return nil, err
+		}
+		if !t.retryBudget.take() {
+			t.retriesDenied.Add(1)
+			return nil, &RetryError{Attempts: attempt, Err: fmt.Errorf("%w (retry budget exhausted)", err)}
+		}
+		t.retries.Add(1)
+
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
+	}
+}
+
+// request performs one attempt of Request, including the CSRF and session
+// refresh retry.
+func (t *Transport) request(ctx context.Context, path string, opts *RequestOptions) (*Response, error) {
 	// Build URL
 	reqURL, err := t.buildURL(path, opts.Query)
 	if err != nil {
@@ -92,15 +269,11 @@ func (t *Transport) Request(ctx context.Context, path string, opts *RequestOptio
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
+	sess := t.sessionFor(ctx, opts)
 
-	// Set authentication - either basic auth or cookies
-	if t.config.HasBasicAuth() {
-		req.SetBasicAuth(t.config.Username, t.config.Password)
-	}
-
-	// Add user-provided cookies for cookie-based authentication
-	for name, value := range t.config.Cookies {
-		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	// Set authentication - bearer token, basic auth and/or cookies
+	if err := t.authenticate(ctx, req); err != nil {
+		return nil, err
 	}
 
 	// Set default headers
@@ -108,49 +281,47 @@ func (t *Transport) Request(ctx context.Context, path string, opts *RequestOptio
 
 	// Add CSRF token for modifying requests
 	if isModifyingMethod(opts.Method) {
-		token := t.getCSRFToken()
+		token := sess.getCSRFToken()
 		if token == "" {
 			// Fetch CSRF token first
-			if err := t.fetchCSRFToken(ctx); err != nil {
+			if err := t.fetchCSRFToken(ctx, sess); err != nil {
 				return nil, fmt.Errorf("fetching CSRF token: %w", err)
 			}
-			token = t.getCSRFToken()
+			token = sess.getCSRFToken()
 		}
 		req.Header.Set("X-CSRF-Token", token)
 	}
 
 	// Execute request
-	resp, err := t.httpClient.Do(req)
+	resp, body, err := t.do(ctx, sess, req)
 	if err != nil {
 		return nil, fmt.Errorf("executing request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("reading response body: %w", err)
-	}
 
 	// Handle CSRF token refresh on 403
 	if resp.StatusCode == http.StatusForbidden && isModifyingMethod(opts.Method) {
 		// Try to refresh CSRF token and retry once
-		if err := t.fetchCSRFToken(ctx); err != nil {
+		if err := t.fetchCSRFToken(ctx, sess); err != nil {
 			return nil, fmt.Errorf("refreshing CSRF token: %w", err)
 		}
 
 		// Retry the request
-		return t.retryRequest(ctx, path, opts)
+		return t.retryRequest(ctx, sess, path, opts)
+	}
+
+	// Expired or revoked access token: get a new one and retry once
+	if resp.StatusCode == http.StatusUnauthorized && t.config.TokenSource != nil && t.invalidateToken() {
+		return t.retryRequest(ctx, sess, path, opts)
 	}
 
 	// Store CSRF token from response
 	if token := resp.Header.Get("X-CSRF-Token"); token != "" && token != "Required" {
-		t.setCSRFToken(token)
+		sess.setCSRFToken(token)
 	}
 
 	// Store session ID
 	if sessionID := t.extractSessionID(resp); sessionID != "" {
-		t.setSessionID(sessionID)
+		sess.setSessionID(sessionID)
 	}
 
 	// Check for error status codes
@@ -164,14 +335,14 @@ func (t *Transport) Request(ctx context.Context, path string, opts *RequestOptio
 		// Handle session timeout - refresh session and retry once
 		if apiErr.IsSessionExpired() {
 			// Clear cached CSRF token and session ID
-			t.setCSRFToken("")
-			t.setSessionID("")
+			sess.setCSRFToken("")
+			sess.setSessionID("")
 			// Fetch new CSRF token (this establishes a new session)
-			if err := t.fetchCSRFToken(ctx); err != nil {
+			if err := t.fetchCSRFToken(ctx, sess); err != nil {
 				return nil, fmt.Errorf("refreshing session after timeout: %w", err)
 			}
 			// Retry the request
-			return t.retryRequest(ctx, path, opts)
+			return t.retryRequest(ctx, sess, path, opts)
 		}
 
 		return nil, apiErr
@@ -185,7 +356,7 @@ func (t *Transport) Request(ctx context.Context, path string, opts *RequestOptio
 }
 
 // retryRequest retries a request after CSRF token refresh.
-func (t *Transport) retryRequest(ctx context.Context, path string, opts *RequestOptions) (*Response, error) {
+func (t *Transport) retryRequest(ctx context.Context, sess *session, path string, opts *RequestOptions) (*Response, error) {
 	reqURL, err := t.buildURL(path, opts.Query)
 	if err != nil {
 		return nil, fmt.Errorf("building URL: %w", err)
@@ -202,30 +373,21 @@ func (t *Transport) retryRequest(ctx context.Context, path string, opts *Request
 	}
 
 	// Set authentication
-	if t.config.HasBasicAuth() {
-		req.SetBasicAuth(t.config.Username, t.config.Password)
-	}
-	for name, value := range t.config.Cookies {
-		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	if err := t.authenticate(ctx, req); err != nil {
+		return nil, err
 	}
 	t.setDefaultHeaders(req, opts)
-	req.Header.Set("X-CSRF-Token", t.getCSRFToken())
+	req.Header.Set("X-CSRF-Token", sess.getCSRFToken())
 
 	// Ensure session type header is set for retry
 	if t.config.SessionType == SessionStateful {
 		req.Header.Set("X-sap-adt-sessiontype", "stateful")
 	}
 
-	resp, err := t.httpClient.Do(req)
+	resp, body, err := t.do(ctx, sess, req)
 	if err != nil {
 		return nil, fmt.Errorf("executing retry request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("reading response body: %w", err)
-	}
 
 	if resp.StatusCode >= 400 {
 		return nil, &APIError{
@@ -242,9 +404,9 @@ func (t *Transport) retryRequest(ctx context.Context, path string, opts *Request
 	}, nil
 }
 
-// fetchCSRFToken retrieves a CSRF token from the server.
+// fetchCSRFToken retrieves a CSRF token for sess from the server.
 // Uses /core/discovery with HEAD for optimal performance (~25ms vs ~56s for GET on /discovery)
-func (t *Transport) fetchCSRFToken(ctx context.Context) error {
+func (t *Transport) fetchCSRFToken(ctx context.Context, sess *session) error {
 	reqURL, err := t.buildURL("/sap/bc/adt/core/discovery", nil)
 	if err != nil {
 		return fmt.Errorf("building URL: %w", err)
@@ -257,11 +419,8 @@ func (t *Transport) fetchCSRFToken(ctx context.Context) error {
 	}
 
 	// Set authentication
-	if t.config.HasBasicAuth() {
-		req.SetBasicAuth(t.config.Username, t.config.Password)
-	}
-	for name, value := range t.config.Cookies {
-		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	if err := t.authenticate(ctx, req); err != nil {
+		return err
 	}
 	req.Header.Set("X-CSRF-Token", "fetch")
 	req.Header.Set("Accept", "*/*")
@@ -271,14 +430,11 @@ func (t *Transport) fetchCSRFToken(ctx context.Context) error {
 		req.Header.Set("X-sap-adt-sessiontype", "stateful")
 	}
 
-	resp, err := t.httpClient.Do(req)
+	// The body is drained to allow connection reuse
+	resp, _, err := t.do(ctx, sess, req)
 	if err != nil {
 		return fmt.Errorf("executing request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	// Drain body to allow connection reuse
-	_, _ = io.Copy(io.Discard, resp.Body)
 
 	// Note: HEAD may return 400 but still provides CSRF token in headers
 	// But 401/403 indicates auth failure and won't have a valid token
@@ -296,7 +452,7 @@ func (t *Transport) fetchCSRFToken(ctx context.Context) error {
 		}
 	}
 
-	t.setCSRFToken(token)
+	sess.setCSRFToken(token)
 	return nil
 }
 
@@ -372,32 +528,6 @@ func (t *Transport) extractSessionID(resp *http.Response) string {
 	return ""
 }
 
-// CSRF token accessors with mutex protection
-func (t *Transport) getCSRFToken() string {
-	t.csrfMu.RLock()
-	defer t.csrfMu.RUnlock()
-	return t.csrfToken
-}
-
-func (t *Transport) setCSRFToken(token string) {
-	t.csrfMu.Lock()
-	defer t.csrfMu.Unlock()
-	t.csrfToken = token
-}
-
-// Session ID accessors with mutex protection
-func (t *Transport) getSessionID() string {
-	t.sessionMu.RLock()
-	defer t.sessionMu.RUnlock()
-	return t.sessionID
-}
-
-func (t *Transport) setSessionID(id string) {
-	t.sessionMu.Lock()
-	defer t.sessionMu.Unlock()
-	t.sessionID = id
-}
-
 // isModifyingMethod returns true for HTTP methods that modify server state.
 func isModifyingMethod(method string) bool {
 	switch method {
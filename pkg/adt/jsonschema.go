@@ -0,0 +1,201 @@
+package adt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SchemaError lists the violations found by ValidateSchema.
+type SchemaError struct {
+	Violations []string
+}
+
+func (e *SchemaError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// jsonSchema is the subset of JSON Schema that ZADT_VSP domains use to
+// describe action params.
+type jsonSchema struct {
+	Type                 any                    `json:"type"` // string or list of strings
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []any                  `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+}
+
+// ValidateSchema validates the JSON encoding of value against a JSON
+// schema. It supports type,
+// properties, required, additionalProperties, items, enum, minimum,
+// maximum, minLength, maxLength, pattern, minItems and maxItems; other
+// keywords are ignored. Nil params validate as an empty object. Violations
+// are returned as *SchemaError.
+func ValidateSchema(schema json.RawMessage, value any) error {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	// Absent params are an empty object; typed values are normalized to
+	// what encoding/json decodes
+	if value == nil || reflect.ValueOf(value).Kind() == reflect.Map && reflect.ValueOf(value).IsNil() {
+		value = map[string]any{}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	value = nil
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	var violations []string
+	s.validate("params", value, &violations)
+	if len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+	return nil
+}
+
+func (s *jsonSchema) validate(path string, v any, violations *[]string) {
+	fail := func(format string, args ...any) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if types := s.types(); len(types) > 0 {
+		actual := jsonType(v)
+		ok := false
+		for _, t := range types {
+			if t == actual || (t == "number" && actual == "integer") {
+				ok = true
+			}
+		}
+		if !ok {
+			fail("expected %s, got %s", strings.Join(types, " or "), actual)
+			return
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+			}
+		}
+		if !found {
+			fail("must be one of %s", enumList(s.Enum))
+		}
+	}
+
+	switch val := v.(type) {
+	case string:
+		n := len([]rune(val))
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("shorter than %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("longer than %d characters", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(val) {
+				fail("does not match %s", s.Pattern)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			fail("less than %v", *s.Minimum)
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			fail("greater than %v", *s.Maximum)
+		}
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			fail("fewer than %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			fail("more than %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(path+"."+name, val[name], violations)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				fail("unknown property %q", name)
+			}
+		}
+	}
+}
+
+func (s *jsonSchema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var out []string
+		for _, x := range t {
+			if str, ok := x.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func jsonType(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func enumList(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		data, _ := json.Marshal(v)
+		parts[i] = string(data)
+	}
+	return strings.Join(parts, ", ")
+}
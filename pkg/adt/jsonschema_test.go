@@ -0,0 +1,67 @@
+package adt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	schema := []byte(`{
+		"type": "object",
+		"required": ["object"],
+		"additionalProperties": false,
+		"properties": {
+			"object":   {"type": "string", "pattern": "^Z", "maxLength": 20},
+			"severity": {"enum": ["E", "W", "I"]},
+			"max":      {"type": "integer", "minimum": 1},
+			"tags":     {"type": "array", "items": {"type": "string"}}
+		}
+	}`)
+
+	tests := []struct {
+		name   string
+		params any
+		want   []string // substrings of the violations; none for valid params
+	}{
+		{"valid", map[string]any{"object": "ZSALES", "severity": "E", "max": 10, "tags": []string{"a"}}, nil},
+		{"typed struct", struct {
+			Object string `json:"object"`
+		}{"ZSALES"}, nil},
+		{"missing required", nil, []string{`missing required property "object"`}},
+		{"wrong types", map[string]any{"object": 1, "max": 1.5, "tags": []any{"a", 2}}, []string{
+			"params.object: expected string, got integer",
+			"params.max: expected integer, got number",
+			"params.tags[1]: expected string",
+		}},
+		{"constraints", map[string]any{"object": "YSALES", "severity": "X", "max": 0}, []string{
+			"params.object: does not match ^Z",
+			`params.severity: must be one of "E", "W", "I"`,
+			"params.max: less than 1",
+		}},
+		{"unknown property", map[string]any{"object": "ZA", "extra": true}, []string{`unknown property "extra"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema(schema, tt.params)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var serr *SchemaError
+			if !errors.As(err, &serr) {
+				t.Fatalf("expected SchemaError, got %v", err)
+			}
+			if len(serr.Violations) != len(tt.want) {
+				t.Errorf("violations = %q", serr.Violations)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("missing %q in %q", w, err)
+				}
+			}
+		})
+	}
+}
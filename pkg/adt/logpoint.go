@@ -0,0 +1,276 @@
+// Package adt provides ABAP Development Tools client functionality.
+// logpoint.go implements logpoints: breakpoints that log a message and continue.
+package adt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxLogpointHits bounds the in-memory logpoint log; older hits are dropped.
+const maxLogpointHits = 1000
+
+// Logpoint is a breakpoint that, when hit, evaluates the variables referenced
+// in Message, logs the formatted message and continues execution.
+type Logpoint struct {
+	ID      string `json:"id"` // Breakpoint ID
+	Program string `json:"program"`
+	Line    int    `json:"line"`
+	Message string `json:"message"` // e.g. "order {ls_order-vbeln} total {lv_total}"
+	Hits    int    `json:"hits"`
+}
+
+// LogpointHit is one logged message.
+type LogpointHit struct {
+	Time       time.Time         `json:"time"`
+	LogpointID string            `json:"logpoint_id"`
+	Location   CodeLocation      `json:"location"`
+	Message    string            `json:"message"`
+	Values     map[string]string `json:"values,omitempty"`
+}
+
+// LogpointDebugger is the part of the debugger API used to evaluate logpoints.
+// It is implemented by *Client.
+type LogpointDebugger interface {
+	DebuggerGetStack(ctx context.Context, semanticURIs bool) (*DebugStackInfo, error)
+	DebuggerGetVariables(ctx context.Context, variableIDs []string) ([]DebugVariable, error)
+	DebuggerStep(ctx context.Context, stepType DebugStepType, uri string) (*DebugStepResult, error)
+}
+
+// LogTemplateVariables returns the variable expressions referenced in a
+// logpoint message template, in order of first appearance and upper-cased.
+func LogTemplateVariables(template string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for rest := template; ; {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			break
+		}
+		name := strings.ToUpper(strings.TrimSpace(rest[start+1 : start+end]))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		rest = rest[start+end+1:]
+	}
+	return names
+}
+
+// FormatLogMessage replaces each {expression} in template with its value.
+// Expressions without a value are rendered as <expression?>.
+func FormatLogMessage(template string, values map[string]string) string {
+	var sb strings.Builder
+	rest := template
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			break
+		}
+		sb.WriteString(rest[:start])
+		expr := strings.TrimSpace(rest[start+1 : start+end])
+		if value, ok := values[strings.ToUpper(expr)]; ok {
+			sb.WriteString(value)
+		} else {
+			fmt.Fprintf(&sb, "<%s?>", expr)
+		}
+		rest = rest[start+end+1:]
+	}
+	sb.WriteString(rest)
+	return sb.String()
+}
+
+// LogpointManager tracks the logpoints of a debug session and their log.
+type LogpointManager struct {
+	mu       sync.Mutex
+	points   map[string]*Logpoint
+	hits     []LogpointHit
+	recorder *ExecutionRecorder
+}
+
+// NewLogpointManager creates an empty logpoint manager.
+func NewLogpointManager() *LogpointManager {
+	return &LogpointManager{points: make(map[string]*Logpoint)}
+}
+
+// Add registers a logpoint for an existing breakpoint.
+func (m *LogpointManager) Add(lp Logpoint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lp.Program = strings.ToUpper(lp.Program)
+	m.points[lp.ID] = &lp
+}
+
+// Remove unregisters a logpoint. It reports whether id was a logpoint.
+func (m *LogpointManager) Remove(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.points[id]
+	delete(m.points, id)
+	return ok
+}
+
+// Len returns the number of registered logpoints.
+func (m *LogpointManager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.points)
+}
+
+// List returns the registered logpoints.
+func (m *LogpointManager) List() []Logpoint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Logpoint, 0, len(m.points))
+	for _, lp := range m.points {
+		list = append(list, *lp)
+	}
+	return list
+}
+
+// Hits returns the logged messages, oldest first.
+func (m *LogpointManager) Hits() []LogpointHit {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]LogpointHit(nil), m.hits...)
+}
+
+// ClearHits empties the log.
+func (m *LogpointManager) ClearHits() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hits = nil
+}
+
+// SetRecorder makes logpoint hits also appear as "logpoint" frames in an
+// execution recording. Pass nil to stop.
+func (m *LogpointManager) SetRecorder(r *ExecutionRecorder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recorder = r
+}
+
+// Match returns the logpoint the debuggee stopped at, by reached breakpoint ID
+// or by location, or nil.
+func (m *LogpointManager) Match(loc CodeLocation, reached []DebugReachedBreakpoint) *Logpoint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, bp := range reached {
+		if lp, ok := m.points[bp.ID]; ok {
+			return lp
+		}
+	}
+	for _, lp := range m.points {
+		if lp.Line == loc.Line && (strings.EqualFold(lp.Program, loc.Program) || strings.EqualFold(lp.Program, loc.Include)) {
+			return lp
+		}
+	}
+	return nil
+}
+
+// Evaluate reads the variables referenced by lp at the current position and logs the message.
+func (m *LogpointManager) Evaluate(ctx context.Context, dbg LogpointDebugger, lp *Logpoint, loc CodeLocation) LogpointHit {
+	names := LogTemplateVariables(lp.Message)
+	values := make(map[string]string, len(names))
+	captured := make(map[string]VariableValue, len(names))
+	if len(names) > 0 {
+		vars, err := dbg.DebuggerGetVariables(ctx, names)
+		if err != nil {
+			// One unknown expression fails the whole request - retry one by one
+			vars = nil
+			for _, name := range names {
+				if v, err := dbg.DebuggerGetVariables(ctx, []string{name}); err == nil {
+					vars = append(vars, v...)
+				}
+			}
+		}
+		for _, v := range vars {
+			id := strings.ToUpper(v.ID)
+			if id == "" {
+				id = strings.ToUpper(v.Name)
+			}
+			value := v.Value
+			if v.TableLines > 0 {
+				value = fmt.Sprintf("<%d rows>", v.TableLines)
+			}
+			values[id] = value
+			captured[id] = VariableValue{Name: id, Type: v.DeclaredTypeName, MetaType: string(v.MetaType), Value: v.Value}
+		}
+	}
+
+	hit := LogpointHit{
+		Time:       time.Now(),
+		LogpointID: lp.ID,
+		Location:   loc,
+		Message:    FormatLogMessage(lp.Message, values),
+		Values:     values,
+	}
+
+	m.mu.Lock()
+	lp.Hits++
+	m.hits = append(m.hits, hit)
+	if len(m.hits) > maxLogpointHits {
+		m.hits = m.hits[len(m.hits)-maxLogpointHits:]
+	}
+	recorder := m.recorder
+	m.mu.Unlock()
+
+	if recorder != nil {
+		recorder.RecordFrame(loc, "logpoint", captured)
+	}
+	return hit
+}
+
+// ContinueThrough handles a debuggee stop: while it is stopped at a logpoint,
+// the message is logged and execution continues. It returns the messages
+// logged and whether the debuggee is still stopped (at a regular breakpoint)
+// rather than finished.
+func (m *LogpointManager) ContinueThrough(ctx context.Context, dbg LogpointDebugger, reached []DebugReachedBreakpoint) ([]LogpointHit, bool, error) {
+	var hits []LogpointHit
+	for {
+		if err := ctx.Err(); err != nil {
+			return hits, true, err
+		}
+
+		stack, err := dbg.DebuggerGetStack(ctx, false)
+		if err != nil {
+			return hits, true, fmt.Errorf("get stack: %w", err)
+		}
+		if len(stack.Stack) == 0 {
+			return hits, true, nil
+		}
+		top := stack.Stack[0]
+		for _, entry := range stack.Stack {
+			if entry.StackPosition == stack.DebugCursorStackIndex {
+				top = entry
+			}
+		}
+		loc := CodeLocation{Program: top.ProgramName, Include: top.IncludeName, Line: top.Line}
+
+		lp := m.Match(loc, reached)
+		if lp == nil {
+			return hits, true, nil
+		}
+		hits = append(hits, m.Evaluate(ctx, dbg, lp, loc))
+
+		result, err := dbg.DebuggerStep(ctx, DebugStepContinue, "")
+		if err != nil {
+			return hits, false, fmt.Errorf("continue after logpoint: %w", err)
+		}
+		if !result.IsSteppingPossible {
+			return hits, false, nil
+		}
+		reached = result.ReachedBreakpoints
+	}
+}
@@ -0,0 +1,141 @@
+package adt
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestLogTemplateVariables(t *testing.T) {
+	got := LogTemplateVariables("order {ls_order-vbeln} total {lv_total} again {LV_TOTAL} {} {open")
+	want := []string{"LS_ORDER-VBELN", "LV_TOTAL"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("LogTemplateVariables = %v, want %v", got, want)
+	}
+}
+
+func TestFormatLogMessage(t *testing.T) {
+	got := FormatLogMessage("order {ls_order-vbeln} total {lv_total} {lv_missing} {open", map[string]string{
+		"LS_ORDER-VBELN": "0000004711",
+		"LV_TOTAL":       "99.50",
+	})
+	want := "order 0000004711 total 99.50 <lv_missing?> {open"
+	if got != want {
+		t.Errorf("FormatLogMessage = %q, want %q", got, want)
+	}
+}
+
+// fakeLogpointDebugger stops at the given locations in turn; each continue
+// moves to the next one, and continuing from the last one ends the program.
+type fakeLogpointDebugger struct {
+	stops     []CodeLocation
+	pos       int
+	vars      map[string]string
+	continues int
+}
+
+func (f *fakeLogpointDebugger) DebuggerGetStack(ctx context.Context, semanticURIs bool) (*DebugStackInfo, error) {
+	loc := f.stops[f.pos]
+	return &DebugStackInfo{Stack: []DebugStackEntry{{ProgramName: loc.Program, IncludeName: loc.Include, Line: loc.Line}}}, nil
+}
+
+func (f *fakeLogpointDebugger) DebuggerGetVariables(ctx context.Context, ids []string) ([]DebugVariable, error) {
+	var vars []DebugVariable
+	for _, id := range ids {
+		value, ok := f.vars[id]
+		if !ok {
+			return nil, fmt.Errorf("variable %s unknown", id)
+		}
+		vars = append(vars, DebugVariable{ID: id, Name: id, Value: value})
+	}
+	return vars, nil
+}
+
+func (f *fakeLogpointDebugger) DebuggerStep(ctx context.Context, stepType DebugStepType, uri string) (*DebugStepResult, error) {
+	if stepType != DebugStepContinue {
+		return nil, fmt.Errorf("unexpected step %s", stepType)
+	}
+	f.continues++
+	f.pos++
+	result := &DebugStepResult{}
+	result.IsSteppingPossible = f.pos < len(f.stops)
+	return result, nil
+}
+
+func TestLogpointManagerContinueThrough(t *testing.T) {
+	m := NewLogpointManager()
+	m.Add(Logpoint{ID: "BP1", Program: "zorders", Line: 10, Message: "order {ls_order-vbeln} total {lv_total} {lv_unknown}"})
+	recorder := NewExecutionRecorder("s", "ZORDERS")
+	m.SetRecorder(recorder)
+
+	dbg := &fakeLogpointDebugger{
+		stops: []CodeLocation{
+			{Program: "ZORDERS", Line: 10},
+			{Program: "ZORDERS", Line: 10},
+			{Program: "ZORDERS", Line: 20}, // regular breakpoint
+		},
+		vars: map[string]string{"LS_ORDER-VBELN": "4711", "LV_TOTAL": "99.50"},
+	}
+
+	hits, stopped, err := m.ContinueThrough(context.Background(), dbg, nil)
+	if err != nil {
+		t.Fatalf("ContinueThrough: %v", err)
+	}
+	if !stopped {
+		t.Error("expected debuggee to stay stopped at the regular breakpoint")
+	}
+	if len(hits) != 2 || dbg.continues != 2 {
+		t.Fatalf("hits = %d, continues = %d, want 2 and 2", len(hits), dbg.continues)
+	}
+	if want := "order 4711 total 99.50 <lv_unknown?>"; hits[0].Message != want {
+		t.Errorf("message = %q, want %q", hits[0].Message, want)
+	}
+	if got := m.List()[0].Hits; got != 2 {
+		t.Errorf("logpoint hits = %d, want 2", got)
+	}
+	if len(m.Hits()) != 2 {
+		t.Errorf("log has %d entries, want 2", len(m.Hits()))
+	}
+
+	rec := recorder.GetRecording()
+	if rec.TotalSteps != 2 || rec.Frames[0].StepType != "logpoint" {
+		t.Errorf("recording = %d steps (%+v)", rec.TotalSteps, rec.Frames)
+	}
+	if v := rec.VariablesAt(1)["LV_TOTAL"]; v.Value != "99.50" {
+		t.Errorf("recorded LV_TOTAL = %v", v.Value)
+	}
+
+	m.ClearHits()
+	if len(m.Hits()) != 0 {
+		t.Error("ClearHits left entries")
+	}
+}
+
+func TestLogpointManagerFinishes(t *testing.T) {
+	m := NewLogpointManager()
+	m.Add(Logpoint{ID: "BP1", Program: "ZCL_X=========================CP", Line: 5, Message: "hit"})
+
+	dbg := &fakeLogpointDebugger{stops: []CodeLocation{{Program: "ZCL_X=========================CP", Include: "ZCL_X=========================CM001", Line: 5}}}
+	hits, stopped, err := m.ContinueThrough(context.Background(), dbg, nil)
+	if err != nil {
+		t.Fatalf("ContinueThrough: %v", err)
+	}
+	if stopped || len(hits) != 1 || hits[0].Message != "hit" {
+		t.Errorf("stopped = %v, hits = %+v", stopped, hits)
+	}
+}
+
+func TestLogpointManagerMatchByID(t *testing.T) {
+	m := NewLogpointManager()
+	m.Add(Logpoint{ID: "BP9", Program: "/sap/bc/adt/programs/programs/ztest", Line: 3, Message: "x"})
+
+	if lp := m.Match(CodeLocation{Program: "ZTEST", Line: 3}, []DebugReachedBreakpoint{{ID: "BP9"}}); lp == nil || lp.ID != "BP9" {
+		t.Errorf("Match by ID = %+v", lp)
+	}
+	if lp := m.Match(CodeLocation{Program: "ZTEST", Line: 3}, nil); lp != nil {
+		t.Errorf("Match by location should fail for URI program, got %+v", lp)
+	}
+	if !m.Remove("BP9") || m.Remove("BP9") || m.Len() != 0 {
+		t.Error("Remove did not behave as expected")
+	}
+}
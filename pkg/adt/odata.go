@@ -0,0 +1,482 @@
+package adt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// ODataTestOptions configures TestODataService.
+type ODataTestOptions struct {
+	Binding        string         `json:"binding,omitempty"`        // Service binding whose service is tested
+	ServiceURL     string         `json:"serviceUrl,omitempty"`     // Service root URL, instead of Binding
+	EntitySets     []string       `json:"entitySets,omitempty"`     // Entity sets to query (default: all)
+	Top            int            `json:"top,omitempty"`            // Rows per query (default: 3)
+	Draft          bool           `json:"draft,omitempty"`          // Run a draft create, activate and delete round trip (OData V4)
+	DraftEntitySet string         `json:"draftEntitySet,omitempty"` // Entity set for the round trip (default: first draft-enabled set)
+	DraftPayload   map[string]any `json:"draftPayload,omitempty"`   // Properties of the created draft
+	SampleSize     int            `json:"sampleSize,omitempty"`     // Payload sample length in bytes (default: 500)
+}
+
+// ODataTestResult is the outcome of TestODataService.
+type ODataTestResult struct {
+	Success    bool                 `json:"success"`
+	ServiceURL string               `json:"serviceUrl"`
+	Version    string               `json:"version,omitempty"` // V2 or V4
+	Metadata   ODataRequest         `json:"metadata"`
+	EntitySets []ODataEntitySetTest `json:"entitySets,omitempty"`
+	Draft      []ODataRequest       `json:"draft,omitempty"`
+}
+
+// ODataEntitySetTest holds the requests run against one entity set.
+type ODataEntitySetTest struct {
+	Name       string         `json:"name"`
+	EntityType string         `json:"entityType"`
+	Keys       []string       `json:"keys"`
+	Navigation []string       `json:"navigation,omitempty"`
+	Requests   []ODataRequest `json:"requests"`
+}
+
+// ODataRequest is one request of a service test.
+type ODataRequest struct {
+	Name       string `json:"name"` // $metadata, $top, $filter, $expand, create, prepare, activate, delete, discard
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	Status     int    `json:"status,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Rows       int    `json:"rows,omitempty"`
+	Sample     string `json:"sample,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Skipped    string `json:"skipped,omitempty"` // reason the request was not run
+}
+
+// OK reports whether the request ran and succeeded.
+func (r *ODataRequest) OK() bool {
+	return r.Skipped == "" && r.Error == "" && r.Status < 400
+}
+
+// odataEntityType is an entity type of a service's $metadata.
+type odataEntityType struct {
+	Name string `xml:"Name,attr"`
+	Keys []struct {
+		Name string `xml:"Name,attr"`
+	} `xml:"Key>PropertyRef"`
+	Properties []struct {
+		Name string `xml:"Name,attr"`
+		Type string `xml:"Type,attr"`
+	} `xml:"Property"`
+	Navigation []struct {
+		Name string `xml:"Name,attr"`
+	} `xml:"NavigationProperty"`
+}
+
+// odataMetadata is the part of an EDMX document the service test uses.
+type odataMetadata struct {
+	Version string `xml:"Version,attr"`
+	Schemas []struct {
+		Namespace   string            `xml:"Namespace,attr"`
+		EntityTypes []odataEntityType `xml:"EntityType"`
+		Actions     []struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Action"`
+		Containers []struct {
+			EntitySets []struct {
+				Name       string `xml:"Name,attr"`
+				EntityType string `xml:"EntityType,attr"`
+			} `xml:"EntitySet"`
+		} `xml:"EntityContainer"`
+	} `xml:"DataServices>Schema"`
+}
+
+// odataTest carries the state of one TestODataService run.
+type odataTest struct {
+	c      *Client
+	root   string
+	v4     bool
+	sample int
+	meta   odataMetadata
+}
+
+// TestODataService smoke tests a published OData service: it reads
+// $metadata, queries each entity set with $top, $filter and $expand, and
+// optionally runs a draft create, activate and delete round trip. Request
+// failures are reported in the result; an error means the service could not
+// be located.
+func (c *Client) TestODataService(ctx context.Context, opts ODataTestOptions) (*ODataTestResult, error) {
+	if err := c.checkSafety(OpRead, "TestODataService"); err != nil {
+		return nil, err
+	}
+	if opts.Draft {
+		// The round trip creates and deletes business data
+		if err := c.checkSafety(OpCreate, "TestODataService (draft)"); err != nil {
+			return nil, err
+		}
+		if err := c.checkSafety(OpDelete, "TestODataService (draft)"); err != nil {
+			return nil, err
+		}
+	}
+	serviceURL := opts.ServiceURL
+	if serviceURL == "" {
+		if opts.Binding == "" {
+			return nil, fmt.Errorf("binding or service URL is required")
+		}
+		sb, err := c.GetSRVB(ctx, opts.Binding)
+		if err != nil {
+			return nil, err
+		}
+		if sb.ServiceURL == "" {
+			return nil, fmt.Errorf("cannot derive the service URL of %s (binding %s %s)", opts.Binding, sb.BindingType, sb.BindingVersion)
+		}
+		serviceURL = sb.ServiceURL
+	}
+	if u, err := url.Parse(serviceURL); err == nil && u.IsAbs() {
+		serviceURL = u.Path // the client's base URL and sap-client apply
+	}
+	serviceURL = path.Clean("/"+serviceURL) + "/"
+	if !strings.HasPrefix(serviceURL, "/sap/opu/odata/") && !strings.HasPrefix(serviceURL, "/sap/opu/odata4/") {
+		return nil, fmt.Errorf("service URL %s is not an OData service (expected /sap/opu/odata/... or /sap/opu/odata4/...)", serviceURL)
+	}
+	if opts.Top <= 0 {
+		opts.Top = 3
+	}
+	if opts.SampleSize <= 0 {
+		opts.SampleSize = 500
+	}
+
+	t := &odataTest{c: c, root: serviceURL, sample: opts.SampleSize}
+	result := &ODataTestResult{ServiceURL: serviceURL}
+	var body []byte
+	result.Metadata, body = t.do(ctx, "$metadata", http.MethodGet, "$metadata", nil, nil, nil)
+	if !result.Metadata.OK() {
+		return result, nil
+	}
+	if err := xml.Unmarshal(body, &t.meta); err != nil {
+		result.Metadata.Error = fmt.Sprintf("parsing $metadata: %v", err)
+		return result, nil
+	}
+	t.v4 = strings.HasPrefix(t.meta.Version, "4")
+	result.Version = "V2"
+	if t.v4 {
+		result.Version = "V4"
+	}
+
+	only := make(map[string]bool)
+	for _, name := range opts.EntitySets {
+		only[strings.ToUpper(name)] = true
+	}
+	for _, set := range t.entitySets() {
+		if len(only) > 0 && !only[strings.ToUpper(set.Name)] {
+			continue
+		}
+		result.EntitySets = append(result.EntitySets, t.queryEntitySet(ctx, set, opts.Top))
+	}
+	if opts.Draft {
+		result.Draft = t.draftRoundTrip(ctx, opts.DraftEntitySet, opts.DraftPayload)
+	}
+
+	result.Success = true
+	for _, set := range result.EntitySets {
+		for _, r := range set.Requests {
+			result.Success = result.Success && (r.OK() || r.Skipped != "")
+		}
+	}
+	for _, r := range result.Draft {
+		result.Success = result.Success && (r.OK() || r.Skipped != "")
+	}
+	return result, nil
+}
+
+// odataEntitySet is an entity set with its resolved entity type.
+type odataEntitySet struct {
+	Name string
+	Type *odataEntityType
+}
+
+func (t *odataTest) entitySets() []odataEntitySet {
+	types := make(map[string]*odataEntityType)
+	for i := range t.meta.Schemas {
+		for j := range t.meta.Schemas[i].EntityTypes {
+			et := &t.meta.Schemas[i].EntityTypes[j]
+			types[et.Name] = et
+		}
+	}
+	var sets []odataEntitySet
+	for _, schema := range t.meta.Schemas {
+		for _, container := range schema.Containers {
+			for _, es := range container.EntitySets {
+				// EntityType is qualified with the schema namespace or alias
+				name := es.EntityType[strings.LastIndex(es.EntityType, ".")+1:]
+				if et, ok := types[name]; ok {
+					sets = append(sets, odataEntitySet{Name: es.Name, Type: et})
+				}
+			}
+		}
+	}
+	return sets
+}
+
+func (t *odataTest) queryEntitySet(ctx context.Context, set odataEntitySet, top int) ODataEntitySetTest {
+	res := ODataEntitySetTest{Name: set.Name, EntityType: set.Type.Name}
+	for _, k := range set.Type.Keys {
+		res.Keys = append(res.Keys, k.Name)
+	}
+	for _, n := range set.Type.Navigation {
+		res.Navigation = append(res.Navigation, n.Name)
+	}
+
+	topReq, body := t.do(ctx, "$top", http.MethodGet, set.Name, t.query("$top", fmt.Sprint(top)), nil, nil)
+	rows := t.rows(body)
+	topReq.Rows = len(rows)
+	res.Requests = append(res.Requests, topReq)
+
+	// Filter on a key of the first row, which must return that row
+	filterReq := ODataRequest{Name: "$filter", Method: http.MethodGet, URL: t.root + set.Name}
+	switch {
+	case !topReq.OK():
+		filterReq.Skipped = "$top failed"
+	case len(rows) == 0:
+		filterReq.Skipped = "no rows to filter on"
+	default:
+		filter := t.keyFilter(set.Type, rows[0])
+		if filter == "" {
+			filterReq.Skipped = "no filterable key"
+			break
+		}
+		filterReq, body = t.do(ctx, "$filter", http.MethodGet, set.Name, t.query("$filter", filter, "$top", fmt.Sprint(top)), nil, nil)
+		if filterReq.Rows = len(t.rows(body)); filterReq.OK() && filterReq.Rows == 0 {
+			filterReq.Error = "filter on an existing key returned no rows"
+		}
+	}
+	res.Requests = append(res.Requests, filterReq)
+
+	if len(set.Type.Navigation) == 0 {
+		res.Requests = append(res.Requests, ODataRequest{Name: "$expand", Method: http.MethodGet, URL: t.root + set.Name, Skipped: "no navigation properties"})
+		return res
+	}
+	expand := set.Type.Navigation[0].Name
+	expandReq, body := t.do(ctx, "$expand", http.MethodGet, set.Name, t.query("$expand", expand, "$top", "1"), nil, nil)
+	expandReq.Rows = len(t.rows(body))
+	res.Requests = append(res.Requests, expandReq)
+	return res
+}
+
+// keyFilter returns a $filter expression matching row by its first key
+// other than IsActiveEntity.
+func (t *odataTest) keyFilter(et *odataEntityType, row map[string]any) string {
+	for _, k := range et.Keys {
+		if k.Name == "IsActiveEntity" {
+			continue
+		}
+		if v, ok := row[k.Name]; ok && v != nil {
+			return fmt.Sprintf("%s eq %s", k.Name, t.literal(t.propertyType(et, k.Name), v))
+		}
+	}
+	return ""
+}
+
+func (t *odataTest) propertyType(et *odataEntityType, name string) string {
+	for _, p := range et.Properties {
+		if p.Name == name {
+			return p.Type
+		}
+	}
+	return ""
+}
+
+// literal formats a JSON value as an OData URL literal of the given EDM type.
+func (t *odataTest) literal(edmType string, v any) string {
+	s := fmt.Sprint(v)
+	switch edmType {
+	case "Edm.String":
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	case "Edm.Guid":
+		if !t.v4 {
+			return "guid'" + s + "'"
+		}
+	case "Edm.DateTime":
+		return "datetime'" + strings.TrimSuffix(s, "Z") + "'"
+	case "Edm.DateTimeOffset":
+		if !t.v4 {
+			return "datetimeoffset'" + s + "'"
+		}
+	case "Edm.Decimal":
+		if !t.v4 {
+			return s + "M"
+		}
+	case "Edm.Int64":
+		if !t.v4 {
+			return s + "L"
+		}
+	case "Edm.Boolean", "Edm.Byte", "Edm.SByte", "Edm.Int16", "Edm.Int32", "Edm.Double", "Edm.Single", "Edm.Date", "Edm.TimeOfDay":
+	default:
+		if _, isString := v.(string); isString {
+			return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+		}
+	}
+	return s
+}
+
+// keyPredicate returns the key predicate of row, e.g.
+// (TravelUUID=...,IsActiveEntity=false), with IsActiveEntity set to active.
+func (t *odataTest) keyPredicate(et *odataEntityType, row map[string]any, active bool) string {
+	var parts []string
+	for _, k := range et.Keys {
+		v := row[k.Name]
+		if k.Name == "IsActiveEntity" {
+			v = active
+		}
+		parts = append(parts, k.Name+"="+t.literal(t.propertyType(et, k.Name), v))
+	}
+	return "(" + strings.Join(parts, ",") + ")"
+}
+
+// draftRoundTrip creates a draft, activates it and deletes the active
+// instance. A draft that fails to activate is discarded.
+func (t *odataTest) draftRoundTrip(ctx context.Context, setName string, payload map[string]any) []ODataRequest {
+	if !t.v4 {
+		return []ODataRequest{{Name: "create", Method: http.MethodPost, URL: t.root, Skipped: "draft round trip needs OData V4"}}
+	}
+	var set *odataEntitySet
+	for _, s := range t.entitySets() {
+		if (setName == "" && t.propertyType(s.Type, "IsActiveEntity") != "") || strings.EqualFold(s.Name, setName) {
+			set = &s
+			break
+		}
+	}
+	if set == nil || t.propertyType(set.Type, "IsActiveEntity") == "" {
+		return []ODataRequest{{Name: "create", Method: http.MethodPost, URL: t.root + setName, Skipped: "no draft-enabled entity set"}}
+	}
+	namespace := ""
+	actions := make(map[string]bool)
+	for _, schema := range t.meta.Schemas {
+		for _, a := range schema.Actions {
+			actions[a.Name] = true
+			namespace = schema.Namespace
+		}
+	}
+
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	data, _ := json.Marshal(payload)
+	create, body := t.do(ctx, "create", http.MethodPost, set.Name, nil, data, nil)
+	reqs := []ODataRequest{create}
+	if !create.OK() {
+		return reqs
+	}
+	var draft map[string]any
+	if err := decodeODataJSON(body, &draft); err != nil {
+		reqs[0].Error = fmt.Sprintf("parsing created draft: %v", err)
+		return reqs
+	}
+	draftPath := set.Name + t.keyPredicate(set.Type, draft, false)
+
+	activated := false
+	if actions["Prepare"] {
+		prepare, _ := t.do(ctx, "prepare", http.MethodPost, draftPath+"/"+namespace+".Prepare", nil, []byte("{}"), nil)
+		reqs = append(reqs, prepare)
+	}
+	if actions["Activate"] {
+		activate, _ := t.do(ctx, "activate", http.MethodPost, draftPath+"/"+namespace+".Activate", nil, []byte("{}"), nil)
+		reqs = append(reqs, activate)
+		activated = activate.OK()
+	} else {
+		reqs = append(reqs, ODataRequest{Name: "activate", Method: http.MethodPost, URL: t.root + draftPath, Skipped: "no Activate action in $metadata"})
+	}
+
+	ifMatch := map[string]string{"If-Match": "*"}
+	if activated {
+		del, _ := t.do(ctx, "delete", http.MethodDelete, set.Name+t.keyPredicate(set.Type, draft, true), nil, nil, ifMatch)
+		return append(reqs, del)
+	}
+	discard, _ := t.do(ctx, "discard", http.MethodDelete, draftPath, nil, nil, ifMatch)
+	return append(reqs, discard)
+}
+
+// query builds query parameters from name/value pairs, adding the JSON
+// format for OData V2.
+func (t *odataTest) query(kv ...string) url.Values {
+	q := url.Values{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		q.Set(kv[i], kv[i+1])
+	}
+	if !t.v4 {
+		q.Set("$format", "json")
+	}
+	return q
+}
+
+// do runs one request relative to the service root and times it.
+func (t *odataTest) do(ctx context.Context, name, method, path string, query url.Values, body []byte, headers map[string]string) (ODataRequest, []byte) {
+	req := ODataRequest{Name: name, Method: method, URL: t.root + path}
+	if len(query) > 0 {
+		// Show the query readable, as in a browser
+		display, _ := url.QueryUnescape(query.Encode())
+		req.URL += "?" + display
+	}
+	opts := &RequestOptions{Method: method, Query: query, Accept: "application/json", Headers: headers}
+	if name == "$metadata" {
+		opts.Accept = "application/xml"
+	}
+	if body != nil {
+		opts.Body, opts.ContentType = body, "application/json"
+	}
+
+	start := time.Now()
+	resp, err := t.c.transport.Request(ctx, t.root+path, opts)
+	req.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			req.Status = apiErr.StatusCode
+			req.Error = t.truncate(apiErr.Message)
+		} else {
+			req.Error = err.Error()
+		}
+		return req, nil
+	}
+	req.Status = resp.StatusCode
+	req.Sample = t.truncate(string(resp.Body))
+	return req, resp.Body
+}
+
+// rows returns the entities of a JSON collection response.
+func (t *odataTest) rows(body []byte) []map[string]any {
+	var v4 struct {
+		Value []map[string]any `json:"value"`
+	}
+	var v2 struct {
+		D struct {
+			Results []map[string]any `json:"results"`
+		} `json:"d"`
+	}
+	if t.v4 {
+		decodeODataJSON(body, &v4)
+		return v4.Value
+	}
+	decodeODataJSON(body, &v2)
+	return v2.D.Results
+}
+
+// decodeODataJSON decodes an OData JSON payload, keeping numbers as
+// written so that keys are reproduced exactly in key predicates.
+func decodeODataJSON(body []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func (t *odataTest) truncate(s string) string {
+	if len(s) > t.sample {
+		return s[:t.sample] + "..."
+	}
+	return s
+}
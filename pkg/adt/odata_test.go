@@ -0,0 +1,204 @@
+package adt
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
+)
+
+func travelService(url string) adttest.ODataService {
+	return adttest.ODataService{URL: url, EntitySets: []adttest.ODataEntitySet{
+		{
+			Name:       "Travel",
+			Keys:       []string{"TravelUUID"},
+			Properties: []adttest.ODataProperty{{Name: "TravelUUID", Type: "Edm.Guid"}, {Name: "Description", Type: "Edm.String"}},
+			Navigation: []string{"_Booking"},
+			Draft:      true,
+			Rows: []map[string]any{
+				{"TravelUUID": "0894ef1b-7f2a-1eef-a6d6-1d5c2b3c4d5e", "Description": "Rome"},
+				{"TravelUUID": "0894ef1b-7f2a-1eef-a6d6-1d5c2b3c4d5f", "Description": "Paris"},
+			},
+		},
+		{
+			Name:       "Carrier",
+			Keys:       []string{"CarrierID"},
+			Properties: []adttest.ODataProperty{{Name: "CarrierID", Type: "Edm.String"}, {Name: "Name", Type: "Edm.String"}},
+			Rows:       []map[string]any{{"CarrierID": "LH", "Name": "Lufthansa"}},
+		},
+	}}
+}
+
+func TestTestODataServiceByBinding(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	client := NewClient(sap.URL, "developer", "secret")
+	ctx := context.Background()
+
+	if err := client.CreateObject(ctx, CreateObjectOptions{
+		ObjectType: ObjectTypeSRVB, Name: "ZUI_TRAVEL_O4", PackageName: "$TMP",
+		ServiceDefinition: "ZUI_TRAVEL", BindingVersion: "V4", BindingCategory: "1",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	serviceURL := "/sap/opu/odata4/sap/zui_travel_o4/srvd/sap/zui_travel/0001/"
+	sap.AddODataService(travelService(serviceURL))
+
+	res, err := client.TestODataService(ctx, ODataTestOptions{Binding: "ZUI_TRAVEL_O4", Top: 1, Draft: true, DraftPayload: map[string]any{"Description": "Smoke test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Version != "V4" || res.ServiceURL != serviceURL || res.Metadata.Status != 200 {
+		t.Fatalf("result = %+v", res)
+	}
+	if len(res.EntitySets) != 2 || res.EntitySets[0].Name != "Travel" || strings.Join(res.EntitySets[0].Keys, ",") != "TravelUUID,IsActiveEntity" {
+		t.Fatalf("entity sets = %+v", res.EntitySets)
+	}
+	travel := res.EntitySets[0].Requests
+	if len(travel) != 3 || travel[0].Rows != 1 || !strings.Contains(travel[0].Sample, "Rome") {
+		t.Errorf("Travel requests = %+v", travel)
+	}
+	if travel[1].Name != "$filter" || travel[1].Rows != 1 || !strings.HasSuffix(travel[1].URL, "$filter=TravelUUID eq 0894ef1b-7f2a-1eef-a6d6-1d5c2b3c4d5e&$top=1") {
+		t.Errorf("$filter = %+v", travel[1])
+	}
+	if travel[2].Name != "$expand" || !travel[2].OK() || !strings.Contains(travel[2].Sample, `"_Booking":[]`) {
+		t.Errorf("$expand = %+v", travel[2])
+	}
+	if carrier := res.EntitySets[1].Requests; carrier[2].Skipped != "no navigation properties" || !strings.Contains(carrier[1].URL, "CarrierID eq 'LH'") {
+		t.Errorf("Carrier requests = %+v", carrier)
+	}
+
+	var steps []string
+	for _, r := range res.Draft {
+		steps = append(steps, r.Name)
+		if !r.OK() {
+			t.Errorf("%s failed: %+v", r.Name, r)
+		}
+	}
+	if strings.Join(steps, ",") != "create,prepare,activate,delete" {
+		t.Errorf("draft steps = %v", steps)
+	}
+	if rows := sap.ODataRows(serviceURL, "Travel"); len(rows) != 2 {
+		t.Errorf("round trip left %d rows: %v", len(rows), rows)
+	}
+}
+
+func TestTestODataServiceV2(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	svc := adttest.ODataService{URL: ODataV2ServiceURL("ZUI_FLIGHT_O2"), V2: true, EntitySets: []adttest.ODataEntitySet{{
+		Name:       "Flight",
+		Keys:       []string{"FlightID"},
+		Properties: []adttest.ODataProperty{{Name: "FlightID", Type: "Edm.Int64"}, {Name: "Price", Type: "Edm.Decimal"}},
+		Rows:       []map[string]any{{"FlightID": 9007199254740993, "Price": "12.50"}},
+	}}}
+	sap.AddODataService(svc)
+	client := NewClient(sap.URL, "developer", "secret")
+
+	res, err := client.TestODataService(context.Background(), ODataTestOptions{ServiceURL: sap.URL + svc.URL, Draft: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Version != "V2" || res.ServiceURL != "/sap/opu/odata/sap/ZUI_FLIGHT_O2/" {
+		t.Fatalf("result = %+v", res)
+	}
+	filter := res.EntitySets[0].Requests[1]
+	if filter.Rows != 1 || !strings.Contains(filter.URL, "$filter=FlightID eq 9007199254740993L") || !strings.Contains(filter.URL, "$format=json") {
+		t.Errorf("$filter = %+v", filter)
+	}
+	if len(res.Draft) != 1 || res.Draft[0].Skipped != "draft round trip needs OData V4" {
+		t.Errorf("draft = %+v", res.Draft)
+	}
+
+	lit := &odataTest{}
+	for _, tc := range []struct {
+		typ  string
+		v    any
+		want string
+	}{
+		{"Edm.String", "O'Neil", "'O''Neil'"},
+		{"Edm.Guid", "0894ef1b", "guid'0894ef1b'"},
+		{"Edm.DateTime", "2024-01-31T00:00:00Z", "datetime'2024-01-31T00:00:00'"},
+		{"Edm.Decimal", "12.50", "12.50M"},
+		{"Edm.Boolean", true, "true"},
+	} {
+		if got := lit.literal(tc.typ, tc.v); got != tc.want {
+			t.Errorf("literal(%s, %v) = %s, want %s", tc.typ, tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestTestODataServiceFailures(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	client := NewClient(sap.URL, "developer", "secret")
+	ctx := context.Background()
+
+	res, err := client.TestODataService(ctx, ODataTestOptions{ServiceURL: "/sap/opu/odata4/sap/zmissing/srvd/sap/zmissing/0001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.Metadata.Status != 404 || res.EntitySets != nil {
+		t.Errorf("unpublished service: %+v", res)
+	}
+	if _, err := client.TestODataService(ctx, ODataTestOptions{}); err == nil {
+		t.Error("expected an error without binding or URL")
+	}
+	for _, u := range []string{"/sap/bc/adt/oo/classes/zcl_x", "/sap/opu/odata/../../bc/adt/discovery", sap.URL + "/sap/public/ping"} {
+		if _, err := client.TestODataService(ctx, ODataTestOptions{ServiceURL: u}); err == nil || !strings.Contains(err.Error(), "is not an OData service") {
+			t.Errorf("%s: err = %v", u, err)
+		}
+	}
+
+	serviceURL := "/sap/opu/odata4/sap/zui_travel_o4/srvd/sap/zui_travel/0001/"
+	svc := travelService(serviceURL)
+	svc.EntitySets[0].ActivateError = "Agency is mandatory"
+	sap.AddODataService(svc)
+
+	// Skipped steps do not fail the test
+	res, err = client.TestODataService(ctx, ODataTestOptions{ServiceURL: serviceURL, EntitySets: []string{"carrier"}, Draft: true, DraftEntitySet: "Carrier"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || len(res.EntitySets) != 1 || res.EntitySets[0].Name != "Carrier" || res.Draft[0].Skipped != "no draft-enabled entity set" {
+		t.Errorf("result = %+v", res)
+	}
+
+	// A draft that fails to activate is discarded
+	res, err = client.TestODataService(ctx, ODataTestOptions{ServiceURL: serviceURL, EntitySets: []string{"Travel"}, Draft: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Draft) != 4 || res.Draft[2].Status != 400 || !strings.Contains(res.Draft[2].Error, "Agency is mandatory") || res.Draft[3].Name != "discard" || !res.Draft[3].OK() {
+		t.Errorf("draft = %+v", res.Draft)
+	}
+	if rows := sap.ODataRows(serviceURL, "Travel"); len(rows) != 2 {
+		t.Errorf("draft not discarded: %v", rows)
+	}
+}
+
+func TestTestODataServiceSafety(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	serviceURL := "/sap/opu/odata4/sap/zui_travel_o4/srvd/sap/zui_travel/0001/"
+	sap.AddODataService(travelService(serviceURL))
+	client := NewClient(sap.URL, "developer", "secret", WithReadOnly())
+	ctx := context.Background()
+
+	res, err := client.TestODataService(ctx, ODataTestOptions{ServiceURL: serviceURL})
+	if err != nil || !res.Success {
+		t.Fatalf("read-only query run: %+v, %v", res, err)
+	}
+	if _, err := client.TestODataService(ctx, ODataTestOptions{ServiceURL: serviceURL, Draft: true}); err == nil || !strings.Contains(err.Error(), "blocked by safety configuration") {
+		t.Errorf("draft round trip under read-only: %v", err)
+	}
+	if n := sap.CountRequests("POST", serviceURL); n != 0 {
+		t.Errorf("%d POST requests under read-only", n)
+	}
+
+	noRead := NewClient(sap.URL, "developer", "secret", WithSafety(SafetyConfig{DisallowedOps: "R"}))
+	if _, err := noRead.TestODataService(ctx, ODataTestOptions{ServiceURL: serviceURL}); err == nil {
+		t.Error("expected reads to be blocked")
+	}
+}
@@ -0,0 +1,582 @@
+package adt
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RAPStackOptions configures GenerateRAPStack.
+type RAPStackOptions struct {
+	Table       string              `json:"table,omitempty"`       // Existing table to build the stack on
+	Spec        *CreateTableOptions `json:"spec,omitempty"`        // Table to create first (CreateTable spec); replaces Table
+	Name        string              `json:"name,omitempty"`        // Entity name used in object names (default: table name without Z/Y prefix)
+	Description string              `json:"description,omitempty"` // Label of the views and service (default: entity name)
+	Package     string              `json:"package,omitempty"`     // Target package (default: $TMP)
+	Transport   string              `json:"transport,omitempty"`
+	DraftTable  string              `json:"draftTable,omitempty"` // Draft table name (default: table name + "_D")
+	SkipPublish bool                `json:"skipPublish,omitempty"`
+}
+
+// RAPStackResult lists the objects of a generated RAP stack.
+type RAPStackResult struct {
+	Success    bool              `json:"success"`
+	Entity     string            `json:"entity"`
+	Objects    []RAPObject       `json:"objects"`
+	Activation *ActivationResult `json:"activation,omitempty"`
+	Publish    *PublishResult    `json:"publish,omitempty"`
+	ServiceURL string            `json:"serviceUrl,omitempty"` // OData V4 service root, once published
+	Message    string            `json:"message,omitempty"`
+}
+
+// RAPObject is an object created by GenerateRAPStack.
+type RAPObject struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	URI    string `json:"uri"`
+	Source string `json:"source,omitempty"`
+}
+
+// rapField is a table field and the CDS element it is exposed as.
+type rapField struct {
+	name  string // table field, lowercase
+	alias string // CDS element, CamelCase
+	typ   string // DDL type, e.g. abap.char(40) or sysuuid_x16
+	key   bool
+}
+
+// rapAdminFields are the administrative fields a draft-enabled business object
+// needs, with the data elements used when the table is generated and the
+// annotation that marks them in the interface view.
+var rapAdminFields = []struct {
+	name, dataElement, annotation string
+}{
+	{"created_by", "abp_creation_user", "@Semantics.user.createdBy: true"},
+	{"created_at", "abp_creation_tstmpl", "@Semantics.systemDateTime.createdAt: true"},
+	{"local_last_changed_by", "abp_locinst_lastchange_user", "@Semantics.user.localInstanceLastChangedBy: true"},
+	{"local_last_changed_at", "abp_locinst_lastchange_tstmpl", "@Semantics.systemDateTime.localInstanceLastChangedAt: true"},
+	{"last_changed_at", "abp_lastchange_tstmpl", "@Semantics.systemDateTime.lastChangedAt: true"},
+}
+
+// rapAdminAnnotations also covers fields that are not generated but are
+// recognized in existing tables.
+var rapAdminAnnotations = map[string]string{
+	"last_changed_by": "@Semantics.user.lastChangedBy: true",
+}
+
+func init() {
+	for _, f := range rapAdminFields {
+		rapAdminAnnotations[f.name] = f.annotation
+	}
+}
+
+// rapStack holds the names and sources of a RAP stack.
+type rapStack struct {
+	table, draftTable, entity, alias, label string
+	fields                                  []rapField
+
+	interfaceView, projectionView, class, serviceDef, serviceBinding string
+}
+
+// GenerateRAPStack creates a draft-enabled RAP business object with a Fiori
+// UI service on top of a table: interface and projection CDS views, a
+// metadata extension, managed and projection behavior definitions, the
+// behavior pool, a service definition and an OData V4 UI service binding.
+// With Spec, the table is created first and administrative fields missing
+// from the spec are added.
+//
+// Objects are written inactive and activated together, then the binding is
+// activated and published. Failed activation or publishing is reported in the
+// result, not as an error; the created objects are kept for fixing.
+func (c *Client) GenerateRAPStack(ctx context.Context, opts RAPStackOptions) (*RAPStackResult, error) {
+	if err := c.checkSafety(OpWorkflow, "GenerateRAPStack"); err != nil {
+		return nil, err
+	}
+	if opts.Package == "" {
+		opts.Package = "$TMP"
+	}
+	opts.Package = strings.ToUpper(opts.Package)
+	if err := c.checkPackageSafety(opts.Package); err != nil {
+		return nil, err
+	}
+	if err := c.checkTransportableEdit(opts.Transport, "GenerateRAPStack"); err != nil {
+		return nil, err
+	}
+
+	var fields []rapField
+	if opts.Spec != nil {
+		spec := *opts.Spec
+		spec.Name = strings.ToUpper(spec.Name)
+		if spec.Name == "" {
+			return nil, fmt.Errorf("spec.name is required")
+		}
+		if exists, err := c.ObjectExists(ctx, tableURL(spec.Name)); err != nil {
+			return nil, err
+		} else if exists {
+			return nil, fmt.Errorf("table %s already exists; pass it as table instead of spec", spec.Name)
+		}
+		spec.Fields = withRAPAdminFields(spec.Fields)
+		if spec.Package == "" {
+			spec.Package = opts.Package
+		}
+		if spec.Transport == "" {
+			spec.Transport = opts.Transport
+		}
+		if spec.Description == "" {
+			spec.Description = spec.Name
+		}
+		opts.Spec, opts.Table = &spec, spec.Name
+		if spec.Source != "" {
+			fields = parseRAPFields(spec.Source)
+		} else {
+			for _, f := range spec.Fields {
+				fields = append(fields, rapField{name: strings.ToLower(f.Name), typ: mapFieldType(f), key: f.IsKey})
+			}
+		}
+	} else {
+		if opts.Table == "" {
+			return nil, fmt.Errorf("table or spec is required")
+		}
+		opts.Table = strings.ToUpper(opts.Table)
+		source, err := c.GetTable(ctx, opts.Table)
+		if err != nil {
+			return nil, fmt.Errorf("reading table %s: %w", opts.Table, err)
+		}
+		fields = parseRAPFields(source)
+	}
+
+	stack, err := newRAPStack(opts, fields)
+	if err != nil {
+		return nil, err
+	}
+	result := &RAPStackResult{Entity: stack.entity}
+
+	if opts.Spec != nil {
+		if err := c.CreateTable(ctx, *opts.Spec); err != nil {
+			return result, fmt.Errorf("creating table %s: %w", opts.Table, err)
+		}
+		result.Objects = append(result.Objects, RAPObject{Type: "TABL", Name: opts.Table, URI: tableURL(opts.Table)})
+	}
+	if err := c.CreateTable(ctx, CreateTableOptions{
+		Name:        stack.draftTable,
+		Description: "Draft table for " + stack.interfaceView,
+		Package:     opts.Package,
+		Transport:   opts.Transport,
+		Source:      stack.draftTableSource(),
+	}); err != nil {
+		return result, fmt.Errorf("creating draft table %s: %w", stack.draftTable, err)
+	}
+	result.Objects = append(result.Objects, RAPObject{Type: "TABL", Name: stack.draftTable, URI: tableURL(stack.draftTable)})
+
+	// The views, behavior and service depend on each other and are activated together
+	objects := []struct {
+		typ         CreatableObjectType
+		name, descr string
+		source      string
+		includes    map[ClassIncludeType]string
+	}{
+		{ObjectTypeDDLS, stack.interfaceView, stack.label, stack.interfaceViewSource(), nil},
+		{ObjectTypeDDLS, stack.projectionView, stack.label, stack.projectionViewSource(), nil},
+		{ObjectTypeDDLX, stack.projectionView, stack.label, stack.metadataExtensionSource(), nil},
+		{ObjectTypeBDEF, stack.interfaceView, stack.label, stack.behaviorSource(), nil},
+		{ObjectTypeBDEF, stack.projectionView, stack.label, stack.projectionBehaviorSource(), nil},
+		{ObjectTypeClass, stack.class, "Behavior implementation for " + stack.interfaceView, stack.classSource(),
+			map[ClassIncludeType]string{ClassIncludeImplementations: stack.handlerSource()}},
+		{ObjectTypeSRVD, stack.serviceDef, stack.label, stack.serviceDefinitionSource(), nil},
+	}
+	var activate []ActivationObject
+	for _, obj := range objects {
+		uri, err := c.createWithSource(ctx, CreateObjectOptions{
+			ObjectType:  obj.typ,
+			Name:        obj.name,
+			Description: obj.descr,
+			PackageName: opts.Package,
+			Transport:   opts.Transport,
+		}, obj.source, obj.includes)
+		if err != nil {
+			return result, fmt.Errorf("creating %s %s: %w", obj.typ, obj.name, err)
+		}
+		result.Objects = append(result.Objects, RAPObject{Type: string(obj.typ), Name: obj.name, URI: uri, Source: obj.source})
+		activate = append(activate, ActivationObject{URI: uri, Name: obj.name})
+	}
+
+	activation, err := c.ActivateObjects(ctx, activate)
+	if err != nil {
+		return result, err
+	}
+	result.Activation = activation
+	if !activation.Success {
+		result.Message = "Objects created but activation failed; fix the sources and activate them"
+		return result, nil
+	}
+
+	if err := c.CreateObject(ctx, CreateObjectOptions{
+		ObjectType:        ObjectTypeSRVB,
+		Name:              stack.serviceBinding,
+		Description:       stack.label,
+		PackageName:       opts.Package,
+		Transport:         opts.Transport,
+		ServiceDefinition: stack.serviceDef,
+		BindingVersion:    "V4",
+		BindingCategory:   "1", // UI
+	}); err != nil {
+		return result, fmt.Errorf("creating service binding %s: %w", stack.serviceBinding, err)
+	}
+	bindingURL := GetObjectURL(ObjectTypeSRVB, stack.serviceBinding, "")
+	result.Objects = append(result.Objects, RAPObject{Type: string(ObjectTypeSRVB), Name: stack.serviceBinding, URI: bindingURL})
+	if activation, err = c.Activate(ctx, bindingURL, stack.serviceBinding); err != nil {
+		return result, err
+	}
+	if !activation.Success {
+		result.Activation = activation
+		result.Message = "Service binding created but activation failed"
+		return result, nil
+	}
+
+	if opts.SkipPublish {
+		result.Success = true
+		result.Message = "RAP stack generated; service binding not published"
+		return result, nil
+	}
+	publish, err := c.PublishServiceBindingV4(ctx, stack.serviceBinding, "0001")
+	if err != nil {
+		return result, err
+	}
+	result.Publish = publish
+	if strings.EqualFold(publish.Severity, "ERROR") {
+		result.Message = "RAP stack generated but publishing failed: " + publish.ShortText
+		return result, nil
+	}
+	result.Success = true
+	result.ServiceURL = ODataV4ServiceURL(stack.serviceBinding, stack.serviceDef, "0001")
+	result.Message = "RAP stack generated and published"
+	return result, nil
+}
+
+// createWithSource creates an object and writes its source and class
+// includes without activating it. It returns the object URL.
+func (c *Client) createWithSource(ctx context.Context, opts CreateObjectOptions, source string, includes map[ClassIncludeType]string) (string, error) {
+	if err := c.CreateObject(ctx, opts); err != nil {
+		return "", err
+	}
+	objectURL := GetObjectURL(opts.ObjectType, opts.Name, "")
+	lock, err := c.LockObject(ctx, objectURL, "MODIFY")
+	if err != nil {
+		return "", fmt.Errorf("locking: %w", err)
+	}
+	defer c.UnlockObject(ctx, objectURL, lock.LockHandle)
+
+	if err := c.UpdateSource(ctx, objectURL+"/source/main", source, lock.LockHandle, opts.Transport); err != nil {
+		return "", err
+	}
+	for include, src := range includes {
+		if err := c.UpdateClassInclude(ctx, opts.Name, include, src, lock.LockHandle, opts.Transport); err != nil {
+			return "", err
+		}
+	}
+	return objectURL, nil
+}
+
+func tableURL(name string) string {
+	return "/sap/bc/adt/ddic/tables/" + url.PathEscape(strings.ToLower(name))
+}
+
+// withRAPAdminFields appends the administrative fields missing from fields.
+func withRAPAdminFields(fields []TableField) []TableField {
+	have := make(map[string]bool)
+	for _, f := range fields {
+		have[strings.ToLower(f.Name)] = true
+	}
+	for _, a := range rapAdminFields {
+		if !have[a.name] {
+			fields = append(fields, TableField{Name: a.name, Type: a.dataElement})
+		}
+	}
+	return fields
+}
+
+var rapFieldPattern = regexp.MustCompile(`(?m)^\s*(key\s+)?([\w/]+)\s*:\s*([^;]+);`)
+
+// parseRAPFields returns the fields of a table's DDL source, without the client.
+func parseRAPFields(source string) []rapField {
+	var fields []rapField
+	for _, m := range rapFieldPattern.FindAllStringSubmatch(source, -1) {
+		typ := strings.ToLower(strings.Fields(m[3])[0])
+		if typ == "abap.clnt" || typ == "mandt" {
+			continue
+		}
+		fields = append(fields, rapField{name: strings.ToLower(m[2]), typ: typ, key: m[1] != ""})
+	}
+	return fields
+}
+
+// newRAPStack derives the object names of a stack and validates the fields.
+func newRAPStack(opts RAPStackOptions, fields []rapField) (*rapStack, error) {
+	st := &rapStack{table: opts.Table, draftTable: strings.ToUpper(opts.DraftTable), entity: strings.ToUpper(opts.Name)}
+	if st.entity == "" {
+		st.entity = strings.TrimPrefix(st.table[1:], "_")
+		if st.table[0] != 'Z' && st.table[0] != 'Y' {
+			st.entity = st.table
+		}
+	}
+	if st.draftTable == "" {
+		st.draftTable = st.table + "_D"
+	}
+	st.alias = camelCase(st.entity)
+	st.label = opts.Description
+	if st.label == "" {
+		st.label = st.alias
+	}
+	st.interfaceView = "ZR_" + st.entity
+	st.projectionView = "ZC_" + st.entity
+	st.class = "ZBP_R_" + st.entity
+	st.serviceDef = "ZUI_" + st.entity
+	st.serviceBinding = "ZUI_" + st.entity + "_O4"
+
+	switch {
+	case strings.Contains(st.entity, "/"):
+		return nil, fmt.Errorf("namespaced tables need an explicit name without namespace")
+	case len(st.class) > 30:
+		return nil, fmt.Errorf("name %s is too long for class %s (max 30 characters); pass a shorter name", st.entity, st.class)
+	case len(st.serviceBinding) > 26:
+		return nil, fmt.Errorf("name %s is too long for service binding %s (max 26 characters); pass a shorter name", st.entity, st.serviceBinding)
+	case len(st.draftTable) > 16:
+		return nil, fmt.Errorf("draft table name %s is longer than 16 characters; pass draftTable", st.draftTable)
+	}
+
+	have := make(map[string]bool)
+	keys := 0
+	for i := range fields {
+		fields[i].alias = camelCase(fields[i].name)
+		have[fields[i].name] = true
+		if fields[i].key {
+			keys++
+		}
+	}
+	if keys == 0 {
+		return nil, fmt.Errorf("table %s has no key field besides the client", st.table)
+	}
+	var missing []string
+	for _, name := range []string{"local_last_changed_at", "last_changed_at"} {
+		if !have[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("table %s lacks %s: draft handling needs them as etag master and total etag", st.table, strings.Join(missing, " and "))
+	}
+	st.fields = fields
+	return st, nil
+}
+
+// camelCase turns a snake_case name into an element alias, e.g. travel_uuid
+// into TravelUUID.
+func camelCase(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(strings.ToLower(name), "_") {
+		switch part {
+		case "":
+		case "id", "uuid":
+			sb.WriteString(strings.ToUpper(part))
+		default:
+			sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return sb.String()
+}
+
+// managedKey returns the key element numbered by the framework, if the
+// table has a single UUID key.
+func (st *rapStack) managedKey() string {
+	var keys []rapField
+	for _, f := range st.fields {
+		if f.key {
+			keys = append(keys, f)
+		}
+	}
+	if len(keys) == 1 && (keys[0].typ == "sysuuid_x16" || keys[0].typ == "abap.raw(16)") {
+		return keys[0].alias
+	}
+	return ""
+}
+
+func (st *rapStack) draftTableSource() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "@EndUserText.label : 'Draft table for %s'\n", st.interfaceView)
+	sb.WriteString("@AbapCatalog.enhancement.category : #EXTENSIBLE_ANY\n")
+	sb.WriteString("@AbapCatalog.tableCategory : #TRANSPARENT\n")
+	sb.WriteString("@AbapCatalog.deliveryClass : #A\n")
+	sb.WriteString("@AbapCatalog.dataMaintenance : #RESTRICTED\n")
+	fmt.Fprintf(&sb, "define table %s {\n\n", strings.ToLower(st.draftTable))
+	sb.WriteString("  key mandt : mandt not null;\n")
+	for _, f := range st.fields {
+		// Draft fields are named after the CDS elements
+		if f.key {
+			fmt.Fprintf(&sb, "  key %s : %s not null;\n", strings.ToLower(f.alias), f.typ)
+		} else {
+			fmt.Fprintf(&sb, "  %s : %s;\n", strings.ToLower(f.alias), f.typ)
+		}
+	}
+	sb.WriteString("  \"%admin\" : include sych_bdl_draft_admin_inc;\n\n}\n")
+	return sb.String()
+}
+
+func (st *rapStack) interfaceViewSource() string {
+	var sb strings.Builder
+	sb.WriteString("@AccessControl.authorizationCheck: #NOT_REQUIRED\n")
+	fmt.Fprintf(&sb, "@EndUserText.label: '%s'\n", escapeQuote(st.label))
+	fmt.Fprintf(&sb, "define root view entity %s\n  as select from %s\n{\n", st.interfaceView, strings.ToLower(st.table))
+	for i, f := range st.fields {
+		if a, ok := rapAdminAnnotations[f.name]; ok {
+			fmt.Fprintf(&sb, "  %s\n", a)
+		}
+		key := ""
+		if f.key {
+			key = "key "
+		}
+		fmt.Fprintf(&sb, "  %s%s as %s%s\n", key, f.name, f.alias, listSep(i, len(st.fields)))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func (st *rapStack) projectionViewSource() string {
+	var sb strings.Builder
+	sb.WriteString("@AccessControl.authorizationCheck: #NOT_REQUIRED\n")
+	fmt.Fprintf(&sb, "@EndUserText.label: '%s'\n", escapeQuote(st.label))
+	sb.WriteString("@Metadata.allowExtensions: true\n")
+	fmt.Fprintf(&sb, "define root view entity %s\n  provider contract transactional_query\n  as projection on %s\n{\n", st.projectionView, st.interfaceView)
+	for i, f := range st.fields {
+		key := ""
+		if f.key {
+			key = "key "
+		}
+		fmt.Fprintf(&sb, "  %s%s%s\n", key, f.alias, listSep(i, len(st.fields)))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func (st *rapStack) metadataExtensionSource() string {
+	var sb strings.Builder
+	sb.WriteString("@Metadata.layer: #CUSTOMER\n")
+	fmt.Fprintf(&sb, "@UI.headerInfo: { typeName: '%s', typeNamePlural: '%ss' }\n", st.alias, st.alias)
+	fmt.Fprintf(&sb, "annotate view %s with\n{\n", st.projectionView)
+	sb.WriteString("  @UI.facet: [ { id: 'General', purpose: #STANDARD, type: #IDENTIFICATION_REFERENCE, label: 'General', position: 10 } ]\n")
+	position := 0
+	for _, f := range st.fields {
+		_, admin := rapAdminAnnotations[f.name]
+		if admin || f.alias == st.managedKey() {
+			sb.WriteString("  @UI.hidden: true\n")
+		} else {
+			position += 10
+			fmt.Fprintf(&sb, "  @UI.lineItem: [ { position: %d } ]\n", position)
+			fmt.Fprintf(&sb, "  @UI.identification: [ { position: %d } ]\n", position)
+		}
+		fmt.Fprintf(&sb, "  %s;\n", f.alias)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func (st *rapStack) behaviorSource() string {
+	var readonly, keys []string
+	for _, f := range st.fields {
+		if _, admin := rapAdminAnnotations[f.name]; admin {
+			readonly = append(readonly, f.alias)
+		} else if f.key {
+			keys = append(keys, f.alias)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "managed implementation in class %s unique;\nstrict ( 2 );\nwith draft;\n\n", strings.ToLower(st.class))
+	fmt.Fprintf(&sb, "define behavior for %s alias %s\n", st.interfaceView, st.alias)
+	fmt.Fprintf(&sb, "persistent table %s\n", strings.ToLower(st.table))
+	fmt.Fprintf(&sb, "draft table %s\n", strings.ToLower(st.draftTable))
+	sb.WriteString("etag master LocalLastChangedAt\n")
+	sb.WriteString("lock master total etag LastChangedAt\n")
+	sb.WriteString("authorization master ( global )\n{\n")
+	fmt.Fprintf(&sb, "  field ( readonly ) %s;\n", strings.Join(readonly, ", "))
+	if key := st.managedKey(); key != "" {
+		fmt.Fprintf(&sb, "  field ( numbering : managed, readonly ) %s;\n", key)
+	} else {
+		fmt.Fprintf(&sb, "  field ( mandatory : create, readonly : update ) %s;\n", strings.Join(keys, ", "))
+	}
+	sb.WriteString("\n  create;\n  update;\n  delete;\n\n")
+	sb.WriteString("  draft action Activate optimized;\n  draft action Discard;\n  draft action Edit;\n  draft action Resume;\n  draft determine action Prepare;\n\n")
+	fmt.Fprintf(&sb, "  mapping for %s corresponding\n  {\n", strings.ToLower(st.table))
+	for _, f := range st.fields {
+		fmt.Fprintf(&sb, "    %s = %s;\n", f.alias, f.name)
+	}
+	sb.WriteString("  }\n}\n")
+	return sb.String()
+}
+
+func (st *rapStack) projectionBehaviorSource() string {
+	return fmt.Sprintf(`projection;
+strict ( 2 );
+use draft;
+
+define behavior for %s alias %s
+{
+  use create;
+  use update;
+  use delete;
+
+  use action Activate;
+  use action Discard;
+  use action Edit;
+  use action Resume;
+  use action Prepare;
+}
+`, st.projectionView, st.alias)
+}
+
+func (st *rapStack) classSource() string {
+	class := strings.ToLower(st.class)
+	return fmt.Sprintf(`CLASS %s DEFINITION
+  PUBLIC
+  ABSTRACT
+  FINAL
+  FOR BEHAVIOR OF %s.
+ENDCLASS.
+
+CLASS %s IMPLEMENTATION.
+ENDCLASS.
+`, class, strings.ToLower(st.interfaceView), class)
+}
+
+// handlerSource returns the local handler class of the behavior pool.
+func (st *rapStack) handlerSource() string {
+	handler := "lhc_" + strings.ToLower(st.alias)
+	if len(handler) > 30 {
+		handler = handler[:30]
+	}
+	return fmt.Sprintf(`CLASS %s DEFINITION INHERITING FROM cl_abap_behavior_handler.
+  PRIVATE SECTION.
+    METHODS get_global_authorizations FOR GLOBAL AUTHORIZATION
+      IMPORTING REQUEST requested_authorizations FOR %s RESULT result.
+ENDCLASS.
+
+CLASS %s IMPLEMENTATION.
+  METHOD get_global_authorizations.
+  ENDMETHOD.
+ENDCLASS.
+`, handler, st.alias, handler)
+}
+
+func (st *rapStack) serviceDefinitionSource() string {
+	return fmt.Sprintf("@EndUserText.label: '%s'\ndefine service %s {\n  expose %s as %s;\n}\n",
+		escapeQuote(st.label), st.serviceDef, st.projectionView, st.alias)
+}
+
+// listSep returns the separator after element i of n in a CDS element list.
+func listSep(i, n int) string {
+	if i < n-1 {
+		return ","
+	}
+	return ""
+}
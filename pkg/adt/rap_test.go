@@ -0,0 +1,164 @@
+package adt
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
+)
+
+func TestGenerateRAPStackFromSpec(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	client := NewClient(sap.URL, "developer", "secret")
+	ctx := context.Background()
+
+	res, err := client.GenerateRAPStack(ctx, RAPStackOptions{
+		Spec: &CreateTableOptions{Name: "zrap_travel", Fields: []TableField{
+			{Name: "travel_uuid", Type: "SYSUUID_X16", IsKey: true},
+			{Name: "description", Type: "CHAR", Length: 40},
+			{Name: "created_by", Type: "abp_creation_user"},
+		}},
+		Description: "Travel",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Entity != "RAP_TRAVEL" || len(res.Objects) != 10 {
+		t.Fatalf("result = %+v", res)
+	}
+	if got := sap.Published("ZUI_RAP_TRAVEL_O4"); got != "odatav4" {
+		t.Errorf("binding published as %q, want odatav4", got)
+	}
+	if res.ServiceURL != "/sap/opu/odata4/sap/zui_rap_travel_o4/srvd/sap/zui_rap_travel/0001/" {
+		t.Errorf("ServiceURL = %s", res.ServiceURL)
+	}
+	for _, o := range res.Objects {
+		obj, ok := sap.Object(o.URI)
+		if !ok || obj.Inactive {
+			t.Errorf("%s %s missing or inactive: %+v", o.Type, o.Name, obj)
+		}
+	}
+	// Tables, the batch of dependent objects and the binding
+	if n := sap.CountRequests(http.MethodPost, "/sap/bc/adt/activation"); n != 4 {
+		t.Errorf("%d activation requests, want 4", n)
+	}
+
+	table, _ := sap.Object(tableURL("ZRAP_TRAVEL"))
+	for _, want := range []string{"key travel_uuid : sysuuid_x16 not null;", "created_by : abp_creation_user;", "last_changed_at : abp_lastchange_tstmpl;"} {
+		if !strings.Contains(table.Source, want) {
+			t.Errorf("table lacks %q:\n%s", want, table.Source)
+		}
+	}
+	draft, _ := sap.Object(tableURL("ZRAP_TRAVEL_D"))
+	if !strings.Contains(draft.Source, "key traveluuid : sysuuid_x16 not null;") || !strings.Contains(draft.Source, `"%admin" : include sych_bdl_draft_admin_inc;`) {
+		t.Errorf("draft table:\n%s", draft.Source)
+	}
+	view, _ := sap.Object(GetObjectURL(ObjectTypeDDLS, "ZR_RAP_TRAVEL", ""))
+	if !strings.Contains(view.Source, "@Semantics.systemDateTime.lastChangedAt: true\n  last_changed_at as LastChangedAt\n") {
+		t.Errorf("interface view:\n%s", view.Source)
+	}
+	bdef, _ := sap.Object(GetObjectURL(ObjectTypeBDEF, "ZR_RAP_TRAVEL", ""))
+	for _, want := range []string{"managed implementation in class zbp_r_rap_travel unique;", "draft table zrap_travel_d", "field ( numbering : managed, readonly ) TravelUUID;", "    Description = description;"} {
+		if !strings.Contains(bdef.Source, want) {
+			t.Errorf("behavior definition lacks %q:\n%s", want, bdef.Source)
+		}
+	}
+	class, _ := sap.Object(GetObjectURL(ObjectTypeClass, "ZBP_R_RAP_TRAVEL", ""))
+	if !strings.Contains(class.Source, "FOR BEHAVIOR OF zr_rap_travel.") || !strings.Contains(class.Includes["implementations"], "FOR RapTravel RESULT result.") {
+		t.Errorf("behavior pool: %+v", class)
+	}
+	if _, ok := sap.Object(GetObjectURL(ObjectTypeDDLX, "ZC_RAP_TRAVEL", "")); !ok {
+		t.Error("metadata extension not created")
+	}
+}
+
+func TestGenerateRAPStackExistingTable(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	sap.AddObject(adttest.Object{Type: "TABL/DT", Name: "ZBOOKING", Source: `@EndUserText.label : 'Bookings'
+define table zbooking {
+  key client : abap.clnt not null;
+  key booking_id : abap.numc(8) not null;
+  carrier : s_carr_id
+    with foreign key scarr
+      where carrid = zbooking.carrier;
+}
+`})
+	client := NewClient(sap.URL, "developer", "secret")
+	ctx := context.Background()
+
+	_, err := client.GenerateRAPStack(ctx, RAPStackOptions{Table: "zbooking"})
+	if err == nil || !strings.Contains(err.Error(), "lacks local_last_changed_at and last_changed_at") {
+		t.Fatalf("err = %v", err)
+	}
+	if n := sap.CountRequests(http.MethodPost, "/sap/bc/adt/ddic"); n != 0 {
+		t.Errorf("%d objects created for an unusable table", n)
+	}
+
+	fields := parseRAPFields(`define table zbooking {
+  key client : abap.clnt not null;
+  key booking_id : abap.numc(8) not null;
+  carrier : s_carr_id
+    with foreign key scarr
+      where carrid = zbooking.carrier;
+  "%admin" : include sych_bdl_draft_admin_inc;
+}`)
+	if len(fields) != 2 || fields[0].typ != "abap.numc(8)" || !fields[0].key || fields[1].name != "carrier" || fields[1].typ != "s_carr_id" {
+		t.Errorf("parseRAPFields = %+v", fields)
+	}
+}
+
+func TestGenerateRAPStackActivationFailure(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	sap.AddObject(adttest.Object{Type: "TABL/DT", Name: "ZORDER", Source: `define table zorder {
+  key client : abap.clnt not null;
+  key order_id : abap.numc(10) not null;
+  local_last_changed_at : abp_locinst_lastchange_tstmpl;
+  last_changed_at : abp_lastchange_tstmpl;
+}`})
+	sap.Check = func(uri, source string) []adttest.Message {
+		if strings.Contains(uri, "behaviordefinitions") {
+			return []adttest.Message{{Line: 1, Severity: "E", Text: "Behavior pool is not available"}}
+		}
+		return nil
+	}
+	client := NewClient(sap.URL, "developer", "secret")
+
+	res, err := client.GenerateRAPStack(context.Background(), RAPStackOptions{Table: "ZORDER", Name: "sales_order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.Activation == nil || res.Activation.Success || sap.Published("ZUI_SALES_ORDER_O4") != "" {
+		t.Fatalf("result = %+v", res)
+	}
+	if obj, ok := sap.Object(GetObjectURL(ObjectTypeDDLS, "ZR_SALES_ORDER", "")); !ok || !obj.Inactive {
+		t.Errorf("interface view should be kept inactive: %+v", obj)
+	}
+	bdef, _ := sap.Object(GetObjectURL(ObjectTypeBDEF, "ZR_SALES_ORDER", ""))
+	if !strings.Contains(bdef.Source, "field ( mandatory : create, readonly : update ) OrderID;") {
+		t.Errorf("behavior definition:\n%s", bdef.Source)
+	}
+}
+
+func TestRAPStackNames(t *testing.T) {
+	for in, want := range map[string]string{"travel_uuid": "TravelUUID", "order_id": "OrderID", "last_changed_at": "LastChangedAt", "NAME": "Name"} {
+		if got := camelCase(in); got != want {
+			t.Errorf("camelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+	fields := []rapField{{name: "id", key: true}, {name: "local_last_changed_at"}, {name: "last_changed_at"}}
+	if _, err := newRAPStack(RAPStackOptions{Table: "ZVERY_LONG_TABLE"}, fields); err == nil || !strings.Contains(err.Error(), "draftTable") {
+		t.Errorf("long draft table name: %v", err)
+	}
+	if _, err := newRAPStack(RAPStackOptions{Table: "ZT", Name: "a_very_long_entity_name"}, fields); err == nil || !strings.Contains(err.Error(), "max 26") {
+		t.Errorf("long service binding name: %v", err)
+	}
+	st, err := newRAPStack(RAPStackOptions{Table: "YTRAVEL", DraftTable: "ytravel_draft"}, fields)
+	if err != nil || st.entity != "TRAVEL" || st.draftTable != "YTRAVEL_DRAFT" || st.managedKey() != "" {
+		t.Errorf("newRAPStack = %+v, %v", st, err)
+	}
+}
@@ -20,6 +20,7 @@ type CodeLocation struct {
 type VariableValue struct {
 	Name      string      `json:"name"`
 	Type      string      `json:"type"`
+	MetaType  string      `json:"meta_type,omitempty"` // simple, string, structure, table, objectref, dataref
 	Value     interface{} `json:"value"`
 	IsChanged bool        `json:"is_changed,omitempty"` // Changed since last frame
 }
@@ -221,14 +222,20 @@ func (r *ExecutionRecorder) GetVariablesAtStep(stepNumber int) map[string]Variab
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	if stepNumber < 1 || stepNumber > len(r.recording.Frames) {
+	return r.recording.VariablesAt(stepNumber)
+}
+
+// VariablesAt reconstructs the full variable state at a specific step
+// from the nearest snapshot and the deltas recorded after it.
+func (rec *ExecutionRecording) VariablesAt(stepNumber int) map[string]VariableValue {
+	if stepNumber < 1 || stepNumber > len(rec.Frames) {
 		return nil
 	}
 
-	frame := r.recording.Frames[stepNumber-1]
+	frame := rec.Frames[stepNumber-1]
 
 	// If this is a full snapshot, return it directly
-	if frame.Variables != nil && len(frame.Variables) > 0 {
+	if len(frame.Variables) > 0 {
 		return frame.Variables
 	}
 
@@ -240,14 +247,14 @@ func (r *ExecutionRecorder) GetVariablesAtStep(stepNumber int) map[string]Variab
 
 	// Start with base frame variables
 	result := make(map[string]VariableValue)
-	baseFrame := r.recording.Frames[baseStep-1]
+	baseFrame := rec.Frames[baseStep-1]
 	for k, v := range baseFrame.Variables {
 		result[k] = v
 	}
 
 	// Apply deltas from base+1 to target step
 	for i := baseStep; i < stepNumber; i++ {
-		f := r.recording.Frames[i]
+		f := rec.Frames[i]
 		for k, v := range f.VariableDelta {
 			result[k] = v
 		}
@@ -261,6 +268,54 @@ func (r *ExecutionRecorder) GetVariablesAtStep(stepNumber int) map[string]Variab
 	return result
 }
 
+// VariableChange is a step at which a variable took a new value.
+type VariableChange struct {
+	StepNumber int          `json:"step_number"`
+	Location   CodeLocation `json:"location"`
+	OldValue   interface{}  `json:"old_value,omitempty"`
+	NewValue   interface{}  `json:"new_value"`
+}
+
+// VariableHistory returns every step at which variableName first appeared
+// or its value differed from the previous step.
+func (rec *ExecutionRecording) VariableHistory(variableName string) []VariableChange {
+	var changes []VariableChange
+	var last string
+	seen := false
+
+	for i, frame := range rec.Frames {
+		vars := frame.Variables
+		if len(vars) == 0 {
+			vars = frame.VariableDelta
+		}
+		val, exists := vars[variableName]
+		if !exists {
+			continue
+		}
+
+		valJSON, _ := json.Marshal(val.Value)
+		if seen && string(valJSON) == last {
+			continue
+		}
+
+		change := VariableChange{
+			StepNumber: i + 1,
+			Location:   frame.Location,
+			NewValue:   val.Value,
+		}
+		if seen {
+			var old interface{}
+			_ = json.Unmarshal([]byte(last), &old)
+			change.OldValue = old
+		}
+		changes = append(changes, change)
+		last = string(valJSON)
+		seen = true
+	}
+
+	return changes
+}
+
 // FindWhenChanged finds the first step where a variable changed to a specific value.
 func (r *ExecutionRecorder) FindWhenChanged(variableName string, targetValue interface{}) int {
 	r.mu.RLock()
@@ -318,6 +373,44 @@ func (r *ExecutionRecorder) GetRecording() *ExecutionRecording {
 	return r.recording
 }
 
+// Snapshot returns a copy of the recording that stays consistent while the
+// recorder keeps recording. Frames, variable maps, DB operations, RFC calls,
+// tags and checkpoints are copied; recorded values are shared, as the
+// recorder never modifies them.
+func (r *ExecutionRecorder) Snapshot() *ExecutionRecording {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rec := *r.recording
+	rec.Frames = make([]ExecutionFrame, len(r.recording.Frames))
+	for i, frame := range r.recording.Frames {
+		frame.Variables = copyVariables(frame.Variables)
+		frame.VariableDelta = copyVariables(frame.VariableDelta)
+		frame.DBOps = append([]DBOperation(nil), frame.DBOps...)
+		frame.RFCCalls = append([]RFCCall(nil), frame.RFCCalls...)
+		rec.Frames[i] = frame
+	}
+	rec.Tags = append([]string(nil), r.recording.Tags...)
+	if r.recording.Checkpoints != nil {
+		rec.Checkpoints = make(map[string]int, len(r.recording.Checkpoints))
+		for name, step := range r.recording.Checkpoints {
+			rec.Checkpoints[name] = step
+		}
+	}
+	return &rec
+}
+
+func copyVariables(vars map[string]VariableValue) map[string]VariableValue {
+	if vars == nil {
+		return nil
+	}
+	out := make(map[string]VariableValue, len(vars))
+	for name, v := range vars {
+		out[name] = v
+	}
+	return out
+}
+
 // Complete marks the recording as finished.
 func (r *ExecutionRecorder) Complete() {
 	r.mu.Lock()
@@ -173,6 +173,65 @@ func TestFindWhenChanged(t *testing.T) {
 	}
 }
 
+func TestVariableHistory(t *testing.T) {
+	recorder := NewExecutionRecorder("test-session", "ZTEST")
+	recorder.snapshotEvery = 2
+
+	statuses := []string{"INIT", "INIT", "PROCESSING", "PROCESSING", "DONE"}
+	for i, status := range statuses {
+		vars := map[string]VariableValue{
+			"LV_STATUS": {Name: "LV_STATUS", Type: "STRING", Value: status},
+		}
+		recorder.RecordFrame(CodeLocation{Program: "ZTEST", Line: i + 1}, "step_over", vars)
+	}
+
+	changes := recorder.GetRecording().VariableHistory("LV_STATUS")
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d: %+v", len(changes), changes)
+	}
+	if changes[0].StepNumber != 1 || changes[0].OldValue != nil || changes[0].NewValue != "INIT" {
+		t.Errorf("unexpected first change: %+v", changes[0])
+	}
+	if changes[1].StepNumber != 3 || changes[1].OldValue != "INIT" || changes[1].NewValue != "PROCESSING" {
+		t.Errorf("unexpected second change: %+v", changes[1])
+	}
+	if changes[2].StepNumber != 5 || changes[2].Location.Line != 5 || changes[2].NewValue != "DONE" {
+		t.Errorf("unexpected third change: %+v", changes[2])
+	}
+
+	if got := recorder.GetRecording().VariableHistory("LV_MISSING"); len(got) != 0 {
+		t.Errorf("expected no changes for unknown variable, got %d", len(got))
+	}
+}
+
+func TestVariablesAtLoadedRecording(t *testing.T) {
+	recorder := NewExecutionRecorder("test-session", "ZTEST")
+	recorder.snapshotEvery = 3
+	for i := 1; i <= 5; i++ {
+		vars := map[string]VariableValue{
+			"LV_VALUE": {Name: "LV_VALUE", Type: "I", Value: i},
+		}
+		recorder.RecordFrame(CodeLocation{Program: "ZTEST", Line: i}, "step_over", vars)
+	}
+
+	data, err := recorder.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	loaded, err := FromJSON(data)
+	if err != nil {
+		t.Fatalf("FromJSON failed: %v", err)
+	}
+
+	// Values round-trip through JSON as float64
+	if v := loaded.VariablesAt(5)["LV_VALUE"].Value; v != float64(5) {
+		t.Errorf("expected LV_VALUE=5 at step 5, got %v", v)
+	}
+	if loaded.VariablesAt(0) != nil || loaded.VariablesAt(6) != nil {
+		t.Error("expected nil for out-of-range steps")
+	}
+}
+
 func TestFindChanges(t *testing.T) {
 	recorder := NewExecutionRecorder("test-session", "ZTEST")
 
@@ -398,3 +457,21 @@ func TestVariablesEqual(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutionRecorderSnapshot(t *testing.T) {
+	recorder := NewExecutionRecorder("test-session", "ZTEST_PROGRAM")
+	recorder.RecordFrame(CodeLocation{Program: "ZTEST_PROGRAM", Line: 1}, "entry", map[string]VariableValue{"LV_X": {Name: "LV_X", Value: 1}})
+	recorder.AddCheckpoint("start")
+
+	snap := recorder.Snapshot()
+	recorder.RecordFrame(CodeLocation{Program: "ZTEST_PROGRAM", Line: 2}, "step_over", map[string]VariableValue{"LV_X": {Name: "LV_X", Value: 2}})
+	recorder.AddCheckpoint("after")
+	snap.Frames[0].Variables["LV_X"] = VariableValue{Name: "LV_X", Value: 99}
+
+	if len(snap.Frames) != 1 || snap.TotalSteps != 1 || len(snap.Checkpoints) != 1 {
+		t.Errorf("snapshot changed with the recorder: %d frames, %d steps, %v", len(snap.Frames), snap.TotalSteps, snap.Checkpoints)
+	}
+	if v := recorder.GetFrame(1).Variables["LV_X"].Value; v != 1 {
+		t.Errorf("changing the snapshot changed the recording: LV_X = %v", v)
+	}
+}
@@ -0,0 +1,173 @@
+package adt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how requests are retried after transient failures:
+// ICM 502/503/504 responses, HTTP 500 after a work process restart,
+// connection resets and timeouts. Only GET and HEAD requests, and POST
+// requests marked Idempotent (syntax check, code completion, data preview,
+// ...), are retried. CSRF and session refreshes are separate and always
+// happen once.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request; 1 disables retries.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles with every retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts.
+	MaxDelay time.Duration
+	// BudgetPerMinute limits retries across all requests of a client, so an
+	// unavailable system is not hammered by every caller at once (0 = unlimited).
+	BudgetPerMinute int
+}
+
+// DefaultRetryPolicy returns the default policy: up to 3 attempts with
+// 500ms, 1s backoff (with jitter) and at most 30 retries per minute.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     3,
+		BaseDelay:       500 * time.Millisecond,
+		MaxDelay:        10 * time.Second,
+		BudgetPerMinute: 30,
+	}
+}
+
+// backoff returns the wait before retry number n (1-based): exponential,
+// capped at MaxDelay, with jitter between half and the full delay.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < n && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(delay-half)+1))
+}
+
+// NetworkError is a failure to reach the SAP system or to read its response,
+// such as a connection reset or a timeout. It is retriable.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string { return e.Err.Error() }
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+// RetryError is returned when a retriable request still failed after all attempts.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (gave up after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// IsTransient returns true for server errors that usually go away on their
+// own: 502/503/504 from the ICM and 500 after a work process restart.
+func (e *APIError) IsTransient() bool {
+	switch e.StatusCode {
+	case 502, 503, 504:
+		return true
+	case 500:
+		msg := strings.ToLower(e.Message)
+		for _, s := range transientServerMessages {
+			if strings.Contains(msg, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// transientServerMessages mark HTTP 500 bodies caused by work process or
+// ICM trouble rather than by the request.
+var transientServerMessages = []string{
+	"work process restarted",
+	"work process was restarted",
+	"wp restarted",
+	"icm_http_service_unavailable",
+	"icm_http_connection_failed",
+	"icm_http_timeout",
+	"service cannot be reached",
+}
+
+// IsRetriableError reports whether err is a transient failure worth
+// retrying: a network error or a transient API error. Cancelled contexts,
+// safety blocks and other API errors are permanent.
+func IsRetriableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.IsTransient()
+	}
+	var netErr *NetworkError
+	return errors.As(err, &netErr)
+}
+
+// isNetworkFailure reports whether err from an HTTP client is a connection
+// failure or timeout (as opposed to e.g. an invalid request or replay miss).
+func isNetworkFailure(err error) bool {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// retryBudget is a token bucket refilled at BudgetPerMinute tokens per minute.
+type retryBudget struct {
+	mu     sync.Mutex
+	perMin int
+	tokens float64
+	last   time.Time
+}
+
+func newRetryBudget(perMinute int) *retryBudget {
+	return &retryBudget{perMin: perMinute, tokens: float64(perMinute), last: time.Now()}
+}
+
+// take consumes one retry token, returning false if the budget is spent.
+func (b *retryBudget) take() bool {
+	if b.perMin <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Minutes() * float64(b.perMin)
+	if b.tokens > float64(b.perMin) {
+		b.tokens = float64(b.perMin)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
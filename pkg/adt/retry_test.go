@@ -0,0 +1,212 @@
+package adt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newRetryTransport(policy RetryPolicy, responses ...*http.Response) (*Transport, *mockHTTPClient) {
+	mock := &mockHTTPClient{responses: responses}
+	cfg := NewConfig("https://sap.example.com", "u", "p", WithRetryPolicy(policy))
+	return NewTransportWithClient(cfg, mock), mock
+}
+
+var fastRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetry_GETOnServiceUnavailable(t *testing.T) {
+	transport, mock := newRetryTransport(fastRetries,
+		newMockResponse(503, "Service cannot be reached", nil),
+		newMockResponse(200, "OK", nil),
+	)
+
+	resp, err := transport.Request(context.Background(), "/sap/bc/adt/programs/programs/ZTEST/source/main", nil)
+	if err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if string(resp.Body) != "OK" || len(mock.requests) != 2 {
+		t.Errorf("body %q after %d requests", resp.Body, len(mock.requests))
+	}
+	if st := transport.Stats(); st.Retries != 1 {
+		t.Errorf("Retries = %d, want 1", st.Retries)
+	}
+}
+
+func TestRetry_WorkProcessRestart(t *testing.T) {
+	transport, mock := newRetryTransport(fastRetries,
+		newMockResponse(500, "Work process restarted; session terminated", nil),
+		newMockResponse(200, "OK", nil),
+	)
+	if _, err := transport.Request(context.Background(), "/sap/bc/adt/test", nil); err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if len(mock.requests) != 2 {
+		t.Errorf("requests = %d, want 2", len(mock.requests))
+	}
+}
+
+func TestRetry_PermanentErrorNotRetried(t *testing.T) {
+	transport, mock := newRetryTransport(fastRetries,
+		newMockResponse(500, "Runtime error CX_SY_ZERODIVIDE", nil),
+		newMockResponse(200, "OK", nil),
+	)
+	_, err := transport.Request(context.Background(), "/sap/bc/adt/test", nil)
+	if err == nil || IsRetriableError(err) || len(mock.requests) != 1 {
+		t.Errorf("err = %v after %d requests, want one permanent failure", err, len(mock.requests))
+	}
+}
+
+func TestRetry_POSTOnlyWhenIdempotent(t *testing.T) {
+	csrf := func() *http.Response { return newMockResponse(200, "", map[string]string{"X-CSRF-Token": "token"}) }
+
+	transport, mock := newRetryTransport(fastRetries, csrf(), newMockResponse(503, "", nil), newMockResponse(200, "OK", nil))
+	_, err := transport.Request(context.Background(), "/sap/bc/adt/activation", &RequestOptions{Method: http.MethodPost})
+	if err == nil || len(mock.requests) != 2 {
+		t.Errorf("modifying POST: err = %v after %d requests, want no retry", err, len(mock.requests))
+	}
+	if !IsRetriableError(err) {
+		t.Errorf("503 should be reported as retriable: %v", err)
+	}
+
+	transport, mock = newRetryTransport(fastRetries, csrf(), newMockResponse(503, "", nil), newMockResponse(200, "OK", nil))
+	_, err = transport.Request(context.Background(), "/sap/bc/adt/checkruns", &RequestOptions{Method: http.MethodPost, Idempotent: true})
+	if err != nil || len(mock.requests) != 3 {
+		t.Errorf("idempotent POST: err = %v after %d requests, want one retry", err, len(mock.requests))
+	}
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	transport, mock := newRetryTransport(fastRetries,
+		newMockResponse(504, "Gateway timeout", nil),
+		newMockResponse(504, "Gateway timeout", nil),
+		newMockResponse(504, "Gateway timeout", nil),
+		newMockResponse(200, "OK", nil),
+	)
+	_, err := transport.Request(context.Background(), "/sap/bc/adt/test", nil)
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 3 {
+		t.Fatalf("err = %v, want RetryError after 3 attempts", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 504 {
+		t.Errorf("RetryError does not wrap the APIError: %v", err)
+	}
+	if len(mock.requests) != 3 {
+		t.Errorf("requests = %d, want 3", len(mock.requests))
+	}
+}
+
+func TestRetry_Budget(t *testing.T) {
+	policy := fastRetries
+	policy.BudgetPerMinute = 1
+	transport, _ := newRetryTransport(policy,
+		newMockResponse(503, "", nil), newMockResponse(200, "OK", nil), // first request: one retry
+		newMockResponse(503, "", nil), newMockResponse(200, "OK", nil), // second request: budget spent
+	)
+	if _, err := transport.Request(context.Background(), "/sap/bc/adt/a", nil); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	_, err := transport.Request(context.Background(), "/sap/bc/adt/b", nil)
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 1 {
+		t.Errorf("err = %v, want RetryError with exhausted budget", err)
+	}
+	if st := transport.Stats(); st.Retries != 1 || st.RetriesDenied != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+// flakyNetClient fails with a connection reset before answering.
+type flakyNetClient struct {
+	failures int
+	calls    int
+}
+
+func (m *flakyNetClient) Do(req *http.Request) (*http.Response, error) {
+	m.calls++
+	if m.calls <= m.failures {
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	}
+	return newMockResponse(200, "OK", nil), nil
+}
+
+func TestRetry_ConnectionReset(t *testing.T) {
+	mock := &flakyNetClient{failures: 2}
+	transport := NewTransportWithClient(NewConfig("https://sap.example.com", "u", "p", WithRetryPolicy(fastRetries)), mock)
+	if _, err := transport.Request(context.Background(), "/sap/bc/adt/test", nil); err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if mock.calls != 3 {
+		t.Errorf("calls = %d, want 3", mock.calls)
+	}
+
+	mock = &flakyNetClient{failures: 1}
+	transport = NewTransportWithClient(NewConfig("https://sap.example.com", "u", "p", WithMaxRetries(0)), mock)
+	_, err := transport.Request(context.Background(), "/sap/bc/adt/test", nil)
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) || !IsRetriableError(err) || mock.calls != 1 {
+		t.Errorf("without retries: err = %v after %d calls", err, mock.calls)
+	}
+}
+
+func TestRetry_ContextCancelledDuringBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Second}
+	transport, mock := newRetryTransport(policy, newMockResponse(503, "", nil), newMockResponse(200, "OK", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := transport.Request(ctx, "/sap/bc/adt/test", nil)
+	if err == nil || time.Since(start) > 500*time.Millisecond || len(mock.requests) != 1 {
+		t.Errorf("err = %v after %v and %d requests, want early failure", err, time.Since(start), len(mock.requests))
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 200 * time.Millisecond, 400 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if d := p.backoff(tt.retry); d < tt.min || d > tt.max {
+				t.Errorf("backoff(%d) = %v, want in [%v, %v]", tt.retry, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestIsRetriableError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&APIError{StatusCode: 503}, true},
+		{&APIError{StatusCode: 502}, true},
+		{&APIError{StatusCode: 500, Message: "ICM_HTTP_TIMEOUT"}, true},
+		{&APIError{StatusCode: 500, Message: "syntax error"}, false},
+		{&APIError{StatusCode: 404}, false},
+		{&NetworkError{Err: syscall.ECONNRESET}, true},
+		{&RetryError{Attempts: 3, Err: &APIError{StatusCode: 503}}, true},
+		{context.Canceled, false},
+		{errors.New("operation blocked by safety configuration"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetriableError(tt.err); got != tt.want {
+			t.Errorf("IsRetriableError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
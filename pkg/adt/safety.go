@@ -30,6 +30,9 @@ type SafetyConfig struct {
 	//   I - Code intelligence (FindDefinition, CodeCompletion, etc.)
 	//   W - Workflow operations (WriteClass, WriteProgram, CreateClassWithTests)
 	//   X - Transport management (requires EnableTransports=true)
+	//   B - Debugger operations (breakpoints, stepping, variable inspection);
+	//       not restricted by AllowedOps, which predates it, so existing
+	//       whitelists keep the debugger. Block it with DisallowedOps.
 	// Example: "RSQ" = only reads, searches, and queries allowed
 	AllowedOps string
 
@@ -117,6 +120,7 @@ const (
 	OpIntelligence OperationType = 'I' // Code intelligence
 	OpWorkflow     OperationType = 'W' // High-level workflows
 	OpTransport    OperationType = 'X' // Transport management (requires explicit opt-in)
+	OpDebug        OperationType = 'B' // Debugger operations
 )
 
 // IsOperationAllowed checks if an operation type is allowed by the safety config
@@ -151,8 +155,8 @@ func (s *SafetyConfig) IsOperationAllowed(op OperationType) bool {
 		return false
 	}
 
-	// Check AllowedOps (whitelist)
-	if s.AllowedOps != "" && !strings.ContainsRune(s.AllowedOps, opChar) {
+	// Check AllowedOps (whitelist); debugger operations are only blocked by DisallowedOps
+	if s.AllowedOps != "" && op != OpDebug && !strings.ContainsRune(s.AllowedOps, opChar) {
 		return false
 	}
 
@@ -101,6 +101,18 @@ func TestSafetyConfig_IsOperationAllowed(t *testing.T) {
 			op:       OpCreate,
 			expected: false,
 		},
+		{
+			name:     "AllowedOps whitelist does not restrict debugger",
+			config:   SafetyConfig{AllowedOps: "RSQ"},
+			op:       OpDebug,
+			expected: true,
+		},
+		{
+			name:     "DisallowedOps blocks debugger",
+			config:   SafetyConfig{AllowedOps: "RSQB", DisallowedOps: "B"},
+			op:       OpDebug,
+			expected: false,
+		},
 		{
 			name:     "DryRun allows all",
 			config:   SafetyConfig{DryRun: true, ReadOnly: true},
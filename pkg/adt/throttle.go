@@ -0,0 +1,301 @@
+package adt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransportStats reports request throttling and session pool usage.
+// Wait times cover the time a request spent waiting for a concurrency slot,
+// the rate limiter or a pooled session before it was sent.
+type TransportStats struct {
+	Requests     int64         `json:"requests"`       // HTTP requests sent, including CSRF fetches and retries
+	InFlight     int64         `json:"in_flight"`      // requests currently being sent
+	PeakInFlight int64         `json:"peak_in_flight"` // highest InFlight seen
+	Throttled    int64         `json:"throttled"`      // requests that had to wait for a slot or the rate limiter
+	TotalWait    time.Duration `json:"total_wait_ns"`
+	MaxWait      time.Duration `json:"max_wait_ns"`
+
+	MaxConcurrent     int     `json:"max_concurrent"`      // 0 = unlimited
+	RequestsPerSecond float64 `json:"requests_per_second"` // 0 = unlimited
+
+	Retries       int64 `json:"retries"`        // retries of transient failures
+	RetriesDenied int64 `json:"retries_denied"` // retries skipped because the retry budget was spent
+
+	SessionPoolSize   int           `json:"session_pool_size"` // 0 = all requests share one session
+	SessionsInUse     int           `json:"sessions_in_use"`
+	SessionWaits      int64         `json:"session_waits"` // lock acquisitions that waited for a free session
+	SessionTotalWait  time.Duration `json:"session_total_wait_ns"`
+	SessionsReclaimed int64         `json:"sessions_reclaimed"` // sessions taken back from expired locks
+}
+
+// AverageWait returns the mean wait of throttled requests.
+func (s TransportStats) AverageWait() time.Duration {
+	if s.Throttled == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Throttled)
+}
+
+// throttle limits the number of requests in flight and their rate.
+type throttle struct {
+	slots    chan struct{} // nil = unlimited concurrency
+	interval time.Duration // minimum spacing between requests; 0 = no rate limit
+
+	rateMu sync.Mutex
+	next   time.Time // earliest start of the next request
+
+	requests, inFlight, peak, throttled atomic.Int64
+	totalWait, maxWait                  atomic.Int64 // nanoseconds
+}
+
+func newThrottle(maxConcurrent int, rps float64) *throttle {
+	t := &throttle{}
+	if maxConcurrent > 0 {
+		t.slots = make(chan struct{}, maxConcurrent)
+	}
+	if rps > 0 {
+		t.interval = time.Duration(float64(time.Second) / rps)
+	}
+	return t
+}
+
+// acquire waits for a concurrency slot and the rate limiter. The returned
+// function releases the slot once the response has been read.
+func (t *throttle) acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err := t.waitRate(ctx); err != nil {
+		if t.slots != nil {
+			<-t.slots
+		}
+		return nil, err
+	}
+
+	if wait := time.Since(start); wait > time.Millisecond {
+		t.throttled.Add(1)
+		t.totalWait.Add(int64(wait))
+		for {
+			max := t.maxWait.Load()
+			if int64(wait) <= max || t.maxWait.CompareAndSwap(max, int64(wait)) {
+				break
+			}
+		}
+	}
+
+	t.requests.Add(1)
+	n := t.inFlight.Add(1)
+	for {
+		peak := t.peak.Load()
+		if n <= peak || t.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	return func() {
+		t.inFlight.Add(-1)
+		if t.slots != nil {
+			<-t.slots
+		}
+	}, nil
+}
+
+// waitRate spaces requests at least interval apart.
+func (t *throttle) waitRate(ctx context.Context) error {
+	if t.interval == 0 {
+		return nil
+	}
+	t.rateMu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	delay := t.next.Sub(now)
+	t.next = t.next.Add(t.interval)
+	t.rateMu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// session is one SAP session: its own cookie jar (via the HTTP client), CSRF
+// token and session ID. SAP ties CSRF tokens and enqueue locks to a session.
+type session struct {
+	client HTTPDoer
+
+	csrfToken string
+	csrfMu    sync.RWMutex
+
+	sessionID string
+	sessionMu sync.RWMutex
+}
+
+// CSRF token accessors with mutex protection
+func (s *session) getCSRFToken() string {
+	s.csrfMu.RLock()
+	defer s.csrfMu.RUnlock()
+	return s.csrfToken
+}
+
+func (s *session) setCSRFToken(token string) {
+	s.csrfMu.Lock()
+	defer s.csrfMu.Unlock()
+	s.csrfToken = token
+}
+
+// Session ID accessors with mutex protection
+func (s *session) getSessionID() string {
+	s.sessionMu.RLock()
+	defer s.sessionMu.RUnlock()
+	return s.sessionID
+}
+
+func (s *session) setSessionID(id string) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	s.sessionID = id
+}
+
+// ErrSessionPoolExhausted is returned when no pooled session becomes free
+// within the acquire timeout, typically because locks were never released.
+var ErrSessionPoolExhausted = errors.New("session pool exhausted")
+
+const (
+	defaultSessionAcquireTimeout = 30 * time.Second
+	defaultSessionLockTTL        = 15 * time.Minute
+)
+
+// sessionPool hands out dedicated sessions for stateful sequences
+// (lock, update, unlock). A lock handle is pinned to the session that
+// acquired it until the object is unlocked. When the pool runs out, sessions
+// pinned longer than lockTTL are reclaimed; a lock that was never unlocked
+// cannot hold its session forever.
+type sessionPool struct {
+	free      chan *session
+	newClient func() HTTPDoer
+
+	acquireTimeout time.Duration
+	lockTTL        time.Duration
+
+	mu     sync.Mutex
+	pinned map[string]pinnedSession // lock handle -> session
+
+	waits, totalWait, reclaimed atomic.Int64
+}
+
+type pinnedSession struct {
+	s     *session
+	since time.Time
+}
+
+func newSessionPool(size int, newClient func() HTTPDoer) *sessionPool {
+	p := &sessionPool{
+		free:           make(chan *session, size),
+		newClient:      newClient,
+		acquireTimeout: defaultSessionAcquireTimeout,
+		lockTTL:        defaultSessionLockTTL,
+		pinned:         make(map[string]pinnedSession),
+	}
+	for i := 0; i < size; i++ {
+		p.free <- &session{client: newClient()}
+	}
+	return p
+}
+
+func (p *sessionPool) acquire(ctx context.Context) (*session, error) {
+	select {
+	case s := <-p.free:
+		return s, nil
+	default:
+	}
+
+	start := time.Now()
+	p.reclaimExpired()
+	timer := time.NewTimer(p.acquireTimeout)
+	defer timer.Stop()
+	select {
+	case s := <-p.free:
+		p.waits.Add(1)
+		p.totalWait.Add(int64(time.Since(start)))
+		return s, nil
+	case <-timer.C:
+		p.mu.Lock()
+		pinned := len(p.pinned)
+		p.mu.Unlock()
+		return nil, fmt.Errorf("%w: no session became free within %s (%d of %d hold locks); unlock objects or use a larger pool", ErrSessionPoolExhausted, p.acquireTimeout, pinned, cap(p.free))
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *sessionPool) release(s *session) {
+	p.free <- s
+}
+
+func (p *sessionPool) pin(lockHandle string, s *session) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pinned[lockHandle] = pinnedSession{s: s, since: time.Now()}
+}
+
+// unpin returns the session holding lockHandle to the pool.
+func (p *sessionPool) unpin(lockHandle string) {
+	p.mu.Lock()
+	ps, ok := p.pinned[lockHandle]
+	delete(p.pinned, lockHandle)
+	p.mu.Unlock()
+	if ok {
+		p.release(ps.s)
+	}
+}
+
+// reclaimExpired replaces the sessions of locks pinned longer than lockTTL
+// with fresh ones. The old SAP session may still hold the enqueue lock, so
+// its cookies, CSRF token and session ID are dropped rather than handed to
+// the next lock; SAP releases the lock when that session times out. Requests
+// with a reclaimed lock handle go to the shared session and fail there like
+// requests with any other expired lock.
+func (p *sessionPool) reclaimExpired() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for handle, ps := range p.pinned {
+		if time.Since(ps.since) > p.lockTTL {
+			delete(p.pinned, handle)
+			p.reclaimed.Add(1)
+			p.release(&session{client: p.newClient()})
+		}
+	}
+}
+
+func (p *sessionPool) lookup(lockHandle string) *session {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pinned[lockHandle].s
+}
+
+type sessionKey struct{}
+
+// withSession makes requests made with ctx use s.
+func withSession(ctx context.Context, s *session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
@@ -0,0 +1,246 @@
+package adt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
+)
+
+// slowHTTPClient answers every request after a delay and tracks concurrency.
+type slowHTTPClient struct {
+	delay    time.Duration
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (m *slowHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	n := m.inFlight.Add(1)
+	defer m.inFlight.Add(-1)
+	for {
+		peak := m.peak.Load()
+		if n <= peak || m.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(m.delay)
+	return newMockResponse(200, "OK", map[string]string{"X-CSRF-Token": "token"}), nil
+}
+
+func TestTransport_MaxConcurrent(t *testing.T) {
+	mock := &slowHTTPClient{delay: 20 * time.Millisecond}
+	transport := NewTransportWithClient(NewConfig("https://sap.example.com", "u", "p", WithMaxConcurrent(2)), mock)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := transport.Request(context.Background(), "/sap/bc/adt/test", nil); err != nil {
+				t.Errorf("Request: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := mock.peak.Load(); peak > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", peak)
+	}
+	stats := transport.Stats()
+	if stats.Requests != 8 || stats.InFlight != 0 || stats.PeakInFlight != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.Throttled == 0 || stats.MaxWait < 20*time.Millisecond || stats.AverageWait() == 0 {
+		t.Errorf("expected recorded waits, got %+v", stats)
+	}
+}
+
+func TestTransport_RateLimit(t *testing.T) {
+	mock := &slowHTTPClient{}
+	transport := NewTransportWithClient(NewConfig("https://sap.example.com", "u", "p", WithRateLimit(50)), mock)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := transport.Request(context.Background(), "/sap/bc/adt/test", nil); err != nil {
+			t.Fatalf("Request: %v", err)
+		}
+	}
+	// 5 requests at 50/s are spaced 20ms apart: at least 80ms in total.
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("5 requests took %v, want >= 80ms", elapsed)
+	}
+	if stats := transport.Stats(); stats.Throttled < 3 || stats.RequestsPerSecond != 50 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestTransport_ThrottleRespectsContext(t *testing.T) {
+	mock := &slowHTTPClient{delay: 200 * time.Millisecond}
+	transport := NewTransportWithClient(NewConfig("https://sap.example.com", "u", "p", WithMaxConcurrent(1)), mock)
+
+	go transport.Request(context.Background(), "/sap/bc/adt/slow", nil)
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := transport.Request(ctx, "/sap/bc/adt/test", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want deadline exceeded while waiting for a slot", err)
+	}
+}
+
+// lockHTTPClient hands out a new lock handle for every LOCK request.
+type lockHTTPClient struct {
+	mu    sync.Mutex
+	locks int
+}
+
+func (m *lockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	headers := map[string]string{"X-CSRF-Token": "token"}
+	if req.URL.Query().Get("_action") != "LOCK" {
+		return newMockResponse(200, "", headers), nil
+	}
+	m.mu.Lock()
+	m.locks++
+	handle := fmt.Sprintf("HANDLE%d", m.locks)
+	m.mu.Unlock()
+	body := `<asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values><DATA><LOCK_HANDLE>` + handle + `</LOCK_HANDLE></DATA></asx:values></asx:abap>`
+	return &http.Response{StatusCode: 200, Header: http.Header{"X-Csrf-Token": {"token"}}, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestSessionPool_PinsLockHandle(t *testing.T) {
+	cfg := NewConfig("https://sap.example.com", "u", "p", WithSessionPool(1))
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, &lockHTTPClient{}))
+	ctx := context.Background()
+	objURL := "/sap/bc/adt/programs/programs/ZTEST"
+
+	lock, err := client.LockObject(ctx, objURL, "MODIFY")
+	if err != nil {
+		t.Fatalf("LockObject: %v", err)
+	}
+	if st := client.TransportStats(); st.SessionPoolSize != 1 || st.SessionsInUse != 1 {
+		t.Fatalf("after lock: %+v", st)
+	}
+
+	pinned := client.transport.sessionFor(ctx, &RequestOptions{Query: map[string][]string{"lockHandle": {lock.LockHandle}}})
+	if pinned == client.transport.shared {
+		t.Error("request with lock handle not routed to the pooled session")
+	}
+	if s := client.transport.sessionFor(ctx, &RequestOptions{}); s != client.transport.shared {
+		t.Error("request without lock handle not routed to the shared session")
+	}
+
+	// The only pooled session is taken: a second lock waits.
+	waitCtx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	if _, err := client.LockObject(waitCtx, "/sap/bc/adt/programs/programs/ZOTHER", "MODIFY"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second LockObject error = %v, want deadline exceeded", err)
+	}
+
+	if err := client.UnlockObject(ctx, objURL, lock.LockHandle); err != nil {
+		t.Fatalf("UnlockObject: %v", err)
+	}
+	if st := client.TransportStats(); st.SessionsInUse != 0 || st.SessionWaits != 0 {
+		t.Errorf("after unlock: %+v", st)
+	}
+	if _, err := client.LockObject(ctx, "/sap/bc/adt/programs/programs/ZOTHER", "MODIFY"); err != nil {
+		t.Errorf("LockObject after unlock: %v", err)
+	}
+}
+
+func TestSessionPool_ExhaustedAndReclaimed(t *testing.T) {
+	cfg := NewConfig("https://sap.example.com", "u", "p", WithSessionPool(1))
+	cfg.SessionAcquireTimeout = 20 * time.Millisecond
+	cfg.SessionLockTTL = time.Hour
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, &lockHTTPClient{}))
+	ctx := context.Background()
+
+	// A lock that is never unlocked holds the only session
+	stale, err := client.LockObject(ctx, "/sap/bc/adt/programs/programs/ZTEST", "MODIFY")
+	if err != nil {
+		t.Fatalf("LockObject: %v", err)
+	}
+	staleSession := client.transport.pool.lookup(stale.LockHandle)
+	_, err = client.LockObject(ctx, "/sap/bc/adt/programs/programs/ZOTHER", "MODIFY")
+	if !errors.Is(err, ErrSessionPoolExhausted) || !strings.Contains(err.Error(), "1 of 1 hold locks") {
+		t.Fatalf("second LockObject error = %v, want session pool exhausted", err)
+	}
+
+	// Once the lock is older than the TTL, its session is reclaimed
+	client.transport.pool.lockTTL = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+	lock, err := client.LockObject(ctx, "/sap/bc/adt/programs/programs/ZOTHER", "MODIFY")
+	if err != nil {
+		t.Fatalf("LockObject after expiry: %v", err)
+	}
+	if st := client.TransportStats(); st.SessionsReclaimed != 1 || st.SessionsInUse != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+	// The SAP session of the stale lock is not reused
+	if client.transport.pool.lookup(lock.LockHandle) == staleSession {
+		t.Error("expected a fresh session after reclaiming an expired lock")
+	}
+}
+
+func TestSessionPool_WithSession(t *testing.T) {
+	cfg := NewConfig("https://sap.example.com", "u", "p", WithSessionPool(1))
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, &lockHTTPClient{}))
+
+	err := client.WithSession(context.Background(), func(ctx context.Context) error {
+		s := client.transport.sessionFor(ctx, &RequestOptions{})
+		if s == client.transport.shared {
+			t.Error("WithSession did not set a pooled session")
+		}
+		// Locks inside reuse the session instead of waiting for another one.
+		lock, err := client.LockObject(ctx, "/sap/bc/adt/programs/programs/ZTEST", "MODIFY")
+		if err != nil {
+			return err
+		}
+		return client.UnlockObject(ctx, "/sap/bc/adt/programs/programs/ZTEST", lock.LockHandle)
+	})
+	if err != nil {
+		t.Fatalf("WithSession: %v", err)
+	}
+	if st := client.TransportStats(); st.SessionsInUse != 0 {
+		t.Errorf("session not returned: %+v", st)
+	}
+}
+
+func TestSessionPool_ParallelWritesAgainstMockSystem(t *testing.T) {
+	srv := adttest.NewServer()
+	defer srv.Close()
+	client := NewClient(srv.URL, "developer", "secret", WithSessionPool(2), WithMaxConcurrent(3))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("ZPAR%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := client.CreateAndActivateProgram(ctx, name, "Parallel", "$TMP", "REPORT "+strings.ToLower(name)+".", "")
+			if err != nil || !result.Success {
+				t.Errorf("%s: %v %+v", name, err, result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 4; i++ {
+		obj, ok := srv.Object(fmt.Sprintf("/sap/bc/adt/programs/programs/ZPAR%d", i))
+		if !ok || obj.Inactive || obj.LockHandle != "" {
+			t.Errorf("ZPAR%d: %+v", i, obj)
+		}
+	}
+	if st := client.TransportStats(); st.SessionsInUse != 0 || st.PeakInFlight > 3 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
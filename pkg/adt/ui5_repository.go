@@ -0,0 +1,359 @@
+package adt
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ui5RepositoryService is the OData service of the UI5 ABAP repository, the
+// one the Fiori tools deploy task uses. Unlike the ADT filestore, it accepts
+// writes: an application is uploaded as a whole, as a base64 ZIP archive.
+const ui5RepositoryService = "/sap/opu/odata/UI5/ABAP_REPOSITORY_SRV"
+
+// UI5DeployOptions configures UI5Deploy.
+type UI5DeployOptions struct {
+	Name        string `json:"name"`                  // BSP application name, max 15 characters after the namespace
+	Package     string `json:"package,omitempty"`     // Package of a new application (default: $TMP)
+	Description string `json:"description,omitempty"` // Application description
+	Transport   string `json:"transport,omitempty"`   // Transport request (required for transportable packages)
+	TestMode    bool   `json:"testMode,omitempty"`    // Only check the upload; nothing is changed
+}
+
+// UI5DeployResult is the outcome of UI5Deploy and UI5Undeploy.
+type UI5DeployResult struct {
+	Name     string                 `json:"name"`
+	Action   string                 `json:"action"` // created, updated or deleted
+	Package  string                 `json:"package,omitempty"`
+	Files    []string               `json:"files,omitempty"`
+	ZipSize  int                    `json:"zipSize,omitempty"`
+	URL      string                 `json:"url,omitempty"` // where the application is served
+	TestMode bool                   `json:"testMode,omitempty"`
+	Messages []UI5RepositoryMessage `json:"messages,omitempty"`
+}
+
+// UI5RepositoryMessage is a message of the UI5 repository service.
+type UI5RepositoryMessage struct {
+	Severity string `json:"severity"`
+	Text     string `json:"text"`
+}
+
+// ui5Repository is a repository entry as returned by the service.
+type ui5Repository struct {
+	Name        string `json:"Name"`
+	Package     string `json:"Package"`
+	Description string `json:"Description"`
+}
+
+// ZipUI5App packs the files below dir, typically the dist folder of a UI5
+// build, into a ZIP archive with paths relative to dir. It returns the
+// archive and the packed paths.
+func ZipUI5App(dir string) ([]byte, []string, error) {
+	var buf bytes.Buffer
+	var files []string
+	zw := zip.NewWriter(&buf)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(rel)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("packing %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("packing %s: no files", dir)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("packing %s: %w", dir, err)
+	}
+	return buf.Bytes(), files, nil
+}
+
+// UI5Deploy packs dir into a ZIP archive and deploys it as a BSP
+// application through the UI5 repository service. An existing application
+// is replaced and keeps its package.
+func (c *Client) UI5Deploy(ctx context.Context, dir string, opts UI5DeployOptions) (*UI5DeployResult, error) {
+	archive, files, err := ZipUI5App(dir)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.UI5DeployZip(ctx, archive, opts)
+	if result != nil {
+		result.Files = files
+	}
+	return result, err
+}
+
+// UI5DeployZip deploys a ZIP archive of a UI5 application as a BSP
+// application through the UI5 repository service.
+func (c *Client) UI5DeployZip(ctx context.Context, archive []byte, opts UI5DeployOptions) (*UI5DeployResult, error) {
+	if err := c.checkSafety(OpUpdate, "UI5Deploy"); err != nil {
+		return nil, err
+	}
+	name, err := ui5AppName(opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkTransportableEdit(opts.Transport, "UI5Deploy"); err != nil {
+		return nil, err
+	}
+
+	existing, err := c.ui5Repository(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	pkg := strings.ToUpper(opts.Package)
+	switch {
+	case existing != nil && pkg == "":
+		pkg = existing.Package
+	case existing != nil && !strings.EqualFold(existing.Package, pkg):
+		return nil, fmt.Errorf("UI5 app %s exists in package %s, not %s", name, existing.Package, pkg)
+	case pkg == "":
+		pkg = "$TMP"
+	}
+	if err := c.checkPackageSafety(pkg); err != nil {
+		return nil, err
+	}
+	description := opts.Description
+	if description == "" && existing != nil {
+		description = existing.Description
+	}
+
+	result := &UI5DeployResult{Name: name, Action: "created", Package: pkg, ZipSize: len(archive), URL: UI5AppURL(name), TestMode: opts.TestMode}
+	method, path := http.MethodPost, ui5RepositoryService+"/Repositories"
+	var headers map[string]string
+	if existing != nil {
+		result.Action = "updated"
+		method, path = http.MethodPut, ui5RepositoryPath(name)
+		headers = map[string]string{"If-Match": "*"}
+	}
+	query := ui5RepositoryQuery(opts.Transport)
+	if opts.TestMode {
+		query.Set("TestMode", "true")
+	}
+
+	resp, err := c.transport.Request(ctx, path, &RequestOptions{
+		Method:      method,
+		Query:       query,
+		Headers:     headers,
+		Body:        []byte(ui5RepositoryEntry(name, pkg, description, archive)),
+		ContentType: "application/atom+xml; type=entry; charset=utf-8",
+		Accept:      "application/json",
+	})
+	if err != nil {
+		return nil, ui5RepositoryError(fmt.Sprintf("deploying UI5 app %s", name), err)
+	}
+	result.Messages = parseUI5RepositoryMessages(resp.Headers.Get("sap-message"))
+	return result, nil
+}
+
+// UI5Undeploy deletes a BSP application through the UI5 repository service.
+func (c *Client) UI5Undeploy(ctx context.Context, name, transport string) (*UI5DeployResult, error) {
+	if err := c.checkSafety(OpDelete, "UI5Undeploy"); err != nil {
+		return nil, err
+	}
+	name, err := ui5AppName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkTransportableEdit(transport, "UI5Undeploy"); err != nil {
+		return nil, err
+	}
+	existing, err := c.ui5Repository(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("UI5 app %s does not exist", name)
+	}
+	if err := c.checkPackageSafety(existing.Package); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.transport.Request(ctx, ui5RepositoryPath(name), &RequestOptions{
+		Method:  http.MethodDelete,
+		Query:   ui5RepositoryQuery(transport),
+		Headers: map[string]string{"If-Match": "*"},
+		Accept:  "application/json",
+	})
+	if err != nil {
+		return nil, ui5RepositoryError(fmt.Sprintf("undeploying UI5 app %s", name), err)
+	}
+	return &UI5DeployResult{
+		Name:     name,
+		Action:   "deleted",
+		Package:  existing.Package,
+		Messages: parseUI5RepositoryMessages(resp.Headers.Get("sap-message")),
+	}, nil
+}
+
+// UI5AppURL returns the path a BSP application is served at, e.g.
+// /sap/bc/ui5_ui5/sap/zorders/ for ZORDERS.
+func UI5AppURL(name string) string {
+	ns, app := splitODataNamespace(name)
+	return fmt.Sprintf("/sap/bc/ui5_ui5/%s/%s/", ns, strings.ToLower(app))
+}
+
+// ui5AppName validates and normalizes a BSP application name.
+func ui5AppName(name string) (string, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" {
+		return "", fmt.Errorf("UI5 app name is required")
+	}
+	if _, app := splitODataNamespace(name); len(app) > 15 {
+		return "", fmt.Errorf("UI5 app name %s is too long (max 15 characters after the namespace)", name)
+	}
+	return name, nil
+}
+
+// ui5Repository returns the repository entry of an application, or nil if
+// it does not exist.
+func (c *Client) ui5Repository(ctx context.Context, name string) (*ui5Repository, error) {
+	resp, err := c.transport.Request(ctx, ui5RepositoryPath(name), &RequestOptions{
+		Method: http.MethodGet,
+		Query:  url.Values{"$format": {"json"}},
+		Accept: "application/json",
+	})
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.IsNotFound() {
+			return nil, nil
+		}
+		return nil, ui5RepositoryError(fmt.Sprintf("reading UI5 app %s", name), err)
+	}
+	var entry struct {
+		D ui5Repository `json:"d"`
+	}
+	if err := json.Unmarshal(resp.Body, &entry); err != nil {
+		return nil, fmt.Errorf("parsing UI5 app %s: %w", name, err)
+	}
+	return &entry.D, nil
+}
+
+func ui5RepositoryPath(name string) string {
+	return fmt.Sprintf("%s/Repositories('%s')", ui5RepositoryService, url.PathEscape(name))
+}
+
+// ui5RepositoryQuery returns the query parameters of a change request. The
+// service reports its messages in the sap-message header.
+func ui5RepositoryQuery(transport string) url.Values {
+	q := url.Values{}
+	q.Set("CodePage", "UTF8")
+	q.Set("CondenseMessagesInHttpResponseHeader", "X")
+	q.Set("format", "json")
+	if transport != "" {
+		q.Set("TransportRequest", transport)
+	}
+	return q
+}
+
+// ui5RepositoryEntry builds the Atom entry of a repository upload.
+func ui5RepositoryEntry(name, pkg, description string, archive []byte) string {
+	base := ui5RepositoryService + "/"
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<entry xmlns="http://www.w3.org/2005/Atom" xmlns:m="http://schemas.microsoft.com/ado/2007/08/dataservices/metadata" xmlns:d="http://schemas.microsoft.com/ado/2007/08/dataservices" xml:base="%s">
+  <id>%sRepositories('%s')</id>
+  <title type="text">Repositories('%s')</title>
+  <updated>%s</updated>
+  <category term="/UI5/ABAP_REPOSITORY_SRV.Repository" scheme="http://schemas.microsoft.com/ado/2007/08/dataservices/scheme"/>
+  <link href="Repositories('%s')" rel="edit" title="Repository"/>
+  <content type="application/xml">
+    <m:properties>
+      <d:Name>%s</d:Name>
+      <d:Package>%s</d:Package>
+      <d:Description>%s</d:Description>
+      <d:ZipArchive>%s</d:ZipArchive>
+      <d:Info/>
+    </m:properties>
+  </content>
+</entry>`,
+		base, base, escapeXML(name), escapeXML(name), time.Now().UTC().Format(time.RFC3339), escapeXML(name),
+		escapeXML(name), escapeXML(pkg), escapeXML(description), base64.StdEncoding.EncodeToString(archive))
+}
+
+// parseUI5RepositoryMessages parses a sap-message header: a JSON message
+// with the further messages in details.
+func parseUI5RepositoryMessages(header string) []UI5RepositoryMessage {
+	if header == "" {
+		return nil
+	}
+	type message struct {
+		Message  string `json:"message"`
+		Severity string `json:"severity"`
+	}
+	var m struct {
+		message
+		Details []message `json:"details"`
+	}
+	if err := json.Unmarshal([]byte(header), &m); err != nil {
+		return []UI5RepositoryMessage{{Severity: "info", Text: header}}
+	}
+	var msgs []UI5RepositoryMessage
+	for _, d := range append([]message{m.message}, m.Details...) {
+		if d.Message != "" {
+			msgs = append(msgs, UI5RepositoryMessage{Severity: d.Severity, Text: d.Message})
+		}
+	}
+	return msgs
+}
+
+// ui5RepositoryError turns an OData error response into an error with the
+// service's message and details.
+func ui5RepositoryError(action string, err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	var body struct {
+		Error struct {
+			Message struct {
+				Value string `json:"value"`
+			} `json:"message"`
+			InnerError struct {
+				ErrorDetails []struct {
+					Message  string `json:"message"`
+					Severity string `json:"severity"`
+				} `json:"errordetails"`
+			} `json:"innererror"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(apiErr.Message), &body) != nil || body.Error.Message.Value == "" {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	msg := body.Error.Message.Value
+	for _, d := range body.Error.InnerError.ErrorDetails {
+		if d.Message != "" && d.Message != msg {
+			msg += "\n  " + d.Severity + ": " + d.Message
+		}
+	}
+	return fmt.Errorf("%s: %s (HTTP %d)", action, msg, apiErr.StatusCode)
+}
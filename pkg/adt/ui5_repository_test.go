@@ -0,0 +1,142 @@
+package adt
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt/adttest"
+)
+
+func writeUI5Dist(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestUI5Deploy(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	client := NewClient(sap.URL, "developer", "secret")
+	ctx := context.Background()
+	dist := writeUI5Dist(t, map[string]string{
+		"index.html":               "<html/>",
+		"manifest.json":            `{"sap.app":{"id":"z.orders"}}`,
+		"Component-preload.js":     "sap.ui.define([], function () {});",
+		"i18n/i18n.properties":     "appTitle=Orders",
+		"test/flpSandbox.html":     "<html/>",
+		"localService/mockdata/.x": "",
+	})
+
+	res, err := client.UI5Deploy(ctx, dist, UI5DeployOptions{Name: "zorders", Description: "Orders"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Action != "created" || res.Package != "$TMP" || len(res.Files) != 6 || res.URL != "/sap/bc/ui5_ui5/sap/zorders/" {
+		t.Fatalf("result = %+v", res)
+	}
+	if len(res.Messages) != 2 || res.Messages[0].Text != "Application ZORDERS created" || res.Messages[1].Severity != "info" {
+		t.Errorf("messages = %+v", res.Messages)
+	}
+	app, ok := sap.UI5App("ZORDERS")
+	if !ok || app.Package != "$TMP" || app.Description != "Orders" || string(app.Files["i18n/i18n.properties"]) != "appTitle=Orders" {
+		t.Fatalf("deployed app = %+v", app)
+	}
+
+	// Redeploying replaces the app and keeps its package and description
+	dist = writeUI5Dist(t, map[string]string{"index.html": "<html>v2</html>"})
+	res, err = client.UI5Deploy(ctx, dist, UI5DeployOptions{Name: "ZORDERS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Action != "updated" || res.Package != "$TMP" {
+		t.Errorf("redeploy = %+v", res)
+	}
+	if app, _ := sap.UI5App("ZORDERS"); len(app.Files) != 1 || app.Description != "Orders" {
+		t.Errorf("redeployed app = %+v", app)
+	}
+	if n := sap.CountRequests(http.MethodPut, "/sap/opu/odata/UI5/ABAP_REPOSITORY_SRV/Repositories('ZORDERS')"); n != 1 {
+		t.Errorf("%d PUT requests, want 1", n)
+	}
+
+	if _, err := client.UI5Deploy(ctx, dist, UI5DeployOptions{Name: "ZORDERS", Package: "ZFIORI"}); err == nil || !strings.Contains(err.Error(), "exists in package $TMP") {
+		t.Errorf("package change: %v", err)
+	}
+
+	res, err = client.UI5Undeploy(ctx, "zorders", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Action != "deleted" || res.Messages[0].Text != "Application ZORDERS deleted" {
+		t.Errorf("undeploy = %+v", res)
+	}
+	if _, ok := sap.UI5App("ZORDERS"); ok {
+		t.Error("app still deployed")
+	}
+	if _, err := client.UI5Undeploy(ctx, "ZORDERS", ""); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("undeploy missing app: %v", err)
+	}
+}
+
+func TestUI5DeployTransportable(t *testing.T) {
+	sap := adttest.NewServer()
+	defer sap.Close()
+	sap.AddPackage("ZFIORI", "Fiori apps", "")
+	dist := writeUI5Dist(t, map[string]string{"index.html": "<html/>"})
+	ctx := context.Background()
+
+	client := NewClient(sap.URL, "developer", "secret", WithEnableTransports(), WithAllowTransportableEdits())
+	_, err := client.UI5Deploy(ctx, dist, UI5DeployOptions{Name: "ZORDERS", Package: "zfiori"})
+	if err == nil || !strings.Contains(err.Error(), "Package ZFIORI is transportable; specify a transport request (HTTP 400)") {
+		t.Fatalf("deploy without transport: %v", err)
+	}
+	res, err := client.UI5Deploy(ctx, dist, UI5DeployOptions{Name: "ZORDERS", Package: "zfiori", Transport: "DEVK900123", TestMode: true})
+	if err != nil || !res.TestMode || res.Messages[0].Text != "Application ZORDERS checked (test mode)" {
+		t.Fatalf("test mode: %+v, %v", res, err)
+	}
+	if _, ok := sap.UI5App("ZORDERS"); ok {
+		t.Error("test mode deployed the app")
+	}
+	if _, err := client.UI5Deploy(ctx, dist, UI5DeployOptions{Name: "ZORDERS", Package: "zfiori", Transport: "DEVK900123"}); err != nil {
+		t.Fatal(err)
+	}
+	if app, _ := sap.UI5App("ZORDERS"); app.Transport != "DEVK900123" {
+		t.Errorf("app = %+v", app)
+	}
+
+	readOnly := NewClient(sap.URL, "developer", "secret", WithReadOnly())
+	if _, err := readOnly.UI5Undeploy(ctx, "ZORDERS", "DEVK900123"); err == nil {
+		t.Error("read-only client undeployed")
+	}
+}
+
+func TestUI5AppNames(t *testing.T) {
+	if _, err := ui5AppName("ZVERY_LONG_APP_NAME"); err == nil {
+		t.Error("expected an error for a name over 15 characters")
+	}
+	if name, err := ui5AppName("/dmo/fiori_travel"); err != nil || name != "/DMO/FIORI_TRAVEL" {
+		t.Errorf("ui5AppName = %s, %v", name, err)
+	}
+	if got := UI5AppURL("/DMO/FIORI_TRAVEL"); got != "/sap/bc/ui5_ui5/dmo/fiori_travel/" {
+		t.Errorf("UI5AppURL = %s", got)
+	}
+	if _, _, err := ZipUI5App(t.TempDir()); err == nil || !strings.Contains(err.Error(), "no files") {
+		t.Errorf("empty dir: %v", err)
+	}
+	msgs := parseUI5RepositoryMessages("not json")
+	if len(msgs) != 1 || msgs[0].Text != "not json" {
+		t.Errorf("messages = %+v", msgs)
+	}
+}
@@ -19,11 +19,29 @@ type DebugWebSocketClient struct {
 	isAttached bool
 	debuggeeID string
 
+	// Breakpoints set in this session, re-registered after a reconnect
+	// (ZADT_VSP deletes them when the connection drops). breakpoints maps the
+	// current ID to the setBreakpoint parameters; bpAliases maps IDs returned
+	// before a reconnect to the current ones. lostBreakpoints holds the ones
+	// that could not be re-registered, retried on the next reconnect.
+	breakpoints     map[string]map[string]any
+	bpAliases       map[string]string
+	lostBreakpoints map[string]lostBreakpoint
+
 	// Event channel for async events (debuggee caught, etc.)
 	Events chan *DebugEvent
 }
 
+// lostBreakpoint is a breakpoint that could not be re-registered after a
+// reconnect.
+type lostBreakpoint struct {
+	params map[string]any
+	err    error
+}
+
 // DebugEvent represents an async event from the debugger.
+// Kind "breakpoint_lost" reports a breakpoint that could not be re-registered
+// after a reconnect; Data holds its breakpointId and the error.
 type DebugEvent struct {
 	Kind       string         `json:"kind"`
 	DebuggeeID string         `json:"debuggee_id,omitempty"`
@@ -65,6 +83,9 @@ func NewDebugWebSocketClient(baseURL, client, user, password string, insecure bo
 	c := &DebugWebSocketClient{
 		BaseWebSocketClient: NewBaseWebSocketClient(baseURL, client, user, password, insecure),
 		Events:              make(chan *DebugEvent, 10),
+		breakpoints:         make(map[string]map[string]any),
+		bpAliases:           make(map[string]string),
+		lostBreakpoints:     make(map[string]lostBreakpoint),
 	}
 
 	// Set disconnect callback to clean up debug state
@@ -75,11 +96,17 @@ func NewDebugWebSocketClient(baseURL, client, user, password string, insecure bo
 		c.mu.Unlock()
 	}
 
+	// Restore breakpoints after a reconnect; an attached debuggee is lost
+	c.BaseWebSocketClient.onReconnect = c.restoreBreakpoints
+
 	return c
 }
 
 // sendRequest sends a request to the debug domain and waits for response.
 func (c *DebugWebSocketClient) sendRequest(ctx context.Context, action string, params map[string]any) (*WSResponse, error) {
+	if err := c.checkSafety(OpDebug, "debug/"+action); err != nil {
+		return nil, err
+	}
 	return c.SendDomainRequest(ctx, "debug", action, params, 65*time.Second)
 }
 
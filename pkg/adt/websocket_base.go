@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -14,18 +15,87 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// ErrConnectionLost is matched (via errors.Is) by the *ConnectionLostError
+// returned for requests that were pending when the connection dropped.
+var ErrConnectionLost = errors.New("ZADT_VSP connection lost")
+
+// ConnectionLostError is returned for a request that was pending when the
+// WebSocket connection dropped. ZADT_VSP may or may not have executed it, so
+// it is not retried automatically.
+type ConnectionLostError struct {
+	RequestID string
+	Cause     error
+}
+
+func (e *ConnectionLostError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("ZADT_VSP connection lost during request %s: %v", e.RequestID, e.Cause)
+	}
+	return fmt.Sprintf("ZADT_VSP connection lost during request %s", e.RequestID)
+}
+
+func (e *ConnectionLostError) Unwrap() error { return e.Cause }
+
+// Is reports whether target is ErrConnectionLost.
+func (e *ConnectionLostError) Is(target error) bool { return target == ErrConnectionLost }
+
+// WebSocketOptions controls keepalive and automatic reconnection of ZADT_VSP
+// connections.
+type WebSocketOptions struct {
+	// KeepaliveInterval is the idle time after which a system/ping request is
+	// sent. A ping that is not answered within PingTimeout marks the
+	// connection dead. Zero disables keepalive.
+	KeepaliveInterval time.Duration
+	PingTimeout       time.Duration
+
+	// MaxReconnectAttempts bounds the reconnect attempts after the connection
+	// drops. Zero disables reconnection. The delay starts at ReconnectBackoff
+	// and doubles per attempt up to MaxReconnectBackoff.
+	MaxReconnectAttempts int
+	ReconnectBackoff     time.Duration
+	MaxReconnectBackoff  time.Duration
+}
+
+// DefaultWebSocketOptions returns the options used by new clients.
+func DefaultWebSocketOptions() WebSocketOptions {
+	return WebSocketOptions{
+		KeepaliveInterval:    30 * time.Second,
+		PingTimeout:          15 * time.Second,
+		MaxReconnectAttempts: 5,
+		ReconnectBackoff:     time.Second,
+		MaxReconnectBackoff:  30 * time.Second,
+	}
+}
+
 // BaseWebSocketClient provides common WebSocket functionality for ZADT_VSP connections.
 // Embed this in domain-specific clients (Debug, AMDP, etc.).
+//
+// The connection is kept alive with system/ping requests while idle. When it
+// drops, pending requests fail with *ConnectionLostError and the client
+// reconnects in the background; requests made meanwhile wait for the new
+// connection.
 type BaseWebSocketClient struct {
 	baseURL  string
 	client   string
 	user     string
 	password string
 	insecure bool
-
-	conn      *websocket.Conn
-	sessionID string
-	mu        sync.RWMutex
+	opts     WebSocketOptions
+
+	// Optional OAuth, client certificate and cookie authentication (UseAuth)
+	tokenSource TokenSource
+	tlsConfig   *tls.Config
+	cookies     map[string]string
+	config      *Config      // configuration passed to UseAuth
+	safety      SafetyConfig // checked by the domain clients before each request
+
+	conn        *websocket.Conn
+	done        chan struct{}   // closed when conn drops
+	established *websocket.Conn // last connection that received the welcome message
+	sessionID   string
+	handshake   *VSPHandshake          // welcome message of the current connection
+	described   map[string]*DomainInfo // system/describe results for handshake
+	mu          sync.RWMutex
 
 	// Request/response handling
 	msgID     atomic.Int64
@@ -36,10 +106,19 @@ type BaseWebSocketClient struct {
 	welcomeCh chan struct{}
 
 	// Connection state
-	connected bool
+	connected    bool
+	closed       bool          // Close was called - do not reconnect
+	reconnecting bool          // reconnect loop running
+	stateCh      chan struct{} // closed and replaced on every state change
+	lastActive   atomic.Int64  // unix nanos of the last message received
+	keepaliveGen int           // identifies the current keepalive goroutine
 
 	// Optional callback when connection is lost
 	onDisconnect func()
+
+	// Optional callback after a successful reconnect, to restore server-side
+	// session state (breakpoints etc.)
+	onReconnect func(ctx context.Context)
 }
 
 // NewBaseWebSocketClient creates a new base WebSocket client.
@@ -50,11 +129,48 @@ func NewBaseWebSocketClient(baseURL, client, user, password string, insecure boo
 		user:      user,
 		password:  password,
 		insecure:  insecure,
+		opts:      DefaultWebSocketOptions(),
 		pending:   make(map[string]chan *WSResponse),
 		welcomeCh: make(chan struct{}, 1),
+		stateCh:   make(chan struct{}),
 	}
 }
 
+// SetOptions changes keepalive and reconnect behaviour. Call before Connect.
+func (c *BaseWebSocketClient) SetOptions(opts WebSocketOptions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.opts = opts
+}
+
+// UseAuth makes the client authenticate like the HTTP transport of cfg: with
+// bearer tokens from its TokenSource, its client certificate and cookies.
+// Call before Connect.
+func (c *BaseWebSocketClient) UseAuth(cfg *Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokenSource = cfg.TokenSource
+	c.tlsConfig = cfg.TLSConfig()
+	c.cookies = cfg.Cookies
+	c.config = cfg
+}
+
+// UseSafety makes the client refuse requests that the safety configuration
+// blocks, typically the configuration of the ADT client. Debug and AMDP
+// requests are debugger operations (OpDebug).
+func (c *BaseWebSocketClient) UseSafety(safety SafetyConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.safety = safety
+}
+
+// checkSafety returns an error if the safety configuration blocks op.
+func (c *BaseWebSocketClient) checkSafety(op OperationType, opName string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.safety.CheckOperation(op, opName)
+}
+
 // Connect establishes WebSocket connection to ZADT_VSP.
 func (c *BaseWebSocketClient) Connect(ctx context.Context) error {
 	c.mu.Lock()
@@ -62,11 +178,28 @@ func (c *BaseWebSocketClient) Connect(ctx context.Context) error {
 		c.mu.Unlock()
 		return fmt.Errorf("already connected")
 	}
+	c.closed = false
+	c.mu.Unlock()
+
+	if err := c.connect(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.keepaliveGen++
+	gen, keepalive := c.keepaliveGen, c.opts.KeepaliveInterval
+	c.mu.Unlock()
+	if keepalive > 0 {
+		go c.keepalive(gen, keepalive)
+	}
+	return nil
+}
 
+// connect dials ZADT_VSP, starts the reader and waits for the welcome message.
+func (c *BaseWebSocketClient) connect(ctx context.Context) error {
 	// Build WebSocket URL
 	u, err := url.Parse(c.baseURL)
 	if err != nil {
-		c.mu.Unlock()
 		return fmt.Errorf("invalid base URL: %w", err)
 	}
 
@@ -77,49 +210,85 @@ func (c *BaseWebSocketClient) Connect(ctx context.Context) error {
 
 	wsURL := fmt.Sprintf("%s://%s/sap/bc/apc/sap/zadt_vsp?sap-client=%s", scheme, u.Host, c.client)
 
+	c.mu.RLock()
+	tokenSource, tlsConfig, cookies := c.tokenSource, c.tlsConfig, c.cookies
+	c.mu.RUnlock()
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{InsecureSkipVerify: c.insecure}
+	}
+
 	// Create dialer with auth and TLS config
 	dialer := websocket.Dialer{
 		HandshakeTimeout: 30 * time.Second,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: c.insecure,
-		},
+		TLSClientConfig:  tlsConfig,
 	}
 
-	// Add basic auth header
+	// Add bearer token or basic auth header. The token is fetched on every
+	// dial, so reconnects use a refreshed token.
 	header := http.Header{}
-	header.Set("Authorization", basicAuth(c.user, c.password))
+	if tokenSource != nil {
+		tok, err := tokenSource.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("getting OAuth token: %w", err)
+		}
+		header.Set("Authorization", "Bearer "+tok.AccessToken)
+	} else {
+		header.Set("Authorization", basicAuth(c.user, c.password))
+	}
+	for name, value := range cookies {
+		header.Add("Cookie", (&http.Cookie{Name: name, Value: value}).String())
+	}
+
+	// Drop a stale welcome signal from a previous connection
+	select {
+	case <-c.welcomeCh:
+	default:
+	}
 
 	conn, _, err := dialer.DialContext(ctx, wsURL, header)
 	if err != nil {
-		c.mu.Unlock()
 		return fmt.Errorf("WebSocket connection failed: %w", err)
 	}
 
+	done := make(chan struct{})
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		conn.Close()
+		return fmt.Errorf("client closed")
+	}
 	c.conn = conn
+	c.done = done
 	c.connected = true
 	c.mu.Unlock()
+	c.lastActive.Store(time.Now().UnixNano())
 
 	// Start message reader goroutine
-	go c.readMessages()
+	go c.readMessages(conn, done)
 
 	// Wait for welcome message
 	select {
 	case <-c.welcomeCh:
+		c.mu.Lock()
+		c.established = conn
+		c.mu.Unlock()
 		return nil
 	case <-time.After(5 * time.Second):
-		c.Close()
+		c.dropConnection(conn)
 		return fmt.Errorf("timeout waiting for welcome message")
 	case <-ctx.Done():
-		c.Close()
+		c.dropConnection(conn)
 		return ctx.Err()
 	}
 }
 
-// Close closes the WebSocket connection.
+// Close closes the WebSocket connection. The client does not reconnect.
 func (c *BaseWebSocketClient) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.closed = true
+	c.notifyLocked()
 	if c.conn != nil {
 		err := c.conn.Close()
 		c.conn = nil
@@ -129,11 +298,31 @@ func (c *BaseWebSocketClient) Close() error {
 	return nil
 }
 
-// IsConnected returns whether the client is connected.
+// dropConnection closes conn without disabling reconnection. The reader
+// notices and handles the disconnect.
+func (c *BaseWebSocketClient) dropConnection(conn *websocket.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == conn {
+		c.conn = nil
+		c.connected = false
+	}
+	conn.Close()
+}
+
+// IsConnected returns whether the client can be used: it is connected, or it
+// is reconnecting automatically after the connection dropped.
 func (c *BaseWebSocketClient) IsConnected() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.connected
+	return c.connected || c.reconnecting
+}
+
+// IsReconnecting returns whether the client is re-establishing a dropped connection.
+func (c *BaseWebSocketClient) IsReconnecting() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reconnecting
 }
 
 // GetUser returns the username.
@@ -141,31 +330,21 @@ func (c *BaseWebSocketClient) GetUser() string {
 	return c.user
 }
 
+// notifyLocked wakes up goroutines waiting for a state change. c.mu must be held.
+func (c *BaseWebSocketClient) notifyLocked() {
+	close(c.stateCh)
+	c.stateCh = make(chan struct{})
+}
+
 // readMessages reads messages from WebSocket and routes them.
-func (c *BaseWebSocketClient) readMessages() {
+func (c *BaseWebSocketClient) readMessages(conn *websocket.Conn, done chan struct{}) {
 	for {
-		c.mu.RLock()
-		conn := c.conn
-		c.mu.RUnlock()
-
-		if conn == nil {
-			return
-		}
-
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			c.mu.Lock()
-			c.conn = nil
-			c.connected = false
-			onDisconnect := c.onDisconnect
-			c.mu.Unlock()
-
-			// Call disconnect callback if set
-			if onDisconnect != nil {
-				onDisconnect()
-			}
+			c.handleDisconnect(conn, done)
 			return
 		}
+		c.lastActive.Store(time.Now().UnixNano())
 
 		var resp WSResponse
 		if err := json.Unmarshal(message, &resp); err != nil {
@@ -184,14 +363,12 @@ func (c *BaseWebSocketClient) readMessages() {
 
 		// Handle welcome message
 		if resp.ID == "welcome" {
-			var welcomeData struct {
-				Session string   `json:"session"`
-				Version string   `json:"version"`
-				Domains []string `json:"domains"`
-			}
-			if err := json.Unmarshal(resp.Data, &welcomeData); err == nil {
+			var h VSPHandshake
+			if err := json.Unmarshal(resp.Data, &h); err == nil {
 				c.mu.Lock()
-				c.sessionID = welcomeData.Session
+				c.sessionID = h.Session
+				c.handshake = &h
+				c.described = nil
 				c.mu.Unlock()
 			}
 			select {
@@ -202,112 +379,213 @@ func (c *BaseWebSocketClient) readMessages() {
 	}
 }
 
-// SendDomainRequest sends a request to any domain and waits for response.
-func (c *BaseWebSocketClient) SendDomainRequest(ctx context.Context, domain, action string, params map[string]any, timeout time.Duration) (*WSResponse, error) {
+// handleDisconnect fails the requests pending on conn and starts reconnecting
+// unless the client was closed.
+func (c *BaseWebSocketClient) handleDisconnect(conn *websocket.Conn, done chan struct{}) {
+	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+		c.connected = false
+	}
+	close(done)
+	// Only reconnect connections that were up, not failed connection attempts
+	reconnect := c.established == conn && !c.closed && !c.reconnecting && c.opts.MaxReconnectAttempts > 0
+	if reconnect {
+		c.reconnecting = true
+	}
+	c.notifyLocked()
+	onDisconnect := c.onDisconnect
+	c.mu.Unlock()
+
+	// Call disconnect callback if set
+	if onDisconnect != nil {
+		onDisconnect()
+	}
+	if reconnect {
+		go c.reconnect()
+	}
+}
+
+// reconnect re-establishes the connection with exponential backoff.
+func (c *BaseWebSocketClient) reconnect() {
 	c.mu.RLock()
-	conn := c.conn
+	opts := c.opts
 	c.mu.RUnlock()
 
-	if conn == nil {
-		return nil, fmt.Errorf("not connected")
-	}
+	delay := opts.ReconnectBackoff
+	for attempt := 1; attempt <= opts.MaxReconnectAttempts; attempt++ {
+		time.Sleep(delay)
+		if delay *= 2; opts.MaxReconnectBackoff > 0 && delay > opts.MaxReconnectBackoff {
+			delay = opts.MaxReconnectBackoff
+		}
 
-	id := fmt.Sprintf("%s_%d", domain, c.msgID.Add(1))
+		c.mu.RLock()
+		closed := c.closed
+		c.mu.RUnlock()
+		if closed {
+			break
+		}
 
-	msg := WSMessage{
-		ID:      id,
-		Domain:  domain,
-		Action:  action,
-		Params:  params,
-		Timeout: int(timeout.Milliseconds()),
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		err := c.connect(ctx)
+		if err == nil {
+			c.mu.RLock()
+			onReconnect := c.onReconnect
+			c.mu.RUnlock()
+			if onReconnect != nil {
+				onReconnect(ctx)
+			}
+		}
+		cancel()
+		if err == nil {
+			c.mu.Lock()
+			c.reconnecting = false
+			c.notifyLocked()
+			c.mu.Unlock()
+			return
+		}
 	}
 
-	respCh := make(chan *WSResponse, 1)
-	c.pendingMu.Lock()
-	c.pending[id] = respCh
-	c.pendingMu.Unlock()
+	c.mu.Lock()
+	c.reconnecting = false
+	c.notifyLocked()
+	c.mu.Unlock()
+}
 
-	data, err := json.Marshal(msg)
-	if err != nil {
+// keepalive pings ZADT_VSP while the connection is idle and drops the
+// connection when a ping is not answered, which triggers a reconnect.
+// ZADT_VSP handles one request at a time, so no ping is sent while a request
+// (e.g. a debugger listen) is pending.
+func (c *BaseWebSocketClient) keepalive(gen int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		c.mu.RLock()
+		conn, timeout := c.conn, c.opts.PingTimeout
+		stop := c.closed || c.keepaliveGen != gen || (conn == nil && !c.reconnecting)
+		c.mu.RUnlock()
+		if stop {
+			return
+		}
+		if conn == nil || time.Since(time.Unix(0, c.lastActive.Load())) < interval {
+			continue
+		}
 		c.pendingMu.Lock()
-		delete(c.pending, id)
+		busy := len(c.pending) > 0
 		c.pendingMu.Unlock()
-		return nil, err
+		if busy {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		_, err := c.SendDomainRequest(ctx, "system", "ping", nil, timeout)
+		cancel()
+		if err != nil && !errors.Is(err, ErrConnectionLost) {
+			c.dropConnection(conn)
+		}
 	}
+}
 
+// awaitConnection returns the current connection, waiting while the client
+// reconnects.
+func (c *BaseWebSocketClient) awaitConnection(ctx context.Context, timeout time.Duration) (*websocket.Conn, chan struct{}, error) {
+	deadline := time.After(timeout)
+	for {
+		c.mu.RLock()
+		conn, done, reconnecting, stateCh := c.conn, c.done, c.reconnecting, c.stateCh
+		c.mu.RUnlock()
+
+		if conn != nil {
+			return conn, done, nil
+		}
+		if !reconnecting {
+			return nil, nil, fmt.Errorf("not connected")
+		}
+		select {
+		case <-stateCh:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-deadline:
+			return nil, nil, fmt.Errorf("not connected: reconnect to ZADT_VSP still in progress")
+		}
+	}
+}
+
+// send writes a message on conn, failing fast if the peer stopped reading.
+func (c *BaseWebSocketClient) send(conn *websocket.Conn, data []byte) error {
 	c.mu.Lock()
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	c.mu.Unlock()
+	defer c.mu.Unlock()
+	if c.conn != conn {
+		return fmt.Errorf("not connected")
+	}
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// roundTrip sends data as request id and waits for the response.
+func (c *BaseWebSocketClient) roundTrip(ctx context.Context, id string, data []byte, timeout time.Duration) (*WSResponse, error) {
+	conn, done, err := c.awaitConnection(ctx, timeout)
 	if err != nil {
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
+		return nil, err
+	}
+
+	respCh := make(chan *WSResponse, 1)
+	c.RegisterPending(id, respCh)
+	defer c.UnregisterPending(id)
+
+	if err := c.send(conn, data); err != nil {
 		return nil, err
 	}
 
 	select {
 	case resp := <-respCh:
 		return resp, nil
+	case <-done:
+		// The response may have arrived just before the connection dropped
+		select {
+		case resp := <-respCh:
+			return resp, nil
+		default:
+		}
+		return nil, &ConnectionLostError{RequestID: id}
 	case <-ctx.Done():
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
 		return nil, ctx.Err()
 	case <-time.After(timeout):
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
 		return nil, fmt.Errorf("request timeout")
 	}
 }
 
-// SendRawRequest sends a raw message (for domains that use different format).
-func (c *BaseWebSocketClient) SendRawRequest(ctx context.Context, id string, rawMsg map[string]any, timeout time.Duration) (*WSResponse, error) {
-	c.mu.RLock()
-	conn := c.conn
-	c.mu.RUnlock()
-
-	if conn == nil {
-		return nil, fmt.Errorf("not connected")
+// SendDomainRequest sends a request to any domain and waits for response.
+// Requests the handler does not serve according to its handshake fail with
+// *VSPUnsupportedError without being sent.
+func (c *BaseWebSocketClient) SendDomainRequest(ctx context.Context, domain, action string, params map[string]any, timeout time.Duration) (*WSResponse, error) {
+	if h := c.Handshake(); h != nil && !h.Supports(domain, action) {
+		return nil, &VSPUnsupportedError{Domain: domain, Action: action, Version: h.Version}
 	}
+	id := fmt.Sprintf("%s_%d", domain, c.msgID.Add(1))
 
-	respCh := make(chan *WSResponse, 1)
-	c.pendingMu.Lock()
-	c.pending[id] = respCh
-	c.pendingMu.Unlock()
+	msg := WSMessage{
+		ID:      id,
+		Domain:  domain,
+		Action:  action,
+		Params:  params,
+		Timeout: int(timeout.Milliseconds()),
+	}
 
-	data, err := json.Marshal(rawMsg)
+	data, err := json.Marshal(msg)
 	if err != nil {
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
 		return nil, err
 	}
+	return c.roundTrip(ctx, id, data, timeout)
+}
 
-	c.mu.Lock()
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	c.mu.Unlock()
+// SendRawRequest sends a raw message (for domains that use different format).
+func (c *BaseWebSocketClient) SendRawRequest(ctx context.Context, id string, rawMsg map[string]any, timeout time.Duration) (*WSResponse, error) {
+	data, err := json.Marshal(rawMsg)
 	if err != nil {
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
 		return nil, err
 	}
-
-	select {
-	case resp := <-respCh:
-		return resp, nil
-	case <-ctx.Done():
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
-		return nil, ctx.Err()
-	case <-time.After(timeout):
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
-		return nil, fmt.Errorf("request timeout")
-	}
+	return c.roundTrip(ctx, id, data, timeout)
 }
 
 // GenerateID generates a unique message ID for a domain.
@@ -331,12 +609,13 @@ func (c *BaseWebSocketClient) UnregisterPending(id string) {
 
 // WriteMessage writes a message to the WebSocket connection.
 func (c *BaseWebSocketClient) WriteMessage(data []byte) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.conn == nil {
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	if conn == nil {
 		return fmt.Errorf("not connected")
 	}
-	return c.conn.WriteMessage(websocket.TextMessage, data)
+	return c.send(conn, data)
 }
 
 // basicAuth creates basic auth header value.
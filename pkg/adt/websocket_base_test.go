@@ -0,0 +1,264 @@
+package adt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeZADTVSP is a minimal ZADT_VSP WebSocket endpoint. Requests whose action
+// is in drop close the connection instead of being answered.
+type fakeZADTVSP struct {
+	mu          sync.Mutex
+	connections int
+	actions     []string
+	nextBP      int
+	drop        map[string]bool
+	ignorePings bool // on the first connection only
+	// setBreakpoint requests for these programs fail after the first connection
+	rejectRestore map[string]bool
+}
+
+func (f *fakeZADTVSP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	f.mu.Lock()
+	f.connections++
+	connection := f.connections
+	f.mu.Unlock()
+
+	conn.WriteJSON(map[string]any{"id": "welcome", "success": true, "data": map[string]any{"session": fmt.Sprintf("S%d", connection)}})
+
+	for {
+		var msg WSMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		f.mu.Lock()
+		f.actions = append(f.actions, msg.Action)
+		drop := f.drop[msg.Action]
+		delete(f.drop, msg.Action)
+		ignore := f.ignorePings && connection == 1
+		reject := msg.Action == "setBreakpoint" && connection > 1 && f.rejectRestore[fmt.Sprint(msg.Params["program"])]
+		var data any = map[string]any{}
+		if msg.Action == "setBreakpoint" && !reject {
+			f.nextBP++
+			data = map[string]any{"breakpointId": fmt.Sprintf("BP%d", f.nextBP), "registered": true}
+		}
+		f.mu.Unlock()
+
+		if drop {
+			return
+		}
+		if msg.Action == "ping" && ignore {
+			continue
+		}
+		if reject {
+			conn.WriteJSON(WSResponse{ID: msg.ID, Error: &WSError{Code: "NOT_FOUND", Message: "Program does not exist"}})
+			continue
+		}
+		raw, _ := json.Marshal(data)
+		conn.WriteJSON(WSResponse{ID: msg.ID, Success: true, Data: raw})
+	}
+}
+
+func (f *fakeZADTVSP) count(action string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, a := range f.actions {
+		if a == action {
+			n++
+		}
+	}
+	return n
+}
+
+func newTestDebugWSClient(t *testing.T, f *fakeZADTVSP, opts WebSocketOptions) *DebugWebSocketClient {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	c := NewDebugWebSocketClient(srv.URL, "001", "user", "pass", false)
+	c.SetOptions(opts)
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestWebSocketReconnectRestoresBreakpoints(t *testing.T) {
+	f := &fakeZADTVSP{drop: map[string]bool{"listen": true}}
+	c := newTestDebugWSClient(t, f, WebSocketOptions{MaxReconnectAttempts: 3, ReconnectBackoff: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	id, err := c.SetLineBreakpoint(ctx, "ZTEST", 10)
+	if err != nil || id != "BP1" {
+		t.Fatalf("SetLineBreakpoint = %q, %v", id, err)
+	}
+
+	// The connection drops while the request is pending
+	_, err = c.Listen(ctx, 5)
+	var lost *ConnectionLostError
+	if !errors.Is(err, ErrConnectionLost) || !errors.As(err, &lost) {
+		t.Fatalf("expected ConnectionLostError, got %v", err)
+	}
+
+	// The next request waits for the reconnect
+	if _, err := c.GetStatus(ctx); err != nil {
+		t.Fatalf("request after reconnect: %v", err)
+	}
+	if !c.IsConnected() {
+		t.Error("expected client to be connected")
+	}
+	if got := f.count("setBreakpoint"); got != 2 {
+		t.Errorf("expected breakpoint to be re-registered, setBreakpoint called %d times", got)
+	}
+
+	// The original ID still works
+	if err := c.DeleteBreakpoint(ctx, id); err != nil {
+		t.Fatalf("DeleteBreakpoint: %v", err)
+	}
+	c.mu.RLock()
+	remaining := len(c.breakpoints) + len(c.bpAliases)
+	c.mu.RUnlock()
+	if remaining != 0 {
+		t.Errorf("expected breakpoint state to be cleared, %d entries left", remaining)
+	}
+}
+
+func TestWebSocketReconnectReportsLostBreakpoints(t *testing.T) {
+	f := &fakeZADTVSP{drop: map[string]bool{"listen": true}, rejectRestore: map[string]bool{"ZGONE": true}}
+	c := newTestDebugWSClient(t, f, WebSocketOptions{MaxReconnectAttempts: 3, ReconnectBackoff: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	kept, err := c.SetLineBreakpoint(ctx, "ZTEST", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gone, err := c.SetLineBreakpoint(ctx, "ZGONE", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Listen(ctx, 5); !errors.Is(err, ErrConnectionLost) {
+		t.Fatalf("expected ConnectionLostError, got %v", err)
+	}
+	if _, err := c.GetStatus(ctx); err != nil {
+		t.Fatalf("request after reconnect: %v", err)
+	}
+
+	select {
+	case ev := <-c.Events:
+		if ev.Kind != "breakpoint_lost" || ev.Program != "ZGONE" || ev.Line != 20 || ev.Data["breakpointId"] != gone {
+			t.Errorf("event = %+v", ev)
+		}
+	default:
+		t.Fatal("expected a breakpoint_lost event")
+	}
+	lost := c.LostBreakpoints()
+	if len(lost) != 1 || lost[gone] == nil {
+		t.Fatalf("LostBreakpoints = %v", lost)
+	}
+
+	// Deleting the lost breakpoint only forgets it
+	deletes := f.count("deleteBreakpoint")
+	if err := c.DeleteBreakpoint(ctx, gone); err != nil {
+		t.Fatalf("DeleteBreakpoint(lost): %v", err)
+	}
+	if got := f.count("deleteBreakpoint"); got != deletes {
+		t.Errorf("deleteBreakpoint sent for a lost breakpoint")
+	}
+	if len(c.LostBreakpoints()) != 0 {
+		t.Error("lost breakpoint not forgotten")
+	}
+	if err := c.DeleteBreakpoint(ctx, kept); err != nil {
+		t.Fatalf("DeleteBreakpoint(restored): %v", err)
+	}
+}
+
+func TestWebSocketDebugSafety(t *testing.T) {
+	f := &fakeZADTVSP{}
+	c := newTestDebugWSClient(t, f, DefaultWebSocketOptions())
+	c.UseSafety(SafetyConfig{DisallowedOps: "B"})
+	ctx := context.Background()
+
+	if _, err := c.SetLineBreakpoint(ctx, "ZTEST", 10); err == nil {
+		t.Error("SetLineBreakpoint: expected safety error")
+	}
+	if _, err := c.Listen(ctx, 5); err == nil {
+		t.Error("Listen: expected safety error")
+	}
+	if got := f.count("setBreakpoint") + f.count("listen"); got != 0 {
+		t.Errorf("%d debug requests sent", got)
+	}
+
+	// Read-only mode does not block the debugger
+	c.UseSafety(SafetyConfig{ReadOnly: true})
+	if _, err := c.SetLineBreakpoint(ctx, "ZTEST", 10); err != nil {
+		t.Errorf("SetLineBreakpoint in read-only mode: %v", err)
+	}
+}
+
+func TestWebSocketKeepaliveDetectsDeadConnection(t *testing.T) {
+	f := &fakeZADTVSP{ignorePings: true}
+	c := newTestDebugWSClient(t, f, WebSocketOptions{
+		KeepaliveInterval:    20 * time.Millisecond,
+		PingTimeout:          50 * time.Millisecond,
+		MaxReconnectAttempts: 3,
+		ReconnectBackoff:     10 * time.Millisecond,
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		f.mu.Lock()
+		connections := f.connections
+		f.mu.Unlock()
+		if connections >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected unanswered ping to trigger a reconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := c.GetStatus(context.Background()); err != nil {
+		t.Fatalf("request after reconnect: %v", err)
+	}
+}
+
+func TestWebSocketNoReconnectAfterClose(t *testing.T) {
+	f := &fakeZADTVSP{}
+	c := newTestDebugWSClient(t, f, WebSocketOptions{MaxReconnectAttempts: 3, ReconnectBackoff: 10 * time.Millisecond})
+
+	c.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	if c.IsConnected() {
+		t.Error("expected client to stay disconnected after Close")
+	}
+	if _, err := c.GetStatus(context.Background()); err == nil {
+		t.Error("expected request to fail after Close")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.connections != 1 {
+		t.Errorf("expected no reconnect after Close, got %d connections", f.connections)
+	}
+}
@@ -31,9 +31,80 @@ func (c *DebugWebSocketClient) setBreakpointInternal(ctx context.Context, params
 		return "", err
 	}
 
+	c.mu.Lock()
+	c.breakpoints[result.BreakpointID] = params
+	c.mu.Unlock()
+
 	return result.BreakpointID, nil
 }
 
+// restoreBreakpoints re-registers the breakpoints of this session after a
+// reconnect. IDs returned earlier stay valid for DeleteBreakpoint. Breakpoints
+// that fail are kept as lost, reported as "breakpoint_lost" events and
+// retried on the next reconnect.
+func (c *DebugWebSocketClient) restoreBreakpoints(ctx context.Context) {
+	c.mu.Lock()
+	old := c.breakpoints
+	for id, lost := range c.lostBreakpoints {
+		old[id] = lost.params
+	}
+	c.breakpoints = make(map[string]map[string]any)
+	c.lostBreakpoints = make(map[string]lostBreakpoint)
+	c.mu.Unlock()
+
+	for oldID, params := range old {
+		newID, err := c.setBreakpointInternal(ctx, params)
+		if err != nil {
+			c.mu.Lock()
+			c.lostBreakpoints[oldID] = lostBreakpoint{params: params, err: err}
+			c.mu.Unlock()
+			program, _ := params["program"].(string)
+			line, _ := params["line"].(int)
+			c.emit(&DebugEvent{
+				Kind:    "breakpoint_lost",
+				Program: program,
+				Line:    line,
+				Data:    map[string]any{"breakpointId": oldID, "kind": params["kind"], "error": err.Error()},
+			})
+			continue
+		}
+		c.mu.Lock()
+		for id, current := range c.bpAliases {
+			if current == oldID {
+				c.bpAliases[id] = newID
+			}
+		}
+		c.bpAliases[oldID] = newID
+		c.mu.Unlock()
+	}
+}
+
+// LostBreakpoints returns the breakpoints that could not be re-registered
+// after a reconnect, with the reason, by every ID DeleteBreakpoint accepts.
+func (c *DebugWebSocketClient) LostBreakpoints() map[string]error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	lost := make(map[string]error, len(c.lostBreakpoints))
+	for id, bp := range c.lostBreakpoints {
+		lost[id] = bp.err
+	}
+	for alias, current := range c.bpAliases {
+		if bp, ok := c.lostBreakpoints[current]; ok {
+			lost[alias] = bp.err
+		}
+	}
+	return lost
+}
+
+// emit sends an event to Events without blocking; it is dropped if the
+// buffer is full.
+func (c *DebugWebSocketClient) emit(event *DebugEvent) {
+	select {
+	case c.Events <- event:
+	default:
+	}
+}
+
 // SetLineBreakpoint sets a breakpoint at a specific line in a program.
 // For classes, program should be in class pool format: ZCL_TEST================CP
 // The line number is pool-absolute (the line in the consolidated class source).
@@ -46,18 +117,33 @@ func (c *DebugWebSocketClient) SetLineBreakpoint(ctx context.Context, program st
 	return c.setBreakpointInternal(ctx, params)
 }
 
-// SetMethodBreakpoint sets a breakpoint at a specific line within a method.
-// For class methods, this uses include-relative line numbers (line 1 = first line of method).
-// The method name is used to resolve the correct include for the breakpoint.
-// Example: SetMethodBreakpoint(ctx, "ZCL_TEST================CP", "MY_METHOD", 5)
+// SetMethodBreakpoint sets a breakpoint at a specific line within a class method.
+// Line 1 is the METHOD statement. ZADT_VSP only takes pool-absolute lines, so
+// the line is resolved from the class structure via ADT REST first, using the
+// configuration passed to UseAuth (or the connection credentials).
+// Example: SetMethodBreakpoint(ctx, "ZCL_TEST======================CP", "MY_METHOD", 5)
+//
+// Deprecated: Use Client.MethodBreakpointLine and SetLineBreakpoint, or
+// MethodBreakpoints, which reuse an existing ADT client.
 func (c *DebugWebSocketClient) SetMethodBreakpoint(ctx context.Context, program, method string, line int) (string, error) {
-	params := map[string]any{
-		"kind":    "line",
-		"program": program,
-		"method":  method,
-		"line":    line,
+	poolLine, err := c.restClient().MethodBreakpointLine(ctx, program, method, line)
+	if err != nil {
+		return "", err
 	}
-	return c.setBreakpointInternal(ctx, params)
+	return c.SetLineBreakpoint(ctx, ClassPoolName(program), poolLine)
+}
+
+// restClient returns an ADT REST client for the system of the WebSocket.
+func (c *DebugWebSocketClient) restClient() *Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cfg := c.config
+	if cfg == nil {
+		cfg = NewConfig(c.baseURL, c.user, c.password, WithClient(c.client))
+		cfg.InsecureSkipVerify = c.insecure
+		cfg.Safety = c.safety
+	}
+	return NewClientWithTransport(cfg, NewTransport(cfg))
 }
 
 // SetStatementBreakpoint sets a breakpoint on a specific ABAP statement.
@@ -104,10 +190,25 @@ func (c *DebugWebSocketClient) GetBreakpoints(ctx context.Context) ([]map[string
 	return result.Breakpoints, nil
 }
 
-// DeleteBreakpoint removes a breakpoint by ID.
+// DeleteBreakpoint removes a breakpoint by ID. Deleting a breakpoint lost in
+// a reconnect only forgets it.
 func (c *DebugWebSocketClient) DeleteBreakpoint(ctx context.Context, breakpointID string) error {
+	c.mu.Lock()
+	current, ok := c.bpAliases[breakpointID]
+	if !ok {
+		current = breakpointID
+	}
+	_, lost := c.lostBreakpoints[current]
+	if lost {
+		c.forgetBreakpointLocked(current)
+	}
+	c.mu.Unlock()
+	if lost {
+		return nil
+	}
+
 	params := map[string]any{
-		"breakpointId": breakpointID,
+		"breakpointId": current,
 	}
 
 	resp, err := c.sendRequest(ctx, "deleteBreakpoint", params)
@@ -122,9 +223,25 @@ func (c *DebugWebSocketClient) DeleteBreakpoint(ctx context.Context, breakpointI
 		return fmt.Errorf("deleteBreakpoint failed")
 	}
 
+	c.mu.Lock()
+	c.forgetBreakpointLocked(current)
+	c.mu.Unlock()
+
 	return nil
 }
 
+// forgetBreakpointLocked drops the state of a breakpoint by its current ID.
+// c.mu must be held.
+func (c *DebugWebSocketClient) forgetBreakpointLocked(current string) {
+	delete(c.breakpoints, current)
+	delete(c.lostBreakpoints, current)
+	for id, target := range c.bpAliases {
+		if target == current {
+			delete(c.bpAliases, id)
+		}
+	}
+}
+
 // --- Debugger Session Operations ---
 
 // Listen waits for a debuggee to hit a breakpoint.
@@ -0,0 +1,305 @@
+package adt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// --- Generic Domain Access ---
+
+// DomainActionInfo describes an action of a ZADT_VSP domain. Schema is the
+// JSON schema of its params, if known.
+type DomainActionInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+}
+
+// DomainInfo describes a ZADT_VSP domain and its actions.
+type DomainInfo struct {
+	Domain  string             `json:"domain"`
+	Version string             `json:"version,omitempty"`
+	Actions []DomainActionInfo `json:"actions"`
+}
+
+// Action returns the action by name, or nil.
+func (d *DomainInfo) Action(name string) *DomainActionInfo {
+	for i := range d.Actions {
+		if d.Actions[i].Name == name {
+			return &d.Actions[i]
+		}
+	}
+	return nil
+}
+
+var (
+	domainRegistryMu sync.RWMutex
+	domainRegistry   = map[string]DomainInfo{}
+)
+
+// RegisterDomain registers the client-side description of a domain. Its
+// schemas validate DomainClient calls and complement what the handler
+// reports with system/describe. Registering a domain again replaces it.
+func RegisterDomain(info DomainInfo) {
+	domainRegistryMu.Lock()
+	defer domainRegistryMu.Unlock()
+	domainRegistry[info.Domain] = info
+}
+
+// LookupDomain returns the registered description of a domain.
+func LookupDomain(domain string) (DomainInfo, bool) {
+	domainRegistryMu.RLock()
+	defer domainRegistryMu.RUnlock()
+	info, ok := domainRegistry[domain]
+	return info, ok
+}
+
+// RegisteredDomains returns the registered domains sorted by name.
+func RegisteredDomains() []DomainInfo {
+	domainRegistryMu.RLock()
+	defer domainRegistryMu.RUnlock()
+	out := make([]DomainInfo, 0, len(domainRegistry))
+	for _, info := range domainRegistry {
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Domain < out[j].Domain })
+	return out
+}
+
+func actionInfos(names ...string) []DomainActionInfo {
+	actions := make([]DomainActionInfo, len(names))
+	for i, name := range names {
+		actions[i] = DomainActionInfo{Name: name}
+	}
+	return actions
+}
+
+// The built-in domains, as served by the embedded ZADT_VSP services.
+func init() {
+	RegisterDomain(DomainInfo{Domain: "rfc", Actions: actionInfos("call", "search", "getMetadata", "ping", "moveToPackage", "runReport")})
+	RegisterDomain(DomainInfo{Domain: "debug", Actions: actionInfos("setBreakpoint", "getBreakpoints", "deleteBreakpoint", "listen", "getDebuggees", "attach", "step", "getStack", "getVariables", "detach", "getStatus")})
+	RegisterDomain(DomainInfo{Domain: "amdp", Actions: actionInfos("start", "stop", "resume", "step", "setBreakpoint", "getVariables", "getStatus", "executeAndDebug")})
+	RegisterDomain(DomainInfo{Domain: "git", Actions: actionInfos("getTypes", "export", "import", "validate")})
+	RegisterDomain(DomainInfo{Domain: "report", Actions: actionInfos("runReport", "getTextElements", "setTextElements", "getVariants")})
+}
+
+// DomainError is an unsuccessful response of a domain action.
+type DomainError struct {
+	Domain  string
+	Action  string
+	Code    string
+	Message string
+}
+
+func (e *DomainError) Error() string {
+	return fmt.Sprintf("%s/%s: %s: %s", e.Domain, e.Action, e.Code, e.Message)
+}
+
+// DomainClient calls the actions of one ZADT_VSP domain, including custom
+// domains installed on the SAP side.
+type DomainClient struct {
+	ws      *BaseWebSocketClient
+	domain  string
+	timeout time.Duration
+}
+
+// Domain returns a client for a domain. Requests time out after 60 seconds
+// unless changed with WithTimeout.
+func (c *BaseWebSocketClient) Domain(name string) *DomainClient {
+	return &DomainClient{ws: c, domain: name, timeout: 60 * time.Second}
+}
+
+// WithTimeout returns a copy of the client with another request timeout.
+func (d *DomainClient) WithTimeout(timeout time.Duration) *DomainClient {
+	dc := *d
+	dc.timeout = timeout
+	return &dc
+}
+
+// Name returns the domain name.
+func (d *DomainClient) Name() string {
+	return d.domain
+}
+
+// Call runs an action. params is a map or a struct marshalled to a JSON
+// object; params of actions with a registered schema are validated first.
+// The response data is unmarshalled into out unless out is nil.
+func (d *DomainClient) Call(ctx context.Context, action string, params any, out any) error {
+	p, err := toParams(params)
+	if err != nil {
+		return err
+	}
+	if info, ok := LookupDomain(d.domain); ok {
+		if a := info.Action(action); a != nil && len(a.Schema) > 0 {
+			if err := ValidateSchema(a.Schema, p); err != nil {
+				return fmt.Errorf("%s/%s params: %w", d.domain, action, err)
+			}
+		}
+	}
+	data, err := d.CallRaw(ctx, action, p)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s/%s: parsing response: %w", d.domain, action, err)
+	}
+	return nil
+}
+
+// CallRaw runs an action without validation and returns the response data.
+func (d *DomainClient) CallRaw(ctx context.Context, action string, params map[string]any) (json.RawMessage, error) {
+	resp, err := d.ws.SendDomainRequest(ctx, d.domain, action, params, d.timeout)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		derr := &DomainError{Domain: d.domain, Action: action, Code: "ERROR", Message: "request failed"}
+		if resp.Error != nil {
+			derr.Code, derr.Message = resp.Error.Code, resp.Error.Message
+		}
+		return nil, derr
+	}
+	return resp.Data, nil
+}
+
+// Describe returns the actions of the domain with descriptions and schemas,
+// as reported by the handler's system/describe and completed from the
+// registered description. Handlers before 2.5.0 cannot describe domains;
+// then the handshake and the registry are used. Results are cached per
+// connection.
+func (d *DomainClient) Describe(ctx context.Context) (*DomainInfo, error) {
+	c := d.ws
+	c.mu.RLock()
+	cached, ok := c.described[d.domain]
+	h := c.handshake
+	c.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	var info DomainInfo
+	resp, err := c.SendDomainRequest(ctx, "system", "describe", map[string]any{"domain": d.domain}, d.timeout)
+	var unsupported *VSPUnsupportedError
+	switch {
+	case errors.As(err, &unsupported):
+		info = d.fallbackInfo(h)
+	case err != nil:
+		return nil, err
+	case resp.Success:
+		if err := json.Unmarshal(resp.Data, &info); err != nil {
+			return nil, fmt.Errorf("parsing description of %s: %w", d.domain, err)
+		}
+	case resp.Error != nil && resp.Error.Code == "UNKNOWN_DOMAIN" && h != nil && h.Negotiated() && h.Supports("system", "describe"):
+		return nil, &DomainError{Domain: d.domain, Action: "describe", Code: resp.Error.Code, Message: resp.Error.Message}
+	default:
+		// Older handlers route system/describe to the services and fail
+		info = d.fallbackInfo(h)
+	}
+	if info.Domain == "" {
+		return nil, &DomainError{Domain: d.domain, Action: "describe", Code: "UNKNOWN_DOMAIN", Message: fmt.Sprintf("domain %s is not installed", d.domain)}
+	}
+
+	// Fill in what the handler did not describe
+	if local, ok := LookupDomain(d.domain); ok {
+		for _, la := range local.Actions {
+			a := info.Action(la.Name)
+			if a == nil {
+				continue
+			}
+			if len(a.Schema) == 0 || string(a.Schema) == "null" {
+				a.Schema = la.Schema
+			}
+			if a.Description == "" {
+				a.Description = la.Description
+			}
+		}
+	}
+	for i := range info.Actions {
+		if string(info.Actions[i].Schema) == "null" {
+			info.Actions[i].Schema = nil
+		}
+	}
+
+	c.mu.Lock()
+	if c.handshake == h {
+		if c.described == nil {
+			c.described = make(map[string]*DomainInfo)
+		}
+		c.described[d.domain] = &info
+	}
+	c.mu.Unlock()
+	return &info, nil
+}
+
+// fallbackInfo describes the domain from the handshake and the registry.
+func (d *DomainClient) fallbackInfo(h *VSPHandshake) DomainInfo {
+	local, registered := LookupDomain(d.domain)
+	if h != nil && h.Negotiated() {
+		svc := h.Service(d.domain)
+		if svc == nil {
+			return DomainInfo{}
+		}
+		info := DomainInfo{Domain: d.domain, Version: svc.Version}
+		for _, name := range svc.Actions {
+			a := DomainActionInfo{Name: name}
+			if la := local.Action(name); la != nil {
+				a = *la
+			}
+			info.Actions = append(info.Actions, a)
+		}
+		return info
+	}
+	if registered && (h == nil || h.Supports(d.domain, "")) {
+		return local
+	}
+	return DomainInfo{}
+}
+
+// toParams converts request params to the map sent to ZADT_VSP.
+func toParams(params any) (map[string]any, error) {
+	switch p := params.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return p, nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("encoding params: %w", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("params must encode to a JSON object: %w", err)
+	}
+	return m, nil
+}
+
+// DomainCall is a typed action of a ZADT_VSP domain, for clients of custom
+// domains:
+//
+//	var readLog = adt.DomainCall[LogQuery, []LogEntry]{Domain: "bal", Action: "read"}
+//	entries, err := readLog.Do(ctx, ws, LogQuery{Object: "ZSALES"})
+type DomainCall[P, R any] struct {
+	Domain  string
+	Action  string
+	Timeout time.Duration // zero for the DomainClient default
+}
+
+// Do runs the action on the connection of c.
+func (dc DomainCall[P, R]) Do(ctx context.Context, c *BaseWebSocketClient, params P) (R, error) {
+	var result R
+	d := c.Domain(dc.Domain)
+	if dc.Timeout > 0 {
+		d = d.WithTimeout(dc.Timeout)
+	}
+	err := d.Call(ctx, dc.Action, params, &result)
+	return result, err
+}
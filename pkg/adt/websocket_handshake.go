@@ -0,0 +1,189 @@
+package adt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VSPHandshake describes the installed ZADT_VSP handler. It is sent as the
+// welcome message on connect and returned by system/hello. Handlers before
+// 2.4.0 report only session, version and domains.
+type VSPHandshake struct {
+	Session  string           `json:"session"`
+	Version  string           `json:"version"`
+	Domains  []string         `json:"domains"`
+	Services []VSPServiceInfo `json:"services,omitempty"`
+}
+
+// VSPServiceInfo is a domain service of the handler. The "system" domain is
+// served by the handler itself and carries its version.
+type VSPServiceInfo struct {
+	Domain  string   `json:"domain"`
+	Version string   `json:"version"`
+	Actions []string `json:"actions"`
+}
+
+// Negotiated reports whether the handler reported service versions and
+// actions.
+func (h *VSPHandshake) Negotiated() bool {
+	return len(h.Services) > 0
+}
+
+// Service returns the service of a domain, or nil if it was not reported.
+func (h *VSPHandshake) Service(domain string) *VSPServiceInfo {
+	for i := range h.Services {
+		if h.Services[i].Domain == domain {
+			return &h.Services[i]
+		}
+	}
+	return nil
+}
+
+// Supports reports whether the handler serves domain/action. Without
+// negotiation only the domain list, if any, is known and any action of a
+// listed domain is assumed.
+func (h *VSPHandshake) Supports(domain, action string) bool {
+	if !h.Negotiated() {
+		return len(h.Domains) == 0 || domain == "system" || slices.Contains(h.Domains, domain)
+	}
+	svc := h.Service(domain)
+	return svc != nil && slices.Contains(svc.Actions, action)
+}
+
+// VSPVersionMismatch is a domain whose installed version differs from the
+// expected one. Actual is empty when the domain is not installed.
+type VSPVersionMismatch struct {
+	Domain   string
+	Expected string
+	Actual   string
+}
+
+func (m VSPVersionMismatch) String() string {
+	if m.Actual == "" {
+		return fmt.Sprintf("%s: missing (expected %s)", m.Domain, m.Expected)
+	}
+	rel := "newer"
+	if CompareVersions(m.Actual, m.Expected) < 0 {
+		rel = "older"
+	}
+	return fmt.Sprintf("%s: %s is %s than %s", m.Domain, m.Actual, rel, m.Expected)
+}
+
+// Mismatches compares the reported versions with the expected versions by
+// domain, where "system" is the handler version. Without negotiation only
+// the handler version and the presence of domains can be compared.
+func (h *VSPHandshake) Mismatches(expected map[string]string) []VSPVersionMismatch {
+	domains := make([]string, 0, len(expected))
+	for d := range expected {
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+
+	var out []VSPVersionMismatch
+	for _, d := range domains {
+		want := expected[d]
+		var got string
+		switch {
+		case d == "system":
+			got = h.Version
+		case h.Negotiated():
+			if svc := h.Service(d); svc != nil {
+				got = svc.Version
+			}
+		case slices.Contains(h.Domains, d):
+			continue // installed, version unknown
+		}
+		if got != want {
+			out = append(out, VSPVersionMismatch{Domain: d, Expected: want, Actual: got})
+		}
+	}
+	return out
+}
+
+// VSPUnsupportedError is returned for a request the installed ZADT_VSP
+// handler does not serve according to its handshake.
+type VSPUnsupportedError struct {
+	Domain  string
+	Action  string
+	Version string // handler version
+}
+
+func (e *VSPUnsupportedError) Error() string {
+	return fmt.Sprintf("ZADT_VSP %s does not support %s/%s; redeploy it (InstallZADTVSP with upgrade=true)",
+		e.Version, e.Domain, e.Action)
+}
+
+// CompareVersions compares dotted numeric versions like "2.4.0" and returns
+// -1, 0 or 1. Missing parts count as zero and non-numeric parts compare as
+// strings.
+func CompareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y string
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		nx, errX := strconv.Atoi(defaultZero(x))
+		ny, errY := strconv.Atoi(defaultZero(y))
+		if errX != nil || errY != nil {
+			if c := strings.Compare(x, y); c != 0 {
+				return c
+			}
+			continue
+		}
+		if nx != ny {
+			if nx < ny {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func defaultZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// Handshake returns the handshake of the current connection, or nil before
+// the first connect.
+func (c *BaseWebSocketClient) Handshake() *VSPHandshake {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.handshake
+}
+
+// Hello asks the handler for its handshake again and stores the result.
+func (c *BaseWebSocketClient) Hello(ctx context.Context) (*VSPHandshake, error) {
+	resp, err := c.SendDomainRequest(ctx, "system", "hello", nil, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		if resp.Error != nil {
+			return nil, fmt.Errorf("hello failed: %s", resp.Error.Message)
+		}
+		return nil, fmt.Errorf("hello failed")
+	}
+	var h VSPHandshake
+	if err := json.Unmarshal(resp.Data, &h); err != nil {
+		return nil, fmt.Errorf("parsing handshake: %w", err)
+	}
+	c.mu.Lock()
+	c.handshake = &h
+	c.described = nil
+	c.mu.Unlock()
+	return &h, nil
+}
@@ -0,0 +1,38 @@
+package adt
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.4.0", "2.4.0", 0},
+		{"2.3.0", "2.4.0", -1},
+		{"2.10.0", "2.9.1", 1},
+		{"2.4", "2.4.0", 0},
+		{"1.0.0", "mock", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVSPHandshakeBeforeNegotiation(t *testing.T) {
+	// Welcome message of a 2.3.0 handler
+	h := &VSPHandshake{Version: "2.3.0", Domains: []string{"rfc", "debug"}}
+
+	if !h.Supports("debug", "anything") || !h.Supports("system", "ping") || h.Supports("report", "runReport") {
+		t.Error("without negotiation, all actions of listed domains should be assumed")
+	}
+
+	got := h.Mismatches(map[string]string{"system": "2.4.0", "debug": "1.0.0", "report": "1.0.0"})
+	if len(got) != 2 || got[0].Domain != "report" || got[0].Actual != "" || got[1].Domain != "system" {
+		t.Fatalf("Mismatches = %+v", got)
+	}
+	if s := got[1].String(); s != "system: 2.3.0 is older than 2.4.0" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -195,6 +195,157 @@ func (c *Client) WriteClass(ctx context.Context, className string, source string
 	return result, nil
 }
 
+// WriteTestClassesResult represents the result of writing a class's test include.
+type WriteTestClassesResult struct {
+	Success        bool              `json:"success"`
+	ClassName      string            `json:"className"`
+	ObjectURL      string            `json:"objectUrl"`
+	IncludeCreated bool              `json:"includeCreated"`
+	Appended       bool              `json:"appended,omitempty"` // added after existing test classes
+	Activation     *ActivationResult `json:"activation,omitempty"`
+	UnitTestResult *UnitTestResult   `json:"unitTestResult,omitempty"`
+	Message        string            `json:"message,omitempty"`
+}
+
+// WriteTestClasses writes test classes to the local test classes include of an
+// existing class. Existing test classes are kept: the source is appended after
+// them, and refused if it defines a class the include already has.
+// Workflow: Lock -> GetClassInclude (CreateTestInclude if missing) -> UpdateClassInclude -> Unlock -> Activate -> RunUnitTests
+func (c *Client) WriteTestClasses(ctx context.Context, className string, testSource string, transport string) (*WriteTestClassesResult, error) {
+	// Safety check for workflow operations
+	if err := c.checkSafety(OpWorkflow, "WriteTestClasses"); err != nil {
+		return nil, err
+	}
+
+	className = strings.ToUpper(className)
+	objectURL := fmt.Sprintf("/sap/bc/adt/oo/classes/%s", url.PathEscape(className))
+
+	result := &WriteTestClassesResult{
+		ClassName: className,
+		ObjectURL: objectURL,
+	}
+
+	// Step 1: Lock
+	lock, err := c.LockObject(ctx, objectURL, "MODIFY")
+	if err != nil {
+		result.Message = fmt.Sprintf("Failed to lock object: %v", err)
+		return result, nil
+	}
+
+	unlocked := false
+	defer func() {
+		if !unlocked {
+			c.UnlockObject(ctx, objectURL, lock.LockHandle)
+		}
+	}()
+
+	// Step 2: Read the test include - create it if it doesn't exist yet
+	existing, err := c.GetClassInclude(ctx, className, ClassIncludeTestClasses)
+	switch {
+	case IsNotFoundError(err):
+		if err := c.CreateTestInclude(ctx, className, lock.LockHandle, transport); err != nil {
+			result.Message = fmt.Sprintf("Failed to create test include: %v", err)
+			return result, nil
+		}
+		result.IncludeCreated = true
+	case err != nil:
+		result.Message = fmt.Sprintf("Failed to read test include: %v", err)
+		return result, nil
+	case hasABAPCode(existing):
+		if dup := duplicateLocalClasses(existing, testSource); len(dup) > 0 {
+			result.Message = fmt.Sprintf("Test include already defines %s; remove or rename the class first", strings.Join(dup, ", "))
+			return result, nil
+		}
+		testSource = strings.TrimRight(existing, " \r\n") + "\n\n" + testSource
+		result.Appended = true
+	}
+
+	// Step 3: Update test include
+	err = c.UpdateClassInclude(ctx, className, ClassIncludeTestClasses, testSource, lock.LockHandle, transport)
+	if err != nil {
+		result.Message = fmt.Sprintf("Failed to update test include: %v", err)
+		return result, nil
+	}
+
+	// Step 4: Unlock
+	unlocked = true
+	if err := c.UnlockObject(ctx, objectURL, lock.LockHandle); err != nil {
+		result.Message = fmt.Sprintf("Failed to unlock object: %v", err)
+		return result, nil
+	}
+
+	// Step 5: Activate
+	activation, err := c.Activate(ctx, objectURL, className)
+	if err != nil {
+		result.Message = fmt.Sprintf("Failed to activate: %v", err)
+		result.Activation = activation
+		return result, nil
+	}
+	result.Activation = activation
+	if !activation.Success {
+		result.Message = "Activation failed - check activation messages"
+		return result, nil
+	}
+
+	// Step 6: Run unit tests
+	flags := DefaultUnitTestFlags()
+	testResult, err := c.RunUnitTests(ctx, objectURL, &flags)
+	result.Success = true
+	if err != nil {
+		result.Message = fmt.Sprintf("Test classes activated but unit tests failed to run: %v", err)
+		return result, nil
+	}
+	result.UnitTestResult = testResult
+	result.Message = "Test classes updated, activated, and unit tests executed"
+	if result.Appended {
+		result.Message = "Test classes added after the existing ones, activated, and unit tests executed"
+	}
+
+	return result, nil
+}
+
+var localClassDefinition = regexp.MustCompile(`(?im)^\s*CLASS\s+([\w/]+)\s+DEFINITION\b(\s+(?:DEFERRED|LOAD|LOCAL\s+FRIENDS)\b)?`)
+
+// hasABAPCode reports whether source holds more than blank and comment lines,
+// such as the comment a new test include is created with.
+func hasABAPCode(source string) bool {
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "*") && !strings.HasPrefix(line, "\"") {
+			return true
+		}
+	}
+	return false
+}
+
+// localClasses returns the classes defined in source. Forward declarations
+// and LOCAL FRIENDS statements do not define a class.
+func localClasses(source string) []string {
+	var names []string
+	for _, m := range localClassDefinition.FindAllStringSubmatch(source, -1) {
+		if m[2] == "" {
+			names = append(names, strings.ToUpper(m[1]))
+		}
+	}
+	return names
+}
+
+// duplicateLocalClasses returns the classes defined in both sources.
+func duplicateLocalClasses(existing, source string) []string {
+	defined := make(map[string]bool)
+	for _, name := range localClasses(existing) {
+		defined[name] = true
+	}
+	var dup []string
+	for _, name := range localClasses(source) {
+		if defined[name] {
+			dup = append(dup, name)
+			defined[name] = false
+		}
+	}
+	return dup
+}
+
 // CreateProgramResult represents the result of creating a program.
 type CreateProgramResult struct {
 	Success      bool                `json:"success"`
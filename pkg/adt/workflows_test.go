@@ -552,3 +552,133 @@ func TestReplaceMatches_LineEndings(t *testing.T) {
 		t.Errorf("replaceMatches result = %q, want %q", result, expected)
 	}
 }
+
+// testIncludeTransport simulates the test include of ZCL_TEST. Without
+// created, reads and writes of the include fail with 404 until it has been
+// created; with created, the include holds existing.
+type testIncludeTransport struct {
+	created  bool
+	existing string
+	readErr  int // status of include reads, if set
+	written  string
+	requests []string
+}
+
+func (m *testIncludeTransport) Do(req *http.Request) (*http.Response, error) {
+	m.requests = append(m.requests, req.Method+" "+req.URL.Path)
+	resp, err := m.respond(req)
+	if resp != nil {
+		resp.Header.Set("X-CSRF-Token", "test-token")
+	}
+	return resp, err
+}
+
+func (m *testIncludeTransport) respond(req *http.Request) (*http.Response, error) {
+	path := req.URL.Path
+	switch {
+	case req.Method == http.MethodPost && strings.HasSuffix(path, "/ZCL_TEST") && req.URL.Query().Get("_action") == "LOCK":
+		return newWorkflowTestResponse(`<?xml version="1.0"?><asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values><DATA><LOCK_HANDLE>LH1</LOCK_HANDLE></DATA></asx:values></asx:abap>`), nil
+	case req.Method == http.MethodGet && strings.HasSuffix(path, "/includes/testclasses"):
+		if m.readErr != 0 {
+			return &http.Response{StatusCode: m.readErr, Body: io.NopCloser(strings.NewReader("read failed")), Header: http.Header{}}, nil
+		}
+		if !m.created {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("include missing")), Header: http.Header{}}, nil
+		}
+		return newWorkflowTestResponse(m.existing), nil
+	case req.Method == http.MethodPut && strings.HasSuffix(path, "/includes/testclasses"):
+		if !m.created {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("include missing")), Header: http.Header{}}, nil
+		}
+		body, _ := io.ReadAll(req.Body)
+		m.written = string(body)
+		return newWorkflowTestResponse(""), nil
+	case req.Method == http.MethodPost && strings.HasSuffix(path, "/ZCL_TEST/includes"):
+		m.created = true
+		return newWorkflowTestResponse(""), nil
+	case strings.Contains(path, "/abapunit/testruns"):
+		return newWorkflowTestResponse(`<?xml version="1.0"?><aunit:runResult xmlns:aunit="http://www.sap.com/adt/aunit"/>`), nil
+	}
+	return newWorkflowTestResponse(""), nil
+}
+
+func TestClient_WriteTestClasses_CreatesInclude(t *testing.T) {
+	mock := &testIncludeTransport{}
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+	result, err := client.WriteTestClasses(context.Background(), "zcl_test", "CLASS ltc_test DEFINITION FOR TESTING.\nENDCLASS.", "")
+	if err != nil {
+		t.Fatalf("WriteTestClasses failed: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("WriteTestClasses not successful: %s (requests: %v)", result.Message, mock.requests)
+	}
+	if !result.IncludeCreated {
+		t.Error("expected test include to be created")
+	}
+	if result.UnitTestResult == nil {
+		t.Error("expected unit test result")
+	}
+}
+
+func TestClient_WriteTestClasses_ExistingInclude(t *testing.T) {
+	source := "CLASS ltc_test DEFINITION DEFERRED.\nCLASS zcl_test DEFINITION LOCAL FRIENDS ltc_test.\nCLASS ltc_test DEFINITION FOR TESTING.\nENDCLASS."
+	write := func(mock *testIncludeTransport) *WriteTestClassesResult {
+		t.Helper()
+		cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+		client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+		result, err := client.WriteTestClasses(context.Background(), "ZCL_TEST", source, "")
+		if err != nil {
+			t.Fatalf("WriteTestClasses failed: %v", err)
+		}
+		return result
+	}
+
+	// Existing test classes are kept
+	existing := "CLASS ltc_old DEFINITION DEFERRED.\nCLASS zcl_test DEFINITION LOCAL FRIENDS ltc_old.\nCLASS ltc_old DEFINITION FOR TESTING.\nENDCLASS.\n"
+	mock := &testIncludeTransport{created: true, existing: existing}
+	result := write(mock)
+	if !result.Success || !result.Appended || result.IncludeCreated {
+		t.Fatalf("append: %+v", result)
+	}
+	if mock.written != strings.TrimSpace(existing)+"\n\n"+source {
+		t.Errorf("written include:\n%s", mock.written)
+	}
+
+	// The comment of a new include is replaced
+	mock = &testIncludeTransport{created: true, existing: "*\"* use this source file for your ABAP unit test classes\n"}
+	if result := write(mock); !result.Success || result.Appended || mock.written != source {
+		t.Errorf("empty include: %+v, written %q", result, mock.written)
+	}
+
+	// A class the include already defines is refused
+	mock = &testIncludeTransport{created: true, existing: "CLASS ltc_test DEFINITION FOR TESTING.\nENDCLASS.\n"}
+	if result := write(mock); result.Success || !strings.Contains(result.Message, "already defines LTC_TEST") || mock.written != "" {
+		t.Errorf("duplicate class: %+v, written %q", result, mock.written)
+	}
+
+	// Only a missing include is created
+	mock = &testIncludeTransport{readErr: http.StatusInternalServerError}
+	if result := write(mock); result.Success || result.IncludeCreated || !strings.Contains(result.Message, "Failed to read test include") {
+		t.Errorf("read error: %+v", result)
+	}
+	for _, r := range mock.requests {
+		if strings.HasSuffix(r, "/includes") {
+			t.Errorf("include created after a read error: %v", mock.requests)
+		}
+	}
+}
+
+func TestClient_WriteTestClasses_ReadOnly(t *testing.T) {
+	mock := &testIncludeTransport{}
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass", WithSafety(SafetyConfig{ReadOnly: true}))
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+	if _, err := client.WriteTestClasses(context.Background(), "ZCL_TEST", "", ""); err == nil {
+		t.Fatal("expected safety error in read-only mode")
+	}
+	if len(mock.requests) != 0 {
+		t.Errorf("expected no requests, got %v", mock.requests)
+	}
+}
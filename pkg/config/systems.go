@@ -22,6 +22,11 @@ type SystemConfig struct {
 	CookieFile   string `json:"cookie_file,omitempty"`   // Path to Netscape-format cookie file
 	CookieString string `json:"cookie_string,omitempty"` // Inline cookie string
 
+	// OAuth2 and mutual TLS authentication (alternatives to user/password)
+	ServiceKey string `json:"service_key,omitempty"` // Path to SAP BTP service key JSON (browser login)
+	ClientCert string `json:"client_cert,omitempty"` // Path to PEM client certificate
+	ClientKey  string `json:"client_key,omitempty"`  // Path to PEM private key (if not in ClientCert)
+
 	// Optional safety settings per system
 	ReadOnly        bool     `json:"read_only,omitempty"`
 	AllowedPackages []string `json:"allowed_packages,omitempty"`
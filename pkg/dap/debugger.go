@@ -0,0 +1,141 @@
+package dap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// Debugger is the ABAP debugger API the adapter drives.
+type Debugger interface {
+	// Listen waits up to timeout seconds for a debuggee; it returns nil on timeout.
+	Listen(ctx context.Context, timeout int) (*adt.Debuggee, error)
+	Attach(ctx context.Context, debuggeeID string) (*adt.DebugAttachResult, error)
+	Detach(ctx context.Context) error
+	Step(ctx context.Context, stepType adt.DebugStepType) (*adt.DebugStepResult, error)
+	Stack(ctx context.Context) (*adt.DebugStackInfo, error)
+	GoToStack(ctx context.Context, stackURI string) error
+	ChildVariables(ctx context.Context, parentIDs []string) (*adt.DebugChildVariablesInfo, error)
+	Variables(ctx context.Context, ids []string) ([]adt.DebugVariable, error)
+	SetVariable(ctx context.Context, id, value string) error
+
+	SetLineBreakpoint(ctx context.Context, program string, line int) (string, error)
+	SetStatementBreakpoint(ctx context.Context, statement string) (string, error)
+	SetExceptionBreakpoint(ctx context.Context, exception string) (string, error)
+	DeleteBreakpoint(ctx context.Context, id string) error
+
+	// Source returns the source of an include (or of program when include is empty).
+	Source(ctx context.Context, program, include string) (string, error)
+	// RunReport starts a report in another session so that it can hit breakpoints.
+	RunReport(ctx context.Context, program, variant string) error
+}
+
+// ADTDebugger implements Debugger with the ADT REST debugger API for the
+// session and the ZADT_VSP WebSocket for breakpoints and report execution.
+type ADTDebugger struct {
+	client *adt.Client
+	ws     *adt.DebugWebSocketClient
+	user   string
+}
+
+// NewADTDebugger creates a Debugger for user. ws may be nil, in which case
+// breakpoints and RunReport are unavailable.
+func NewADTDebugger(client *adt.Client, ws *adt.DebugWebSocketClient, user string) *ADTDebugger {
+	return &ADTDebugger{client: client, ws: ws, user: user}
+}
+
+func (d *ADTDebugger) Listen(ctx context.Context, timeout int) (*adt.Debuggee, error) {
+	result, err := d.client.DebuggerListen(ctx, &adt.ListenOptions{
+		DebuggingMode:  adt.DebuggingModeUser,
+		User:           d.user,
+		TimeoutSeconds: timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Conflict != nil {
+		return nil, fmt.Errorf("listener conflict: %s (user: %s)", result.Conflict.ConflictText, result.Conflict.IdeUser)
+	}
+	if result.TimedOut {
+		return nil, nil
+	}
+	return result.Debuggee, nil
+}
+
+func (d *ADTDebugger) Attach(ctx context.Context, debuggeeID string) (*adt.DebugAttachResult, error) {
+	return d.client.DebuggerAttach(ctx, debuggeeID, d.user)
+}
+
+func (d *ADTDebugger) Detach(ctx context.Context) error {
+	return d.client.DebuggerDetach(ctx)
+}
+
+func (d *ADTDebugger) Step(ctx context.Context, stepType adt.DebugStepType) (*adt.DebugStepResult, error) {
+	return d.client.DebuggerStep(ctx, stepType, "")
+}
+
+func (d *ADTDebugger) Stack(ctx context.Context) (*adt.DebugStackInfo, error) {
+	return d.client.DebuggerGetStack(ctx, true)
+}
+
+func (d *ADTDebugger) GoToStack(ctx context.Context, stackURI string) error {
+	return d.client.DebuggerGoToStack(ctx, stackURI)
+}
+
+func (d *ADTDebugger) ChildVariables(ctx context.Context, parentIDs []string) (*adt.DebugChildVariablesInfo, error) {
+	return d.client.DebuggerGetChildVariables(ctx, parentIDs)
+}
+
+func (d *ADTDebugger) Variables(ctx context.Context, ids []string) ([]adt.DebugVariable, error) {
+	return d.client.DebuggerGetVariables(ctx, ids)
+}
+
+func (d *ADTDebugger) SetVariable(ctx context.Context, id, value string) error {
+	_, err := d.client.DebuggerSetVariableValue(ctx, id, value)
+	return err
+}
+
+func (d *ADTDebugger) SetLineBreakpoint(ctx context.Context, program string, line int) (string, error) {
+	if d.ws == nil {
+		return "", errNoWebSocket
+	}
+	return d.ws.SetLineBreakpoint(ctx, program, line)
+}
+
+func (d *ADTDebugger) SetStatementBreakpoint(ctx context.Context, statement string) (string, error) {
+	if d.ws == nil {
+		return "", errNoWebSocket
+	}
+	return d.ws.SetStatementBreakpoint(ctx, statement)
+}
+
+func (d *ADTDebugger) SetExceptionBreakpoint(ctx context.Context, exception string) (string, error) {
+	if d.ws == nil {
+		return "", errNoWebSocket
+	}
+	return d.ws.SetExceptionBreakpoint(ctx, exception)
+}
+
+func (d *ADTDebugger) DeleteBreakpoint(ctx context.Context, id string) error {
+	if d.ws == nil {
+		return errNoWebSocket
+	}
+	return d.ws.DeleteBreakpoint(ctx, id)
+}
+
+func (d *ADTDebugger) Source(ctx context.Context, program, include string) (string, error) {
+	if include != "" && include != program {
+		return d.client.GetInclude(ctx, include)
+	}
+	return d.client.GetProgram(ctx, program)
+}
+
+func (d *ADTDebugger) RunReport(ctx context.Context, program, variant string) error {
+	if d.ws == nil {
+		return errNoWebSocket
+	}
+	return d.ws.RunReport(ctx, program, variant)
+}
+
+var errNoWebSocket = fmt.Errorf("ZADT_VSP WebSocket not connected (required for breakpoints and running programs)")
@@ -0,0 +1,230 @@
+// Package dap implements a Debug Adapter Protocol server for the ABAP debugger.
+//
+// The adapter speaks DAP (https://microsoft.github.io/debug-adapter-protocol/)
+// over any byte stream (stdio or TCP) and maps requests onto the ADT debugger
+// API, so that VS Code, Neovim and other DAP clients can debug ABAP.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// --- Wire format ---
+
+// Request is a client-to-adapter request.
+type Request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// Response answers a Request.
+type Response struct {
+	Seq        int         `json:"seq"`
+	Type       string      `json:"type"`
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+// Event is an adapter-to-client notification.
+type Event struct {
+	Seq   int         `json:"seq"`
+	Type  string      `json:"type"`
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+// ReadMessage reads one Content-Length framed message.
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// WriteMessage writes one Content-Length framed message.
+func WriteMessage(w io.Writer, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// conn numbers and serializes outgoing messages.
+type conn struct {
+	mu  sync.Mutex
+	w   io.Writer
+	seq int
+}
+
+func (c *conn) respond(req *Request, body interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	return WriteMessage(c.w, &Response{
+		Seq: c.seq, Type: "response", RequestSeq: req.Seq,
+		Success: true, Command: req.Command, Body: body,
+	})
+}
+
+func (c *conn) fail(req *Request, format string, args ...interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	return WriteMessage(c.w, &Response{
+		Seq: c.seq, Type: "response", RequestSeq: req.Seq,
+		Success: false, Command: req.Command, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *conn) event(name string, body interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	return WriteMessage(c.w, &Event{Seq: c.seq, Type: "event", Event: name, Body: body})
+}
+
+// --- Protocol types (the subset used by this adapter) ---
+
+// Capabilities is the body of the initialize response.
+type Capabilities struct {
+	SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest"`
+	SupportsFunctionBreakpoints      bool `json:"supportsFunctionBreakpoints"`
+	SupportsSetVariable              bool `json:"supportsSetVariable"`
+	SupportsEvaluateForHovers        bool `json:"supportsEvaluateForHovers"`
+	SupportTerminateDebuggee         bool `json:"supportTerminateDebuggee"`
+}
+
+// Source identifies a source file or an adapter-provided source.
+type Source struct {
+	Name            string `json:"name,omitempty"`
+	Path            string `json:"path,omitempty"`
+	SourceReference int    `json:"sourceReference,omitempty"`
+}
+
+// SourceBreakpoint is a requested line breakpoint.
+type SourceBreakpoint struct {
+	Line int `json:"line"`
+}
+
+// FunctionBreakpoint is a requested breakpoint by name.
+type FunctionBreakpoint struct {
+	Name string `json:"name"`
+}
+
+// Breakpoint is the adapter's view of a requested breakpoint.
+type Breakpoint struct {
+	ID       int     `json:"id,omitempty"`
+	Verified bool    `json:"verified"`
+	Message  string  `json:"message,omitempty"`
+	Source   *Source `json:"source,omitempty"`
+	Line     int     `json:"line,omitempty"`
+}
+
+// Thread is a debuggee.
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// StackFrame is one call stack entry.
+type StackFrame struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Source *Source `json:"source,omitempty"`
+	Line   int     `json:"line"`
+	Column int     `json:"column"`
+}
+
+// Scope is a named variable container of a stack frame.
+type Scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+// Variable is a name/value pair, expandable when VariablesReference > 0.
+type Variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	EvaluateName       string `json:"evaluateName,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+	IndexedVariables   int    `json:"indexedVariables,omitempty"`
+}
+
+type launchArguments struct {
+	User    string `json:"user"`
+	Timeout int    `json:"timeout"`
+	Program string `json:"program"`
+	Variant string `json:"variant"`
+}
+
+type setBreakpointsArguments struct {
+	Source      Source             `json:"source"`
+	Breakpoints []SourceBreakpoint `json:"breakpoints"`
+	Lines       []int              `json:"lines"`
+}
+
+type setFunctionBreakpointsArguments struct {
+	Breakpoints []FunctionBreakpoint `json:"breakpoints"`
+}
+
+type stackTraceArguments struct {
+	ThreadID   int `json:"threadId"`
+	StartFrame int `json:"startFrame"`
+	Levels     int `json:"levels"`
+}
+
+type scopesArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+type variablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+type setVariableArguments struct {
+	VariablesReference int    `json:"variablesReference"`
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+}
+
+type evaluateArguments struct {
+	Expression string `json:"expression"`
+	FrameID    int    `json:"frameId"`
+}
+
+type sourceArguments struct {
+	Source          *Source `json:"source"`
+	SourceReference int     `json:"sourceReference"`
+}
+
+type disconnectArguments struct {
+	TerminateDebuggee bool `json:"terminateDebuggee"`
+}
@@ -0,0 +1,815 @@
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// threadID is the DAP thread of the attached debuggee. ADT attaches to one
+// debuggee at a time, so the adapter exposes at most one thread.
+const threadID = 1
+
+// maxTableRows caps the rows fetched when expanding an internal table.
+const maxTableRows = 100
+
+// listenRetryDelay is the pause after a failed listen before retrying.
+var listenRetryDelay = 2 * time.Second
+
+// Config configures a Server.
+type Config struct {
+	// ListenTimeout is the listener long-poll timeout in seconds (default: 60).
+	ListenTimeout int
+}
+
+// Server is a debug adapter for one client connection.
+//
+// After configurationDone the adapter listens for debuggees of the user;
+// when one hits a breakpoint it attaches and reports a stopped thread. When
+// the debuggee finishes, the adapter resumes listening for the next one.
+type Server struct {
+	dbg Debugger
+	cfg Config
+	c   *conn
+	ctx context.Context
+	wg  sync.WaitGroup
+
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+
+	mu         sync.Mutex
+	launch     launchArguments
+	listening  bool
+	debuggee   *adt.Debuggee
+	frames     map[int]adt.DebugStackEntry // frame ID -> stack entry
+	selected   int                         // frame whose variables ADT currently serves
+	handles    map[int]varHandle           // variablesReference -> container
+	nextHandle int
+	lineBPs    map[string][]string // program -> ADT breakpoint IDs
+	funcBPs    []string            // ADT breakpoint IDs from setFunctionBreakpoints
+	bpSeq      int
+	paths      map[string]string // program -> local file path from setBreakpoints
+	sources    map[int]sourceRef // sourceReference -> include
+	sourceIDs  map[string]int    // include -> sourceReference
+}
+
+// varHandle is an expandable variable container.
+type varHandle struct {
+	id         string            // ADT variable ID (@ROOT for the Locals scope)
+	tableLines int               // row count when the container is an internal table
+	names      map[string]string // child name -> ADT variable ID, filled by variables
+}
+
+type sourceRef struct {
+	program string
+	include string
+}
+
+// NewServer creates a debug adapter backed by dbg.
+func NewServer(dbg Debugger, cfg Config) *Server {
+	if cfg.ListenTimeout <= 0 {
+		cfg.ListenTimeout = 60
+	}
+	return &Server{
+		dbg:       dbg,
+		cfg:       cfg,
+		frames:    make(map[int]adt.DebugStackEntry),
+		handles:   make(map[int]varHandle),
+		lineBPs:   make(map[string][]string),
+		paths:     make(map[string]string),
+		sources:   make(map[int]sourceRef),
+		sourceIDs: make(map[string]int),
+	}
+}
+
+// Serve handles DAP requests from r until the client disconnects or r is closed.
+// On exit it detaches from the debuggee and deletes the breakpoints it created.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.c = &conn{w: w}
+	defer s.shutdown()
+
+	br := bufio.NewReader(r)
+	for {
+		data, err := ReadMessage(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) || s.ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		var req Request
+		if err := json.Unmarshal(data, &req); err != nil || req.Type != "request" {
+			continue
+		}
+
+		done, err := s.dispatch(&req)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// dispatch handles one request. It reports done when the session is over.
+func (s *Server) dispatch(req *Request) (done bool, err error) {
+	switch req.Command {
+	case "initialize":
+		if err := s.c.respond(req, &Capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsFunctionBreakpoints:      true,
+			SupportsSetVariable:              true,
+			SupportsEvaluateForHovers:        true,
+		}); err != nil {
+			return false, err
+		}
+		return false, s.c.event("initialized", nil)
+	case "launch", "attach":
+		return false, s.onLaunch(req)
+	case "setBreakpoints":
+		return false, s.onSetBreakpoints(req)
+	case "setFunctionBreakpoints":
+		return false, s.onSetFunctionBreakpoints(req)
+	case "setExceptionBreakpoints":
+		return false, s.c.respond(req, nil)
+	case "configurationDone":
+		return false, s.onConfigurationDone(req)
+	case "threads":
+		return false, s.onThreads(req)
+	case "stackTrace":
+		return false, s.onStackTrace(req)
+	case "scopes":
+		return false, s.onScopes(req)
+	case "variables":
+		return false, s.onVariables(req)
+	case "setVariable":
+		return false, s.onSetVariable(req)
+	case "evaluate":
+		return false, s.onEvaluate(req)
+	case "source":
+		return false, s.onSource(req)
+	case "continue":
+		return false, s.onStep(req, adt.DebugStepContinue, "breakpoint")
+	case "next":
+		return false, s.onStep(req, adt.DebugStepOver, "step")
+	case "stepIn":
+		return false, s.onStep(req, adt.DebugStepInto, "step")
+	case "stepOut":
+		return false, s.onStep(req, adt.DebugStepReturn, "step")
+	case "pause":
+		return false, s.c.fail(req, "pause is not supported by the ABAP debugger; set a breakpoint instead")
+	case "disconnect", "terminate":
+		s.shutdown()
+		if err := s.c.respond(req, nil); err != nil {
+			return true, err
+		}
+		if req.Command == "terminate" {
+			return true, s.c.event("terminated", nil)
+		}
+		return true, nil
+	default:
+		return false, s.c.fail(req, "unsupported request: %s", req.Command)
+	}
+}
+
+func (s *Server) onLaunch(req *Request) error {
+	var args launchArguments
+	if len(req.Arguments) > 0 {
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return s.c.fail(req, "invalid %s arguments: %v", req.Command, err)
+		}
+	}
+	if req.Command == "launch" && args.Program == "" {
+		return s.c.fail(req, "launch requires 'program' (use 'attach' to wait for any debuggee)")
+	}
+	args.Program = strings.ToUpper(args.Program)
+	args.Variant = strings.ToUpper(args.Variant)
+	if args.Timeout > 0 {
+		s.cfg.ListenTimeout = args.Timeout
+	}
+
+	s.mu.Lock()
+	s.launch = args
+	s.mu.Unlock()
+	return s.c.respond(req, nil)
+}
+
+func (s *Server) onSetBreakpoints(req *Request) error {
+	var args setBreakpointsArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return s.c.fail(req, "invalid setBreakpoints arguments: %v", err)
+	}
+
+	s.mu.Lock()
+	program := programFromSource(args.Source)
+	if ref, ok := s.sources[args.Source.SourceReference]; ok {
+		program = ref.include
+	}
+	old := s.lineBPs[program]
+	s.mu.Unlock()
+	if program == "" {
+		return s.c.fail(req, "cannot derive an ABAP program name from source %q", args.Source.Name)
+	}
+
+	for _, id := range old {
+		_ = s.dbg.DeleteBreakpoint(s.ctx, id)
+	}
+
+	lines := args.Lines
+	if len(args.Breakpoints) > 0 {
+		lines = lines[:0]
+		for _, bp := range args.Breakpoints {
+			lines = append(lines, bp.Line)
+		}
+	}
+
+	var ids []string
+	result := make([]Breakpoint, 0, len(lines))
+	for _, line := range lines {
+		bp := Breakpoint{Line: line, Source: &args.Source}
+		id, err := s.dbg.SetLineBreakpoint(s.ctx, program, line)
+		if err != nil {
+			bp.Message = err.Error()
+		} else {
+			ids = append(ids, id)
+			bp.Verified = true
+			bp.ID = s.nextBreakpointID()
+		}
+		result = append(result, bp)
+	}
+
+	s.mu.Lock()
+	s.lineBPs[program] = ids
+	if args.Source.Path != "" {
+		s.paths[program] = args.Source.Path
+	}
+	s.mu.Unlock()
+
+	return s.c.respond(req, map[string]interface{}{"breakpoints": result})
+}
+
+func (s *Server) onSetFunctionBreakpoints(req *Request) error {
+	var args setFunctionBreakpointsArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return s.c.fail(req, "invalid setFunctionBreakpoints arguments: %v", err)
+	}
+
+	s.mu.Lock()
+	old := s.funcBPs
+	s.funcBPs = nil
+	s.mu.Unlock()
+	for _, id := range old {
+		_ = s.dbg.DeleteBreakpoint(s.ctx, id)
+	}
+
+	var ids []string
+	result := make([]Breakpoint, 0, len(args.Breakpoints))
+	for _, fb := range args.Breakpoints {
+		name := strings.ToUpper(strings.TrimSpace(fb.Name))
+		var id string
+		var err error
+		if isExceptionClass(name) {
+			id, err = s.dbg.SetExceptionBreakpoint(s.ctx, name)
+		} else {
+			id, err = s.dbg.SetStatementBreakpoint(s.ctx, name)
+		}
+		bp := Breakpoint{}
+		if err != nil {
+			bp.Message = err.Error()
+		} else {
+			ids = append(ids, id)
+			bp.Verified = true
+			bp.ID = s.nextBreakpointID()
+		}
+		result = append(result, bp)
+	}
+
+	s.mu.Lock()
+	s.funcBPs = ids
+	s.mu.Unlock()
+	return s.c.respond(req, map[string]interface{}{"breakpoints": result})
+}
+
+func (s *Server) onConfigurationDone(req *Request) error {
+	if err := s.c.respond(req, nil); err != nil {
+		return err
+	}
+	s.startListening()
+
+	s.mu.Lock()
+	launch := s.launch
+	s.mu.Unlock()
+	if launch.Program != "" {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			// Give the listener a moment to register before the report runs.
+			time.Sleep(100 * time.Millisecond)
+			s.output("console", fmt.Sprintf("Running %s...\n", launch.Program))
+			if err := s.dbg.RunReport(s.ctx, launch.Program, launch.Variant); err != nil {
+				s.output("stderr", fmt.Sprintf("Failed to run %s: %v\n", launch.Program, err))
+			}
+		}()
+	}
+	return nil
+}
+
+func (s *Server) onThreads(req *Request) error {
+	s.mu.Lock()
+	debuggee := s.debuggee
+	s.mu.Unlock()
+
+	threads := []Thread{}
+	if debuggee != nil {
+		threads = append(threads, Thread{ID: threadID, Name: fmt.Sprintf("%s (%s)", debuggee.Program, debuggee.User)})
+	}
+	return s.c.respond(req, map[string]interface{}{"threads": threads})
+}
+
+func (s *Server) onStackTrace(req *Request) error {
+	var args stackTraceArguments
+	_ = json.Unmarshal(req.Arguments, &args)
+	if !s.attached() {
+		return s.c.fail(req, "not attached to a debuggee")
+	}
+
+	info, err := s.dbg.Stack(s.ctx)
+	if err != nil {
+		return s.c.fail(req, "get stack: %v", err)
+	}
+
+	s.mu.Lock()
+	s.frames = make(map[int]adt.DebugStackEntry)
+	frames := make([]StackFrame, 0, len(info.Stack))
+	for i, entry := range info.Stack {
+		id := i + 1
+		s.frames[id] = entry
+		if entry.StackPosition == info.DebugCursorStackIndex || (i == 0 && s.selected == 0) {
+			s.selected = id
+		}
+		name := entry.ProgramName
+		if entry.EventName != "" {
+			name = fmt.Sprintf("%s (%s)", entry.EventName, entry.ProgramName)
+		}
+		frames = append(frames, StackFrame{ID: id, Name: name, Source: s.frameSource(entry), Line: entry.Line, Column: 1})
+	}
+	s.mu.Unlock()
+
+	total := len(frames)
+	if args.StartFrame > 0 && args.StartFrame < len(frames) {
+		frames = frames[args.StartFrame:]
+	} else if args.StartFrame >= len(frames) {
+		frames = frames[:0]
+	}
+	if args.Levels > 0 && args.Levels < len(frames) {
+		frames = frames[:args.Levels]
+	}
+	return s.c.respond(req, map[string]interface{}{"stackFrames": frames, "totalFrames": total})
+}
+
+func (s *Server) onScopes(req *Request) error {
+	var args scopesArguments
+	_ = json.Unmarshal(req.Arguments, &args)
+
+	s.mu.Lock()
+	entry, ok := s.frames[args.FrameID]
+	switchFrame := ok && args.FrameID != s.selected
+	s.mu.Unlock()
+	if !ok {
+		return s.c.fail(req, "unknown frame %d", args.FrameID)
+	}
+
+	if switchFrame {
+		uri := entry.StackURI
+		if uri == "" {
+			uri = entry.URI
+		}
+		if err := s.dbg.GoToStack(s.ctx, uri); err != nil {
+			return s.c.fail(req, "select frame: %v", err)
+		}
+		s.mu.Lock()
+		s.selected = args.FrameID
+		s.handles = make(map[int]varHandle)
+		s.mu.Unlock()
+	}
+
+	ref := s.newHandle(varHandle{id: "@ROOT"})
+	return s.c.respond(req, map[string]interface{}{
+		"scopes": []Scope{{Name: "Locals", VariablesReference: ref}},
+	})
+}
+
+func (s *Server) onVariables(req *Request) error {
+	var args variablesArguments
+	_ = json.Unmarshal(req.Arguments, &args)
+
+	s.mu.Lock()
+	h, ok := s.handles[args.VariablesReference]
+	s.mu.Unlock()
+	if !ok {
+		return s.c.fail(req, "unknown variablesReference %d", args.VariablesReference)
+	}
+
+	children, err := s.children(h)
+	if err != nil {
+		return s.c.fail(req, "get variables: %v", err)
+	}
+
+	names := make(map[string]string, len(children))
+	vars := make([]Variable, 0, len(children))
+	for _, v := range children {
+		names[v.Name] = v.ID
+		vars = append(vars, s.toVariable(v))
+	}
+
+	s.mu.Lock()
+	h.names = names
+	s.handles[args.VariablesReference] = h
+	s.mu.Unlock()
+	return s.c.respond(req, map[string]interface{}{"variables": vars})
+}
+
+// children fetches the child variables of a container. Internal tables
+// without child entries are expanded row by row (ID[1], ID[2], ...).
+func (s *Server) children(h varHandle) ([]adt.DebugVariable, error) {
+	info, err := s.dbg.ChildVariables(s.ctx, []string{h.id})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []adt.DebugVariable
+	if len(info.Hierarchies) > 0 {
+		ids := make(map[string]bool)
+		for _, hier := range info.Hierarchies {
+			if hier.ParentID == h.id {
+				ids[hier.ChildID] = true
+			}
+		}
+		for _, v := range info.Variables {
+			if ids[v.ID] {
+				result = append(result, v)
+			}
+		}
+	} else {
+		result = info.Variables
+	}
+
+	if len(result) == 0 && h.tableLines > 0 {
+		n := h.tableLines
+		if n > maxTableRows {
+			n = maxTableRows
+		}
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("%s[%d]", h.id, i+1)
+		}
+		return s.dbg.Variables(s.ctx, ids)
+	}
+	return result, nil
+}
+
+func (s *Server) onSetVariable(req *Request) error {
+	var args setVariableArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return s.c.fail(req, "invalid setVariable arguments: %v", err)
+	}
+
+	s.mu.Lock()
+	id := s.handles[args.VariablesReference].names[args.Name]
+	s.mu.Unlock()
+	if id == "" {
+		id = strings.ToUpper(args.Name)
+	}
+
+	if err := s.dbg.SetVariable(s.ctx, id, args.Value); err != nil {
+		return s.c.fail(req, "set %s: %v", args.Name, err)
+	}
+
+	vars, err := s.dbg.Variables(s.ctx, []string{id})
+	if err != nil || len(vars) == 0 {
+		return s.c.respond(req, map[string]interface{}{"value": args.Value})
+	}
+	v := s.toVariable(vars[0])
+	return s.c.respond(req, map[string]interface{}{
+		"value": v.Value, "type": v.Type, "variablesReference": v.VariablesReference,
+	})
+}
+
+func (s *Server) onEvaluate(req *Request) error {
+	var args evaluateArguments
+	_ = json.Unmarshal(req.Arguments, &args)
+	if !s.attached() {
+		return s.c.fail(req, "not attached to a debuggee")
+	}
+
+	name := strings.ToUpper(strings.TrimSpace(args.Expression))
+	if name == "" {
+		return s.c.fail(req, "empty expression")
+	}
+	vars, err := s.dbg.Variables(s.ctx, []string{name})
+	if err != nil {
+		return s.c.fail(req, "evaluate %s: %v", args.Expression, err)
+	}
+	if len(vars) == 0 {
+		return s.c.fail(req, "%s not found (only variable names can be evaluated)", args.Expression)
+	}
+	v := s.toVariable(vars[0])
+	return s.c.respond(req, map[string]interface{}{
+		"result": v.Value, "type": v.Type, "variablesReference": v.VariablesReference,
+	})
+}
+
+func (s *Server) onSource(req *Request) error {
+	var args sourceArguments
+	_ = json.Unmarshal(req.Arguments, &args)
+	ref := args.SourceReference
+	if args.Source != nil && args.Source.SourceReference > 0 {
+		ref = args.Source.SourceReference
+	}
+
+	s.mu.Lock()
+	src, ok := s.sources[ref]
+	s.mu.Unlock()
+	if !ok {
+		return s.c.fail(req, "unknown sourceReference %d", ref)
+	}
+
+	content, err := s.dbg.Source(s.ctx, src.program, src.include)
+	if err != nil {
+		return s.c.fail(req, "get source of %s: %v", src.include, err)
+	}
+	return s.c.respond(req, map[string]interface{}{"content": content, "mimeType": "text/x-abap"})
+}
+
+// onStep acknowledges a stepping request and performs the step in the
+// background, reporting the outcome with a stopped or thread exited event.
+func (s *Server) onStep(req *Request, stepType adt.DebugStepType, reason string) error {
+	if !s.attached() {
+		return s.c.fail(req, "not attached to a debuggee")
+	}
+	var body interface{}
+	if stepType == adt.DebugStepContinue {
+		body = map[string]interface{}{"allThreadsContinued": true}
+	}
+	if err := s.c.respond(req, body); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.frames = make(map[int]adt.DebugStackEntry)
+	s.handles = make(map[int]varHandle)
+	s.selected = 0
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		result, err := s.dbg.Step(s.ctx, stepType)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.output("stderr", fmt.Sprintf("Step failed: %v\n", err))
+			s.debuggeeEnded()
+			return
+		}
+		if !result.IsSteppingPossible {
+			s.debuggeeEnded()
+			return
+		}
+		if len(result.ReachedBreakpoints) > 0 {
+			reason = "breakpoint"
+		}
+		_ = s.c.event("stopped", map[string]interface{}{
+			"reason": reason, "threadId": threadID, "allThreadsStopped": true,
+		})
+	}()
+	return nil
+}
+
+// startListening starts the background listener unless it is already
+// running or a debuggee is attached.
+func (s *Server) startListening() {
+	s.mu.Lock()
+	if s.listening || s.debuggee != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.listening = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.listen()
+	}()
+}
+
+// listen waits for a debuggee, attaches to it and reports it as stopped.
+func (s *Server) listen() {
+	defer func() {
+		s.mu.Lock()
+		s.listening = false
+		s.mu.Unlock()
+	}()
+
+	s.output("console", "Waiting for a debuggee to hit a breakpoint...\n")
+	for s.ctx.Err() == nil {
+		debuggee, err := s.dbg.Listen(s.ctx, s.cfg.ListenTimeout)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.output("stderr", fmt.Sprintf("Listen failed: %v\n", err))
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(listenRetryDelay):
+			}
+			continue
+		}
+		if debuggee == nil {
+			continue // timeout - poll again
+		}
+
+		if _, err := s.dbg.Attach(s.ctx, debuggee.ID); err != nil {
+			s.output("stderr", fmt.Sprintf("Attach to %s failed: %v\n", debuggee.Program, err))
+			continue
+		}
+
+		s.mu.Lock()
+		s.debuggee = debuggee
+		s.mu.Unlock()
+
+		_ = s.c.event("thread", map[string]interface{}{"reason": "started", "threadId": threadID})
+		_ = s.c.event("stopped", map[string]interface{}{
+			"reason":            "breakpoint",
+			"description":       fmt.Sprintf("Stopped in %s at line %d", debuggee.Program, debuggee.Line),
+			"threadId":          threadID,
+			"allThreadsStopped": true,
+		})
+		return
+	}
+}
+
+// debuggeeEnded reports the debuggee as finished and listens for the next one.
+func (s *Server) debuggeeEnded() {
+	s.mu.Lock()
+	s.debuggee = nil
+	s.mu.Unlock()
+
+	_ = s.c.event("thread", map[string]interface{}{"reason": "exited", "threadId": threadID})
+	s.output("console", "Debuggee finished.\n")
+	if s.ctx.Err() == nil {
+		s.startListening()
+	}
+}
+
+// shutdown stops background work, detaches and removes the breakpoints
+// created by this session. It is safe to call more than once.
+func (s *Server) shutdown() {
+	s.stopOnce.Do(func() {
+		s.cancel()
+		s.wg.Wait()
+		s.cleanup()
+	})
+}
+
+// cleanup detaches and removes the breakpoints created by this session.
+func (s *Server) cleanup() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s.mu.Lock()
+	attached := s.debuggee != nil
+	s.debuggee = nil
+	var ids []string
+	for _, bps := range s.lineBPs {
+		ids = append(ids, bps...)
+	}
+	ids = append(ids, s.funcBPs...)
+	s.lineBPs = make(map[string][]string)
+	s.funcBPs = nil
+	s.mu.Unlock()
+
+	if attached {
+		_ = s.dbg.Detach(ctx)
+	}
+	for _, id := range ids {
+		_ = s.dbg.DeleteBreakpoint(ctx, id)
+	}
+}
+
+// --- Helpers ---
+
+func (s *Server) attached() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.debuggee != nil
+}
+
+func (s *Server) output(category, text string) {
+	_ = s.c.event("output", map[string]interface{}{"category": category, "output": text})
+}
+
+func (s *Server) nextBreakpointID() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bpSeq++
+	return s.bpSeq
+}
+
+func (s *Server) newHandle(h varHandle) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextHandle++
+	s.handles[s.nextHandle] = h
+	return s.nextHandle
+}
+
+// frameSource returns the local file for a frame when setBreakpoints named
+// one for its include, otherwise an adapter-provided source. Caller holds s.mu.
+func (s *Server) frameSource(entry adt.DebugStackEntry) *Source {
+	include := entry.IncludeName
+	if include == "" {
+		include = entry.ProgramName
+	}
+	if path, ok := s.paths[include]; ok {
+		return &Source{Name: filepath.Base(path), Path: path}
+	}
+	ref, ok := s.sourceIDs[include]
+	if !ok {
+		ref = len(s.sources) + 1
+		s.sources[ref] = sourceRef{program: entry.ProgramName, include: include}
+		s.sourceIDs[include] = ref
+	}
+	return &Source{Name: include, SourceReference: ref}
+}
+
+// toVariable converts an ADT variable, allocating a handle for complex types.
+func (s *Server) toVariable(v adt.DebugVariable) Variable {
+	typeName := v.DeclaredTypeName
+	if typeName == "" {
+		typeName = string(v.MetaType)
+	}
+	out := Variable{Name: v.Name, Value: displayValue(v), Type: typeName, EvaluateName: v.ID}
+	if v.IsComplexType() {
+		out.VariablesReference = s.newHandle(varHandle{id: v.ID, tableLines: v.TableLines})
+		if v.MetaType == adt.DebugMetaTypeTable {
+			out.IndexedVariables = v.TableLines
+		}
+	}
+	return out
+}
+
+// displayValue renders a variable value the way the CLI debugger shows it.
+func displayValue(v adt.DebugVariable) string {
+	switch v.MetaType {
+	case adt.DebugMetaTypeTable:
+		return fmt.Sprintf("<%d rows>", v.TableLines)
+	case adt.DebugMetaTypeStructure:
+		if v.Value == "" {
+			return "{...}"
+		}
+	}
+	value := v.Value
+	if v.IsValueIncomplete {
+		value += "..."
+	}
+	return value
+}
+
+// programFromSource derives the ABAP program from a DAP source. abapGit file
+// names are understood: ztest.prog.abap -> ZTEST, zcl_foo.clas.abap -> the
+// class pool ZCL_FOO=======================CP, and "#" stands for "/".
+func programFromSource(src Source) string {
+	name := src.Name
+	if src.Path != "" {
+		name = filepath.Base(src.Path)
+	}
+	parts := strings.Split(strings.ToLower(name), ".")
+	program := strings.ToUpper(strings.ReplaceAll(parts[0], "#", "/"))
+	if len(parts) == 3 && parts[1] == "clas" && parts[2] == "abap" {
+		return adt.ClassPoolName(program)
+	}
+	return program
+}
+
+// isExceptionClass reports whether a function breakpoint name is an exception
+// class (CX_*, ZCX_*, YCX_*, /NS/CX_*) rather than an ABAP statement.
+func isExceptionClass(name string) bool {
+	return strings.HasPrefix(name, "CX_") || strings.HasPrefix(name, "ZCX_") ||
+		strings.HasPrefix(name, "YCX_") || strings.Contains(name, "/CX_")
+}
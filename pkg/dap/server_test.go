@@ -0,0 +1,486 @@
+package dap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// fakeDebugger simulates one debuggee stopped in ZTEST.
+type fakeDebugger struct {
+	mu       sync.Mutex
+	caught   bool
+	detached bool
+	bps      map[string]string // ID -> "PROGRAM:LINE" or statement/exception
+	deleted  []string
+	steps    []adt.DebugStepType
+	values   map[string]string
+	gotoURIs []string
+	ran      string
+}
+
+func newFakeDebugger() *fakeDebugger {
+	return &fakeDebugger{
+		bps:    make(map[string]string),
+		values: map[string]string{"LV_COUNT": "1"},
+	}
+}
+
+func (f *fakeDebugger) Listen(ctx context.Context, timeout int) (*adt.Debuggee, error) {
+	f.mu.Lock()
+	caught := f.caught
+	f.caught = true
+	f.mu.Unlock()
+	if caught {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &adt.Debuggee{ID: "DBG1", Program: "ZTEST", User: "DEVELOPER", Line: 10}, nil
+}
+
+func (f *fakeDebugger) Attach(ctx context.Context, id string) (*adt.DebugAttachResult, error) {
+	return &adt.DebugAttachResult{}, nil
+}
+
+func (f *fakeDebugger) Detach(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.detached = true
+	return nil
+}
+
+func (f *fakeDebugger) Step(ctx context.Context, stepType adt.DebugStepType) (*adt.DebugStepResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.steps = append(f.steps, stepType)
+	res := &adt.DebugStepResult{}
+	res.IsSteppingPossible = stepType != adt.DebugStepContinue
+	return res, nil
+}
+
+func (f *fakeDebugger) Stack(ctx context.Context) (*adt.DebugStackInfo, error) {
+	return &adt.DebugStackInfo{
+		DebugCursorStackIndex: 2,
+		Stack: []adt.DebugStackEntry{
+			{StackPosition: 2, ProgramName: "ZTEST", IncludeName: "ZTEST", Line: 11, EventName: "START-OF-SELECTION", StackURI: "/stack/2"},
+			{StackPosition: 1, ProgramName: "ZCL_FOO=======================CP", IncludeName: "ZCL_FOO=======================CM001", Line: 3, EventName: "RUN", StackURI: "/stack/1"},
+		},
+	}, nil
+}
+
+func (f *fakeDebugger) GoToStack(ctx context.Context, uri string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gotoURIs = append(f.gotoURIs, uri)
+	return nil
+}
+
+func (f *fakeDebugger) ChildVariables(ctx context.Context, parentIDs []string) (*adt.DebugChildVariablesInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch parentIDs[0] {
+	case "@ROOT":
+		return &adt.DebugChildVariablesInfo{
+			Hierarchies: []adt.DebugVariableHierarchy{
+				{ParentID: "@ROOT", ChildID: "LV_COUNT"},
+				{ParentID: "@ROOT", ChildID: "LS_DATA"},
+				{ParentID: "@ROOT", ChildID: "LT_ITEMS"},
+			},
+			Variables: []adt.DebugVariable{
+				{ID: "LV_COUNT", Name: "LV_COUNT", DeclaredTypeName: "I", MetaType: adt.DebugMetaTypeSimple, Value: f.values["LV_COUNT"]},
+				{ID: "LS_DATA", Name: "LS_DATA", DeclaredTypeName: "ZS_DATA", MetaType: adt.DebugMetaTypeStructure},
+				{ID: "LT_ITEMS", Name: "LT_ITEMS", DeclaredTypeName: "ZT_ITEMS", MetaType: adt.DebugMetaTypeTable, TableLines: 2},
+				{ID: "SY-SUBRC", Name: "SY-SUBRC", MetaType: adt.DebugMetaTypeSimple, Value: "0"},
+			},
+		}, nil
+	case "LS_DATA":
+		return &adt.DebugChildVariablesInfo{Variables: []adt.DebugVariable{
+			{ID: "LS_DATA-NAME", Name: "NAME", DeclaredTypeName: "STRING", MetaType: adt.DebugMetaTypeString, Value: "abc"},
+		}}, nil
+	}
+	return &adt.DebugChildVariablesInfo{}, nil
+}
+
+func (f *fakeDebugger) Variables(ctx context.Context, ids []string) ([]adt.DebugVariable, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result []adt.DebugVariable
+	for _, id := range ids {
+		if strings.HasPrefix(id, "LT_ITEMS[") {
+			result = append(result, adt.DebugVariable{ID: id, Name: strings.TrimPrefix(id, "LT_ITEMS"), MetaType: adt.DebugMetaTypeSimple, Value: "row"})
+		} else if v, ok := f.values[id]; ok {
+			result = append(result, adt.DebugVariable{ID: id, Name: id, DeclaredTypeName: "I", MetaType: adt.DebugMetaTypeSimple, Value: v})
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeDebugger) SetVariable(ctx context.Context, id, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[id] = value
+	return nil
+}
+
+func (f *fakeDebugger) setBP(desc string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := fmt.Sprintf("BP%d", len(f.bps)+len(f.deleted)+1)
+	f.bps[id] = desc
+	return id, nil
+}
+
+func (f *fakeDebugger) SetLineBreakpoint(ctx context.Context, program string, line int) (string, error) {
+	return f.setBP(fmt.Sprintf("%s:%d", program, line))
+}
+
+func (f *fakeDebugger) SetStatementBreakpoint(ctx context.Context, statement string) (string, error) {
+	return f.setBP("statement:" + statement)
+}
+
+func (f *fakeDebugger) SetExceptionBreakpoint(ctx context.Context, exception string) (string, error) {
+	return f.setBP("exception:" + exception)
+}
+
+func (f *fakeDebugger) DeleteBreakpoint(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.bps, id)
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func (f *fakeDebugger) Source(ctx context.Context, program, include string) (string, error) {
+	return "* source of " + include, nil
+}
+
+func (f *fakeDebugger) RunReport(ctx context.Context, program, variant string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ran = program
+	return nil
+}
+
+// testClient drives a Server over in-memory pipes.
+type testClient struct {
+	t      *testing.T
+	w      io.Writer
+	msgs   chan map[string]interface{}
+	seq    int
+	done   chan error
+	closer io.Closer
+}
+
+func startServer(t *testing.T, dbg Debugger) *testClient {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	c := &testClient{t: t, w: clientW, msgs: make(chan map[string]interface{}, 100), done: make(chan error, 1), closer: clientW}
+	go func() {
+		c.done <- NewServer(dbg, Config{}).Serve(context.Background(), serverR, serverW)
+		serverW.Close()
+	}()
+	go func() {
+		br := bufio.NewReader(clientR)
+		for {
+			data, err := ReadMessage(br)
+			if err != nil {
+				close(c.msgs)
+				return
+			}
+			var m map[string]interface{}
+			_ = json.Unmarshal(data, &m)
+			c.msgs <- m
+		}
+	}()
+	return c
+}
+
+// request sends a request and returns its response, collecting events seen before it.
+func (c *testClient) request(command string, args interface{}) (map[string]interface{}, []map[string]interface{}) {
+	c.t.Helper()
+	c.seq++
+	req := map[string]interface{}{"seq": c.seq, "type": "request", "command": command}
+	if args != nil {
+		req["arguments"] = args
+	}
+	if err := WriteMessage(c.w, req); err != nil {
+		c.t.Fatalf("write %s: %v", command, err)
+	}
+	var events []map[string]interface{}
+	for {
+		m := c.next()
+		if m["type"] == "response" && m["request_seq"] == float64(c.seq) {
+			return m, events
+		}
+		events = append(events, m)
+	}
+}
+
+// waitEvent returns the next event with the given name.
+func (c *testClient) waitEvent(name string) map[string]interface{} {
+	c.t.Helper()
+	for {
+		m := c.next()
+		if m["type"] == "event" && m["event"] == name {
+			return m
+		}
+	}
+}
+
+func (c *testClient) next() map[string]interface{} {
+	c.t.Helper()
+	select {
+	case m, ok := <-c.msgs:
+		if !ok {
+			c.t.Fatal("connection closed")
+		}
+		return m
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timeout waiting for message")
+	}
+	return nil
+}
+
+func body(m map[string]interface{}) map[string]interface{} {
+	b, _ := m["body"].(map[string]interface{})
+	return b
+}
+
+func TestServerSession(t *testing.T) {
+	dbg := newFakeDebugger()
+	c := startServer(t, dbg)
+
+	resp, _ := c.request("initialize", map[string]interface{}{"adapterID": "abap"})
+	if resp["success"] != true || body(resp)["supportsSetVariable"] != true {
+		t.Fatalf("initialize: %v", resp)
+	}
+	c.waitEvent("initialized")
+
+	if resp, _ := c.request("attach", map[string]interface{}{"timeout": 5}); resp["success"] != true {
+		t.Fatalf("attach: %v", resp)
+	}
+
+	resp, _ = c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": "/src/ztest.prog.abap"},
+		"breakpoints": []map[string]interface{}{{"line": 10}, {"line": 20}},
+	})
+	bps := body(resp)["breakpoints"].([]interface{})
+	if len(bps) != 2 || bps[0].(map[string]interface{})["verified"] != true {
+		t.Fatalf("setBreakpoints: %v", resp)
+	}
+	resp, _ = c.request("setFunctionBreakpoints", map[string]interface{}{
+		"breakpoints": []map[string]interface{}{{"name": "cx_sy_zerodivide"}, {"name": "CALL FUNCTION"}},
+	})
+	if len(body(resp)["breakpoints"].([]interface{})) != 2 {
+		t.Fatalf("setFunctionBreakpoints: %v", resp)
+	}
+	dbg.mu.Lock()
+	want := map[string]bool{"ZTEST:10": true, "ZTEST:20": true, "exception:CX_SY_ZERODIVIDE": true, "statement:CALL FUNCTION": true}
+	for _, desc := range dbg.bps {
+		delete(want, desc)
+	}
+	dbg.mu.Unlock()
+	if len(want) != 0 {
+		t.Errorf("breakpoints not set: %v", want)
+	}
+
+	c.request("configurationDone", nil)
+	stopped := c.waitEvent("stopped")
+	if body(stopped)["reason"] != "breakpoint" {
+		t.Errorf("stopped: %v", stopped)
+	}
+
+	resp, _ = c.request("threads", nil)
+	threads := body(resp)["threads"].([]interface{})
+	if len(threads) != 1 || !strings.Contains(threads[0].(map[string]interface{})["name"].(string), "ZTEST") {
+		t.Fatalf("threads: %v", resp)
+	}
+
+	resp, _ = c.request("stackTrace", map[string]interface{}{"threadId": 1})
+	frames := body(resp)["stackFrames"].([]interface{})
+	if len(frames) != 2 {
+		t.Fatalf("stackTrace: %v", resp)
+	}
+	top := frames[0].(map[string]interface{})
+	if top["line"] != float64(11) || top["source"].(map[string]interface{})["path"] != "/src/ztest.prog.abap" {
+		t.Errorf("top frame should map to the local file: %v", top)
+	}
+	classFrame := frames[1].(map[string]interface{})
+	ref := classFrame["source"].(map[string]interface{})["sourceReference"]
+	if ref == nil {
+		t.Fatalf("class frame should have a sourceReference: %v", classFrame)
+	}
+	resp, _ = c.request("source", map[string]interface{}{"sourceReference": ref})
+	if body(resp)["content"] != "* source of ZCL_FOO=======================CM001" {
+		t.Errorf("source: %v", resp)
+	}
+
+	resp, _ = c.request("scopes", map[string]interface{}{"frameId": top["id"]})
+	scopes := body(resp)["scopes"].([]interface{})
+	locals := scopes[0].(map[string]interface{})["variablesReference"]
+
+	resp, _ = c.request("variables", map[string]interface{}{"variablesReference": locals})
+	vars := map[string]map[string]interface{}{}
+	for _, v := range body(resp)["variables"].([]interface{}) {
+		m := v.(map[string]interface{})
+		vars[m["name"].(string)] = m
+	}
+	if len(vars) != 3 {
+		t.Fatalf("expected the 3 children of @ROOT, got %v", vars)
+	}
+	if vars["LV_COUNT"]["value"] != "1" || vars["LV_COUNT"]["variablesReference"] != float64(0) {
+		t.Errorf("LV_COUNT: %v", vars["LV_COUNT"])
+	}
+	if vars["LT_ITEMS"]["value"] != "<2 rows>" {
+		t.Errorf("LT_ITEMS: %v", vars["LT_ITEMS"])
+	}
+
+	resp, _ = c.request("variables", map[string]interface{}{"variablesReference": vars["LS_DATA"]["variablesReference"]})
+	if fields := body(resp)["variables"].([]interface{}); len(fields) != 1 || fields[0].(map[string]interface{})["value"] != "abc" {
+		t.Errorf("LS_DATA children: %v", resp)
+	}
+	resp, _ = c.request("variables", map[string]interface{}{"variablesReference": vars["LT_ITEMS"]["variablesReference"]})
+	if rows := body(resp)["variables"].([]interface{}); len(rows) != 2 || rows[1].(map[string]interface{})["name"] != "[2]" {
+		t.Errorf("LT_ITEMS rows: %v", resp)
+	}
+
+	resp, _ = c.request("setVariable", map[string]interface{}{"variablesReference": locals, "name": "LV_COUNT", "value": "42"})
+	if body(resp)["value"] != "42" {
+		t.Errorf("setVariable: %v", resp)
+	}
+	resp, _ = c.request("evaluate", map[string]interface{}{"expression": "lv_count"})
+	if body(resp)["result"] != "42" {
+		t.Errorf("evaluate: %v", resp)
+	}
+
+	// Selecting another frame moves the ADT stack cursor.
+	c.request("scopes", map[string]interface{}{"frameId": classFrame["id"]})
+	dbg.mu.Lock()
+	gotos := append([]string(nil), dbg.gotoURIs...)
+	dbg.mu.Unlock()
+	if len(gotos) != 1 || gotos[0] != "/stack/1" {
+		t.Errorf("expected GoToStack(/stack/1), got %v", gotos)
+	}
+
+	c.request("next", map[string]interface{}{"threadId": 1})
+	if body(c.waitEvent("stopped"))["reason"] != "step" {
+		t.Error("expected stopped(step) after next")
+	}
+
+	// The fake debuggee ends on continue.
+	c.request("continue", map[string]interface{}{"threadId": 1})
+	exited := c.waitEvent("thread")
+	if body(exited)["reason"] != "exited" {
+		t.Errorf("expected thread exited, got %v", exited)
+	}
+
+	if resp, _ := c.request("pause", map[string]interface{}{"threadId": 1}); resp["success"] != false {
+		t.Errorf("pause should be unsupported: %v", resp)
+	}
+
+	c.request("disconnect", nil)
+	c.closer.Close()
+	if err := <-c.done; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	dbg.mu.Lock()
+	defer dbg.mu.Unlock()
+	if len(dbg.bps) != 0 {
+		t.Errorf("breakpoints not deleted on disconnect: %v", dbg.bps)
+	}
+	if !reflectSteps(dbg.steps, adt.DebugStepOver, adt.DebugStepContinue) {
+		t.Errorf("unexpected steps: %v", dbg.steps)
+	}
+}
+
+func reflectSteps(got []adt.DebugStepType, want ...adt.DebugStepType) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestServerLaunchRunsProgram(t *testing.T) {
+	dbg := newFakeDebugger()
+	c := startServer(t, dbg)
+
+	c.request("initialize", nil)
+	if resp, _ := c.request("launch", map[string]interface{}{}); resp["success"] != false {
+		t.Errorf("launch without program should fail: %v", resp)
+	}
+	c.request("launch", map[string]interface{}{"program": "ztest"})
+	c.request("configurationDone", nil)
+	c.waitEvent("stopped")
+
+	// The report is started in the background after the listener.
+	var ran string
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline) && ran == ""; time.Sleep(10 * time.Millisecond) {
+		dbg.mu.Lock()
+		ran = dbg.ran
+		dbg.mu.Unlock()
+	}
+	if ran != "ZTEST" {
+		t.Errorf("expected ZTEST to run, got %q", ran)
+	}
+
+	c.request("disconnect", nil)
+	<-c.done
+	dbg.mu.Lock()
+	defer dbg.mu.Unlock()
+	if !dbg.detached {
+		t.Error("expected detach on disconnect")
+	}
+}
+
+func TestProgramFromSource(t *testing.T) {
+	tests := []struct {
+		src  Source
+		want string
+	}{
+		{Source{Path: "/repo/src/ztest.prog.abap"}, "ZTEST"},
+		{Source{Path: "/repo/src/zcl_foo.clas.abap"}, "ZCL_FOO=======================CP"},
+		{Source{Path: "/repo/src/#ns#cl_bar.clas.abap"}, "/NS/CL_BAR====================CP"},
+		{Source{Path: "/repo/src/ztest_top.prog.abap"}, "ZTEST_TOP"},
+		{Source{Name: "ZREPORT"}, "ZREPORT"},
+	}
+	for _, tt := range tests {
+		if got := programFromSource(tt.src); got != tt.want {
+			t.Errorf("programFromSource(%+v) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestMessageFraming(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, map[string]string{"a": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "Content-Length: 9\r\n\r\n") {
+		t.Errorf("unexpected framing: %q", buf.String())
+	}
+	data, err := ReadMessage(bufio.NewReader(&buf))
+	if err != nil || string(data) != `{"a":"b"}` {
+		t.Errorf("ReadMessage = %q, %v", data, err)
+	}
+
+	if _, err := ReadMessage(bufio.NewReader(strings.NewReader("Content-Type: x\r\n\r\n"))); err == nil {
+		t.Error("expected error for missing Content-Length")
+	}
+}
@@ -2,7 +2,12 @@ package dsl
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
 )
 
 func TestSearchBuilder(t *testing.T) {
@@ -221,6 +226,7 @@ func TestTestRunner(t *testing.T) {
 			{ObjectRef{Type: "PROG", Name: "ZTEST"}, "/sap/bc/adt/programs/programs/ZTEST"},
 			{ObjectRef{Type: "PROG/P", Name: "ZTEST"}, "/sap/bc/adt/programs/programs/ZTEST"},
 			{ObjectRef{Type: "INTF", Name: "ZIF_TEST"}, "/sap/bc/adt/oo/interfaces/ZIF_TEST"},
+			{ObjectRef{Type: "FUNC", Name: "Z_FM", Package: "ZPKG", Group: "ZFG"}, "/sap/bc/adt/functions/groups/ZFG/fmodules/Z_FM"},
 			{ObjectRef{Type: "UNKNOWN", Name: "TEST"}, ""},
 		}
 
@@ -459,3 +465,158 @@ func TestObjectRef(t *testing.T) {
 		t.Errorf("expected name 'ZCL_TEST', got '%s'", obj.Name)
 	}
 }
+
+func TestImpactAnalyzer(t *testing.T) {
+	// ZIF_PRICING <- ZCL_PRICING <- ZCL_ORDER, ZREPORT
+	graph := map[string][]ObjectRef{
+		"INTF:ZIF_PRICING": {{Type: "CLAS/OC", Name: "zcl_pricing"}},
+		"CLAS:ZCL_PRICING": {{Type: "CLAS/OC", Name: "ZCL_ORDER"}, {Type: "PROG/P", Name: "ZREPORT"}},
+		"CLAS:ZCL_ORDER":   {{Type: "CLAS/OC", Name: "ZCL_PRICING"}}, // cycle
+	}
+	withTests := map[string]bool{
+		"CLAS:ZCL_ORDER": true,
+		"PROG:ZREPORT":   true,
+	}
+
+	newAnalyzer := func() *ImpactAnalyzer {
+		a := Impact(nil)
+		a.dependents = func(ctx context.Context, obj ObjectRef) ([]ObjectRef, error) {
+			return graph[objectKey(obj)], nil
+		}
+		a.hasTests = func(ctx context.Context, obj ObjectRef) (bool, error) {
+			return withTests[objectKey(obj)], nil
+		}
+		return a
+	}
+
+	t.Run("TransitiveCallers", func(t *testing.T) {
+		result, err := newAnalyzer().Analyze(context.Background(), ObjectRef{Type: TypeInterface, Name: "ZIF_PRICING"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.Visited) != 4 {
+			t.Errorf("expected 4 visited objects, got %d: %v", len(result.Visited), result.Visited)
+		}
+		if len(result.TestTargets) != 2 {
+			t.Fatalf("expected 2 test targets, got %v", result.TestTargets)
+		}
+		if result.TestTargets[0].Name != "ZCL_ORDER" || result.TestTargets[1].Name != "ZREPORT" {
+			t.Errorf("unexpected test targets: %v", result.TestTargets)
+		}
+	})
+
+	t.Run("MaxDepth", func(t *testing.T) {
+		result, err := newAnalyzer().MaxDepth(1).Analyze(context.Background(), ObjectRef{Type: TypeInterface, Name: "ZIF_PRICING"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.TestTargets) != 0 {
+			t.Errorf("expected no test targets at depth 1, got %v", result.TestTargets)
+		}
+	})
+
+	t.Run("ChangedObjectWithTests", func(t *testing.T) {
+		result, err := newAnalyzer().Analyze(context.Background(), ObjectRef{Type: "CLAS/OC", Name: "zcl_order"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.TestTargets) != 2 {
+			t.Errorf("expected ZCL_ORDER and ZREPORT, got %v", result.TestTargets)
+		}
+	})
+
+	t.Run("AllLookupsFail", func(t *testing.T) {
+		a := newAnalyzer()
+		a.dependents = func(ctx context.Context, obj ObjectRef) ([]ObjectRef, error) {
+			return nil, context.DeadlineExceeded
+		}
+		if _, err := a.Analyze(context.Background(), ObjectRef{Type: TypeClass, Name: "ZCL_X"}); err == nil {
+			t.Error("expected error when every lookup fails")
+		}
+	})
+
+	t.Run("TestIncludeLookup", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := strings.ToLower(r.URL.Path)
+			switch {
+			case strings.Contains(path, "/zcl_tested/"):
+				w.Write([]byte("CLASS ltc_test DEFINITION FOR TESTING.\nENDCLASS."))
+			case strings.Contains(path, "/zcl_denied/"):
+				http.Error(w, "no authorization", http.StatusForbidden)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer srv.Close()
+
+		a := Impact(adt.NewClient(srv.URL, "user", "pass"))
+		a.dependents = func(ctx context.Context, obj ObjectRef) ([]ObjectRef, error) {
+			return []ObjectRef{{Type: TypeClass, Name: "ZCL_TESTED"}, {Type: TypeClass, Name: "ZCL_DENIED"}}, nil
+		}
+		result, err := a.Analyze(context.Background(), ObjectRef{Type: TypeClass, Name: "ZCL_UNTESTED"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.TestTargets) != 1 || result.TestTargets[0].Name != "ZCL_TESTED" {
+			t.Errorf("unexpected test targets: %v", result.TestTargets)
+		}
+		// A missing include means no tests; other failures are reported
+		if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "ZCL_DENIED") {
+			t.Errorf("unexpected warnings: %v", result.Warnings)
+		}
+	})
+}
+
+func TestDedupeObjects(t *testing.T) {
+	got := DedupeObjects([]ObjectRef{
+		{Type: TypeClass, Name: "ZCL_ORDER"},
+		{Type: "CLAS/OC", Name: "zcl_order"},
+		{Type: TypeProgram, Name: "ZCL_ORDER"},
+	})
+	if len(got) != 2 || got[0].Name != "ZCL_ORDER" || got[1].Type != TypeProgram {
+		t.Errorf("DedupeObjects = %v", got)
+	}
+}
+
+func TestObjectFromURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want ObjectRef
+	}{
+		{"/sap/bc/adt/oo/classes/zcl_order/includes/testclasses#start=10,2", ObjectRef{Type: TypeClass, Name: "ZCL_ORDER"}},
+		{"/sap/bc/adt/oo/classes/%2fui5%2fcl_repo/source/main", ObjectRef{Type: TypeClass, Name: "/UI5/CL_REPO"}},
+		{"/sap/bc/adt/programs/programs/zreport", ObjectRef{Type: TypeProgram, Name: "ZREPORT"}},
+		{"/sap/bc/adt/functions/groups/zfg/fmodules/z_fm/source/main", ObjectRef{Type: TypeFunction, Name: "Z_FM", Group: "ZFG"}},
+		{"/sap/bc/adt/ddic/tables/ztab", ObjectRef{}},
+	}
+	for _, tt := range tests {
+		if got := objectFromURI(tt.uri); got != tt.want {
+			t.Errorf("objectFromURI(%q) = %+v, want %+v", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestParseObjectRef(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ObjectRef
+		wantErr bool
+	}{
+		{"CLAS:zcl_order", ObjectRef{Type: TypeClass, Name: "ZCL_ORDER"}, false},
+		{"zcl_order", ObjectRef{Type: TypeClass, Name: "ZCL_ORDER"}, false},
+		{"PROG/P:ZREPORT", ObjectRef{Type: TypeProgram, Name: "ZREPORT"}, false},
+		{"FUNC:ZFG/Z_FM", ObjectRef{Type: TypeFunction, Name: "Z_FM", Group: "ZFG"}, false},
+		{"FUNC:Z_FM", ObjectRef{}, true},
+		{"", ObjectRef{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseObjectRef(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseObjectRef(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseObjectRef(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
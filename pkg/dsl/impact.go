@@ -0,0 +1,370 @@
+package dsl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// ImpactAnalyzer finds the test targets affected by a set of changed objects.
+// It walks the caller graph (GetCallersOf) and the where-used list
+// (FindReferences) upwards from each changed object and keeps every class
+// and program on the way that has ABAP Unit test classes.
+type ImpactAnalyzer struct {
+	client   *adt.Client
+	maxDepth int
+
+	// Lookups, replaceable for testing.
+	dependents func(ctx context.Context, obj ObjectRef) ([]ObjectRef, error)
+	hasTests   func(ctx context.Context, obj ObjectRef) (bool, error)
+}
+
+// ImpactResult contains the outcome of an impact analysis.
+type ImpactResult struct {
+	Changed     []ObjectRef `json:"changed"`
+	Visited     []ObjectRef `json:"visited"`     // All objects reached by the walk (including changed)
+	TestTargets []ObjectRef `json:"testTargets"` // Visited classes/programs with test classes
+	Warnings    []string    `json:"warnings,omitempty"`
+}
+
+// Impact creates a new impact analyzer.
+func Impact(client *adt.Client) *ImpactAnalyzer {
+	a := &ImpactAnalyzer{
+		client:   client,
+		maxDepth: 3,
+	}
+	a.dependents = a.fetchDependents
+	a.hasTests = a.fetchHasTests
+	return a
+}
+
+// MaxDepth sets how many levels of callers/users are followed (default 3).
+func (a *ImpactAnalyzer) MaxDepth(n int) *ImpactAnalyzer {
+	if n > 0 {
+		a.maxDepth = n
+	}
+	return a
+}
+
+// Analyze walks from the changed objects to every object that (transitively)
+// uses them and returns the ones that carry unit tests.
+func (a *ImpactAnalyzer) Analyze(ctx context.Context, changed ...ObjectRef) (*ImpactResult, error) {
+	result := &ImpactResult{Changed: changed}
+
+	visited := make(map[string]bool)
+	var frontier []ObjectRef
+	for _, obj := range changed {
+		obj = normalizeObjectRef(obj)
+		key := objectKey(obj)
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+		result.Visited = append(result.Visited, obj)
+		frontier = append(frontier, obj)
+	}
+
+	failures := 0
+	lookups := 0
+	for depth := 0; depth < a.maxDepth && len(frontier) > 0; depth++ {
+		var next []ObjectRef
+		for _, obj := range frontier {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+
+			lookups++
+			deps, err := a.dependents(ctx, obj)
+			if err != nil {
+				failures++
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s %s: %v", obj.Type, obj.Name, err))
+				continue
+			}
+			for _, dep := range deps {
+				dep = normalizeObjectRef(dep)
+				key := objectKey(dep)
+				if dep.Name == "" || visited[key] {
+					continue
+				}
+				visited[key] = true
+				result.Visited = append(result.Visited, dep)
+				next = append(next, dep)
+			}
+		}
+		frontier = next
+	}
+
+	if lookups > 0 && failures == lookups {
+		return nil, fmt.Errorf("impact analysis failed for all objects: %s", strings.Join(result.Warnings, "; "))
+	}
+
+	for _, obj := range result.Visited {
+		if !isTestableType(obj.Type) {
+			continue
+		}
+		ok, err := a.hasTests(ctx, obj)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("checking tests for %s %s: %v", obj.Type, obj.Name, err))
+			continue
+		}
+		if ok {
+			result.TestTargets = append(result.TestTargets, obj)
+		}
+	}
+
+	sort.Slice(result.TestTargets, func(i, j int) bool {
+		return objectKey(result.TestTargets[i]) < objectKey(result.TestTargets[j])
+	})
+
+	return result, nil
+}
+
+// fetchDependents returns the direct callers and users of an object.
+func (a *ImpactAnalyzer) fetchDependents(ctx context.Context, obj ObjectRef) ([]ObjectRef, error) {
+	objectURL := buildImpactURL(obj)
+	if objectURL == "" {
+		return nil, fmt.Errorf("unable to determine object URL")
+	}
+
+	var deps []ObjectRef
+	var errs []string
+
+	callers, err := a.client.GetCallersOf(ctx, objectURL, 1)
+	if err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		deps = append(deps, callGraphObjects(callers)...)
+	}
+
+	refs, err := a.client.FindReferences(ctx, objectURL, 0, 0)
+	if err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		for _, ref := range refs {
+			if !ref.IsResult {
+				continue
+			}
+			if dep := objectFromURI(ref.URI); dep.Name != "" {
+				deps = append(deps, dep)
+			} else if ref.Name != "" {
+				deps = append(deps, ObjectRef{Type: baseType(ref.Type), Name: ref.Name, Package: ref.PackageName})
+			}
+		}
+	}
+
+	if len(errs) == 2 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return deps, nil
+}
+
+// fetchHasTests checks whether a class or program contains test classes.
+// Classes are checked via their testclasses include, programs via their main source.
+func (a *ImpactAnalyzer) fetchHasTests(ctx context.Context, obj ObjectRef) (bool, error) {
+	var source string
+	var err error
+	switch obj.Type {
+	case TypeClass:
+		source, err = a.client.GetClassInclude(ctx, obj.Name, adt.ClassIncludeTestClasses)
+		if adt.IsNotFoundError(err) {
+			// Classes without a test include return 404
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	case TypeProgram:
+		source, err = a.client.GetProgram(ctx, obj.Name)
+		if err != nil {
+			return false, err
+		}
+	default:
+		return false, nil
+	}
+	return strings.Contains(strings.ToUpper(source), "FOR TESTING"), nil
+}
+
+// callGraphObjects returns all nodes below the root of a call graph.
+func callGraphObjects(root *adt.CallGraphNode) []ObjectRef {
+	if root == nil {
+		return nil
+	}
+	var objects []ObjectRef
+	var walk func(node *adt.CallGraphNode)
+	walk = func(node *adt.CallGraphNode) {
+		for i := range node.Children {
+			child := &node.Children[i]
+			if obj := objectFromURI(child.URI); obj.Name != "" {
+				objects = append(objects, obj)
+			} else if child.Name != "" {
+				objects = append(objects, ObjectRef{Type: baseType(child.Type), Name: child.Name})
+			}
+			walk(child)
+		}
+	}
+	walk(root)
+	return objects
+}
+
+// objectFromURI maps an ADT URI (possibly pointing into an include or
+// method) to the main object it belongs to.
+func objectFromURI(uri string) ObjectRef {
+	if i := strings.IndexAny(uri, "#?"); i >= 0 {
+		uri = uri[:i]
+	}
+	parts := strings.Split(strings.TrimPrefix(uri, "/sap/bc/adt/"), "/")
+	segment := func(i int) string {
+		if i >= len(parts) {
+			return ""
+		}
+		s, err := url.PathUnescape(parts[i])
+		if err != nil {
+			s = parts[i]
+		}
+		return strings.ToUpper(s)
+	}
+
+	switch {
+	case len(parts) >= 3 && parts[0] == "oo" && parts[1] == "classes":
+		return ObjectRef{Type: TypeClass, Name: segment(2)}
+	case len(parts) >= 3 && parts[0] == "oo" && parts[1] == "interfaces":
+		return ObjectRef{Type: TypeInterface, Name: segment(2)}
+	case len(parts) >= 3 && parts[0] == "programs" && parts[1] == "programs":
+		return ObjectRef{Type: TypeProgram, Name: segment(2)}
+	case len(parts) >= 3 && parts[0] == "programs" && parts[1] == "includes":
+		return ObjectRef{Type: "INCL", Name: segment(2)}
+	case len(parts) >= 5 && parts[0] == "functions" && parts[1] == "groups" && parts[3] == "fmodules":
+		return ObjectRef{Type: TypeFunction, Name: segment(4), Group: segment(2)}
+	case len(parts) >= 3 && parts[0] == "functions" && parts[1] == "groups":
+		return ObjectRef{Type: TypeFuncGroup, Name: segment(2)}
+	}
+	return ObjectRef{}
+}
+
+// normalizeObjectRef upper-cases the name and reduces type codes like CLAS/OC to CLAS.
+func normalizeObjectRef(obj ObjectRef) ObjectRef {
+	if obj.URL != "" && obj.Type == "" {
+		if fromURI := objectFromURI(obj.URL); fromURI.Name != "" {
+			return fromURI
+		}
+	}
+	obj.Type = baseType(obj.Type)
+	obj.Name = strings.ToUpper(obj.Name)
+	return obj
+}
+
+// baseType strips the subtype from an ADT type code (CLAS/OC -> CLAS).
+func baseType(objType string) string {
+	if i := strings.Index(objType, "/"); i > 0 {
+		objType = objType[:i]
+	}
+	return strings.ToUpper(objType)
+}
+
+// objectKey returns a unique key for an object reference.
+func objectKey(obj ObjectRef) string {
+	return obj.Type + ":" + obj.Name
+}
+
+// isTestableType reports whether ABAP Unit can run tests for the object type.
+func isTestableType(objType string) bool {
+	return objType == TypeClass || objType == TypeProgram
+}
+
+// buildImpactURL constructs the ADT URL used for where-used lookups.
+func buildImpactURL(obj ObjectRef) string {
+	if obj.URL != "" {
+		return obj.URL
+	}
+	name := url.PathEscape(obj.Name)
+	switch obj.Type {
+	case TypeClass:
+		return fmt.Sprintf("/sap/bc/adt/oo/classes/%s", name)
+	case TypeInterface:
+		return fmt.Sprintf("/sap/bc/adt/oo/interfaces/%s", name)
+	case TypeProgram:
+		return fmt.Sprintf("/sap/bc/adt/programs/programs/%s", name)
+	case "INCL":
+		return fmt.Sprintf("/sap/bc/adt/programs/includes/%s", name)
+	case TypeFuncGroup:
+		return fmt.Sprintf("/sap/bc/adt/functions/groups/%s", name)
+	case TypeFunction:
+		if obj.Group == "" {
+			return ""
+		}
+		return fmt.Sprintf("/sap/bc/adt/functions/groups/%s/fmodules/%s", url.PathEscape(obj.Group), name)
+	case TypeTable:
+		return fmt.Sprintf("/sap/bc/adt/ddic/tables/%s", name)
+	case TypeDDLS:
+		return fmt.Sprintf("/sap/bc/adt/ddic/ddl/sources/%s", name)
+	default:
+		return ""
+	}
+}
+
+// ParseObjectRef parses "TYPE:NAME" (e.g. "CLAS:ZCL_ORDER") into an ObjectRef.
+// Function modules use "FUNC:GROUP/NAME". A bare name is treated as a class.
+func ParseObjectRef(s string) (ObjectRef, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ObjectRef{}, fmt.Errorf("empty object reference")
+	}
+	objType, name, found := strings.Cut(s, ":")
+	if !found {
+		return ObjectRef{Type: TypeClass, Name: strings.ToUpper(s)}, nil
+	}
+	obj := ObjectRef{Type: baseType(objType), Name: strings.ToUpper(name)}
+	if obj.Type == TypeFunction {
+		group, fm, ok := strings.Cut(obj.Name, "/")
+		if !ok {
+			return ObjectRef{}, fmt.Errorf("function module must be given as FUNC:GROUP/NAME: %s", s)
+		}
+		obj.Group, obj.Name = group, fm
+	}
+	if obj.Name == "" {
+		return ObjectRef{}, fmt.Errorf("missing object name: %s", s)
+	}
+	return obj, nil
+}
+
+// ObjectsFromTransport returns the objects recorded in a transport request and its tasks.
+func ObjectsFromTransport(ctx context.Context, client *adt.Client, number string) ([]ObjectRef, error) {
+	details, err := client.GetTransport(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("reading transport %s: %w", number, err)
+	}
+
+	all := append([]adt.TransportObjectV2{}, details.Objects...)
+	for _, task := range details.Tasks {
+		all = append(all, task.Objects...)
+	}
+
+	seen := make(map[string]bool)
+	var objects []ObjectRef
+	for _, o := range all {
+		obj := normalizeObjectRef(ObjectRef{Type: o.Type, Name: o.Name})
+		switch o.Type {
+		case "METH", "CINC", "CPUB", "CPRI", "CPRO", "CLSD":
+			// LIMU class parts ("ZCL_FOO   METHOD", "ZCL_FOO=====CCAU") refer to the class
+			obj.Type = TypeClass
+			obj.Name = strings.TrimRight(strings.SplitN(obj.Name, "=", 2)[0], " ")
+			if fields := strings.Fields(obj.Name); len(fields) > 0 {
+				obj.Name = fields[0]
+			}
+		case "REPS":
+			obj.Type = TypeProgram
+		}
+		if obj.Name == "" || seen[objectKey(obj)] {
+			continue
+		}
+		seen[objectKey(obj)] = true
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
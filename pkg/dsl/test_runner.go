@@ -15,6 +15,11 @@ type TestRunner struct {
 	objects []ObjectRef
 	config  TestConfig
 
+	// Impact analysis
+	changed     []ObjectRef
+	impactDepth int
+	impact      *ImpactResult
+
 	// Callbacks
 	onStart    func(obj ObjectRef)
 	onComplete func(obj ObjectRef, result TestResult)
@@ -59,6 +64,25 @@ func (t *TestRunner) Package(pkgName string) *TestRunner {
 	return t
 }
 
+// AffectedBy adds the tests affected by the given changed objects.
+// Test targets are resolved during execution by walking callers and
+// where-used references (see ImpactAnalyzer).
+func (t *TestRunner) AffectedBy(objects ...ObjectRef) *TestRunner {
+	t.changed = append(t.changed, objects...)
+	return t
+}
+
+// ImpactDepth sets how many caller levels AffectedBy follows (default 3).
+func (t *TestRunner) ImpactDepth(n int) *TestRunner {
+	t.impactDepth = n
+	return t
+}
+
+// Impact returns the impact analysis of the last run, or nil if AffectedBy was not used.
+func (t *TestRunner) Impact() *ImpactResult {
+	return t.impact
+}
+
 // FromSearch uses search results as test targets.
 func (t *TestRunner) FromSearch(search *SearchBuilder) *TestRunner {
 	// Objects will be resolved during execution
@@ -294,7 +318,7 @@ func (t *TestRunner) runSingleTest(ctx context.Context, obj ObjectRef) TestResul
 	return result
 }
 
-// resolveObjects resolves package references to actual testable objects.
+// resolveObjects resolves package references and changed objects to actual testable objects.
 func (t *TestRunner) resolveObjects(ctx context.Context) ([]ObjectRef, error) {
 	var resolved []ObjectRef
 
@@ -316,7 +340,31 @@ func (t *TestRunner) resolveObjects(ctx context.Context) ([]ObjectRef, error) {
 		}
 	}
 
-	return resolved, nil
+	if len(t.changed) > 0 {
+		impact, err := Impact(t.client).MaxDepth(t.impactDepth).Analyze(ctx, t.changed...)
+		if err != nil {
+			return nil, fmt.Errorf("analyzing impact: %w", err)
+		}
+		t.impact = impact
+		resolved = append(resolved, impact.TestTargets...)
+	}
+
+	return DedupeObjects(resolved), nil
+}
+
+// DedupeObjects removes duplicate object references, keeping the first occurrence.
+func DedupeObjects(objects []ObjectRef) []ObjectRef {
+	seen := make(map[string]bool, len(objects))
+	result := make([]ObjectRef, 0, len(objects))
+	for _, obj := range objects {
+		key := objectKey(normalizeObjectRef(obj))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, obj)
+	}
+	return result
 }
 
 // buildObjectURL constructs the ADT URL for an object.
@@ -334,7 +382,7 @@ func (t *TestRunner) buildObjectURL(obj ObjectRef) string {
 	case TypeInterface, "INTF/OI":
 		return fmt.Sprintf("/sap/bc/adt/oo/interfaces/%s", name)
 	case TypeFunction:
-		return fmt.Sprintf("/sap/bc/adt/functions/groups/%s/fmodules/%s", obj.Package, name)
+		return fmt.Sprintf("/sap/bc/adt/functions/groups/%s/fmodules/%s", obj.Group, name)
 	default:
 		return ""
 	}
@@ -11,10 +11,11 @@ import (
 
 // ObjectRef represents a reference to an ABAP object.
 type ObjectRef struct {
-	Type    string `json:"type" yaml:"type"`       // CLAS, PROG, FUNC, etc.
-	Name    string `json:"name" yaml:"name"`       // Object name
-	Package string `json:"package" yaml:"package"` // Package (DEVCLASS)
-	URL     string `json:"url" yaml:"url"`         // ADT URL
+	Type    string `json:"type" yaml:"type"`                       // CLAS, PROG, FUNC, etc.
+	Name    string `json:"name" yaml:"name"`                       // Object name
+	Package string `json:"package" yaml:"package"`                 // Package (DEVCLASS)
+	Group   string `json:"group,omitempty" yaml:"group,omitempty"` // Function group (FUNC only)
+	URL     string `json:"url" yaml:"url"`                         // ADT URL
 }
 
 // ObjectType constants for ABAP object types.
@@ -340,7 +340,7 @@ func handleSearch(ctx *ExecutionContext, params map[string]interface{}) (interfa
 	}
 
 	maxResults := 100
-	if mr, ok := params["maxResults"].(int); ok {
+	if mr, ok := intParam(params, "maxResults"); ok {
 		maxResults = mr
 	}
 
@@ -370,6 +370,15 @@ func handleSearch(ctx *ExecutionContext, params map[string]interface{}) (interfa
 }
 
 func handleTest(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	runner, err := testRunnerFromParams(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return runner.Run(ctx.Context())
+}
+
+// testRunnerFromParams configures a test runner from the parameters of a test step.
+func testRunnerFromParams(ctx *ExecutionContext, params map[string]interface{}) (*TestRunner, error) {
 	runner := Test(ctx.Client())
 
 	// Get objects from params or context
@@ -389,6 +398,22 @@ func handleTest(ctx *ExecutionContext, params map[string]interface{}) (interface
 		runner.Package(pkgName)
 	}
 
+	if changed, ok := params["affectedBy"].([]interface{}); ok {
+		for _, c := range changed {
+			if cs, ok := c.(string); ok {
+				obj, err := ParseObjectRef(cs)
+				if err != nil {
+					return nil, err
+				}
+				runner.AffectedBy(obj)
+			}
+		}
+	}
+
+	if depth, ok := intParam(params, "impactDepth"); ok {
+		runner.ImpactDepth(depth)
+	}
+
 	if dangerous, ok := params["dangerous"].(bool); ok && dangerous {
 		runner.IncludeDangerous()
 	}
@@ -401,7 +426,21 @@ func handleTest(ctx *ExecutionContext, params map[string]interface{}) (interface
 		runner.StopOnFirstFailure()
 	}
 
-	return runner.Run(ctx.Context())
+	return runner, nil
+}
+
+// intParam returns an integer parameter. YAML decodes whole numbers as int,
+// JSON and YAML floats (2.0) decode as float64.
+func intParam(params map[string]interface{}, key string) (int, bool) {
+	switch v := params[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
 }
 
 func handleSyntaxCheck(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
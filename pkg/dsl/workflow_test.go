@@ -655,3 +655,31 @@ steps:
 		t.Errorf("unexpected error: %s", result.Error)
 	}
 }
+
+func TestTestStepParameters(t *testing.T) {
+	for _, depth := range []string{"2", "2.0"} {
+		yamlContent := `
+name: impact
+steps:
+  - action: test
+    parameters:
+      affectedBy: ["CLAS:ZCL_PRICING"]
+      impactDepth: ` + depth + `
+`
+		engine := NewWorkflowEngine(nil)
+		workflow, err := engine.ParseWorkflow([]byte(yamlContent))
+		if err != nil {
+			t.Fatalf("ParseWorkflow failed: %v", err)
+		}
+		runner, err := testRunnerFromParams(NewExecutionContext(context.Background(), nil), workflow.Steps[0].Parameters)
+		if err != nil {
+			t.Fatalf("testRunnerFromParams failed: %v", err)
+		}
+		if runner.impactDepth != 2 {
+			t.Errorf("impactDepth %s: got %d, want 2", depth, runner.impactDepth)
+		}
+		if len(runner.changed) != 1 || runner.changed[0].Name != "ZCL_PRICING" {
+			t.Errorf("affectedBy: got %v", runner.changed)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package extraction
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GenerateOptions controls the generated test class.
+type GenerateOptions struct {
+	TestClass  string // local test class name (default: ltc_recorded)
+	TestMethod string // test method name (default: test_<method>)
+}
+
+// GenerateTestClass renders tc as the source of a class's local test classes
+// include. The test class is a local friend of the class under test, so
+// private methods can be tested as well. Database tables accessed by the
+// method are replaced by an Open SQL test environment and called function
+// modules by function module test doubles.
+func GenerateTestClass(tc *TestCase, opts GenerateOptions) string {
+	testClass := opts.TestClass
+	if testClass == "" {
+		testClass = "ltc_recorded"
+	}
+	testMethod := opts.TestMethod
+	if testMethod == "" {
+		testMethod = abapIdentifier("test_" + tc.Method)
+	}
+	class := strings.ToLower(tc.Class)
+	tables := tc.Tables()
+	functions := tc.Functions()
+	hasSetup := len(tables) > 0 || len(functions) > 0 || !tc.Static
+
+	var sb strings.Builder
+	w := func(format string, args ...interface{}) {
+		fmt.Fprintf(&sb, format, args...)
+		sb.WriteString("\n")
+	}
+
+	w(`*"* use this source file for your ABAP unit test classes`)
+	if tc.RecordingID != "" {
+		w(`*"* generated from execution recording %s (steps %d-%d)`, tc.RecordingID, tc.EntryStep, tc.ExitStep)
+	}
+	w("CLASS %s DEFINITION DEFERRED.", testClass)
+	w("CLASS %s DEFINITION LOCAL FRIENDS %s.", class, testClass)
+	w("")
+	w("CLASS %s DEFINITION FINAL FOR TESTING", testClass)
+	w("  DURATION SHORT")
+	w("  RISK LEVEL HARMLESS.")
+	w("")
+	w("  PRIVATE SECTION.")
+	if len(tables) > 0 {
+		w("    CLASS-DATA sql_environment TYPE REF TO if_osql_test_environment.")
+	}
+	if len(functions) > 0 {
+		w("    CLASS-DATA function_environment TYPE REF TO if_function_test_environment.")
+	}
+	if !tc.Static {
+		w("    DATA cut TYPE REF TO %s.", class)
+	}
+	w("")
+	if len(tables) > 0 || len(functions) > 0 {
+		w("    CLASS-METHODS class_setup.")
+	}
+	if len(tables) > 0 {
+		w("    CLASS-METHODS class_teardown.")
+	}
+	if hasSetup {
+		w("    METHODS setup.")
+	}
+	w("    METHODS %s FOR TESTING RAISING cx_static_check.", testMethod)
+	w("ENDCLASS.")
+	w("")
+	w("CLASS %s IMPLEMENTATION.", testClass)
+	w("")
+	if len(tables) > 0 || len(functions) > 0 {
+		w("  METHOD class_setup.")
+		if len(tables) > 0 {
+			w("    sql_environment = cl_osql_test_environment=>create(")
+			w("      i_dependency_list = VALUE #( %s ) ).", quotedList(tables))
+		}
+		if len(functions) > 0 {
+			w("    function_environment = cl_function_test_environment=>create(")
+			w("      VALUE #( %s ) ).", quotedList(functions))
+		}
+		w("  ENDMETHOD.")
+		w("")
+	}
+	if len(tables) > 0 {
+		w("  METHOD class_teardown.")
+		w("    sql_environment->destroy( ).")
+		w("  ENDMETHOD.")
+		w("")
+	}
+	if hasSetup {
+		w("  METHOD setup.")
+		if len(tables) > 0 {
+			w("    sql_environment->clear_doubles( ).")
+		}
+		if len(functions) > 0 {
+			w("    function_environment->clear_doubles( ).")
+		}
+		if !tc.Static {
+			w("    cut = NEW #( ).")
+		}
+		w("  ENDMETHOD.")
+		w("")
+	}
+	w("  METHOD %s.", testMethod)
+
+	// Given
+	w(`    " Given`)
+	for _, v := range tc.Importing {
+		writeDeclaration(w, v, true)
+	}
+	for _, v := range tc.Changing {
+		writeDeclaration(w, v, true)
+	}
+	for _, v := range tc.Exporting {
+		writeDeclaration(w, v, false)
+	}
+	if tc.Returning != nil {
+		writeDeclaration(w, *tc.Returning, false)
+	}
+	writeTestData(w, tc)
+	writeFunctionDoubles(w, tc)
+	w("")
+
+	// When
+	w(`    " When`)
+	writeCall(w, tc)
+	w("")
+
+	// Then
+	w(`    " Then`)
+	expectations := append([]Value{}, tc.Exporting...)
+	expectations = append(expectations, tc.ChangingResults...)
+	if tc.Returning != nil {
+		expectations = append(expectations, *tc.Returning)
+	}
+	if len(expectations) == 0 {
+		w(`    " TODO: no output parameters were recorded - add assertions`)
+	}
+	for _, v := range expectations {
+		writeAssertion(w, v)
+	}
+	writeDBNotes(w, tc)
+
+	w("  ENDMETHOD.")
+	w("")
+	w("ENDCLASS.")
+
+	return sb.String()
+}
+
+// writeDeclaration declares a parameter variable, assigning the recorded value
+// for inputs.
+func writeDeclaration(w func(string, ...interface{}), v Value, input bool) {
+	name := strings.ToLower(v.Name)
+	w("    DATA %s TYPE %s.", name, abapType(v.Type))
+	if !input {
+		return
+	}
+	if v.Complex {
+		w(`    " TODO: fill %s (recorded: %s)`, name, comment(v.Value))
+		return
+	}
+	if v.Value != "" {
+		w("    %s = %s.", name, literal(v.Value))
+	}
+}
+
+// writeTestData inserts rows recorded for SELECTs into the Open SQL test environment.
+func writeTestData(w func(string, ...interface{}), tc *TestCase) {
+	for _, op := range tc.DBOps {
+		table := strings.ToLower(op.Table)
+		if table == "" {
+			continue
+		}
+		rows, _ := op.Details["rows"].([]interface{})
+		if !strings.EqualFold(op.Operation, "SELECT") || len(rows) == 0 {
+			w(`    " Recorded %s on %s (%d rows)`, strings.ToUpper(op.Operation), strings.ToUpper(op.Table), op.Rows)
+			continue
+		}
+		data := abapIdentifier("td_" + table)
+		w("    DATA %s TYPE STANDARD TABLE OF %s WITH EMPTY KEY.", data, table)
+		w("    %s = VALUE #(", data)
+		for _, row := range rows {
+			fields, ok := row.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var parts []string
+			for _, field := range sortedKeys(fields) {
+				parts = append(parts, fmt.Sprintf("%s = %s", strings.ToLower(field), literal(fmt.Sprint(fields[field]))))
+			}
+			w("      ( %s )", strings.Join(parts, " "))
+		}
+		w("    ).")
+		w("    sql_environment->insert_test_data( %s ).", data)
+	}
+}
+
+// writeFunctionDoubles configures a double per recorded function module call.
+func writeFunctionDoubles(w func(string, ...interface{}), tc *TestCase) {
+	configured := make(map[string]bool)
+	for _, call := range tc.RFCCalls {
+		fm := strings.ToUpper(call.Function)
+		if fm == "" || configured[fm] {
+			continue
+		}
+		configured[fm] = true
+		double := abapIdentifier("double_" + strings.ToLower(strings.ReplaceAll(fm, "/", "_")))
+		w("    DATA(%s) = function_environment->get_double( '%s' ).", double, fm)
+		if call.Exception != "" {
+			w(`    " TODO: recorded exception %s - raise it from the double`, call.Exception)
+		}
+		if len(call.Outputs) == 0 {
+			w("    %s->configure_call( )->ignore_all_parameters( ).", double)
+			continue
+		}
+		w("    %s->configure_call( )->ignore_all_parameters( )->then_set_output(", double)
+		w("      %s->create_output_configuration( )", double)
+		for _, name := range sortedKeys(call.Outputs) {
+			value, ok := call.Outputs[name].(string)
+			if !ok {
+				if f, isNum := call.Outputs[name].(float64); isNum {
+					value, ok = strconv.FormatFloat(f, 'f', -1, 64), true
+				}
+			}
+			if !ok {
+				w(`        " TODO: set %s (recorded: %s)`, strings.ToUpper(name), comment(fmt.Sprint(call.Outputs[name])))
+				continue
+			}
+			w("        ->set_exporting_parameter( name = '%s' value = %s )", strings.ToUpper(name), literal(value))
+		}
+		w("    ).")
+	}
+}
+
+// writeCall calls the method under test.
+func writeCall(w func(string, ...interface{}), tc *TestCase) {
+	method := strings.ToLower(tc.Method)
+	target := "cut->" + method
+	if tc.Static {
+		target = strings.ToLower(tc.Class) + "=>" + method
+	}
+
+	var sections []string
+	add := func(keyword string, values []Value) {
+		if len(values) == 0 {
+			return
+		}
+		var parts []string
+		for _, v := range values {
+			name := strings.ToLower(v.Name)
+			parts = append(parts, name+" = "+name)
+		}
+		sections = append(sections, keyword+" "+strings.Join(parts, " "))
+	}
+	add("EXPORTING", tc.Importing)
+	add("IMPORTING", tc.Exporting)
+	add("CHANGING", tc.Changing)
+
+	if tc.Returning != nil {
+		result := strings.ToLower(tc.Returning.Name)
+		if len(tc.Exporting) == 0 && len(tc.Changing) == 0 {
+			// Functional call
+			args := ""
+			if len(sections) > 0 {
+				args = strings.TrimPrefix(sections[0], "EXPORTING ")
+			}
+			w("    %s = %s( %s ).", result, target, args)
+			return
+		}
+		sections = append(sections, "RECEIVING "+result+" = "+result)
+	}
+
+	if len(sections) == 0 {
+		w("    %s( ).", target)
+		return
+	}
+	w("    %s(", target)
+	for _, s := range sections {
+		w("      %s", s)
+	}
+	w("    ).")
+}
+
+// writeAssertion compares a parameter with its recorded value.
+func writeAssertion(w func(string, ...interface{}), v Value) {
+	name := strings.ToLower(v.Name)
+	if v.Complex {
+		w(`    " TODO: assert %s (recorded: %s)`, name, comment(v.Value))
+		return
+	}
+	exp := literal(v.Value)
+	if t := abapType(v.Type); t != "string" {
+		exp = fmt.Sprintf("CONV %s( %s )", t, exp)
+	}
+	w("    cl_abap_unit_assert=>assert_equals(")
+	w("      act = %s", name)
+	w("      exp = %s", exp)
+	w("      msg = '%s' ).", v.Name)
+}
+
+// writeDBNotes lists recorded database changes, which the test does not verify.
+func writeDBNotes(w func(string, ...interface{}), tc *TestCase) {
+	for _, op := range tc.DBOps {
+		switch strings.ToUpper(op.Operation) {
+		case "INSERT", "UPDATE", "MODIFY", "DELETE":
+			w(`    " TODO: verify %s on %s (%d rows recorded)`, strings.ToUpper(op.Operation), strings.ToUpper(op.Table), op.Rows)
+		}
+	}
+}
+
+// abapType returns a usable type name for a recorded declared type.
+func abapType(t string) string {
+	t = strings.TrimSpace(t)
+	if t == "" || strings.ContainsAny(t, `\= `) {
+		return "string"
+	}
+	return strings.ToLower(t)
+}
+
+// literal renders a recorded value as an ABAP literal.
+func literal(value string) string {
+	if _, err := strconv.Atoi(value); err == nil && !strings.HasPrefix(value, "0") || value == "0" {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// comment makes a value safe to embed in a single-line comment.
+func comment(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if len(value) > 60 {
+		value = value[:57] + "..."
+	}
+	return value
+}
+
+// abapIdentifier lowercases name and truncates it to the 30-character ABAP limit.
+func abapIdentifier(name string) string {
+	name = strings.ToLower(name)
+	if len(name) > 30 {
+		name = name[:30]
+	}
+	return name
+}
+
+func quotedList(names []string) string {
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = "( '" + n + "' )"
+	}
+	return strings.Join(parts, " ")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,152 @@
+package extraction
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+const testPool = "ZCL_PRICING===================CP"
+
+// newTestRecording records a call of ZCL_PRICING->CALCULATE between two steps
+// in the calling report.
+func newTestRecording(t *testing.T) *adt.ExecutionRecording {
+	t.Helper()
+	r := adt.NewExecutionRecorder("session", "ZREPORT")
+
+	r.RecordFrame(adt.CodeLocation{Program: "ZREPORT", Line: 10}, "entry", map[string]adt.VariableValue{
+		"LV_TOTAL": {Name: "LV_TOTAL", Type: "P", Value: "0"},
+	})
+	r.RecordFrame(adt.CodeLocation{Program: testPool, Include: "ZCL_PRICING===================CM001", Line: 5}, "step_into", map[string]adt.VariableValue{
+		"IV_MATNR":    {Name: "IV_MATNR", Type: "MATNR", Value: "MAT-01"},
+		"IV_QUANTITY": {Name: "IV_QUANTITY", Type: "I", Value: "3"},
+		"IT_ITEMS":    {Name: "IT_ITEMS", Type: "ZTT_ITEMS", MetaType: "table", Value: "Standard Table[2x3]"},
+		"EV_PRICE":    {Name: "EV_PRICE", Type: "P", Value: "0"},
+		"LV_TMP":      {Name: "LV_TMP", Type: "I", Value: "0"},
+	})
+	r.AddDBOperation(adt.DBOperation{Operation: "SELECT", Table: "ZPRICES", Rows: 1, Details: map[string]interface{}{
+		"rows": []interface{}{map[string]interface{}{"MATNR": "MAT-01", "PRICE": "10.5"}},
+	}})
+	r.RecordFrame(adt.CodeLocation{Program: testPool, Include: "ZCL_PRICING===================CM001", Line: 8}, "step_over", map[string]adt.VariableValue{
+		"IV_MATNR":    {Name: "IV_MATNR", Type: "MATNR", Value: "MAT-01"},
+		"IV_QUANTITY": {Name: "IV_QUANTITY", Type: "I", Value: "3"},
+		"IT_ITEMS":    {Name: "IT_ITEMS", Type: "ZTT_ITEMS", MetaType: "table", Value: "Standard Table[2x3]"},
+		"EV_PRICE":    {Name: "EV_PRICE", Type: "P", Value: "31.5"},
+		"LV_TMP":      {Name: "LV_TMP", Type: "I", Value: "1"},
+	})
+	r.AddRFCCall(adt.RFCCall{Function: "Z_GET_DISCOUNT", Outputs: map[string]interface{}{"EV_RATE": float64(5)}})
+	r.RecordFrame(adt.CodeLocation{Program: "ZREPORT", Line: 11}, "step_return", map[string]adt.VariableValue{
+		"LV_TOTAL": {Name: "LV_TOTAL", Type: "P", Value: "31.5"},
+	})
+	return r.GetRecording()
+}
+
+func TestExtractAutoBoundary(t *testing.T) {
+	tc, err := Extract(newTestRecording(t), MethodBoundary{Class: "zcl_pricing", Method: "calculate"})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if tc.EntryStep != 2 || tc.ExitStep != 3 {
+		t.Errorf("boundary = %d-%d, want 2-3", tc.EntryStep, tc.ExitStep)
+	}
+	if len(tc.Importing) != 3 {
+		t.Fatalf("importing = %+v, want IT_ITEMS, IV_MATNR, IV_QUANTITY", tc.Importing)
+	}
+	if !tc.Importing[0].Complex || tc.Importing[1].Value != "MAT-01" {
+		t.Errorf("importing = %+v", tc.Importing)
+	}
+	if len(tc.Exporting) != 1 || tc.Exporting[0].Value != "31.5" {
+		t.Errorf("exporting = %+v, want EV_PRICE = 31.5", tc.Exporting)
+	}
+	if got := tc.Tables(); len(got) != 1 || got[0] != "ZPRICES" {
+		t.Errorf("tables = %v", got)
+	}
+	if got := tc.Functions(); len(got) != 1 || got[0] != "Z_GET_DISCOUNT" {
+		t.Errorf("functions = %v", got)
+	}
+}
+
+func TestExtractExplicitBoundary(t *testing.T) {
+	rec := newTestRecording(t)
+	tc, err := Extract(rec, MethodBoundary{
+		Class: "ZCL_PRICING", Method: "CALCULATE", EntryStep: 2, ExitStep: 3,
+		Importing: []string{"iv_matnr"}, Returning: "LV_TMP",
+	})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(tc.Importing) != 1 || len(tc.Exporting) != 0 {
+		t.Errorf("importing = %+v, exporting = %+v", tc.Importing, tc.Exporting)
+	}
+	if tc.Returning == nil || tc.Returning.Value != "1" {
+		t.Errorf("returning = %+v, want LV_TMP = 1", tc.Returning)
+	}
+
+	if _, err := Extract(rec, MethodBoundary{Class: "ZCL_OTHER", Method: "X"}); err == nil {
+		t.Error("expected error for class without recorded steps")
+	}
+	if _, err := Extract(rec, MethodBoundary{Class: "ZCL_PRICING", Method: "X", EntryStep: 3, ExitStep: 2}); err == nil {
+		t.Error("expected error for exit before entry")
+	}
+}
+
+func TestGenerateTestClass(t *testing.T) {
+	tc, err := Extract(newTestRecording(t), MethodBoundary{Class: "ZCL_PRICING", Method: "CALCULATE"})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	src := GenerateTestClass(tc, GenerateOptions{})
+
+	for _, want := range []string{
+		"CLASS zcl_pricing DEFINITION LOCAL FRIENDS ltc_recorded.",
+		"METHODS test_calculate FOR TESTING RAISING cx_static_check.",
+		"i_dependency_list = VALUE #( ( 'ZPRICES' ) ) ).",
+		"VALUE #( ( 'Z_GET_DISCOUNT' ) ) ).",
+		"iv_matnr = 'MAT-01'.",
+		"iv_quantity = 3.",
+		`" TODO: fill it_items (recorded: Standard Table[2x3])`,
+		"( matnr = 'MAT-01' price = '10.5' )",
+		"sql_environment->insert_test_data( td_zprices ).",
+		"function_environment->get_double( 'Z_GET_DISCOUNT' ).",
+		"->set_exporting_parameter( name = 'EV_RATE' value = 5 )",
+		"EXPORTING it_items = it_items iv_matnr = iv_matnr iv_quantity = iv_quantity",
+		"IMPORTING ev_price = ev_price",
+		"exp = CONV p( '31.5' )",
+		"cut = NEW #( ).",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated source missing %q\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "lv_tmp") {
+		t.Error("local variables must not become parameters")
+	}
+}
+
+func TestGenerateStaticFunctionalCall(t *testing.T) {
+	tc := &TestCase{
+		Class:     "ZCL_UTIL",
+		Method:    "TO_UPPER",
+		Static:    true,
+		Importing: []Value{{Name: "IV_TEXT", Type: "STRING", Value: "it's"}},
+		Returning: &Value{Name: "RV_TEXT", Type: "STRING", Value: "IT'S"},
+	}
+	src := GenerateTestClass(tc, GenerateOptions{TestClass: "ltc_util", TestMethod: "upper"})
+
+	for _, want := range []string{
+		"CLASS ltc_util DEFINITION FINAL FOR TESTING",
+		"iv_text = 'it''s'.",
+		"rv_text = zcl_util=>to_upper( iv_text = iv_text ).",
+		"exp = 'IT''S'",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated source missing %q\n%s", want, src)
+		}
+	}
+	for _, unwanted := range []string{"cut", "sql_environment", "function_environment", "class_setup", "METHODS setup"} {
+		if strings.Contains(src, unwanted+" ") {
+			t.Errorf("generated source should not contain %q\n%s", unwanted, src)
+		}
+	}
+}
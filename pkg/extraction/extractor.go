@@ -0,0 +1,271 @@
+// Package extraction turns recorded debugger executions into ABAP Unit tests.
+//
+// The extractor cuts a method call out of an execution recording: the
+// parameter values at the method entry become the test's given data, the
+// values at the method exit become the expected results, and the database
+// and RFC interactions in between become test doubles.
+package extraction
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// MethodBoundary identifies the method call to extract from a recording.
+type MethodBoundary struct {
+	Class  string `json:"class"`
+	Method string `json:"method"`
+	Static bool   `json:"static,omitempty"`
+
+	// EntryStep and ExitStep are the first and last recorded steps inside the
+	// method. Zero means: the first step in the class pool, and the last step
+	// of the run of consecutive steps in the class pool that follows it.
+	EntryStep int `json:"entry_step,omitempty"`
+	ExitStep  int `json:"exit_step,omitempty"`
+
+	// Parameter names. When all are empty, parameters are classified by the
+	// usual naming convention (IV_/IS_/IT_ importing, EV_/ES_/ET_ exporting,
+	// CV_/CS_/CT_ changing, RV_/RS_/RT_ returning).
+	Importing []string `json:"importing,omitempty"`
+	Exporting []string `json:"exporting,omitempty"`
+	Changing  []string `json:"changing,omitempty"`
+	Returning string   `json:"returning,omitempty"`
+}
+
+// Value is a recorded parameter value.
+type Value struct {
+	Name  string `json:"name"`
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value"`
+	// Complex is set for structures, tables and references, whose recorded
+	// value is only a summary and cannot be turned into a literal.
+	Complex bool `json:"complex,omitempty"`
+}
+
+// TestCase is a method call extracted from a recording.
+type TestCase struct {
+	RecordingID string `json:"recording_id"`
+	Class       string `json:"class"`
+	Method      string `json:"method"`
+	Static      bool   `json:"static,omitempty"`
+	EntryStep   int    `json:"entry_step"`
+	ExitStep    int    `json:"exit_step"`
+
+	Importing []Value `json:"importing,omitempty"` // values at entry
+	Changing  []Value `json:"changing,omitempty"`  // values at entry
+	Exporting []Value `json:"exporting,omitempty"` // values at exit
+	Returning *Value  `json:"returning,omitempty"` // value at exit
+	// ChangingResults holds the values of the changing parameters at exit.
+	ChangingResults []Value `json:"changing_results,omitempty"`
+
+	DBOps    []adt.DBOperation `json:"db_ops,omitempty"`
+	RFCCalls []adt.RFCCall     `json:"rfc_calls,omitempty"`
+}
+
+// Tables returns the database tables accessed by the method, sorted.
+func (tc *TestCase) Tables() []string {
+	seen := make(map[string]bool)
+	var tables []string
+	for _, op := range tc.DBOps {
+		table := strings.ToUpper(op.Table)
+		if table != "" && !seen[table] {
+			seen[table] = true
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// Functions returns the function modules called by the method, sorted.
+func (tc *TestCase) Functions() []string {
+	seen := make(map[string]bool)
+	var functions []string
+	for _, call := range tc.RFCCalls {
+		fm := strings.ToUpper(call.Function)
+		if fm != "" && !seen[fm] {
+			seen[fm] = true
+			functions = append(functions, fm)
+		}
+	}
+	sort.Strings(functions)
+	return functions
+}
+
+// Extract cuts the method call described by b out of rec.
+func Extract(rec *adt.ExecutionRecording, b MethodBoundary) (*TestCase, error) {
+	if rec == nil {
+		return nil, fmt.Errorf("recording is nil")
+	}
+	if b.Class == "" || b.Method == "" {
+		return nil, fmt.Errorf("class and method are required")
+	}
+	if len(rec.Frames) == 0 {
+		return nil, fmt.Errorf("recording %s has no frames", rec.ID)
+	}
+
+	entry, exit, err := findBoundary(rec, b)
+	if err != nil {
+		return nil, err
+	}
+
+	tc := &TestCase{
+		RecordingID: rec.ID,
+		Class:       strings.ToUpper(b.Class),
+		Method:      strings.ToUpper(b.Method),
+		Static:      b.Static,
+		EntryStep:   entry,
+		ExitStep:    exit,
+	}
+
+	entryVars := rec.VariablesAt(entry)
+	exitVars := rec.VariablesAt(exit)
+
+	importing, exporting, changing, returning := b.Importing, b.Exporting, b.Changing, b.Returning
+	if len(importing) == 0 && len(exporting) == 0 && len(changing) == 0 && returning == "" {
+		importing, exporting, changing, returning = classifyParameters(entryVars, exitVars)
+	}
+
+	tc.Importing = collectValues(entryVars, importing)
+	tc.Changing = collectValues(entryVars, changing)
+	tc.Exporting = collectValues(exitVars, exporting)
+	tc.ChangingResults = collectValues(exitVars, changing)
+	if returning != "" {
+		if values := collectValues(exitVars, []string{returning}); len(values) > 0 {
+			tc.Returning = &values[0]
+		}
+	}
+
+	for _, frame := range rec.Frames[entry-1 : exit] {
+		tc.DBOps = append(tc.DBOps, frame.DBOps...)
+		tc.RFCCalls = append(tc.RFCCalls, frame.RFCCalls...)
+	}
+
+	return tc, nil
+}
+
+// findBoundary resolves the entry and exit step of the method call.
+func findBoundary(rec *adt.ExecutionRecording, b MethodBoundary) (int, int, error) {
+	total := len(rec.Frames)
+	entry := b.EntryStep
+	if entry == 0 {
+		for i, frame := range rec.Frames {
+			if inClass(frame.Location, b.Class) {
+				entry = i + 1
+				break
+			}
+		}
+		if entry == 0 {
+			return 0, 0, fmt.Errorf("no recorded step in class %s", strings.ToUpper(b.Class))
+		}
+	}
+	if entry < 1 || entry > total {
+		return 0, 0, fmt.Errorf("entry step %d out of range (1-%d)", entry, total)
+	}
+
+	exit := b.ExitStep
+	if exit == 0 {
+		exit = entry
+		for exit < total && inClass(rec.Frames[exit].Location, b.Class) {
+			exit++
+		}
+	}
+	if exit < entry || exit > total {
+		return 0, 0, fmt.Errorf("exit step %d out of range (%d-%d)", exit, entry, total)
+	}
+	return entry, exit, nil
+}
+
+// inClass reports whether loc is in the class pool of className.
+func inClass(loc adt.CodeLocation, className string) bool {
+	pool := adt.ClassPoolName(className)
+	prefix := strings.TrimSuffix(pool, "CP")
+	return strings.EqualFold(loc.Program, pool) ||
+		strings.HasPrefix(strings.ToUpper(loc.Include), prefix)
+}
+
+var (
+	importingPrefixes = []string{"IV_", "IS_", "IT_", "IO_", "IR_", "I_"}
+	exportingPrefixes = []string{"EV_", "ES_", "ET_", "EO_", "ER_", "E_"}
+	changingPrefixes  = []string{"CV_", "CS_", "CT_", "CO_", "CR_", "C_"}
+	returningPrefixes = []string{"RV_", "RS_", "RT_", "RO_", "RR_", "R_"}
+)
+
+// classifyParameters sorts the recorded variables into parameter kinds by name.
+func classifyParameters(entryVars, exitVars map[string]adt.VariableValue) (importing, exporting, changing []string, returning string) {
+	for _, name := range sortedNames(entryVars) {
+		switch {
+		case hasAnyPrefix(name, importingPrefixes):
+			importing = append(importing, name)
+		case hasAnyPrefix(name, changingPrefixes):
+			changing = append(changing, name)
+		}
+	}
+	for _, name := range sortedNames(exitVars) {
+		switch {
+		case hasAnyPrefix(name, exportingPrefixes):
+			exporting = append(exporting, name)
+		case returning == "" && hasAnyPrefix(name, returningPrefixes):
+			returning = name
+		}
+	}
+	return importing, exporting, changing, returning
+}
+
+func hasAnyPrefix(name string, prefixes []string) bool {
+	upper := strings.ToUpper(name)
+	for _, p := range prefixes {
+		if strings.HasPrefix(upper, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(vars map[string]adt.VariableValue) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collectValues returns the recorded values of names that exist in vars.
+func collectValues(vars map[string]adt.VariableValue, names []string) []Value {
+	var values []Value
+	for _, name := range names {
+		v, ok := vars[name]
+		if !ok {
+			v, ok = vars[strings.ToUpper(name)]
+		}
+		if !ok {
+			continue
+		}
+		values = append(values, toValue(v))
+	}
+	return values
+}
+
+func toValue(v adt.VariableValue) Value {
+	val := Value{Name: strings.ToUpper(v.Name), Type: v.Type}
+	switch x := v.Value.(type) {
+	case nil:
+	case string:
+		val.Value = x
+	case float64:
+		val.Value = strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		val.Value = fmt.Sprint(x)
+		val.Complex = true
+	}
+	switch strings.ToLower(v.MetaType) {
+	case "structure", "table", "objectref", "dataref":
+		val.Complex = true
+	}
+	return val
+}
@@ -0,0 +1,42 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// Backend is the ADT code intelligence API the server uses. ADTBackend
+// implements it with an *adt.Client.
+type Backend interface {
+	CodeCompletion(ctx context.Context, sourceURL, source string, line, column int) ([]adt.CompletionProposal, error)
+	FindDefinition(ctx context.Context, sourceURL, source string, line, startCol, endCol int, implementation bool, mainProgram string) (*adt.DefinitionLocation, error)
+	FindReferences(ctx context.Context, objectURL string, line, column int) ([]adt.UsageReference, error)
+	GetClassComponents(ctx context.Context, classURL string) (*adt.ClassComponent, error)
+	GetTypeHierarchy(ctx context.Context, sourceURL, source string, line, column int, superTypes bool) ([]adt.HierarchyNode, error)
+	PrettyPrint(ctx context.Context, source string) (string, error)
+	SyntaxCheck(ctx context.Context, objectURL, content string) ([]adt.SyntaxCheckResult, error)
+
+	// Source returns the source behind an ADT source URL such as
+	// /sap/bc/adt/oo/classes/zcl_foo/source/main.
+	Source(ctx context.Context, sourceURL string) (string, error)
+}
+
+// ADTBackend implements Backend with the ADT client.
+type ADTBackend struct {
+	*adt.Client
+}
+
+// NewADTBackend creates a Backend for client.
+func NewADTBackend(client *adt.Client) *ADTBackend {
+	return &ADTBackend{Client: client}
+}
+
+func (b *ADTBackend) Source(ctx context.Context, sourceURL string) (string, error) {
+	ref, ok := parseSourceURL(sourceURL)
+	if !ok {
+		return "", fmt.Errorf("unsupported source URL %s", sourceURL)
+	}
+	return b.GetSource(ctx, ref.objectType, ref.name, &adt.GetSourceOptions{Parent: ref.parent, Include: ref.include})
+}
@@ -0,0 +1,336 @@
+// Package lsp implements a Language Server Protocol server for ABAP.
+//
+// The server speaks LSP (https://microsoft.github.io/language-server-protocol/)
+// over any byte stream (normally stdio) and answers requests with the ADT
+// code intelligence API of a live system, so that any LSP editor can be used
+// for ABAP development. Local files are named the abapGit way
+// (zcl_foo.clas.abap, ztest.prog.abap, ...) and map to the ADT source of
+// the object; definitions in objects without a local file are downloaded to
+// a read-only cache directory.
+//
+// Positions: LSP lines are 0-based and ADT lines 1-based; columns are 0-based
+// in both. Columns are counted in bytes, which equals the LSP UTF-16 count
+// for ASCII source.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// --- Wire format (JSON-RPC 2.0 with Content-Length framing) ---
+
+// Message is an incoming request (ID set) or notification (ID empty).
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// IsRequest reports whether the message expects a response.
+func (m *Message) IsRequest() bool { return len(m.ID) > 0 }
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+}
+
+type errorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *ResponseError  `json:"error"`
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// ResponseError is a JSON-RPC error.
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string { return e.Message }
+
+// JSON-RPC and LSP error codes.
+const (
+	CodeInvalidParams        = -32602
+	CodeMethodNotFound       = -32601
+	CodeInternalError        = -32603
+	CodeServerNotInitialized = -32002
+	CodeRequestCancelled     = -32800
+)
+
+// ReadMessage reads one Content-Length framed message.
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// WriteMessage writes one Content-Length framed message.
+func WriteMessage(w io.Writer, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// conn serializes outgoing messages; requests are answered concurrently.
+type conn struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (c *conn) respond(id json.RawMessage, result interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return WriteMessage(c.w, &response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (c *conn) fail(id json.RawMessage, code int, format string, args ...interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return WriteMessage(c.w, &errorResponse{JSONRPC: "2.0", ID: id, Error: &ResponseError{Code: code, Message: fmt.Sprintf(format, args...)}})
+}
+
+func (c *conn) notify(method string, params interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return WriteMessage(c.w, &notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// --- Protocol types (the subset used by this server) ---
+
+// Position is a 0-based line and character offset.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a half-open range of positions.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// TextDocumentIdentifier names a document.
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// TextDocumentItem is an opened document.
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+// TextDocumentPositionParams is a position in a document.
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// TextEdit replaces a range with new text.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// MarkupContent is markdown or plain text.
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Hover is the result of textDocument/hover.
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+// CompletionItem is one completion proposal.
+type CompletionItem struct {
+	Label    string    `json:"label"`
+	Kind     int       `json:"kind,omitempty"`
+	SortText string    `json:"sortText,omitempty"`
+	TextEdit *TextEdit `json:"textEdit,omitempty"`
+}
+
+// CompletionList is the result of textDocument/completion.
+type CompletionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+// Symbol kinds used for ABAP constructs.
+const (
+	SymbolKindModule    = 2
+	SymbolKindClass     = 5
+	SymbolKindMethod    = 6
+	SymbolKindField     = 8
+	SymbolKindInterface = 11
+	SymbolKindFunction  = 12
+	SymbolKindConstant  = 14
+	SymbolKindEvent     = 24
+	SymbolKindStruct    = 23
+)
+
+// DocumentSymbol is a hierarchical symbol of a document.
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+// Diagnostic severities.
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+)
+
+// Diagnostic is a syntax check message.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// PublishDiagnosticsParams is sent with textDocument/publishDiagnostics.
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// TypeHierarchyItem is an entry of textDocument/prepareTypeHierarchy and
+// typeHierarchy/supertypes and subtypes. Data carries the position the
+// hierarchy was requested for.
+type TypeHierarchyItem struct {
+	Name           string                      `json:"name"`
+	Kind           int                         `json:"kind"`
+	Detail         string                      `json:"detail,omitempty"`
+	URI            string                      `json:"uri"`
+	Range          Range                       `json:"range"`
+	SelectionRange Range                       `json:"selectionRange"`
+	Data           *TextDocumentPositionParams `json:"data,omitempty"`
+}
+
+// ServerCapabilities lists the features of this server.
+type ServerCapabilities struct {
+	TextDocumentSync           textDocumentSyncOptions `json:"textDocumentSync"`
+	CompletionProvider         *completionOptions      `json:"completionProvider,omitempty"`
+	HoverProvider              bool                    `json:"hoverProvider"`
+	DefinitionProvider         bool                    `json:"definitionProvider"`
+	ImplementationProvider     bool                    `json:"implementationProvider"`
+	ReferencesProvider         bool                    `json:"referencesProvider"`
+	DocumentSymbolProvider     bool                    `json:"documentSymbolProvider"`
+	DocumentFormattingProvider bool                    `json:"documentFormattingProvider"`
+	TypeHierarchyProvider      bool                    `json:"typeHierarchyProvider"`
+}
+
+type textDocumentSyncOptions struct {
+	OpenClose bool        `json:"openClose"`
+	Change    int         `json:"change"` // 1 = full document
+	Save      saveOptions `json:"save"`
+}
+
+type saveOptions struct {
+	IncludeText bool `json:"includeText"`
+}
+
+type completionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
+}
+
+type initializeParams struct {
+	RootURI          string `json:"rootUri"`
+	RootPath         string `json:"rootPath"`
+	WorkspaceFolders []struct {
+		URI string `json:"uri"`
+	} `json:"workspaceFolders"`
+}
+
+type initializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   struct {
+		Name string `json:"name"`
+	} `json:"serverInfo"`
+}
+
+type didOpenParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument struct {
+		URI     string `json:"uri"`
+		Version int    `json:"version"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Range *Range `json:"range"`
+		Text  string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didSaveParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Text         *string                `json:"text"`
+}
+
+type documentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type referenceParams struct {
+	TextDocumentPositionParams
+	Context struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+type typeHierarchyParams struct {
+	Item TypeHierarchyItem `json:"item"`
+}
+
+type cancelParams struct {
+	ID json.RawMessage `json:"id"`
+}
@@ -0,0 +1,709 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// Config configures a Server.
+type Config struct {
+	// CacheDir receives read-only copies of objects that are the target of
+	// a definition or reference but have no local file
+	// (default: <user cache dir>/vsp/lsp).
+	CacheDir string
+}
+
+// Server is a language server for one client connection.
+//
+// Documents are synchronized in full. Requests run concurrently and can be
+// cancelled with $/cancelRequest; document notifications are applied in
+// order.
+type Server struct {
+	backend Backend
+	cfg     Config
+	c       *conn
+	ctx     context.Context
+	wg      sync.WaitGroup
+
+	mu          sync.Mutex
+	initialized bool
+	ws          *workspace
+	docs        map[string]*document          // URI -> open document
+	pending     map[string]context.CancelFunc // request ID -> cancel
+}
+
+// document is an open text document.
+type document struct {
+	uri    string
+	path   string
+	text   string
+	ref    sourceRef
+	mapped bool // ref is valid: the file is an abapGit ABAP source
+}
+
+// NewServer creates a language server backed by backend.
+func NewServer(backend Backend, cfg Config) *Server {
+	if cfg.CacheDir == "" {
+		if dir, err := os.UserCacheDir(); err == nil {
+			cfg.CacheDir = filepath.Join(dir, "vsp", "lsp")
+		}
+	}
+	return &Server{
+		backend: backend,
+		cfg:     cfg,
+		ws:      newWorkspace(nil, cfg.CacheDir),
+		docs:    make(map[string]*document),
+		pending: make(map[string]context.CancelFunc),
+	}
+}
+
+// Serve handles LSP messages from r until the client sends exit or r is closed.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.ctx = ctx
+	s.c = &conn{w: w}
+	defer s.wg.Wait()
+
+	br := bufio.NewReader(r)
+	for {
+		data, err := ReadMessage(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Method == "" {
+			continue // malformed, or a response to a server request
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if err := s.dispatch(&msg); err != nil {
+			return err
+		}
+	}
+}
+
+// dispatch handles one message: requests in their own goroutine,
+// notifications in order.
+func (s *Server) dispatch(msg *Message) error {
+	if !msg.IsRequest() {
+		s.onNotification(msg)
+		return nil
+	}
+
+	s.mu.Lock()
+	initialized := s.initialized
+	s.mu.Unlock()
+	if msg.Method == "initialize" {
+		return s.onInitialize(msg)
+	}
+	if !initialized {
+		return s.c.fail(msg.ID, CodeServerNotInitialized, "server not initialized")
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	key := string(msg.ID)
+	s.mu.Lock()
+	s.pending[key] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.pending, key)
+			s.mu.Unlock()
+			cancel()
+		}()
+
+		result, err := s.handle(ctx, msg)
+		switch {
+		case ctx.Err() != nil:
+			s.c.fail(msg.ID, CodeRequestCancelled, "request cancelled")
+		case err != nil:
+			var rerr *ResponseError
+			if errors.As(err, &rerr) {
+				s.c.fail(msg.ID, rerr.Code, "%s", rerr.Message)
+			} else {
+				s.c.fail(msg.ID, CodeInternalError, "%v", err)
+			}
+		default:
+			s.c.respond(msg.ID, result)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) onInitialize(msg *Message) error {
+	var params initializeParams
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.c.fail(msg.ID, CodeInvalidParams, "invalid initialize params: %v", err)
+		}
+	}
+
+	var roots []string
+	for _, f := range params.WorkspaceFolders {
+		if path, err := uriToPath(f.URI); err == nil {
+			roots = append(roots, path)
+		}
+	}
+	if len(roots) == 0 && params.RootURI != "" {
+		if path, err := uriToPath(params.RootURI); err == nil {
+			roots = append(roots, path)
+		}
+	}
+	if len(roots) == 0 && params.RootPath != "" {
+		roots = append(roots, params.RootPath)
+	}
+
+	s.mu.Lock()
+	s.ws = newWorkspace(roots, s.cfg.CacheDir)
+	s.initialized = true
+	s.mu.Unlock()
+
+	result := initializeResult{Capabilities: ServerCapabilities{
+		TextDocumentSync: textDocumentSyncOptions{
+			OpenClose: true,
+			Change:    1,
+			Save:      saveOptions{IncludeText: true},
+		},
+		CompletionProvider:         &completionOptions{TriggerCharacters: []string{">", "~", "-"}},
+		HoverProvider:              true,
+		DefinitionProvider:         true,
+		ImplementationProvider:     true,
+		ReferencesProvider:         true,
+		DocumentSymbolProvider:     true,
+		DocumentFormattingProvider: true,
+		TypeHierarchyProvider:      true,
+	}}
+	result.ServerInfo.Name = "vsp"
+	return s.c.respond(msg.ID, result)
+}
+
+// handle answers a request.
+func (s *Server) handle(ctx context.Context, msg *Message) (interface{}, error) {
+	switch msg.Method {
+	case "shutdown":
+		return nil, nil
+	case "textDocument/completion":
+		var p TextDocumentPositionParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return s.completion(ctx, p)
+	case "textDocument/definition", "textDocument/implementation":
+		var p TextDocumentPositionParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return s.definition(ctx, p, msg.Method == "textDocument/implementation")
+	case "textDocument/references":
+		var p referenceParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return s.references(ctx, p)
+	case "textDocument/hover":
+		var p TextDocumentPositionParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return s.hover(ctx, p)
+	case "textDocument/documentSymbol":
+		var p documentParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return s.documentSymbol(ctx, p)
+	case "textDocument/formatting":
+		var p documentParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return s.formatting(ctx, p)
+	case "textDocument/prepareTypeHierarchy":
+		var p TextDocumentPositionParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return s.prepareTypeHierarchy(p)
+	case "typeHierarchy/supertypes", "typeHierarchy/subtypes":
+		var p typeHierarchyParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return s.typeHierarchy(ctx, p, msg.Method == "typeHierarchy/supertypes")
+	}
+	return nil, &ResponseError{Code: CodeMethodNotFound, Message: "method not supported: " + msg.Method}
+}
+
+func decode(msg *Message, v interface{}) error {
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &ResponseError{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid %s params: %v", msg.Method, err)}
+	}
+	return nil
+}
+
+// --- Document synchronization ---
+
+func (s *Server) onNotification(msg *Message) {
+	switch msg.Method {
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if json.Unmarshal(msg.Params, &p) == nil {
+			s.open(p.TextDocument.URI, p.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var p didChangeParams
+		if json.Unmarshal(msg.Params, &p) == nil && len(p.ContentChanges) > 0 {
+			s.mu.Lock()
+			if doc, ok := s.docs[p.TextDocument.URI]; ok {
+				doc.text = p.ContentChanges[len(p.ContentChanges)-1].Text
+			}
+			s.mu.Unlock()
+		}
+	case "textDocument/didSave":
+		var p didSaveParams
+		if json.Unmarshal(msg.Params, &p) == nil {
+			s.onSave(p)
+		}
+	case "textDocument/didClose":
+		var p documentParams
+		if json.Unmarshal(msg.Params, &p) == nil {
+			s.mu.Lock()
+			delete(s.docs, p.TextDocument.URI)
+			s.mu.Unlock()
+			s.c.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		}
+	case "$/cancelRequest":
+		var p cancelParams
+		if json.Unmarshal(msg.Params, &p) == nil {
+			s.mu.Lock()
+			if cancel, ok := s.pending[string(p.ID)]; ok {
+				cancel()
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// open records an open document and maps it to its ABAP object.
+func (s *Server) open(uri, text string) *document {
+	doc := &document{uri: uri, text: text}
+	if path, err := uriToPath(uri); err == nil {
+		doc.path = path
+		if ref, err := refForFile(path); err == nil {
+			doc.ref, doc.mapped = ref, true
+		}
+	}
+
+	s.mu.Lock()
+	s.docs[uri] = doc
+	ws := s.ws
+	s.mu.Unlock()
+	if doc.mapped {
+		ws.add(doc.ref, doc.path)
+	}
+	return doc
+}
+
+// document returns a snapshot of a document, reading it from disk when the
+// client has not opened it.
+func (s *Server) document(uri string) (document, error) {
+	s.mu.Lock()
+	doc, ok := s.docs[uri]
+	var snapshot document
+	if ok {
+		snapshot = *doc
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		path, err := uriToPath(uri)
+		if err != nil {
+			return document{}, &ResponseError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return document{}, &ResponseError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		snapshot = *s.open(uri, string(data))
+	}
+	if !snapshot.mapped {
+		return document{}, &ResponseError{Code: CodeInvalidParams, Message: fmt.Sprintf("%s is not an ABAP source file with an abapGit name (e.g. zcl_foo.clas.abap)", filepath.Base(snapshot.path))}
+	}
+	return snapshot, nil
+}
+
+// onSave runs the syntax check of the saved document and publishes its
+// messages as diagnostics.
+func (s *Server) onSave(p didSaveParams) {
+	s.mu.Lock()
+	if doc, ok := s.docs[p.TextDocument.URI]; ok && p.Text != nil {
+		doc.text = *p.Text
+	}
+	s.mu.Unlock()
+
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		results, err := s.backend.SyntaxCheck(s.ctx, doc.ref.objectURL(), doc.text)
+		if err != nil {
+			s.c.notify("window/logMessage", map[string]interface{}{"type": 1, "message": fmt.Sprintf("syntax check of %s failed: %v", doc.ref.name, err)})
+			return
+		}
+		s.c.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{URI: doc.uri, Diagnostics: diagnostics(doc, results)})
+	}()
+}
+
+// diagnostics converts the syntax check messages for doc. Messages for other
+// includes of the object are dropped.
+func diagnostics(doc document, results []adt.SyntaxCheckResult) []Diagnostic {
+	diags := []Diagnostic{}
+	for _, r := range results {
+		if r.URI != "" {
+			if ref, ok := parseSourceURL(r.URI); ok && ref != doc.ref {
+				continue
+			}
+		}
+		line := r.Line - 1
+		if line < 0 {
+			line = 0
+		}
+		text := lineText(doc.text, line)
+		rng := Range{Start: Position{Line: line, Character: r.Offset}, End: Position{Line: line, Character: len(text)}}
+		if start, end, ok := wordAt(text, r.Offset); ok && start == r.Offset {
+			rng.End.Character = end
+		}
+		if rng.End.Character < rng.Start.Character {
+			rng.End.Character = rng.Start.Character
+		}
+
+		severity := SeverityInformation
+		switch r.Severity {
+		case "E", "A", "X":
+			severity = SeverityError
+		case "W":
+			severity = SeverityWarning
+		}
+		diags = append(diags, Diagnostic{Range: rng, Severity: severity, Source: "abap", Message: r.Text})
+	}
+	return diags
+}
+
+// --- Navigation ---
+
+// adtPosition matches the position fragment of ADT URIs.
+var adtPosition = regexp.MustCompile(`#start=(\d+)(?:,(\d+))?`)
+
+// location resolves an ADT source position (1-based line) to a local file,
+// downloading the source to the cache when the workspace has no file for it.
+func (s *Server) location(ctx context.Context, sourceURL string, line, column int) (*Location, error) {
+	ref, ok := parseSourceURL(sourceURL)
+	if !ok {
+		return nil, fmt.Errorf("unsupported ADT source %s", sourceURL)
+	}
+
+	s.mu.Lock()
+	ws := s.ws
+	s.mu.Unlock()
+
+	path, ok := ws.find(ref)
+	if !ok {
+		source, err := s.backend.Source(ctx, ref.sourceURL())
+		if err != nil {
+			return nil, err
+		}
+		if path, err = ws.cache(ref, source); err != nil {
+			return nil, err
+		}
+	}
+
+	pos := Position{Line: line - 1, Character: column}
+	if pos.Line < 0 {
+		pos.Line = 0
+	}
+	return &Location{URI: pathToURI(path), Range: Range{Start: pos, End: pos}}, nil
+}
+
+func (s *Server) definition(ctx context.Context, p TextDocumentPositionParams, implementation bool) (interface{}, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	start, end, ok := wordAt(lineText(doc.text, p.Position.Line), p.Position.Character)
+	if !ok {
+		return nil, nil
+	}
+	def, err := s.backend.FindDefinition(ctx, doc.ref.sourceURL(), doc.text, p.Position.Line+1, start, end, implementation, "")
+	if err != nil {
+		return nil, err
+	}
+	if def == nil || def.URL == "" {
+		return nil, nil
+	}
+	return s.location(ctx, def.URL, def.Line, def.Column)
+}
+
+func (s *Server) references(ctx context.Context, p referenceParams) (interface{}, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	column := p.Position.Character
+	if column == 0 {
+		// ADT ignores the position at column 0; any column of the word works.
+		if _, end, ok := wordAt(lineText(doc.text, p.Position.Line), 0); ok {
+			column = end
+		}
+	}
+	refs, err := s.backend.FindReferences(ctx, doc.ref.sourceURL(), p.Position.Line+1, column)
+	if err != nil {
+		return nil, err
+	}
+
+	locs := []Location{}
+	for _, r := range refs {
+		if !r.IsResult {
+			continue
+		}
+		line, col := 1, 0
+		if m := adtPosition.FindStringSubmatch(r.URI); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			col, _ = strconv.Atoi(m[2])
+		}
+		loc, err := s.location(ctx, r.URI, line, col)
+		if err != nil {
+			continue // e.g. usages in objects without ABAP source
+		}
+		locs = append(locs, *loc)
+	}
+	return locs, nil
+}
+
+// hover shows the statement defining the word under the cursor.
+func (s *Server) hover(ctx context.Context, p TextDocumentPositionParams) (interface{}, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	start, end, ok := wordAt(lineText(doc.text, p.Position.Line), p.Position.Character)
+	if !ok {
+		return nil, nil
+	}
+	def, err := s.backend.FindDefinition(ctx, doc.ref.sourceURL(), doc.text, p.Position.Line+1, start, end, false, "")
+	if err != nil || def == nil || def.URL == "" {
+		return nil, err
+	}
+
+	text := doc.text
+	if ref, ok := parseSourceURL(def.URL); !ok || ref != doc.ref {
+		loc, err := s.location(ctx, def.URL, def.Line, def.Column)
+		if err != nil {
+			return nil, err
+		}
+		text, err = s.text(loc.URI)
+		if err != nil {
+			return nil, err
+		}
+	}
+	stmt := statementAt(text, def.Line-1)
+	if stmt == "" {
+		return nil, nil
+	}
+	rng := Range{Start: Position{Line: p.Position.Line, Character: start}, End: Position{Line: p.Position.Line, Character: end}}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: "```abap\n" + stmt + "\n```"}, Range: &rng}, nil
+}
+
+// text returns the content of a document, open or on disk.
+func (s *Server) text(uri string) (string, error) {
+	s.mu.Lock()
+	doc, ok := s.docs[uri]
+	s.mu.Unlock()
+	if ok {
+		return doc.text, nil
+	}
+	path, err := uriToPath(uri)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+// --- Editing ---
+
+func (s *Server) completion(ctx context.Context, p TextDocumentPositionParams) (interface{}, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	proposals, err := s.backend.CodeCompletion(ctx, doc.ref.sourceURL(), doc.text, p.Position.Line+1, p.Position.Character)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &CompletionList{Items: []CompletionItem{}}
+	for i, prop := range proposals {
+		if prop.Identifier == "" || strings.HasPrefix(prop.Identifier, "@") {
+			continue
+		}
+		start := p.Position.Character - prop.PrefixLength
+		if start < 0 {
+			start = 0
+		}
+		list.Items = append(list.Items, CompletionItem{
+			Label:    prop.Identifier,
+			SortText: fmt.Sprintf("%05d", i),
+			TextEdit: &TextEdit{
+				Range:   Range{Start: Position{Line: p.Position.Line, Character: start}, End: p.Position},
+				NewText: prop.Identifier,
+			},
+		})
+	}
+	return list, nil
+}
+
+func (s *Server) formatting(ctx context.Context, p documentParams) (interface{}, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := s.backend.PrettyPrint(ctx, doc.text)
+	if err != nil {
+		return nil, err
+	}
+	if formatted == doc.text {
+		return []TextEdit{}, nil
+	}
+	return []TextEdit{{Range: Range{End: endPosition(doc.text)}, NewText: formatted}}, nil
+}
+
+// documentSymbol lists the symbols of the document. For the main source of
+// a class, components are described with their visibility and short text.
+func (s *Server) documentSymbol(ctx context.Context, p documentParams) (interface{}, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	syms := documentSymbols(doc.text)
+	if syms == nil {
+		syms = []DocumentSymbol{}
+	}
+	if doc.ref.objectType != "CLAS" || doc.ref.include != "" {
+		return syms, nil
+	}
+
+	structure, err := s.backend.GetClassComponents(ctx, adt.GetObjectURL(adt.ObjectTypeClass, doc.ref.name, ""))
+	if err != nil || structure == nil {
+		return syms, nil // the scanned symbols are still useful
+	}
+	components := make(map[string]adt.ClassComponent)
+	for _, c := range structure.Components {
+		components[strings.ToUpper(c.Name)] = c
+	}
+	for i := range syms {
+		if syms[i].Name != doc.ref.name {
+			continue
+		}
+		for j := range syms[i].Children {
+			child := &syms[i].Children[j]
+			if c, ok := components[child.Name]; ok {
+				child.Detail = strings.TrimSpace(strings.ToLower(c.Visibility) + " " + c.Description)
+			}
+		}
+	}
+	return syms, nil
+}
+
+// --- Type hierarchy ---
+
+func (s *Server) prepareTypeHierarchy(p TextDocumentPositionParams) (interface{}, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	line := lineText(doc.text, p.Position.Line)
+	start, end, ok := wordAt(line, p.Position.Character)
+	if !ok {
+		return nil, nil
+	}
+	rng := Range{Start: Position{Line: p.Position.Line, Character: start}, End: Position{Line: p.Position.Line, Character: end}}
+	return []TypeHierarchyItem{{
+		Name:           strings.ToUpper(line[start:end]),
+		Kind:           SymbolKindClass,
+		URI:            doc.uri,
+		Range:          rng,
+		SelectionRange: rng,
+		Data:           &p,
+	}}, nil
+}
+
+func (s *Server) typeHierarchy(ctx context.Context, p typeHierarchyParams, superTypes bool) (interface{}, error) {
+	if p.Item.Data == nil {
+		return nil, &ResponseError{Code: CodeInvalidParams, Message: "type hierarchy item has no position"}
+	}
+	pos := *p.Item.Data
+	doc, err := s.document(pos.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.backend.GetTypeHierarchy(ctx, doc.ref.sourceURL(), doc.text, pos.Position.Line+1, pos.Position.Character, superTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []TypeHierarchyItem{}
+	for _, n := range nodes {
+		if strings.EqualFold(n.Name, p.Item.Name) {
+			continue
+		}
+		line, col := n.Line, n.Column
+		if m := adtPosition.FindStringSubmatch(n.URI); m != nil && line == 0 {
+			line, _ = strconv.Atoi(m[1])
+			col, _ = strconv.Atoi(m[2])
+		}
+		loc, err := s.location(ctx, n.URI, line, col)
+		if err != nil {
+			continue
+		}
+		kind := SymbolKindClass
+		if strings.HasPrefix(n.Type, "INTF") {
+			kind = SymbolKindInterface
+		}
+		items = append(items, TypeHierarchyItem{
+			Name:           strings.ToUpper(n.Name),
+			Kind:           kind,
+			Detail:         n.Description,
+			URI:            loc.URI,
+			Range:          loc.Range,
+			SelectionRange: loc.Range,
+			Data:           &TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: loc.URI}, Position: loc.Range.Start},
+		})
+	}
+	return items, nil
+}
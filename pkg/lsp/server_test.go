@@ -0,0 +1,430 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// fakeBackend answers code intelligence requests for ZCL_FOO.
+type fakeBackend struct {
+	mu      sync.Mutex
+	checked []string // object URLs passed to SyntaxCheck
+	sources []string // source URLs passed to Source
+}
+
+func (f *fakeBackend) CodeCompletion(ctx context.Context, sourceURL, source string, line, column int) ([]adt.CompletionProposal, error) {
+	return []adt.CompletionProposal{
+		{Identifier: "LV_COUNT", PrefixLength: 3},
+		{Identifier: "LV_COUNTER", PrefixLength: 3},
+		{Identifier: "@end"},
+	}, nil
+}
+
+func (f *fakeBackend) FindDefinition(ctx context.Context, sourceURL, source string, line, startCol, endCol int, implementation bool, mainProgram string) (*adt.DefinitionLocation, error) {
+	word := strings.ToUpper(lineText(source, line-1)[startCol:endCol])
+	switch word {
+	case "ZCL_BAR":
+		return &adt.DefinitionLocation{URL: "/sap/bc/adt/oo/classes/zcl_bar/source/main", Line: 1, Column: 6}, nil
+	case "LV_COUNT":
+		return &adt.DefinitionLocation{URL: sourceURL, Line: 4, Column: 9}, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeBackend) FindReferences(ctx context.Context, objectURL string, line, column int) ([]adt.UsageReference, error) {
+	return []adt.UsageReference{
+		{URI: "/sap/bc/adt/oo/classes/zcl_foo", IsResult: false},
+		{URI: "/sap/bc/adt/programs/programs/ztest/source/main#start=3,2", IsResult: true},
+	}, nil
+}
+
+func (f *fakeBackend) GetClassComponents(ctx context.Context, classURL string) (*adt.ClassComponent, error) {
+	return &adt.ClassComponent{Name: "ZCL_FOO", Components: []adt.ClassComponent{
+		{Name: "RUN", Type: "CLAS/OM", Visibility: "PUBLIC", Description: "Run it"},
+	}}, nil
+}
+
+func (f *fakeBackend) GetTypeHierarchy(ctx context.Context, sourceURL, source string, line, column int, superTypes bool) ([]adt.HierarchyNode, error) {
+	return []adt.HierarchyNode{
+		{Name: "ZCL_FOO", URI: "/sap/bc/adt/oo/classes/zcl_foo/source/main", Line: 1},
+		{Name: "ZCL_BAR", Type: "CLAS/OC", URI: "/sap/bc/adt/oo/classes/zcl_bar/source/main", Line: 1, Column: 6},
+	}, nil
+}
+
+func (f *fakeBackend) PrettyPrint(ctx context.Context, source string) (string, error) {
+	return strings.ToUpper(source), nil
+}
+
+func (f *fakeBackend) SyntaxCheck(ctx context.Context, objectURL, content string) ([]adt.SyntaxCheckResult, error) {
+	f.mu.Lock()
+	f.checked = append(f.checked, objectURL)
+	f.mu.Unlock()
+	return []adt.SyntaxCheckResult{
+		{URI: "/sap/bc/adt/oo/classes/zcl_foo/source/main#start=5,4", Line: 5, Offset: 4, Severity: "E", Text: "LV_X is unknown"},
+		{URI: "/sap/bc/adt/oo/classes/zcl_foo/includes/testclasses#start=1,0", Line: 1, Severity: "W", Text: "in another include"},
+	}, nil
+}
+
+func (f *fakeBackend) Source(ctx context.Context, sourceURL string) (string, error) {
+	f.mu.Lock()
+	f.sources = append(f.sources, sourceURL)
+	f.mu.Unlock()
+	return "CLASS zcl_bar DEFINITION PUBLIC.\nENDCLASS.\n", nil
+}
+
+const fooSource = `CLASS zcl_foo DEFINITION PUBLIC INHERITING FROM zcl_bar.
+  PUBLIC SECTION.
+    METHODS run.
+    DATA lv_count TYPE i.
+ENDCLASS.
+
+CLASS zcl_foo IMPLEMENTATION.
+  METHOD run.
+    lv_count = lv_cou
+  ENDMETHOD.
+ENDCLASS.
+`
+
+// testClient drives a Server over in-memory pipes.
+type testClient struct {
+	t    *testing.T
+	w    io.Writer
+	msgs chan map[string]interface{}
+	seq  int
+	done chan error
+}
+
+func startServer(t *testing.T, backend Backend, cfg Config) *testClient {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	c := &testClient{t: t, w: clientW, msgs: make(chan map[string]interface{}, 100), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer(backend, cfg).Serve(context.Background(), serverR, serverW)
+		serverW.Close()
+	}()
+	go func() {
+		br := bufio.NewReader(clientR)
+		for {
+			data, err := ReadMessage(br)
+			if err != nil {
+				close(c.msgs)
+				return
+			}
+			var m map[string]interface{}
+			_ = json.Unmarshal(data, &m)
+			c.msgs <- m
+		}
+	}()
+	t.Cleanup(func() { clientW.Close() })
+	return c
+}
+
+// request sends a request and returns its response.
+func (c *testClient) request(method string, params interface{}) map[string]interface{} {
+	c.t.Helper()
+	c.seq++
+	if err := WriteMessage(c.w, map[string]interface{}{"jsonrpc": "2.0", "id": c.seq, "method": method, "params": params}); err != nil {
+		c.t.Fatalf("write %s: %v", method, err)
+	}
+	for {
+		m := c.next()
+		if m["id"] == float64(c.seq) {
+			return m
+		}
+	}
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	c.t.Helper()
+	if err := WriteMessage(c.w, map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}); err != nil {
+		c.t.Fatalf("write %s: %v", method, err)
+	}
+}
+
+// waitNotification returns the params of the next notification with the given method.
+func (c *testClient) waitNotification(method string) map[string]interface{} {
+	c.t.Helper()
+	for {
+		m := c.next()
+		if m["method"] == method {
+			p, _ := m["params"].(map[string]interface{})
+			return p
+		}
+	}
+}
+
+func (c *testClient) next() map[string]interface{} {
+	c.t.Helper()
+	select {
+	case m, ok := <-c.msgs:
+		if !ok {
+			c.t.Fatal("connection closed")
+		}
+		return m
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timeout waiting for message")
+	}
+	return nil
+}
+
+// result decodes the result of a response into v.
+func result(t *testing.T, m map[string]interface{}, v interface{}) {
+	t.Helper()
+	if m["error"] != nil {
+		t.Fatalf("error response: %v", m["error"])
+	}
+	data, _ := json.Marshal(m["result"])
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decode result %s: %v", data, err)
+	}
+}
+
+// setup creates a workspace with zcl_foo.clas.abap and ztest.prog.abap, and
+// an initialized server with zcl_foo opened.
+func setup(t *testing.T) (*testClient, *fakeBackend, string, string) {
+	t.Helper()
+	root := t.TempDir()
+	fooPath := filepath.Join(root, "src", "zcl_foo.clas.abap")
+	os.MkdirAll(filepath.Dir(fooPath), 0755)
+	os.WriteFile(fooPath, []byte(fooSource), 0644)
+	os.WriteFile(filepath.Join(root, "src", "ztest.prog.abap"), []byte("REPORT ztest.\n\nNEW zcl_foo( )->run( ).\n"), 0644)
+
+	backend := &fakeBackend{}
+	c := startServer(t, backend, Config{CacheDir: filepath.Join(t.TempDir(), "cache")})
+	result(t, c.request("initialize", map[string]interface{}{"rootUri": pathToURI(root)}), &map[string]interface{}{})
+	c.notify("initialized", map[string]interface{}{})
+
+	uri := pathToURI(fooPath)
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "abap", "version": 1, "text": fooSource},
+	})
+	return c, backend, root, uri
+}
+
+func position(uri string, line, char int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"position":     map[string]interface{}{"line": line, "character": char},
+	}
+}
+
+func TestServerNotInitialized(t *testing.T) {
+	c := startServer(t, &fakeBackend{}, Config{})
+	resp := c.request("textDocument/hover", position("file:///x.prog.abap", 0, 0))
+	e, _ := resp["error"].(map[string]interface{})
+	if e == nil || e["code"] != float64(CodeServerNotInitialized) {
+		t.Fatalf("want ServerNotInitialized, got %v", resp)
+	}
+}
+
+func TestServerCompletion(t *testing.T) {
+	c, _, _, uri := setup(t)
+
+	var list CompletionList
+	result(t, c.request("textDocument/completion", position(uri, 8, 21)), &list)
+	if len(list.Items) != 2 {
+		t.Fatalf("want 2 items (@end dropped), got %+v", list.Items)
+	}
+	item := list.Items[0]
+	if item.Label != "LV_COUNT" || item.TextEdit == nil {
+		t.Fatalf("unexpected item %+v", item)
+	}
+	if item.TextEdit.Range.Start.Character != 18 || item.TextEdit.Range.End.Character != 21 {
+		t.Errorf("edit should replace the typed prefix, got %+v", item.TextEdit.Range)
+	}
+}
+
+func TestServerDefinition(t *testing.T) {
+	c, backend, _, uri := setup(t)
+
+	// Same document
+	var loc Location
+	result(t, c.request("textDocument/definition", position(uri, 8, 6)), &loc)
+	if loc.URI != uri || loc.Range.Start.Line != 3 || loc.Range.Start.Character != 9 {
+		t.Errorf("unexpected local definition %+v", loc)
+	}
+
+	// Object without a local file is downloaded to the cache
+	result(t, c.request("textDocument/definition", position(uri, 0, 50)), &loc)
+	if !strings.HasSuffix(loc.URI, "/cache/zcl_bar.clas.abap") || loc.Range.Start.Line != 0 {
+		t.Fatalf("unexpected cached definition %+v", loc)
+	}
+	path, _ := uriToPath(loc.URI)
+	if data, err := os.ReadFile(path); err != nil || !strings.HasPrefix(string(data), "CLASS zcl_bar") {
+		t.Errorf("cached source: %q, %v", data, err)
+	}
+	if len(backend.sources) != 1 || backend.sources[0] != "/sap/bc/adt/oo/classes/ZCL_BAR/source/main" {
+		t.Errorf("unexpected source requests %v", backend.sources)
+	}
+
+	// No identifier under the cursor
+	resp := c.request("textDocument/definition", position(uri, 5, 0))
+	if resp["result"] != nil {
+		t.Errorf("want null result on an empty line, got %v", resp["result"])
+	}
+}
+
+func TestServerReferences(t *testing.T) {
+	c, _, root, uri := setup(t)
+
+	var locs []Location
+	result(t, c.request("textDocument/references", position(uri, 2, 12)), &locs)
+	if len(locs) != 1 {
+		t.Fatalf("want 1 reference, got %+v", locs)
+	}
+	if locs[0].URI != pathToURI(filepath.Join(root, "src", "ztest.prog.abap")) {
+		t.Errorf("reference should map to the workspace file, got %s", locs[0].URI)
+	}
+	if locs[0].Range.Start.Line != 2 || locs[0].Range.Start.Character != 2 {
+		t.Errorf("unexpected range %+v", locs[0].Range)
+	}
+}
+
+func TestServerHover(t *testing.T) {
+	c, _, _, uri := setup(t)
+
+	var hover Hover
+	result(t, c.request("textDocument/hover", position(uri, 8, 6)), &hover)
+	if !strings.Contains(hover.Contents.Value, "DATA lv_count TYPE i.") || hover.Contents.Kind != "markdown" {
+		t.Errorf("unexpected hover %+v", hover.Contents)
+	}
+}
+
+func TestServerDocumentSymbols(t *testing.T) {
+	c, _, _, uri := setup(t)
+
+	var syms []DocumentSymbol
+	result(t, c.request("textDocument/documentSymbol", map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri}}), &syms)
+	if len(syms) != 2 {
+		t.Fatalf("want definition and implementation, got %+v", syms)
+	}
+	def := syms[0]
+	if def.Name != "ZCL_FOO" || def.Kind != SymbolKindClass || def.Range.End.Line != 4 {
+		t.Errorf("unexpected class symbol %+v", def)
+	}
+	if len(def.Children) != 2 || def.Children[0].Name != "RUN" || def.Children[0].Detail != "public Run it" {
+		t.Errorf("unexpected components %+v", def.Children)
+	}
+	if def.Children[1].Kind != SymbolKindField {
+		t.Errorf("DATA should be a field, got %+v", def.Children[1])
+	}
+	if impl := syms[1]; len(impl.Children) != 1 || impl.Children[0].Range.End.Line != 9 {
+		t.Errorf("unexpected implementation %+v", impl)
+	}
+}
+
+func TestServerFormatting(t *testing.T) {
+	c, _, _, uri := setup(t)
+
+	var edits []TextEdit
+	result(t, c.request("textDocument/formatting", map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri}}), &edits)
+	if len(edits) != 1 || edits[0].NewText != strings.ToUpper(fooSource) {
+		t.Fatalf("unexpected edits %+v", edits)
+	}
+	if end := edits[0].Range.End; end.Line != 11 || end.Character != 0 {
+		t.Errorf("edit should span the document, got %+v", edits[0].Range)
+	}
+}
+
+func TestServerDiagnosticsOnSave(t *testing.T) {
+	c, backend, _, uri := setup(t)
+
+	c.notify("textDocument/didSave", map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri}, "text": fooSource})
+	p := c.waitNotification("textDocument/publishDiagnostics")
+	if p["uri"] != uri {
+		t.Fatalf("diagnostics for %v", p["uri"])
+	}
+	var diags []Diagnostic
+	data, _ := json.Marshal(p["diagnostics"])
+	json.Unmarshal(data, &diags)
+	if len(diags) != 1 {
+		t.Fatalf("want the message of this include only, got %+v", diags)
+	}
+	d := diags[0]
+	if d.Severity != SeverityError || d.Range.Start.Line != 4 || d.Range.Start.Character != 4 || d.Message != "LV_X is unknown" {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+	if len(backend.checked) != 1 || backend.checked[0] != "/sap/bc/adt/oo/classes/ZCL_FOO" {
+		t.Errorf("unexpected syntax check URL %v", backend.checked)
+	}
+
+	c.notify("textDocument/didClose", map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri}})
+	p = c.waitNotification("textDocument/publishDiagnostics")
+	if d, _ := p["diagnostics"].([]interface{}); len(d) != 0 {
+		t.Errorf("close should clear diagnostics, got %v", p)
+	}
+}
+
+func TestServerTypeHierarchy(t *testing.T) {
+	c, _, _, uri := setup(t)
+
+	var items []TypeHierarchyItem
+	result(t, c.request("textDocument/prepareTypeHierarchy", position(uri, 0, 8)), &items)
+	if len(items) != 1 || items[0].Name != "ZCL_FOO" {
+		t.Fatalf("unexpected prepare result %+v", items)
+	}
+	var supers []TypeHierarchyItem
+	result(t, c.request("typeHierarchy/supertypes", map[string]interface{}{"item": items[0]}), &supers)
+	if len(supers) != 1 || supers[0].Name != "ZCL_BAR" || !strings.HasSuffix(supers[0].URI, "zcl_bar.clas.abap") {
+		t.Errorf("unexpected supertypes %+v", supers)
+	}
+}
+
+func TestServerShutdownExit(t *testing.T) {
+	c, _, _, _ := setup(t)
+
+	if resp := c.request("shutdown", nil); resp["error"] != nil {
+		t.Fatalf("shutdown: %v", resp["error"])
+	}
+	c.notify("exit", nil)
+	select {
+	case err := <-c.done:
+		if err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not exit")
+	}
+}
+
+func TestParseSourceURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want sourceRef
+		file string
+	}{
+		{"/sap/bc/adt/programs/programs/ztest/source/main#start=3,2", sourceRef{objectType: "PROG", name: "ZTEST"}, "ztest.prog.abap"},
+		{"/sap/bc/adt/oo/classes/%2fdmo%2fcl_flight/source/main", sourceRef{objectType: "CLAS", name: "/DMO/CL_FLIGHT"}, "#dmo#cl_flight.clas.abap"},
+		{"/sap/bc/adt/oo/classes/zcl_foo/includes/testclasses", sourceRef{objectType: "CLAS", name: "ZCL_FOO", include: "testclasses"}, "zcl_foo.clas.testclasses.abap"},
+		{"/sap/bc/adt/oo/interfaces/zif_foo", sourceRef{objectType: "INTF", name: "ZIF_FOO"}, "zif_foo.intf.abap"},
+		{"/sap/bc/adt/functions/groups/zfg/fmodules/z_run/source/main", sourceRef{objectType: "FUNC", name: "Z_RUN", parent: "ZFG"}, "zfg.fugr.z_run.func.abap"},
+		{"/sap/bc/adt/ddic/ddl/sources/zi_foo/source/main", sourceRef{objectType: "DDLS", name: "ZI_FOO"}, "zi_foo.ddls.asddls"},
+	}
+	for _, tt := range tests {
+		got, ok := parseSourceURL(tt.url)
+		if !ok || got != tt.want {
+			t.Errorf("parseSourceURL(%s) = %+v, %v; want %+v", tt.url, got, ok, tt.want)
+		}
+		if file := got.fileName(); file != tt.file {
+			t.Errorf("fileName(%+v) = %s, want %s", got, file, tt.file)
+		}
+		if back, ok := refFromFileName(tt.file); tt.want.objectType != "DDLS" && (!ok || back != tt.want) {
+			t.Errorf("refFromFileName(%s) = %+v, %v", tt.file, back, ok)
+		}
+	}
+	if _, ok := parseSourceURL("/sap/bc/adt/packages/ztest"); ok {
+		t.Error("packages have no source")
+	}
+}
@@ -0,0 +1,191 @@
+package lsp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// symbolStatement matches the ABAP statements that open, close or declare a
+// document symbol, with the symbol name.
+var symbolStatement = regexp.MustCompile(`(?i)^(\s*)(CLASS-METHODS|CLASS-DATA|CLASS-EVENTS|CLASS|INTERFACE|METHODS|METHOD|FORM|MODULE|FUNCTION|DATA|CONSTANTS|EVENTS|ENDCLASS|ENDINTERFACE|ENDMETHOD|ENDFORM|ENDMODULE|ENDFUNCTION)\b:?\s*([A-Za-z0-9_/~]*)(.*)$`)
+
+// containers maps statements opening a symbol to the statement closing it.
+var containers = map[string]string{
+	"CLASS":     "ENDCLASS",
+	"INTERFACE": "ENDINTERFACE",
+	"METHOD":    "ENDMETHOD",
+	"FORM":      "ENDFORM",
+	"MODULE":    "ENDMODULE",
+	"FUNCTION":  "ENDFUNCTION",
+}
+
+// declarations are symbols declared in class definitions and interfaces.
+var declarations = map[string]int{
+	"METHODS":       SymbolKindMethod,
+	"CLASS-METHODS": SymbolKindMethod,
+	"DATA":          SymbolKindField,
+	"CLASS-DATA":    SymbolKindField,
+	"CONSTANTS":     SymbolKindConstant,
+	"EVENTS":        SymbolKindEvent,
+	"CLASS-EVENTS":  SymbolKindEvent,
+}
+
+type symbolNode struct {
+	sym      DocumentSymbol
+	end      string // closing statement
+	declares bool   // class definition or interface: collects declarations
+	children []*symbolNode
+}
+
+// documentSymbols scans ABAP source for classes, interfaces, methods, form
+// routines, modules and function modules, and for the components declared
+// in class definitions and interfaces.
+func documentSymbols(text string) []DocumentSymbol {
+	var roots []*symbolNode
+	var stack []*symbolNode
+	add := func(n *symbolNode) {
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			top.children = append(top.children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "*") || strings.HasPrefix(strings.TrimSpace(line), "\"") {
+			continue
+		}
+		m := symbolStatement.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		keyword, name, rest := strings.ToUpper(m[2]), m[3], strings.ToUpper(m[4])
+		nameCol := strings.Index(line[len(m[1])+len(m[2]):], name) + len(m[1]) + len(m[2])
+		lineRange := Range{Start: Position{Line: i}, End: Position{Line: i, Character: len(line)}}
+		nameRange := Range{Start: Position{Line: i, Character: nameCol}, End: Position{Line: i, Character: nameCol + len(name)}}
+
+		if strings.HasPrefix(keyword, "END") {
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].end == keyword {
+					stack[j].sym.Range.End = lineRange.End
+					stack = stack[:j]
+					break
+				}
+			}
+			continue
+		}
+		if name == "" {
+			continue
+		}
+
+		if end, ok := containers[keyword]; ok {
+			// CLASS x DEFINITION DEFERRED/LOAD and INTERFACE x DEFERRED declare nothing
+			if strings.Contains(rest, "DEFERRED") || strings.Contains(rest, " LOAD") {
+				continue
+			}
+			n := &symbolNode{
+				sym: DocumentSymbol{Name: strings.ToUpper(name), Range: lineRange, SelectionRange: nameRange},
+				end: end,
+			}
+			switch keyword {
+			case "CLASS":
+				n.sym.Kind = SymbolKindClass
+				n.declares = strings.Contains(rest, "DEFINITION")
+				n.sym.Detail = "implementation"
+				if n.declares {
+					n.sym.Detail = "definition"
+				}
+			case "INTERFACE":
+				n.sym.Kind = SymbolKindInterface
+				n.declares = true
+			case "METHOD":
+				n.sym.Kind = SymbolKindMethod
+			case "MODULE":
+				n.sym.Kind = SymbolKindModule
+			default:
+				n.sym.Kind = SymbolKindFunction
+			}
+			add(n)
+			stack = append(stack, n)
+			continue
+		}
+
+		if kind, ok := declarations[keyword]; ok && len(stack) > 0 && stack[len(stack)-1].declares {
+			if strings.HasPrefix(rest, " BEGIN OF") || strings.EqualFold(name, "BEGIN") {
+				continue
+			}
+			add(&symbolNode{sym: DocumentSymbol{Name: strings.ToUpper(name), Kind: kind, Range: lineRange, SelectionRange: nameRange}})
+		}
+	}
+
+	var convert func([]*symbolNode) []DocumentSymbol
+	convert = func(nodes []*symbolNode) []DocumentSymbol {
+		var syms []DocumentSymbol
+		for _, n := range nodes {
+			n.sym.Children = convert(n.children)
+			syms = append(syms, n.sym)
+		}
+		return syms
+	}
+	return convert(roots)
+}
+
+// wordAt returns the ABAP identifier at a character offset of line, as a
+// byte range. Namespaces (/DMO/CL_X) are part of the identifier; component
+// selectors (-, ->, =>, ~) are not.
+func wordAt(line string, char int) (start, end int, ok bool) {
+	isIdent := func(c byte) bool {
+		return c == '_' || c == '/' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	}
+	if char > len(line) {
+		char = len(line)
+	}
+	start, end = char, char
+	for start > 0 && isIdent(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdent(line[end]) {
+		end++
+	}
+	return start, end, start < end
+}
+
+// statementAt returns the ABAP statement starting at a 0-based line, up to
+// the period ending it (at most 10 lines).
+func statementAt(text string, line int) string {
+	lines := strings.Split(text, "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	var stmt []string
+	for i := line; i < len(lines) && i < line+10; i++ {
+		l := strings.TrimRight(lines[i], "\r")
+		stmt = append(stmt, l)
+		code := l
+		if j := strings.Index(code, "\""); j >= 0 {
+			code = code[:j]
+		}
+		if strings.HasSuffix(strings.TrimSpace(code), ".") || strings.Contains(code, ". ") {
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(stmt, "\n"))
+}
+
+// lineText returns a 0-based line of text.
+func lineText(text string, line int) string {
+	lines := strings.Split(text, "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[line], "\r")
+}
+
+// endPosition returns the position after the last character of text.
+func endPosition(text string) Position {
+	lines := strings.Split(text, "\n")
+	return Position{Line: len(lines) - 1, Character: len(lines[len(lines)-1])}
+}
@@ -0,0 +1,325 @@
+package lsp
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// sourceRef identifies the ABAP source behind a local file or ADT URL.
+type sourceRef struct {
+	objectType string // GetSource type: PROG, INCL, CLAS, INTF, FUNC, DDLS, BDEF, SRVD
+	name       string
+	parent     string // function group of a function module
+	include    string // class include: definitions, implementations, macros, testclasses
+}
+
+// abapGit file suffixes of class includes, by ADT include type.
+var classIncludeFiles = map[string]string{
+	string(adt.ClassIncludeDefinitions):     "locals_def",
+	string(adt.ClassIncludeImplementations): "locals_imp",
+	string(adt.ClassIncludeMacros):          "macros",
+	string(adt.ClassIncludeTestClasses):     "testclasses",
+}
+
+func (r sourceRef) creatableType() adt.CreatableObjectType {
+	switch r.objectType {
+	case "PROG":
+		return adt.ObjectTypeProgram
+	case "INCL":
+		return adt.ObjectTypeInclude
+	case "CLAS":
+		return adt.ObjectTypeClass
+	case "INTF":
+		return adt.ObjectTypeInterface
+	case "FUNC":
+		return adt.ObjectTypeFunctionMod
+	case "DDLS":
+		return adt.ObjectTypeDDLS
+	case "BDEF":
+		return adt.ObjectTypeBDEF
+	case "SRVD":
+		return adt.ObjectTypeSRVD
+	}
+	return ""
+}
+
+// sourceURL returns the ADT URL of the source, e.g. for code completion.
+func (r sourceRef) sourceURL() string {
+	if r.objectType == "CLAS" && r.include != "" {
+		return adt.GetClassIncludeSourceURL(r.name, adt.ClassIncludeType(r.include))
+	}
+	return adt.GetSourceURL(r.creatableType(), r.name, r.parent)
+}
+
+// objectURL returns the ADT URL the syntax check expects: the object, or
+// the include for class includes.
+func (r sourceRef) objectURL() string {
+	if r.objectType == "CLAS" && r.include != "" {
+		return adt.GetClassIncludeURL(r.name, adt.ClassIncludeType(r.include))
+	}
+	return adt.GetObjectURL(r.creatableType(), r.name, r.parent)
+}
+
+// fileName returns the abapGit file name of the source.
+func (r sourceRef) fileName() string {
+	base := func(name string) string {
+		return strings.ReplaceAll(strings.ToLower(name), "/", "#")
+	}
+	switch r.objectType {
+	case "PROG", "INCL":
+		return base(r.name) + ".prog.abap"
+	case "CLAS":
+		if suffix, ok := classIncludeFiles[r.include]; ok {
+			return base(r.name) + ".clas." + suffix + ".abap"
+		}
+		return base(r.name) + ".clas.abap"
+	case "INTF":
+		return base(r.name) + ".intf.abap"
+	case "FUNC":
+		return base(r.parent) + ".fugr." + base(r.name) + ".func.abap"
+	case "DDLS":
+		return base(r.name) + ".ddls.asddls"
+	case "BDEF":
+		return base(r.name) + ".bdef.asbdef"
+	case "SRVD":
+		return base(r.name) + ".srvd.srvdsrv"
+	}
+	return base(r.name) + ".abap"
+}
+
+// parseSourceURL maps an ADT source or object URL (with or without a
+// #start= fragment) to the object.
+func parseSourceURL(u string) (sourceRef, bool) {
+	if i := strings.IndexAny(u, "#?"); i >= 0 {
+		u = u[:i]
+	}
+	u = strings.TrimPrefix(u, "/sap/bc/adt/")
+	parts := strings.Split(u, "/")
+	for i, p := range parts {
+		if unescaped, err := url.PathUnescape(p); err == nil {
+			parts[i] = unescaped
+		}
+	}
+	// Namespaced names arrive escaped (%2fDMO%2fCL_X) and stay one segment.
+	at := func(i int) string {
+		if i < len(parts) {
+			return strings.ToUpper(parts[i])
+		}
+		return ""
+	}
+
+	var ref sourceRef
+	switch {
+	case len(parts) >= 3 && parts[0] == "programs" && parts[1] == "programs":
+		ref = sourceRef{objectType: "PROG", name: at(2)}
+	case len(parts) >= 3 && parts[0] == "programs" && parts[1] == "includes":
+		ref = sourceRef{objectType: "INCL", name: at(2)}
+	case len(parts) >= 3 && parts[0] == "oo" && parts[1] == "classes":
+		ref = sourceRef{objectType: "CLAS", name: at(2)}
+		if at(3) == "INCLUDES" && len(parts) > 4 && parts[4] != "main" {
+			ref.include = strings.ToLower(parts[4])
+		}
+	case len(parts) >= 3 && parts[0] == "oo" && parts[1] == "interfaces":
+		ref = sourceRef{objectType: "INTF", name: at(2)}
+	case len(parts) >= 5 && parts[0] == "functions" && parts[1] == "groups" && parts[3] == "fmodules":
+		ref = sourceRef{objectType: "FUNC", name: at(4), parent: at(2)}
+	case len(parts) >= 5 && parts[0] == "functions" && parts[1] == "groups" && parts[3] == "includes":
+		ref = sourceRef{objectType: "INCL", name: at(4)}
+	case len(parts) >= 4 && parts[0] == "ddic" && parts[1] == "ddl" && parts[2] == "sources":
+		ref = sourceRef{objectType: "DDLS", name: at(3)}
+	case len(parts) >= 3 && parts[0] == "bo" && parts[1] == "behaviordefinitions":
+		ref = sourceRef{objectType: "BDEF", name: at(2)}
+	case len(parts) >= 4 && parts[0] == "ddic" && parts[1] == "srvd" && parts[2] == "sources":
+		ref = sourceRef{objectType: "SRVD", name: at(3)}
+	default:
+		return sourceRef{}, false
+	}
+	return ref, ref.name != ""
+}
+
+// refForFile maps a local file to its object with ParseABAPFile. Files whose
+// content it cannot parse (e.g. includes without a REPORT statement) are
+// mapped by their abapGit name alone.
+func refForFile(path string) (sourceRef, error) {
+	info, err := adt.ParseABAPFile(path)
+	if err != nil {
+		if ref, ok := refFromFileName(path); ok {
+			return ref, nil
+		}
+		return sourceRef{}, err
+	}
+	ref := sourceRef{name: strings.ToUpper(info.ObjectName)}
+	switch info.ObjectType {
+	case adt.ObjectTypeProgram:
+		ref.objectType = "PROG"
+	case adt.ObjectTypeInclude:
+		ref.objectType = "INCL"
+	case adt.ObjectTypeClass:
+		ref.objectType = "CLAS"
+		if info.ClassIncludeType != "" && info.ClassIncludeType != adt.ClassIncludeMain {
+			ref.include = string(info.ClassIncludeType)
+		}
+	case adt.ObjectTypeInterface:
+		ref.objectType = "INTF"
+	case adt.ObjectTypeFunctionMod:
+		ref.objectType = "FUNC"
+		ref.parent = strings.ToUpper(info.ParentName)
+	case adt.ObjectTypeDDLS:
+		ref.objectType = "DDLS"
+	case adt.ObjectTypeBDEF:
+		ref.objectType = "BDEF"
+	case adt.ObjectTypeSRVD:
+		ref.objectType = "SRVD"
+	default:
+		return sourceRef{}, fmt.Errorf("%s: object type %s has no ABAP source", filepath.Base(path), info.ObjectType)
+	}
+	return ref, nil
+}
+
+// refFromFileName maps an abapGit file name (name.prog.abap, ...) to its object.
+func refFromFileName(path string) (sourceRef, bool) {
+	parts := strings.Split(strings.ToLower(filepath.Base(path)), ".")
+	if len(parts) < 3 {
+		return sourceRef{}, false
+	}
+	name := strings.ToUpper(strings.ReplaceAll(parts[0], "#", "/"))
+	switch {
+	case len(parts) == 3 && parts[1] == "prog" && parts[2] == "abap":
+		return sourceRef{objectType: "PROG", name: name}, true
+	case len(parts) == 3 && parts[1] == "clas" && parts[2] == "abap":
+		return sourceRef{objectType: "CLAS", name: name}, true
+	case len(parts) == 4 && parts[1] == "clas" && parts[3] == "abap":
+		for include, suffix := range classIncludeFiles {
+			if parts[2] == suffix {
+				return sourceRef{objectType: "CLAS", name: name, include: include}, true
+			}
+		}
+	case len(parts) == 3 && parts[1] == "intf" && parts[2] == "abap":
+		return sourceRef{objectType: "INTF", name: name}, true
+	case len(parts) == 5 && parts[1] == "fugr" && parts[3] == "func" && parts[4] == "abap":
+		return sourceRef{objectType: "FUNC", name: strings.ToUpper(strings.ReplaceAll(parts[2], "#", "/")), parent: name}, true
+	}
+	return sourceRef{}, false
+}
+
+// isSourceFile reports whether a file name has an abapGit source extension.
+func isSourceFile(name string) bool {
+	for _, ext := range []string{".abap", ".asddls", ".asbdef", ".srvdsrv"} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// workspace finds local files for ADT objects and caches the source of
+// objects that have none.
+type workspace struct {
+	roots    []string
+	cacheDir string
+
+	mu      sync.Mutex
+	files   map[sourceRef]string // object -> local file
+	indexed bool
+}
+
+func newWorkspace(roots []string, cacheDir string) *workspace {
+	return &workspace{roots: roots, cacheDir: cacheDir, files: make(map[sourceRef]string)}
+}
+
+// add records the local file of an object (e.g. an opened document).
+func (w *workspace) add(ref sourceRef, path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files[ref] = path
+}
+
+// find returns the local file of an object, indexing the workspace on first use.
+func (w *workspace) find(ref sourceRef) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.indexed {
+		w.indexed = true
+		for _, root := range w.roots {
+			w.index(root)
+		}
+	}
+	path, ok := w.files[ref]
+	return path, ok
+}
+
+func (w *workspace) index(root string) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isSourceFile(d.Name()) {
+			return nil
+		}
+		if ref, err := refForFile(path); err == nil {
+			if _, seen := w.files[ref]; !seen {
+				w.files[ref] = path
+			}
+		}
+		return nil
+	})
+}
+
+// cache writes the source of an object without a local file to the cache
+// directory, read-only, and returns the file.
+func (w *workspace) cache(ref sourceRef, source string) (string, error) {
+	if w.cacheDir == "" {
+		return "", fmt.Errorf("no cache directory for %s", ref.name)
+	}
+	if err := os.MkdirAll(w.cacheDir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(w.cacheDir, ref.fileName())
+	os.Remove(path) // read-only from a previous download
+	if err := os.WriteFile(path, []byte(source), 0444); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// uriToPath converts a file:// URI to a local path.
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI scheme %q (only file:// documents map to ABAP objects)", u.Scheme)
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/") // /C:/src -> C:/src
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// pathToURI converts a local path to a file:// URI.
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Windows drive letter
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
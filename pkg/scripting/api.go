@@ -0,0 +1,139 @@
+package scripting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// BindingDoc describes one ADT binding for the generated API reference.
+type BindingDoc struct {
+	Section     string
+	Name        string
+	Signature   string // call form, e.g. "getSource(type, name, [include])"
+	Returns     string // Lua shape of the result
+	Description string
+}
+
+// APIDocs lists every ADT binding, grouped by section in reference order.
+// Errors are always returned as (nil, message) or (false, message).
+var APIDocs = []BindingDoc{
+	// Search & Source
+	{"Search & Source", "searchObject", "searchObject(query, [maxResults=100])", "{ {name, type, uri, package}, ... }", "Search ABAP objects by name pattern"},
+	{"Search & Source", "grepObjects", "grepObjects(pattern, [objectQuery], [contextLines=0])", "{ {uri, name, line, content}, ... }", "Regex search in object sources"},
+	{"Search & Source", "getSource", "getSource(type, name, [include])", "string", "Read source code"},
+	{"Search & Source", "writeSource", "writeSource(type, name, source)", "true", "Create or update source (lock, write, activate)"},
+	{"Search & Source", "editSource", "editSource(objectURI, old, new, [replaceAll])", "true", "Replace text in source and activate"},
+
+	// Debugging
+	{"Debugging", "setBreakpoint", "setBreakpoint(program, line)", "breakpoint id", "Set a line breakpoint"},
+	{"Debugging", "setStatementBP", "setStatementBP(statement)", "breakpoint id", "Break on an ABAP statement, e.g. \"COMMIT\""},
+	{"Debugging", "setExceptionBP", "setExceptionBP(exception)", "breakpoint id", "Break when an exception class is raised"},
+	{"Debugging", "setMessageBP", "setMessageBP(class, number, [type])", "breakpoint id", "Break on a message"},
+	{"Debugging", "setBadiBP", "setBadiBP(badiName)", "breakpoint id", "Break on a BAdI call"},
+	{"Debugging", "setEnhancementBP", "setEnhancementBP(spot, [implementation])", "breakpoint id", "Break on an enhancement point"},
+	{"Debugging", "setWatchpoint", "setWatchpoint(variable, [condition])", "breakpoint id", "Break when a variable changes"},
+	{"Debugging", "setMethodBP", "setMethodBP(class, method)", "breakpoint id", "Break on method entry"},
+	{"Debugging", "setLogpoint", "setLogpoint(program, line, message)", "breakpoint id", "Breakpoint that logs message ({var} replaced by its value) and continues; passed through by attach() and continue_()"},
+	{"Debugging", "getLogpointLog", "getLogpointLog([clear=false])", "{ {time, id, program, line, message}, ... }", "Messages logged by logpoints"},
+	{"Debugging", "getBreakpoints", "getBreakpoints()", "{ {id, kind, uri, line, enabled}, ... }", "List active breakpoints"},
+	{"Debugging", "deleteBreakpoint", "deleteBreakpoint(id)", "true", "Delete a breakpoint"},
+	{"Debugging", "listen", "listen([timeout=30])", "{id, program, user, line}", "Wait for a debuggee to hit a breakpoint"},
+	{"Debugging", "attach", "attach(debuggeeId, [user])", "{session_id, server, stepping_possible, logged}", "Attach to a debuggee (logpoint stops are logged and continued)"},
+	{"Debugging", "detach", "detach()", "true", "Detach from the debuggee"},
+	{"Debugging", "stepOver", "stepOver()", "{session_id, stepping, termination}", "Step over"},
+	{"Debugging", "stepInto", "stepInto()", "{session_id, stepping, termination}", "Step into"},
+	{"Debugging", "stepReturn", "stepReturn()", "{session_id, stepping, termination}", "Step out of the current frame"},
+	{"Debugging", "continue_", "continue_()", "{session_id, stepping, termination, logged}", "Continue execution (logpoint stops are logged and continued)"},
+	{"Debugging", "getStack", "getStack()", "{ {program, include, line, type, event}, ... }", "Current call stack"},
+	{"Debugging", "getVariables", "getVariables([{id, ...}])", "{ {id, name, type, value}, ... }", "Read variables (default: locals)"},
+	{"Debugging", "setVariable", "setVariable(name, value)", "true", "Change a variable in the live session"},
+
+	// Call Graph
+	{"Call Graph", "getCallGraph", "getCallGraph(objectURI, [direction=\"callees\"], [maxDepth=5])", "{uri, name, type, children={...}}", "Callers or callees of an object"},
+	{"Call Graph", "getCallersOf", "getCallersOf(objectURI, [maxDepth=5])", "{uri, name, type, children={...}}", "Who calls this object"},
+	{"Call Graph", "getCalleesOf", "getCalleesOf(objectURI, [maxDepth=5])", "{uri, name, type, children={...}}", "What this object calls"},
+
+	// Checkpoints
+	{"Checkpoints & Force Replay", "saveCheckpoint", "saveCheckpoint(name)", "true", "Save the current variables"},
+	{"Checkpoints & Force Replay", "getCheckpoint", "getCheckpoint(name)", "{name = value, ...}", "Read a saved checkpoint"},
+	{"Checkpoints & Force Replay", "listCheckpoints", "listCheckpoints()", "{ {name, timestamp}, ... }", "List checkpoint names"},
+	{"Checkpoints & Force Replay", "injectCheckpoint", "injectCheckpoint(name)", "true, injected", "Write a checkpoint back into the session"},
+	{"Checkpoints & Force Replay", "forceReplay", "forceReplay(recordingId, [step], [path])", "true, injected", "Inject state from a saved recording"},
+	{"Checkpoints & Force Replay", "replayFromStep", "replayFromStep(step)", "true, injected", "Inject state from the current recording"},
+
+	// Recording & History
+	{"Recording & History", "startRecording", "startRecording([session], [program])", "recording id", "Start recording execution"},
+	{"Recording & History", "stopRecording", "stopRecording()", "statistics table", "Stop recording and return statistics"},
+	{"Recording & History", "getRecording", "getRecording()", "{id, session_id, program, total_steps, current_step, is_complete, checkpoints}", "Current recording"},
+	{"Recording & History", "saveRecording", "saveRecording([path])", "true, recording id", "Save the recording to disk"},
+	{"Recording & History", "getStateAtStep", "getStateAtStep(step)", "{ {name, type, value, is_changed}, ... }", "Variables at a recorded step"},
+	{"Recording & History", "findWhenChanged", "findWhenChanged(variable, value)", "step number (-1 if never)", "First step where a variable took a value"},
+	{"Recording & History", "findChanges", "findChanges(variable)", "{step, ...}", "All changes of a variable"},
+	{"Recording & History", "listRecordings", "listRecordings([path])", "{ {id, session_id, program, total_steps, is_complete, start_time}, ... }", "Saved recordings"},
+	{"Recording & History", "loadRecording", "loadRecording(id, [path])", "{id, session_id, program, total_steps, is_complete, frames={...}}", "Load a saved recording"},
+	{"Recording & History", "compareRecordings", "compareRecordings(id1, id2, [path])", "{recording1_id, recording2_id, steps_compared, paths_match, differences={...}}", "Compare two recordings"},
+
+	// Diagnostics
+	{"Diagnostics", "listDumps", "listDumps([maxResults=20])", "{ {id, program, exception, user, time, title}, ... }", "Recent short dumps"},
+	{"Diagnostics", "getDumps", "getDumps([maxResults=20])", "{ {id, program, exception, user, time, title}, ... }", "Alias of listDumps"},
+	{"Diagnostics", "getDump", "getDump(id)", "{id, program, exception, title, user, line, time, stack={...}}", "Full short dump"},
+	{"Diagnostics", "getMessages", "getMessages(messageClass)", "{name, description, messages={ {number, text}, ... }}", "Messages of a message class"},
+	{"Diagnostics", "runUnitTests", "runUnitTests(objectURI)", "{classes={ {name, uri, methods={ {name, type, status}, ... }}, ... }}", "Run ABAP Unit tests"},
+	{"Diagnostics", "syntaxCheck", "syntaxCheck(type, name)", "{ {line, offset, message, severity}, ... }", "Syntax check an object"},
+
+	// Transports
+	{"Transports", "getTransport", "getTransport(number)", "{number, owner, description, status, tasks={...}, objects={...}}", "Transport request details"},
+	{"Transports", "createTransport", "createTransport(description, package, [{layer, type}])", "transport number", "Create a transport request (type: workbench or customizing)"},
+
+	// Quality & Analysis
+	{"Quality & Analysis", "runATC", "runATC(objectURL, [variant], [maxResults=100])", "{id, objects={ {name, type, findings={...}}, ... }}", "Run ATC checks"},
+	{"Quality & Analysis", "getCDSDependencies", "getCDSDependencies(ddlsName, [{level, associations, package}])", "{name, type, children={...}}", "CDS view dependency tree"},
+
+	// Data
+	{"Data", "getTableContents", "getTableContents(table, [maxRows=100], [filter])", "{columns={ {name, type, description, length, key}, ... }, rows={ {FIELD=value}, ... }}", "Read table contents"},
+	{"Data", "runQuery", "runQuery(sql, [maxRows=100])", "{columns={...}, rows={...}}", "Run a freestyle SQL query"},
+
+	// CRUD
+	{"CRUD", "createObject", "createObject({type, name, description, package, [transport], [parent], ...})", "true", "Create an object (type: PROG/P, CLAS/OC, INTF/OI, FUGR/F, DDLS/DF, ...)"},
+	{"CRUD", "activate", "activate(objectURL, objectName)", "{success, messages={...}, inactive={...}}", "Activate an object"},
+
+	// UI5
+	{"UI5", "listUI5Apps", "listUI5Apps([query=\"*\"], [maxResults=100])", "{ {name, type, uri, description, package}, ... }", "List BSP/UI5 applications"},
+
+	// WebSocket (ZADT_VSP)
+	{"WebSocket (ZADT_VSP)", "callRFC", "callRFC(function, [{PARAM=value}])", "{subrc, exports={...}, tables={...}}", "Call a function module"},
+	{"WebSocket (ZADT_VSP)", "runReport", "runReport(report, [variant], [{PARAM=value}])", "{status, report, jobname, jobcount}", "Schedule a report as a background job"},
+}
+
+// APIReference renders APIDocs as a Markdown reference, including the
+// safety operation type each binding requires in sandbox mode.
+func APIReference() string {
+	var sb strings.Builder
+	sb.WriteString("# vsp Lua API Reference\n\n")
+	sb.WriteString("<!-- Generated by `vsp lua --api-doc`. Do not edit by hand. -->\n\n")
+	sb.WriteString("Failing calls return `nil, message` (or `false, message` for boolean results).\n")
+	sb.WriteString("In sandbox mode a binding is only callable if its operation type is allowed.\n")
+
+	section := ""
+	for _, doc := range APIDocs {
+		if doc.Section != section {
+			section = doc.Section
+			fmt.Fprintf(&sb, "\n## %s\n\n", section)
+			sb.WriteString("| Function | Returns | Op | Description |\n")
+			sb.WriteString("|----------|---------|----|-------------|\n")
+		}
+		fmt.Fprintf(&sb, "| `%s` | `%s` | %s | %s |\n",
+			doc.Signature, doc.Returns, opName(bindingOps[doc.Name]), doc.Description)
+	}
+	return sb.String()
+}
+
+// opName renders an operation type as its code letter.
+func opName(op adt.OperationType) string {
+	if op == 0 {
+		return "-"
+	}
+	return string(rune(op))
+}
@@ -12,76 +12,85 @@ import (
 // registerADTBindings registers all ADT-related Lua functions.
 func (e *LuaEngine) registerADTBindings() {
 	// Search & Source
-	e.L.SetGlobal("searchObject", e.L.NewFunction(e.luaSearchObject))
-	e.L.SetGlobal("grepObjects", e.L.NewFunction(e.luaGrepObjects))
-	e.L.SetGlobal("getSource", e.L.NewFunction(e.luaGetSource))
-	e.L.SetGlobal("writeSource", e.L.NewFunction(e.luaWriteSource))
-	e.L.SetGlobal("editSource", e.L.NewFunction(e.luaEditSource))
+	e.bind("searchObject", e.luaSearchObject)
+	e.bind("grepObjects", e.luaGrepObjects)
+	e.bind("getSource", e.luaGetSource)
+	e.bind("writeSource", e.luaWriteSource)
+	e.bind("editSource", e.luaEditSource)
 
 	// Debugging - Breakpoints
-	e.L.SetGlobal("setBreakpoint", e.L.NewFunction(e.luaSetBreakpoint))
-	e.L.SetGlobal("setStatementBP", e.L.NewFunction(e.luaSetStatementBreakpoint))
-	e.L.SetGlobal("setExceptionBP", e.L.NewFunction(e.luaSetExceptionBreakpoint))
-	e.L.SetGlobal("setMessageBP", e.L.NewFunction(e.luaSetMessageBreakpoint))
-	e.L.SetGlobal("setBadiBP", e.L.NewFunction(e.luaSetBadiBreakpoint))
-	e.L.SetGlobal("setEnhancementBP", e.L.NewFunction(e.luaSetEnhancementBreakpoint))
-	e.L.SetGlobal("setWatchpoint", e.L.NewFunction(e.luaSetWatchpoint))
-	e.L.SetGlobal("setMethodBP", e.L.NewFunction(e.luaSetMethodBreakpoint))
-	e.L.SetGlobal("getBreakpoints", e.L.NewFunction(e.luaGetBreakpoints))
-	e.L.SetGlobal("deleteBreakpoint", e.L.NewFunction(e.luaDeleteBreakpoint))
+	e.bind("setBreakpoint", e.luaSetBreakpoint)
+	e.bind("setStatementBP", e.luaSetStatementBreakpoint)
+	e.bind("setExceptionBP", e.luaSetExceptionBreakpoint)
+	e.bind("setMessageBP", e.luaSetMessageBreakpoint)
+	e.bind("setBadiBP", e.luaSetBadiBreakpoint)
+	e.bind("setEnhancementBP", e.luaSetEnhancementBreakpoint)
+	e.bind("setWatchpoint", e.luaSetWatchpoint)
+	e.bind("setMethodBP", e.luaSetMethodBreakpoint)
+	e.bind("setLogpoint", e.luaSetLogpoint)
+	e.bind("getLogpointLog", e.luaGetLogpointLog)
+	e.bind("getBreakpoints", e.luaGetBreakpoints)
+	e.bind("deleteBreakpoint", e.luaDeleteBreakpoint)
 
 	// Debugging - Session
-	e.L.SetGlobal("listen", e.L.NewFunction(e.luaListen))
-	e.L.SetGlobal("attach", e.L.NewFunction(e.luaAttach))
-	e.L.SetGlobal("detach", e.L.NewFunction(e.luaDetach))
+	e.bind("listen", e.luaListen)
+	e.bind("attach", e.luaAttach)
+	e.bind("detach", e.luaDetach)
 
 	// Debugging - Execution
-	e.L.SetGlobal("stepOver", e.L.NewFunction(e.luaStepOver))
-	e.L.SetGlobal("stepInto", e.L.NewFunction(e.luaStepInto))
-	e.L.SetGlobal("stepReturn", e.L.NewFunction(e.luaStepReturn))
-	e.L.SetGlobal("continue_", e.L.NewFunction(e.luaContinue))
+	e.bind("stepOver", e.luaStepOver)
+	e.bind("stepInto", e.luaStepInto)
+	e.bind("stepReturn", e.luaStepReturn)
+	e.bind("continue_", e.luaContinue)
 
 	// Debugging - Inspection
-	e.L.SetGlobal("getStack", e.L.NewFunction(e.luaGetStack))
-	e.L.SetGlobal("getVariables", e.L.NewFunction(e.luaGetVariables))
-	e.L.SetGlobal("setVariable", e.L.NewFunction(e.luaSetVariable))
+	e.bind("getStack", e.luaGetStack)
+	e.bind("getVariables", e.luaGetVariables)
+	e.bind("setVariable", e.luaSetVariable)
 
 	// Call Graph
-	e.L.SetGlobal("getCallGraph", e.L.NewFunction(e.luaGetCallGraph))
-	e.L.SetGlobal("getCallersOf", e.L.NewFunction(e.luaGetCallersOf))
-	e.L.SetGlobal("getCalleesOf", e.L.NewFunction(e.luaGetCalleesOf))
+	e.bind("getCallGraph", e.luaGetCallGraph)
+	e.bind("getCallersOf", e.luaGetCallersOf)
+	e.bind("getCalleesOf", e.luaGetCalleesOf)
 
 	// Checkpoints (for Force Replay)
-	e.L.SetGlobal("saveCheckpoint", e.L.NewFunction(e.luaSaveCheckpoint))
-	e.L.SetGlobal("getCheckpoint", e.L.NewFunction(e.luaGetCheckpoint))
-	e.L.SetGlobal("listCheckpoints", e.L.NewFunction(e.luaListCheckpoints))
-	e.L.SetGlobal("injectCheckpoint", e.L.NewFunction(e.luaInjectCheckpoint))
+	e.bind("saveCheckpoint", e.luaSaveCheckpoint)
+	e.bind("getCheckpoint", e.luaGetCheckpoint)
+	e.bind("listCheckpoints", e.luaListCheckpoints)
+	e.bind("injectCheckpoint", e.luaInjectCheckpoint)
 
 	// Execution Recording (Phase 5.2)
-	e.L.SetGlobal("startRecording", e.L.NewFunction(e.luaStartRecording))
-	e.L.SetGlobal("stopRecording", e.L.NewFunction(e.luaStopRecording))
-	e.L.SetGlobal("getRecording", e.L.NewFunction(e.luaGetRecording))
-	e.L.SetGlobal("saveRecording", e.L.NewFunction(e.luaSaveRecording))
+	e.bind("startRecording", e.luaStartRecording)
+	e.bind("stopRecording", e.luaStopRecording)
+	e.bind("getRecording", e.luaGetRecording)
+	e.bind("saveRecording", e.luaSaveRecording)
 
 	// History Navigation (Phase 5.2)
-	e.L.SetGlobal("getStateAtStep", e.L.NewFunction(e.luaGetStateAtStep))
-	e.L.SetGlobal("findWhenChanged", e.L.NewFunction(e.luaFindWhenChanged))
-	e.L.SetGlobal("findChanges", e.L.NewFunction(e.luaFindChanges))
-	e.L.SetGlobal("listRecordings", e.L.NewFunction(e.luaListRecordings))
-	e.L.SetGlobal("loadRecording", e.L.NewFunction(e.luaLoadRecording))
-	e.L.SetGlobal("compareRecordings", e.L.NewFunction(e.luaCompareRecordings))
+	e.bind("getStateAtStep", e.luaGetStateAtStep)
+	e.bind("findWhenChanged", e.luaFindWhenChanged)
+	e.bind("findChanges", e.luaFindChanges)
+	e.bind("listRecordings", e.luaListRecordings)
+	e.bind("loadRecording", e.luaLoadRecording)
+	e.bind("compareRecordings", e.luaCompareRecordings)
 
 	// Force Replay (Phase 5.5)
-	e.L.SetGlobal("forceReplay", e.L.NewFunction(e.luaForceReplay))
-	e.L.SetGlobal("replayFromStep", e.L.NewFunction(e.luaReplayFromStep))
+	e.bind("forceReplay", e.luaForceReplay)
+	e.bind("replayFromStep", e.luaReplayFromStep)
 
 	// Diagnostics
-	e.L.SetGlobal("listDumps", e.L.NewFunction(e.luaGetDumps)) // New canonical name
-	e.L.SetGlobal("getDumps", e.L.NewFunction(e.luaGetDumps))  // Backwards compatibility
-	e.L.SetGlobal("getDump", e.L.NewFunction(e.luaGetDump))
-	e.L.SetGlobal("getMessages", e.L.NewFunction(e.luaGetMessages))
-	e.L.SetGlobal("runUnitTests", e.L.NewFunction(e.luaRunUnitTests))
-	e.L.SetGlobal("syntaxCheck", e.L.NewFunction(e.luaSyntaxCheck))
+	e.bind("listDumps", e.luaGetDumps) // New canonical name
+	e.bind("getDumps", e.luaGetDumps)  // Backwards compatibility
+	e.bind("getDump", e.luaGetDump)
+	e.bind("getMessages", e.luaGetMessages)
+	e.bind("runUnitTests", e.luaRunUnitTests)
+	e.bind("syntaxCheck", e.luaSyntaxCheck)
+}
+
+// bind registers an ADT binding as a Lua global and records its name
+// so sandbox mode can apply the safety policy to it.
+func (e *LuaEngine) bind(name string, fn lua.LGFunction) {
+	e.L.SetGlobal(name, e.L.NewFunction(fn))
+	e.bindings = append(e.bindings, name)
 }
 
 // --- Search & Source ---
@@ -477,6 +486,61 @@ func (e *LuaEngine) luaGetBreakpoints(L *lua.LState) int {
 	return 1
 }
 
+// setLogpoint(program, line, message) - line breakpoint that logs and continues
+func (e *LuaEngine) luaSetLogpoint(L *lua.LState) int {
+	program := getString(L, 1)
+	line := getInt(L, 2)
+	message := getString(L, 3)
+
+	req := &adt.BreakpointRequest{
+		Breakpoints: []adt.Breakpoint{{
+			Kind:    adt.BreakpointKindLine,
+			URI:     program,
+			Line:    line,
+			Enabled: true,
+		}},
+	}
+
+	resp, err := e.client.SetExternalBreakpoint(e.ctx, req)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	if len(resp.Breakpoints) == 0 || resp.Breakpoints[0].ID == "" {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("no breakpoint created"))
+		return 2
+	}
+
+	id := resp.Breakpoints[0].ID
+	e.logpoints.Add(adt.Logpoint{ID: id, Program: program, Line: line, Message: message})
+	L.Push(lua.LString(id))
+	return 1
+}
+
+// getLogpointLog([clear]) - messages logged by logpoints
+func (e *LuaEngine) luaGetLogpointLog(L *lua.LState) int {
+	clearLog := L.OptBool(1, false)
+
+	tbl := L.NewTable()
+	for i, hit := range e.logpoints.Hits() {
+		row := L.NewTable()
+		L.SetField(row, "time", lua.LString(hit.Time.Format(time.RFC3339Nano)))
+		L.SetField(row, "id", lua.LString(hit.LogpointID))
+		L.SetField(row, "program", lua.LString(hit.Location.Program))
+		L.SetField(row, "line", lua.LNumber(hit.Location.Line))
+		L.SetField(row, "message", lua.LString(hit.Message))
+		tbl.RawSetInt(i+1, row)
+	}
+	if clearLog {
+		e.logpoints.ClearHits()
+	}
+
+	L.Push(tbl)
+	return 1
+}
+
 func (e *LuaEngine) luaDeleteBreakpoint(L *lua.LState) int {
 	id := getString(L, 1)
 	user := getOptString(L, 2, "")
@@ -487,6 +551,7 @@ func (e *LuaEngine) luaDeleteBreakpoint(L *lua.LState) int {
 		L.Push(lua.LString(err.Error()))
 		return 2
 	}
+	e.logpoints.Remove(id)
 
 	L.Push(lua.LBool(true))
 	return 1
@@ -540,10 +605,13 @@ func (e *LuaEngine) luaAttach(L *lua.LState) int {
 		return 2
 	}
 
+	stopped, logged := e.passLogpoints(result.ReachedBreakpoints)
+
 	tbl := L.NewTable()
 	L.SetField(tbl, "session_id", lua.LString(result.DebugSessionID))
 	L.SetField(tbl, "server", lua.LString(result.ServerName))
-	L.SetField(tbl, "stepping_possible", lua.LBool(result.IsSteppingPossible))
+	L.SetField(tbl, "stepping_possible", lua.LBool(result.IsSteppingPossible && stopped))
+	L.SetField(tbl, "logged", lua.LNumber(logged))
 
 	L.Push(tbl)
 	return 1
@@ -619,14 +687,36 @@ func (e *LuaEngine) luaContinue(L *lua.LState) int {
 		return 2
 	}
 
+	stopped, logged := true, 0
+	if result.IsSteppingPossible {
+		stopped, logged = e.passLogpoints(result.ReachedBreakpoints)
+	}
+
 	L.Push(goToLua(L, map[string]interface{}{
 		"session_id":  result.DebugSessionID,
-		"stepping":    result.IsSteppingPossible,
+		"stepping":    result.IsSteppingPossible && stopped,
 		"termination": result.IsTerminationPossible,
+		"logged":      logged,
 	}))
 	return 1
 }
 
+// passLogpoints logs and continues while the debuggee is stopped at a logpoint.
+// It returns whether the debuggee is still stopped and how many messages were logged.
+func (e *LuaEngine) passLogpoints(reached []adt.DebugReachedBreakpoint) (bool, int) {
+	if e.logpoints.Len() == 0 {
+		return true, 0
+	}
+	hits, stopped, err := e.logpoints.ContinueThrough(e.ctx, e.client, reached)
+	for _, hit := range hits {
+		fmt.Fprintf(e.output, "[log] %s:%d  %s\n", hit.Location.Program, hit.Location.Line, hit.Message)
+	}
+	if err != nil {
+		fmt.Fprintf(e.output, "[log] logpoint: %v\n", err)
+	}
+	return stopped, len(hits)
+}
+
 // --- Debugging: Inspection ---
 
 func (e *LuaEngine) luaGetStack(L *lua.LState) int {
@@ -1081,6 +1171,7 @@ func (e *LuaEngine) luaStartRecording(L *lua.LState) int {
 
 	e.recorder = adt.NewExecutionRecorder(sessionID, program)
 	e.isRecording = true
+	e.logpoints.SetRecorder(e.recorder)
 
 	L.Push(lua.LString(e.recorder.GetRecording().ID))
 	return 1
@@ -1095,6 +1186,7 @@ func (e *LuaEngine) luaStopRecording(L *lua.LState) int {
 
 	e.recorder.Complete()
 	e.isRecording = false
+	e.logpoints.SetRecorder(nil)
 
 	stats := e.recorder.Stats()
 	L.Push(goToLua(L, stats))
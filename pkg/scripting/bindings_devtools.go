@@ -0,0 +1,339 @@
+package scripting
+
+import (
+	"fmt"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// registerDevToolsBindings registers transport, quality, data, CRUD, UI5
+// and WebSocket (ZADT_VSP) bindings.
+func (e *LuaEngine) registerDevToolsBindings() {
+	// Transports
+	e.bind("getTransport", e.luaGetTransport)
+	e.bind("createTransport", e.luaCreateTransport)
+
+	// Quality & Analysis
+	e.bind("runATC", e.luaRunATC)
+	e.bind("getCDSDependencies", e.luaGetCDSDependencies)
+
+	// Data
+	e.bind("getTableContents", e.luaGetTableContents)
+	e.bind("runQuery", e.luaRunQuery)
+
+	// CRUD
+	e.bind("createObject", e.luaCreateObject)
+	e.bind("activate", e.luaActivate)
+
+	// UI5
+	e.bind("listUI5Apps", e.luaListUI5Apps)
+
+	// WebSocket (ZADT_VSP)
+	e.bind("callRFC", e.luaCallRFC)
+	e.bind("runReport", e.luaRunReport)
+}
+
+// pushError pushes the (nil, message) pair returned by failing bindings.
+func pushError(L *lua.LState, err error) int {
+	L.Push(lua.LNil)
+	L.Push(lua.LString(err.Error()))
+	return 2
+}
+
+// --- Transports ---
+
+func (e *LuaEngine) luaGetTransport(L *lua.LState) int {
+	number := getString(L, 1)
+
+	details, err := e.client.GetTransport(e.ctx, number)
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	L.Push(goToLua(L, details))
+	return 1
+}
+
+// luaCreateTransport creates a transport request and returns its number.
+// Lua: createTransport(description, package, [{layer=, type="workbench"|"customizing"}])
+func (e *LuaEngine) luaCreateTransport(L *lua.LState) int {
+	opts := adt.CreateTransportOptions{
+		Description: getString(L, 1),
+		Package:     getString(L, 2),
+	}
+	if extra := getTable(L, 3); extra != nil {
+		opts.TransportLayer = tableString(extra, "layer")
+		opts.Type = tableString(extra, "type")
+	}
+
+	number, err := e.client.CreateTransportV2(e.ctx, opts)
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	L.Push(lua.LString(number))
+	return 1
+}
+
+// --- Quality & Analysis ---
+
+func (e *LuaEngine) luaRunATC(L *lua.LState) int {
+	objectURL := getString(L, 1)
+	variant := getOptString(L, 2, "")
+	maxResults := getOptInt(L, 3, 100)
+
+	worklist, err := e.client.RunATCCheck(e.ctx, objectURL, variant, maxResults)
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	L.Push(goToLua(L, worklist))
+	return 1
+}
+
+// luaGetCDSDependencies returns the dependency tree of a CDS view.
+// Lua: getCDSDependencies(ddlsName, [{level="hierarchy"|"unit", associations=bool, package=}])
+func (e *LuaEngine) luaGetCDSDependencies(L *lua.LState) int {
+	ddlsName := getString(L, 1)
+
+	var opts adt.CDSDependencyOptions
+	if extra := getTable(L, 2); extra != nil {
+		opts.DependencyLevel = tableString(extra, "level")
+		opts.ContextPackage = tableString(extra, "package")
+		if b, ok := extra["associations"].(bool); ok {
+			opts.WithAssociations = b
+		}
+	}
+
+	node, err := e.client.GetCDSDependencies(e.ctx, ddlsName, opts)
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	L.Push(goToLua(L, node))
+	return 1
+}
+
+// --- Data ---
+
+func (e *LuaEngine) luaGetTableContents(L *lua.LState) int {
+	table := getString(L, 1)
+	maxRows := getOptInt(L, 2, 100)
+	filter := getOptString(L, 3, "")
+
+	result, err := e.client.GetTableContents(e.ctx, table, maxRows, filter)
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	L.Push(tableContentsToLua(L, result))
+	return 1
+}
+
+func (e *LuaEngine) luaRunQuery(L *lua.LState) int {
+	query := getString(L, 1)
+	maxRows := getOptInt(L, 2, 100)
+
+	result, err := e.client.RunQuery(e.ctx, query, maxRows)
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	L.Push(tableContentsToLua(L, result))
+	return 1
+}
+
+// tableContentsToLua converts a data preview result to
+// {columns = {{name=, type=, description=, length=, key=}, ...}, rows = {{FIELD=value, ...}, ...}}.
+func tableContentsToLua(L *lua.LState, result *adt.TableContentsResult) *lua.LTable {
+	tbl := L.NewTable()
+
+	columns := L.NewTable()
+	for i, col := range result.Columns {
+		c := L.NewTable()
+		L.SetField(c, "name", lua.LString(col.Name))
+		L.SetField(c, "type", lua.LString(col.Type))
+		L.SetField(c, "description", lua.LString(col.Description))
+		L.SetField(c, "length", lua.LNumber(col.Length))
+		L.SetField(c, "key", lua.LBool(col.IsKey))
+		columns.RawSetInt(i+1, c)
+	}
+	L.SetField(tbl, "columns", columns)
+
+	rows := L.NewTable()
+	for i, row := range result.Rows {
+		rows.RawSetInt(i+1, goMapToLua(L, row))
+	}
+	L.SetField(tbl, "rows", rows)
+
+	return tbl
+}
+
+// --- CRUD ---
+
+// luaCreateObject creates a new ABAP object.
+// Lua: createObject({type="PROG/P", name=, description=, package=, [transport=], [parent=], ...})
+func (e *LuaEngine) luaCreateObject(L *lua.LState) int {
+	spec := getTable(L, 1)
+	if spec == nil {
+		L.Push(lua.LBool(false))
+		L.Push(lua.LString("createObject expects a table"))
+		return 2
+	}
+
+	opts := adt.CreateObjectOptions{
+		ObjectType:         adt.CreatableObjectType(tableString(spec, "type")),
+		Name:               tableString(spec, "name"),
+		Description:        tableString(spec, "description"),
+		PackageName:        tableString(spec, "package"),
+		Transport:          tableString(spec, "transport"),
+		Responsible:        tableString(spec, "responsible"),
+		ParentName:         tableString(spec, "parent"),
+		SoftwareComponent:  tableString(spec, "softwareComponent"),
+		RootEntity:         tableString(spec, "rootEntity"),
+		ImplementationType: tableString(spec, "implementationType"),
+		ServiceDefinition:  tableString(spec, "serviceDefinition"),
+		BindingType:        tableString(spec, "bindingType"),
+	}
+
+	if err := e.client.CreateObject(e.ctx, opts); err != nil {
+		L.Push(lua.LBool(false))
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(lua.LBool(true))
+	return 1
+}
+
+func (e *LuaEngine) luaActivate(L *lua.LState) int {
+	objectURL := getString(L, 1)
+	objectName := getString(L, 2)
+
+	result, err := e.client.Activate(e.ctx, objectURL, objectName)
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	L.Push(goToLua(L, result))
+	return 1
+}
+
+// --- UI5 ---
+
+func (e *LuaEngine) luaListUI5Apps(L *lua.LState) int {
+	query := getOptString(L, 1, "*")
+	maxResults := getOptInt(L, 2, 100)
+
+	apps, err := e.client.UI5ListApps(e.ctx, query, maxResults)
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	tbl := L.NewTable()
+	for i, app := range apps {
+		tbl.RawSetInt(i+1, goToLua(L, app))
+	}
+	L.Push(tbl)
+	return 1
+}
+
+// --- WebSocket (ZADT_VSP) ---
+
+// luaCallRFC calls a function module through ZADT_VSP.
+// Lua: callRFC(function, [{PARAM = value, ...}]) -> {subrc=, exports={}, tables={}}
+func (e *LuaEngine) luaCallRFC(L *lua.LState) int {
+	function := getString(L, 1)
+	params := stringParams(getTable(L, 2))
+
+	ws, err := e.debugWebSocket()
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	result, err := ws.CallRFC(e.ctx, function, params)
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	L.Push(goToLua(L, result))
+	return 1
+}
+
+// luaRunReport schedules a report as a background job through ZADT_VSP.
+// Lua: runReport(report, [variant], [{PARAM = value, ...}]) -> {status=, report=, jobname=, jobcount=}
+func (e *LuaEngine) luaRunReport(L *lua.LState) int {
+	params := adt.RunReportParams{
+		Report:  getString(L, 1),
+		Variant: getOptString(L, 2, ""),
+		Params:  stringParams(getTable(L, 3)),
+	}
+
+	ws, err := e.reportWebSocket()
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	result, err := ws.RunReport(e.ctx, params)
+	if err != nil {
+		return pushError(L, err)
+	}
+
+	L.Push(goToLua(L, result))
+	return 1
+}
+
+// debugWebSocket returns a connected ZADT_VSP RFC/debug client, connecting on first use.
+func (e *LuaEngine) debugWebSocket() (*adt.DebugWebSocketClient, error) {
+	if e.debugWS != nil && e.debugWS.IsConnected() {
+		return e.debugWS, nil
+	}
+
+	cfg := e.client.Config()
+	ws := adt.NewDebugWebSocketClient(cfg.BaseURL, cfg.Client, cfg.Username, cfg.Password, cfg.InsecureSkipVerify)
+	ws.UseAuth(cfg)
+	ws.UseSafety(cfg.Safety)
+	if err := ws.Connect(e.ctx); err != nil {
+		return nil, fmt.Errorf("ZADT_VSP WebSocket connect failed: %w", err)
+	}
+	e.debugWS = ws
+	return ws, nil
+}
+
+// reportWebSocket returns a connected ZADT_VSP report client, connecting on first use.
+func (e *LuaEngine) reportWebSocket() (*adt.AMDPWebSocketClient, error) {
+	if e.reportWS != nil && e.reportWS.IsConnected() {
+		return e.reportWS, nil
+	}
+
+	cfg := e.client.Config()
+	ws := adt.NewAMDPWebSocketClient(cfg.BaseURL, cfg.Client, cfg.Username, cfg.Password, cfg.InsecureSkipVerify)
+	ws.UseAuth(cfg)
+	ws.UseSafety(cfg.Safety)
+	if err := ws.Connect(e.ctx); err != nil {
+		return nil, fmt.Errorf("ZADT_VSP WebSocket connect failed: %w", err)
+	}
+	e.reportWS = ws
+	return ws, nil
+}
+
+// tableString returns m[key] as a string, or "" if absent.
+func tableString(m map[string]interface{}, key string) string {
+	if v, ok := m[key]; ok && v != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return ""
+}
+
+// stringParams flattens a Lua parameter table to the string map ZADT_VSP expects.
+func stringParams(m map[string]interface{}) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(m))
+	for k, v := range m {
+		params[k] = toString(v)
+	}
+	return params
+}
@@ -0,0 +1,126 @@
+-- vsp.assert: assertions for scripts and tests.
+--
+--   local assert = require("vsp.assert")
+--   assert.equal(result.subrc, 0, "RFC failed")
+--   local src = assert.ok(getSource("PROG", "ZTEST"))
+
+local M = {}
+
+local function fail(msg, default)
+    error(msg or default, 3)
+end
+
+local function show(v)
+    if type(v) == "string" then
+        return string.format("%q", v)
+    end
+    return tostring(v)
+end
+
+-- ok(value, err) unwraps a binding result: returns value, or raises err.
+function M.ok(value, err, ...)
+    if value == nil or value == false then
+        error(err or "expected a result, got " .. tostring(value), 2)
+    end
+    return value, err, ...
+end
+
+function M.truthy(value, msg)
+    if not value then
+        fail(msg, "expected truthy value, got " .. show(value))
+    end
+end
+
+function M.falsy(value, msg)
+    if value then
+        fail(msg, "expected falsy value, got " .. show(value))
+    end
+end
+
+function M.isNil(value, msg)
+    if value ~= nil then
+        fail(msg, "expected nil, got " .. show(value))
+    end
+end
+
+function M.notNil(value, msg)
+    if value == nil then
+        fail(msg, "expected a value, got nil")
+    end
+end
+
+function M.equal(actual, expected, msg)
+    if actual ~= expected then
+        fail(msg, "expected " .. show(expected) .. ", got " .. show(actual))
+    end
+end
+
+function M.notEqual(actual, unexpected, msg)
+    if actual == unexpected then
+        fail(msg, "expected value other than " .. show(unexpected))
+    end
+end
+
+-- deepEqual compares tables recursively.
+local function deepEqual(a, b)
+    if a == b then
+        return true
+    end
+    if type(a) ~= "table" or type(b) ~= "table" then
+        return false
+    end
+    for k, v in pairs(a) do
+        if not deepEqual(v, b[k]) then
+            return false
+        end
+    end
+    for k in pairs(b) do
+        if a[k] == nil then
+            return false
+        end
+    end
+    return true
+end
+
+function M.same(actual, expected, msg)
+    if not deepEqual(actual, expected) then
+        fail(msg, "tables differ: expected " .. json.encode(expected) .. ", got " .. json.encode(actual))
+    end
+end
+
+-- contains checks a substring (plain match) or a list element.
+function M.contains(haystack, needle, msg)
+    if type(haystack) == "string" then
+        if not string.find(haystack, needle, 1, true) then
+            fail(msg, show(haystack) .. " does not contain " .. show(needle))
+        end
+        return
+    end
+    if type(haystack) == "table" then
+        for _, v in ipairs(haystack) do
+            if v == needle then
+                return
+            end
+        end
+    end
+    fail(msg, "list does not contain " .. show(needle))
+end
+
+function M.type(value, expected, msg)
+    if type(value) ~= expected then
+        fail(msg, "expected " .. expected .. ", got " .. type(value))
+    end
+end
+
+-- raises checks that fn raises an error, optionally containing pattern.
+function M.raises(fn, pattern, msg)
+    local ok, err = pcall(fn)
+    if ok then
+        fail(msg, "expected an error")
+    end
+    if pattern and not string.find(tostring(err), pattern, 1, true) then
+        fail(msg, "error " .. show(tostring(err)) .. " does not contain " .. show(pattern))
+    end
+end
+
+return M
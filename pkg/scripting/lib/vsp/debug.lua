@@ -0,0 +1,123 @@
+-- vsp.debug: debugger session recipes.
+--
+--   local dbg = require("vsp.debug")
+--   dbg.session("ZTEST_PROGRAM", 10, function(s)
+--       dbg.steps(20, function(frame, n)
+--           print(n, frame.program, frame.line)
+--       end)
+--   end, {timeout = 120})
+
+local M = {}
+
+-- catch sets a line breakpoint, waits for a debuggee and attaches.
+-- Returns {bp, event, session} or nil, err. The breakpoint is removed on failure.
+function M.catch(program, line, timeout)
+    local bp, err = setBreakpoint(program, line)
+    if not bp then
+        return nil, "setBreakpoint: " .. tostring(err)
+    end
+
+    local event, lerr = listen(timeout or 60)
+    if not event then
+        deleteBreakpoint(bp)
+        return nil, lerr or "no debuggee caught"
+    end
+
+    local session, aerr = attach(event.id)
+    if not session then
+        deleteBreakpoint(bp)
+        return nil, "attach: " .. tostring(aerr)
+    end
+
+    return {bp = bp, event = event, session = session}
+end
+
+-- release detaches and deletes the breakpoint of a catch() result.
+function M.release(s)
+    if not s then
+        return
+    end
+    detach()
+    if s.bp then
+        deleteBreakpoint(s.bp)
+    end
+end
+
+-- session runs fn(s) inside a caught debug session and always releases it.
+-- opts: timeout (listen seconds), record (recording session id).
+-- Returns fn's result, or nil, err. With opts.record the recording
+-- statistics are returned as the last value.
+function M.session(program, line, fn, opts)
+    opts = opts or {}
+    if opts.record then
+        startRecording(opts.record, program)
+    end
+
+    local s, err = M.catch(program, line, opts.timeout)
+    if not s then
+        if opts.record then
+            stopRecording()
+        end
+        return nil, err
+    end
+
+    local ok, result = pcall(fn, s)
+    M.release(s)
+
+    local stats
+    if opts.record then
+        stats = stopRecording()
+    end
+    if not ok then
+        return nil, result
+    end
+    return result, nil, stats
+end
+
+-- steps steps over up to n times, calling fn(frame, step) on the top
+-- frame before each step. fn may return false to stop early.
+-- Returns the number of steps taken.
+function M.steps(n, fn, mode)
+    local step = mode == "into" and stepInto or stepOver
+    local taken = 0
+    while taken < n do
+        local stack = getStack()
+        if not stack or #stack == 0 then
+            break
+        end
+        if fn and fn(stack[1], taken + 1) == false then
+            break
+        end
+        local result = step()
+        taken = taken + 1
+        if not result or not result.stepping then
+            break
+        end
+    end
+    return taken
+end
+
+-- vars returns the named variables as a {NAME = value} table.
+function M.vars(names)
+    local result = {}
+    local list = getVariables(names)
+    for _, v in ipairs(list or {}) do
+        result[v.name] = v.value
+    end
+    return result
+end
+
+-- untilValue steps until variable name has value (compared as strings).
+-- Returns the step count, or nil if the limit was reached.
+function M.untilValue(name, value, limit)
+    local found
+    M.steps(limit or 100, function(_, n)
+        if M.vars({name})[name] == tostring(value) then
+            found = n
+            return false
+        end
+    end)
+    return found
+end
+
+return M
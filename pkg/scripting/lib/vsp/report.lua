@@ -0,0 +1,121 @@
+-- vsp.report: plain-text output helpers.
+--
+--   local report = require("vsp.report")
+--   report.header("Short Dump Analysis")
+--   report.table(listDumps(10), {"id", "program", "exception"})
+
+local M = {}
+
+local function str(v)
+    if v == nil then
+        return ""
+    end
+    if type(v) == "table" then
+        return json.encode(v)
+    end
+    return tostring(v)
+end
+
+-- header prints a title underlined with "=".
+function M.header(title, width)
+    width = width or 50
+    print(title)
+    print(string.rep("=", width))
+end
+
+-- section prints a blank line and a sub-title underlined with "-".
+function M.section(title)
+    print("")
+    print(title)
+    print(string.rep("-", #title))
+end
+
+-- kv prints key/value pairs aligned on the keys, in sorted order
+-- (or in the order given by keys).
+function M.kv(tbl, keys)
+    if not keys then
+        keys = {}
+        for k in pairs(tbl) do
+            table.insert(keys, k)
+        end
+        table.sort(keys, function(a, b) return tostring(a) < tostring(b) end)
+    end
+    local w = 0
+    for _, k in ipairs(keys) do
+        w = math.max(w, #tostring(k))
+    end
+    for _, k in ipairs(keys) do
+        print(string.format("  %-" .. w .. "s  %s", tostring(k), str(tbl[k])))
+    end
+end
+
+-- table prints a list of rows as aligned columns. columns defaults to
+-- the sorted keys of the first row.
+function M.table(rows, columns)
+    if not rows or #rows == 0 then
+        print("  (none)")
+        return
+    end
+    if not columns then
+        columns = {}
+        for k in pairs(rows[1]) do
+            table.insert(columns, k)
+        end
+        table.sort(columns)
+    end
+
+    local widths = {}
+    for i, col in ipairs(columns) do
+        widths[i] = #col
+        for _, row in ipairs(rows) do
+            widths[i] = math.max(widths[i], #str(row[col]))
+        end
+    end
+
+    local function line(values)
+        local parts = {}
+        for i, v in ipairs(values) do
+            parts[i] = string.format("%-" .. widths[i] .. "s", v)
+        end
+        print("  " .. table.concat(parts, "  "))
+    end
+
+    line(columns)
+    local rule = {}
+    for i in ipairs(columns) do
+        rule[i] = string.rep("-", widths[i])
+    end
+    line(rule)
+    for _, row in ipairs(rows) do
+        local values = {}
+        for i, col in ipairs(columns) do
+            values[i] = str(row[col])
+        end
+        line(values)
+    end
+end
+
+-- stack prints call stack frames as returned by getStack() or getDump().stack.
+function M.stack(frames)
+    for i, frame in ipairs(frames or {}) do
+        local where = frame.program .. ":" .. str(frame.line)
+        if frame.event and frame.event ~= "" then
+            where = where .. " (" .. frame.event .. ")"
+        end
+        print(string.format("  %2d. %s", i, where))
+    end
+end
+
+-- tree prints a call graph node ({name, type, children}) as an indented tree.
+function M.tree(node, depth)
+    depth = depth or 0
+    if not node then
+        return
+    end
+    print(string.rep("  ", depth + 1) .. "- " .. str(node.name) .. " (" .. (node.type or "unknown") .. ")")
+    for _, child in ipairs(node.children or {}) do
+        M.tree(child, depth + 1)
+    end
+end
+
+return M
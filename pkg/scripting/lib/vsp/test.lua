@@ -0,0 +1,53 @@
+-- vsp.test: a minimal test runner for E2E scripts.
+--
+--   local t = require("vsp.test")
+--   t.run("search works", function() ... end)
+--   t.run("needs system", function() t.skip("no SAP system") end)
+--   if t.summary() > 0 then error("tests failed") end
+
+local M = {passed = 0, failed = 0, skipped = 0, failures = {}}
+
+local SKIP = "SKIP: "
+
+-- skip aborts the current test and marks it skipped.
+function M.skip(reason)
+    error(SKIP .. reason, 2)
+end
+
+-- run executes fn and records PASS, FAIL or SKIP. Returns true on PASS.
+function M.run(name, fn)
+    local ok, err = pcall(fn)
+    if ok then
+        print("PASS  " .. name)
+        M.passed = M.passed + 1
+        return true
+    end
+
+    local msg = tostring(err)
+    local at = string.find(msg, SKIP, 1, true)
+    if at then
+        print("SKIP  " .. name .. ": " .. string.sub(msg, at + #SKIP))
+        M.skipped = M.skipped + 1
+    else
+        print("FAIL  " .. name .. ": " .. msg)
+        M.failed = M.failed + 1
+        table.insert(M.failures, {name = name, error = msg})
+    end
+    return false
+end
+
+-- summary prints the totals and returns the number of failures.
+function M.summary()
+    print(string.format("\n%d passed, %d failed, %d skipped", M.passed, M.failed, M.skipped))
+    for _, f in ipairs(M.failures) do
+        print("  - " .. f.name .. ": " .. f.error)
+    end
+    return M.failed
+end
+
+-- reset clears the counters (for running several suites in one VM).
+function M.reset()
+    M.passed, M.failed, M.skipped, M.failures = 0, 0, 0, {}
+end
+
+return M
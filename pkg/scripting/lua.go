@@ -29,6 +29,22 @@ type LuaEngine struct {
 	recorder       *adt.ExecutionRecorder
 	historyManager *adt.HistoryManager
 	isRecording    bool
+
+	// Logpoints, passed through by attach() and continue_()
+	logpoints *adt.LogpointManager
+
+	// ZADT_VSP WebSocket clients (connected on first use)
+	debugWS  *adt.DebugWebSocketClient
+	reportWS *adt.AMDPWebSocketClient
+
+	// Names of registered ADT bindings
+	bindings []string
+
+	// Lua's built-in package.path, kept when SetSearchPath prepends directories
+	defaultLuaPath string
+
+	// Sandbox limits (nil = unrestricted)
+	sandbox *SandboxConfig
 }
 
 // NewLuaEngine creates a new Lua engine with ADT client bindings.
@@ -40,16 +56,24 @@ func NewLuaEngine(client *adt.Client) *LuaEngine {
 		IncludeGoStackTrace: true,
 	})
 
+	return newLuaEngine(L, client)
+}
+
+// newLuaEngine wraps an initialized Lua state and registers all bindings.
+func newLuaEngine(L *lua.LState, client *adt.Client) *LuaEngine {
 	engine := &LuaEngine{
 		L:           L,
 		client:      client,
 		ctx:         context.Background(),
 		output:      os.Stdout,
 		checkpoints: make(map[string]map[string]interface{}),
+		logpoints:   adt.NewLogpointManager(),
 	}
 
 	engine.registerBuiltins()
 	engine.registerADTBindings()
+	engine.registerDevToolsBindings()
+	engine.registerModules()
 
 	return engine
 }
@@ -64,19 +88,27 @@ func (e *LuaEngine) SetOutput(w io.Writer) {
 	e.output = w
 }
 
-// Close closes the Lua state.
+// Close closes the Lua state and any open WebSocket connections.
 func (e *LuaEngine) Close() {
+	if e.debugWS != nil {
+		e.debugWS.Close()
+	}
+	if e.reportWS != nil {
+		e.reportWS.Close()
+	}
 	e.L.Close()
 }
 
 // Execute runs a Lua script string.
 func (e *LuaEngine) Execute(script string) error {
-	return e.L.DoString(script)
+	return e.run(func() error { return e.L.DoString(script) })
 }
 
-// ExecuteFile runs a Lua script file.
-func (e *LuaEngine) ExecuteFile(path string) error {
-	return e.L.DoFile(path)
+// ExecuteFile runs a Lua script file. args are available to the script
+// as the arg table and as varargs (...).
+func (e *LuaEngine) ExecuteFile(path string, args ...string) error {
+	e.SetArgs(path, args)
+	return e.run(func() error { return e.executeFile(path, args) })
 }
 
 // REPL runs an interactive Lua Read-Eval-Print Loop.
@@ -142,6 +174,8 @@ Debugging - Breakpoints:
   setEnhancementBP(spot, [impl])  Set enhancement point breakpoint
   setWatchpoint(var, [condition]) Set watchpoint (condition: change/read/any)
   setMethodBP(class, method)      Set method entry breakpoint
+  setLogpoint(prog, line, msg)    Log "{var}" values and continue (no stop)
+  getLogpointLog([clear])         Messages logged by logpoints
   getBreakpoints()                List active breakpoints
   deleteBreakpoint(id)            Delete a breakpoint
 
@@ -184,6 +218,32 @@ Force Replay (Phase 5.5) - THE KILLER FEATURE:
   forceReplay(recordingId, [step]) Inject state from saved recording
   replayFromStep(stepNumber)      Inject state from current recording
 
+Transports, Quality & Data:
+  getTransport(number)            Transport details (tasks, objects)
+  createTransport(desc, pkg, [opts]) Create transport request
+  runATC(url, [variant], [max])   Run ATC checks
+  getCDSDependencies(ddls, [opts]) CDS dependency tree
+  getTableContents(tab, [max], [filter]) Read table contents
+  runQuery(sql, [max])            Freestyle SQL query
+
+CRUD & UI5:
+  createObject({type=, name=, ...}) Create ABAP object
+  activate(url, name)             Activate object
+  listUI5Apps([query], [max])     List UI5/BSP apps
+
+WebSocket (ZADT_VSP):
+  callRFC(fm, [params])           Call function module
+  runReport(report, [var], [params]) Schedule report as background job
+
+  Full reference: vsp lua --api-doc
+
+Modules:
+  require("vsp.debug")            Session recipes (catch, session, steps, vars)
+  require("vsp.report")           Output helpers (header, table, kv, stack, tree)
+  require("vsp.assert")           Assertions (ok, equal, same, contains, raises)
+  require("vsp.test")             Test runner (run, skip, summary)
+  Own modules: .vsp/lua/<name>.lua, ~/.vsp/lua/<name>.lua
+
 Utilities:
   print(...)                      Print values
   sleep(seconds)                  Sleep for N seconds
@@ -3,10 +3,15 @@ package scripting
 import (
 	"bytes"
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/oisee/vibing-steampunk/pkg/adt"
 	lua "github.com/yuin/gopher-lua"
 )
 
@@ -536,6 +541,32 @@ func TestGetRecordingWithoutClient(t *testing.T) {
 	}
 }
 
+func TestGetLogpointLogWithoutClient(t *testing.T) {
+	engine := NewLuaEngine(nil)
+	defer engine.Close()
+
+	var buf bytes.Buffer
+	engine.SetOutput(&buf)
+
+	// A message without variables needs no debugger round trip
+	engine.logpoints.Add(adt.Logpoint{ID: "BP1", Program: "ZORDERS", Line: 10, Message: "reached"})
+	loc := adt.CodeLocation{Program: "ZORDERS", Line: 10}
+	engine.logpoints.Evaluate(context.Background(), nil, engine.logpoints.Match(loc, nil), loc)
+
+	err := engine.Execute(`
+		local log = getLogpointLog(true)
+		print(#log, log[1].id, log[1].program, log[1].line, log[1].message)
+		print("after clear", #getLogpointLog())
+	`)
+	if err != nil {
+		t.Fatalf("getLogpointLog failed: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "1\tBP1\tZORDERS\t10\treached") || !strings.Contains(output, "after clear\t0") {
+		t.Errorf("unexpected log output: %q", output)
+	}
+}
+
 func TestGlobalFunctionsRegistered(t *testing.T) {
 	engine := NewLuaEngine(nil)
 	defer engine.Close()
@@ -564,3 +595,344 @@ func TestGlobalFunctionsRegistered(t *testing.T) {
 		t.Errorf("json module is not registered: %v", err)
 	}
 }
+
+func TestSandboxBlocksUnsafeLibraries(t *testing.T) {
+	engine, err := NewSandboxedLuaEngine(nil, DefaultSandboxConfig())
+	if err != nil {
+		t.Fatalf("NewSandboxedLuaEngine failed: %v", err)
+	}
+	defer engine.Close()
+
+	for _, name := range []string{"io", "os", "package", "debug", "dofile", "loadfile"} {
+		if err := engine.Execute("assert(" + name + " == nil, '" + name + " is available')"); err != nil {
+			t.Errorf("expected %s to be unavailable: %v", name, err)
+		}
+	}
+	if err := engine.Execute("assert(string.upper('x') == 'X' and math.max(1, 2) == 2)"); err != nil {
+		t.Errorf("expected string and math libraries: %v", err)
+	}
+}
+
+func TestSandboxRejectsUnknownLibrary(t *testing.T) {
+	cfg := DefaultSandboxConfig()
+	cfg.Libraries = append(cfg.Libraries, "io")
+	if _, err := NewSandboxedLuaEngine(nil, cfg); err == nil {
+		t.Error("expected error for io library")
+	}
+}
+
+func TestSandboxSafeOs(t *testing.T) {
+	cfg := DefaultSandboxConfig()
+	cfg.Libraries = append(cfg.Libraries, "os")
+	engine, err := NewSandboxedLuaEngine(nil, cfg)
+	if err != nil {
+		t.Fatalf("NewSandboxedLuaEngine failed: %v", err)
+	}
+	defer engine.Close()
+
+	if err := engine.Execute("assert(type(os.time()) == 'number'); assert(os.execute == nil); assert(os.date('%Y', 0) ~= nil)"); err != nil {
+		t.Errorf("safe os check failed: %v", err)
+	}
+}
+
+func TestSandboxInstructionLimit(t *testing.T) {
+	cfg := DefaultSandboxConfig()
+	cfg.MaxInstructions = 10000
+	cfg.Timeout = 0
+	engine, err := NewSandboxedLuaEngine(nil, cfg)
+	if err != nil {
+		t.Fatalf("NewSandboxedLuaEngine failed: %v", err)
+	}
+	defer engine.Close()
+
+	err = engine.Execute("while true do end")
+	if !errors.Is(err, ErrInstructionLimit) {
+		t.Fatalf("expected ErrInstructionLimit, got %v", err)
+	}
+
+	// Budget is per call: a short script still runs afterwards
+	if err := engine.Execute("local x = 0; for i = 1, 10 do x = x + i end"); err != nil {
+		t.Errorf("expected short script to succeed: %v", err)
+	}
+}
+
+func TestSandboxTimeout(t *testing.T) {
+	cfg := DefaultSandboxConfig()
+	cfg.MaxInstructions = 0
+	cfg.Timeout = 50 * time.Millisecond
+	engine, err := NewSandboxedLuaEngine(nil, cfg)
+	if err != nil {
+		t.Fatalf("NewSandboxedLuaEngine failed: %v", err)
+	}
+	defer engine.Close()
+
+	start := time.Now()
+	err = engine.Execute("while true do end")
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("timeout took too long: %v", time.Since(start))
+	}
+}
+
+func TestSandboxMemoryLimit(t *testing.T) {
+	cfg := DefaultSandboxConfig()
+	cfg.MaxInstructions = 0
+	cfg.Timeout = 0
+	cfg.MaxMemory = 32 << 20
+	engine, err := NewSandboxedLuaEngine(nil, cfg)
+	if err != nil {
+		t.Fatalf("NewSandboxedLuaEngine failed: %v", err)
+	}
+	defer engine.Close()
+
+	err = engine.Execute("local s = string.rep('x', 1e9)")
+	if err == nil || !strings.Contains(err.Error(), "memory limit") {
+		t.Errorf("expected string.rep to be refused, got %v", err)
+	}
+	err = engine.Execute("local s = ('xy'):rep(1e9)")
+	if err == nil || !strings.Contains(err.Error(), "memory limit") {
+		t.Errorf("expected the string method to be refused, got %v", err)
+	}
+
+	err = engine.Execute("local t = {}; for i = 1, 1e9 do t[i] = string.rep('x', 64) .. i end")
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("expected ErrMemoryLimit, got %v", err)
+	}
+
+	// The limit is per call: small allocations still work afterwards
+	if err := engine.Execute("local t = {}; for i = 1, 1000 do t[i] = string.rep('x', 10) end; assert(#t == 1000)"); err != nil {
+		t.Errorf("expected short script to succeed: %v", err)
+	}
+}
+
+func TestSandboxMemoryCheckRateLimitsGC(t *testing.T) {
+	c := newBudgetContext(context.Background(), 0, 1)
+	defer c.stop()
+	garbage := make([][]byte, 0, 8)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for i := 0; i < 8; i++ {
+		garbage = append(garbage, make([]byte, 1<<20))
+		c.memoryExceeded()
+	}
+	runtime.ReadMemStats(&after)
+	if forced := after.NumForcedGC - before.NumForcedGC; forced > 1 {
+		t.Errorf("expected at most one forced GC per interval, got %d", forced)
+	}
+	runtime.KeepAlive(garbage)
+}
+
+func TestSandboxBindingPolicy(t *testing.T) {
+	cfg := DefaultSandboxConfig()
+	safety := adt.SafetyConfig{AllowedOps: "RS"}
+	cfg.Safety = &safety
+	engine, err := NewSandboxedLuaEngine(nil, cfg)
+	if err != nil {
+		t.Fatalf("NewSandboxedLuaEngine failed: %v", err)
+	}
+	defer engine.Close()
+
+	err = engine.Execute("writeSource('PROG', 'ZTEST', 'REPORT ztest.')")
+	if err == nil || !strings.Contains(err.Error(), "blocked by safety configuration") {
+		t.Errorf("expected writeSource to be blocked, got %v", err)
+	}
+	err = engine.Execute("setBreakpoint('ZTEST', 10)")
+	if err == nil || !strings.Contains(err.Error(), "blocked") {
+		t.Errorf("expected setBreakpoint to be blocked, got %v", err)
+	}
+	// Local, read-only bindings stay available
+	if err := engine.Execute("listCheckpoints()"); err != nil {
+		t.Errorf("expected listCheckpoints to be allowed: %v", err)
+	}
+}
+
+func TestBindingOpsCoverAllBindings(t *testing.T) {
+	engine := NewLuaEngine(nil)
+	defer engine.Close()
+
+	for _, name := range engine.bindings {
+		if _, ok := bindingOps[name]; !ok {
+			t.Errorf("binding %s has no operation type in bindingOps", name)
+		}
+	}
+}
+
+func TestAPIDocsCoverAllBindings(t *testing.T) {
+	engine := NewLuaEngine(nil)
+	defer engine.Close()
+
+	documented := make(map[string]bool, len(APIDocs))
+	for _, doc := range APIDocs {
+		if documented[doc.Name] {
+			t.Errorf("binding %s documented twice", doc.Name)
+		}
+		documented[doc.Name] = true
+		if !strings.HasPrefix(doc.Signature, doc.Name+"(") {
+			t.Errorf("signature %q does not start with %s(", doc.Signature, doc.Name)
+		}
+	}
+
+	registered := make(map[string]bool, len(engine.bindings))
+	for _, name := range engine.bindings {
+		registered[name] = true
+		if !documented[name] {
+			t.Errorf("binding %s missing from APIDocs", name)
+		}
+	}
+	for name := range documented {
+		if !registered[name] {
+			t.Errorf("APIDocs documents unknown binding %s", name)
+		}
+	}
+
+	ref := APIReference()
+	for _, want := range []string{"## Transports", "`runQuery(sql, [maxRows=100])`", "| F |", "`callRFC("} {
+		if !strings.Contains(ref, want) {
+			t.Errorf("APIReference missing %q", want)
+		}
+	}
+}
+
+func TestTableContentsToLua(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	result := &adt.TableContentsResult{
+		Columns: []adt.TableColumn{{Name: "MANDT", Type: "C", Length: 3, IsKey: true}},
+		Rows:    []map[string]interface{}{{"MANDT": "001"}, {"MANDT": "100"}},
+	}
+	L.SetGlobal("data", tableContentsToLua(L, result))
+
+	if err := L.DoString(`
+		assert(#data.columns == 1)
+		assert(data.columns[1].name == "MANDT")
+		assert(data.columns[1].key == true)
+		assert(data.columns[1].length == 3)
+		assert(#data.rows == 2)
+		assert(data.rows[2].MANDT == "100")
+	`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStringParams(t *testing.T) {
+	if got := stringParams(nil); got != nil {
+		t.Errorf("stringParams(nil) = %v, want nil", got)
+	}
+
+	got := stringParams(map[string]interface{}{"IV_NAME": "X", "IV_COUNT": int64(3), "IV_FLAG": true})
+	want := map[string]string{"IV_NAME": "X", "IV_COUNT": "3", "IV_FLAG": "true"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("stringParams[%s] = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestDevToolsBindingsRegistered(t *testing.T) {
+	engine := NewLuaEngine(nil)
+	defer engine.Close()
+
+	for _, name := range []string{"getTransport", "createTransport", "runATC", "getCDSDependencies",
+		"getTableContents", "runQuery", "createObject", "activate", "listUI5Apps", "callRFC", "runReport"} {
+		if engine.L.GetGlobal(name).Type() != lua.LTFunction {
+			t.Errorf("%s not registered", name)
+		}
+	}
+
+	// createObject validates its argument before touching the client
+	if err := engine.Execute(`ok, err = createObject("ZTEST")`); err != nil {
+		t.Fatal(err)
+	}
+	if engine.L.GetGlobal("err").String() != "createObject expects a table" {
+		t.Errorf("err = %s", engine.L.GetGlobal("err"))
+	}
+}
+
+func TestBundledModules(t *testing.T) {
+	want := []string{"vsp.assert", "vsp.debug", "vsp.report", "vsp.test"}
+	got := BundledModules()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("BundledModules() = %v, want %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	engine := NewLuaEngine(nil)
+	defer engine.Close()
+	engine.SetOutput(&buf)
+
+	err := engine.Execute(`
+		local assert = require("vsp.assert")
+		local report = require("vsp.report")
+		local t = require("vsp.test")
+		assert.type(require("vsp.debug").session, "function")
+		assert.equal(require("vsp.assert"), assert)
+
+		t.run("equal", function() assert.equal(1 + 1, 2) end)
+		t.run("contains", function() assert.contains({"a", "b"}, "b") end)
+		t.run("same", function() assert.same({x = {1, 2}}, {x = {1, 2}}) end)
+		t.run("raises", function() assert.raises(function() assert.equal(1, 2) end, "expected 2, got 1") end)
+		t.run("ok", function() assert.raises(function() assert.ok(nil, "boom") end, "boom") end)
+		t.run("skipped", function() t.skip("no system") end)
+		t.run("failing", function() assert.truthy(false, "nope") end)
+		failures = t.summary()
+
+		report.table({{id = "D1", program = "ZPROG"}}, {"id", "program"})
+	`)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if n := engine.L.GetGlobal("failures"); n.String() != "1" {
+		t.Errorf("failures = %s, want 1\n%s", n, buf.String())
+	}
+	out := buf.String()
+	for _, want := range []string{"PASS  equal", "SKIP  skipped: no system", "FAIL  failing:", "5 passed, 1 failed, 1 skipped", "  id  program", "  D1  ZPROG"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSearchPathAndArgs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "recipes.lua"), []byte(`return {greet = function(n) return "hi " .. n end}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "main.lua")
+	if err := os.WriteFile(script, []byte(`
+		local first = ...
+		result = require("recipes").greet(first) .. " " .. arg[2] .. " " .. arg[0]
+	`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewLuaEngine(nil)
+	defer engine.Close()
+	engine.SetSearchPath([]string{dir})
+
+	if err := engine.ExecuteFile(script, "bob", "x"); err != nil {
+		t.Fatalf("ExecuteFile failed: %v", err)
+	}
+	if got, want := engine.L.GetGlobal("result").String(), "hi bob x "+script; got != want {
+		t.Errorf("result = %q, want %q", got, want)
+	}
+}
+
+func TestSandboxRequireBundledOnly(t *testing.T) {
+	engine, err := NewSandboxedLuaEngine(nil, DefaultSandboxConfig())
+	if err != nil {
+		t.Fatalf("NewSandboxedLuaEngine failed: %v", err)
+	}
+	defer engine.Close()
+
+	if err := engine.Execute(`local a = require("vsp.assert"); a.equal(require("vsp.assert"), a)`); err != nil {
+		t.Errorf("bundled module not available in sandbox: %v", err)
+	}
+	err = engine.Execute(`require("os")`)
+	if err == nil || !strings.Contains(err.Error(), "only bundled vsp.* modules") {
+		t.Errorf("expected non-bundled require to fail, got %v", err)
+	}
+}
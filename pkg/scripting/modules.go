@@ -0,0 +1,151 @@
+package scripting
+
+import (
+	"embed"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// stdlib holds the bundled vsp.* Lua modules (lib/vsp/<name>.lua -> "vsp.<name>").
+//
+//go:embed lib
+var stdlib embed.FS
+
+// BundledModules returns the names of the bundled modules, e.g. "vsp.debug".
+func BundledModules() []string {
+	var names []string
+	entries, _ := stdlib.ReadDir("lib/vsp")
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".lua"); ok {
+			names = append(names, "vsp."+name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bundledSource returns the source of a bundled module.
+func bundledSource(module string) (string, bool) {
+	name, ok := strings.CutPrefix(module, "vsp.")
+	if !ok || strings.ContainsAny(name, "./\\") {
+		return "", false
+	}
+	data, err := stdlib.ReadFile("lib/vsp/" + name + ".lua")
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// DefaultSearchPath returns the directories searched by require():
+// the project's .vsp/lua, then ~/.vsp/lua.
+func DefaultSearchPath() []string {
+	dirs := []string{filepath.Join(".vsp", "lua")}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".vsp", "lua"))
+	}
+	return dirs
+}
+
+// registerModules makes the bundled modules loadable with require().
+// With the package library open, they are preloaded and the default search
+// path is configured; otherwise (sandbox) require() only resolves bundled modules.
+func (e *LuaEngine) registerModules() {
+	if pkg, ok := e.L.GetGlobal("package").(*lua.LTable); ok {
+		e.defaultLuaPath = lua.LVAsString(e.L.GetField(pkg, "path"))
+		for _, module := range BundledModules() {
+			e.L.PreloadModule(module, bundledLoader(module))
+		}
+		e.SetSearchPath(DefaultSearchPath())
+		return
+	}
+
+	loaded := e.L.NewTable()
+	e.L.SetGlobal("require", e.L.NewFunction(func(L *lua.LState) int {
+		module := L.CheckString(1)
+		if v := loaded.RawGetString(module); v != lua.LNil {
+			L.Push(v)
+			return 1
+		}
+		if _, ok := bundledSource(module); !ok {
+			L.RaiseError("module %q not found (only bundled vsp.* modules are available in sandbox mode)", module)
+			return 0
+		}
+		L.Push(L.NewFunction(bundledLoader(module)))
+		L.Push(lua.LString(module))
+		L.Call(1, 1)
+		v := L.Get(-1)
+		if v == lua.LNil {
+			v = lua.LTrue
+		}
+		loaded.RawSetString(module, v)
+		L.Pop(1)
+		L.Push(v)
+		return 1
+	}))
+}
+
+// bundledLoader returns a loader that compiles and runs a bundled module.
+func bundledLoader(module string) lua.LGFunction {
+	return func(L *lua.LState) int {
+		src, _ := bundledSource(module)
+		fn, err := L.Load(strings.NewReader(src), module)
+		if err != nil {
+			L.RaiseError("loading %s: %v", module, err)
+			return 0
+		}
+		L.Push(fn)
+		L.Call(0, 1)
+		return 1
+	}
+}
+
+// SetSearchPath sets the directories require() searches for <name>.lua and
+// <name>/init.lua, ahead of the Lua defaults. It has no effect in sandbox mode.
+func (e *LuaEngine) SetSearchPath(dirs []string) {
+	pkg, ok := e.L.GetGlobal("package").(*lua.LTable)
+	if !ok {
+		return
+	}
+	var patterns []string
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		patterns = append(patterns,
+			filepath.Join(dir, "?.lua"),
+			filepath.Join(dir, "?", "init.lua"))
+	}
+	if e.defaultLuaPath != "" {
+		patterns = append(patterns, e.defaultLuaPath)
+	}
+	e.L.SetField(pkg, "path", lua.LString(strings.Join(patterns, ";")))
+}
+
+// SetArgs sets the global arg table: arg[0] is the script name and
+// arg[1..n] the script arguments.
+func (e *LuaEngine) SetArgs(script string, args []string) {
+	tbl := e.L.NewTable()
+	tbl.RawSetInt(0, lua.LString(script))
+	for i, a := range args {
+		tbl.RawSetInt(i+1, lua.LString(a))
+	}
+	e.L.SetGlobal("arg", tbl)
+}
+
+// executeFile loads a script and runs it with args passed as varargs (...).
+func (e *LuaEngine) executeFile(path string, args []string) error {
+	fn, err := e.L.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	e.L.Push(fn)
+	for _, a := range args {
+		e.L.Push(lua.LString(a))
+	}
+	return e.L.PCall(len(args), lua.MultRet, nil)
+}
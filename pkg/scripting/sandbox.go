@@ -0,0 +1,521 @@
+package scripting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Errors returned when a sandboxed script exceeds its limits.
+var (
+	ErrInstructionLimit = errors.New("lua: instruction limit exceeded")
+	ErrTimeout          = errors.New("lua: execution timeout")
+	ErrMemoryLimit      = errors.New("lua: memory limit exceeded")
+)
+
+// SandboxConfig restricts what a Lua script may do.
+type SandboxConfig struct {
+	// Libraries lists the standard libraries opened in the VM.
+	// Allowed: base, table, string, math, coroutine, os.
+	// "os" only exposes time/clock/date/difftime; io, package, debug and
+	// channel are never available in a sandbox.
+	Libraries []string
+
+	// MaxInstructions caps the number of VM instructions per Execute call (0 = unlimited).
+	MaxInstructions int64
+
+	// Timeout caps wall-clock time per Execute call, including ADT calls (0 = unlimited).
+	Timeout time.Duration
+
+	// MaxMemory caps heap growth in bytes during an Execute call (0 = unlimited).
+	// It is a process-level guard: Go cannot attribute heap memory to a
+	// script, so the growth of the whole process heap is measured, and
+	// allocations of concurrent Execute calls or other goroutines count too.
+	// The heap is sampled every memoryCheckInterval instructions, so the cap
+	// is approximate; string.rep results above it are refused up front.
+	MaxMemory int64
+
+	// CallStackSize and RegistryMaxSize bound Lua stack memory.
+	CallStackSize   int
+	RegistryMaxSize int
+
+	// Safety decides which ADT bindings are callable, using the same
+	// operation codes as the MCP server. nil uses the client's safety config.
+	// Unlike on the server, AllowedOps also restricts debugger bindings (B).
+	Safety *adt.SafetyConfig
+}
+
+// DefaultSandboxConfig returns limits suitable for scripts submitted by agents.
+func DefaultSandboxConfig() SandboxConfig {
+	return SandboxConfig{
+		Libraries:       []string{"base", "table", "string", "math"},
+		MaxInstructions: 10_000_000,
+		MaxMemory:       256 << 20,
+		Timeout:         60 * time.Second,
+		CallStackSize:   120,
+		RegistryMaxSize: 1024 * 80,
+	}
+}
+
+// sandboxLibs maps library names to their openers.
+var sandboxLibs = map[string]lua.LGFunction{
+	"base":      lua.OpenBase,
+	"table":     lua.OpenTable,
+	"string":    lua.OpenString,
+	"math":      lua.OpenMath,
+	"coroutine": lua.OpenCoroutine,
+	"os":        openSafeOs,
+}
+
+// unsafeBaseFuncs are removed from the base library in sandbox mode.
+var unsafeBaseFuncs = []string{"dofile", "loadfile", "require", "module"}
+
+// bindingOps maps each ADT binding to the SafetyConfig operation type it performs.
+// Bindings missing from this map are blocked in sandbox mode.
+var bindingOps = map[string]adt.OperationType{
+	// Search & Source
+	"searchObject": adt.OpSearch,
+	"grepObjects":  adt.OpSearch,
+	"getSource":    adt.OpRead,
+	"writeSource":  adt.OpWorkflow,
+	"editSource":   adt.OpUpdate,
+
+	// Debugging
+	"setBreakpoint":    adt.OpDebug,
+	"setStatementBP":   adt.OpDebug,
+	"setExceptionBP":   adt.OpDebug,
+	"setMessageBP":     adt.OpDebug,
+	"setBadiBP":        adt.OpDebug,
+	"setEnhancementBP": adt.OpDebug,
+	"setWatchpoint":    adt.OpDebug,
+	"setMethodBP":      adt.OpDebug,
+	"setLogpoint":      adt.OpDebug,
+	"getLogpointLog":   adt.OpDebug,
+	"getBreakpoints":   adt.OpDebug,
+	"deleteBreakpoint": adt.OpDebug,
+	"listen":           adt.OpDebug,
+	"attach":           adt.OpDebug,
+	"detach":           adt.OpDebug,
+	"stepOver":         adt.OpDebug,
+	"stepInto":         adt.OpDebug,
+	"stepReturn":       adt.OpDebug,
+	"continue_":        adt.OpDebug,
+	"getStack":         adt.OpDebug,
+	"getVariables":     adt.OpDebug,
+
+	// Modifying a live session's variables changes program state
+	"setVariable":      adt.OpUpdate,
+	"injectCheckpoint": adt.OpUpdate,
+	"forceReplay":      adt.OpUpdate,
+	"replayFromStep":   adt.OpUpdate,
+
+	// Call Graph
+	"getCallGraph": adt.OpRead,
+	"getCallersOf": adt.OpRead,
+	"getCalleesOf": adt.OpRead,
+
+	// Checkpoints, recording and history (local state)
+	"saveCheckpoint":    adt.OpDebug,
+	"getCheckpoint":     adt.OpRead,
+	"listCheckpoints":   adt.OpRead,
+	"startRecording":    adt.OpDebug,
+	"stopRecording":     adt.OpDebug,
+	"getRecording":      adt.OpRead,
+	"saveRecording":     adt.OpDebug,
+	"getStateAtStep":    adt.OpRead,
+	"findWhenChanged":   adt.OpRead,
+	"findChanges":       adt.OpRead,
+	"listRecordings":    adt.OpRead,
+	"loadRecording":     adt.OpRead,
+	"compareRecordings": adt.OpRead,
+
+	// Diagnostics
+	"listDumps":    adt.OpRead,
+	"getDumps":     adt.OpRead,
+	"getDump":      adt.OpRead,
+	"getMessages":  adt.OpRead,
+	"runUnitTests": adt.OpTest,
+	"syntaxCheck":  adt.OpIntelligence,
+
+	// Transports
+	"getTransport":    adt.OpTransport,
+	"createTransport": adt.OpTransport,
+
+	// Quality, data and CRUD
+	"runATC":             adt.OpTest,
+	"getCDSDependencies": adt.OpRead,
+	"getTableContents":   adt.OpQuery,
+	"runQuery":           adt.OpFreeSQL,
+	"createObject":       adt.OpCreate,
+	"activate":           adt.OpActivate,
+	"listUI5Apps":        adt.OpRead,
+
+	// Server-side execution via ZADT_VSP
+	"callRFC":   adt.OpWorkflow,
+	"runReport": adt.OpWorkflow,
+}
+
+// NewSandboxedLuaEngine creates a Lua engine with restricted libraries,
+// execution limits and ADT bindings filtered by the safety configuration.
+func NewSandboxedLuaEngine(client *adt.Client, cfg SandboxConfig) (*LuaEngine, error) {
+	for _, lib := range cfg.Libraries {
+		if _, ok := sandboxLibs[lib]; !ok {
+			return nil, fmt.Errorf("library %q is not allowed in sandbox mode", lib)
+		}
+	}
+
+	opts := lua.Options{
+		CallStackSize:       cfg.CallStackSize,
+		RegistrySize:        1024 * 20,
+		RegistryMaxSize:     cfg.RegistryMaxSize,
+		SkipOpenLibs:        true,
+		IncludeGoStackTrace: false,
+	}
+	if opts.CallStackSize <= 0 {
+		opts.CallStackSize = 120
+	}
+	if opts.RegistryMaxSize > 0 && opts.RegistryMaxSize < opts.RegistrySize {
+		opts.RegistrySize = opts.RegistryMaxSize
+	}
+	L := lua.NewState(opts)
+
+	for _, lib := range cfg.Libraries {
+		L.Push(L.NewFunction(sandboxLibs[lib]))
+		L.Push(lua.LString(lib))
+		L.Call(1, 0)
+	}
+	for _, name := range unsafeBaseFuncs {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	if cfg.MaxMemory > 0 {
+		limitStringRep(L, cfg.MaxMemory)
+	}
+
+	engine := newLuaEngine(L, client)
+	engine.sandbox = &cfg
+	engine.applyBindingPolicy()
+
+	return engine, nil
+}
+
+// IsSandboxed reports whether the engine runs in sandbox mode.
+func (e *LuaEngine) IsSandboxed() bool {
+	return e.sandbox != nil
+}
+
+// applyBindingPolicy replaces ADT bindings not allowed by the sandbox
+// safety configuration with functions that raise an error.
+func (e *LuaEngine) applyBindingPolicy() {
+	safety := e.sandbox.Safety
+	if safety == nil && e.client != nil {
+		safety = e.client.Safety()
+	}
+
+	for _, name := range e.bindings {
+		op, known := bindingOps[name]
+		var reason string
+		switch {
+		case !known:
+			reason = fmt.Sprintf("%s is not available in sandbox mode", name)
+		case safety != nil && !safety.IsOperationAllowed(op),
+			op == adt.OpDebug && e.sandbox.Safety != nil && e.sandbox.Safety.AllowedOps != "" && !strings.ContainsRune(e.sandbox.Safety.AllowedOps, rune(op)):
+			reason = fmt.Sprintf("%s (type %c) is blocked by safety configuration", name, op)
+		default:
+			continue
+		}
+		e.L.SetGlobal(name, e.L.NewFunction(func(L *lua.LState) int {
+			L.RaiseError("%s", reason)
+			return 0
+		}))
+	}
+}
+
+// run executes fn under the sandbox limits (if any).
+func (e *LuaEngine) run(fn func() error) error {
+	if e.sandbox == nil {
+		return fn()
+	}
+
+	parent := e.ctx
+	ctx := parent
+	var cancel context.CancelFunc = func() {}
+	if e.sandbox.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, e.sandbox.Timeout)
+	}
+	defer cancel()
+
+	vmCtx := ctx
+	var budget *budgetContext
+	if e.sandbox.MaxInstructions > 0 || e.sandbox.MaxMemory > 0 {
+		budget = newBudgetContext(ctx, e.sandbox.MaxInstructions, e.sandbox.MaxMemory)
+		defer budget.stop()
+		vmCtx = budget
+	}
+
+	// ADT calls use the timeout context so a stuck request cannot outlive the script.
+	e.ctx = ctx
+	e.L.SetContext(vmCtx)
+	defer func() {
+		e.L.RemoveContext()
+		e.ctx = parent
+	}()
+
+	err := fn()
+	if err == nil {
+		return nil
+	}
+	switch {
+	case budget != nil && budget.exhausted():
+		return fmt.Errorf("%w (%d)", ErrInstructionLimit, e.sandbox.MaxInstructions)
+	case budget != nil && budget.outOfMemory():
+		return fmt.Errorf("%w (%d bytes)", ErrMemoryLimit, e.sandbox.MaxMemory)
+	case ctx.Err() == context.DeadlineExceeded && parent.Err() == nil:
+		return fmt.Errorf("%w (%s)", ErrTimeout, e.sandbox.Timeout)
+	}
+	return err
+}
+
+// memoryCheckInterval is the number of instructions between heap samples.
+const memoryCheckInterval = 4096
+
+// heapObjectsMetric is the heap memory occupied by objects, live or not yet
+// swept. Reading it does not stop the world.
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// heapLiveMetric is the heap memory marked live by the last collection.
+const heapLiveMetric = "/gc/heap/live:bytes"
+
+// forcedGCInterval is the minimum time between the collections that confirm
+// a sample over the memory limit. A forced collection stops the program, so
+// it runs at most once per interval for all scripts of the process.
+const forcedGCInterval = time.Second
+
+// lastForcedGC is the time of the last confirming collection (Unix nanoseconds).
+var lastForcedGC atomic.Int64
+
+// budgetContext is a context that is cancelled after a fixed number of
+// Done() calls, or when the heap has grown by more than a memory limit. The
+// gopher-lua VM checks Done() once per instruction when a context is set,
+// which makes this an instruction counter.
+type budgetContext struct {
+	context.Context
+	limited   bool  // instruction budget set
+	remaining int64 // instructions left
+	executed  int64
+	maxMemory uint64 // 0 = unlimited
+	baseline  uint64 // heap objects when the call started
+	done      chan struct{}
+	once      sync.Once
+	quit      chan struct{}
+	over      atomic.Bool
+	overMem   atomic.Bool
+}
+
+func newBudgetContext(parent context.Context, budget, maxMemory int64) *budgetContext {
+	c := &budgetContext{
+		Context:   parent,
+		limited:   budget > 0,
+		remaining: budget,
+		done:      make(chan struct{}),
+		quit:      make(chan struct{}),
+	}
+	if maxMemory > 0 {
+		c.maxMemory = uint64(maxMemory)
+		c.baseline = readMetric(heapObjectsMetric)
+	}
+	go func() {
+		select {
+		case <-parent.Done():
+			c.once.Do(func() { close(c.done) })
+		case <-c.quit:
+		}
+	}()
+	return c
+}
+
+// Done counts one instruction and returns a channel closed when the budget
+// or the memory limit is used up, or the parent is cancelled.
+func (c *budgetContext) Done() <-chan struct{} {
+	if c.limited && atomic.AddInt64(&c.remaining, -1) < 0 {
+		c.over.Store(true)
+		c.once.Do(func() { close(c.done) })
+	}
+	if c.maxMemory > 0 && atomic.AddInt64(&c.executed, 1)%memoryCheckInterval == 0 && c.memoryExceeded() {
+		c.overMem.Store(true)
+		c.once.Do(func() { close(c.done) })
+	}
+	return c.done
+}
+
+// memoryExceeded reports whether the heap grew by more than maxMemory since
+// the call started. Garbage counts until it is swept, so a sample over the
+// limit is confirmed with the live heap of a collection: a forced one if
+// none was forced within forcedGCInterval, otherwise the last one.
+func (c *budgetContext) memoryExceeded() bool {
+	limit := c.baseline + c.maxMemory
+	if readMetric(heapObjectsMetric) < limit {
+		return false
+	}
+	now := time.Now().UnixNano()
+	last := lastForcedGC.Load()
+	if now-last >= int64(forcedGCInterval) && lastForcedGC.CompareAndSwap(last, now) {
+		runtime.GC()
+	}
+	return readMetric(heapLiveMetric) >= limit
+}
+
+// Err reports why the context was cancelled.
+func (c *budgetContext) Err() error {
+	switch {
+	case c.over.Load():
+		return ErrInstructionLimit
+	case c.overMem.Load():
+		return ErrMemoryLimit
+	}
+	return c.Context.Err()
+}
+
+func (c *budgetContext) exhausted() bool {
+	return c.over.Load()
+}
+
+func (c *budgetContext) outOfMemory() bool {
+	return c.overMem.Load()
+}
+
+// readMetric returns a uint64 runtime metric, or 0 if it is not supported.
+func readMetric(name string) uint64 {
+	sample := []metrics.Sample{{Name: name}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// limitStringRep replaces string.rep with a version that refuses results
+// larger than maxBytes. string.rep allocates its result in one step, before
+// the next heap sample could stop the script.
+func limitStringRep(L *lua.LState, maxBytes int64) {
+	mod, ok := L.GetGlobal("string").(*lua.LTable)
+	if !ok {
+		return
+	}
+	rep, ok := mod.RawGetString("rep").(*lua.LFunction)
+	if !ok {
+		return
+	}
+	mod.RawSetString("rep", L.NewFunction(func(L *lua.LState) int {
+		str := L.CheckString(1)
+		n := L.CheckInt(2)
+		if n > 0 && len(str) > 0 && int64(n) > maxBytes/int64(len(str)) {
+			L.RaiseError("string.rep: result exceeds the sandbox memory limit (%d bytes)", maxBytes)
+			return 0
+		}
+		L.Push(rep)
+		L.Push(lua.LString(str))
+		L.Push(lua.LNumber(n))
+		L.Call(2, 1)
+		return 1
+	}))
+}
+
+func (c *budgetContext) stop() {
+	close(c.quit)
+}
+
+// openSafeOs opens an os module limited to time functions.
+func openSafeOs(L *lua.LState) int {
+	mod := L.NewTable()
+	for name, fn := range safeOsFuncs {
+		L.SetField(mod, name, L.NewFunction(fn))
+	}
+	L.SetGlobal("os", mod)
+	L.Push(mod)
+	return 1
+}
+
+// safeOsFuncs holds the time-related os functions allowed in the sandbox.
+var safeOsFuncs = map[string]lua.LGFunction{
+	"time":     osTime,
+	"clock":    osClock,
+	"date":     osDate,
+	"difftime": osDiffTime,
+}
+
+var processStart = time.Now()
+
+func osTime(L *lua.LState) int {
+	L.Push(lua.LNumber(time.Now().Unix()))
+	return 1
+}
+
+func osClock(L *lua.LState) int {
+	L.Push(lua.LNumber(time.Since(processStart).Seconds()))
+	return 1
+}
+
+func osDate(L *lua.LState) int {
+	layout := getOptString(L, 1, "%c")
+	t := time.Now()
+	if L.GetTop() >= 2 {
+		t = time.Unix(int64(L.CheckNumber(2)), 0)
+	}
+	if strings.HasPrefix(layout, "!") {
+		t = t.UTC()
+		layout = layout[1:]
+	}
+	if layout == "*t" {
+		tbl := L.NewTable()
+		L.SetField(tbl, "year", lua.LNumber(t.Year()))
+		L.SetField(tbl, "month", lua.LNumber(t.Month()))
+		L.SetField(tbl, "day", lua.LNumber(t.Day()))
+		L.SetField(tbl, "hour", lua.LNumber(t.Hour()))
+		L.SetField(tbl, "min", lua.LNumber(t.Minute()))
+		L.SetField(tbl, "sec", lua.LNumber(t.Second()))
+		L.SetField(tbl, "wday", lua.LNumber(t.Weekday()+1))
+		L.SetField(tbl, "yday", lua.LNumber(t.YearDay()))
+		L.Push(tbl)
+		return 1
+	}
+	L.Push(lua.LString(strftime(layout, t)))
+	return 1
+}
+
+// strftime formats t using the common C strftime directives.
+func strftime(layout string, t time.Time) string {
+	directives := map[byte]string{
+		'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'H': "15", 'M': "04", 'S': "05",
+		'p': "PM", 'A': "Monday", 'a': "Mon", 'B': "January", 'b': "Jan",
+		'c': "Mon Jan  2 15:04:05 2006", 'x': "01/02/06", 'X': "15:04:05",
+	}
+	var sb strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' || i+1 == len(layout) {
+			sb.WriteByte(layout[i])
+			continue
+		}
+		i++
+		if goLayout, ok := directives[layout[i]]; ok {
+			sb.WriteString(t.Format(goLayout))
+		} else {
+			sb.WriteByte(layout[i])
+		}
+	}
+	return sb.String()
+}
+
+func osDiffTime(L *lua.LState) int {
+	L.Push(lua.LNumber(L.CheckNumber(1) - L.CheckNumber(2)))
+	return 1
+}